@@ -0,0 +1,90 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AllowlistEntry is a vulnerability that was triaged and accepted. It is
+// suppressed from the results of the API and from the notifications, but kept
+// in the database.
+type AllowlistEntry struct {
+	// Name is the name of the vulnerability, e.g. CVE-2018-1000001.
+	Name string `yaml:"name"`
+
+	// Namespace and Feature optionally restrict the entry to the
+	// vulnerability in a namespace and to the features with the given name.
+	Namespace string `yaml:"namespace"`
+	Feature   string `yaml:"feature"`
+
+	// Reason documents why the vulnerability is accepted.
+	Reason string `yaml:"reason"`
+}
+
+// matches returns whether the entry suppresses the vulnerability of the given
+// namespace affecting the given feature. An empty feature is only matched by
+// the entries that are not restricted to a feature.
+func (e AllowlistEntry) matches(namespace, feature string) bool {
+	if e.Namespace != "" && e.Namespace != namespace {
+		return false
+	}
+	return e.Feature == "" || e.Feature == feature
+}
+
+// allowlist holds the entries of the allowlist by vulnerability name.
+var allowlist = struct {
+	sync.RWMutex
+	entries map[string][]AllowlistEntry
+}{}
+
+// SetAllowlist replaces the entries of the allowlist. It can be called while
+// Clair is running, e.g. when its configuration is reloaded.
+func SetAllowlist(entries []AllowlistEntry) {
+	byName := make(map[string][]AllowlistEntry, len(entries))
+	for _, entry := range entries {
+		byName[entry.Name] = append(byName[entry.Name], entry)
+	}
+
+	allowlist.Lock()
+	allowlist.entries = byName
+	allowlist.Unlock()
+
+	log.WithField("count", len(entries)).Info("allowlist loaded")
+}
+
+// IsAllowlisted returns whether the vulnerability of the given namespace
+// affecting the given feature is suppressed by the allowlist.
+func IsAllowlisted(vulnerability, namespace, feature string) bool {
+	allowlist.RLock()
+	defer allowlist.RUnlock()
+
+	for _, entry := range allowlist.entries[vulnerability] {
+		if entry.matches(namespace, feature) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAllowlist returns whether the allowlist has any entry.
+func hasAllowlist() bool {
+	allowlist.RLock()
+	defer allowlist.RUnlock()
+
+	return len(allowlist.entries) > 0
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/pagination"
+)
+
+func TestIsAllowlisted(t *testing.T) {
+	defer SetAllowlist(nil)
+	SetAllowlist([]AllowlistEntry{
+		{Name: "CVE-1"},
+		{Name: "CVE-2", Namespace: "debian:9"},
+		{Name: "CVE-3", Namespace: "debian:9", Feature: "openssl"},
+		{Name: "CVE-4", Feature: "bash"},
+	})
+
+	for _, test := range []struct {
+		vulnerability, namespace, feature string
+		expected                          bool
+	}{
+		{"CVE-1", "debian:9", "openssl", true},
+		{"CVE-1", "alpine:v3.8", "", true},
+		{"CVE-2", "debian:9", "openssl", true},
+		{"CVE-2", "debian:8", "openssl", false},
+		{"CVE-3", "debian:9", "openssl", true},
+		{"CVE-3", "debian:9", "libssl", false},
+		{"CVE-3", "debian:9", "", false},
+		{"CVE-4", "centos:7", "bash", true},
+		{"CVE-5", "debian:9", "openssl", false},
+	} {
+		assert.Equal(t, test.expected, IsAllowlisted(test.vulnerability, test.namespace, test.feature), "%+v", test)
+	}
+
+	SetAllowlist(nil)
+	assert.False(t, IsAllowlisted("CVE-1", "debian:9", "openssl"))
+}
+
+func TestAllowlistedNotification(t *testing.T) {
+	vuln := func(name string) *database.PagedVulnerableAncestries {
+		return &database.PagedVulnerableAncestries{Vulnerability: database.Vulnerability{Name: name, Namespace: database.Namespace{Name: "debian:9"}}}
+	}
+	notifications := map[string]database.VulnerabilityNotificationWithVulnerable{
+		"new-allowed":       {New: vuln("CVE-1")},
+		"both-allowed":      {Old: vuln("CVE-1"), New: vuln("CVE-2")},
+		"old-not-allowed":   {Old: vuln("CVE-3"), New: vuln("CVE-1")},
+		"feature-scoped":    {New: vuln("CVE-4")},
+		"without-any-vulns": {},
+	}
+
+	datastore := &database.MockDatastore{}
+	datastore.FctBegin = func() (database.Session, error) {
+		session := &database.MockSession{}
+		session.FctRollback = func() error { return nil }
+		session.FctFindVulnerabilityNotification = func(name string, limit int, oldPage pagination.Token, newPage pagination.Token) (database.VulnerabilityNotificationWithVulnerable, bool, error) {
+			n, ok := notifications[name]
+			return n, ok, nil
+		}
+		return session, nil
+	}
+
+	// Without allowlist, every notification is sent.
+	allowed, err := allowlistedNotification(datastore, "new-allowed")
+	if assert.Nil(t, err) {
+		assert.False(t, allowed)
+	}
+
+	defer SetAllowlist(nil)
+	SetAllowlist([]AllowlistEntry{{Name: "CVE-1"}, {Name: "CVE-2", Namespace: "debian:9"}, {Name: "CVE-4", Feature: "openssl"}})
+
+	for name, expected := range map[string]bool{
+		"new-allowed":       true,
+		"both-allowed":      true,
+		"old-not-allowed":   false,
+		"feature-scoped":    false,
+		"without-any-vulns": false,
+		"missing":           false,
+	} {
+		allowed, err := allowlistedNotification(datastore, name)
+		if assert.Nil(t, err) {
+			assert.Equal(t, expected, allowed, name)
+		}
+	}
+}
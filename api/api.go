@@ -16,6 +16,8 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -26,6 +28,24 @@ import (
 	"github.com/coreos/clair/pkg/stopper"
 )
 
+// The policies for the certificates of the clients.
+const (
+	ClientAuthRequire  = "require"
+	ClientAuthOptional = "optional"
+)
+
+// DefaultMinTLSVersion is the minimum version of TLS negotiated with the
+// clients when none is configured.
+const DefaultMinTLSVersion = "1.2"
+
+// tlsVersions are the versions of TLS that may be the minimum one, by name.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
 const timeoutResponse = `{"Error":{"Message":"Clair failed to respond within the configured timeout window.","Type":"Timeout"}}`
 
 // Config is the configuration for the API service.
@@ -34,16 +54,175 @@ type Config struct {
 	HealthAddr                string
 	Timeout                   time.Duration
 	CertFile, KeyFile, CAFile string
+
+	// ClientAuth is whether the clients must present a certificate signed by
+	// CAFile, "require", or may connect without one, "optional". The
+	// certificates are required when it is empty.
+	ClientAuth string
+
+	// MinTLSVersion is the minimum version of TLS negotiated with the REST and
+	// gRPC clients, among "1.0", "1.1", "1.2" and "1.3", which defaults to
+	// DefaultMinTLSVersion.
+	MinTLSVersion string
+
+	// CipherSuites are the names of the cipher suites allowed up to TLS 1.2,
+	// e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". The secure cipher suites
+	// of Go are allowed when it is empty, and the ones of TLS 1.3 are not
+	// configurable.
+	CipherSuites []string
+
+	// UpdaterFreshness is the maximum time since the last successful update
+	// of the vulnerabilities for the readiness check to pass. The freshness of
+	// the vulnerabilities is not checked when it is not set.
+	UpdaterFreshness time.Duration
+
+	// UpdaterToken is the bearer token authorizing the updates of the
+	// vulnerabilities triggered on demand, the resolution of the pending
+	// notifications and the deletion of the results of the scans. They are
+	// disabled when it is not set.
+	UpdaterToken string
+
+	// RateLimit limits the number of requests per minute of each client,
+	// identified by its certificate or else its address.
+	RateLimit RateLimitConfig
+
+	// MaxConcurrentAnalyses is the maximum number of requests analyzing layers
+	// that are run at the same time, while the other ones wait. They are not
+	// limited when it is not set.
+	MaxConcurrentAnalyses int
+
+	// MaxQueuedAnalyses is the maximum number of requests analyzing layers
+	// that wait for their turn, beyond which they are rejected with a 503.
+	// They are not limited when it is not set.
+	MaxQueuedAnalyses int
+
+	// MaxRequestSize is the maximum size, in bytes, of the body of the HTTP
+	// requests, such as the layers uploaded in their body. It is not limited
+	// when it is not set.
+	MaxRequestSize int64
+
+	// PaginationTTL is the time to live of the pagination tokens, and
+	// PaginationClockSkew the difference tolerated between the clocks of the
+	// hosts signing and verifying them. The defaults of pagination apply when
+	// they are not set.
+	PaginationTTL       time.Duration
+	PaginationClockSkew time.Duration
+}
+
+// RateLimitConfig is the number of requests per minute of each client of the
+// API, which are not limited when it is not set.
+type RateLimitConfig struct {
+	// Analyses limits the requests analyzing layers.
+	Analyses int
+
+	// Reads limits the other requests, which are cheaper.
+	Reads int
+}
+
+// ParseClientAuth returns the TLS policy for the certificates of the clients
+// named by clientAuth, which defaults to requiring them.
+func ParseClientAuth(clientAuth string) (tls.ClientAuthType, error) {
+	switch clientAuth {
+	case "", ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	case ClientAuthOptional:
+		return tls.VerifyClientCertIfGiven, nil
+	}
+	return tls.NoClientCert, fmt.Errorf("unknown client authentication %q, expected %q or %q", clientAuth, ClientAuthRequire, ClientAuthOptional)
+}
+
+// ParseTLSVersion returns the version of TLS named by version, e.g. "1.2",
+// which defaults to DefaultMinTLSVersion.
+func ParseTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		version = DefaultMinTLSVersion
+	}
+
+	if v, ok := tlsVersions[version]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q, expected 1.0, 1.1, 1.2 or 1.3", version)
+}
+
+// ParseCipherSuites returns the IDs of the cipher suites named by names, as
+// crypto/tls names them. Nothing is returned when names is empty, so that the
+// defaults of Go apply.
+//
+// The insecure cipher suites are rejected, as well as the ones of TLS 1.3,
+// which are always enabled.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]*tls.CipherSuite)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite
+	}
+
+	insecure := make(map[string]bool)
+	for _, suite := range tls.InsecureCipherSuites() {
+		insecure[suite.Name] = true
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		suite, ok := known[name]
+		switch {
+		case insecure[name]:
+			return nil, fmt.Errorf("insecure cipher suite %q", name)
+		case !ok:
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		case len(suite.SupportedVersions) == 1 && suite.SupportedVersions[0] == tls.VersionTLS13:
+			return nil, fmt.Errorf("cipher suite %q of TLS 1.3 is not configurable", name)
+		}
+		ids = append(ids, suite.ID)
+	}
+
+	return ids, nil
 }
 
-func Run(cfg *Config, store database.Datastore) {
-	err := v3.ListenAndServe(cfg.Addr, cfg.CertFile, cfg.KeyFile, cfg.CAFile, store)
+// Run serves the main API until st is stopped, and then stops accepting
+// requests and waits for the in-flight ones.
+func Run(cfg *Config, store database.Datastore, st *stopper.Stopper) {
+	defer st.End()
+
+	clientAuth, err := ParseClientAuth(cfg.ClientAuth)
 	if err != nil {
 		log.WithError(err).Fatal("could not initialize gRPC server")
 	}
+
+	minVersion, err := ParseTLSVersion(cfg.MinTLSVersion)
+	if err != nil {
+		log.WithError(err).Fatal("could not initialize gRPC server")
+	}
+
+	cipherSuites, err := ParseCipherSuites(cfg.CipherSuites)
+	if err != nil {
+		log.WithError(err).Fatal("could not initialize gRPC server")
+	}
+	tlsConfig := &tls.Config{MinVersion: minVersion, CipherSuites: cipherSuites}
+
+	limits := v3.Limits{
+		AnalysesPerMinute:     cfg.RateLimit.Analyses,
+		ReadsPerMinute:        cfg.RateLimit.Reads,
+		MaxConcurrentAnalyses: cfg.MaxConcurrentAnalyses,
+		MaxQueuedAnalyses:     cfg.MaxQueuedAnalyses,
+		MaxRequestSize:        cfg.MaxRequestSize,
+	}
+
+	err = v3.ListenAndServe(cfg.Addr, cfg.KeyFile, cfg.CertFile, cfg.CAFile, tlsConfig, clientAuth, cfg.Timeout, cfg.UpdaterToken, limits, store, st.Chan())
+	if err != nil {
+		log.WithError(err).Fatal("could not initialize gRPC server")
+	}
+
+	log.Info("main API stopped")
 }
 
-func RunHealth(cfg *Config, store database.Datastore, st *stopper.Stopper) {
+// RunHealth serves the health API until st is stopped. The health check fails
+// once draining is closed, so that no more requests are routed to Clair while
+// it stops.
+func RunHealth(cfg *Config, store database.Datastore, draining <-chan struct{}, st *stopper.Stopper) {
 	defer st.End()
 
 	// Do not run the API service if there is no config.
@@ -55,7 +234,7 @@ func RunHealth(cfg *Config, store database.Datastore, st *stopper.Stopper) {
 
 	srv := http.Server{
 		Addr:    cfg.HealthAddr,
-		Handler: http.TimeoutHandler(newHealthHandler(store), cfg.Timeout, timeoutResponse),
+		Handler: http.TimeoutHandler(newHealthHandler(store, draining, cfg.UpdaterFreshness), cfg.Timeout, timeoutResponse),
 	}
 
 	go func() {
@@ -65,7 +244,7 @@ func RunHealth(cfg *Config, store database.Datastore, st *stopper.Stopper) {
 
 	err := srv.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
-		log.Fatal(err)
+		log.WithError(err).Fatal("could not serve the health API")
 	}
 
 	log.Info("health API stopped")
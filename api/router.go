@@ -15,28 +15,48 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
 
+	"github.com/coreos/clair"
 	"github.com/coreos/clair/database"
 )
 
+const (
+	healthyStatus   = "healthy"
+	degradedStatus  = "degraded"
+	unhealthyStatus = "unhealthy"
+)
+
 // router is an HTTP router that forwards requests to the appropriate sub-router
 // depending on the API version specified in the request URI.
 type router map[string]*httprouter.Router
 
-func newHealthHandler(store database.Datastore) http.Handler {
+func newHealthHandler(store database.Datastore, draining <-chan struct{}, freshness time.Duration) http.Handler {
 	router := httprouter.New()
-	router.GET("/health", healthHandler(store))
+	router.GET("/health", healthHandler(store, draining))
+	router.GET("/health/ready", readyHandler(store, draining, freshness))
+	router.Handler("GET", "/metrics", prometheus.Handler())
 	return router
 }
 
-func healthHandler(store database.Datastore) httprouter.Handle {
+func healthHandler(store database.Datastore, draining <-chan struct{}) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 		header := w.Header()
 		header.Set("Server", "clair")
 
+		select {
+		case <-draining:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		default:
+		}
+
 		status := http.StatusInternalServerError
 		if store.Ping() {
 			status = http.StatusOK
@@ -45,3 +65,67 @@ func healthHandler(store database.Datastore) httprouter.Handle {
 		w.WriteHeader(status)
 	}
 }
+
+// readiness is the body of the answers of the readiness check.
+type readiness struct {
+	// Status is healthy, degraded when the vulnerabilities are stale, or
+	// unhealthy when the database is unreachable or Clair is stopping.
+	Status string
+
+	Database bool
+	Draining bool
+
+	// UpdaterFresh is whether the last successful update finished within the
+	// freshness window. It is always true when the window is not configured.
+	UpdaterFresh       bool
+	UpdaterLastSuccess *time.Time `json:",omitempty"`
+	UpdaterLastError   string     `json:",omitempty"`
+}
+
+// readyHandler checks that the database is reachable and, when freshness is
+// set, that the last update succeeded within it. It answers 200 when both
+// hold and 503 otherwise, with the details of the checks.
+func readyHandler(store database.Datastore, draining <-chan struct{}, freshness time.Duration) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		header := w.Header()
+		header.Set("Server", "clair")
+		header.Set("Content-Type", "application/json")
+
+		ready := readiness{Status: unhealthyStatus, UpdaterFresh: true}
+		select {
+		case <-draining:
+			ready.Draining = true
+		default:
+			ready.Database = store.Ping()
+		}
+
+		if ready.Database && freshness > 0 {
+			updaterStatus, err := clair.GetUpdaterStatus(store)
+			if err != nil {
+				log.WithError(err).Warning("could not get the updater status for the readiness check")
+				ready.Database = false
+			} else {
+				ready.UpdaterFresh = !updaterStatus.LastSuccess.IsZero() && time.Since(updaterStatus.LastSuccess) <= freshness
+				ready.UpdaterLastError = updaterStatus.LastError
+				if !updaterStatus.LastSuccess.IsZero() {
+					ready.UpdaterLastSuccess = &updaterStatus.LastSuccess
+				}
+			}
+		}
+
+		status := http.StatusServiceUnavailable
+		switch {
+		case !ready.Database:
+		case !ready.UpdaterFresh:
+			ready.Status = degradedStatus
+		default:
+			ready.Status = healthyStatus
+			status = http.StatusOK
+		}
+
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(ready); err != nil {
+			log.WithError(err).Warning("could not write the readiness check")
+		}
+	}
+}
@@ -15,16 +15,55 @@ It has these top-level messages:
 	Layer
 	ClairStatus
 	GetAncestryRequest
+	GetAncestrySBOMRequest
+	GetAncestrySBOMResponse
 	GetAncestryResponse
+	StreamAncestryRequest
+	StreamAncestryResponse
+	CompareAncestriesRequest
+	CompareAncestriesResponse
+	GetAncestrySummaryRequest
+	GetAncestrySummaryResponse
 	PostAncestryRequest
 	PostAncestryResponse
+	PostLayersRequest
+	PostLayersResponse
+	PostImageRequest
+	PostImageResponse
+	GetLayerRequest
+	GetLayerResponse
+	DeleteAncestryRequest
+	DeleteAncestryResponse
+	DeleteLayerRequest
+	DeleteLayerResponse
+	PruneAnalysesRequest
+	PruneAnalysesResponse
 	GetNotificationRequest
 	GetNotificationResponse
 	PagedVulnerableAncestries
 	MarkNotificationAsReadRequest
 	MarkNotificationAsReadResponse
+	ListPendingNotificationsRequest
+	ListPendingNotificationsResponse
+	ResolveNotificationRequest
+	ResolveNotificationResponse
+	FlushNotificationsRequest
+	FlushNotificationsResponse
 	GetStatusRequest
 	GetStatusResponse
+	UpdaterStatus
+	GetUpdaterStatusRequest
+	GetUpdaterStatusResponse
+	UpdateJob
+	TriggerUpdateRequest
+	TriggerUpdateResponse
+	GetUpdateJobRequest
+	GetUpdateJobResponse
+	ListNamespacesRequest
+	ListNamespacesResponse
+	ExportVulnerabilitiesRequest
+	ListFeatureLocationsRequest
+	ListFeatureLocationsResponse
 */
 package clairpb
 
@@ -74,6 +113,90 @@ func (x Detector_DType) String() string {
 }
 func (Detector_DType) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{1, 0} }
 
+type ResolveNotificationRequest_Resolution int32
+
+const (
+	ResolveNotificationRequest_RESOLUTION_INVALID ResolveNotificationRequest_Resolution = 0
+	// The notification was delivered otherwise, and is handled as if it had
+	// been sent: it is sent again after the renotify interval unless the
+	// client marks it as read.
+	ResolveNotificationRequest_RESOLUTION_DELIVERED ResolveNotificationRequest_Resolution = 1
+	// The notification is discarded, as if the client had marked it as read.
+	ResolveNotificationRequest_RESOLUTION_CANCELLED ResolveNotificationRequest_Resolution = 2
+)
+
+var ResolveNotificationRequest_Resolution_name = map[int32]string{
+	0: "RESOLUTION_INVALID",
+	1: "RESOLUTION_DELIVERED",
+	2: "RESOLUTION_CANCELLED",
+}
+var ResolveNotificationRequest_Resolution_value = map[string]int32{
+	"RESOLUTION_INVALID":   0,
+	"RESOLUTION_DELIVERED": 1,
+	"RESOLUTION_CANCELLED": 2,
+}
+
+func (x ResolveNotificationRequest_Resolution) String() string {
+	return proto.EnumName(ResolveNotificationRequest_Resolution_name, int32(x))
+}
+func (ResolveNotificationRequest_Resolution) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{37, 0}
+}
+
+type UpdaterStatus_Result int32
+
+const (
+	UpdaterStatus_UPDATER_STATUS_RESULT_NEVER_RUN   UpdaterStatus_Result = 0
+	UpdaterStatus_UPDATER_STATUS_RESULT_SUCCEEDED   UpdaterStatus_Result = 1
+	UpdaterStatus_UPDATER_STATUS_RESULT_FAILED      UpdaterStatus_Result = 2
+	UpdaterStatus_UPDATER_STATUS_RESULT_IN_PROGRESS UpdaterStatus_Result = 3
+)
+
+var UpdaterStatus_Result_name = map[int32]string{
+	0: "UPDATER_STATUS_RESULT_NEVER_RUN",
+	1: "UPDATER_STATUS_RESULT_SUCCEEDED",
+	2: "UPDATER_STATUS_RESULT_FAILED",
+	3: "UPDATER_STATUS_RESULT_IN_PROGRESS",
+}
+var UpdaterStatus_Result_value = map[string]int32{
+	"UPDATER_STATUS_RESULT_NEVER_RUN":   0,
+	"UPDATER_STATUS_RESULT_SUCCEEDED":   1,
+	"UPDATER_STATUS_RESULT_FAILED":      2,
+	"UPDATER_STATUS_RESULT_IN_PROGRESS": 3,
+}
+
+func (x UpdaterStatus_Result) String() string {
+	return proto.EnumName(UpdaterStatus_Result_name, int32(x))
+}
+func (UpdaterStatus_Result) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{43, 0} }
+
+type UpdateJob_State int32
+
+const (
+	UpdateJob_UPDATE_JOB_STATE_PENDING   UpdateJob_State = 0
+	UpdateJob_UPDATE_JOB_STATE_RUNNING   UpdateJob_State = 1
+	UpdateJob_UPDATE_JOB_STATE_SUCCEEDED UpdateJob_State = 2
+	UpdateJob_UPDATE_JOB_STATE_FAILED    UpdateJob_State = 3
+)
+
+var UpdateJob_State_name = map[int32]string{
+	0: "UPDATE_JOB_STATE_PENDING",
+	1: "UPDATE_JOB_STATE_RUNNING",
+	2: "UPDATE_JOB_STATE_SUCCEEDED",
+	3: "UPDATE_JOB_STATE_FAILED",
+}
+var UpdateJob_State_value = map[string]int32{
+	"UPDATE_JOB_STATE_PENDING":   0,
+	"UPDATE_JOB_STATE_RUNNING":   1,
+	"UPDATE_JOB_STATE_SUCCEEDED": 2,
+	"UPDATE_JOB_STATE_FAILED":    3,
+}
+
+func (x UpdateJob_State) String() string {
+	return proto.EnumName(UpdateJob_State_name, int32(x))
+}
+func (UpdateJob_State) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{46, 0} }
+
 type Vulnerability struct {
 	// The name of the vulnerability.
 	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
@@ -91,8 +214,29 @@ type Vulnerability struct {
 	// This field only exists when a vulnerability is a part of a Feature.
 	FixedBy string `protobuf:"bytes,7,opt,name=fixed_by,json=fixedBy" json:"fixed_by,omitempty"`
 	// The Features that are affected by the vulnerability.
-	// This field only exists when a vulnerability is a part of a Notification.
+	// This field only exists when a vulnerability is a part of a Notification
+	// or of the comparison of two ancestries.
+	// They are sorted by name, then namespace, then version.
 	AffectedVersions []*Feature `protobuf:"bytes,8,rep,name=affected_versions,json=affectedVersions" json:"affected_versions,omitempty"`
+	// Whether the vulnerability is suppressed by the allowlist of Clair.
+	// Suppressed vulnerabilities are only returned when explicitly requested.
+	Suppressed bool `protobuf:"varint,9,opt,name=suppressed" json:"suppressed,omitempty"`
+	// How the data source triages the vulnerability for the feature, e.g.
+	// "no-dsa" when Debian does not plan a security advisory for it.
+	// This field only exists when a vulnerability is a part of a Feature.
+	Tag string `protobuf:"bytes,10,opt,name=tag" json:"tag,omitempty"`
+	// The withdrawal of the vulnerability by its data source, if it was
+	// withdrawn. Withdrawn vulnerabilities are only returned when explicitly
+	// requested, or as the old vulnerability of a notification.
+	Withdrawn *Vulnerability_Withdrawal `protobuf:"bytes,11,opt,name=withdrawn" json:"withdrawn,omitempty"`
+	// Whether a version of the feature fixes the vulnerability, which is then
+	// fixed_by, or no fix is available yet.
+	// This field only exists when a vulnerability is a part of a Feature.
+	FixAvailable bool `protobuf:"varint,12,opt,name=fix_available,json=fixAvailable" json:"fix_available,omitempty"`
+	// The ranges of versions of the feature affected by the vulnerability, when
+	// its data source gives more than fixed_by, e.g. the advisories of OSV.
+	// This field only exists when a vulnerability is a part of a Feature.
+	AffectedRanges []*Vulnerability_AffectedRange `protobuf:"bytes,13,rep,name=affected_ranges,json=affectedRanges" json:"affected_ranges,omitempty"`
 }
 
 func (m *Vulnerability) Reset()                    { *m = Vulnerability{} }
@@ -156,6 +300,103 @@ func (m *Vulnerability) GetAffectedVersions() []*Feature {
 	return nil
 }
 
+func (m *Vulnerability) GetSuppressed() bool {
+	if m != nil {
+		return m.Suppressed
+	}
+	return false
+}
+
+func (m *Vulnerability) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetWithdrawn() *Vulnerability_Withdrawal {
+	if m != nil {
+		return m.Withdrawn
+	}
+	return nil
+}
+
+func (m *Vulnerability) GetFixAvailable() bool {
+	if m != nil {
+		return m.FixAvailable
+	}
+	return false
+}
+
+func (m *Vulnerability) GetAffectedRanges() []*Vulnerability_AffectedRange {
+	if m != nil {
+		return m.AffectedRanges
+	}
+	return nil
+}
+
+type Vulnerability_Withdrawal struct {
+	// The time at which the vulnerability was found to be withdrawn.
+	Time *google_protobuf.Timestamp `protobuf:"bytes,1,opt,name=time" json:"time,omitempty"`
+	// Why the vulnerability was withdrawn.
+	Reason string `protobuf:"bytes,2,opt,name=reason" json:"reason,omitempty"`
+}
+
+func (m *Vulnerability_Withdrawal) Reset()                    { *m = Vulnerability_Withdrawal{} }
+func (m *Vulnerability_Withdrawal) String() string            { return proto.CompactTextString(m) }
+func (*Vulnerability_Withdrawal) ProtoMessage()               {}
+func (*Vulnerability_Withdrawal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 0} }
+
+func (m *Vulnerability_Withdrawal) GetTime() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *Vulnerability_Withdrawal) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type Vulnerability_AffectedRange struct {
+	// The first affected version, empty when the range has no lower bound.
+	Introduced string `protobuf:"bytes,1,opt,name=introduced" json:"introduced,omitempty"`
+	// The version fixing the vulnerability, empty when it is unknown.
+	Fixed string `protobuf:"bytes,2,opt,name=fixed" json:"fixed,omitempty"`
+	// The last affected version, when the data source gives it instead of
+	// the fixed version.
+	LastAffected string `protobuf:"bytes,3,opt,name=last_affected,json=lastAffected" json:"last_affected,omitempty"`
+}
+
+func (m *Vulnerability_AffectedRange) Reset()                    { *m = Vulnerability_AffectedRange{} }
+func (m *Vulnerability_AffectedRange) String() string            { return proto.CompactTextString(m) }
+func (*Vulnerability_AffectedRange) ProtoMessage()               {}
+func (*Vulnerability_AffectedRange) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 1} }
+
+func (m *Vulnerability_AffectedRange) GetIntroduced() string {
+	if m != nil {
+		return m.Introduced
+	}
+	return ""
+}
+
+func (m *Vulnerability_AffectedRange) GetFixed() string {
+	if m != nil {
+		return m.Fixed
+	}
+	return ""
+}
+
+func (m *Vulnerability_AffectedRange) GetLastAffected() string {
+	if m != nil {
+		return m.LastAffected
+	}
+	return ""
+}
+
 type Detector struct {
 	// The name of the detector.
 	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
@@ -230,8 +471,13 @@ type Feature struct {
 	// The detector used to detect this feature. This only exists when present in
 	// an Ancestry.
 	Detector *Detector `protobuf:"bytes,5,opt,name=detector" json:"detector,omitempty"`
-	// The list of vulnerabilities that affect the feature.
+	// The list of vulnerabilities that affect the feature, sorted by name then
+	// namespace.
 	Vulnerabilities []*Vulnerability `protobuf:"bytes,6,rep,name=vulnerabilities" json:"vulnerabilities,omitempty"`
+	// The Package URL of the feature, derived from its namespace and the lister
+	// that found it. This only exists when present in an Ancestry and when the
+	// namespace is known.
+	Purl string `protobuf:"bytes,7,opt,name=purl" json:"purl,omitempty"`
 }
 
 func (m *Feature) Reset()                    { *m = Feature{} }
@@ -281,6 +527,13 @@ func (m *Feature) GetVulnerabilities() []*Vulnerability {
 	return nil
 }
 
+func (m *Feature) GetPurl() string {
+	if m != nil {
+		return m.Purl
+	}
+	return ""
+}
+
 type Layer struct {
 	// The sha256 tarsum for the layer.
 	Hash string `protobuf:"bytes,1,opt,name=hash" json:"hash,omitempty"`
@@ -327,6 +580,13 @@ func (m *ClairStatus) GetLastUpdateTime() *google_protobuf.Timestamp {
 type GetAncestryRequest struct {
 	// The name of the desired ancestry.
 	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+	// Whether the vulnerabilities suppressed by the allowlist are returned,
+	// flagged as suppressed, to audit them.
+	WithSuppressed bool `protobuf:"varint,2,opt,name=with_suppressed,json=withSuppressed" json:"with_suppressed,omitempty"`
+	// The tags of the vulnerabilities that are left out, e.g. "no-dsa".
+	ExcludedTags []string `protobuf:"bytes,3,rep,name=excluded_tags,json=excludedTags" json:"excluded_tags,omitempty"`
+	// Whether only the vulnerabilities with an available fix are returned.
+	OnlyFixable bool `protobuf:"varint,4,opt,name=only_fixable,json=onlyFixable" json:"only_fixable,omitempty"`
 }
 
 func (m *GetAncestryRequest) Reset()                    { *m = GetAncestryRequest{} }
@@ -341,6 +601,109 @@ func (m *GetAncestryRequest) GetAncestryName() string {
 	return ""
 }
 
+func (m *GetAncestryRequest) GetWithSuppressed() bool {
+	if m != nil {
+		return m.WithSuppressed
+	}
+	return false
+}
+
+func (m *GetAncestryRequest) GetExcludedTags() []string {
+	if m != nil {
+		return m.ExcludedTags
+	}
+	return nil
+}
+
+func (m *GetAncestryRequest) GetOnlyFixable() bool {
+	if m != nil {
+		return m.OnlyFixable
+	}
+	return false
+}
+
+type GetAncestrySBOMRequest struct {
+	// The name of the desired ancestry.
+	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+	// The format of the bill of materials: "cyclonedx-json", "cyclonedx-xml" or
+	// "spdx-json". The REST clients may instead accept its media type, and it
+	// defaults to "cyclonedx-json".
+	Format string `protobuf:"bytes,2,opt,name=format" json:"format,omitempty"`
+	// Whether the vulnerabilities suppressed by the allowlist are listed.
+	WithSuppressed bool `protobuf:"varint,3,opt,name=with_suppressed,json=withSuppressed" json:"with_suppressed,omitempty"`
+	// The tags of the vulnerabilities that are left out, e.g. "no-dsa".
+	ExcludedTags []string `protobuf:"bytes,4,rep,name=excluded_tags,json=excludedTags" json:"excluded_tags,omitempty"`
+	// Whether only the vulnerabilities with an available fix are listed.
+	OnlyFixable bool `protobuf:"varint,5,opt,name=only_fixable,json=onlyFixable" json:"only_fixable,omitempty"`
+}
+
+func (m *GetAncestrySBOMRequest) Reset()                    { *m = GetAncestrySBOMRequest{} }
+func (m *GetAncestrySBOMRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetAncestrySBOMRequest) ProtoMessage()               {}
+func (*GetAncestrySBOMRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *GetAncestrySBOMRequest) GetAncestryName() string {
+	if m != nil {
+		return m.AncestryName
+	}
+	return ""
+}
+
+func (m *GetAncestrySBOMRequest) GetFormat() string {
+	if m != nil {
+		return m.Format
+	}
+	return ""
+}
+
+func (m *GetAncestrySBOMRequest) GetWithSuppressed() bool {
+	if m != nil {
+		return m.WithSuppressed
+	}
+	return false
+}
+
+func (m *GetAncestrySBOMRequest) GetExcludedTags() []string {
+	if m != nil {
+		return m.ExcludedTags
+	}
+	return nil
+}
+
+func (m *GetAncestrySBOMRequest) GetOnlyFixable() bool {
+	if m != nil {
+		return m.OnlyFixable
+	}
+	return false
+}
+
+type GetAncestrySBOMResponse struct {
+	// The media type of the bill of materials, e.g.
+	// "application/vnd.cyclonedx+json; version=1.5".
+	MediaType string `protobuf:"bytes,1,opt,name=media_type,json=mediaType" json:"media_type,omitempty"`
+	// The encoded bill of materials.
+	Sbom []byte `protobuf:"bytes,2,opt,name=sbom,proto3" json:"sbom,omitempty"`
+}
+
+func (m *GetAncestrySBOMResponse) Reset()                    { *m = GetAncestrySBOMResponse{} }
+func (m *GetAncestrySBOMResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetAncestrySBOMResponse) ProtoMessage()               {}
+func (*GetAncestrySBOMResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *GetAncestrySBOMResponse) GetMediaType() string {
+	if m != nil {
+		return m.MediaType
+	}
+	return ""
+}
+
+func (m *GetAncestrySBOMResponse) GetSbom() []byte {
+	if m != nil {
+		return m.Sbom
+	}
+	return nil
+}
+
 type GetAncestryResponse struct {
 	// The ancestry requested.
 	Ancestry *GetAncestryResponse_Ancestry `protobuf:"bytes,1,opt,name=ancestry" json:"ancestry,omitempty"`
@@ -351,7 +714,7 @@ type GetAncestryResponse struct {
 func (m *GetAncestryResponse) Reset()                    { *m = GetAncestryResponse{} }
 func (m *GetAncestryResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetAncestryResponse) ProtoMessage()               {}
-func (*GetAncestryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*GetAncestryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
 
 func (m *GetAncestryResponse) GetAncestry() *GetAncestryResponse_Ancestry {
 	if m != nil {
@@ -370,7 +733,8 @@ func (m *GetAncestryResponse) GetStatus() *ClairStatus {
 type GetAncestryResponse_AncestryLayer struct {
 	// The layer's information.
 	Layer *Layer `protobuf:"bytes,1,opt,name=layer" json:"layer,omitempty"`
-	// The features detected in this layer.
+	// The features detected in this layer, sorted by name, then namespace,
+	// then version.
 	DetectedFeatures []*Feature `protobuf:"bytes,2,rep,name=detected_features,json=detectedFeatures" json:"detected_features,omitempty"`
 }
 
@@ -378,7 +742,7 @@ func (m *GetAncestryResponse_AncestryLayer) Reset()         { *m = GetAncestryRe
 func (m *GetAncestryResponse_AncestryLayer) String() string { return proto.CompactTextString(m) }
 func (*GetAncestryResponse_AncestryLayer) ProtoMessage()    {}
 func (*GetAncestryResponse_AncestryLayer) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{7, 0}
+	return fileDescriptor0, []int{9, 0}
 }
 
 func (m *GetAncestryResponse_AncestryLayer) GetLayer() *Layer {
@@ -408,7 +772,7 @@ type GetAncestryResponse_Ancestry struct {
 func (m *GetAncestryResponse_Ancestry) Reset()                    { *m = GetAncestryResponse_Ancestry{} }
 func (m *GetAncestryResponse_Ancestry) String() string            { return proto.CompactTextString(m) }
 func (*GetAncestryResponse_Ancestry) ProtoMessage()               {}
-func (*GetAncestryResponse_Ancestry) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7, 1} }
+func (*GetAncestryResponse_Ancestry) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9, 1} }
 
 func (m *GetAncestryResponse_Ancestry) GetName() string {
 	if m != nil {
@@ -431,164 +795,1072 @@ func (m *GetAncestryResponse_Ancestry) GetLayers() []*GetAncestryResponse_Ancest
 	return nil
 }
 
-type PostAncestryRequest struct {
-	// The name of the ancestry being scanned.
-	// If scanning OCI images, this should be the hash of the manifest.
+type StreamAncestryRequest struct {
+	// The name of the desired ancestry.
 	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
-	// The format of the image being uploaded.
-	Format string `protobuf:"bytes,2,opt,name=format" json:"format,omitempty"`
-	// The layers to be scanned for this Ancestry, ordered in the way that i th
-	// layer is the parent of i + 1 th layer.
-	Layers []*PostAncestryRequest_PostLayer `protobuf:"bytes,3,rep,name=layers" json:"layers,omitempty"`
+	// Whether the vulnerabilities suppressed by the allowlist are returned,
+	// flagged as suppressed, to audit them.
+	WithSuppressed bool `protobuf:"varint,2,opt,name=with_suppressed,json=withSuppressed" json:"with_suppressed,omitempty"`
+	// The tags of the vulnerabilities that are left out, e.g. "no-dsa".
+	ExcludedTags []string `protobuf:"bytes,3,rep,name=excluded_tags,json=excludedTags" json:"excluded_tags,omitempty"`
+	// The requested maximum number of features per message.
+	Limit int32 `protobuf:"varint,4,opt,name=limit" json:"limit,omitempty"`
+	// Whether only the vulnerabilities with an available fix are returned.
+	OnlyFixable bool `protobuf:"varint,5,opt,name=only_fixable,json=onlyFixable" json:"only_fixable,omitempty"`
 }
 
-func (m *PostAncestryRequest) Reset()                    { *m = PostAncestryRequest{} }
-func (m *PostAncestryRequest) String() string            { return proto.CompactTextString(m) }
-func (*PostAncestryRequest) ProtoMessage()               {}
-func (*PostAncestryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (m *StreamAncestryRequest) Reset()                    { *m = StreamAncestryRequest{} }
+func (m *StreamAncestryRequest) String() string            { return proto.CompactTextString(m) }
+func (*StreamAncestryRequest) ProtoMessage()               {}
+func (*StreamAncestryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
-func (m *PostAncestryRequest) GetAncestryName() string {
+func (m *StreamAncestryRequest) GetAncestryName() string {
 	if m != nil {
 		return m.AncestryName
 	}
 	return ""
 }
 
-func (m *PostAncestryRequest) GetFormat() string {
+func (m *StreamAncestryRequest) GetWithSuppressed() bool {
 	if m != nil {
-		return m.Format
+		return m.WithSuppressed
 	}
-	return ""
+	return false
 }
 
-func (m *PostAncestryRequest) GetLayers() []*PostAncestryRequest_PostLayer {
+func (m *StreamAncestryRequest) GetExcludedTags() []string {
 	if m != nil {
-		return m.Layers
+		return m.ExcludedTags
 	}
 	return nil
 }
 
-type PostAncestryRequest_PostLayer struct {
-	// The hash of the layer.
-	Hash string `protobuf:"bytes,1,opt,name=hash" json:"hash,omitempty"`
-	// The location of the layer (URL or filepath).
-	Path string `protobuf:"bytes,2,opt,name=path" json:"path,omitempty"`
-	// Any HTTP Headers that need to be used if requesting a layer over HTTP(S).
-	Headers map[string]string `protobuf:"bytes,3,rep,name=headers" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-}
-
-func (m *PostAncestryRequest_PostLayer) Reset()         { *m = PostAncestryRequest_PostLayer{} }
-func (m *PostAncestryRequest_PostLayer) String() string { return proto.CompactTextString(m) }
-func (*PostAncestryRequest_PostLayer) ProtoMessage()    {}
-func (*PostAncestryRequest_PostLayer) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{8, 0}
+func (m *StreamAncestryRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
 }
 
-func (m *PostAncestryRequest_PostLayer) GetHash() string {
+func (m *StreamAncestryRequest) GetOnlyFixable() bool {
 	if m != nil {
-		return m.Hash
+		return m.OnlyFixable
 	}
-	return ""
+	return false
 }
 
-func (m *PostAncestryRequest_PostLayer) GetPath() string {
+type StreamAncestryResponse struct {
+	// The detectors used to scan the ancestry, only set in the first message.
+	Detectors []*Detector `protobuf:"bytes,1,rep,name=detectors" json:"detectors,omitempty"`
+	// The status of Clair at the time of the request, only set in the first
+	// message.
+	Status *ClairStatus `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+	// The layer in which the features were detected. The consecutive messages of
+	// a layer with many features have the same layer.
+	Layer *Layer `protobuf:"bytes,3,opt,name=layer" json:"layer,omitempty"`
+	// The features detected in this layer, sorted by name, then namespace, then
+	// version across the messages of the layer.
+	DetectedFeatures []*Feature `protobuf:"bytes,4,rep,name=detected_features,json=detectedFeatures" json:"detected_features,omitempty"`
+}
+
+func (m *StreamAncestryResponse) Reset()                    { *m = StreamAncestryResponse{} }
+func (m *StreamAncestryResponse) String() string            { return proto.CompactTextString(m) }
+func (*StreamAncestryResponse) ProtoMessage()               {}
+func (*StreamAncestryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *StreamAncestryResponse) GetDetectors() []*Detector {
 	if m != nil {
-		return m.Path
+		return m.Detectors
 	}
-	return ""
+	return nil
 }
 
-func (m *PostAncestryRequest_PostLayer) GetHeaders() map[string]string {
+func (m *StreamAncestryResponse) GetStatus() *ClairStatus {
 	if m != nil {
-		return m.Headers
+		return m.Status
 	}
 	return nil
 }
 
-type PostAncestryResponse struct {
-	// The status of Clair at the time of the request.
-	Status *ClairStatus `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+func (m *StreamAncestryResponse) GetLayer() *Layer {
+	if m != nil {
+		return m.Layer
+	}
+	return nil
 }
 
-func (m *PostAncestryResponse) Reset()                    { *m = PostAncestryResponse{} }
-func (m *PostAncestryResponse) String() string            { return proto.CompactTextString(m) }
-func (*PostAncestryResponse) ProtoMessage()               {}
-func (*PostAncestryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
-
-func (m *PostAncestryResponse) GetStatus() *ClairStatus {
+func (m *StreamAncestryResponse) GetDetectedFeatures() []*Feature {
 	if m != nil {
-		return m.Status
+		return m.DetectedFeatures
 	}
 	return nil
 }
 
-type GetNotificationRequest struct {
-	// The current page of previous vulnerabilities for the ancestry.
-	// This will be empty when it is the first page.
-	OldVulnerabilityPage string `protobuf:"bytes,1,opt,name=old_vulnerability_page,json=oldVulnerabilityPage" json:"old_vulnerability_page,omitempty"`
-	// The current page of vulnerabilities for the ancestry.
-	// This will be empty when it is the first page.
-	NewVulnerabilityPage string `protobuf:"bytes,2,opt,name=new_vulnerability_page,json=newVulnerabilityPage" json:"new_vulnerability_page,omitempty"`
-	// The requested maximum number of results per page.
-	Limit int32 `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
-	// The name of the notification being requested.
-	Name string `protobuf:"bytes,4,opt,name=name" json:"name,omitempty"`
+type CompareAncestriesRequest struct {
+	// The name of the compared ancestry, e.g. the new version of an image.
+	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+	// The name of the ancestry it is compared with, e.g. the previous version
+	// of the image.
+	BaseAncestryName string `protobuf:"bytes,2,opt,name=base_ancestry_name,json=baseAncestryName" json:"base_ancestry_name,omitempty"`
+	// Whether the vulnerabilities suppressed by the allowlist are compared,
+	// flagged as suppressed.
+	WithSuppressed bool `protobuf:"varint,3,opt,name=with_suppressed,json=withSuppressed" json:"with_suppressed,omitempty"`
+	// The tags of the vulnerabilities that are left out, e.g. "no-dsa".
+	ExcludedTags []string `protobuf:"bytes,4,rep,name=excluded_tags,json=excludedTags" json:"excluded_tags,omitempty"`
+	// Whether only the vulnerabilities with an available fix are compared.
+	OnlyFixable bool `protobuf:"varint,5,opt,name=only_fixable,json=onlyFixable" json:"only_fixable,omitempty"`
 }
 
-func (m *GetNotificationRequest) Reset()                    { *m = GetNotificationRequest{} }
-func (m *GetNotificationRequest) String() string            { return proto.CompactTextString(m) }
-func (*GetNotificationRequest) ProtoMessage()               {}
-func (*GetNotificationRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (m *CompareAncestriesRequest) Reset()                    { *m = CompareAncestriesRequest{} }
+func (m *CompareAncestriesRequest) String() string            { return proto.CompactTextString(m) }
+func (*CompareAncestriesRequest) ProtoMessage()               {}
+func (*CompareAncestriesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
 
-func (m *GetNotificationRequest) GetOldVulnerabilityPage() string {
+func (m *CompareAncestriesRequest) GetAncestryName() string {
 	if m != nil {
-		return m.OldVulnerabilityPage
+		return m.AncestryName
 	}
 	return ""
 }
 
-func (m *GetNotificationRequest) GetNewVulnerabilityPage() string {
+func (m *CompareAncestriesRequest) GetBaseAncestryName() string {
 	if m != nil {
-		return m.NewVulnerabilityPage
+		return m.BaseAncestryName
 	}
 	return ""
 }
 
-func (m *GetNotificationRequest) GetLimit() int32 {
+func (m *CompareAncestriesRequest) GetWithSuppressed() bool {
 	if m != nil {
-		return m.Limit
+		return m.WithSuppressed
 	}
-	return 0
+	return false
 }
 
-func (m *GetNotificationRequest) GetName() string {
+func (m *CompareAncestriesRequest) GetExcludedTags() []string {
 	if m != nil {
-		return m.Name
+		return m.ExcludedTags
 	}
-	return ""
+	return nil
 }
 
-type GetNotificationResponse struct {
-	// The notification as requested.
-	Notification *GetNotificationResponse_Notification `protobuf:"bytes,1,opt,name=notification" json:"notification,omitempty"`
+func (m *CompareAncestriesRequest) GetOnlyFixable() bool {
+	if m != nil {
+		return m.OnlyFixable
+	}
+	return false
 }
 
-func (m *GetNotificationResponse) Reset()                    { *m = GetNotificationResponse{} }
-func (m *GetNotificationResponse) String() string            { return proto.CompactTextString(m) }
-func (*GetNotificationResponse) ProtoMessage()               {}
-func (*GetNotificationResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+type CompareAncestriesResponse struct {
+	// The differences between the vulnerabilities of the ancestries, by
+	// severity from the highest, for the severities of any of their
+	// vulnerabilities. The vulnerabilities are sorted by name then namespace.
+	Severities []*CompareAncestriesResponse_SeverityDiff `protobuf:"bytes,1,rep,name=severities" json:"severities,omitempty"`
+	// The status of Clair at the time of the request.
+	Status *ClairStatus `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+}
 
-func (m *GetNotificationResponse) GetNotification() *GetNotificationResponse_Notification {
+func (m *CompareAncestriesResponse) Reset()                    { *m = CompareAncestriesResponse{} }
+func (m *CompareAncestriesResponse) String() string            { return proto.CompactTextString(m) }
+func (*CompareAncestriesResponse) ProtoMessage()               {}
+func (*CompareAncestriesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *CompareAncestriesResponse) GetSeverities() []*CompareAncestriesResponse_SeverityDiff {
 	if m != nil {
-		return m.Notification
+		return m.Severities
 	}
 	return nil
 }
 
-type GetNotificationResponse_Notification struct {
-	// The name of the requested notification.
-	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
-	// The time at which the notification was created.
+func (m *CompareAncestriesResponse) GetStatus() *ClairStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type CompareAncestriesResponse_SeverityDiff struct {
+	// The severity of the vulnerabilities.
+	Severity string `protobuf:"bytes,1,opt,name=severity" json:"severity,omitempty"`
+	// The vulnerabilities of the ancestry that the base ancestry does not
+	// have, with the features of the ancestry that they affect.
+	Added []*Vulnerability `protobuf:"bytes,2,rep,name=added" json:"added,omitempty"`
+	// The vulnerabilities of the base ancestry that the ancestry does not
+	// have, with the features of the base ancestry that they affect.
+	Removed []*Vulnerability `protobuf:"bytes,3,rep,name=removed" json:"removed,omitempty"`
+	// The vulnerabilities of both ancestries, with the features of the
+	// ancestry that they affect.
+	Unchanged []*Vulnerability `protobuf:"bytes,4,rep,name=unchanged" json:"unchanged,omitempty"`
+}
+
+func (m *CompareAncestriesResponse_SeverityDiff) Reset() {
+	*m = CompareAncestriesResponse_SeverityDiff{}
+}
+func (m *CompareAncestriesResponse_SeverityDiff) String() string { return proto.CompactTextString(m) }
+func (*CompareAncestriesResponse_SeverityDiff) ProtoMessage()    {}
+func (*CompareAncestriesResponse_SeverityDiff) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{13, 0}
+}
+
+func (m *CompareAncestriesResponse_SeverityDiff) GetSeverity() string {
+	if m != nil {
+		return m.Severity
+	}
+	return ""
+}
+
+func (m *CompareAncestriesResponse_SeverityDiff) GetAdded() []*Vulnerability {
+	if m != nil {
+		return m.Added
+	}
+	return nil
+}
+
+func (m *CompareAncestriesResponse_SeverityDiff) GetRemoved() []*Vulnerability {
+	if m != nil {
+		return m.Removed
+	}
+	return nil
+}
+
+func (m *CompareAncestriesResponse_SeverityDiff) GetUnchanged() []*Vulnerability {
+	if m != nil {
+		return m.Unchanged
+	}
+	return nil
+}
+
+type GetAncestrySummaryRequest struct {
+	// The name of the desired ancestry.
+	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+	// Whether the vulnerabilities suppressed by the allowlist are counted.
+	WithSuppressed bool `protobuf:"varint,2,opt,name=with_suppressed,json=withSuppressed" json:"with_suppressed,omitempty"`
+	// The tags of the vulnerabilities that are left out, e.g. "no-dsa".
+	ExcludedTags []string `protobuf:"bytes,3,rep,name=excluded_tags,json=excludedTags" json:"excluded_tags,omitempty"`
+	// Whether only the vulnerabilities with an available fix are counted.
+	OnlyFixable bool `protobuf:"varint,4,opt,name=only_fixable,json=onlyFixable" json:"only_fixable,omitempty"`
+}
+
+func (m *GetAncestrySummaryRequest) Reset()                    { *m = GetAncestrySummaryRequest{} }
+func (m *GetAncestrySummaryRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetAncestrySummaryRequest) ProtoMessage()               {}
+func (*GetAncestrySummaryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *GetAncestrySummaryRequest) GetAncestryName() string {
+	if m != nil {
+		return m.AncestryName
+	}
+	return ""
+}
+
+func (m *GetAncestrySummaryRequest) GetWithSuppressed() bool {
+	if m != nil {
+		return m.WithSuppressed
+	}
+	return false
+}
+
+func (m *GetAncestrySummaryRequest) GetExcludedTags() []string {
+	if m != nil {
+		return m.ExcludedTags
+	}
+	return nil
+}
+
+func (m *GetAncestrySummaryRequest) GetOnlyFixable() bool {
+	if m != nil {
+		return m.OnlyFixable
+	}
+	return false
+}
+
+type GetAncestrySummaryResponse struct {
+	// The number of vulnerabilities of the ancestry by severity, from the
+	// highest, for every severity. A vulnerability affecting several features
+	// of the ancestry is counted for each of them, as returned by GetAncestry.
+	Severities []*GetAncestrySummaryResponse_SeverityCount `protobuf:"bytes,1,rep,name=severities" json:"severities,omitempty"`
+	// The number of vulnerabilities of the ancestry.
+	VulnerabilityCount int32 `protobuf:"varint,2,opt,name=vulnerability_count,json=vulnerabilityCount" json:"vulnerability_count,omitempty"`
+	// The number of features of the ancestry, as returned by GetAncestry.
+	FeatureCount int32 `protobuf:"varint,3,opt,name=feature_count,json=featureCount" json:"feature_count,omitempty"`
+}
+
+func (m *GetAncestrySummaryResponse) Reset()                    { *m = GetAncestrySummaryResponse{} }
+func (m *GetAncestrySummaryResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetAncestrySummaryResponse) ProtoMessage()               {}
+func (*GetAncestrySummaryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *GetAncestrySummaryResponse) GetSeverities() []*GetAncestrySummaryResponse_SeverityCount {
+	if m != nil {
+		return m.Severities
+	}
+	return nil
+}
+
+func (m *GetAncestrySummaryResponse) GetVulnerabilityCount() int32 {
+	if m != nil {
+		return m.VulnerabilityCount
+	}
+	return 0
+}
+
+func (m *GetAncestrySummaryResponse) GetFeatureCount() int32 {
+	if m != nil {
+		return m.FeatureCount
+	}
+	return 0
+}
+
+type GetAncestrySummaryResponse_SeverityCount struct {
+	// The severity of the vulnerabilities.
+	Severity string `protobuf:"bytes,1,opt,name=severity" json:"severity,omitempty"`
+	// The number of vulnerabilities of the ancestry with this severity.
+	Count int32 `protobuf:"varint,2,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *GetAncestrySummaryResponse_SeverityCount) Reset() {
+	*m = GetAncestrySummaryResponse_SeverityCount{}
+}
+func (m *GetAncestrySummaryResponse_SeverityCount) String() string { return proto.CompactTextString(m) }
+func (*GetAncestrySummaryResponse_SeverityCount) ProtoMessage()    {}
+func (*GetAncestrySummaryResponse_SeverityCount) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{15, 0}
+}
+
+func (m *GetAncestrySummaryResponse_SeverityCount) GetSeverity() string {
+	if m != nil {
+		return m.Severity
+	}
+	return ""
+}
+
+func (m *GetAncestrySummaryResponse_SeverityCount) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type PostAncestryRequest struct {
+	// The name of the ancestry being scanned.
+	// If scanning OCI images, this should be the hash of the manifest.
+	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+	// The format of the image being uploaded.
+	Format string `protobuf:"bytes,2,opt,name=format" json:"format,omitempty"`
+	// The layers to be scanned for this Ancestry, ordered in the way that i th
+	// layer is the parent of i + 1 th layer.
+	Layers []*PostAncestryRequest_PostLayer `protobuf:"bytes,3,rep,name=layers" json:"layers,omitempty"`
+	// The registry from which the layers without path are pulled, by their hash
+	// as the digest of their blob. Its credentials are only used for the pull
+	// of these layers.
+	Registry *PostAncestryRequest_Registry `protobuf:"bytes,4,opt,name=registry" json:"registry,omitempty"`
+	// The credentials reading the layers whose path is the URI of an object.
+	// They are only used for the reads of these layers.
+	ObjectStorage *PostAncestryRequest_ObjectStorage `protobuf:"bytes,5,opt,name=object_storage,json=objectStorage" json:"object_storage,omitempty"`
+}
+
+func (m *PostAncestryRequest) Reset()                    { *m = PostAncestryRequest{} }
+func (m *PostAncestryRequest) String() string            { return proto.CompactTextString(m) }
+func (*PostAncestryRequest) ProtoMessage()               {}
+func (*PostAncestryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *PostAncestryRequest) GetAncestryName() string {
+	if m != nil {
+		return m.AncestryName
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest) GetFormat() string {
+	if m != nil {
+		return m.Format
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest) GetLayers() []*PostAncestryRequest_PostLayer {
+	if m != nil {
+		return m.Layers
+	}
+	return nil
+}
+
+func (m *PostAncestryRequest) GetRegistry() *PostAncestryRequest_Registry {
+	if m != nil {
+		return m.Registry
+	}
+	return nil
+}
+
+func (m *PostAncestryRequest) GetObjectStorage() *PostAncestryRequest_ObjectStorage {
+	if m != nil {
+		return m.ObjectStorage
+	}
+	return nil
+}
+
+type PostAncestryRequest_PostLayer struct {
+	// The hash of the layer.
+	Hash string `protobuf:"bytes,1,opt,name=hash" json:"hash,omitempty"`
+	// The location of the layer (URL, filepath, or URI of an object in object
+	// storage, e.g. "s3://bucket/key" or "gs://bucket/key").
+	// It can be empty when the layer is pulled from the registry of the request.
+	Path string `protobuf:"bytes,2,opt,name=path" json:"path,omitempty"`
+	// Any HTTP Headers that need to be used if requesting a layer over HTTP(S).
+	Headers map[string]string `protobuf:"bytes,3,rep,name=headers" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *PostAncestryRequest_PostLayer) Reset()         { *m = PostAncestryRequest_PostLayer{} }
+func (m *PostAncestryRequest_PostLayer) String() string { return proto.CompactTextString(m) }
+func (*PostAncestryRequest_PostLayer) ProtoMessage()    {}
+func (*PostAncestryRequest_PostLayer) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{16, 0}
+}
+
+func (m *PostAncestryRequest_PostLayer) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest_PostLayer) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest_PostLayer) GetHeaders() map[string]string {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
+type PostAncestryRequest_Registry struct {
+	// The host, and optional port, of the registry, e.g. "quay.io".
+	Host string `protobuf:"bytes,1,opt,name=host" json:"host,omitempty"`
+	// The repository of the image in the registry, e.g. "coreos/clair".
+	Repository string `protobuf:"bytes,2,opt,name=repository" json:"repository,omitempty"`
+	// Whether the registry is served over plain HTTP instead of HTTPS.
+	Insecure bool `protobuf:"varint,3,opt,name=insecure" json:"insecure,omitempty"`
+	// The bearer token authorizing the pull of the repository.
+	Token string `protobuf:"bytes,4,opt,name=token" json:"token,omitempty"`
+	// The credentials exchanged for a bearer token, or sent as basic
+	// authentication, when the registry asks for credentials.
+	Username string `protobuf:"bytes,5,opt,name=username" json:"username,omitempty"`
+	Password string `protobuf:"bytes,6,opt,name=password" json:"password,omitempty"`
+}
+
+func (m *PostAncestryRequest_Registry) Reset()         { *m = PostAncestryRequest_Registry{} }
+func (m *PostAncestryRequest_Registry) String() string { return proto.CompactTextString(m) }
+func (*PostAncestryRequest_Registry) ProtoMessage()    {}
+func (*PostAncestryRequest_Registry) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{16, 1}
+}
+
+func (m *PostAncestryRequest_Registry) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest_Registry) GetRepository() string {
+	if m != nil {
+		return m.Repository
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest_Registry) GetInsecure() bool {
+	if m != nil {
+		return m.Insecure
+	}
+	return false
+}
+
+func (m *PostAncestryRequest_Registry) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest_Registry) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest_Registry) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+type PostAncestryRequest_ObjectStorage struct {
+	// The access key signing the S3 requests, along with the session token of
+	// temporary credentials.
+	AccessKeyId     string `protobuf:"bytes,1,opt,name=access_key_id,json=accessKeyId" json:"access_key_id,omitempty"`
+	SecretAccessKey string `protobuf:"bytes,2,opt,name=secret_access_key,json=secretAccessKey" json:"secret_access_key,omitempty"`
+	SessionToken    string `protobuf:"bytes,3,opt,name=session_token,json=sessionToken" json:"session_token,omitempty"`
+	// The region of the S3 bucket, e.g. "us-east-1".
+	Region string `protobuf:"bytes,4,opt,name=region" json:"region,omitempty"`
+	// The endpoint replacing the one of the service, e.g. the URL of an
+	// S3-compatible store.
+	Endpoint string `protobuf:"bytes,5,opt,name=endpoint" json:"endpoint,omitempty"`
+	// The OAuth 2.0 access token authorizing the GCS requests.
+	Token string `protobuf:"bytes,6,opt,name=token" json:"token,omitempty"`
+}
+
+func (m *PostAncestryRequest_ObjectStorage) Reset()         { *m = PostAncestryRequest_ObjectStorage{} }
+func (m *PostAncestryRequest_ObjectStorage) String() string { return proto.CompactTextString(m) }
+func (*PostAncestryRequest_ObjectStorage) ProtoMessage()    {}
+func (*PostAncestryRequest_ObjectStorage) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{16, 2}
+}
+
+func (m *PostAncestryRequest_ObjectStorage) GetAccessKeyId() string {
+	if m != nil {
+		return m.AccessKeyId
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest_ObjectStorage) GetSecretAccessKey() string {
+	if m != nil {
+		return m.SecretAccessKey
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest_ObjectStorage) GetSessionToken() string {
+	if m != nil {
+		return m.SessionToken
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest_ObjectStorage) GetRegion() string {
+	if m != nil {
+		return m.Region
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest_ObjectStorage) GetEndpoint() string {
+	if m != nil {
+		return m.Endpoint
+	}
+	return ""
+}
+
+func (m *PostAncestryRequest_ObjectStorage) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+type PostAncestryResponse struct {
+	// The status of Clair at the time of the request.
+	Status *ClairStatus `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *PostAncestryResponse) Reset()                    { *m = PostAncestryResponse{} }
+func (m *PostAncestryResponse) String() string            { return proto.CompactTextString(m) }
+func (*PostAncestryResponse) ProtoMessage()               {}
+func (*PostAncestryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *PostAncestryResponse) GetStatus() *ClairStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type PostLayersRequest struct {
+	// The format of the image whose layers are uploaded.
+	Format string `protobuf:"bytes,1,opt,name=format" json:"format,omitempty"`
+	// The layers to be scanned, in order, ordered in the way that i th layer is
+	// the parent of i + 1 th layer.
+	Layers []*PostAncestryRequest_PostLayer `protobuf:"bytes,2,rep,name=layers" json:"layers,omitempty"`
+	// The registry from which the layers without path are pulled, by their hash
+	// as the digest of their blob.
+	Registry *PostAncestryRequest_Registry `protobuf:"bytes,3,opt,name=registry" json:"registry,omitempty"`
+	// The credentials reading the layers whose path is the URI of an object.
+	ObjectStorage *PostAncestryRequest_ObjectStorage `protobuf:"bytes,4,opt,name=object_storage,json=objectStorage" json:"object_storage,omitempty"`
+}
+
+func (m *PostLayersRequest) Reset()                    { *m = PostLayersRequest{} }
+func (m *PostLayersRequest) String() string            { return proto.CompactTextString(m) }
+func (*PostLayersRequest) ProtoMessage()               {}
+func (*PostLayersRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *PostLayersRequest) GetFormat() string {
+	if m != nil {
+		return m.Format
+	}
+	return ""
+}
+
+func (m *PostLayersRequest) GetLayers() []*PostAncestryRequest_PostLayer {
+	if m != nil {
+		return m.Layers
+	}
+	return nil
+}
+
+func (m *PostLayersRequest) GetRegistry() *PostAncestryRequest_Registry {
+	if m != nil {
+		return m.Registry
+	}
+	return nil
+}
+
+func (m *PostLayersRequest) GetObjectStorage() *PostAncestryRequest_ObjectStorage {
+	if m != nil {
+		return m.ObjectStorage
+	}
+	return nil
+}
+
+type PostLayersResponse struct {
+	// The results of the scanned layers, in order. The scan stops at the first
+	// layer that fails, which is then the last one.
+	Results []*PostLayersResponse_LayerResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+	// The number of layers successfully scanned.
+	ScannedCount int32 `protobuf:"varint,2,opt,name=scanned_count,json=scannedCount" json:"scanned_count,omitempty"`
+}
+
+func (m *PostLayersResponse) Reset()                    { *m = PostLayersResponse{} }
+func (m *PostLayersResponse) String() string            { return proto.CompactTextString(m) }
+func (*PostLayersResponse) ProtoMessage()               {}
+func (*PostLayersResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *PostLayersResponse) GetResults() []*PostLayersResponse_LayerResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+func (m *PostLayersResponse) GetScannedCount() int32 {
+	if m != nil {
+		return m.ScannedCount
+	}
+	return 0
+}
+
+type PostLayersResponse_LayerResult struct {
+	// The hash of the layer.
+	Hash string `protobuf:"bytes,1,opt,name=hash" json:"hash,omitempty"`
+	// The detectors that scanned the layer.
+	Detectors []*Detector `protobuf:"bytes,2,rep,name=detectors" json:"detectors,omitempty"`
+	// The number of features found in the layer.
+	FeatureCount int32 `protobuf:"varint,3,opt,name=feature_count,json=featureCount" json:"feature_count,omitempty"`
+	// The number of namespaces found in the layer.
+	NamespaceCount int32 `protobuf:"varint,4,opt,name=namespace_count,json=namespaceCount" json:"namespace_count,omitempty"`
+	// The error that stopped the scan at this layer, if any.
+	Error string `protobuf:"bytes,5,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *PostLayersResponse_LayerResult) Reset()         { *m = PostLayersResponse_LayerResult{} }
+func (m *PostLayersResponse_LayerResult) String() string { return proto.CompactTextString(m) }
+func (*PostLayersResponse_LayerResult) ProtoMessage()    {}
+func (*PostLayersResponse_LayerResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{19, 0}
+}
+
+func (m *PostLayersResponse_LayerResult) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+func (m *PostLayersResponse_LayerResult) GetDetectors() []*Detector {
+	if m != nil {
+		return m.Detectors
+	}
+	return nil
+}
+
+func (m *PostLayersResponse_LayerResult) GetFeatureCount() int32 {
+	if m != nil {
+		return m.FeatureCount
+	}
+	return 0
+}
+
+func (m *PostLayersResponse_LayerResult) GetNamespaceCount() int32 {
+	if m != nil {
+		return m.NamespaceCount
+	}
+	return 0
+}
+
+func (m *PostLayersResponse_LayerResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type PostImageRequest struct {
+	// The format of the squashed image.
+	Format string `protobuf:"bytes,1,opt,name=format" json:"format,omitempty"`
+	// The single layer of the squashed image.
+	Layer *PostAncestryRequest_PostLayer `protobuf:"bytes,2,opt,name=layer" json:"layer,omitempty"`
+	// The registry from which the layer is pulled, by its hash as the digest of
+	// its blob, when it has no path.
+	Registry *PostAncestryRequest_Registry `protobuf:"bytes,3,opt,name=registry" json:"registry,omitempty"`
+	// Whether the vulnerabilities suppressed by the allowlist are returned,
+	// flagged as suppressed, to audit them.
+	WithSuppressed bool `protobuf:"varint,4,opt,name=with_suppressed,json=withSuppressed" json:"with_suppressed,omitempty"`
+	// The tags of the vulnerabilities that are left out, e.g. "no-dsa".
+	ExcludedTags []string `protobuf:"bytes,5,rep,name=excluded_tags,json=excludedTags" json:"excluded_tags,omitempty"`
+	// Whether only the vulnerabilities with an available fix are returned.
+	OnlyFixable bool `protobuf:"varint,6,opt,name=only_fixable,json=onlyFixable" json:"only_fixable,omitempty"`
+	// The credentials reading the layer when its path is the URI of an object.
+	ObjectStorage *PostAncestryRequest_ObjectStorage `protobuf:"bytes,7,opt,name=object_storage,json=objectStorage" json:"object_storage,omitempty"`
+}
+
+func (m *PostImageRequest) Reset()                    { *m = PostImageRequest{} }
+func (m *PostImageRequest) String() string            { return proto.CompactTextString(m) }
+func (*PostImageRequest) ProtoMessage()               {}
+func (*PostImageRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *PostImageRequest) GetFormat() string {
+	if m != nil {
+		return m.Format
+	}
+	return ""
+}
+
+func (m *PostImageRequest) GetLayer() *PostAncestryRequest_PostLayer {
+	if m != nil {
+		return m.Layer
+	}
+	return nil
+}
+
+func (m *PostImageRequest) GetRegistry() *PostAncestryRequest_Registry {
+	if m != nil {
+		return m.Registry
+	}
+	return nil
+}
+
+func (m *PostImageRequest) GetWithSuppressed() bool {
+	if m != nil {
+		return m.WithSuppressed
+	}
+	return false
+}
+
+func (m *PostImageRequest) GetExcludedTags() []string {
+	if m != nil {
+		return m.ExcludedTags
+	}
+	return nil
+}
+
+func (m *PostImageRequest) GetOnlyFixable() bool {
+	if m != nil {
+		return m.OnlyFixable
+	}
+	return false
+}
+
+func (m *PostImageRequest) GetObjectStorage() *PostAncestryRequest_ObjectStorage {
+	if m != nil {
+		return m.ObjectStorage
+	}
+	return nil
+}
+
+type PostImageResponse struct {
+	// The layer of the image along with its detected features.
+	Layer *GetAncestryResponse_AncestryLayer `protobuf:"bytes,1,opt,name=layer" json:"layer,omitempty"`
+	// The detectors used to scan the image.
+	Detectors []*Detector `protobuf:"bytes,2,rep,name=detectors" json:"detectors,omitempty"`
+	// The status of Clair at the time of the request.
+	Status *ClairStatus `protobuf:"bytes,3,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *PostImageResponse) Reset()                    { *m = PostImageResponse{} }
+func (m *PostImageResponse) String() string            { return proto.CompactTextString(m) }
+func (*PostImageResponse) ProtoMessage()               {}
+func (*PostImageResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *PostImageResponse) GetLayer() *GetAncestryResponse_AncestryLayer {
+	if m != nil {
+		return m.Layer
+	}
+	return nil
+}
+
+func (m *PostImageResponse) GetDetectors() []*Detector {
+	if m != nil {
+		return m.Detectors
+	}
+	return nil
+}
+
+func (m *PostImageResponse) GetStatus() *ClairStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type GetLayerRequest struct {
+	// The hash of the layer.
+	Hash string `protobuf:"bytes,1,opt,name=hash" json:"hash,omitempty"`
+}
+
+func (m *GetLayerRequest) Reset()                    { *m = GetLayerRequest{} }
+func (m *GetLayerRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetLayerRequest) ProtoMessage()               {}
+func (*GetLayerRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *GetLayerRequest) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+type GetLayerResponse struct {
+	// The layer's information.
+	Layer *Layer `protobuf:"bytes,1,opt,name=layer" json:"layer,omitempty"`
+	// The detectors that scanned the layer.
+	Detectors []*Detector `protobuf:"bytes,2,rep,name=detectors" json:"detectors,omitempty"`
+	// The enabled detectors that did not scan the layer, e.g. because they were
+	// upgraded since. The layer should be posted again when it is not empty.
+	MissingDetectors []*Detector `protobuf:"bytes,3,rep,name=missing_detectors,json=missingDetectors" json:"missing_detectors,omitempty"`
+	// The number of features found in the layer.
+	FeatureCount int32 `protobuf:"varint,4,opt,name=feature_count,json=featureCount" json:"feature_count,omitempty"`
+	// The number of namespaces found in the layer.
+	NamespaceCount int32 `protobuf:"varint,5,opt,name=namespace_count,json=namespaceCount" json:"namespace_count,omitempty"`
+	// The status of Clair at the time of the request.
+	Status *ClairStatus `protobuf:"bytes,6,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *GetLayerResponse) Reset()                    { *m = GetLayerResponse{} }
+func (m *GetLayerResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetLayerResponse) ProtoMessage()               {}
+func (*GetLayerResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *GetLayerResponse) GetLayer() *Layer {
+	if m != nil {
+		return m.Layer
+	}
+	return nil
+}
+
+func (m *GetLayerResponse) GetDetectors() []*Detector {
+	if m != nil {
+		return m.Detectors
+	}
+	return nil
+}
+
+func (m *GetLayerResponse) GetMissingDetectors() []*Detector {
+	if m != nil {
+		return m.MissingDetectors
+	}
+	return nil
+}
+
+func (m *GetLayerResponse) GetFeatureCount() int32 {
+	if m != nil {
+		return m.FeatureCount
+	}
+	return 0
+}
+
+func (m *GetLayerResponse) GetNamespaceCount() int32 {
+	if m != nil {
+		return m.NamespaceCount
+	}
+	return 0
+}
+
+func (m *GetLayerResponse) GetStatus() *ClairStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type DeleteAncestryRequest struct {
+	// The name of the ancestry to delete.
+	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+}
+
+func (m *DeleteAncestryRequest) Reset()                    { *m = DeleteAncestryRequest{} }
+func (m *DeleteAncestryRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteAncestryRequest) ProtoMessage()               {}
+func (*DeleteAncestryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *DeleteAncestryRequest) GetAncestryName() string {
+	if m != nil {
+		return m.AncestryName
+	}
+	return ""
+}
+
+type DeleteAncestryResponse struct {
+}
+
+func (m *DeleteAncestryResponse) Reset()                    { *m = DeleteAncestryResponse{} }
+func (m *DeleteAncestryResponse) String() string            { return proto.CompactTextString(m) }
+func (*DeleteAncestryResponse) ProtoMessage()               {}
+func (*DeleteAncestryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+type DeleteLayerRequest struct {
+	// The hash of the layer to delete.
+	Hash string `protobuf:"bytes,1,opt,name=hash" json:"hash,omitempty"`
+}
+
+func (m *DeleteLayerRequest) Reset()                    { *m = DeleteLayerRequest{} }
+func (m *DeleteLayerRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteLayerRequest) ProtoMessage()               {}
+func (*DeleteLayerRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *DeleteLayerRequest) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+type DeleteLayerResponse struct {
+}
+
+func (m *DeleteLayerResponse) Reset()                    { *m = DeleteLayerResponse{} }
+func (m *DeleteLayerResponse) String() string            { return proto.CompactTextString(m) }
+func (*DeleteLayerResponse) ProtoMessage()               {}
+func (*DeleteLayerResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+type PruneAnalysesRequest struct {
+	// The number of days after which the results of the scans are deleted. The
+	// ancestries scanned before are deleted, then the layers scanned before that
+	// no ancestry contains anymore.
+	OlderThanDays int32 `protobuf:"varint,1,opt,name=older_than_days,json=olderThanDays" json:"older_than_days,omitempty"`
+}
+
+func (m *PruneAnalysesRequest) Reset()                    { *m = PruneAnalysesRequest{} }
+func (m *PruneAnalysesRequest) String() string            { return proto.CompactTextString(m) }
+func (*PruneAnalysesRequest) ProtoMessage()               {}
+func (*PruneAnalysesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *PruneAnalysesRequest) GetOlderThanDays() int32 {
+	if m != nil {
+		return m.OlderThanDays
+	}
+	return 0
+}
+
+type PruneAnalysesResponse struct {
+	// The names of the deleted ancestries.
+	DeletedAncestries []string `protobuf:"bytes,1,rep,name=deleted_ancestries,json=deletedAncestries" json:"deleted_ancestries,omitempty"`
+	// The hashes of the deleted layers.
+	DeletedLayers []string `protobuf:"bytes,2,rep,name=deleted_layers,json=deletedLayers" json:"deleted_layers,omitempty"`
+}
+
+func (m *PruneAnalysesResponse) Reset()                    { *m = PruneAnalysesResponse{} }
+func (m *PruneAnalysesResponse) String() string            { return proto.CompactTextString(m) }
+func (*PruneAnalysesResponse) ProtoMessage()               {}
+func (*PruneAnalysesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *PruneAnalysesResponse) GetDeletedAncestries() []string {
+	if m != nil {
+		return m.DeletedAncestries
+	}
+	return nil
+}
+
+func (m *PruneAnalysesResponse) GetDeletedLayers() []string {
+	if m != nil {
+		return m.DeletedLayers
+	}
+	return nil
+}
+
+type GetNotificationRequest struct {
+	// The current page of previous vulnerabilities for the ancestry.
+	// This will be empty when it is the first page.
+	OldVulnerabilityPage string `protobuf:"bytes,1,opt,name=old_vulnerability_page,json=oldVulnerabilityPage" json:"old_vulnerability_page,omitempty"`
+	// The current page of vulnerabilities for the ancestry.
+	// This will be empty when it is the first page.
+	NewVulnerabilityPage string `protobuf:"bytes,2,opt,name=new_vulnerability_page,json=newVulnerabilityPage" json:"new_vulnerability_page,omitempty"`
+	// The requested maximum number of results per page.
+	Limit int32 `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
+	// The name of the notification being requested.
+	Name string `protobuf:"bytes,4,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *GetNotificationRequest) Reset()                    { *m = GetNotificationRequest{} }
+func (m *GetNotificationRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetNotificationRequest) ProtoMessage()               {}
+func (*GetNotificationRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *GetNotificationRequest) GetOldVulnerabilityPage() string {
+	if m != nil {
+		return m.OldVulnerabilityPage
+	}
+	return ""
+}
+
+func (m *GetNotificationRequest) GetNewVulnerabilityPage() string {
+	if m != nil {
+		return m.NewVulnerabilityPage
+	}
+	return ""
+}
+
+func (m *GetNotificationRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *GetNotificationRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type GetNotificationResponse struct {
+	// The notification as requested.
+	Notification *GetNotificationResponse_Notification `protobuf:"bytes,1,opt,name=notification" json:"notification,omitempty"`
+}
+
+func (m *GetNotificationResponse) Reset()                    { *m = GetNotificationResponse{} }
+func (m *GetNotificationResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetNotificationResponse) ProtoMessage()               {}
+func (*GetNotificationResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *GetNotificationResponse) GetNotification() *GetNotificationResponse_Notification {
+	if m != nil {
+		return m.Notification
+	}
+	return nil
+}
+
+type GetNotificationResponse_Notification struct {
+	// The name of the requested notification.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// The time at which the notification was created.
 	Created string `protobuf:"bytes,2,opt,name=created" json:"created,omitempty"`
 	// The time at which the notification was last sent out.
 	Notified string `protobuf:"bytes,3,opt,name=notified" json:"notified,omitempty"`
@@ -598,208 +1870,884 @@ type GetNotificationResponse_Notification struct {
 	Old *PagedVulnerableAncestries `protobuf:"bytes,5,opt,name=old" json:"old,omitempty"`
 	// The newly updated vulnerability and a paginated view of the ancestries it affects.
 	New *PagedVulnerableAncestries `protobuf:"bytes,6,opt,name=new" json:"new,omitempty"`
+	// Whether the notification is the withdrawal of the old vulnerability by
+	// its data source, in which case there is no new vulnerability.
+	Withdrawn bool `protobuf:"varint,7,opt,name=withdrawn" json:"withdrawn,omitempty"`
+}
+
+func (m *GetNotificationResponse_Notification) Reset()         { *m = GetNotificationResponse_Notification{} }
+func (m *GetNotificationResponse_Notification) String() string { return proto.CompactTextString(m) }
+func (*GetNotificationResponse_Notification) ProtoMessage()    {}
+func (*GetNotificationResponse_Notification) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{31, 0}
+}
+
+func (m *GetNotificationResponse_Notification) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *GetNotificationResponse_Notification) GetCreated() string {
+	if m != nil {
+		return m.Created
+	}
+	return ""
+}
+
+func (m *GetNotificationResponse_Notification) GetNotified() string {
+	if m != nil {
+		return m.Notified
+	}
+	return ""
+}
+
+func (m *GetNotificationResponse_Notification) GetDeleted() string {
+	if m != nil {
+		return m.Deleted
+	}
+	return ""
+}
+
+func (m *GetNotificationResponse_Notification) GetOld() *PagedVulnerableAncestries {
+	if m != nil {
+		return m.Old
+	}
+	return nil
+}
+
+func (m *GetNotificationResponse_Notification) GetNew() *PagedVulnerableAncestries {
+	if m != nil {
+		return m.New
+	}
+	return nil
+}
+
+func (m *GetNotificationResponse_Notification) GetWithdrawn() bool {
+	if m != nil {
+		return m.Withdrawn
+	}
+	return false
+}
+
+type PagedVulnerableAncestries struct {
+	// The identifier for the current page.
+	CurrentPage string `protobuf:"bytes,1,opt,name=current_page,json=currentPage" json:"current_page,omitempty"`
+	// The token used to request the next page.
+	// This will be empty when there are no more pages.
+	NextPage string `protobuf:"bytes,2,opt,name=next_page,json=nextPage" json:"next_page,omitempty"`
+	// The requested maximum number of results per page.
+	Limit int32 `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
+	// The vulnerability that affects a given set of ancestries.
+	Vulnerability *Vulnerability `protobuf:"bytes,4,opt,name=vulnerability" json:"vulnerability,omitempty"`
+	// The ancestries affected by a vulnerability.
+	Ancestries []*PagedVulnerableAncestries_IndexedAncestryName `protobuf:"bytes,5,rep,name=ancestries" json:"ancestries,omitempty"`
+}
+
+func (m *PagedVulnerableAncestries) Reset()                    { *m = PagedVulnerableAncestries{} }
+func (m *PagedVulnerableAncestries) String() string            { return proto.CompactTextString(m) }
+func (*PagedVulnerableAncestries) ProtoMessage()               {}
+func (*PagedVulnerableAncestries) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *PagedVulnerableAncestries) GetCurrentPage() string {
+	if m != nil {
+		return m.CurrentPage
+	}
+	return ""
+}
+
+func (m *PagedVulnerableAncestries) GetNextPage() string {
+	if m != nil {
+		return m.NextPage
+	}
+	return ""
+}
+
+func (m *PagedVulnerableAncestries) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *PagedVulnerableAncestries) GetVulnerability() *Vulnerability {
+	if m != nil {
+		return m.Vulnerability
+	}
+	return nil
+}
+
+func (m *PagedVulnerableAncestries) GetAncestries() []*PagedVulnerableAncestries_IndexedAncestryName {
+	if m != nil {
+		return m.Ancestries
+	}
+	return nil
+}
+
+type PagedVulnerableAncestries_IndexedAncestryName struct {
+	// The index is an ever increasing number associated with the particular ancestry.
+	// This is useful if you're processing notifications, and need to keep track of the progress of paginating the results.
+	Index int32 `protobuf:"varint,1,opt,name=index" json:"index,omitempty"`
+	// The name of the ancestry.
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *PagedVulnerableAncestries_IndexedAncestryName) Reset() {
+	*m = PagedVulnerableAncestries_IndexedAncestryName{}
+}
+func (m *PagedVulnerableAncestries_IndexedAncestryName) String() string {
+	return proto.CompactTextString(m)
+}
+func (*PagedVulnerableAncestries_IndexedAncestryName) ProtoMessage() {}
+func (*PagedVulnerableAncestries_IndexedAncestryName) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{32, 0}
+}
+
+func (m *PagedVulnerableAncestries_IndexedAncestryName) GetIndex() int32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *PagedVulnerableAncestries_IndexedAncestryName) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type MarkNotificationAsReadRequest struct {
+	// The name of the Notification that has been processed.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *MarkNotificationAsReadRequest) Reset()                    { *m = MarkNotificationAsReadRequest{} }
+func (m *MarkNotificationAsReadRequest) String() string            { return proto.CompactTextString(m) }
+func (*MarkNotificationAsReadRequest) ProtoMessage()               {}
+func (*MarkNotificationAsReadRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *MarkNotificationAsReadRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type MarkNotificationAsReadResponse struct {
+}
+
+func (m *MarkNotificationAsReadResponse) Reset()                    { *m = MarkNotificationAsReadResponse{} }
+func (m *MarkNotificationAsReadResponse) String() string            { return proto.CompactTextString(m) }
+func (*MarkNotificationAsReadResponse) ProtoMessage()               {}
+func (*MarkNotificationAsReadResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+type ListPendingNotificationsRequest struct {
+	// The requested maximum number of notifications per page.
+	Limit int32 `protobuf:"varint,1,opt,name=limit" json:"limit,omitempty"`
+	// The requested page. This will be empty when it is the first page.
+	Page string `protobuf:"bytes,2,opt,name=page" json:"page,omitempty"`
+}
+
+func (m *ListPendingNotificationsRequest) Reset()         { *m = ListPendingNotificationsRequest{} }
+func (m *ListPendingNotificationsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListPendingNotificationsRequest) ProtoMessage()    {}
+func (*ListPendingNotificationsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{35}
+}
+
+func (m *ListPendingNotificationsRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *ListPendingNotificationsRequest) GetPage() string {
+	if m != nil {
+		return m.Page
+	}
+	return ""
+}
+
+type ListPendingNotificationsResponse struct {
+	// The pending notifications of the page, from the oldest one.
+	Notifications []*ListPendingNotificationsResponse_PendingNotification `protobuf:"bytes,1,rep,name=notifications" json:"notifications,omitempty"`
+	// The next page. This will be empty when it is the last page.
+	NextPage string `protobuf:"bytes,2,opt,name=next_page,json=nextPage" json:"next_page,omitempty"`
+	// The number of pending notifications, on all the pages.
+	Count int32 `protobuf:"varint,3,opt,name=count" json:"count,omitempty"`
+	// The number of seconds since the oldest pending notification was created.
+	OldestAgeSeconds int64 `protobuf:"varint,4,opt,name=oldest_age_seconds,json=oldestAgeSeconds" json:"oldest_age_seconds,omitempty"`
+}
+
+func (m *ListPendingNotificationsResponse) Reset()         { *m = ListPendingNotificationsResponse{} }
+func (m *ListPendingNotificationsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListPendingNotificationsResponse) ProtoMessage()    {}
+func (*ListPendingNotificationsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{36}
+}
+
+func (m *ListPendingNotificationsResponse) GetNotifications() []*ListPendingNotificationsResponse_PendingNotification {
+	if m != nil {
+		return m.Notifications
+	}
+	return nil
+}
+
+func (m *ListPendingNotificationsResponse) GetNextPage() string {
+	if m != nil {
+		return m.NextPage
+	}
+	return ""
 }
 
-func (m *GetNotificationResponse_Notification) Reset()         { *m = GetNotificationResponse_Notification{} }
-func (m *GetNotificationResponse_Notification) String() string { return proto.CompactTextString(m) }
-func (*GetNotificationResponse_Notification) ProtoMessage()    {}
-func (*GetNotificationResponse_Notification) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{11, 0}
+func (m *ListPendingNotificationsResponse) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
 }
 
-func (m *GetNotificationResponse_Notification) GetName() string {
+func (m *ListPendingNotificationsResponse) GetOldestAgeSeconds() int64 {
+	if m != nil {
+		return m.OldestAgeSeconds
+	}
+	return 0
+}
+
+type ListPendingNotificationsResponse_PendingNotification struct {
+	// The name of the notification.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// The time at which the notification was created.
+	Created *google_protobuf.Timestamp `protobuf:"bytes,2,opt,name=created" json:"created,omitempty"`
+	// The number of seconds since the notification was created.
+	AgeSeconds int64 `protobuf:"varint,3,opt,name=age_seconds,json=ageSeconds" json:"age_seconds,omitempty"`
+}
+
+func (m *ListPendingNotificationsResponse_PendingNotification) Reset() {
+	*m = ListPendingNotificationsResponse_PendingNotification{}
+}
+func (m *ListPendingNotificationsResponse_PendingNotification) String() string {
+	return proto.CompactTextString(m)
+}
+func (*ListPendingNotificationsResponse_PendingNotification) ProtoMessage() {}
+func (*ListPendingNotificationsResponse_PendingNotification) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{36, 0}
+}
+
+func (m *ListPendingNotificationsResponse_PendingNotification) GetName() string {
 	if m != nil {
 		return m.Name
 	}
 	return ""
 }
 
-func (m *GetNotificationResponse_Notification) GetCreated() string {
+func (m *ListPendingNotificationsResponse_PendingNotification) GetCreated() *google_protobuf.Timestamp {
 	if m != nil {
 		return m.Created
 	}
+	return nil
+}
+
+func (m *ListPendingNotificationsResponse_PendingNotification) GetAgeSeconds() int64 {
+	if m != nil {
+		return m.AgeSeconds
+	}
+	return 0
+}
+
+type ResolveNotificationRequest struct {
+	// The name of the pending notification.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// How the notification is resolved.
+	Resolution ResolveNotificationRequest_Resolution `protobuf:"varint,2,opt,name=resolution,enum=coreos.clair.ResolveNotificationRequest_Resolution" json:"resolution,omitempty"`
+}
+
+func (m *ResolveNotificationRequest) Reset()                    { *m = ResolveNotificationRequest{} }
+func (m *ResolveNotificationRequest) String() string            { return proto.CompactTextString(m) }
+func (*ResolveNotificationRequest) ProtoMessage()               {}
+func (*ResolveNotificationRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+func (m *ResolveNotificationRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
 	return ""
 }
 
-func (m *GetNotificationResponse_Notification) GetNotified() string {
+func (m *ResolveNotificationRequest) GetResolution() ResolveNotificationRequest_Resolution {
 	if m != nil {
-		return m.Notified
+		return m.Resolution
+	}
+	return ResolveNotificationRequest_RESOLUTION_INVALID
+}
+
+type ResolveNotificationResponse struct {
+}
+
+func (m *ResolveNotificationResponse) Reset()                    { *m = ResolveNotificationResponse{} }
+func (m *ResolveNotificationResponse) String() string            { return proto.CompactTextString(m) }
+func (*ResolveNotificationResponse) ProtoMessage()               {}
+func (*ResolveNotificationResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+type FlushNotificationsRequest struct {
+	// The number of hours after which the pending notifications are discarded.
+	OlderThanHours int32 `protobuf:"varint,1,opt,name=older_than_hours,json=olderThanHours" json:"older_than_hours,omitempty"`
+}
+
+func (m *FlushNotificationsRequest) Reset()                    { *m = FlushNotificationsRequest{} }
+func (m *FlushNotificationsRequest) String() string            { return proto.CompactTextString(m) }
+func (*FlushNotificationsRequest) ProtoMessage()               {}
+func (*FlushNotificationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+func (m *FlushNotificationsRequest) GetOlderThanHours() int32 {
+	if m != nil {
+		return m.OlderThanHours
+	}
+	return 0
+}
+
+type FlushNotificationsResponse struct {
+	// The names of the discarded notifications.
+	FlushedNotifications []string `protobuf:"bytes,1,rep,name=flushed_notifications,json=flushedNotifications" json:"flushed_notifications,omitempty"`
+}
+
+func (m *FlushNotificationsResponse) Reset()                    { *m = FlushNotificationsResponse{} }
+func (m *FlushNotificationsResponse) String() string            { return proto.CompactTextString(m) }
+func (*FlushNotificationsResponse) ProtoMessage()               {}
+func (*FlushNotificationsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *FlushNotificationsResponse) GetFlushedNotifications() []string {
+	if m != nil {
+		return m.FlushedNotifications
+	}
+	return nil
+}
+
+type GetStatusRequest struct {
+}
+
+func (m *GetStatusRequest) Reset()                    { *m = GetStatusRequest{} }
+func (m *GetStatusRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetStatusRequest) ProtoMessage()               {}
+func (*GetStatusRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+
+type GetStatusResponse struct {
+	// The status of the current Clair instance.
+	Status *ClairStatus `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *GetStatusResponse) Reset()                    { *m = GetStatusResponse{} }
+func (m *GetStatusResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetStatusResponse) ProtoMessage()               {}
+func (*GetStatusResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
+
+func (m *GetStatusResponse) GetStatus() *ClairStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type UpdaterStatus struct {
+	// The outcome of the last update.
+	Result UpdaterStatus_Result `protobuf:"varint,1,opt,name=result,enum=coreos.clair.UpdaterStatus_Result" json:"result,omitempty"`
+	// The time at which the last update started.
+	LastStartTime *google_protobuf.Timestamp `protobuf:"bytes,2,opt,name=last_start_time,json=lastStartTime" json:"last_start_time,omitempty"`
+	// The time at which the last successful update finished.
+	LastSuccessTime *google_protobuf.Timestamp `protobuf:"bytes,3,opt,name=last_success_time,json=lastSuccessTime" json:"last_success_time,omitempty"`
+	// The error of the last update, if it failed.
+	LastError string `protobuf:"bytes,4,opt,name=last_error,json=lastError" json:"last_error,omitempty"`
+	// Whether an update is currently in progress.
+	InProgress bool `protobuf:"varint,5,opt,name=in_progress,json=inProgress" json:"in_progress,omitempty"`
+	// The name of the instance of Clair holding the updater lock, while an
+	// update is in progress.
+	LockHolder string `protobuf:"bytes,6,opt,name=lock_holder,json=lockHolder" json:"lock_holder,omitempty"`
+	// The time at which the updater lock expires, unless its holder refreshes
+	// it, while an update is in progress.
+	LockExpirationTime *google_protobuf.Timestamp `protobuf:"bytes,7,opt,name=lock_expiration_time,json=lockExpirationTime" json:"lock_expiration_time,omitempty"`
+}
+
+func (m *UpdaterStatus) Reset()                    { *m = UpdaterStatus{} }
+func (m *UpdaterStatus) String() string            { return proto.CompactTextString(m) }
+func (*UpdaterStatus) ProtoMessage()               {}
+func (*UpdaterStatus) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
+
+func (m *UpdaterStatus) GetResult() UpdaterStatus_Result {
+	if m != nil {
+		return m.Result
+	}
+	return UpdaterStatus_UPDATER_STATUS_RESULT_NEVER_RUN
+}
+
+func (m *UpdaterStatus) GetLastStartTime() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.LastStartTime
+	}
+	return nil
+}
+
+func (m *UpdaterStatus) GetLastSuccessTime() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.LastSuccessTime
+	}
+	return nil
+}
+
+func (m *UpdaterStatus) GetLastError() string {
+	if m != nil {
+		return m.LastError
 	}
 	return ""
 }
 
-func (m *GetNotificationResponse_Notification) GetDeleted() string {
+func (m *UpdaterStatus) GetInProgress() bool {
 	if m != nil {
-		return m.Deleted
+		return m.InProgress
+	}
+	return false
+}
+
+func (m *UpdaterStatus) GetLockHolder() string {
+	if m != nil {
+		return m.LockHolder
 	}
 	return ""
 }
 
-func (m *GetNotificationResponse_Notification) GetOld() *PagedVulnerableAncestries {
+func (m *UpdaterStatus) GetLockExpirationTime() *google_protobuf.Timestamp {
 	if m != nil {
-		return m.Old
+		return m.LockExpirationTime
 	}
 	return nil
 }
 
-func (m *GetNotificationResponse_Notification) GetNew() *PagedVulnerableAncestries {
+type GetUpdaterStatusRequest struct {
+}
+
+func (m *GetUpdaterStatusRequest) Reset()                    { *m = GetUpdaterStatusRequest{} }
+func (m *GetUpdaterStatusRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetUpdaterStatusRequest) ProtoMessage()               {}
+func (*GetUpdaterStatusRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+type GetUpdaterStatusResponse struct {
+	// The status of the vulnerability updater.
+	Status *UpdaterStatus `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *GetUpdaterStatusResponse) Reset()                    { *m = GetUpdaterStatusResponse{} }
+func (m *GetUpdaterStatusResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetUpdaterStatusResponse) ProtoMessage()               {}
+func (*GetUpdaterStatusResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+func (m *GetUpdaterStatusResponse) GetStatus() *UpdaterStatus {
 	if m != nil {
-		return m.New
+		return m.Status
 	}
 	return nil
 }
 
-type PagedVulnerableAncestries struct {
-	// The identifier for the current page.
-	CurrentPage string `protobuf:"bytes,1,opt,name=current_page,json=currentPage" json:"current_page,omitempty"`
-	// The token used to request the next page.
-	// This will be empty when there are no more pages.
-	NextPage string `protobuf:"bytes,2,opt,name=next_page,json=nextPage" json:"next_page,omitempty"`
-	// The requested maximum number of results per page.
-	Limit int32 `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
-	// The vulnerability that affects a given set of ancestries.
-	Vulnerability *Vulnerability `protobuf:"bytes,4,opt,name=vulnerability" json:"vulnerability,omitempty"`
-	// The ancestries affected by a vulnerability.
-	Ancestries []*PagedVulnerableAncestries_IndexedAncestryName `protobuf:"bytes,5,rep,name=ancestries" json:"ancestries,omitempty"`
+type UpdateJob struct {
+	// The identifier of the job.
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// The updaters whose vulnerabilities are updated by the job.
+	Updaters []string `protobuf:"bytes,2,rep,name=updaters" json:"updaters,omitempty"`
+	// The progress of the job.
+	State UpdateJob_State `protobuf:"varint,3,opt,name=state,enum=coreos.clair.UpdateJob_State" json:"state,omitempty"`
+	// The error of the job, if it failed.
+	Error string `protobuf:"bytes,4,opt,name=error" json:"error,omitempty"`
+	// The time at which the job was triggered.
+	CreatedTime *google_protobuf.Timestamp `protobuf:"bytes,5,opt,name=created_time,json=createdTime" json:"created_time,omitempty"`
+	// The time at which the job started updating.
+	StartTime *google_protobuf.Timestamp `protobuf:"bytes,6,opt,name=start_time,json=startTime" json:"start_time,omitempty"`
+	// The time at which the job finished.
+	FinishTime *google_protobuf.Timestamp `protobuf:"bytes,7,opt,name=finish_time,json=finishTime" json:"finish_time,omitempty"`
 }
 
-func (m *PagedVulnerableAncestries) Reset()                    { *m = PagedVulnerableAncestries{} }
-func (m *PagedVulnerableAncestries) String() string            { return proto.CompactTextString(m) }
-func (*PagedVulnerableAncestries) ProtoMessage()               {}
-func (*PagedVulnerableAncestries) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (m *UpdateJob) Reset()                    { *m = UpdateJob{} }
+func (m *UpdateJob) String() string            { return proto.CompactTextString(m) }
+func (*UpdateJob) ProtoMessage()               {}
+func (*UpdateJob) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
 
-func (m *PagedVulnerableAncestries) GetCurrentPage() string {
+func (m *UpdateJob) GetId() string {
 	if m != nil {
-		return m.CurrentPage
+		return m.Id
 	}
 	return ""
 }
 
-func (m *PagedVulnerableAncestries) GetNextPage() string {
+func (m *UpdateJob) GetUpdaters() []string {
 	if m != nil {
-		return m.NextPage
+		return m.Updaters
+	}
+	return nil
+}
+
+func (m *UpdateJob) GetState() UpdateJob_State {
+	if m != nil {
+		return m.State
+	}
+	return UpdateJob_UPDATE_JOB_STATE_PENDING
+}
+
+func (m *UpdateJob) GetError() string {
+	if m != nil {
+		return m.Error
 	}
 	return ""
 }
 
-func (m *PagedVulnerableAncestries) GetLimit() int32 {
+func (m *UpdateJob) GetCreatedTime() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.CreatedTime
+	}
+	return nil
+}
+
+func (m *UpdateJob) GetStartTime() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.StartTime
+	}
+	return nil
+}
+
+func (m *UpdateJob) GetFinishTime() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.FinishTime
+	}
+	return nil
+}
+
+type TriggerUpdateRequest struct {
+	// The updaters to run, e.g. "debian". All the enabled updaters are run when
+	// it is empty.
+	Updaters []string `protobuf:"bytes,1,rep,name=updaters" json:"updaters,omitempty"`
+}
+
+func (m *TriggerUpdateRequest) Reset()                    { *m = TriggerUpdateRequest{} }
+func (m *TriggerUpdateRequest) String() string            { return proto.CompactTextString(m) }
+func (*TriggerUpdateRequest) ProtoMessage()               {}
+func (*TriggerUpdateRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+
+func (m *TriggerUpdateRequest) GetUpdaters() []string {
+	if m != nil {
+		return m.Updaters
+	}
+	return nil
+}
+
+type TriggerUpdateResponse struct {
+	// The job running the update.
+	Job *UpdateJob `protobuf:"bytes,1,opt,name=job" json:"job,omitempty"`
+}
+
+func (m *TriggerUpdateResponse) Reset()                    { *m = TriggerUpdateResponse{} }
+func (m *TriggerUpdateResponse) String() string            { return proto.CompactTextString(m) }
+func (*TriggerUpdateResponse) ProtoMessage()               {}
+func (*TriggerUpdateResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{48} }
+
+func (m *TriggerUpdateResponse) GetJob() *UpdateJob {
+	if m != nil {
+		return m.Job
+	}
+	return nil
+}
+
+type GetUpdateJobRequest struct {
+	// The identifier of the job.
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *GetUpdateJobRequest) Reset()                    { *m = GetUpdateJobRequest{} }
+func (m *GetUpdateJobRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetUpdateJobRequest) ProtoMessage()               {}
+func (*GetUpdateJobRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{49} }
+
+func (m *GetUpdateJobRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type GetUpdateJobResponse struct {
+	// The requested job.
+	Job *UpdateJob `protobuf:"bytes,1,opt,name=job" json:"job,omitempty"`
+}
+
+func (m *GetUpdateJobResponse) Reset()                    { *m = GetUpdateJobResponse{} }
+func (m *GetUpdateJobResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetUpdateJobResponse) ProtoMessage()               {}
+func (*GetUpdateJobResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{50} }
+
+func (m *GetUpdateJobResponse) GetJob() *UpdateJob {
+	if m != nil {
+		return m.Job
+	}
+	return nil
+}
+
+type ListNamespacesRequest struct {
+	// The prefix of the names of the requested namespaces, e.g. "ubuntu:".
+	Prefix string `protobuf:"bytes,1,opt,name=prefix" json:"prefix,omitempty"`
+	// The requested maximum number of namespaces per page.
+	Limit int32 `protobuf:"varint,2,opt,name=limit" json:"limit,omitempty"`
+	// The requested page. This will be empty when it is the first page.
+	Page string `protobuf:"bytes,3,opt,name=page" json:"page,omitempty"`
+}
+
+func (m *ListNamespacesRequest) Reset()                    { *m = ListNamespacesRequest{} }
+func (m *ListNamespacesRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListNamespacesRequest) ProtoMessage()               {}
+func (*ListNamespacesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{51} }
+
+func (m *ListNamespacesRequest) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *ListNamespacesRequest) GetLimit() int32 {
 	if m != nil {
 		return m.Limit
 	}
 	return 0
 }
 
-func (m *PagedVulnerableAncestries) GetVulnerability() *Vulnerability {
+func (m *ListNamespacesRequest) GetPage() string {
 	if m != nil {
-		return m.Vulnerability
+		return m.Page
+	}
+	return ""
+}
+
+type ListNamespacesResponse struct {
+	// The namespaces of the page.
+	Namespaces []*Namespace `protobuf:"bytes,1,rep,name=namespaces" json:"namespaces,omitempty"`
+	// The next page. This will be empty when it is the last page.
+	NextPage string `protobuf:"bytes,2,opt,name=next_page,json=nextPage" json:"next_page,omitempty"`
+}
+
+func (m *ListNamespacesResponse) Reset()                    { *m = ListNamespacesResponse{} }
+func (m *ListNamespacesResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListNamespacesResponse) ProtoMessage()               {}
+func (*ListNamespacesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{52} }
+
+func (m *ListNamespacesResponse) GetNamespaces() []*Namespace {
+	if m != nil {
+		return m.Namespaces
 	}
 	return nil
 }
 
-func (m *PagedVulnerableAncestries) GetAncestries() []*PagedVulnerableAncestries_IndexedAncestryName {
+func (m *ListNamespacesResponse) GetNextPage() string {
 	if m != nil {
-		return m.Ancestries
+		return m.NextPage
+	}
+	return ""
+}
+
+type ExportVulnerabilitiesRequest struct {
+	// The name of the namespace of the exported vulnerabilities.
+	NamespaceName string `protobuf:"bytes,1,opt,name=namespace_name,json=namespaceName" json:"namespace_name,omitempty"`
+	// The time after which the exported vulnerabilities were added or changed.
+	// All the vulnerabilities are exported when it is not set.
+	ModifiedSince *google_protobuf.Timestamp `protobuf:"bytes,2,opt,name=modified_since,json=modifiedSince" json:"modified_since,omitempty"`
+	// Whether the vulnerabilities withdrawn by their data source, after
+	// modified_since if it is set, are exported too, after the others.
+	IncludeWithdrawn bool `protobuf:"varint,3,opt,name=include_withdrawn,json=includeWithdrawn" json:"include_withdrawn,omitempty"`
+}
+
+func (m *ExportVulnerabilitiesRequest) Reset()                    { *m = ExportVulnerabilitiesRequest{} }
+func (m *ExportVulnerabilitiesRequest) String() string            { return proto.CompactTextString(m) }
+func (*ExportVulnerabilitiesRequest) ProtoMessage()               {}
+func (*ExportVulnerabilitiesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{53} }
+
+func (m *ExportVulnerabilitiesRequest) GetNamespaceName() string {
+	if m != nil {
+		return m.NamespaceName
+	}
+	return ""
+}
+
+func (m *ExportVulnerabilitiesRequest) GetModifiedSince() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.ModifiedSince
 	}
 	return nil
 }
 
-type PagedVulnerableAncestries_IndexedAncestryName struct {
-	// The index is an ever increasing number associated with the particular ancestry.
-	// This is useful if you're processing notifications, and need to keep track of the progress of paginating the results.
-	Index int32 `protobuf:"varint,1,opt,name=index" json:"index,omitempty"`
-	// The name of the ancestry.
-	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+func (m *ExportVulnerabilitiesRequest) GetIncludeWithdrawn() bool {
+	if m != nil {
+		return m.IncludeWithdrawn
+	}
+	return false
 }
 
-func (m *PagedVulnerableAncestries_IndexedAncestryName) Reset() {
-	*m = PagedVulnerableAncestries_IndexedAncestryName{}
+type ListFeatureLocationsRequest struct {
+	// The name of the feature.
+	FeatureName string `protobuf:"bytes,1,opt,name=feature_name,json=featureName" json:"feature_name,omitempty"`
+	// The version of the feature.
+	FeatureVersion string `protobuf:"bytes,2,opt,name=feature_version,json=featureVersion" json:"feature_version,omitempty"`
+	// The optional name of the namespace of the feature, e.g. "debian:9".
+	NamespaceName string `protobuf:"bytes,3,opt,name=namespace_name,json=namespaceName" json:"namespace_name,omitempty"`
+	// The requested maximum number of locations per page.
+	Limit int32 `protobuf:"varint,4,opt,name=limit" json:"limit,omitempty"`
+	// The requested page. This will be empty when it is the first page.
+	Page string `protobuf:"bytes,5,opt,name=page" json:"page,omitempty"`
 }
-func (m *PagedVulnerableAncestries_IndexedAncestryName) String() string {
-	return proto.CompactTextString(m)
+
+func (m *ListFeatureLocationsRequest) Reset()                    { *m = ListFeatureLocationsRequest{} }
+func (m *ListFeatureLocationsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListFeatureLocationsRequest) ProtoMessage()               {}
+func (*ListFeatureLocationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{54} }
+
+func (m *ListFeatureLocationsRequest) GetFeatureName() string {
+	if m != nil {
+		return m.FeatureName
+	}
+	return ""
+}
+
+func (m *ListFeatureLocationsRequest) GetFeatureVersion() string {
+	if m != nil {
+		return m.FeatureVersion
+	}
+	return ""
 }
-func (*PagedVulnerableAncestries_IndexedAncestryName) ProtoMessage() {}
-func (*PagedVulnerableAncestries_IndexedAncestryName) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{12, 0}
+
+func (m *ListFeatureLocationsRequest) GetNamespaceName() string {
+	if m != nil {
+		return m.NamespaceName
+	}
+	return ""
 }
 
-func (m *PagedVulnerableAncestries_IndexedAncestryName) GetIndex() int32 {
+func (m *ListFeatureLocationsRequest) GetLimit() int32 {
 	if m != nil {
-		return m.Index
+		return m.Limit
 	}
 	return 0
 }
 
-func (m *PagedVulnerableAncestries_IndexedAncestryName) GetName() string {
+func (m *ListFeatureLocationsRequest) GetPage() string {
 	if m != nil {
-		return m.Name
+		return m.Page
 	}
 	return ""
 }
 
-type MarkNotificationAsReadRequest struct {
-	// The name of the Notification that has been processed.
-	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+type ListFeatureLocationsResponse struct {
+	// The locations of the page.
+	Locations []*ListFeatureLocationsResponse_FeatureLocation `protobuf:"bytes,1,rep,name=locations" json:"locations,omitempty"`
+	// The next page. This will be empty when it is the last page.
+	NextPage string `protobuf:"bytes,2,opt,name=next_page,json=nextPage" json:"next_page,omitempty"`
 }
 
-func (m *MarkNotificationAsReadRequest) Reset()                    { *m = MarkNotificationAsReadRequest{} }
-func (m *MarkNotificationAsReadRequest) String() string            { return proto.CompactTextString(m) }
-func (*MarkNotificationAsReadRequest) ProtoMessage()               {}
-func (*MarkNotificationAsReadRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (m *ListFeatureLocationsResponse) Reset()                    { *m = ListFeatureLocationsResponse{} }
+func (m *ListFeatureLocationsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListFeatureLocationsResponse) ProtoMessage()               {}
+func (*ListFeatureLocationsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{55} }
 
-func (m *MarkNotificationAsReadRequest) GetName() string {
+func (m *ListFeatureLocationsResponse) GetLocations() []*ListFeatureLocationsResponse_FeatureLocation {
 	if m != nil {
-		return m.Name
+		return m.Locations
 	}
-	return ""
+	return nil
 }
 
-type MarkNotificationAsReadResponse struct {
+func (m *ListFeatureLocationsResponse) GetNextPage() string {
+	if m != nil {
+		return m.NextPage
+	}
+	return ""
 }
 
-func (m *MarkNotificationAsReadResponse) Reset()                    { *m = MarkNotificationAsReadResponse{} }
-func (m *MarkNotificationAsReadResponse) String() string            { return proto.CompactTextString(m) }
-func (*MarkNotificationAsReadResponse) ProtoMessage()               {}
-func (*MarkNotificationAsReadResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
-
-type GetStatusRequest struct {
+type ListFeatureLocationsResponse_FeatureLocation struct {
+	// The name of the ancestry containing the feature.
+	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+	// The hash of the layer of the ancestry that introduced the feature.
+	LayerHash string `protobuf:"bytes,2,opt,name=layer_hash,json=layerHash" json:"layer_hash,omitempty"`
+	// The feature, with its namespace.
+	Feature *Feature `protobuf:"bytes,3,opt,name=feature" json:"feature,omitempty"`
 }
 
-func (m *GetStatusRequest) Reset()                    { *m = GetStatusRequest{} }
-func (m *GetStatusRequest) String() string            { return proto.CompactTextString(m) }
-func (*GetStatusRequest) ProtoMessage()               {}
-func (*GetStatusRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+func (m *ListFeatureLocationsResponse_FeatureLocation) Reset() {
+	*m = ListFeatureLocationsResponse_FeatureLocation{}
+}
+func (m *ListFeatureLocationsResponse_FeatureLocation) String() string {
+	return proto.CompactTextString(m)
+}
+func (*ListFeatureLocationsResponse_FeatureLocation) ProtoMessage() {}
+func (*ListFeatureLocationsResponse_FeatureLocation) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{55, 0}
+}
 
-type GetStatusResponse struct {
-	// The status of the current Clair instance.
-	Status *ClairStatus `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+func (m *ListFeatureLocationsResponse_FeatureLocation) GetAncestryName() string {
+	if m != nil {
+		return m.AncestryName
+	}
+	return ""
 }
 
-func (m *GetStatusResponse) Reset()                    { *m = GetStatusResponse{} }
-func (m *GetStatusResponse) String() string            { return proto.CompactTextString(m) }
-func (*GetStatusResponse) ProtoMessage()               {}
-func (*GetStatusResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+func (m *ListFeatureLocationsResponse_FeatureLocation) GetLayerHash() string {
+	if m != nil {
+		return m.LayerHash
+	}
+	return ""
+}
 
-func (m *GetStatusResponse) GetStatus() *ClairStatus {
+func (m *ListFeatureLocationsResponse_FeatureLocation) GetFeature() *Feature {
 	if m != nil {
-		return m.Status
+		return m.Feature
 	}
 	return nil
 }
 
 func init() {
 	proto.RegisterType((*Vulnerability)(nil), "coreos.clair.Vulnerability")
+	proto.RegisterType((*Vulnerability_Withdrawal)(nil), "coreos.clair.Vulnerability.Withdrawal")
+	proto.RegisterType((*Vulnerability_AffectedRange)(nil), "coreos.clair.Vulnerability.AffectedRange")
 	proto.RegisterType((*Detector)(nil), "coreos.clair.Detector")
 	proto.RegisterType((*Namespace)(nil), "coreos.clair.Namespace")
 	proto.RegisterType((*Feature)(nil), "coreos.clair.Feature")
 	proto.RegisterType((*Layer)(nil), "coreos.clair.Layer")
 	proto.RegisterType((*ClairStatus)(nil), "coreos.clair.ClairStatus")
 	proto.RegisterType((*GetAncestryRequest)(nil), "coreos.clair.GetAncestryRequest")
+	proto.RegisterType((*GetAncestrySBOMRequest)(nil), "coreos.clair.GetAncestrySBOMRequest")
+	proto.RegisterType((*GetAncestrySBOMResponse)(nil), "coreos.clair.GetAncestrySBOMResponse")
 	proto.RegisterType((*GetAncestryResponse)(nil), "coreos.clair.GetAncestryResponse")
 	proto.RegisterType((*GetAncestryResponse_AncestryLayer)(nil), "coreos.clair.GetAncestryResponse.AncestryLayer")
 	proto.RegisterType((*GetAncestryResponse_Ancestry)(nil), "coreos.clair.GetAncestryResponse.Ancestry")
+	proto.RegisterType((*StreamAncestryRequest)(nil), "coreos.clair.StreamAncestryRequest")
+	proto.RegisterType((*StreamAncestryResponse)(nil), "coreos.clair.StreamAncestryResponse")
+	proto.RegisterType((*CompareAncestriesRequest)(nil), "coreos.clair.CompareAncestriesRequest")
+	proto.RegisterType((*CompareAncestriesResponse)(nil), "coreos.clair.CompareAncestriesResponse")
+	proto.RegisterType((*CompareAncestriesResponse_SeverityDiff)(nil), "coreos.clair.CompareAncestriesResponse.SeverityDiff")
+	proto.RegisterType((*GetAncestrySummaryRequest)(nil), "coreos.clair.GetAncestrySummaryRequest")
+	proto.RegisterType((*GetAncestrySummaryResponse)(nil), "coreos.clair.GetAncestrySummaryResponse")
+	proto.RegisterType((*GetAncestrySummaryResponse_SeverityCount)(nil), "coreos.clair.GetAncestrySummaryResponse.SeverityCount")
 	proto.RegisterType((*PostAncestryRequest)(nil), "coreos.clair.PostAncestryRequest")
 	proto.RegisterType((*PostAncestryRequest_PostLayer)(nil), "coreos.clair.PostAncestryRequest.PostLayer")
+	proto.RegisterType((*PostAncestryRequest_Registry)(nil), "coreos.clair.PostAncestryRequest.Registry")
+	proto.RegisterType((*PostAncestryRequest_ObjectStorage)(nil), "coreos.clair.PostAncestryRequest.ObjectStorage")
 	proto.RegisterType((*PostAncestryResponse)(nil), "coreos.clair.PostAncestryResponse")
+	proto.RegisterType((*PostLayersRequest)(nil), "coreos.clair.PostLayersRequest")
+	proto.RegisterType((*PostLayersResponse)(nil), "coreos.clair.PostLayersResponse")
+	proto.RegisterType((*PostLayersResponse_LayerResult)(nil), "coreos.clair.PostLayersResponse.LayerResult")
+	proto.RegisterType((*PostImageRequest)(nil), "coreos.clair.PostImageRequest")
+	proto.RegisterType((*PostImageResponse)(nil), "coreos.clair.PostImageResponse")
+	proto.RegisterType((*GetLayerRequest)(nil), "coreos.clair.GetLayerRequest")
+	proto.RegisterType((*GetLayerResponse)(nil), "coreos.clair.GetLayerResponse")
+	proto.RegisterType((*DeleteAncestryRequest)(nil), "coreos.clair.DeleteAncestryRequest")
+	proto.RegisterType((*DeleteAncestryResponse)(nil), "coreos.clair.DeleteAncestryResponse")
+	proto.RegisterType((*DeleteLayerRequest)(nil), "coreos.clair.DeleteLayerRequest")
+	proto.RegisterType((*DeleteLayerResponse)(nil), "coreos.clair.DeleteLayerResponse")
+	proto.RegisterType((*PruneAnalysesRequest)(nil), "coreos.clair.PruneAnalysesRequest")
+	proto.RegisterType((*PruneAnalysesResponse)(nil), "coreos.clair.PruneAnalysesResponse")
 	proto.RegisterType((*GetNotificationRequest)(nil), "coreos.clair.GetNotificationRequest")
 	proto.RegisterType((*GetNotificationResponse)(nil), "coreos.clair.GetNotificationResponse")
 	proto.RegisterType((*GetNotificationResponse_Notification)(nil), "coreos.clair.GetNotificationResponse.Notification")
@@ -807,9 +2755,33 @@ func init() {
 	proto.RegisterType((*PagedVulnerableAncestries_IndexedAncestryName)(nil), "coreos.clair.PagedVulnerableAncestries.IndexedAncestryName")
 	proto.RegisterType((*MarkNotificationAsReadRequest)(nil), "coreos.clair.MarkNotificationAsReadRequest")
 	proto.RegisterType((*MarkNotificationAsReadResponse)(nil), "coreos.clair.MarkNotificationAsReadResponse")
+	proto.RegisterType((*ListPendingNotificationsRequest)(nil), "coreos.clair.ListPendingNotificationsRequest")
+	proto.RegisterType((*ListPendingNotificationsResponse)(nil), "coreos.clair.ListPendingNotificationsResponse")
+	proto.RegisterType((*ListPendingNotificationsResponse_PendingNotification)(nil), "coreos.clair.ListPendingNotificationsResponse.PendingNotification")
+	proto.RegisterType((*ResolveNotificationRequest)(nil), "coreos.clair.ResolveNotificationRequest")
+	proto.RegisterType((*ResolveNotificationResponse)(nil), "coreos.clair.ResolveNotificationResponse")
+	proto.RegisterType((*FlushNotificationsRequest)(nil), "coreos.clair.FlushNotificationsRequest")
+	proto.RegisterType((*FlushNotificationsResponse)(nil), "coreos.clair.FlushNotificationsResponse")
 	proto.RegisterType((*GetStatusRequest)(nil), "coreos.clair.GetStatusRequest")
 	proto.RegisterType((*GetStatusResponse)(nil), "coreos.clair.GetStatusResponse")
+	proto.RegisterType((*UpdaterStatus)(nil), "coreos.clair.UpdaterStatus")
+	proto.RegisterType((*GetUpdaterStatusRequest)(nil), "coreos.clair.GetUpdaterStatusRequest")
+	proto.RegisterType((*GetUpdaterStatusResponse)(nil), "coreos.clair.GetUpdaterStatusResponse")
+	proto.RegisterType((*UpdateJob)(nil), "coreos.clair.UpdateJob")
+	proto.RegisterType((*TriggerUpdateRequest)(nil), "coreos.clair.TriggerUpdateRequest")
+	proto.RegisterType((*TriggerUpdateResponse)(nil), "coreos.clair.TriggerUpdateResponse")
+	proto.RegisterType((*GetUpdateJobRequest)(nil), "coreos.clair.GetUpdateJobRequest")
+	proto.RegisterType((*GetUpdateJobResponse)(nil), "coreos.clair.GetUpdateJobResponse")
+	proto.RegisterType((*ListNamespacesRequest)(nil), "coreos.clair.ListNamespacesRequest")
+	proto.RegisterType((*ListNamespacesResponse)(nil), "coreos.clair.ListNamespacesResponse")
+	proto.RegisterType((*ExportVulnerabilitiesRequest)(nil), "coreos.clair.ExportVulnerabilitiesRequest")
+	proto.RegisterType((*ListFeatureLocationsRequest)(nil), "coreos.clair.ListFeatureLocationsRequest")
+	proto.RegisterType((*ListFeatureLocationsResponse)(nil), "coreos.clair.ListFeatureLocationsResponse")
+	proto.RegisterType((*ListFeatureLocationsResponse_FeatureLocation)(nil), "coreos.clair.ListFeatureLocationsResponse.FeatureLocation")
 	proto.RegisterEnum("coreos.clair.Detector_DType", Detector_DType_name, Detector_DType_value)
+	proto.RegisterEnum("coreos.clair.ResolveNotificationRequest_Resolution", ResolveNotificationRequest_Resolution_name, ResolveNotificationRequest_Resolution_value)
+	proto.RegisterEnum("coreos.clair.UpdaterStatus_Result", UpdaterStatus_Result_name, UpdaterStatus_Result_value)
+	proto.RegisterEnum("coreos.clair.UpdateJob_State", UpdateJob_State_name, UpdateJob_State_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -825,8 +2797,38 @@ const _ = grpc.SupportPackageIsVersion4
 type AncestryServiceClient interface {
 	// The RPC used to read the results of scanning for a particular ancestry.
 	GetAncestry(ctx context.Context, in *GetAncestryRequest, opts ...grpc.CallOption) (*GetAncestryResponse, error)
+	// The RPC used to stream the results of scanning for a large ancestry, a
+	// bounded number of features with their vulnerabilities at a time, in the
+	// order of its layers.
+	StreamAncestry(ctx context.Context, in *StreamAncestryRequest, opts ...grpc.CallOption) (AncestryService_StreamAncestryClient, error)
 	// The RPC used to create a new scan of an ancestry.
 	PostAncestry(ctx context.Context, in *PostAncestryRequest, opts ...grpc.CallOption) (*PostAncestryResponse, error)
+	// The RPC used to scan a list of layers in a single request.
+	PostLayers(ctx context.Context, in *PostLayersRequest, opts ...grpc.CallOption) (*PostLayersResponse, error)
+	// The RPC used to read the result of the scan of a layer, e.g. to find
+	// whether it should be scanned again after an upgrade.
+	GetLayer(ctx context.Context, in *GetLayerRequest, opts ...grpc.CallOption) (*GetLayerResponse, error)
+	// The RPC used to scan a squashed image, made of a single layer without
+	// parent, and read its features and vulnerabilities in a single request.
+	PostImage(ctx context.Context, in *PostImageRequest, opts ...grpc.CallOption) (*PostImageResponse, error)
+	// The RPC used to read the results of scanning for a particular ancestry as
+	// a software bill of materials, which is the body of the REST responses.
+	GetAncestrySBOM(ctx context.Context, in *GetAncestrySBOMRequest, opts ...grpc.CallOption) (*GetAncestrySBOMResponse, error)
+	// The RPC used to compare the vulnerabilities of an ancestry with those of a
+	// base ancestry, e.g. the previous version of an image.
+	CompareAncestries(ctx context.Context, in *CompareAncestriesRequest, opts ...grpc.CallOption) (*CompareAncestriesResponse, error)
+	// The RPC used to count the vulnerabilities of an ancestry by severity,
+	// without reading them, e.g. for the overviews of many images.
+	GetAncestrySummary(ctx context.Context, in *GetAncestrySummaryRequest, opts ...grpc.CallOption) (*GetAncestrySummaryResponse, error)
+	// The RPC used to delete the result of the scan of an ancestry, whose layers
+	// are kept.
+	DeleteAncestry(ctx context.Context, in *DeleteAncestryRequest, opts ...grpc.CallOption) (*DeleteAncestryResponse, error)
+	// The RPC used to delete the result of the scan of a layer, which fails
+	// while an ancestry contains it.
+	DeleteLayer(ctx context.Context, in *DeleteLayerRequest, opts ...grpc.CallOption) (*DeleteLayerResponse, error)
+	// The RPC used to delete the results of the scans older than a number of
+	// days, e.g. to enforce a retention policy.
+	PruneAnalyses(ctx context.Context, in *PruneAnalysesRequest, opts ...grpc.CallOption) (*PruneAnalysesResponse, error)
 }
 
 type ancestryServiceClient struct {
@@ -846,6 +2848,38 @@ func (c *ancestryServiceClient) GetAncestry(ctx context.Context, in *GetAncestry
 	return out, nil
 }
 
+func (c *ancestryServiceClient) StreamAncestry(ctx context.Context, in *StreamAncestryRequest, opts ...grpc.CallOption) (AncestryService_StreamAncestryClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_AncestryService_serviceDesc.Streams[0], c.cc, "/coreos.clair.AncestryService/StreamAncestry", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ancestryServiceStreamAncestryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AncestryService_StreamAncestryClient interface {
+	Recv() (*StreamAncestryResponse, error)
+	grpc.ClientStream
+}
+
+type ancestryServiceStreamAncestryClient struct {
+	grpc.ClientStream
+}
+
+func (x *ancestryServiceStreamAncestryClient) Recv() (*StreamAncestryResponse, error) {
+	m := new(StreamAncestryResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *ancestryServiceClient) PostAncestry(ctx context.Context, in *PostAncestryRequest, opts ...grpc.CallOption) (*PostAncestryResponse, error) {
 	out := new(PostAncestryResponse)
 	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/PostAncestry", in, out, c.cc, opts...)
@@ -855,13 +2889,124 @@ func (c *ancestryServiceClient) PostAncestry(ctx context.Context, in *PostAncest
 	return out, nil
 }
 
+func (c *ancestryServiceClient) PostLayers(ctx context.Context, in *PostLayersRequest, opts ...grpc.CallOption) (*PostLayersResponse, error) {
+	out := new(PostLayersResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/PostLayers", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) GetLayer(ctx context.Context, in *GetLayerRequest, opts ...grpc.CallOption) (*GetLayerResponse, error) {
+	out := new(GetLayerResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/GetLayer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) PostImage(ctx context.Context, in *PostImageRequest, opts ...grpc.CallOption) (*PostImageResponse, error) {
+	out := new(PostImageResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/PostImage", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) GetAncestrySBOM(ctx context.Context, in *GetAncestrySBOMRequest, opts ...grpc.CallOption) (*GetAncestrySBOMResponse, error) {
+	out := new(GetAncestrySBOMResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/GetAncestrySBOM", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) CompareAncestries(ctx context.Context, in *CompareAncestriesRequest, opts ...grpc.CallOption) (*CompareAncestriesResponse, error) {
+	out := new(CompareAncestriesResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/CompareAncestries", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) GetAncestrySummary(ctx context.Context, in *GetAncestrySummaryRequest, opts ...grpc.CallOption) (*GetAncestrySummaryResponse, error) {
+	out := new(GetAncestrySummaryResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/GetAncestrySummary", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) DeleteAncestry(ctx context.Context, in *DeleteAncestryRequest, opts ...grpc.CallOption) (*DeleteAncestryResponse, error) {
+	out := new(DeleteAncestryResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/DeleteAncestry", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) DeleteLayer(ctx context.Context, in *DeleteLayerRequest, opts ...grpc.CallOption) (*DeleteLayerResponse, error) {
+	out := new(DeleteLayerResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/DeleteLayer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) PruneAnalyses(ctx context.Context, in *PruneAnalysesRequest, opts ...grpc.CallOption) (*PruneAnalysesResponse, error) {
+	out := new(PruneAnalysesResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/PruneAnalyses", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for AncestryService service
 
 type AncestryServiceServer interface {
 	// The RPC used to read the results of scanning for a particular ancestry.
 	GetAncestry(context.Context, *GetAncestryRequest) (*GetAncestryResponse, error)
+	// The RPC used to stream the results of scanning for a large ancestry, a
+	// bounded number of features with their vulnerabilities at a time, in the
+	// order of its layers.
+	StreamAncestry(*StreamAncestryRequest, AncestryService_StreamAncestryServer) error
 	// The RPC used to create a new scan of an ancestry.
 	PostAncestry(context.Context, *PostAncestryRequest) (*PostAncestryResponse, error)
+	// The RPC used to scan a list of layers in a single request.
+	PostLayers(context.Context, *PostLayersRequest) (*PostLayersResponse, error)
+	// The RPC used to read the result of the scan of a layer, e.g. to find
+	// whether it should be scanned again after an upgrade.
+	GetLayer(context.Context, *GetLayerRequest) (*GetLayerResponse, error)
+	// The RPC used to scan a squashed image, made of a single layer without
+	// parent, and read its features and vulnerabilities in a single request.
+	PostImage(context.Context, *PostImageRequest) (*PostImageResponse, error)
+	// The RPC used to read the results of scanning for a particular ancestry as
+	// a software bill of materials, which is the body of the REST responses.
+	GetAncestrySBOM(context.Context, *GetAncestrySBOMRequest) (*GetAncestrySBOMResponse, error)
+	// The RPC used to compare the vulnerabilities of an ancestry with those of a
+	// base ancestry, e.g. the previous version of an image.
+	CompareAncestries(context.Context, *CompareAncestriesRequest) (*CompareAncestriesResponse, error)
+	// The RPC used to count the vulnerabilities of an ancestry by severity,
+	// without reading them, e.g. for the overviews of many images.
+	GetAncestrySummary(context.Context, *GetAncestrySummaryRequest) (*GetAncestrySummaryResponse, error)
+	// The RPC used to delete the result of the scan of an ancestry, whose layers
+	// are kept.
+	DeleteAncestry(context.Context, *DeleteAncestryRequest) (*DeleteAncestryResponse, error)
+	// The RPC used to delete the result of the scan of a layer, which fails
+	// while an ancestry contains it.
+	DeleteLayer(context.Context, *DeleteLayerRequest) (*DeleteLayerResponse, error)
+	// The RPC used to delete the results of the scans older than a number of
+	// days, e.g. to enforce a retention policy.
+	PruneAnalyses(context.Context, *PruneAnalysesRequest) (*PruneAnalysesResponse, error)
 }
 
 func RegisterAncestryServiceServer(s *grpc.Server, srv AncestryServiceServer) {
@@ -886,6 +3031,27 @@ func _AncestryService_GetAncestry_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AncestryService_StreamAncestry_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamAncestryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AncestryServiceServer).StreamAncestry(m, &ancestryServiceStreamAncestryServer{stream})
+}
+
+type AncestryService_StreamAncestryServer interface {
+	Send(*StreamAncestryResponse) error
+	grpc.ServerStream
+}
+
+type ancestryServiceStreamAncestryServer struct {
+	grpc.ServerStream
+}
+
+func (x *ancestryServiceStreamAncestryServer) Send(m *StreamAncestryResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _AncestryService_PostAncestry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PostAncestryRequest)
 	if err := dec(in); err != nil {
@@ -904,6 +3070,168 @@ func _AncestryService_PostAncestry_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AncestryService_PostLayers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostLayersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).PostLayers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/PostLayers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).PostLayers(ctx, req.(*PostLayersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_GetLayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLayerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).GetLayer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/GetLayer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).GetLayer(ctx, req.(*GetLayerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_PostImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).PostImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/PostImage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).PostImage(ctx, req.(*PostImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_GetAncestrySBOM_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAncestrySBOMRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).GetAncestrySBOM(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/GetAncestrySBOM",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).GetAncestrySBOM(ctx, req.(*GetAncestrySBOMRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_CompareAncestries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareAncestriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).CompareAncestries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/CompareAncestries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).CompareAncestries(ctx, req.(*CompareAncestriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_GetAncestrySummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAncestrySummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).GetAncestrySummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/GetAncestrySummary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).GetAncestrySummary(ctx, req.(*GetAncestrySummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_DeleteAncestry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAncestryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).DeleteAncestry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/DeleteAncestry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).DeleteAncestry(ctx, req.(*DeleteAncestryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_DeleteLayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteLayerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).DeleteLayer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/DeleteLayer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).DeleteLayer(ctx, req.(*DeleteLayerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_PruneAnalyses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PruneAnalysesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).PruneAnalyses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/PruneAnalyses",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).PruneAnalyses(ctx, req.(*PruneAnalysesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _AncestryService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "coreos.clair.AncestryService",
 	HandlerType: (*AncestryServiceServer)(nil),
@@ -916,8 +3244,50 @@ var _AncestryService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "PostAncestry",
 			Handler:    _AncestryService_PostAncestry_Handler,
 		},
+		{
+			MethodName: "PostLayers",
+			Handler:    _AncestryService_PostLayers_Handler,
+		},
+		{
+			MethodName: "GetLayer",
+			Handler:    _AncestryService_GetLayer_Handler,
+		},
+		{
+			MethodName: "PostImage",
+			Handler:    _AncestryService_PostImage_Handler,
+		},
+		{
+			MethodName: "GetAncestrySBOM",
+			Handler:    _AncestryService_GetAncestrySBOM_Handler,
+		},
+		{
+			MethodName: "CompareAncestries",
+			Handler:    _AncestryService_CompareAncestries_Handler,
+		},
+		{
+			MethodName: "GetAncestrySummary",
+			Handler:    _AncestryService_GetAncestrySummary_Handler,
+		},
+		{
+			MethodName: "DeleteAncestry",
+			Handler:    _AncestryService_DeleteAncestry_Handler,
+		},
+		{
+			MethodName: "DeleteLayer",
+			Handler:    _AncestryService_DeleteLayer_Handler,
+		},
+		{
+			MethodName: "PruneAnalyses",
+			Handler:    _AncestryService_PruneAnalyses_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamAncestry",
+			Handler:       _AncestryService_StreamAncestry_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/v3/clairpb/clair.proto",
 }
 
@@ -928,6 +3298,12 @@ type NotificationServiceClient interface {
 	GetNotification(ctx context.Context, in *GetNotificationRequest, opts ...grpc.CallOption) (*GetNotificationResponse, error)
 	// The RPC used to mark a Notification as read after it has been processed.
 	MarkNotificationAsRead(ctx context.Context, in *MarkNotificationAsReadRequest, opts ...grpc.CallOption) (*MarkNotificationAsReadResponse, error)
+	// The RPC used to list the notifications that have not been sent yet.
+	ListPendingNotifications(ctx context.Context, in *ListPendingNotificationsRequest, opts ...grpc.CallOption) (*ListPendingNotificationsResponse, error)
+	// The RPC used to resolve a pending notification without sending it.
+	ResolveNotification(ctx context.Context, in *ResolveNotificationRequest, opts ...grpc.CallOption) (*ResolveNotificationResponse, error)
+	// The RPC used to discard the pending notifications older than an age.
+	FlushNotifications(ctx context.Context, in *FlushNotificationsRequest, opts ...grpc.CallOption) (*FlushNotificationsResponse, error)
 }
 
 type notificationServiceClient struct {
@@ -956,6 +3332,33 @@ func (c *notificationServiceClient) MarkNotificationAsRead(ctx context.Context,
 	return out, nil
 }
 
+func (c *notificationServiceClient) ListPendingNotifications(ctx context.Context, in *ListPendingNotificationsRequest, opts ...grpc.CallOption) (*ListPendingNotificationsResponse, error) {
+	out := new(ListPendingNotificationsResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.NotificationService/ListPendingNotifications", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) ResolveNotification(ctx context.Context, in *ResolveNotificationRequest, opts ...grpc.CallOption) (*ResolveNotificationResponse, error) {
+	out := new(ResolveNotificationResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.NotificationService/ResolveNotification", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) FlushNotifications(ctx context.Context, in *FlushNotificationsRequest, opts ...grpc.CallOption) (*FlushNotificationsResponse, error) {
+	out := new(FlushNotificationsResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.NotificationService/FlushNotifications", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for NotificationService service
 
 type NotificationServiceServer interface {
@@ -963,6 +3366,12 @@ type NotificationServiceServer interface {
 	GetNotification(context.Context, *GetNotificationRequest) (*GetNotificationResponse, error)
 	// The RPC used to mark a Notification as read after it has been processed.
 	MarkNotificationAsRead(context.Context, *MarkNotificationAsReadRequest) (*MarkNotificationAsReadResponse, error)
+	// The RPC used to list the notifications that have not been sent yet.
+	ListPendingNotifications(context.Context, *ListPendingNotificationsRequest) (*ListPendingNotificationsResponse, error)
+	// The RPC used to resolve a pending notification without sending it.
+	ResolveNotification(context.Context, *ResolveNotificationRequest) (*ResolveNotificationResponse, error)
+	// The RPC used to discard the pending notifications older than an age.
+	FlushNotifications(context.Context, *FlushNotificationsRequest) (*FlushNotificationsResponse, error)
 }
 
 func RegisterNotificationServiceServer(s *grpc.Server, srv NotificationServiceServer) {
@@ -1005,6 +3414,60 @@ func _NotificationService_MarkNotificationAsRead_Handler(srv interface{}, ctx co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _NotificationService_ListPendingNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPendingNotificationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).ListPendingNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.NotificationService/ListPendingNotifications",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).ListPendingNotifications(ctx, req.(*ListPendingNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_ResolveNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveNotificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).ResolveNotification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.NotificationService/ResolveNotification",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).ResolveNotification(ctx, req.(*ResolveNotificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_FlushNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlushNotificationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).FlushNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.NotificationService/FlushNotifications",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).FlushNotifications(ctx, req.(*FlushNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _NotificationService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "coreos.clair.NotificationService",
 	HandlerType: (*NotificationServiceServer)(nil),
@@ -1017,6 +3480,215 @@ var _NotificationService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "MarkNotificationAsRead",
 			Handler:    _NotificationService_MarkNotificationAsRead_Handler,
 		},
+		{
+			MethodName: "ListPendingNotifications",
+			Handler:    _NotificationService_ListPendingNotifications_Handler,
+		},
+		{
+			MethodName: "ResolveNotification",
+			Handler:    _NotificationService_ResolveNotification_Handler,
+		},
+		{
+			MethodName: "FlushNotifications",
+			Handler:    _NotificationService_FlushNotifications_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/v3/clairpb/clair.proto",
+}
+
+// Client API for NamespaceService service
+
+type NamespaceServiceClient interface {
+	// The RPC used to list the namespaces known by Clair.
+	ListNamespaces(ctx context.Context, in *ListNamespacesRequest, opts ...grpc.CallOption) (*ListNamespacesResponse, error)
+	// The RPC used to stream all the vulnerabilities of a namespace, with their
+	// affected features.
+	ExportVulnerabilities(ctx context.Context, in *ExportVulnerabilitiesRequest, opts ...grpc.CallOption) (NamespaceService_ExportVulnerabilitiesClient, error)
+}
+
+type namespaceServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewNamespaceServiceClient(cc *grpc.ClientConn) NamespaceServiceClient {
+	return &namespaceServiceClient{cc}
+}
+
+func (c *namespaceServiceClient) ListNamespaces(ctx context.Context, in *ListNamespacesRequest, opts ...grpc.CallOption) (*ListNamespacesResponse, error) {
+	out := new(ListNamespacesResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.NamespaceService/ListNamespaces", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *namespaceServiceClient) ExportVulnerabilities(ctx context.Context, in *ExportVulnerabilitiesRequest, opts ...grpc.CallOption) (NamespaceService_ExportVulnerabilitiesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_NamespaceService_serviceDesc.Streams[0], c.cc, "/coreos.clair.NamespaceService/ExportVulnerabilities", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &namespaceServiceExportVulnerabilitiesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NamespaceService_ExportVulnerabilitiesClient interface {
+	Recv() (*Vulnerability, error)
+	grpc.ClientStream
+}
+
+type namespaceServiceExportVulnerabilitiesClient struct {
+	grpc.ClientStream
+}
+
+func (x *namespaceServiceExportVulnerabilitiesClient) Recv() (*Vulnerability, error) {
+	m := new(Vulnerability)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for NamespaceService service
+
+type NamespaceServiceServer interface {
+	// The RPC used to list the namespaces known by Clair.
+	ListNamespaces(context.Context, *ListNamespacesRequest) (*ListNamespacesResponse, error)
+	// The RPC used to stream all the vulnerabilities of a namespace, with their
+	// affected features.
+	ExportVulnerabilities(*ExportVulnerabilitiesRequest, NamespaceService_ExportVulnerabilitiesServer) error
+}
+
+func RegisterNamespaceServiceServer(s *grpc.Server, srv NamespaceServiceServer) {
+	s.RegisterService(&_NamespaceService_serviceDesc, srv)
+}
+
+func _NamespaceService_ListNamespaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNamespacesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NamespaceServiceServer).ListNamespaces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.NamespaceService/ListNamespaces",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NamespaceServiceServer).ListNamespaces(ctx, req.(*ListNamespacesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NamespaceService_ExportVulnerabilities_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportVulnerabilitiesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NamespaceServiceServer).ExportVulnerabilities(m, &namespaceServiceExportVulnerabilitiesServer{stream})
+}
+
+type NamespaceService_ExportVulnerabilitiesServer interface {
+	Send(*Vulnerability) error
+	grpc.ServerStream
+}
+
+type namespaceServiceExportVulnerabilitiesServer struct {
+	grpc.ServerStream
+}
+
+func (x *namespaceServiceExportVulnerabilitiesServer) Send(m *Vulnerability) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _NamespaceService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "coreos.clair.NamespaceService",
+	HandlerType: (*NamespaceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListNamespaces",
+			Handler:    _NamespaceService_ListNamespaces_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportVulnerabilities",
+			Handler:       _NamespaceService_ExportVulnerabilities_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/v3/clairpb/clair.proto",
+}
+
+// Client API for FeatureService service
+
+type FeatureServiceClient interface {
+	// The RPC used to find the ancestries and layers containing a feature.
+	ListFeatureLocations(ctx context.Context, in *ListFeatureLocationsRequest, opts ...grpc.CallOption) (*ListFeatureLocationsResponse, error)
+}
+
+type featureServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewFeatureServiceClient(cc *grpc.ClientConn) FeatureServiceClient {
+	return &featureServiceClient{cc}
+}
+
+func (c *featureServiceClient) ListFeatureLocations(ctx context.Context, in *ListFeatureLocationsRequest, opts ...grpc.CallOption) (*ListFeatureLocationsResponse, error) {
+	out := new(ListFeatureLocationsResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.FeatureService/ListFeatureLocations", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for FeatureService service
+
+type FeatureServiceServer interface {
+	// The RPC used to find the ancestries and layers containing a feature.
+	ListFeatureLocations(context.Context, *ListFeatureLocationsRequest) (*ListFeatureLocationsResponse, error)
+}
+
+func RegisterFeatureServiceServer(s *grpc.Server, srv FeatureServiceServer) {
+	s.RegisterService(&_FeatureService_serviceDesc, srv)
+}
+
+func _FeatureService_ListFeatureLocations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFeatureLocationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServiceServer).ListFeatureLocations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.FeatureService/ListFeatureLocations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServiceServer).ListFeatureLocations(ctx, req.(*ListFeatureLocationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _FeatureService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "coreos.clair.FeatureService",
+	HandlerType: (*FeatureServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListFeatureLocations",
+			Handler:    _FeatureService_ListFeatureLocations_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/v3/clairpb/clair.proto",
@@ -1027,6 +3699,12 @@ var _NotificationService_serviceDesc = grpc.ServiceDesc{
 type StatusServiceClient interface {
 	// The RPC used to show the internal state of current Clair instance.
 	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+	// The RPC used to show the state of the vulnerability updater.
+	GetUpdaterStatus(ctx context.Context, in *GetUpdaterStatusRequest, opts ...grpc.CallOption) (*GetUpdaterStatusResponse, error)
+	// The RPC used to update the vulnerabilities immediately, in the background.
+	TriggerUpdate(ctx context.Context, in *TriggerUpdateRequest, opts ...grpc.CallOption) (*TriggerUpdateResponse, error)
+	// The RPC used to show the progress of an update triggered on demand.
+	GetUpdateJob(ctx context.Context, in *GetUpdateJobRequest, opts ...grpc.CallOption) (*GetUpdateJobResponse, error)
 }
 
 type statusServiceClient struct {
@@ -1046,11 +3724,44 @@ func (c *statusServiceClient) GetStatus(ctx context.Context, in *GetStatusReques
 	return out, nil
 }
 
+func (c *statusServiceClient) GetUpdaterStatus(ctx context.Context, in *GetUpdaterStatusRequest, opts ...grpc.CallOption) (*GetUpdaterStatusResponse, error) {
+	out := new(GetUpdaterStatusResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.StatusService/GetUpdaterStatus", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statusServiceClient) TriggerUpdate(ctx context.Context, in *TriggerUpdateRequest, opts ...grpc.CallOption) (*TriggerUpdateResponse, error) {
+	out := new(TriggerUpdateResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.StatusService/TriggerUpdate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statusServiceClient) GetUpdateJob(ctx context.Context, in *GetUpdateJobRequest, opts ...grpc.CallOption) (*GetUpdateJobResponse, error) {
+	out := new(GetUpdateJobResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.StatusService/GetUpdateJob", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for StatusService service
 
 type StatusServiceServer interface {
 	// The RPC used to show the internal state of current Clair instance.
 	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+	// The RPC used to show the state of the vulnerability updater.
+	GetUpdaterStatus(context.Context, *GetUpdaterStatusRequest) (*GetUpdaterStatusResponse, error)
+	// The RPC used to update the vulnerabilities immediately, in the background.
+	TriggerUpdate(context.Context, *TriggerUpdateRequest) (*TriggerUpdateResponse, error)
+	// The RPC used to show the progress of an update triggered on demand.
+	GetUpdateJob(context.Context, *GetUpdateJobRequest) (*GetUpdateJobResponse, error)
 }
 
 func RegisterStatusServiceServer(s *grpc.Server, srv StatusServiceServer) {
@@ -1075,6 +3786,60 @@ func _StatusService_GetStatus_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StatusService_GetUpdaterStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUpdaterStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceServer).GetUpdaterStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.StatusService/GetUpdaterStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceServer).GetUpdaterStatus(ctx, req.(*GetUpdaterStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatusService_TriggerUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerUpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceServer).TriggerUpdate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.StatusService/TriggerUpdate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceServer).TriggerUpdate(ctx, req.(*TriggerUpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatusService_GetUpdateJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUpdateJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceServer).GetUpdateJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.StatusService/GetUpdateJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceServer).GetUpdateJob(ctx, req.(*GetUpdateJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _StatusService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "coreos.clair.StatusService",
 	HandlerType: (*StatusServiceServer)(nil),
@@ -1083,6 +3848,18 @@ var _StatusService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetStatus",
 			Handler:    _StatusService_GetStatus_Handler,
 		},
+		{
+			MethodName: "GetUpdaterStatus",
+			Handler:    _StatusService_GetUpdaterStatus_Handler,
+		},
+		{
+			MethodName: "TriggerUpdate",
+			Handler:    _StatusService_TriggerUpdate_Handler,
+		},
+		{
+			MethodName: "GetUpdateJob",
+			Handler:    _StatusService_GetUpdateJob_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/v3/clairpb/clair.proto",
@@ -1091,89 +3868,254 @@ var _StatusService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("api/v3/clairpb/clair.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1336 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x57, 0x4b, 0x6f, 0x1b, 0x55,
-	0x14, 0x66, 0x9c, 0x3a, 0xb6, 0x8f, 0xed, 0xc4, 0xbd, 0x49, 0x13, 0x67, 0xd2, 0x47, 0x32, 0x50,
-	0x51, 0x0a, 0xb2, 0x85, 0x5b, 0xa4, 0xb6, 0x2c, 0x90, 0x9b, 0x38, 0x21, 0x52, 0x1b, 0xa2, 0x49,
-	0x1a, 0x09, 0x10, 0x32, 0x37, 0x9e, 0xe3, 0x64, 0x94, 0xf1, 0xcc, 0x30, 0x73, 0x9d, 0xd4, 0xaa,
-	0xca, 0x82, 0x1d, 0x3b, 0x04, 0x0b, 0x56, 0xfc, 0x00, 0x36, 0x88, 0xff, 0xc0, 0x9e, 0x05, 0x6c,
-	0x61, 0xc7, 0x82, 0x3f, 0xc0, 0x1e, 0xdd, 0xc7, 0x4c, 0x66, 0x92, 0x49, 0xe2, 0x76, 0xe5, 0x7b,
-	0xde, 0x8f, 0xfb, 0xdd, 0x73, 0xc6, 0xa0, 0x53, 0xdf, 0x6e, 0x1e, 0xdd, 0x6b, 0xf6, 0x1c, 0x6a,
-	0x07, 0xfe, 0x9e, 0xfc, 0x6d, 0xf8, 0x81, 0xc7, 0x3c, 0x52, 0xe9, 0x79, 0x01, 0x7a, 0x61, 0x43,
-	0xf0, 0xf4, 0x5b, 0xfb, 0x9e, 0xb7, 0xef, 0x60, 0x53, 0xc8, 0xf6, 0x86, 0xfd, 0x26, 0xb3, 0x07,
-	0x18, 0x32, 0x3a, 0xf0, 0xa5, 0xba, 0x7e, 0x5d, 0x29, 0x70, 0x8f, 0xd4, 0x75, 0x3d, 0x46, 0x99,
-	0xed, 0xb9, 0xa1, 0x94, 0x1a, 0x3f, 0xe6, 0xa0, 0xba, 0x3b, 0x74, 0x5c, 0x0c, 0xe8, 0x9e, 0xed,
-	0xd8, 0x6c, 0x44, 0x08, 0x5c, 0x71, 0xe9, 0x00, 0xeb, 0xda, 0x92, 0x76, 0xa7, 0x64, 0x8a, 0x33,
-	0xb9, 0x0d, 0x53, 0xfc, 0x37, 0xf4, 0x69, 0x0f, 0xbb, 0x42, 0x9a, 0x13, 0xd2, 0x6a, 0xcc, 0xdd,
-	0xe4, 0x6a, 0x4b, 0x50, 0xb6, 0x30, 0xec, 0x05, 0xb6, 0xcf, 0x43, 0xd4, 0x27, 0x84, 0x4e, 0x92,
-	0xc5, 0x9d, 0x3b, 0xb6, 0x7b, 0x58, 0xbf, 0x22, 0x9d, 0xf3, 0x33, 0xd1, 0xa1, 0x18, 0xe2, 0x11,
-	0x06, 0x36, 0x1b, 0xd5, 0xf3, 0x82, 0x1f, 0xd3, 0x5c, 0x36, 0x40, 0x46, 0x2d, 0xca, 0x68, 0x7d,
-	0x52, 0xca, 0x22, 0x9a, 0x2c, 0x40, 0xb1, 0x6f, 0x3f, 0x47, 0xab, 0xbb, 0x37, 0xaa, 0x17, 0x84,
-	0xac, 0x20, 0xe8, 0xc7, 0x23, 0xf2, 0x18, 0xae, 0xd2, 0x7e, 0x1f, 0x7b, 0x0c, 0xad, 0xee, 0x11,
-	0x06, 0x21, 0x2f, 0xb8, 0x5e, 0x5c, 0x9a, 0xb8, 0x53, 0x6e, 0x5d, 0x6b, 0x24, 0xdb, 0xd7, 0x58,
-	0x43, 0xca, 0x86, 0x01, 0x9a, 0xb5, 0x48, 0x7f, 0x57, 0xa9, 0x1b, 0xbf, 0x6b, 0x50, 0x5c, 0x45,
-	0x86, 0x3d, 0xe6, 0x05, 0x99, 0x4d, 0xa9, 0x43, 0x41, 0xf9, 0x56, 0xdd, 0x88, 0x48, 0xd2, 0x82,
-	0xbc, 0xc5, 0x46, 0x3e, 0x8a, 0x0e, 0x4c, 0xb5, 0xae, 0xa7, 0x43, 0x46, 0x4e, 0x1b, 0xab, 0x3b,
-	0x23, 0x1f, 0x4d, 0xa9, 0x6a, 0x7c, 0x09, 0x79, 0x41, 0x93, 0x45, 0x98, 0x5f, 0xed, 0xec, 0x74,
-	0x56, 0x76, 0x3e, 0x31, 0xbb, 0xab, 0xdd, 0x9d, 0x4f, 0xb7, 0x3a, 0xdd, 0x8d, 0xcd, 0xdd, 0xf6,
-	0x93, 0x8d, 0xd5, 0xda, 0x1b, 0xe4, 0x06, 0x2c, 0x9c, 0x16, 0x6e, 0xb6, 0x9f, 0x76, 0xb6, 0xb7,
-	0xda, 0x2b, 0x9d, 0x9a, 0x96, 0x65, 0xbb, 0xd6, 0x69, 0xef, 0x3c, 0x33, 0x3b, 0xb5, 0x9c, 0xb1,
-	0x0d, 0xa5, 0xcd, 0xe8, 0xba, 0x32, 0x0b, 0x6a, 0x41, 0xd1, 0x52, 0xb9, 0x89, 0x8a, 0xca, 0xad,
-	0xb9, 0xec, 0xcc, 0xcd, 0x58, 0xcf, 0xf8, 0x2e, 0x07, 0x05, 0xd5, 0xc3, 0x4c, 0x9f, 0x1f, 0x40,
-	0x29, 0xc6, 0x88, 0x72, 0x3a, 0x9f, 0x76, 0x1a, 0xe7, 0x64, 0x9e, 0x68, 0x26, 0x7b, 0x3b, 0x91,
-	0xee, 0xed, 0x6d, 0x98, 0x52, 0xc7, 0x6e, 0xdf, 0x0b, 0x06, 0x94, 0x29, 0x2c, 0x55, 0x15, 0x77,
-	0x4d, 0x30, 0x53, 0xb5, 0xe4, 0xc7, 0xab, 0x85, 0x74, 0x60, 0xfa, 0x28, 0xf1, 0x14, 0x6c, 0x0c,
-	0xeb, 0x93, 0x02, 0x33, 0x8b, 0x69, 0xd3, 0xd4, 0x7b, 0x31, 0x4f, 0xdb, 0x18, 0x8b, 0x90, 0x7f,
-	0x42, 0x47, 0x28, 0x40, 0x73, 0x40, 0xc3, 0x83, 0xa8, 0x1f, 0xfc, 0x6c, 0x7c, 0xab, 0x41, 0x79,
-	0x85, 0x7b, 0xd9, 0x66, 0x94, 0x0d, 0x43, 0x72, 0x1f, 0x4a, 0x51, 0xfc, 0xb0, 0xae, 0x89, 0x68,
-	0xe7, 0x25, 0x7a, 0xa2, 0x48, 0x56, 0xa1, 0xe6, 0xd0, 0x90, 0x75, 0x87, 0xbe, 0x45, 0x19, 0x76,
-	0xf9, 0x93, 0x57, 0xcd, 0xd5, 0x1b, 0xf2, 0xb9, 0x37, 0xa2, 0x79, 0xd0, 0xd8, 0x89, 0xe6, 0x81,
-	0x39, 0xc5, 0x6d, 0x9e, 0x09, 0x13, 0xce, 0x34, 0x1e, 0x02, 0x59, 0x47, 0xd6, 0x76, 0x7b, 0x18,
-	0xb2, 0x60, 0x64, 0xe2, 0x57, 0x43, 0x0c, 0x19, 0x79, 0x13, 0xaa, 0x54, 0xb1, 0xba, 0x89, 0xeb,
-	0xac, 0x44, 0x4c, 0x7e, 0x5f, 0xc6, 0xaf, 0x13, 0x30, 0x93, 0xb2, 0x0d, 0x7d, 0xcf, 0x0d, 0x91,
-	0xac, 0x41, 0x31, 0xd2, 0x13, 0x76, 0xe5, 0xd6, 0xdd, 0x74, 0x35, 0x19, 0x46, 0x8d, 0x98, 0x11,
-	0xdb, 0x92, 0xf7, 0x61, 0x32, 0x14, 0x0d, 0x52, 0x65, 0x2d, 0xa4, 0xbd, 0x24, 0x3a, 0x68, 0x2a,
-	0x45, 0xfd, 0x6b, 0xa8, 0x46, 0x8e, 0x64, 0xfb, 0xdf, 0x81, 0xbc, 0xc3, 0x0f, 0x2a, 0x91, 0x99,
-	0xb4, 0x0b, 0xa1, 0x63, 0x4a, 0x0d, 0x3e, 0x2f, 0x64, 0x73, 0xd1, 0xea, 0xf6, 0x25, 0x9a, 0x79,
-	0xe4, 0x8b, 0xe6, 0x45, 0xa4, 0xaf, 0x18, 0xa1, 0xfe, 0x93, 0x06, 0xc5, 0x28, 0x81, 0xcc, 0xa7,
-	0x90, 0xba, 0xea, 0xdc, 0xb8, 0x57, 0xbd, 0x0e, 0x93, 0x22, 0xc7, 0xb0, 0x3e, 0x21, 0x4c, 0x9a,
-	0xe3, 0xf7, 0x53, 0x96, 0xa8, 0xcc, 0x8d, 0xbf, 0x73, 0x30, 0xb3, 0xe5, 0x85, 0xaf, 0x75, 0xdf,
-	0x64, 0x0e, 0x26, 0xd5, 0x6b, 0x93, 0xa3, 0x4e, 0x51, 0x64, 0xe5, 0x54, 0x76, 0xef, 0xa6, 0xb3,
-	0xcb, 0x88, 0x27, 0x78, 0xa9, 0xcc, 0xf4, 0xdf, 0x34, 0x28, 0xc5, 0xdc, 0xac, 0x57, 0xc3, 0x79,
-	0x3e, 0x65, 0x07, 0x2a, 0xb8, 0x38, 0x13, 0x13, 0x0a, 0x07, 0x48, 0xad, 0x93, 0xd8, 0x0f, 0x5e,
-	0x21, 0x76, 0xe3, 0x63, 0x69, 0xda, 0x71, 0xb9, 0x34, 0x72, 0xa4, 0x3f, 0x82, 0x4a, 0x52, 0x40,
-	0x6a, 0x30, 0x71, 0x88, 0x23, 0x95, 0x0a, 0x3f, 0x92, 0x59, 0xc8, 0x1f, 0x51, 0x67, 0x18, 0x2d,
-	0x40, 0x49, 0x3c, 0xca, 0x3d, 0xd0, 0x8c, 0x0d, 0x98, 0x4d, 0x87, 0x54, 0x4f, 0xe2, 0x04, 0xca,
-	0xda, 0x98, 0x50, 0x36, 0x7e, 0xd1, 0x60, 0x6e, 0x1d, 0xd9, 0xa6, 0xc7, 0xec, 0xbe, 0xdd, 0x13,
-	0xfb, 0x3a, 0xba, 0xad, 0xfb, 0x30, 0xe7, 0x39, 0x56, 0x37, 0x39, 0x73, 0x46, 0x5d, 0x9f, 0xee,
-	0x47, 0xd7, 0x36, 0xeb, 0x39, 0x56, 0x6a, 0x3e, 0x6d, 0xd1, 0x7d, 0x0e, 0xbd, 0x39, 0x17, 0x8f,
-	0xb3, 0xac, 0x64, 0x19, 0xb3, 0x2e, 0x1e, 0x9f, 0xb5, 0x9a, 0x85, 0xbc, 0x63, 0x0f, 0x6c, 0x26,
-	0x46, 0x70, 0xde, 0x94, 0x44, 0x0c, 0xed, 0x2b, 0x27, 0xd0, 0x36, 0xfe, 0xca, 0xc1, 0xfc, 0x99,
-	0x84, 0x55, 0xfd, 0xbb, 0x50, 0x71, 0x13, 0x7c, 0xd5, 0x85, 0xd6, 0x19, 0x18, 0x67, 0x19, 0x37,
-	0x52, 0xcc, 0x94, 0x1f, 0xfd, 0x5f, 0x0d, 0x2a, 0x49, 0xf1, 0x79, 0x3b, 0xba, 0x17, 0x20, 0x65,
-	0x68, 0x45, 0x3b, 0x5a, 0x91, 0xfc, 0xcb, 0x42, 0xba, 0x43, 0x4b, 0xad, 0x98, 0x98, 0xe6, 0x56,
-	0x16, 0x3a, 0xc8, 0xad, 0x64, 0x95, 0x11, 0x49, 0x1e, 0xc2, 0x84, 0xe7, 0x58, 0x6a, 0xa3, 0xbc,
-	0x7d, 0x0a, 0x70, 0x74, 0x1f, 0xe3, 0xde, 0x3b, 0xa8, 0x80, 0x60, 0x63, 0x68, 0x72, 0x1b, 0x6e,
-	0xea, 0xe2, 0xb1, 0xf8, 0x8a, 0x79, 0x15, 0x53, 0x17, 0x8f, 0x8d, 0x3f, 0x72, 0xb0, 0x70, 0xae,
-	0x0a, 0x59, 0x86, 0x4a, 0x6f, 0x18, 0x04, 0xe8, 0xb2, 0x24, 0x10, 0xca, 0x8a, 0x27, 0x6e, 0x72,
-	0x11, 0x4a, 0x2e, 0x3e, 0x67, 0xc9, 0x2b, 0x2f, 0x72, 0xc6, 0x05, 0xd7, 0xdc, 0x86, 0x6a, 0x0a,
-	0x2e, 0xa2, 0x13, 0x97, 0xac, 0xc2, 0xb4, 0x05, 0xf9, 0x1c, 0x80, 0xc6, 0x69, 0xd6, 0xf3, 0xe2,
-	0x91, 0x7e, 0x38, 0x66, 0xe1, 0x8d, 0x0d, 0xd7, 0xc2, 0xe7, 0x68, 0xb5, 0x13, 0x53, 0xc8, 0x4c,
-	0xb8, 0xd3, 0x3f, 0x82, 0x99, 0x0c, 0x15, 0x5e, 0x8c, 0xcd, 0xd9, 0xa2, 0x0b, 0x79, 0x53, 0x12,
-	0x31, 0x34, 0x72, 0x09, 0xcc, 0xde, 0x83, 0x1b, 0x4f, 0x69, 0x70, 0x98, 0x84, 0x50, 0x3b, 0x34,
-	0x91, 0x5a, 0xd1, 0x53, 0xcb, 0xc0, 0x93, 0xb1, 0x04, 0x37, 0xcf, 0x33, 0x92, 0x88, 0x35, 0x08,
-	0xd4, 0xd6, 0x91, 0xa9, 0x07, 0x2d, 0x3d, 0x19, 0x6b, 0x70, 0x35, 0xc1, 0x7b, 0xed, 0xb9, 0xd0,
-	0xfa, 0x4f, 0x83, 0xe9, 0xa8, 0xda, 0x6d, 0x0c, 0x8e, 0xec, 0x1e, 0x92, 0x21, 0x94, 0x13, 0x3b,
-	0x80, 0x2c, 0x5d, 0xb0, 0x1e, 0x44, 0x32, 0xfa, 0xf2, 0xa5, 0x0b, 0xc4, 0x58, 0xfe, 0xe6, 0xcf,
-	0x7f, 0x7e, 0xc8, 0x2d, 0x92, 0x85, 0x66, 0xb4, 0x04, 0x9a, 0x2f, 0x52, 0x3b, 0xe2, 0x25, 0x39,
-	0x84, 0x4a, 0x72, 0xda, 0x91, 0xe5, 0x4b, 0x87, 0xaf, 0x6e, 0x5c, 0xa4, 0xa2, 0x22, 0xcf, 0x8a,
-	0xc8, 0x53, 0x46, 0x29, 0x8e, 0xfc, 0x48, 0xbb, 0xdb, 0xfa, 0x39, 0x07, 0x33, 0xc9, 0x96, 0x47,
-	0xb5, 0xbf, 0x84, 0xe9, 0x53, 0x83, 0x83, 0xbc, 0x75, 0xc9, 0x5c, 0x91, 0xa9, 0xdc, 0x1e, 0x6b,
-	0xfa, 0x18, 0x37, 0x44, 0x36, 0xf3, 0xe4, 0x5a, 0x33, 0x39, 0x79, 0xc2, 0xe6, 0x0b, 0xd9, 0x83,
-	0xef, 0x35, 0x98, 0xcb, 0x46, 0x03, 0x39, 0xb5, 0x07, 0x2f, 0x04, 0x9a, 0xfe, 0xde, 0x78, 0xca,
-	0xe9, 0xa4, 0xee, 0x66, 0x27, 0xd5, 0x72, 0xa1, 0x2a, 0x51, 0x13, 0x35, 0xe9, 0x0b, 0x28, 0xc5,
-	0xe0, 0x23, 0x37, 0xcf, 0x14, 0x9e, 0x42, 0xaa, 0x7e, 0xeb, 0x5c, 0xb9, 0x8a, 0x3e, 0x2d, 0xa2,
-	0x97, 0x48, 0xa1, 0x29, 0x31, 0xf9, 0xf8, 0x26, 0xcc, 0xf4, 0xbc, 0x41, 0xda, 0xcc, 0xdf, 0xfb,
-	0xac, 0xa0, 0xfe, 0xb9, 0xee, 0x4d, 0x8a, 0x0f, 0xd1, 0x7b, 0xff, 0x07, 0x00, 0x00, 0xff, 0xff,
-	0xcb, 0x5c, 0xce, 0x34, 0xd2, 0x0e, 0x00, 0x00,
+	// 3973 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x5b, 0xcd, 0x6f, 0x2b, 0x59,
+	0x56, 0xef, 0xb2, 0xe3, 0x24, 0x3e, 0xfe, 0x88, 0x73, 0xe3, 0xe4, 0x39, 0xf5, 0x3e, 0x92, 0xd4,
+	0xeb, 0xbc, 0xce, 0xcb, 0xf4, 0xd8, 0x33, 0x7e, 0x3d, 0x30, 0xfd, 0x86, 0x0f, 0x39, 0xb1, 0xf3,
+	0x3a, 0x3d, 0xe9, 0x24, 0x94, 0x9d, 0x30, 0x03, 0x42, 0x35, 0x15, 0xd7, 0x8d, 0x53, 0xef, 0xd9,
+	0x55, 0x9e, 0xaa, 0x72, 0x5e, 0x4c, 0xeb, 0x8d, 0x5a, 0x20, 0x0d, 0x02, 0x89, 0x0f, 0xcd, 0x2c,
+	0x90, 0x40, 0xb0, 0x9a, 0x05, 0x62, 0x81, 0x60, 0xc1, 0x02, 0x06, 0x89, 0x45, 0x4b, 0x2c, 0x58,
+	0x0c, 0x02, 0xf6, 0xb0, 0xe1, 0x0f, 0x18, 0x36, 0x48, 0xb0, 0x40, 0xe8, 0x7e, 0x95, 0xab, 0xec,
+	0xf2, 0x47, 0xa2, 0x87, 0xd4, 0xab, 0xf8, 0x9e, 0x7b, 0xce, 0xb9, 0xe7, 0xde, 0xfb, 0xbb, 0xe7,
+	0x9e, 0x73, 0x6e, 0x05, 0x64, 0xbd, 0x6b, 0x96, 0xae, 0x9f, 0x95, 0x9a, 0x6d, 0xdd, 0x74, 0xba,
+	0x17, 0xec, 0x6f, 0xb1, 0xeb, 0xd8, 0x9e, 0x8d, 0xd2, 0x4d, 0xdb, 0xc1, 0xb6, 0x5b, 0xa4, 0x34,
+	0x79, 0xa3, 0x65, 0xdb, 0xad, 0x36, 0x2e, 0xd1, 0xbe, 0x8b, 0xde, 0x65, 0xc9, 0x33, 0x3b, 0xd8,
+	0xf5, 0xf4, 0x4e, 0x97, 0xb1, 0xcb, 0x0f, 0x38, 0x03, 0xd1, 0xa8, 0x5b, 0x96, 0xed, 0xe9, 0x9e,
+	0x69, 0x5b, 0x2e, 0xeb, 0x55, 0xfe, 0x31, 0x01, 0x99, 0xf3, 0x5e, 0xdb, 0xc2, 0x8e, 0x7e, 0x61,
+	0xb6, 0x4d, 0xaf, 0x8f, 0x10, 0xcc, 0x59, 0x7a, 0x07, 0x17, 0xa4, 0x4d, 0x69, 0x27, 0xa9, 0xd2,
+	0xdf, 0x68, 0x1b, 0xb2, 0xe4, 0xaf, 0xdb, 0xd5, 0x9b, 0x58, 0xa3, 0xbd, 0x31, 0xda, 0x9b, 0xf1,
+	0xa9, 0xc7, 0x84, 0x6d, 0x13, 0x52, 0x06, 0x76, 0x9b, 0x8e, 0xd9, 0x25, 0x43, 0x14, 0xe2, 0x94,
+	0x27, 0x48, 0x22, 0xca, 0xdb, 0xa6, 0xf5, 0xaa, 0x30, 0xc7, 0x94, 0x93, 0xdf, 0x48, 0x86, 0x45,
+	0x17, 0x5f, 0x63, 0xc7, 0xf4, 0xfa, 0x85, 0x04, 0xa5, 0xfb, 0x6d, 0xd2, 0xd7, 0xc1, 0x9e, 0x6e,
+	0xe8, 0x9e, 0x5e, 0x98, 0x67, 0x7d, 0xa2, 0x8d, 0xd6, 0x61, 0xf1, 0xd2, 0xbc, 0xc1, 0x86, 0x76,
+	0xd1, 0x2f, 0x2c, 0xd0, 0xbe, 0x05, 0xda, 0xde, 0xeb, 0xa3, 0x3d, 0x58, 0xd6, 0x2f, 0x2f, 0x71,
+	0xd3, 0xc3, 0x86, 0x76, 0x8d, 0x1d, 0x97, 0x4c, 0xb8, 0xb0, 0xb8, 0x19, 0xdf, 0x49, 0x95, 0x57,
+	0x8b, 0xc1, 0xe5, 0x2b, 0x1e, 0x60, 0xdd, 0xeb, 0x39, 0x58, 0xcd, 0x09, 0xfe, 0x73, 0xce, 0x8e,
+	0x1e, 0x01, 0xb8, 0xbd, 0x6e, 0xd7, 0xc1, 0xae, 0x8b, 0x8d, 0x42, 0x72, 0x53, 0xda, 0x59, 0x54,
+	0x03, 0x14, 0x94, 0x83, 0xb8, 0xa7, 0xb7, 0x0a, 0x40, 0x47, 0x26, 0x3f, 0x51, 0x15, 0x92, 0xaf,
+	0x4d, 0xef, 0xca, 0x70, 0xf4, 0xd7, 0x56, 0x21, 0xb5, 0x29, 0xed, 0xa4, 0xca, 0x4f, 0xc2, 0xa3,
+	0x85, 0x56, 0xba, 0xf8, 0xcb, 0x9c, 0x59, 0x6f, 0xab, 0x03, 0x41, 0xf4, 0x18, 0x32, 0x97, 0xe6,
+	0x8d, 0xa6, 0x5f, 0xeb, 0x66, 0x5b, 0xbf, 0x68, 0xe3, 0x42, 0x9a, 0x0e, 0x9d, 0xbe, 0x34, 0x6f,
+	0x2a, 0x82, 0x86, 0x54, 0x58, 0xf2, 0x27, 0xe8, 0xe8, 0x56, 0x0b, 0xbb, 0x85, 0x0c, 0x9d, 0xde,
+	0xd3, 0x49, 0x03, 0x56, 0xb8, 0x88, 0x4a, 0x24, 0xd4, 0xac, 0x1e, 0x6c, 0xba, 0x72, 0x03, 0x60,
+	0x60, 0x11, 0x2a, 0xc2, 0x9c, 0x67, 0x72, 0x18, 0xa4, 0xca, 0x72, 0x91, 0xa1, 0xa8, 0x28, 0x60,
+	0x56, 0x6c, 0x08, 0x98, 0xa9, 0x94, 0x0f, 0xad, 0xc1, 0xbc, 0x83, 0x75, 0xd7, 0xb6, 0x38, 0x34,
+	0x78, 0x4b, 0x7e, 0x09, 0x99, 0xd0, 0xb0, 0x64, 0x5d, 0x4d, 0xcb, 0x73, 0x6c, 0xa3, 0xd7, 0xc4,
+	0x06, 0x47, 0x59, 0x80, 0x82, 0xf2, 0x90, 0xa0, 0xdb, 0xc8, 0xf5, 0xb0, 0x06, 0x59, 0x95, 0xb6,
+	0xee, 0x7a, 0x9a, 0xb0, 0x99, 0x83, 0x2b, 0x4d, 0x88, 0x42, 0xbf, 0xf2, 0x13, 0x09, 0x16, 0xab,
+	0xd8, 0xc3, 0x4d, 0xcf, 0x76, 0x22, 0x71, 0x5c, 0x80, 0x05, 0x0e, 0x07, 0xae, 0x5d, 0x34, 0x51,
+	0x19, 0x12, 0x86, 0xd7, 0xef, 0x62, 0xaa, 0x37, 0x5b, 0x7e, 0x10, 0x5e, 0x46, 0xa1, 0xb4, 0x58,
+	0x6d, 0xf4, 0xbb, 0x58, 0x65, 0xac, 0xca, 0x77, 0x20, 0x41, 0xdb, 0xe8, 0x3e, 0xdc, 0xab, 0xd6,
+	0x1a, 0xb5, 0xfd, 0xc6, 0x89, 0xaa, 0x55, 0xb5, 0xc6, 0xb7, 0x4f, 0x6b, 0xda, 0xe1, 0xf1, 0x79,
+	0xe5, 0xe8, 0xb0, 0x9a, 0x7b, 0x07, 0x3d, 0x84, 0xf5, 0xe1, 0xce, 0xe3, 0xca, 0x27, 0xb5, 0xfa,
+	0x69, 0x65, 0xbf, 0x96, 0x93, 0xa2, 0x64, 0x0f, 0x6a, 0x95, 0xc6, 0x99, 0x5a, 0xcb, 0xc5, 0x94,
+	0x3a, 0x24, 0x8f, 0xc5, 0x09, 0x8b, 0x9c, 0x50, 0x19, 0x16, 0x0d, 0x6e, 0x1b, 0x9d, 0x51, 0xaa,
+	0xbc, 0x16, 0x6d, 0xb9, 0xea, 0xf3, 0x29, 0x3f, 0x8a, 0xc1, 0x02, 0x87, 0x7d, 0xa4, 0xce, 0xaf,
+	0x41, 0xd2, 0x3f, 0xd6, 0x5c, 0xe9, 0xbd, 0xb0, 0x52, 0xdf, 0x26, 0x75, 0xc0, 0x19, 0x5c, 0xdb,
+	0x78, 0x78, 0x6d, 0xb7, 0x21, 0xcb, 0x7f, 0x6a, 0x97, 0xb6, 0xd3, 0xd1, 0x3d, 0x7e, 0xfc, 0x33,
+	0x9c, 0x7a, 0x40, 0x89, 0xa1, 0xb9, 0x24, 0x66, 0x9b, 0x0b, 0xaa, 0xc1, 0xd2, 0x75, 0x00, 0xe2,
+	0x26, 0x76, 0x0b, 0xf3, 0xf4, 0x1c, 0xdc, 0x9f, 0x70, 0x0e, 0xd4, 0x61, 0x19, 0xb2, 0x0c, 0xdd,
+	0x9e, 0xd3, 0xe6, 0x6e, 0x84, 0xfe, 0x56, 0xee, 0x43, 0xe2, 0x48, 0xef, 0x63, 0x0a, 0xa4, 0x2b,
+	0xdd, 0xbd, 0x12, 0x6b, 0x44, 0x7e, 0x2b, 0xbf, 0x2d, 0x41, 0x6a, 0x9f, 0x68, 0xae, 0x7b, 0xba,
+	0xd7, 0x73, 0xd1, 0x07, 0x90, 0x14, 0x36, 0xb9, 0x05, 0x69, 0x33, 0x3e, 0xc1, 0xf8, 0x01, 0x23,
+	0xaa, 0x42, 0x8e, 0x82, 0xba, 0xd7, 0x35, 0x74, 0x0f, 0x6b, 0xf4, 0xbc, 0xc5, 0xa6, 0x9e, 0xb7,
+	0x2c, 0x91, 0x39, 0xa3, 0x22, 0x84, 0xa8, 0xfc, 0xb9, 0x04, 0xe8, 0x05, 0xf6, 0x2a, 0x56, 0x13,
+	0xbb, 0x9e, 0xd3, 0x57, 0xf1, 0x77, 0x7b, 0xd8, 0xf5, 0xc8, 0x89, 0xd1, 0x39, 0x49, 0x0b, 0xec,
+	0x71, 0x5a, 0x10, 0xa9, 0xc7, 0x7e, 0x0f, 0x96, 0x88, 0xe7, 0xd1, 0x02, 0x9e, 0x2e, 0x46, 0xdd,
+	0x4d, 0x96, 0x90, 0xeb, 0x3e, 0x95, 0x68, 0xc3, 0x37, 0xcd, 0x76, 0xcf, 0xc0, 0x86, 0xe6, 0xe9,
+	0x2d, 0xb7, 0x10, 0xdf, 0x8c, 0x13, 0x6d, 0x82, 0xd8, 0xd0, 0x5b, 0x2e, 0xda, 0x82, 0xb4, 0x6d,
+	0xb5, 0xfb, 0xda, 0xa5, 0x79, 0x43, 0x3d, 0xd7, 0x1c, 0x55, 0x95, 0x22, 0xb4, 0x03, 0x46, 0x52,
+	0xfe, 0x41, 0x82, 0xb5, 0x80, 0xb1, 0xf5, 0xbd, 0x93, 0x4f, 0x6e, 0x65, 0xf0, 0x1a, 0xcc, 0x73,
+	0x0c, 0x71, 0x37, 0xc3, 0x5a, 0x51, 0x13, 0x89, 0xcf, 0x36, 0x91, 0xb9, 0x19, 0x26, 0x92, 0x18,
+	0x9d, 0xc8, 0x11, 0xdc, 0x1b, 0x99, 0x87, 0xdb, 0xb5, 0x2d, 0x17, 0xa3, 0x87, 0x00, 0x1d, 0x6c,
+	0x98, 0xba, 0x46, 0x1d, 0x0a, 0x9b, 0x45, 0x92, 0x52, 0xa8, 0xb7, 0x40, 0x30, 0xe7, 0x5e, 0xd8,
+	0x1d, 0x3a, 0x81, 0xb4, 0x4a, 0x7f, 0x2b, 0x7f, 0x19, 0x87, 0x95, 0xd0, 0x1e, 0x72, 0x55, 0x07,
+	0xb0, 0x28, 0xa6, 0xcf, 0x3d, 0xf1, 0x6e, 0x18, 0x56, 0x11, 0x42, 0x45, 0x9f, 0xe0, 0xcb, 0xa2,
+	0xaf, 0xc2, 0xbc, 0x4b, 0x91, 0xca, 0xf1, 0xb5, 0x1e, 0xd6, 0x12, 0x80, 0xb2, 0xca, 0x19, 0xe5,
+	0xef, 0x41, 0x46, 0x28, 0x62, 0xe7, 0xe0, 0x29, 0x24, 0xda, 0xe4, 0x07, 0x37, 0x64, 0x25, 0xac,
+	0x82, 0xf2, 0xa8, 0x8c, 0x83, 0xdc, 0xbf, 0x0c, 0xe5, 0xd8, 0xd0, 0x2e, 0x99, 0xab, 0x21, 0x23,
+	0x4f, 0xba, 0x7f, 0x05, 0x3f, 0x27, 0xb8, 0xf2, 0x9f, 0x48, 0xb0, 0x28, 0x0c, 0x88, 0xf4, 0x53,
+	0xa1, 0x33, 0x17, 0x9b, 0xf5, 0xcc, 0xbd, 0x80, 0x79, 0x6a, 0x23, 0x43, 0x70, 0xaa, 0x5c, 0x9a,
+	0x7d, 0x3d, 0xd9, 0x14, 0xb9, 0xb8, 0xf2, 0xb9, 0x04, 0xab, 0x75, 0xcf, 0xc1, 0x7a, 0xe7, 0x0b,
+	0x70, 0xf2, 0xf2, 0x90, 0x68, 0x9b, 0x1d, 0x93, 0x79, 0xd6, 0x84, 0xca, 0x1a, 0xb3, 0xc0, 0xf8,
+	0xa7, 0x12, 0xac, 0x0d, 0xcf, 0x82, 0x63, 0xef, 0x6e, 0x3e, 0xed, 0xf6, 0x48, 0x1b, 0x00, 0x2b,
+	0x7e, 0x37, 0x60, 0xcd, 0xdd, 0x0a, 0x58, 0xca, 0xbf, 0x49, 0x50, 0xd8, 0xb7, 0x3b, 0x5d, 0xdd,
+	0xc1, 0x7c, 0xce, 0x26, 0x76, 0x6f, 0xb5, 0x77, 0xef, 0x03, 0xba, 0xd0, 0x5d, 0xac, 0x85, 0x39,
+	0x99, 0x43, 0xca, 0x91, 0x9e, 0xca, 0x94, 0x9d, 0xfe, 0xff, 0x75, 0x4d, 0xff, 0x15, 0x83, 0xf5,
+	0x88, 0x09, 0xf2, 0x6d, 0x6d, 0x00, 0xf0, 0xf0, 0xda, 0xc4, 0x62, 0x5f, 0x3f, 0x18, 0xda, 0xa4,
+	0x71, 0xc2, 0xc5, 0x3a, 0x93, 0xec, 0x57, 0xcd, 0xcb, 0x4b, 0x35, 0xa0, 0xe7, 0x2e, 0x0e, 0xe6,
+	0x9f, 0x24, 0x48, 0x07, 0xf5, 0x85, 0x12, 0x01, 0x69, 0x28, 0x11, 0xf8, 0x2a, 0x24, 0x74, 0xc3,
+	0xa0, 0x87, 0x64, 0xea, 0xf5, 0xce, 0x38, 0xd1, 0xd7, 0x60, 0xc1, 0xc1, 0x1d, 0xfb, 0x9a, 0xae,
+	0xf7, 0x54, 0x21, 0xc1, 0x8b, 0x3e, 0x84, 0x64, 0xcf, 0x6a, 0x5e, 0x91, 0x58, 0xd5, 0x28, 0xcc,
+	0x4d, 0x17, 0x1c, 0x70, 0x2b, 0x7f, 0x25, 0xc1, 0x7a, 0xf0, 0x52, 0xe8, 0x75, 0x3a, 0xfa, 0x17,
+	0xfd, 0x42, 0xfe, 0xdd, 0x18, 0xc8, 0x51, 0x36, 0x73, 0xb4, 0x9c, 0x47, 0xa0, 0xe5, 0x67, 0xc6,
+	0xba, 0xcc, 0x21, 0x69, 0x1f, 0x2e, 0xfb, 0x76, 0xcf, 0xf2, 0x42, 0x78, 0x29, 0xc1, 0x4a, 0x30,
+	0x08, 0xeb, 0x6b, 0x4d, 0xc2, 0x42, 0xe7, 0x9a, 0x50, 0x51, 0xa8, 0x8b, 0x0a, 0xd3, 0xb4, 0x88,
+	0x9d, 0x60, 0xce, 0x1a, 0xa7, 0xac, 0x69, 0x4e, 0xa4, 0x4c, 0x72, 0x05, 0x32, 0xa1, 0x21, 0x27,
+	0x42, 0x2a, 0x0f, 0x89, 0xe0, 0xa0, 0xac, 0xa1, 0xfc, 0xf7, 0x3c, 0xac, 0x9c, 0xda, 0xee, 0xdd,
+	0xc2, 0xa9, 0x71, 0xd1, 0xc9, 0xfe, 0xd0, 0xa5, 0xf3, 0xa5, 0xf0, 0x0a, 0x46, 0x8c, 0x47, 0x69,
+	0xa1, 0x0b, 0x87, 0xc4, 0x02, 0x0e, 0x6e, 0x99, 0x84, 0xa9, 0x30, 0x17, 0x15, 0x0b, 0x44, 0xa9,
+	0x51, 0xb9, 0x84, 0xea, 0xcb, 0xa2, 0x73, 0xc8, 0xda, 0x17, 0x2f, 0x71, 0xd3, 0xd3, 0x5c, 0xcf,
+	0x76, 0xf4, 0x16, 0xe6, 0xd1, 0x76, 0x69, 0xba, 0xb6, 0x13, 0x2a, 0x57, 0x67, 0x62, 0x6a, 0xc6,
+	0x0e, 0x36, 0xe5, 0xcf, 0x25, 0x48, 0xfa, 0x56, 0x47, 0x45, 0xcd, 0x84, 0xd6, 0xd5, 0xbd, 0x2b,
+	0xbe, 0x38, 0xf4, 0x37, 0x52, 0x61, 0xe1, 0x0a, 0xeb, 0xc6, 0x60, 0x6d, 0xbe, 0x7e, 0x8b, 0xb5,
+	0x29, 0x7e, 0xc4, 0x44, 0x6b, 0x16, 0xe9, 0x15, 0x8a, 0xe4, 0xe7, 0x90, 0x0e, 0x76, 0x90, 0x54,
+	0xfd, 0x15, 0x16, 0x00, 0x20, 0x3f, 0xc9, 0xde, 0x5f, 0xeb, 0xed, 0x9e, 0x70, 0xda, 0xac, 0xf1,
+	0x3c, 0xf6, 0x75, 0x49, 0xfe, 0x33, 0x09, 0x16, 0xc5, 0xa2, 0xd1, 0x49, 0xd8, 0xae, 0xe7, 0x4f,
+	0xc2, 0x76, 0x3d, 0x92, 0xbf, 0x3a, 0xb8, 0x6b, 0xbb, 0xa6, 0x67, 0x3b, 0x7d, 0x2e, 0x1f, 0xa0,
+	0x10, 0xc8, 0x99, 0x96, 0x8b, 0x9b, 0x3d, 0x07, 0x73, 0x3f, 0xef, 0xb7, 0xc9, 0xb0, 0x9e, 0xfd,
+	0x0a, 0x5b, 0x3c, 0x01, 0x62, 0x0d, 0x22, 0xd1, 0x73, 0xb1, 0x43, 0x51, 0xc5, 0x0b, 0x20, 0xa2,
+	0x4d, 0xfa, 0xba, 0xba, 0xeb, 0xbe, 0xb6, 0x1d, 0x43, 0x14, 0x40, 0x44, 0x5b, 0xfe, 0x89, 0x04,
+	0x99, 0xd0, 0x8e, 0x20, 0x05, 0x32, 0x7a, 0xb3, 0x89, 0x5d, 0x57, 0x7b, 0x85, 0xfb, 0x9a, 0x29,
+	0xd2, 0xeb, 0x14, 0x23, 0x7e, 0x13, 0xf7, 0x0f, 0x0d, 0xb4, 0x0b, 0xcb, 0x2e, 0x6e, 0x3a, 0xd8,
+	0xd3, 0x06, 0xac, 0x7c, 0x1a, 0x4b, 0xac, 0xa3, 0x22, 0xb8, 0x09, 0xe8, 0x5d, 0xec, 0xd2, 0xcc,
+	0x8d, 0xd9, 0xcd, 0xb3, 0x6e, 0x4e, 0x6c, 0x50, 0xf3, 0x69, 0xe6, 0xdf, 0x32, 0x6d, 0x31, 0x2b,
+	0xde, 0x22, 0xa6, 0x63, 0xcb, 0xe8, 0xda, 0xa6, 0xe5, 0x89, 0x69, 0x89, 0xf6, 0x60, 0x21, 0xe6,
+	0x03, 0x0b, 0xa1, 0x1c, 0x42, 0x3e, 0xbc, 0xdd, 0xdc, 0x09, 0x0d, 0x2e, 0x17, 0x69, 0xc6, 0xcb,
+	0x45, 0xf9, 0x83, 0x18, 0x2c, 0xfb, 0x30, 0xf1, 0x6f, 0xf7, 0xc1, 0xf9, 0x94, 0xc6, 0x9c, 0xcf,
+	0xd8, 0xdb, 0x39, 0x9f, 0xf1, 0xb7, 0x7a, 0x3e, 0xe7, 0xde, 0xc6, 0xf9, 0x54, 0xfe, 0x3e, 0x06,
+	0x28, 0xb8, 0x24, 0x7e, 0x8a, 0xb1, 0xe0, 0x60, 0xb7, 0xd7, 0xf6, 0x84, 0x7b, 0x7f, 0x7f, 0x74,
+	0x9c, 0xb0, 0x08, 0x0f, 0xc9, 0xa8, 0x90, 0x2a, 0x84, 0x29, 0x56, 0x9a, 0xba, 0x65, 0x61, 0x23,
+	0xe4, 0xcb, 0xd3, 0x9c, 0xc8, 0x1c, 0xf4, 0x8f, 0x25, 0x48, 0x05, 0xa4, 0x23, 0xbd, 0xc4, 0xdd,
+	0xe2, 0xfa, 0x59, 0xee, 0x07, 0x72, 0xbb, 0x0e, 0xea, 0x98, 0x8c, 0x8d, 0x05, 0xcc, 0x83, 0xf2,
+	0x26, 0x63, 0xcc, 0x43, 0x02, 0x3b, 0x0e, 0x2f, 0x44, 0x24, 0x55, 0xd6, 0x50, 0xfe, 0x37, 0x06,
+	0x39, 0xb2, 0x1c, 0x87, 0x1d, 0xb2, 0xbc, 0x53, 0x30, 0x55, 0x11, 0x51, 0x2d, 0x0b, 0x88, 0x6e,
+	0x05, 0x29, 0x26, 0xf9, 0xd6, 0x10, 0x15, 0x11, 0x54, 0xcc, 0xcd, 0x16, 0x54, 0x24, 0x66, 0x08,
+	0x2a, 0xe6, 0x47, 0x82, 0x8a, 0x08, 0x08, 0x2f, 0xbc, 0x15, 0x08, 0x7f, 0x2e, 0xc1, 0x72, 0x60,
+	0x03, 0x38, 0x82, 0x6b, 0xe1, 0xc4, 0xf4, 0xd6, 0x19, 0x1d, 0x5f, 0xed, 0xbb, 0xe1, 0x6e, 0xe0,
+	0x9b, 0xe2, 0xb3, 0xfa, 0xa6, 0x6d, 0x58, 0x7a, 0x81, 0xf9, 0x4e, 0x73, 0x10, 0x45, 0xd5, 0x98,
+	0xfe, 0x26, 0x06, 0xb9, 0x01, 0x1f, 0x9f, 0xeb, 0x2d, 0x92, 0xf0, 0xbb, 0xcd, 0x67, 0x1f, 0x96,
+	0x3b, 0xa6, 0xeb, 0x9a, 0x56, 0x4b, 0x1b, 0x48, 0xc7, 0x27, 0x4a, 0xe7, 0xb8, 0x40, 0x75, 0xfc,
+	0x61, 0x9c, 0x9b, 0xed, 0x30, 0x26, 0x22, 0x0f, 0xe3, 0x60, 0x89, 0xe7, 0x67, 0x5d, 0xe2, 0x9f,
+	0x83, 0xd5, 0x2a, 0x6e, 0x63, 0x0f, 0xdf, 0x25, 0x8c, 0x53, 0x0a, 0xb0, 0x36, 0x2c, 0xcd, 0x96,
+	0x5f, 0xd9, 0x01, 0xc4, 0x7a, 0xa6, 0xee, 0xde, 0x2a, 0xac, 0x84, 0x38, 0xb9, 0x82, 0x5f, 0x80,
+	0xfc, 0xa9, 0xd3, 0xb3, 0x70, 0xc5, 0xd2, 0xdb, 0x7d, 0x77, 0x90, 0x77, 0x3e, 0x81, 0x25, 0xbb,
+	0x6d, 0x60, 0x47, 0xf3, 0xae, 0x74, 0x4b, 0x33, 0xf4, 0x3e, 0xbb, 0xeb, 0x12, 0x6a, 0x86, 0x92,
+	0x1b, 0x57, 0xba, 0x55, 0xd5, 0xfb, 0xae, 0xd2, 0x81, 0xd5, 0x21, 0x79, 0x0e, 0x8c, 0x2f, 0x03,
+	0x32, 0xe8, 0x78, 0x86, 0x48, 0x4b, 0x45, 0xc0, 0x9e, 0x54, 0x97, 0x79, 0xcf, 0x20, 0xa1, 0x23,
+	0x35, 0x59, 0xc1, 0x1e, 0xb8, 0xf9, 0x92, 0x6a, 0x86, 0x53, 0x99, 0xc7, 0x57, 0xfe, 0x82, 0x95,
+	0xeb, 0x8e, 0x6d, 0xcf, 0xbc, 0x34, 0x9b, 0xf4, 0xe5, 0x48, 0x58, 0xfc, 0x01, 0xac, 0xd9, 0x6d,
+	0x43, 0x0b, 0x47, 0xf1, 0x5d, 0x72, 0xd6, 0xd9, 0x32, 0xe4, 0xed, 0xb6, 0x11, 0xca, 0x94, 0x4e,
+	0x49, 0x84, 0xf2, 0x01, 0xac, 0x59, 0xf8, 0x75, 0x94, 0x14, 0x0b, 0x41, 0xf2, 0x16, 0x7e, 0x3d,
+	0x2a, 0xe5, 0x97, 0x37, 0xe2, 0xc1, 0xf2, 0x86, 0x28, 0x0a, 0xcd, 0x0d, 0x8a, 0x42, 0xca, 0xff,
+	0xc4, 0xe0, 0xde, 0x88, 0xc1, 0x7e, 0x2e, 0x93, 0xb6, 0x02, 0x74, 0x7e, 0x84, 0xca, 0x23, 0xee,
+	0x22, 0x4a, 0xb8, 0x18, 0x22, 0x86, 0xf4, 0xc8, 0xdf, 0x8f, 0x41, 0x3a, 0xd8, 0x3d, 0xee, 0xe9,
+	0xa1, 0xe9, 0x60, 0xdd, 0xf3, 0x1f, 0x36, 0x44, 0x93, 0xc4, 0x49, 0x4c, 0x9d, 0xff, 0xaa, 0xe1,
+	0xb7, 0x89, 0x14, 0xdf, 0x10, 0x3e, 0x4b, 0xd1, 0x44, 0x1f, 0x42, 0xdc, 0x6e, 0x1b, 0x3c, 0x74,
+	0x7f, 0x6f, 0xc8, 0xaf, 0xea, 0x2d, 0xec, 0xaf, 0x7d, 0x3b, 0x98, 0xc7, 0x13, 0x19, 0x22, 0x6a,
+	0xe1, 0xd7, 0x85, 0xf9, 0x5b, 0x8a, 0x5a, 0xf8, 0x35, 0x7a, 0x10, 0x7c, 0xe2, 0x5a, 0xa0, 0x8e,
+	0x7f, 0x40, 0x50, 0xfe, 0x25, 0x06, 0xeb, 0x63, 0x15, 0x90, 0x7b, 0xa3, 0xd9, 0x73, 0x1c, 0x6c,
+	0x79, 0x41, 0x98, 0xa4, 0x38, 0x8d, 0xee, 0xf3, 0x7d, 0x48, 0x5a, 0xf8, 0xc6, 0x0b, 0x02, 0x62,
+	0x91, 0x10, 0x26, 0x80, 0xa0, 0x02, 0x99, 0x10, 0x98, 0x78, 0xb0, 0x34, 0x31, 0x65, 0x0f, 0x4b,
+	0xa0, 0x5f, 0x05, 0x08, 0x1c, 0x99, 0x04, 0xf5, 0x75, 0xdf, 0x98, 0x71, 0x59, 0x8a, 0x87, 0x96,
+	0x81, 0x6f, 0xfc, 0xa3, 0x45, 0xfd, 0x87, 0x1a, 0x50, 0x27, 0xff, 0x22, 0xac, 0x44, 0xb0, 0x90,
+	0xc9, 0x98, 0x84, 0xcc, 0x4f, 0x39, 0x6b, 0xf8, 0xc0, 0x89, 0x05, 0x10, 0xfd, 0x0c, 0x1e, 0x7e,
+	0xa2, 0x3b, 0xaf, 0x82, 0x00, 0xab, 0xb8, 0x2a, 0xd6, 0x8d, 0x80, 0xf7, 0x19, 0x46, 0x9b, 0xb2,
+	0x09, 0x8f, 0xc6, 0x09, 0x71, 0x47, 0xf4, 0x4d, 0xd8, 0x38, 0x32, 0x5d, 0xef, 0x14, 0x5b, 0x86,
+	0x69, 0xb5, 0x82, 0x8c, 0xbe, 0x4f, 0xf2, 0x17, 0x5c, 0x1a, 0x3a, 0x75, 0x81, 0xed, 0xa1, 0xbf,
+	0x95, 0x9f, 0xc6, 0x60, 0x73, 0xbc, 0x36, 0x7e, 0xfc, 0xae, 0x20, 0x13, 0x3c, 0x36, 0x22, 0xdc,
+	0xdc, 0x1b, 0xba, 0xc2, 0xa6, 0xa8, 0x29, 0x46, 0x74, 0xaa, 0x61, 0xc5, 0x53, 0x61, 0x14, 0x0c,
+	0x10, 0x59, 0x83, 0x94, 0xf4, 0x88, 0xa3, 0x25, 0x2f, 0x8c, 0x2d, 0xac, 0xb9, 0xb8, 0x69, 0x5b,
+	0x86, 0x4b, 0xb1, 0x14, 0x57, 0x73, 0xac, 0xa7, 0xd2, 0xc2, 0x75, 0x46, 0x97, 0x3f, 0x93, 0x60,
+	0x25, 0xc2, 0x8e, 0x31, 0x65, 0xea, 0xd0, 0xc1, 0x9f, 0xfc, 0xb6, 0xe3, 0x3b, 0x85, 0x0d, 0x48,
+	0x05, 0x0d, 0x89, 0x53, 0x43, 0x40, 0xf7, 0x4d, 0x50, 0xfe, 0x5d, 0x02, 0x59, 0xc5, 0xae, 0xdd,
+	0xbe, 0xc6, 0x51, 0xde, 0x39, 0xca, 0x92, 0x3a, 0x80, 0x43, 0x24, 0x7a, 0x9e, 0x78, 0x00, 0xcd,
+	0x96, 0x9f, 0x85, 0x57, 0x7f, 0xbc, 0xc6, 0xa2, 0xea, 0x8b, 0xaa, 0x01, 0x35, 0xca, 0xb7, 0x00,
+	0x06, 0x3d, 0x68, 0x0d, 0x90, 0x5a, 0xab, 0x9f, 0x1c, 0x9d, 0x35, 0x0e, 0x4f, 0x8e, 0x03, 0x8f,
+	0xa0, 0x05, 0xc8, 0x07, 0xe8, 0xd5, 0xda, 0xd1, 0xe1, 0x79, 0x4d, 0xad, 0x55, 0x73, 0xd2, 0x50,
+	0xcf, 0x7e, 0xe5, 0x78, 0xbf, 0x76, 0x74, 0x54, 0xab, 0xe6, 0x62, 0xca, 0x43, 0xb8, 0x1f, 0x69,
+	0x0e, 0x07, 0x70, 0x0d, 0xd6, 0x0f, 0xda, 0x3d, 0xf7, 0x2a, 0x12, 0xba, 0x3b, 0x90, 0x0b, 0x5c,
+	0xa7, 0x57, 0x76, 0xcf, 0x11, 0xf7, 0x69, 0xd6, 0xbf, 0x4f, 0x3f, 0x22, 0x54, 0xe5, 0x97, 0x40,
+	0x8e, 0x52, 0xc3, 0x31, 0xfb, 0x0c, 0x56, 0x2f, 0x49, 0x2f, 0x36, 0xb4, 0x51, 0xec, 0x26, 0xd5,
+	0x3c, 0xef, 0x0c, 0x09, 0x2b, 0x88, 0xc6, 0x6d, 0x3c, 0x22, 0x61, 0x06, 0x29, 0x07, 0xb0, 0x1c,
+	0xa0, 0xdd, 0x3d, 0xaf, 0xfd, 0xd1, 0x1c, 0x64, 0xd8, 0xdb, 0x1f, 0xef, 0x41, 0xcf, 0x49, 0xfa,
+	0x4d, 0x92, 0x29, 0xaa, 0x24, 0x5b, 0x56, 0xc2, 0x4a, 0x42, 0xcc, 0x45, 0x9e, 0xb4, 0x71, 0x09,
+	0xb4, 0x07, 0x4b, 0xf4, 0x01, 0xd2, 0xf5, 0x74, 0xc7, 0x9b, 0xf5, 0xfd, 0x91, 0x3e, 0xc4, 0xd7,
+	0x89, 0x04, 0xa1, 0xa1, 0x03, 0x58, 0x66, 0x3a, 0x7a, 0xac, 0x9c, 0x40, 0xb5, 0xc4, 0xa7, 0x6a,
+	0xa1, 0x03, 0xd7, 0x99, 0x0c, 0xd5, 0xf3, 0x10, 0x80, 0xea, 0x61, 0x79, 0x17, 0xbb, 0xed, 0x92,
+	0x84, 0x52, 0x23, 0x04, 0x72, 0x20, 0x4c, 0x4b, 0xeb, 0x3a, 0x76, 0xcb, 0xc1, 0xae, 0xcb, 0xcb,
+	0xde, 0x60, 0x5a, 0xa7, 0x9c, 0x42, 0x18, 0xda, 0x76, 0xf3, 0x95, 0x76, 0x45, 0x37, 0x98, 0x17,
+	0x16, 0x80, 0x90, 0x3e, 0xa2, 0x14, 0x74, 0x04, 0x79, 0xca, 0x80, 0x6f, 0xba, 0xa6, 0x43, 0xb7,
+	0x8a, 0xd9, 0xba, 0x30, 0xd5, 0x56, 0x44, 0xe4, 0x6a, 0xbe, 0x18, 0xe9, 0x50, 0xfe, 0x58, 0x82,
+	0x79, 0x9e, 0xc4, 0x3e, 0x86, 0x8d, 0xb3, 0xd3, 0x6a, 0xa5, 0x51, 0x53, 0xb5, 0x7a, 0xa3, 0xd2,
+	0x38, 0xab, 0x6b, 0x6a, 0xad, 0x7e, 0x76, 0xd4, 0xd0, 0x8e, 0x6b, 0xe7, 0x35, 0x55, 0x53, 0xcf,
+	0x8e, 0x73, 0xef, 0x8c, 0x67, 0xaa, 0x9f, 0xed, 0xef, 0xd7, 0x6a, 0x55, 0x7a, 0x18, 0x36, 0xe1,
+	0x41, 0x34, 0xd3, 0x41, 0xe5, 0x90, 0x1e, 0x0a, 0xb4, 0x0d, 0x5b, 0xd1, 0x1c, 0x87, 0xc7, 0xda,
+	0xa9, 0x7a, 0xf2, 0x42, 0xad, 0xd5, 0xeb, 0xb9, 0xb8, 0xb2, 0x4e, 0xa3, 0xa0, 0xd0, 0xde, 0x0b,
+	0x24, 0x9e, 0x40, 0x61, 0xb4, 0xcb, 0x87, 0x7b, 0x18, 0x90, 0xf7, 0x27, 0x60, 0xc9, 0x87, 0xe4,
+	0xdf, 0xc5, 0x21, 0xc9, 0x7a, 0x3e, 0xb6, 0x2f, 0x50, 0x16, 0x62, 0x7e, 0xdd, 0x29, 0x66, 0xd2,
+	0xe8, 0x86, 0x3d, 0x6f, 0xfb, 0x21, 0xa6, 0xdf, 0x46, 0xcf, 0x20, 0x41, 0x74, 0x88, 0x8f, 0x2e,
+	0x1e, 0x46, 0x8d, 0xf6, 0xb1, 0x7d, 0x51, 0x24, 0x03, 0x62, 0x95, 0xf1, 0x0e, 0x52, 0xf3, 0xb9,
+	0x40, 0x6a, 0x8e, 0x7e, 0x1e, 0xd2, 0xdc, 0x75, 0xb2, 0x4d, 0x4d, 0x4c, 0xdd, 0xd4, 0x14, 0xe7,
+	0xa7, 0xe0, 0xfb, 0x10, 0x20, 0x70, 0x06, 0xe6, 0xa7, 0x0a, 0x27, 0x5d, 0x1f, 0xff, 0xdf, 0x80,
+	0xd4, 0xa5, 0x69, 0x99, 0xee, 0xd5, 0xac, 0x68, 0x02, 0xc6, 0x4e, 0x51, 0xf4, 0x99, 0x04, 0x09,
+	0x3a, 0x3b, 0xf4, 0x00, 0x0a, 0x6c, 0x63, 0xb5, 0x8f, 0x4f, 0xf6, 0xe8, 0xde, 0xd6, 0xb4, 0xd3,
+	0xda, 0x71, 0xf5, 0xf0, 0xf8, 0x45, 0xee, 0x9d, 0xc8, 0x5e, 0xf5, 0xec, 0xf8, 0x98, 0xf4, 0x4a,
+	0xe8, 0x11, 0xc8, 0x23, 0xbd, 0x03, 0x58, 0xc5, 0xc8, 0x37, 0x26, 0x23, 0xfd, 0x1c, 0x51, 0x71,
+	0xa5, 0x0c, 0xf9, 0x86, 0x63, 0xb6, 0x5a, 0xd8, 0x61, 0x0b, 0x2e, 0x5c, 0x68, 0x70, 0xe3, 0xa4,
+	0xf0, 0xc6, 0x29, 0x7b, 0xb0, 0x3a, 0x24, 0xe3, 0xa7, 0xa7, 0xf1, 0x97, 0xf6, 0x45, 0x41, 0x8a,
+	0xfa, 0x6a, 0xc4, 0xdf, 0x4f, 0x95, 0xf0, 0x28, 0xdb, 0xf4, 0xc5, 0x7b, 0x40, 0xe4, 0xc3, 0x0e,
+	0xe1, 0x47, 0xa9, 0x40, 0x3e, 0xcc, 0x76, 0xfb, 0x91, 0xbe, 0x0d, 0xab, 0x24, 0xaa, 0xf0, 0xbf,
+	0x5a, 0x09, 0x96, 0x03, 0xbb, 0x0e, 0xbe, 0x34, 0x6f, 0x44, 0xe9, 0x86, 0xb5, 0x06, 0x81, 0x4f,
+	0x2c, 0x2a, 0xf0, 0x89, 0x07, 0x02, 0x1f, 0x0b, 0xd6, 0x86, 0x55, 0x73, 0xfb, 0x7e, 0x16, 0xc0,
+	0x4f, 0x63, 0x45, 0xa8, 0x33, 0xf6, 0x33, 0x9a, 0x00, 0xeb, 0xc4, 0xe0, 0x45, 0xf9, 0x6b, 0x09,
+	0x1e, 0xd4, 0x6e, 0xba, 0xb6, 0xe3, 0x9d, 0x87, 0x3f, 0x61, 0x11, 0x53, 0x1a, 0xfd, 0x52, 0x4f,
+	0x8a, 0xfa, 0x52, 0xaf, 0x02, 0xd9, 0x8e, 0x6d, 0xd0, 0x1c, 0x43, 0x73, 0x4d, 0xab, 0x39, 0x93,
+	0xdf, 0x17, 0x12, 0x75, 0x22, 0x80, 0xbe, 0x04, 0xcb, 0xa6, 0x45, 0xcb, 0x42, 0xda, 0x20, 0x25,
+	0x60, 0x05, 0xef, 0x1c, 0xef, 0x10, 0x9f, 0x93, 0x59, 0xca, 0xdf, 0x4a, 0x70, 0x9f, 0x2c, 0x14,
+	0x7f, 0x84, 0x3d, 0xb2, 0x87, 0xee, 0xeb, 0x2d, 0x10, 0xb5, 0x81, 0xa0, 0xd1, 0x29, 0x4e, 0x13,
+	0x2f, 0x63, 0x82, 0x25, 0xfc, 0x0d, 0x57, 0x96, 0x93, 0xcf, 0x07, 0x9f, 0x1b, 0x0d, 0x2d, 0x41,
+	0x3c, 0x6a, 0x09, 0xa2, 0x9f, 0xcc, 0xc5, 0x26, 0x27, 0x02, 0x9b, 0xfc, 0xa7, 0x31, 0x78, 0x10,
+	0x6d, 0x3c, 0xdf, 0xeb, 0x6f, 0x41, 0xb2, 0x6d, 0x87, 0xa3, 0xda, 0xe7, 0xa3, 0x51, 0xed, 0x38,
+	0xf1, 0xe2, 0x50, 0x87, 0x3a, 0x50, 0x36, 0x11, 0x0c, 0xf2, 0xf7, 0x25, 0x58, 0x1a, 0x92, 0x9d,
+	0xed, 0x99, 0x8a, 0x5e, 0xb5, 0x7d, 0xec, 0x68, 0xb4, 0x6a, 0x11, 0x13, 0x57, 0x6d, 0x1f, 0x3b,
+	0x1f, 0x91, 0x02, 0x6c, 0x09, 0x16, 0xf8, 0x92, 0xf2, 0x7b, 0x7c, 0xcc, 0xd3, 0xba, 0xe0, 0x2a,
+	0xff, 0x38, 0x05, 0x4b, 0xfe, 0x13, 0x20, 0x76, 0xae, 0xcd, 0x26, 0x46, 0x3d, 0x48, 0x05, 0x2a,
+	0x6f, 0x68, 0x73, 0x42, 0x51, 0x8e, 0x42, 0x40, 0xde, 0x9a, 0x5a, 0xb6, 0x53, 0xb6, 0x7e, 0xe3,
+	0x5f, 0xff, 0xe3, 0x87, 0xb1, 0xfb, 0x68, 0xbd, 0x24, 0xa6, 0x53, 0xfa, 0x34, 0x34, 0xdb, 0x37,
+	0xe8, 0xb7, 0x24, 0xc8, 0x86, 0xbf, 0x67, 0x40, 0x8f, 0xc3, 0x8a, 0x23, 0xbf, 0xd9, 0x90, 0xdf,
+	0x9d, 0xcc, 0x24, 0xca, 0x3f, 0xd4, 0x00, 0x05, 0x6d, 0x8e, 0x35, 0xa0, 0xe4, 0x52, 0xc9, 0xaf,
+	0x48, 0xe8, 0x15, 0xa4, 0x83, 0xf5, 0x4d, 0xb4, 0x35, 0xb5, 0xf6, 0x29, 0x2b, 0x93, 0x58, 0xb8,
+	0x09, 0x79, 0x6a, 0x42, 0xf6, 0xb9, 0xb4, 0xab, 0x24, 0x7d, 0x2b, 0x50, 0x13, 0x60, 0x50, 0xa7,
+	0x47, 0x1b, 0xe3, 0x2b, 0xf8, 0x6c, 0xa0, 0xcd, 0x69, 0x25, 0x7e, 0x05, 0xd1, 0x61, 0xd2, 0xca,
+	0x42, 0x89, 0x95, 0x87, 0x9e, 0x4b, 0xbb, 0x48, 0x87, 0x45, 0x51, 0x8f, 0x44, 0x0f, 0x47, 0x76,
+	0x2b, 0x58, 0x11, 0x93, 0x1f, 0x8d, 0xeb, 0xe6, 0xea, 0xd7, 0xa8, 0xfa, 0x1c, 0xca, 0x72, 0xf5,
+	0xa5, 0x4f, 0x09, 0x14, 0xdf, 0xa0, 0xef, 0x40, 0xd2, 0xaf, 0xef, 0xa2, 0x47, 0xa3, 0x56, 0x06,
+	0x2b, 0xef, 0xf2, 0xc6, 0xd8, 0xfe, 0x91, 0x49, 0x98, 0x84, 0x4e, 0x27, 0x41, 0x0e, 0xcd, 0xd0,
+	0x87, 0x5b, 0xe8, 0xdd, 0xf1, 0x0f, 0xda, 0x83, 0xef, 0xd3, 0xe4, 0xed, 0x29, 0x5c, 0x7c, 0xd0,
+	0x27, 0x74, 0xd0, 0x4d, 0xf4, 0x68, 0x02, 0x46, 0x2e, 0xec, 0x0e, 0xfa, 0x7d, 0x09, 0x96, 0x47,
+	0x3e, 0xb4, 0x40, 0x4f, 0xa6, 0x7e, 0x89, 0xc1, 0x8c, 0x79, 0x6f, 0xc6, 0x2f, 0x36, 0x94, 0xa7,
+	0xd4, 0x9c, 0xc7, 0x68, 0x6b, 0xbc, 0x39, 0x4d, 0x26, 0x8c, 0x7e, 0x10, 0xfe, 0x90, 0x90, 0x3f,
+	0xe6, 0xa3, 0xf7, 0xa6, 0x3f, 0xf7, 0x33, 0x9b, 0x76, 0x66, 0xfd, 0x2e, 0x60, 0x16, 0xa3, 0x5c,
+	0x3e, 0xfa, 0xf7, 0x20, 0x1b, 0xae, 0xc5, 0x0e, 0x9f, 0xe7, 0xc8, 0x3a, 0xaf, 0xfc, 0xee, 0x64,
+	0xa6, 0xb0, 0x43, 0xd9, 0x9d, 0xe0, 0x50, 0x5e, 0x42, 0x2a, 0x50, 0xc7, 0x1d, 0xf6, 0x63, 0xa3,
+	0xc5, 0x60, 0x79, 0x6b, 0x02, 0x47, 0x18, 0xfd, 0xbb, 0xc3, 0xe8, 0xf7, 0x20, 0x13, 0x2a, 0xee,
+	0xa2, 0x61, 0x87, 0x10, 0x51, 0x39, 0x96, 0x1f, 0x4f, 0xe4, 0xe1, 0x23, 0xca, 0x74, 0xc4, 0x3c,
+	0xf1, 0x1a, 0x4b, 0x25, 0x9d, 0xf7, 0x96, 0xba, 0x84, 0xb7, 0xfc, 0x9f, 0x09, 0x58, 0x09, 0x26,
+	0xb0, 0xc2, 0x83, 0xbf, 0xa1, 0x07, 0x25, 0xd8, 0x13, 0x71, 0x50, 0x22, 0x2a, 0x05, 0xf2, 0xf6,
+	0x14, 0x2e, 0x6e, 0xd3, 0x43, 0x6a, 0xd3, 0x3d, 0xb4, 0x5a, 0x0a, 0xa5, 0xd6, 0xa5, 0x4f, 0xd9,
+	0xc2, 0xff, 0x40, 0x82, 0xb5, 0xe8, 0x1a, 0x16, 0x1a, 0x7a, 0x4b, 0x9b, 0x58, 0x1e, 0x93, 0xdf,
+	0x9f, 0x8d, 0x39, 0x6c, 0xd4, 0xee, 0x18, 0xa3, 0xfe, 0x50, 0x82, 0xc2, 0xb8, 0x0a, 0x15, 0xfa,
+	0xf2, 0xac, 0x95, 0x2c, 0x66, 0x58, 0xf1, 0x76, 0x85, 0x2f, 0xe5, 0x01, 0x35, 0x6d, 0x0d, 0xe5,
+	0x4b, 0xba, 0xd1, 0x31, 0xad, 0xb0, 0x81, 0xe8, 0x8f, 0x24, 0x58, 0x89, 0x28, 0x97, 0xa0, 0x9d,
+	0x59, 0x0b, 0x3c, 0xf2, 0xd3, 0x19, 0x38, 0xb9, 0x29, 0x45, 0x6a, 0xca, 0x8e, 0xf2, 0x38, 0xca,
+	0x14, 0xbe, 0x56, 0x25, 0x87, 0x29, 0x20, 0x4e, 0xf7, 0xf7, 0x24, 0x40, 0xa3, 0x55, 0x96, 0x61,
+	0xcf, 0x32, 0xb6, 0x9c, 0x23, 0xef, 0x4c, 0x67, 0xe4, 0x96, 0x6d, 0x53, 0xcb, 0x36, 0x14, 0x39,
+	0xd2, 0x32, 0x5a, 0xae, 0x79, 0x2e, 0xed, 0x96, 0x7f, 0x27, 0x06, 0x39, 0x3f, 0xfc, 0x16, 0x80,
+	0xef, 0x42, 0x36, 0x1c, 0xcc, 0x0f, 0xbb, 0x9a, 0xc8, 0x2c, 0x42, 0x7e, 0x77, 0x32, 0x13, 0x37,
+	0x6c, 0x85, 0x1a, 0x96, 0x41, 0xa9, 0x52, 0x20, 0xd6, 0xff, 0x4d, 0x09, 0x56, 0x23, 0xc3, 0x79,
+	0x34, 0xf4, 0xd0, 0x3b, 0x29, 0xe6, 0x97, 0x27, 0xd5, 0xba, 0x95, 0x0d, 0x3a, 0xee, 0x3a, 0xba,
+	0x57, 0x1a, 0xfa, 0xe8, 0xbd, 0x84, 0xa9, 0xce, 0xaf, 0x48, 0xe5, 0x1f, 0x4a, 0x90, 0xe5, 0x31,
+	0x9d, 0x58, 0x8a, 0xcf, 0x24, 0xc8, 0x47, 0xc5, 0xac, 0xe8, 0xe9, 0x2c, 0x71, 0x2d, 0x33, 0x6b,
+	0x77, 0xf6, 0x10, 0x58, 0x59, 0xa6, 0x56, 0xa6, 0x50, 0xb2, 0x24, 0xbe, 0xea, 0x2c, 0xff, 0x73,
+	0x1c, 0x32, 0xac, 0xd2, 0x20, 0x8c, 0xfa, 0x35, 0x48, 0xfa, 0x35, 0x34, 0x34, 0x1a, 0x61, 0x84,
+	0xca, 0x1c, 0xf2, 0xc6, 0xd8, 0x7e, 0x3e, 0xe4, 0x12, 0x1d, 0x32, 0x89, 0x16, 0x4a, 0xfc, 0x33,
+	0xd4, 0x5f, 0xa7, 0x65, 0xbb, 0x70, 0x71, 0x6d, 0xd4, 0x95, 0x45, 0xd5, 0x54, 0xe4, 0x27, 0xd3,
+	0xd8, 0xf8, 0x98, 0xf7, 0xe8, 0x98, 0xcb, 0x68, 0xa9, 0xc4, 0x53, 0x69, 0x31, 0xb6, 0x03, 0x99,
+	0x50, 0x42, 0x3d, 0xec, 0xf9, 0xa3, 0x32, 0x74, 0xf9, 0xf1, 0x44, 0x1e, 0x3e, 0x64, 0x81, 0x0e,
+	0x89, 0x88, 0xe7, 0xcf, 0xf8, 0xa3, 0xbe, 0xb4, 0x2f, 0x5c, 0xf4, 0x5d, 0x48, 0x07, 0x33, 0x6b,
+	0xb4, 0x35, 0x66, 0x12, 0x83, 0xe4, 0x5c, 0x56, 0x26, 0xb1, 0x84, 0xaf, 0x1a, 0x84, 0x42, 0xa3,
+	0x95, 0x3e, 0x35, 0x8d, 0x37, 0x7b, 0x8f, 0x60, 0xa5, 0x69, 0x77, 0xc2, 0x4a, 0xba, 0x17, 0xbf,
+	0xb2, 0xc0, 0xff, 0xcd, 0xed, 0x62, 0x9e, 0xa6, 0x9d, 0xcf, 0xfe, 0x6f, 0x00, 0x6a, 0x44, 0x71,
+	0xbb, 0xff, 0x36, 0x00, 0x00,
 }
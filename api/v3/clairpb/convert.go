@@ -18,8 +18,11 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/golang/protobuf/ptypes"
+
 	"github.com/coreos/clair/database"
 	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/pkg/purl"
 )
 
 // DatabaseDetectorTypeMapping maps the database detector type to the integer
@@ -61,6 +64,7 @@ func PagedVulnerableAncestriesFromDatabaseModel(dbVuln *database.PagedVulnerable
 		}
 		vulnAncestry.Ancestries = append(vulnAncestry.Ancestries, &indexedAncestry)
 	}
+	sortIndexedAncestries(vulnAncestry.Ancestries)
 
 	return &vulnAncestry, nil
 }
@@ -96,6 +100,7 @@ func NotificationFromDatabaseModel(dbNotification database.VulnerabilityNotifica
 		return nil, err
 	}
 
+	noti.Withdrawn = dbNotification.New == nil && dbNotification.Old != nil && dbNotification.Old.Withdrawn != nil
 	return &noti, nil
 }
 
@@ -109,14 +114,25 @@ func VulnerabilityFromDatabaseModel(dbVuln database.Vulnerability) (*Vulnerabili
 		metaString = string(metadataByte)
 	}
 
-	return &Vulnerability{
+	vuln := &Vulnerability{
 		Name:          dbVuln.Name,
 		NamespaceName: dbVuln.Namespace.Name,
 		Description:   dbVuln.Description,
 		Link:          dbVuln.Link,
 		Severity:      string(dbVuln.Severity),
 		Metadata:      metaString,
-	}, nil
+	}
+
+	if dbVuln.Withdrawn != nil {
+		withdrawnTime, err := ptypes.TimestampProto(dbVuln.Withdrawn.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		vuln.Withdrawn = &Vulnerability_Withdrawal{Time: withdrawnTime, Reason: dbVuln.Withdrawn.Reason}
+	}
+
+	return vuln, nil
 }
 
 func VulnerabilityWithFixedInFromDatabaseModel(dbVuln database.VulnerabilityWithFixedIn) (*Vulnerability, error) {
@@ -126,6 +142,41 @@ func VulnerabilityWithFixedInFromDatabaseModel(dbVuln database.VulnerabilityWith
 	}
 
 	vuln.FixedBy = dbVuln.FixedInVersion
+	vuln.FixAvailable = dbVuln.FixedInVersion != ""
+	vuln.Tag = dbVuln.Tag
+	for _, r := range dbVuln.AffectedRanges {
+		vuln.AffectedRanges = append(vuln.AffectedRanges, &Vulnerability_AffectedRange{
+			Introduced:   r.IntroducedInVersion,
+			Fixed:        r.FixedInVersion,
+			LastAffected: r.LastAffectedVersion,
+		})
+	}
+	return vuln, nil
+}
+
+// VulnerabilityWithAffectedFromDatabaseModel converts a database vulnerability
+// to an api Vulnerability listing its affected features.
+func VulnerabilityWithAffectedFromDatabaseModel(dbVuln database.VulnerabilityWithAffected) (*Vulnerability, error) {
+	vuln, err := VulnerabilityFromDatabaseModel(dbVuln.Vulnerability)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, affected := range dbVuln.Affected {
+		version := affected.AffectedVersion
+		if version == versionfmt.MaxVersion {
+			version = "None"
+		}
+
+		vuln.AffectedVersions = append(vuln.AffectedVersions, &Feature{
+			Name:          affected.FeatureName,
+			Namespace:     &Namespace{Name: affected.Namespace.Name},
+			VersionFormat: affected.Namespace.VersionFormat,
+			Version:       version,
+		})
+	}
+	SortFeatures(vuln.AffectedVersions)
+
 	return vuln, nil
 }
 
@@ -136,7 +187,7 @@ func NamespacedFeatureFromDatabaseModel(feature database.AncestryFeature) *Featu
 		version = "None"
 	}
 
-	return &Feature{
+	f := &Feature{
 		Name: feature.Feature.Name,
 		Namespace: &Namespace{
 			Name:     feature.Namespace.Name,
@@ -146,6 +197,12 @@ func NamespacedFeatureFromDatabaseModel(feature database.AncestryFeature) *Featu
 		Version:       version,
 		Detector:      DetectorFromDatabaseModel(feature.FeatureBy),
 	}
+
+	if p, ok := purl.New(feature.FeatureBy.Name, feature.Namespace.Name, feature.Feature.Name, feature.Feature.Version); ok {
+		f.Purl = p.String()
+	}
+
+	return f
 }
 
 func DetectorFromDatabaseModel(detector database.Detector) *Detector {
@@ -161,6 +218,7 @@ func DetectorsFromDatabaseModel(dbDetectors []database.Detector) []*Detector {
 	for _, d := range dbDetectors {
 		detectors = append(detectors, DetectorFromDatabaseModel(d))
 	}
+	sortDetectors(detectors)
 
 	return detectors
 }
@@ -0,0 +1,73 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clairpb
+
+import "sort"
+
+// The lists of the responses are sorted so that identical results are always
+// serialized identically, whatever the order in which the database returned
+// them.
+
+// SortVulnerabilities sorts vulnerabilities by name, then namespace.
+func SortVulnerabilities(vulns []*Vulnerability) {
+	sort.SliceStable(vulns, func(i, j int) bool {
+		if vulns[i].Name != vulns[j].Name {
+			return vulns[i].Name < vulns[j].Name
+		}
+		return vulns[i].NamespaceName < vulns[j].NamespaceName
+	})
+}
+
+// SortFeatures sorts features by name, then namespace, then version.
+func SortFeatures(features []*Feature) {
+	sort.SliceStable(features, func(i, j int) bool {
+		if features[i].Name != features[j].Name {
+			return features[i].Name < features[j].Name
+		}
+
+		if ni, nj := namespaceName(features[i]), namespaceName(features[j]); ni != nj {
+			return ni < nj
+		}
+		return features[i].Version < features[j].Version
+	})
+}
+
+func namespaceName(feature *Feature) string {
+	if feature.Namespace == nil {
+		return ""
+	}
+	return feature.Namespace.Name
+}
+
+// sortDetectors sorts detectors by type, then name, then version.
+func sortDetectors(detectors []*Detector) {
+	sort.SliceStable(detectors, func(i, j int) bool {
+		if detectors[i].Dtype != detectors[j].Dtype {
+			return detectors[i].Dtype < detectors[j].Dtype
+		}
+
+		if detectors[i].Name != detectors[j].Name {
+			return detectors[i].Name < detectors[j].Name
+		}
+		return detectors[i].Version < detectors[j].Version
+	})
+}
+
+// sortIndexedAncestries sorts the ancestries of a page by index.
+func sortIndexedAncestries(ancestries []*PagedVulnerableAncestries_IndexedAncestryName) {
+	sort.Slice(ancestries, func(i, j int) bool {
+		return ancestries[i].Index < ancestries[j].Index
+	})
+}
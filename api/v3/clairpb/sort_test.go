@@ -0,0 +1,107 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clairpb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortVulnerabilities(t *testing.T) {
+	// The vulnerabilities that only differ by their other fields keep their
+	// order.
+	first := &Vulnerability{Name: "CVE-2018-0002", NamespaceName: "debian:9", Severity: "High"}
+	second := &Vulnerability{Name: "CVE-2018-0002", NamespaceName: "debian:9", Severity: "Low"}
+
+	vulns := []*Vulnerability{
+		{Name: "CVE-2018-0003", NamespaceName: "debian:8"},
+		first,
+		{Name: "CVE-2018-0002", NamespaceName: "debian:8"},
+		second,
+		{Name: "CVE-2018-0001", NamespaceName: "debian:9"},
+	}
+	SortVulnerabilities(vulns)
+
+	assert.Equal(t, []*Vulnerability{
+		{Name: "CVE-2018-0001", NamespaceName: "debian:9"},
+		{Name: "CVE-2018-0002", NamespaceName: "debian:8"},
+		first,
+		second,
+		{Name: "CVE-2018-0003", NamespaceName: "debian:8"},
+	}, vulns)
+	assert.True(t, vulns[2] == first && vulns[3] == second)
+}
+
+func TestSortFeatures(t *testing.T) {
+	debian8, debian9 := &Namespace{Name: "debian:8"}, &Namespace{Name: "debian:9"}
+	first := &Feature{Name: "openssl", Namespace: debian9, Version: "1.0", VersionFormat: "dpkg"}
+	second := &Feature{Name: "openssl", Namespace: debian9, Version: "1.0", VersionFormat: "rpm"}
+
+	features := []*Feature{
+		{Name: "zlib", Namespace: debian8, Version: "1.2"},
+		first,
+		{Name: "openssl", Namespace: debian9, Version: "0.9"},
+		second,
+		{Name: "openssl", Namespace: debian8, Version: "1.1"},
+		// The features without namespace come first.
+		{Name: "openssl", Version: "2.0"},
+	}
+	SortFeatures(features)
+
+	assert.Equal(t, []*Feature{
+		{Name: "openssl", Version: "2.0"},
+		{Name: "openssl", Namespace: debian8, Version: "1.1"},
+		{Name: "openssl", Namespace: debian9, Version: "0.9"},
+		first,
+		second,
+		{Name: "zlib", Namespace: debian8, Version: "1.2"},
+	}, features)
+	assert.True(t, features[3] == first && features[4] == second)
+}
+
+func TestSortDetectors(t *testing.T) {
+	detectors := []*Detector{
+		{Name: "dpkg", Version: "1.0", Dtype: Detector_DETECTOR_D_TYPE_FEATURE},
+		{Name: "os-release", Version: "1.0", Dtype: Detector_DETECTOR_D_TYPE_NAMESPACE},
+		{Name: "apk", Version: "1.0", Dtype: Detector_DETECTOR_D_TYPE_FEATURE},
+		{Name: "dpkg", Version: "0.9", Dtype: Detector_DETECTOR_D_TYPE_FEATURE},
+		{Name: "apt-sources", Version: "1.0", Dtype: Detector_DETECTOR_D_TYPE_NAMESPACE},
+	}
+	sortDetectors(detectors)
+
+	assert.Equal(t, []*Detector{
+		{Name: "apt-sources", Version: "1.0", Dtype: Detector_DETECTOR_D_TYPE_NAMESPACE},
+		{Name: "os-release", Version: "1.0", Dtype: Detector_DETECTOR_D_TYPE_NAMESPACE},
+		{Name: "apk", Version: "1.0", Dtype: Detector_DETECTOR_D_TYPE_FEATURE},
+		{Name: "dpkg", Version: "0.9", Dtype: Detector_DETECTOR_D_TYPE_FEATURE},
+		{Name: "dpkg", Version: "1.0", Dtype: Detector_DETECTOR_D_TYPE_FEATURE},
+	}, detectors)
+}
+
+func TestSortIndexedAncestries(t *testing.T) {
+	ancestries := []*PagedVulnerableAncestries_IndexedAncestryName{
+		{Index: 3, Name: "c"},
+		{Index: 1, Name: "b"},
+		{Index: 2, Name: "a"},
+	}
+	sortIndexedAncestries(ancestries)
+
+	assert.Equal(t, []*PagedVulnerableAncestries_IndexedAncestryName{
+		{Index: 1, Name: "b"},
+		{Index: 2, Name: "a"},
+		{Index: 3, Name: "c"},
+	}, ancestries)
+}
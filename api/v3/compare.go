@@ -0,0 +1,138 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+
+	pb "github.com/coreos/clair/api/v3/clairpb"
+	"github.com/coreos/clair/database"
+)
+
+// ancestryVulnerabilityKey identifies a vulnerability across the features of
+// the ancestries.
+type ancestryVulnerabilityKey struct {
+	name      string
+	namespace string
+}
+
+// CompareAncestries implements comparing the vulnerabilities of two
+// ancestries via the Clair gRPC service.
+func (s *AncestryServer) CompareAncestries(ctx context.Context, req *pb.CompareAncestriesRequest) (*pb.CompareAncestriesResponse, error) {
+	if req.GetBaseAncestryName() == "" {
+		return nil, newError(ErrorCodeInvalidArgument, "base ancestry name should not be empty")
+	}
+
+	resp, err := s.GetAncestry(ctx, &pb.GetAncestryRequest{
+		AncestryName:   req.GetAncestryName(),
+		WithSuppressed: req.GetWithSuppressed(),
+		ExcludedTags:   req.GetExcludedTags(),
+		OnlyFixable:    req.GetOnlyFixable(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := s.GetAncestry(ctx, &pb.GetAncestryRequest{
+		AncestryName:   req.GetBaseAncestryName(),
+		WithSuppressed: req.GetWithSuppressed(),
+		ExcludedTags:   req.GetExcludedTags(),
+		OnlyFixable:    req.GetOnlyFixable(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CompareAncestriesResponse{
+		Severities: compareAncestryVulnerabilities(ancestryVulnerabilities(resp.GetAncestry()), ancestryVulnerabilities(base.GetAncestry())),
+		Status:     resp.GetStatus(),
+	}, nil
+}
+
+// ancestryVulnerabilities returns the vulnerabilities of the features of an
+// ancestry, with the features that they affect.
+//
+// The fields of a vulnerability that depend on the feature, e.g. fixed_by,
+// are those of the first feature that it affects in the ancestry.
+func ancestryVulnerabilities(ancestry *pb.GetAncestryResponse_Ancestry) map[ancestryVulnerabilityKey]*pb.Vulnerability {
+	vulns := make(map[ancestryVulnerabilityKey]*pb.Vulnerability)
+	for _, layer := range ancestry.GetLayers() {
+		for _, feature := range layer.GetDetectedFeatures() {
+			affected := proto.Clone(feature).(*pb.Feature)
+			affected.Vulnerabilities = nil
+
+			for _, vuln := range feature.GetVulnerabilities() {
+				key := ancestryVulnerabilityKey{vuln.GetName(), vuln.GetNamespaceName()}
+				if _, ok := vulns[key]; !ok {
+					vulns[key] = vuln
+				}
+				vulns[key].AffectedVersions = append(vulns[key].AffectedVersions, affected)
+			}
+		}
+	}
+
+	for _, vuln := range vulns {
+		pb.SortFeatures(vuln.AffectedVersions)
+	}
+
+	return vulns
+}
+
+// compareAncestryVulnerabilities groups the vulnerabilities added to,
+// removed from and kept from the base vulnerabilities by severity, from the
+// highest. The vulnerabilities with an unknown severity are grouped under
+// Unknown.
+func compareAncestryVulnerabilities(vulns, baseVulns map[ancestryVulnerabilityKey]*pb.Vulnerability) []*pb.CompareAncestriesResponse_SeverityDiff {
+	diffs := make(map[database.Severity]*pb.CompareAncestriesResponse_SeverityDiff)
+	diff := func(s string) *pb.CompareAncestriesResponse_SeverityDiff {
+		severity, _ := database.NewSeverity(s)
+		if _, ok := diffs[severity]; !ok {
+			diffs[severity] = &pb.CompareAncestriesResponse_SeverityDiff{Severity: string(severity)}
+		}
+		return diffs[severity]
+	}
+
+	for key, vuln := range vulns {
+		d := diff(vuln.GetSeverity())
+		if _, ok := baseVulns[key]; ok {
+			d.Unchanged = append(d.Unchanged, vuln)
+		} else {
+			d.Added = append(d.Added, vuln)
+		}
+	}
+
+	for key, vuln := range baseVulns {
+		if _, ok := vulns[key]; !ok {
+			d := diff(vuln.GetSeverity())
+			d.Removed = append(d.Removed, vuln)
+		}
+	}
+
+	var severities []*pb.CompareAncestriesResponse_SeverityDiff
+	for i := len(database.Severities) - 1; i >= 0; i-- {
+		d, ok := diffs[database.Severities[i]]
+		if !ok {
+			continue
+		}
+
+		pb.SortVulnerabilities(d.Added)
+		pb.SortVulnerabilities(d.Removed)
+		pb.SortVulnerabilities(d.Unchanged)
+		severities = append(severities, d)
+	}
+
+	return severities
+}
@@ -0,0 +1,137 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/coreos/clair/api/v3/clairpb"
+)
+
+func testVulnerability(name, namespace, severity string) *pb.Vulnerability {
+	return &pb.Vulnerability{Name: name, NamespaceName: namespace, Severity: severity}
+}
+
+func vulnerabilitiesOf(vulns ...*pb.Vulnerability) map[ancestryVulnerabilityKey]*pb.Vulnerability {
+	m := make(map[ancestryVulnerabilityKey]*pb.Vulnerability)
+	for _, vuln := range vulns {
+		m[ancestryVulnerabilityKey{vuln.Name, vuln.NamespaceName}] = vuln
+	}
+	return m
+}
+
+func TestCompareAncestryVulnerabilities(t *testing.T) {
+	var (
+		critical = testVulnerability("CVE-1", "debian:9", "Critical")
+		high     = testVulnerability("CVE-2", "debian:9", "High")
+		// The same vulnerability in another namespace is another one.
+		highOther = testVulnerability("CVE-2", "debian:10", "High")
+		low       = testVulnerability("CVE-3", "debian:9", "Low")
+		unknown   = testVulnerability("CVE-4", "debian:9", "Unknown")
+		// The unknown severities are grouped under Unknown, instead of being
+		// dropped.
+		empty   = testVulnerability("CVE-5", "debian:9", "")
+		invalid = testVulnerability("CVE-6", "debian:9", "Urgent")
+	)
+
+	for _, test := range []struct {
+		name            string
+		vulns, baseVuln map[ancestryVulnerabilityKey]*pb.Vulnerability
+		expected        []*pb.CompareAncestriesResponse_SeverityDiff
+	}{
+		{
+			name: "no vulnerabilities",
+		},
+		{
+			name:  "added",
+			vulns: vulnerabilitiesOf(high, critical),
+			expected: []*pb.CompareAncestriesResponse_SeverityDiff{
+				{Severity: "Critical", Added: []*pb.Vulnerability{critical}},
+				{Severity: "High", Added: []*pb.Vulnerability{high}},
+			},
+		},
+		{
+			name:     "removed",
+			baseVuln: vulnerabilitiesOf(low),
+			expected: []*pb.CompareAncestriesResponse_SeverityDiff{
+				{Severity: "Low", Removed: []*pb.Vulnerability{low}},
+			},
+		},
+		{
+			name:     "added, removed and unchanged",
+			vulns:    vulnerabilitiesOf(critical, high, highOther),
+			baseVuln: vulnerabilitiesOf(high, low),
+			expected: []*pb.CompareAncestriesResponse_SeverityDiff{
+				{Severity: "Critical", Added: []*pb.Vulnerability{critical}},
+				{Severity: "High", Added: []*pb.Vulnerability{highOther}, Unchanged: []*pb.Vulnerability{high}},
+				{Severity: "Low", Removed: []*pb.Vulnerability{low}},
+			},
+		},
+		{
+			name:     "unknown severities",
+			vulns:    vulnerabilitiesOf(unknown, empty, invalid),
+			baseVuln: vulnerabilitiesOf(unknown),
+			expected: []*pb.CompareAncestriesResponse_SeverityDiff{
+				{Severity: "Unknown", Added: []*pb.Vulnerability{empty, invalid}, Unchanged: []*pb.Vulnerability{unknown}},
+			},
+		},
+	} {
+		assert.Equal(t, test.expected, compareAncestryVulnerabilities(test.vulns, test.baseVuln), test.name)
+	}
+}
+
+func TestAncestryVulnerabilities(t *testing.T) {
+	namespace := &pb.Namespace{Name: "debian:9"}
+	openssl := &pb.Feature{Name: "openssl", Namespace: namespace, Version: "1.0", Vulnerabilities: []*pb.Vulnerability{
+		{Name: "CVE-1", NamespaceName: "debian:9", FixedBy: "1.1"},
+	}}
+	libssl := &pb.Feature{Name: "libssl", Namespace: namespace, Version: "1.0", Vulnerabilities: []*pb.Vulnerability{
+		{Name: "CVE-1", NamespaceName: "debian:9", FixedBy: "1.2"},
+		{Name: "CVE-2", NamespaceName: "debian:9"},
+	}}
+
+	for _, test := range []struct {
+		name     string
+		ancestry *pb.GetAncestryResponse_Ancestry
+		expected map[ancestryVulnerabilityKey]*pb.Vulnerability
+	}{
+		{
+			name:     "no ancestry",
+			expected: map[ancestryVulnerabilityKey]*pb.Vulnerability{},
+		},
+		{
+			name: "vulnerabilities of several features and layers",
+			ancestry: &pb.GetAncestryResponse_Ancestry{Layers: []*pb.GetAncestryResponse_AncestryLayer{
+				{DetectedFeatures: []*pb.Feature{openssl}},
+				{DetectedFeatures: []*pb.Feature{libssl}},
+			}},
+			expected: map[ancestryVulnerabilityKey]*pb.Vulnerability{
+				// The fields of the first feature affected are kept, and the
+				// features are sorted.
+				{"CVE-1", "debian:9"}: {Name: "CVE-1", NamespaceName: "debian:9", FixedBy: "1.1", AffectedVersions: []*pb.Feature{
+					{Name: "libssl", Namespace: namespace, Version: "1.0"},
+					{Name: "openssl", Namespace: namespace, Version: "1.0"},
+				}},
+				{"CVE-2", "debian:9"}: {Name: "CVE-2", NamespaceName: "debian:9", AffectedVersions: []*pb.Feature{
+					{Name: "libssl", Namespace: namespace, Version: "1.0"},
+				}},
+			},
+		},
+	} {
+		assert.Equal(t, test.expected, ancestryVulnerabilities(test.ancestry), test.name)
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/coreos/clair"
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/imagefmt"
+	"github.com/coreos/clair/ext/objectstore"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/grpcutil"
+	"github.com/coreos/clair/pkg/pagination"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+// ErrorCode is the stable machine-readable cause of an error of the API. It is
+// sent in the x-error-code trailer of the gRPC responses and in the error_code
+// field of the REST responses.
+type ErrorCode string
+
+// The causes of the errors of the API.
+const (
+	// ErrorCodeInvalidArgument is the cause of the malformed requests.
+	ErrorCodeInvalidArgument ErrorCode = "INVALID_ARGUMENT"
+	// ErrorCodeUnauthenticated is the cause of the requests without valid
+	// credentials.
+	ErrorCodeUnauthenticated ErrorCode = "UNAUTHENTICATED"
+	// ErrorCodePermissionDenied is the cause of the requests that are not
+	// allowed.
+	ErrorCodePermissionDenied ErrorCode = "PERMISSION_DENIED"
+	// ErrorCodeNotFound is the cause of the requests of unknown resources.
+	ErrorCodeNotFound ErrorCode = "NOT_FOUND"
+	// ErrorCodeFailedPrecondition is the cause of the requests that Clair
+	// cannot serve in its current configuration.
+	ErrorCodeFailedPrecondition ErrorCode = "FAILED_PRECONDITION"
+	// ErrorCodeUnprocessableLayer is the cause of the analyses of layers that
+	// cannot be found, extracted or analyzed, which fail again if retried.
+	ErrorCodeUnprocessableLayer ErrorCode = "UNPROCESSABLE_LAYER"
+	// ErrorCodeLayerUnavailable is the cause of the analyses of layers that
+	// could not be downloaded, which may succeed if retried.
+	ErrorCodeLayerUnavailable ErrorCode = "LAYER_UNAVAILABLE"
+	// ErrorCodeRateLimited is the cause of the requests of the clients that
+	// exceeded their rate limit, which can be retried after the delay given in
+	// the retry-after trailer.
+	ErrorCodeRateLimited ErrorCode = "RATE_LIMITED"
+	// ErrorCodeOverloaded is the cause of the analyses rejected because too
+	// many of them are already waiting for their turn, which can be retried
+	// after the delay given in the retry-after trailer.
+	ErrorCodeOverloaded ErrorCode = "OVERLOADED"
+	// ErrorCodeRequestTooLarge is the cause of the requests whose body
+	// exceeds the maximum size.
+	ErrorCodeRequestTooLarge ErrorCode = "REQUEST_TOO_LARGE"
+	// ErrorCodeCanceled is the cause of the requests canceled by the client.
+	ErrorCodeCanceled ErrorCode = "CANCELED"
+	// ErrorCodeTimeout is the cause of the requests that exceeded the timeout
+	// of the API.
+	ErrorCodeTimeout ErrorCode = "TIMEOUT"
+	// ErrorCodeDatabaseUnavailable is the cause of the requests that failed
+	// because the database could not be queried, which may succeed if retried.
+	ErrorCodeDatabaseUnavailable ErrorCode = "DATABASE_UNAVAILABLE"
+	// ErrorCodeInternal is the cause of the unexpected errors.
+	ErrorCodeInternal ErrorCode = "INTERNAL"
+)
+
+// errorStatuses are the gRPC and HTTP statuses of the errors by cause.
+var errorStatuses = map[ErrorCode]struct {
+	grpc codes.Code
+	http int
+}{
+	ErrorCodeInvalidArgument:     {codes.InvalidArgument, http.StatusBadRequest},
+	ErrorCodeUnauthenticated:     {codes.Unauthenticated, http.StatusUnauthorized},
+	ErrorCodePermissionDenied:    {codes.PermissionDenied, http.StatusForbidden},
+	ErrorCodeNotFound:            {codes.NotFound, http.StatusNotFound},
+	ErrorCodeFailedPrecondition:  {codes.FailedPrecondition, http.StatusPreconditionFailed},
+	ErrorCodeUnprocessableLayer:  {codes.InvalidArgument, http.StatusUnprocessableEntity},
+	ErrorCodeLayerUnavailable:    {codes.Unavailable, http.StatusBadGateway},
+	ErrorCodeRateLimited:         {codes.ResourceExhausted, http.StatusTooManyRequests},
+	ErrorCodeOverloaded:          {codes.Unavailable, http.StatusServiceUnavailable},
+	ErrorCodeRequestTooLarge:     {codes.ResourceExhausted, http.StatusRequestEntityTooLarge},
+	ErrorCodeCanceled:            {codes.Canceled, http.StatusRequestTimeout},
+	ErrorCodeTimeout:             {codes.DeadlineExceeded, http.StatusGatewayTimeout},
+	ErrorCodeDatabaseUnavailable: {codes.Unavailable, http.StatusServiceUnavailable},
+	ErrorCodeInternal:            {codes.Internal, http.StatusInternalServerError},
+}
+
+// apiError is an error of the API, reported with its cause by the gRPC
+// services and the Gateway.
+type apiError struct {
+	code    ErrorCode
+	message string
+}
+
+// newError returns an error of the API with the given cause.
+func newError(code ErrorCode, message string) error {
+	return &apiError{code: code, message: message}
+}
+
+// errorf returns an error of the API with the given cause and formatted
+// message.
+func errorf(code ErrorCode, format string, args ...interface{}) error {
+	return newError(code, fmt.Sprintf(format, args...))
+}
+
+// clairError converts an internal error to an error of the API, reporting its
+// cause.
+func clairError(err error) error {
+	return newError(errorCodeOf(err), err.Error())
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// ErrorCode implements grpcutil.CodedError.
+func (e *apiError) ErrorCode() string {
+	return string(e.code)
+}
+
+// GRPCStatus implements grpcutil.CodedError.
+func (e *apiError) GRPCStatus() *status.Status {
+	return status.New(errorStatuses[e.code].grpc, e.message)
+}
+
+// errorCodeOf returns the cause of an internal error.
+func errorCodeOf(err error) ErrorCode {
+	switch err {
+	case context.Canceled:
+		return ErrorCodeCanceled
+	case context.DeadlineExceeded:
+		return ErrorCodeTimeout
+	case commonerr.ErrNotFound:
+		return ErrorCodeNotFound
+	case pagination.ErrInvalidToken:
+		return ErrorCodeInvalidArgument
+	case imagefmt.ErrCouldNotFindLayer, imagefmt.ErrRegistryUnauthorized, objectstore.ErrUnauthorized, clair.ErrUnsupported,
+		tarutil.ErrCouldNotExtract, tarutil.ErrExtractedFileTooBig, tarutil.ErrExtractedSizeTooBig:
+		return ErrorCodeUnprocessableLayer
+	case commonerr.ErrCouldNotDownload:
+		return ErrorCodeLayerUnavailable
+	case database.ErrBackendException:
+		return ErrorCodeDatabaseUnavailable
+	}
+
+	if _, ok := err.(*commonerr.ErrBadRequest); ok {
+		return ErrorCodeInvalidArgument
+	}
+	return ErrorCodeInternal
+}
+
+// grpcErrorCode returns the cause of an error from its gRPC code only.
+func grpcErrorCode(code codes.Code) ErrorCode {
+	switch code {
+	case codes.InvalidArgument:
+		return ErrorCodeInvalidArgument
+	case codes.Unauthenticated:
+		return ErrorCodeUnauthenticated
+	case codes.PermissionDenied:
+		return ErrorCodePermissionDenied
+	case codes.NotFound:
+		return ErrorCodeNotFound
+	case codes.FailedPrecondition:
+		return ErrorCodeFailedPrecondition
+	case codes.ResourceExhausted:
+		return ErrorCodeRateLimited
+	case codes.Canceled:
+		return ErrorCodeCanceled
+	case codes.DeadlineExceeded:
+		return ErrorCodeTimeout
+	}
+	return ErrorCodeInternal
+}
+
+// httpErrorBody is the body of the error responses of the Gateway.
+type httpErrorBody struct {
+	Error     string `json:"error"`
+	Code      int32  `json:"code"`
+	ErrorCode string `json:"error_code"`
+}
+
+// writeHTTPError replies to a request that is not forwarded to the gRPC
+// services with the given error, as the Gateway would.
+func writeHTTPError(w http.ResponseWriter, code ErrorCode, message string) {
+	buf, err := json.Marshal(httpErrorBody{Error: message, Code: int32(errorStatuses[code].grpc), ErrorCode: string(code)})
+	if err != nil {
+		log.WithError(err).Error("could not marshal the error response")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(errorStatuses[code].http)
+	if _, err := w.Write(buf); err != nil {
+		log.WithError(err).Debug("could not write the error response")
+	}
+}
+
+// httpError replies to the failed requests of the Gateway with the cause of
+// their error, sent by the gRPC services in their trailers, and its HTTP
+// status.
+func httpError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	s, ok := status.FromError(err)
+	if !ok {
+		s = status.New(codes.Unknown, err.Error())
+	}
+
+	// The errors of the Gateway itself, such as the malformed requests, have
+	// no cause in their trailers.
+	code := grpcErrorCode(s.Code())
+	httpStatus := runtime.HTTPStatusFromCode(s.Code())
+
+	md, _ := runtime.ServerMetadataFromContext(ctx)
+	if trailer := md.TrailerMD[grpcutil.ErrorCodeTrailer]; len(trailer) > 0 {
+		code = ErrorCode(trailer[0])
+		if errorStatus, ok := errorStatuses[code]; ok {
+			httpStatus = errorStatus.http
+		}
+	}
+
+	body := httpErrorBody{Error: s.Message(), Code: int32(s.Code()), ErrorCode: string(code)}
+
+	buf, err := marshaler.Marshal(body)
+	if err != nil {
+		log.WithError(err).Error("could not marshal the error response")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Del("Trailer")
+	w.Header().Set("Content-Type", marshaler.ContentType())
+	if retryAfter := md.TrailerMD[RetryAfterTrailer]; len(retryAfter) > 0 {
+		w.Header().Set("Retry-After", retryAfter[0])
+	}
+	w.WriteHeader(httpStatus)
+	if _, err := w.Write(buf); err != nil {
+		log.WithError(err).Debug("could not write the error response")
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/imagefmt"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/grpcutil"
+	"github.com/coreos/clair/pkg/pagination"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	for _, test := range []struct {
+		err      error
+		expected ErrorCode
+	}{
+		{context.Canceled, ErrorCodeCanceled},
+		{context.DeadlineExceeded, ErrorCodeTimeout},
+		{commonerr.ErrNotFound, ErrorCodeNotFound},
+		{pagination.ErrInvalidToken, ErrorCodeInvalidArgument},
+		{commonerr.NewBadRequestError("bad request"), ErrorCodeInvalidArgument},
+		{imagefmt.ErrCouldNotFindLayer, ErrorCodeUnprocessableLayer},
+		{tarutil.ErrExtractedSizeTooBig, ErrorCodeUnprocessableLayer},
+		{commonerr.ErrCouldNotDownload, ErrorCodeLayerUnavailable},
+		{database.ErrBackendException, ErrorCodeDatabaseUnavailable},
+		{errors.New("unexpected"), ErrorCodeInternal},
+	} {
+		assert.Equal(t, test.expected, errorCodeOf(test.err), test.err.Error())
+
+		// The internal errors keep their message and get their cause.
+		err := clairError(test.err)
+		assert.Equal(t, test.err.Error(), err.Error())
+		assert.Equal(t, string(test.expected), err.(grpcutil.CodedError).ErrorCode())
+	}
+}
+
+func TestErrorStatuses(t *testing.T) {
+	for _, test := range []struct {
+		code       ErrorCode
+		grpcCode   codes.Code
+		httpStatus int
+	}{
+		{ErrorCodeInvalidArgument, codes.InvalidArgument, http.StatusBadRequest},
+		{ErrorCodeUnauthenticated, codes.Unauthenticated, http.StatusUnauthorized},
+		{ErrorCodePermissionDenied, codes.PermissionDenied, http.StatusForbidden},
+		{ErrorCodeNotFound, codes.NotFound, http.StatusNotFound},
+		{ErrorCodeFailedPrecondition, codes.FailedPrecondition, http.StatusPreconditionFailed},
+		{ErrorCodeUnprocessableLayer, codes.InvalidArgument, http.StatusUnprocessableEntity},
+		{ErrorCodeLayerUnavailable, codes.Unavailable, http.StatusBadGateway},
+		{ErrorCodeRateLimited, codes.ResourceExhausted, http.StatusTooManyRequests},
+		{ErrorCodeOverloaded, codes.Unavailable, http.StatusServiceUnavailable},
+		{ErrorCodeRequestTooLarge, codes.ResourceExhausted, http.StatusRequestEntityTooLarge},
+		{ErrorCodeCanceled, codes.Canceled, http.StatusRequestTimeout},
+		{ErrorCodeTimeout, codes.DeadlineExceeded, http.StatusGatewayTimeout},
+		{ErrorCodeDatabaseUnavailable, codes.Unavailable, http.StatusServiceUnavailable},
+		{ErrorCodeInternal, codes.Internal, http.StatusInternalServerError},
+	} {
+		s := newError(test.code, "message").(*apiError).GRPCStatus()
+		assert.Equal(t, test.grpcCode, s.Code(), string(test.code))
+		assert.Equal(t, "message", s.Message(), string(test.code))
+
+		// The statuses of the REST responses are sent by both the Gateway
+		// and the handlers in front of it.
+		ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{
+			TrailerMD: metadata.Pairs(grpcutil.ErrorCodeTrailer, string(test.code)),
+		})
+		w := httptest.NewRecorder()
+		httpError(ctx, nil, &runtime.JSONPb{OrigName: true}, w, httptest.NewRequest("GET", "/", nil), s.Err())
+		assert.Equal(t, test.httpStatus, w.Code, string(test.code))
+
+		w = httptest.NewRecorder()
+		writeHTTPError(w, test.code, "message")
+		assert.Equal(t, test.httpStatus, w.Code, string(test.code))
+	}
+
+	// Every cause has its statuses.
+	assert.Len(t, errorStatuses, 14)
+}
+
+func TestHTTPError(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		err        error
+		trailer    metadata.MD
+		httpStatus int
+		expected   httpErrorBody
+		retryAfter string
+	}{
+		{
+			name:       "cause in the trailer",
+			err:        status.Error(codes.InvalidArgument, "could not find layer"),
+			trailer:    metadata.Pairs(grpcutil.ErrorCodeTrailer, string(ErrorCodeUnprocessableLayer)),
+			httpStatus: http.StatusUnprocessableEntity,
+			expected:   httpErrorBody{Error: "could not find layer", Code: int32(codes.InvalidArgument), ErrorCode: string(ErrorCodeUnprocessableLayer)},
+		},
+		{
+			name:       "retry after",
+			err:        status.Error(codes.ResourceExhausted, "rate limit exceeded"),
+			trailer:    metadata.Pairs(grpcutil.ErrorCodeTrailer, string(ErrorCodeRateLimited), RetryAfterTrailer, "12"),
+			httpStatus: http.StatusTooManyRequests,
+			expected:   httpErrorBody{Error: "rate limit exceeded", Code: int32(codes.ResourceExhausted), ErrorCode: string(ErrorCodeRateLimited)},
+			retryAfter: "12",
+		},
+		{
+			name:       "error of the Gateway",
+			err:        status.Error(codes.InvalidArgument, "malformed request"),
+			httpStatus: http.StatusBadRequest,
+			expected:   httpErrorBody{Error: "malformed request", Code: int32(codes.InvalidArgument), ErrorCode: string(ErrorCodeInvalidArgument)},
+		},
+		{
+			name:       "unknown cause",
+			err:        status.Error(codes.NotFound, "not found"),
+			trailer:    metadata.Pairs(grpcutil.ErrorCodeTrailer, "UNKNOWN_CAUSE"),
+			httpStatus: http.StatusNotFound,
+			expected:   httpErrorBody{Error: "not found", Code: int32(codes.NotFound), ErrorCode: "UNKNOWN_CAUSE"},
+		},
+		{
+			name:       "not a gRPC error",
+			err:        errors.New("unexpected"),
+			httpStatus: http.StatusInternalServerError,
+			expected:   httpErrorBody{Error: "unexpected", Code: int32(codes.Unknown), ErrorCode: string(ErrorCodeInternal)},
+		},
+	} {
+		ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{TrailerMD: test.trailer})
+		w := httptest.NewRecorder()
+		w.Header().Set("Trailer", "Grpc-Trailer-X-Error-Code")
+		httpError(ctx, nil, &runtime.JSONPb{OrigName: true}, w, httptest.NewRequest("GET", "/", nil), test.err)
+
+		assert.Equal(t, test.httpStatus, w.Code, test.name)
+		assert.Equal(t, test.retryAfter, w.Header().Get("Retry-After"), test.name)
+		assert.Empty(t, w.Header().Get("Trailer"), test.name)
+
+		var body httpErrorBody
+		require.Nil(t, json.Unmarshal(w.Body.Bytes(), &body), test.name)
+		assert.Equal(t, test.expected, body, test.name)
+	}
+}
+
+func TestWriteHTTPError(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeHTTPError(w, ErrorCodeRequestTooLarge, "request body too large")
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var body httpErrorBody
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, httpErrorBody{
+		Error:     "request body too large",
+		Code:      int32(codes.ResourceExhausted),
+		ErrorCode: string(ErrorCodeRequestTooLarge),
+	}, body)
+}
@@ -0,0 +1,240 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/coreos/clair/pkg/grpcutil"
+	"github.com/coreos/clair/pkg/logutil"
+	"github.com/coreos/clair/pkg/ratelimit"
+)
+
+// RetryAfterTrailer is the metadata key holding the number of seconds after
+// which a rate limited request can be retried, which the Gateway sends as the
+// Retry-After header.
+const RetryAfterTrailer = "retry-after"
+
+// clairServicePrefix is the prefix of the methods of the Clair services, as
+// opposed to the health and reflection services, which are never limited.
+const clairServicePrefix = "/coreos.clair."
+
+// overloadedRetryAfter is the number of seconds after which the analyses
+// rejected because too many of them are waiting can be retried.
+const overloadedRetryAfter = 10
+
+var (
+	promAnalysesRunning = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "clair_v3_api_analyses_running",
+		Help: "Number of requests analyzing layers being run, at most the maximum number of concurrent analyses.",
+	})
+
+	promAnalysesQueued = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "clair_v3_api_analyses_queued",
+		Help: "Number of requests analyzing layers waiting for their turn to be run.",
+	})
+
+	promAnalysesRejectedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "clair_v3_api_analyses_rejected_total",
+		Help: "Number of requests analyzing layers rejected because the maximum number of them were already waiting.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(promAnalysesRunning)
+	prometheus.MustRegister(promAnalysesQueued)
+	prometheus.MustRegister(promAnalysesRejectedTotal)
+}
+
+// analysisMethods are the methods analyzing layers, which are the expensive
+// ones.
+var analysisMethods = map[string]struct{}{
+	"/coreos.clair.AncestryService/PostAncestry": {},
+	"/coreos.clair.AncestryService/PostLayers":   {},
+	"/coreos.clair.AncestryService/PostImage":    {},
+}
+
+// Limits are the limits of the requests of the clients of the API.
+type Limits struct {
+	// AnalysesPerMinute is the number of requests analyzing layers that each
+	// client can send per minute. They are not limited when it is not set.
+	AnalysesPerMinute int
+
+	// ReadsPerMinute is the number of the other requests that each client can
+	// send per minute. They are not limited when it is not set.
+	ReadsPerMinute int
+
+	// MaxConcurrentAnalyses is the number of requests analyzing layers that
+	// are run at the same time, of all the clients, while the other ones wait.
+	// They are not limited when it is not set.
+	MaxConcurrentAnalyses int
+
+	// MaxQueuedAnalyses is the number of requests analyzing layers that wait
+	// for their turn when MaxConcurrentAnalyses are run, beyond which they are
+	// rejected with a 503. They are not limited when it is not set.
+	MaxQueuedAnalyses int
+
+	// MaxRequestSize is the maximum size, in bytes, of the body of the HTTP
+	// requests, including the uploaded layers. The larger ones are answered
+	// with a 413. It is not limited when it is not set.
+	MaxRequestSize int64
+}
+
+// limiter enforces the Limits of the requests of the gRPC services.
+type limiter struct {
+	analyses  *ratelimit.Limiter
+	reads     *ratelimit.Limiter
+	analyzing chan struct{}
+
+	// queued is the number of analyses waiting, which is at most maxQueued
+	// when it is set.
+	queued    int64
+	maxQueued int64
+}
+
+// newLimiter returns a limiter enforcing the given limits of the gRPC requests,
+// or nil if there are none.
+func newLimiter(limits Limits) *limiter {
+	if limits.AnalysesPerMinute == 0 && limits.ReadsPerMinute == 0 && limits.MaxConcurrentAnalyses == 0 {
+		return nil
+	}
+
+	var l limiter
+	if limits.AnalysesPerMinute > 0 {
+		l.analyses = ratelimit.NewLimiter(limits.AnalysesPerMinute)
+	}
+	if limits.ReadsPerMinute > 0 {
+		l.reads = ratelimit.NewLimiter(limits.ReadsPerMinute)
+	}
+	if limits.MaxConcurrentAnalyses > 0 {
+		l.analyzing = make(chan struct{}, limits.MaxConcurrentAnalyses)
+		l.maxQueued = int64(limits.MaxQueuedAnalyses)
+	}
+	return &l
+}
+
+// clientKey identifies the client of a request by the identity of its
+// certificate, or else by its address.
+func clientKey(ctx context.Context) string {
+	if identity, ok := logutil.ClientIdentity(ctx); ok {
+		return "identity:" + identity
+	}
+	return "address:" + grpcutil.ClientAddress(ctx)
+}
+
+// acquire counts a request of the given method against the limits, and waits,
+// for the analyses, until it can be run, unless too many of them are waiting.
+// The returned function must be called once the request is done. The delay
+// before retrying a rate limited or rejected request is set in the trailers
+// with setTrailer.
+func (l *limiter) acquire(ctx context.Context, method string, setTrailer func(metadata.MD)) (func(), error) {
+	if !strings.HasPrefix(method, clairServicePrefix) {
+		return func() {}, nil
+	}
+
+	_, analysis := analysisMethods[method]
+	rateLimiter := l.reads
+	if analysis {
+		rateLimiter = l.analyses
+	}
+
+	if rateLimiter != nil {
+		key := clientKey(ctx)
+		if ok, wait := rateLimiter.Allow(key); !ok {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			setTrailer(metadata.Pairs(RetryAfterTrailer, strconv.Itoa(retryAfter)))
+			logutil.FromContext(ctx).WithFields(log.Fields{"method": method, "retry after": retryAfter}).Warning("rate limited request")
+			return nil, errorf(ErrorCodeRateLimited, "too many requests, retry in %d seconds", retryAfter)
+		}
+	}
+
+	if !analysis || l.analyzing == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.analyzing <- struct{}{}:
+		return l.running(), nil
+	default:
+	}
+
+	if queued := atomic.AddInt64(&l.queued, 1); l.maxQueued > 0 && queued > l.maxQueued {
+		atomic.AddInt64(&l.queued, -1)
+		promAnalysesRejectedTotal.Inc()
+		setTrailer(metadata.Pairs(RetryAfterTrailer, strconv.Itoa(overloadedRetryAfter)))
+		logutil.FromContext(ctx).WithFields(log.Fields{"method": method, "queued": queued - 1}).Warning("rejected analysis: too many analyses waiting")
+		return nil, errorf(ErrorCodeOverloaded, "too many analyses waiting, retry in %d seconds", overloadedRetryAfter)
+	}
+
+	promAnalysesQueued.Inc()
+	defer func() {
+		atomic.AddInt64(&l.queued, -1)
+		promAnalysesQueued.Dec()
+	}()
+
+	select {
+	case l.analyzing <- struct{}{}:
+		return l.running(), nil
+	case <-ctx.Done():
+		return nil, clairError(ctx.Err())
+	}
+}
+
+// running counts an analysis that acquired its turn, and returns the function
+// releasing it.
+func (l *limiter) running() func() {
+	promAnalysesRunning.Inc()
+	return func() {
+		promAnalysesRunning.Dec()
+		<-l.analyzing
+	}
+}
+
+// interceptors returns the gRPC interceptors enforcing the limits.
+func (l *limiter) interceptors() grpcutil.Interceptors {
+	if l == nil {
+		return grpcutil.Interceptors{}
+	}
+
+	return grpcutil.Interceptors{
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			release, err := l.acquire(ctx, info.FullMethod, func(md metadata.MD) { grpc.SetTrailer(ctx, md) })
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+
+			return handler(ctx, req)
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			release, err := l.acquire(ss.Context(), info.FullMethod, ss.SetTrailer)
+			if err != nil {
+				return err
+			}
+			defer release()
+
+			return handler(srv, ss)
+		},
+	}
+}
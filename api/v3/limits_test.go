@@ -0,0 +1,105 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+const testAnalysisMethod = "/coreos.clair.AncestryService/PostAncestry"
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	var m dto.Metric
+	require.Nil(t, g.Write(&m))
+	return m.GetGauge().GetValue()
+}
+
+// waitQueued waits until the given number of analyses wait for their turn.
+func waitQueued(t *testing.T, l *limiter, queued int64) {
+	for deadline := time.Now().Add(5 * time.Second); atomic.LoadInt64(&l.queued) != queued; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued analyses, got %d", queued, atomic.LoadInt64(&l.queued))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLimiterAcquire(t *testing.T) {
+	l := newLimiter(Limits{MaxConcurrentAnalyses: 1, MaxQueuedAnalyses: 1})
+	require.NotNil(t, l)
+	noTrailer := func(metadata.MD) {}
+
+	// The first analysis runs.
+	release, err := l.acquire(context.Background(), testAnalysisMethod, noTrailer)
+	require.Nil(t, err)
+	assert.Equal(t, float64(1), gaugeValue(t, promAnalysesRunning))
+
+	// The second one waits for its turn.
+	acquired := make(chan func())
+	go func() {
+		release, err := l.acquire(context.Background(), testAnalysisMethod, noTrailer)
+		if assert.Nil(t, err) {
+			acquired <- release
+		}
+	}()
+	waitQueued(t, l, 1)
+	assert.Equal(t, float64(1), gaugeValue(t, promAnalysesQueued))
+
+	// The third one is rejected, as too many of them are waiting.
+	var trailer metadata.MD
+	_, err = l.acquire(context.Background(), testAnalysisMethod, func(md metadata.MD) { trailer = md })
+	if assert.IsType(t, &apiError{}, err) {
+		assert.Equal(t, ErrorCodeOverloaded, err.(*apiError).code)
+	}
+	assert.Equal(t, []string{"10"}, trailer[RetryAfterTrailer])
+
+	// The reads are not limited.
+	releaseRead, err := l.acquire(context.Background(), "/coreos.clair.AncestryService/GetAncestry", noTrailer)
+	assert.Nil(t, err)
+	releaseRead()
+
+	// The second one runs once the first one is done.
+	release()
+	release = <-acquired
+	assert.Equal(t, float64(0), gaugeValue(t, promAnalysesQueued))
+	assert.Equal(t, float64(1), gaugeValue(t, promAnalysesRunning))
+
+	// A waiting analysis stops waiting when it is canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := l.acquire(ctx, testAnalysisMethod, noTrailer)
+		canceled <- err
+	}()
+	waitQueued(t, l, 1)
+	cancel()
+	if err := <-canceled; assert.IsType(t, &apiError{}, err) {
+		assert.Equal(t, ErrorCodeCanceled, err.(*apiError).code)
+	}
+
+	release()
+	assert.Equal(t, int64(0), atomic.LoadInt64(&l.queued))
+	assert.Equal(t, float64(0), gaugeValue(t, promAnalysesQueued))
+	assert.Equal(t, float64(0), gaugeValue(t, promAnalysesRunning))
+}
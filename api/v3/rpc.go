@@ -15,117 +15,516 @@
 package v3
 
 import (
-	"fmt"
+	"crypto/subtle"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/golang/protobuf/ptypes"
 	"golang.org/x/net/context"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/coreos/clair"
 	pb "github.com/coreos/clair/api/v3/clairpb"
 	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/imagefmt"
+	"github.com/coreos/clair/ext/objectstore"
 	"github.com/coreos/clair/pkg/commonerr"
 	"github.com/coreos/clair/pkg/pagination"
 )
 
+const (
+	// defaultNamespacePageLimit is the number of namespaces per page when the
+	// request does not specify it.
+	defaultNamespacePageLimit = 100
+
+	// defaultFeatureLocationPageLimit is the number of feature locations per
+	// page when the request does not specify it.
+	defaultFeatureLocationPageLimit = 100
+
+	// defaultStreamFeatureLimit is the number of features per message of a
+	// streamed ancestry when the request does not specify it.
+	defaultStreamFeatureLimit = 100
+
+	// defaultPendingNotificationPageLimit is the number of pending
+	// notifications per page when the request does not specify it.
+	defaultPendingNotificationPageLimit = 100
+)
+
 // NotificationServer implements NotificationService interface for serving RPC.
 type NotificationServer struct {
 	Store database.Datastore
+
+	// UpdaterToken is the bearer token authorizing the resolution and the
+	// flushing of the pending notifications, which are refused when it is
+	// empty.
+	UpdaterToken string
 }
 
 // AncestryServer implements AncestryService interface for serving RPC.
 type AncestryServer struct {
 	Store database.Datastore
+
+	// Timeout bounds the duration of the analysis of the posted layers, which
+	// is also stopped when the client cancels its request.
+	Timeout time.Duration
+
+	// UpdaterToken is the bearer token authorizing the deletion of the results
+	// of the scans, which is refused when it is empty.
+	UpdaterToken string
+}
+
+// NamespaceServer implements NamespaceService interface for serving RPC.
+type NamespaceServer struct {
+	Store database.Datastore
+}
+
+// FeatureServer implements FeatureService interface for serving RPC.
+type FeatureServer struct {
+	Store database.Datastore
 }
 
 // StatusServer implements StatusService interface for serving RPC.
 type StatusServer struct {
 	Store database.Datastore
+
+	// UpdaterToken is the bearer token authorizing the updates triggered on
+	// demand, which are refused when it is empty.
+	UpdaterToken string
+}
+
+// HealthServer implements the standard gRPC Health service for serving RPC.
+type HealthServer struct {
+	Store database.Datastore
+
+	// Services are the names of the services whose health can be checked
+	// individually, besides the whole server.
+	Services map[string]struct{}
+}
+
+// Check implements checking the health of Clair or of one of its services via
+// the gRPC Health service. They are serving as long as the database is
+// reachable.
+func (s *HealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if service := req.Service; service != "" {
+		if _, ok := s.Services[service]; !ok {
+			return nil, newError(ErrorCodeNotFound, "unknown service")
+		}
+	}
+
+	if !s.Store.Ping() {
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
 }
 
 // GetStatus implements getting the current status of Clair via the Clair service.
 func (s *StatusServer) GetStatus(ctx context.Context, req *pb.GetStatusRequest) (*pb.GetStatusResponse, error) {
 	clairStatus, err := GetClairStatus(s.Store)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, clairError(err)
 	}
 
 	return &pb.GetStatusResponse{Status: clairStatus}, nil
 }
 
+// GetUpdaterStatus implements getting the current status of the vulnerability
+// updater via the Clair service.
+func (s *StatusServer) GetUpdaterStatus(ctx context.Context, req *pb.GetUpdaterStatusRequest) (*pb.GetUpdaterStatusResponse, error) {
+	updaterStatus, err := GetUpdaterStatus(s.Store)
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	return &pb.GetUpdaterStatusResponse{Status: updaterStatus}, nil
+}
+
+// TriggerUpdate implements starting an update of the vulnerabilities in the
+// background via the Clair service.
+func (s *StatusServer) TriggerUpdate(ctx context.Context, req *pb.TriggerUpdateRequest) (*pb.TriggerUpdateResponse, error) {
+	if err := authorizeUpdate(ctx, s.UpdaterToken); err != nil {
+		return nil, err
+	}
+
+	job, err := clair.TriggerUpdate(req.GetUpdaters())
+	if _, ok := err.(*commonerr.ErrBadRequest); ok {
+		return nil, newError(ErrorCodeInvalidArgument, err.Error())
+	} else if err == clair.ErrUpdaterDisabled {
+		return nil, newError(ErrorCodeFailedPrecondition, err.Error())
+	} else if err != nil {
+		return nil, clairError(err)
+	}
+
+	pbJob, err := newPbUpdateJob(job)
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	return &pb.TriggerUpdateResponse{Job: pbJob}, nil
+}
+
+// GetUpdateJob implements getting the progress of an update triggered on
+// demand via the Clair service.
+func (s *StatusServer) GetUpdateJob(ctx context.Context, req *pb.GetUpdateJobRequest) (*pb.GetUpdateJobResponse, error) {
+	if err := authorizeUpdate(ctx, s.UpdaterToken); err != nil {
+		return nil, err
+	}
+
+	job, err := clair.GetUpdateJob(req.GetId())
+	if err == commonerr.ErrNotFound {
+		return nil, newError(ErrorCodeNotFound, "requested update job is not found")
+	} else if err != nil {
+		return nil, clairError(err)
+	}
+
+	pbJob, err := newPbUpdateJob(job)
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	return &pb.GetUpdateJobResponse{Job: pbJob}, nil
+}
+
+// authorizeUpdate checks that the request carries the updater token in its
+// authorization metadata, which the gRPC Gateway sets from the Authorization
+// header. The administrative requests are refused when the token is empty.
+func authorizeUpdate(ctx context.Context, updaterToken string) error {
+	if updaterToken == "" {
+		return newError(ErrorCodePermissionDenied, "requests requiring the updater token are disabled")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, authorization := range md["authorization"] {
+		token := strings.TrimPrefix(authorization, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(updaterToken)) == 1 {
+			return nil
+		}
+	}
+
+	return newError(ErrorCodeUnauthenticated, "invalid updater token")
+}
+
+// ListNamespaces implements listing a page of the namespaces via the Clair
+// gRPC service.
+func (s *NamespaceServer) ListNamespaces(ctx context.Context, req *pb.ListNamespacesRequest) (*pb.ListNamespacesResponse, error) {
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return nil, newError(ErrorCodeInvalidArgument, "namespace page limit should not be less than 1")
+	} else if limit == 0 {
+		limit = defaultNamespacePageLimit
+	}
+
+	tx, err := s.Store.Begin()
+	if err != nil {
+		return nil, clairError(err)
+	}
+	defer tx.Rollback()
+
+	dbNamespaces, nextPage, err := tx.FindNamespaces(req.GetPrefix(), limit, pagination.Token(req.GetPage()))
+	if _, ok := err.(*commonerr.ErrBadRequest); ok {
+		return nil, newError(ErrorCodeInvalidArgument, err.Error())
+	} else if err != nil {
+		return nil, clairError(err)
+	}
+
+	namespaces := make([]*pb.Namespace, 0, len(dbNamespaces))
+	for _, ns := range dbNamespaces {
+		namespaces = append(namespaces, &pb.Namespace{Name: ns.Name})
+	}
+
+	return &pb.ListNamespacesResponse{
+		Namespaces: namespaces,
+		NextPage:   string(nextPage),
+	}, nil
+}
+
+// ListFeatureLocations implements listing a page of the ancestries and layers
+// containing a feature via the Clair gRPC service.
+func (s *FeatureServer) ListFeatureLocations(ctx context.Context, req *pb.ListFeatureLocationsRequest) (*pb.ListFeatureLocationsResponse, error) {
+	if req.GetFeatureName() == "" || req.GetFeatureVersion() == "" {
+		return nil, newError(ErrorCodeInvalidArgument, "feature name and version should not be empty")
+	}
+
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return nil, newError(ErrorCodeInvalidArgument, "feature location page limit should not be less than 1")
+	} else if limit == 0 {
+		limit = defaultFeatureLocationPageLimit
+	}
+
+	tx, err := s.Store.Begin()
+	if err != nil {
+		return nil, clairError(err)
+	}
+	defer tx.Rollback()
+
+	dbLocations, nextPage, err := tx.FindFeatureLocations(req.GetFeatureName(), req.GetFeatureVersion(), req.GetNamespaceName(), limit, pagination.Token(req.GetPage()))
+	if _, ok := err.(*commonerr.ErrBadRequest); ok {
+		return nil, newError(ErrorCodeInvalidArgument, err.Error())
+	} else if err != nil {
+		return nil, clairError(err)
+	}
+
+	locations := make([]*pb.ListFeatureLocationsResponse_FeatureLocation, 0, len(dbLocations))
+	for _, location := range dbLocations {
+		locations = append(locations, &pb.ListFeatureLocationsResponse_FeatureLocation{
+			AncestryName: location.AncestryName,
+			LayerHash:    location.LayerHash,
+			Feature: &pb.Feature{
+				Name:          location.Name,
+				Namespace:     &pb.Namespace{Name: location.Namespace.Name},
+				Version:       location.Version,
+				VersionFormat: location.VersionFormat,
+			},
+		})
+	}
+
+	return &pb.ListFeatureLocationsResponse{
+		Locations: locations,
+		NextPage:  string(nextPage),
+	}, nil
+}
+
+// ExportVulnerabilities implements streaming the vulnerabilities of a
+// namespace via the Clair gRPC service.
+func (s *NamespaceServer) ExportVulnerabilities(req *pb.ExportVulnerabilitiesRequest, stream pb.NamespaceService_ExportVulnerabilitiesServer) error {
+	namespaceName := req.GetNamespaceName()
+	if namespaceName == "" {
+		return newError(ErrorCodeInvalidArgument, "namespace name should not be empty")
+	}
+
+	var since time.Time
+	if req.GetModifiedSince() != nil {
+		var err error
+		if since, err = ptypes.Timestamp(req.GetModifiedSince()); err != nil {
+			return newError(ErrorCodeInvalidArgument, "modified since is invalid: "+err.Error())
+		}
+	}
+
+	tx, err := s.Store.Begin()
+	if err != nil {
+		return clairError(err)
+	}
+	defer tx.Rollback()
+
+	var sendErr error
+	send := func(dbVuln database.VulnerabilityWithAffected) error {
+		vuln, err := pb.VulnerabilityWithAffectedFromDatabaseModel(dbVuln)
+		if err != nil {
+			return err
+		}
+
+		// An error of the stream means that the client is gone, which stops
+		// reading the vulnerabilities from the database.
+		sendErr = stream.Send(vuln)
+		return sendErr
+	}
+
+	err = tx.WalkVulnerabilities(namespaceName, since, send)
+	if err == nil && req.GetIncludeWithdrawn() {
+		// The withdrawn vulnerabilities follow the active ones, so that a
+		// mirror can remove them once it has stored the others.
+		err = tx.WalkWithdrawnVulnerabilities(namespaceName, since, send)
+	}
+
+	if sendErr != nil {
+		return sendErr
+	} else if err != nil {
+		return clairError(err)
+	}
+
+	return nil
+}
+
 // PostAncestry implements posting an ancestry via the Clair gRPC service.
 func (s *AncestryServer) PostAncestry(ctx context.Context, req *pb.PostAncestryRequest) (*pb.PostAncestryResponse, error) {
 	ancestryName := req.GetAncestryName()
 	if ancestryName == "" {
-		return nil, status.Error(codes.InvalidArgument, "ancestry name should not be empty")
+		return nil, newError(ErrorCodeInvalidArgument, "ancestry name should not be empty")
 	}
 
 	layers := req.GetLayers()
 	if len(layers) == 0 {
-		return nil, status.Error(codes.InvalidArgument, "ancestry should have at least one layer")
+		return nil, newError(ErrorCodeInvalidArgument, "ancestry should have at least one layer")
 	}
 
 	ancestryFormat := req.GetFormat()
 	if ancestryFormat == "" {
-		return nil, status.Error(codes.InvalidArgument, "ancestry format should not be empty")
+		return nil, newError(ErrorCodeInvalidArgument, "ancestry format should not be empty")
+	}
+
+	ancestryLayers, err := layerRequestsFromPostLayers(layers, req.GetRegistry(), req.GetObjectStorage())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.analysisContext(ctx)
+	defer cancel()
+
+	err = clair.ProcessAncestry(ctx, s.Store, ancestryFormat, ancestryName, ancestryLayers)
+	if err != nil {
+		return nil, analysisError("ancestry is failed to be processed: ", err)
+	}
+
+	clairStatus, err := GetClairStatus(s.Store)
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	return &pb.PostAncestryResponse{Status: clairStatus}, nil
+}
+
+// analysisContext derives the context of the analysis of posted layers from
+// the context of the request, bounded by the timeout of the server.
+func (s *AncestryServer) analysisContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.Timeout)
+}
+
+// analysisError converts the error of the analysis of posted layers to an
+// error of the API, reporting its cause.
+func analysisError(message string, err error) error {
+	return newError(errorCodeOf(err), message+err.Error())
+}
+
+// PostLayers implements scanning a list of layers via the Clair gRPC service.
+func (s *AncestryServer) PostLayers(ctx context.Context, req *pb.PostLayersRequest) (*pb.PostLayersResponse, error) {
+	layers := req.GetLayers()
+	if len(layers) == 0 {
+		return nil, newError(ErrorCodeInvalidArgument, "at least one layer should be posted")
+	}
+
+	format := req.GetFormat()
+	if format == "" {
+		return nil, newError(ErrorCodeInvalidArgument, "layer format should not be empty")
+	}
+
+	layerRequests, err := layerRequestsFromPostLayers(layers, req.GetRegistry(), req.GetObjectStorage())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.analysisContext(ctx)
+	defer cancel()
+
+	results, err := clair.ProcessLayers(ctx, s.Store, format, layerRequests)
+	if err != nil {
+		return nil, analysisError("layers are failed to be processed: ", err)
+	}
+
+	resp := &pb.PostLayersResponse{Results: make([]*pb.PostLayersResponse_LayerResult, 0, len(results))}
+	for _, r := range results {
+		result := &pb.PostLayersResponse_LayerResult{
+			Hash:           r.Layer.Hash,
+			Detectors:      pb.DetectorsFromDatabaseModel(r.Layer.By),
+			FeatureCount:   int32(len(r.Layer.Features)),
+			NamespaceCount: int32(len(r.Layer.Namespaces)),
+		}
+
+		if r.Err != nil {
+			result.Error = r.Err.Error()
+		} else {
+			resp.ScannedCount++
+		}
+
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+// layerRequestsFromPostLayers validates the posted layers and converts them
+// into layer requests for the worker, pulling the layers without path from the
+// given registry, if any, and reading the objects of the layers whose path is
+// an object URI with the given credentials, if any.
+func layerRequestsFromPostLayers(layers []*pb.PostAncestryRequest_PostLayer, pbRegistry *pb.PostAncestryRequest_Registry, pbObjectStorage *pb.PostAncestryRequest_ObjectStorage) ([]clair.LayerRequest, error) {
+	var registry *imagefmt.Registry
+	if pbRegistry != nil {
+		if pbRegistry.GetHost() == "" || pbRegistry.GetRepository() == "" {
+			return nil, newError(ErrorCodeInvalidArgument, "registry host and repository should not be empty")
+		}
+
+		registry = &imagefmt.Registry{
+			Host:       pbRegistry.Host,
+			Repository: pbRegistry.Repository,
+			Insecure:   pbRegistry.Insecure,
+			Token:      pbRegistry.Token,
+			Username:   pbRegistry.Username,
+			Password:   pbRegistry.Password,
+		}
 	}
 
-	ancestryLayers := []clair.LayerRequest{}
+	var objectStorage *objectstore.Credentials
+	if pbObjectStorage != nil {
+		objectStorage = &objectstore.Credentials{
+			AccessKeyID:     pbObjectStorage.AccessKeyId,
+			SecretAccessKey: pbObjectStorage.SecretAccessKey,
+			SessionToken:    pbObjectStorage.SessionToken,
+			Region:          pbObjectStorage.Region,
+			Endpoint:        pbObjectStorage.Endpoint,
+			Token:           pbObjectStorage.Token,
+		}
+	}
+
+	layerRequests := []clair.LayerRequest{}
 	for _, layer := range layers {
 		if layer == nil {
-			err := status.Error(codes.InvalidArgument, "ancestry layer is invalid")
+			err := newError(ErrorCodeInvalidArgument, "ancestry layer is invalid")
 			return nil, err
 		}
 
 		if layer.GetHash() == "" {
-			return nil, status.Error(codes.InvalidArgument, "ancestry layer hash should not be empty")
+			return nil, newError(ErrorCodeInvalidArgument, "ancestry layer hash should not be empty")
 		}
 
-		if layer.GetPath() == "" {
-			return nil, status.Error(codes.InvalidArgument, "ancestry layer path should not be empty")
+		if layer.GetPath() == "" && registry == nil {
+			return nil, newError(ErrorCodeInvalidArgument, "ancestry layer path should not be empty")
 		}
 
-		ancestryLayers = append(ancestryLayers, clair.LayerRequest{
-			Hash:    layer.Hash,
-			Headers: layer.Headers,
-			Path:    layer.Path,
+		layerRequests = append(layerRequests, clair.LayerRequest{
+			Hash:          layer.Hash,
+			Headers:       layer.Headers,
+			Path:          layer.Path,
+			Registry:      registry,
+			ObjectStorage: objectStorage,
 		})
 	}
 
-	err := clair.ProcessAncestry(s.Store, ancestryFormat, ancestryName, ancestryLayers)
-	if err != nil {
-		return nil, status.Error(codes.Internal, "ancestry is failed to be processed: "+err.Error())
-	}
-
-	clairStatus, err := GetClairStatus(s.Store)
-	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-
-	return &pb.PostAncestryResponse{Status: clairStatus}, nil
+	return layerRequests, nil
 }
 
 // GetAncestry implements retrieving an ancestry via the Clair gRPC service.
 func (s *AncestryServer) GetAncestry(ctx context.Context, req *pb.GetAncestryRequest) (*pb.GetAncestryResponse, error) {
 	name := req.GetAncestryName()
 	if name == "" {
-		return nil, status.Errorf(codes.InvalidArgument, "ancestry name should not be empty")
+		return nil, newError(ErrorCodeInvalidArgument, "ancestry name should not be empty")
+	}
+
+	// The status is retrieved before the ancestry's session begins, as
+	// GetClairStatus begins its own.
+	pbClairStatus, err := GetClairStatus(s.Store)
+	if err != nil {
+		return nil, clairError(err)
 	}
 
 	tx, err := s.Store.Begin()
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, clairError(err)
 	}
 
 	defer tx.Rollback()
 
 	ancestry, ok, err := tx.FindAncestry(name)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, clairError(err)
 	}
 
 	if !ok {
-		return nil, status.Error(codes.NotFound, fmt.Sprintf("requested ancestry '%s' is not found", req.GetAncestryName()))
+		return nil, errorf(ErrorCodeNotFound, "requested ancestry '%s' is not found", req.GetAncestryName())
 	}
 
 	pbAncestry := &pb.GetAncestryResponse_Ancestry{
@@ -134,7 +533,7 @@ func (s *AncestryServer) GetAncestry(ctx context.Context, req *pb.GetAncestryReq
 	}
 
 	for _, layer := range ancestry.Layers {
-		pbLayer, err := GetPbAncestryLayer(tx, layer)
+		pbLayer, err := GetPbAncestryLayer(tx, layer, req.GetWithSuppressed(), req.GetOnlyFixable(), req.GetExcludedTags())
 		if err != nil {
 			return nil, err
 		}
@@ -142,14 +541,266 @@ func (s *AncestryServer) GetAncestry(ctx context.Context, req *pb.GetAncestryReq
 		pbAncestry.Layers = append(pbAncestry.Layers, pbLayer)
 	}
 
+	return &pb.GetAncestryResponse{
+		Status:   pbClairStatus,
+		Ancestry: pbAncestry,
+	}, nil
+}
+
+// StreamAncestry implements streaming the features of an ancestry with their
+// vulnerabilities via the Clair gRPC service.
+//
+// The vulnerabilities are retrieved for a message at a time, so that neither
+// the server nor the client holds those of the whole ancestry.
+func (s *AncestryServer) StreamAncestry(req *pb.StreamAncestryRequest, stream pb.AncestryService_StreamAncestryServer) error {
+	name := req.GetAncestryName()
+	if name == "" {
+		return newError(ErrorCodeInvalidArgument, "ancestry name should not be empty")
+	}
+
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return newError(ErrorCodeInvalidArgument, "feature limit should not be less than 1")
+	} else if limit == 0 {
+		limit = defaultStreamFeatureLimit
+	}
+
+	// The status is retrieved before the ancestry's session begins, as
+	// GetClairStatus begins its own.
 	pbClairStatus, err := GetClairStatus(s.Store)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return clairError(err)
 	}
 
-	return &pb.GetAncestryResponse{
-		Status:   pbClairStatus,
-		Ancestry: pbAncestry,
+	tx, err := s.Store.Begin()
+	if err != nil {
+		return clairError(err)
+	}
+	defer tx.Rollback()
+
+	ancestry, ok, err := tx.FindAncestry(name)
+	if err != nil {
+		return clairError(err)
+	}
+
+	if !ok {
+		return errorf(ErrorCodeNotFound, "requested ancestry '%s' is not found", name)
+	}
+
+	first := &pb.StreamAncestryResponse{
+		Detectors: pb.DetectorsFromDatabaseModel(ancestry.By),
+		Status:    pbClairStatus,
+	}
+
+	ctx := stream.Context()
+	for _, layer := range ancestry.Layers {
+		// Every layer is sent at least once, even without features.
+		groups := sortedAncestryFeatures(layer.Features)
+		for sent := false; !sent || len(groups) > 0; sent = true {
+			if err := ctx.Err(); err != nil {
+				return clairError(err)
+			}
+
+			batch := groups
+			if len(batch) > limit {
+				batch = batch[:limit]
+			}
+			groups = groups[len(batch):]
+
+			var features []database.AncestryFeature
+			for _, group := range batch {
+				features = append(features, group...)
+			}
+
+			pbFeatures, err := getPbAncestryFeatures(tx, features, req.GetWithSuppressed(), req.GetOnlyFixable(), req.GetExcludedTags())
+			if err != nil {
+				return err
+			}
+			pb.SortFeatures(pbFeatures)
+
+			resp := first
+			if resp == nil {
+				resp = &pb.StreamAncestryResponse{}
+			}
+			resp.Layer = &pb.Layer{Hash: layer.Hash}
+			resp.DetectedFeatures = pbFeatures
+			first = nil
+
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// sortedAncestryFeatures returns the features of an ancestry layer grouped by
+// feature, as getPbAncestryFeatures returns them, and sorted by the name, then
+// the most likely namespace, then the version of the feature, as
+// pb.SortFeatures sorts them.
+func sortedAncestryFeatures(features []database.AncestryFeature) [][]database.AncestryFeature {
+	sorted := groupAncestryFeatures(features)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		fi, fj := sorted[i][0].NamespacedFeature, sorted[j][0].NamespacedFeature
+		if fi.Feature.Name != fj.Feature.Name {
+			return fi.Feature.Name < fj.Feature.Name
+		}
+
+		if fi.Namespace.Name != fj.Namespace.Name {
+			return fi.Namespace.Name < fj.Namespace.Name
+		}
+		return fi.Feature.Version < fj.Feature.Version
+	})
+	return sorted
+}
+
+// GetLayer implements retrieving the result of the scan of a layer via the
+// Clair gRPC service.
+func (s *AncestryServer) GetLayer(ctx context.Context, req *pb.GetLayerRequest) (*pb.GetLayerResponse, error) {
+	hash := req.GetHash()
+	if hash == "" {
+		return nil, newError(ErrorCodeInvalidArgument, "layer hash should not be empty")
+	}
+
+	layer, ok, err := database.FindLayerAndRollback(s.Store, hash)
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	if !ok {
+		return nil, errorf(ErrorCodeNotFound, "requested layer '%s' is not found", hash)
+	}
+
+	pbClairStatus, err := GetClairStatus(s.Store)
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	return &pb.GetLayerResponse{
+		Layer:            &pb.Layer{Hash: layer.Hash},
+		Detectors:        pb.DetectorsFromDatabaseModel(layer.By),
+		MissingDetectors: pb.DetectorsFromDatabaseModel(database.DiffDetectors(clair.EnabledDetectors, layer.By)),
+		FeatureCount:     int32(len(layer.Features)),
+		NamespaceCount:   int32(len(layer.Namespaces)),
+		Status:           pbClairStatus,
+	}, nil
+}
+
+// DeleteAncestry implements deleting the result of the scan of an ancestry via
+// the Clair gRPC service.
+func (s *AncestryServer) DeleteAncestry(ctx context.Context, req *pb.DeleteAncestryRequest) (*pb.DeleteAncestryResponse, error) {
+	if err := authorizeUpdate(ctx, s.UpdaterToken); err != nil {
+		return nil, err
+	}
+
+	name := req.GetAncestryName()
+	if name == "" {
+		return nil, newError(ErrorCodeInvalidArgument, "ancestry name should not be empty")
+	}
+
+	err := clair.DeleteAncestry(s.Store, name)
+	if err == commonerr.ErrNotFound {
+		return nil, errorf(ErrorCodeNotFound, "requested ancestry '%s' is not found", name)
+	} else if err != nil {
+		return nil, clairError(err)
+	}
+
+	return &pb.DeleteAncestryResponse{}, nil
+}
+
+// DeleteLayer implements deleting the result of the scan of a layer that no
+// ancestry contains via the Clair gRPC service.
+func (s *AncestryServer) DeleteLayer(ctx context.Context, req *pb.DeleteLayerRequest) (*pb.DeleteLayerResponse, error) {
+	if err := authorizeUpdate(ctx, s.UpdaterToken); err != nil {
+		return nil, err
+	}
+
+	hash := req.GetHash()
+	if hash == "" {
+		return nil, newError(ErrorCodeInvalidArgument, "layer hash should not be empty")
+	}
+
+	err := clair.DeleteLayer(s.Store, hash)
+	if err == commonerr.ErrNotFound {
+		return nil, errorf(ErrorCodeNotFound, "requested layer '%s' is not found", hash)
+	} else if err == database.ErrReferenced {
+		return nil, errorf(ErrorCodeFailedPrecondition, "requested layer '%s' is contained in ancestries, which should be deleted first", hash)
+	} else if err != nil {
+		return nil, clairError(err)
+	}
+
+	return &pb.DeleteLayerResponse{}, nil
+}
+
+// PruneAnalyses implements deleting the results of the scans older than a
+// number of days via the Clair gRPC service.
+func (s *AncestryServer) PruneAnalyses(ctx context.Context, req *pb.PruneAnalysesRequest) (*pb.PruneAnalysesResponse, error) {
+	if err := authorizeUpdate(ctx, s.UpdaterToken); err != nil {
+		return nil, err
+	}
+
+	days := req.GetOlderThanDays()
+	if days <= 0 {
+		return nil, newError(ErrorCodeInvalidArgument, "number of days should be at least 1")
+	}
+
+	ancestries, layers, err := clair.DeleteAnalysesBefore(s.Store, time.Now().AddDate(0, 0, -int(days)))
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	return &pb.PruneAnalysesResponse{
+		DeletedAncestries: ancestries,
+		DeletedLayers:     layers,
+	}, nil
+}
+
+// PostImage implements scanning a squashed image via the Clair gRPC service.
+func (s *AncestryServer) PostImage(ctx context.Context, req *pb.PostImageRequest) (*pb.PostImageResponse, error) {
+	if req.GetLayer() == nil {
+		return nil, newError(ErrorCodeInvalidArgument, "image layer should not be empty")
+	}
+
+	format := req.GetFormat()
+	if format == "" {
+		return nil, newError(ErrorCodeInvalidArgument, "image format should not be empty")
+	}
+
+	layerRequests, err := layerRequestsFromPostLayers([]*pb.PostAncestryRequest_PostLayer{req.GetLayer()}, req.GetRegistry(), req.GetObjectStorage())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.analysisContext(ctx)
+	defer cancel()
+
+	ancestry, err := clair.ProcessImage(ctx, s.Store, format, layerRequests[0])
+	if err != nil {
+		return nil, analysisError("image is failed to be processed: ", err)
+	}
+
+	pbClairStatus, err := GetClairStatus(s.Store)
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	tx, err := s.Store.Begin()
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	defer tx.Rollback()
+
+	pbLayer, err := GetPbAncestryLayer(tx, ancestry.Layers[0], req.GetWithSuppressed(), req.GetOnlyFixable(), req.GetExcludedTags())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.PostImageResponse{
+		Layer:     pbLayer,
+		Detectors: pb.DetectorsFromDatabaseModel(ancestry.By),
+		Status:    pbClairStatus,
 	}, nil
 }
 
@@ -157,16 +808,16 @@ func (s *AncestryServer) GetAncestry(ctx context.Context, req *pb.GetAncestryReq
 // service.
 func (s *NotificationServer) GetNotification(ctx context.Context, req *pb.GetNotificationRequest) (*pb.GetNotificationResponse, error) {
 	if req.GetName() == "" {
-		return nil, status.Error(codes.InvalidArgument, "notification name should not be empty")
+		return nil, newError(ErrorCodeInvalidArgument, "notification name should not be empty")
 	}
 
 	if req.GetLimit() <= 0 {
-		return nil, status.Error(codes.InvalidArgument, "notification page limit should not be empty or less than 1")
+		return nil, newError(ErrorCodeInvalidArgument, "notification page limit should not be empty or less than 1")
 	}
 
 	tx, err := s.Store.Begin()
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, clairError(err)
 	}
 	defer tx.Rollback()
 
@@ -178,16 +829,22 @@ func (s *NotificationServer) GetNotification(ctx context.Context, req *pb.GetNot
 	)
 
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, clairError(err)
 	}
 
 	if !ok {
-		return nil, status.Error(codes.NotFound, fmt.Sprintf("requested notification '%s' is not found", req.GetName()))
+		return nil, errorf(ErrorCodeNotFound, "requested notification '%s' is not found", req.GetName())
 	}
 
 	notification, err := pb.NotificationFromDatabaseModel(dbNotification)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, clairError(err)
+	}
+
+	for _, vuln := range []*pb.PagedVulnerableAncestries{notification.Old, notification.New} {
+		if vuln != nil && vuln.Vulnerability != nil {
+			vuln.Vulnerability.Suppressed = clair.IsAllowlisted(vuln.Vulnerability.Name, vuln.Vulnerability.NamespaceName, "")
+		}
 	}
 
 	return &pb.GetNotificationResponse{Notification: notification}, nil
@@ -197,25 +854,149 @@ func (s *NotificationServer) GetNotification(ctx context.Context, req *pb.GetNot
 // service.
 func (s *NotificationServer) MarkNotificationAsRead(ctx context.Context, req *pb.MarkNotificationAsReadRequest) (*pb.MarkNotificationAsReadResponse, error) {
 	if req.GetName() == "" {
-		return nil, status.Error(codes.InvalidArgument, "notification name should not be empty")
+		return nil, newError(ErrorCodeInvalidArgument, "notification name should not be empty")
 	}
 
 	tx, err := s.Store.Begin()
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, clairError(err)
 	}
 
 	defer tx.Rollback()
 	err = tx.DeleteNotification(req.GetName())
 	if err == commonerr.ErrNotFound {
-		return nil, status.Error(codes.NotFound, "requested notification \""+req.GetName()+"\" is not found")
+		return nil, newError(ErrorCodeNotFound, "requested notification \""+req.GetName()+"\" is not found")
 	} else if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, clairError(err)
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, clairError(err)
 	}
 
 	return &pb.MarkNotificationAsReadResponse{}, nil
 }
+
+// ListPendingNotifications implements listing a page of the notifications
+// that have not been sent yet, from the oldest one, via the Clair gRPC
+// service.
+func (s *NotificationServer) ListPendingNotifications(ctx context.Context, req *pb.ListPendingNotificationsRequest) (*pb.ListPendingNotificationsResponse, error) {
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return nil, newError(ErrorCodeInvalidArgument, "notification page limit should not be less than 1")
+	} else if limit == 0 {
+		limit = defaultPendingNotificationPageLimit
+	}
+
+	tx, err := s.Store.Begin()
+	if err != nil {
+		return nil, clairError(err)
+	}
+	defer tx.Rollback()
+
+	hooks, nextPage, err := tx.FindPendingNotifications(limit, pagination.Token(req.GetPage()))
+	if _, ok := err.(*commonerr.ErrBadRequest); ok {
+		return nil, newError(ErrorCodeInvalidArgument, err.Error())
+	} else if err != nil {
+		return nil, clairError(err)
+	}
+
+	count, oldest, err := tx.CountPendingNotifications()
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	now := time.Now()
+	resp := &pb.ListPendingNotificationsResponse{
+		Notifications: make([]*pb.ListPendingNotificationsResponse_PendingNotification, 0, len(hooks)),
+		NextPage:      string(nextPage),
+		Count:         int32(count),
+	}
+	if !oldest.IsZero() {
+		resp.OldestAgeSeconds = int64(now.Sub(oldest) / time.Second)
+	}
+
+	for _, hook := range hooks {
+		created, err := ptypes.TimestampProto(hook.Created)
+		if err != nil {
+			return nil, clairError(err)
+		}
+
+		resp.Notifications = append(resp.Notifications, &pb.ListPendingNotificationsResponse_PendingNotification{
+			Name:       hook.Name,
+			Created:    created,
+			AgeSeconds: int64(now.Sub(hook.Created) / time.Second),
+		})
+	}
+
+	return resp, nil
+}
+
+// ResolveNotification implements marking a pending notification as delivered,
+// as if it had been sent, or as cancelled, as if it had been marked as read,
+// via the Clair gRPC service.
+func (s *NotificationServer) ResolveNotification(ctx context.Context, req *pb.ResolveNotificationRequest) (*pb.ResolveNotificationResponse, error) {
+	if err := authorizeUpdate(ctx, s.UpdaterToken); err != nil {
+		return nil, err
+	}
+
+	name := req.GetName()
+	if name == "" {
+		return nil, newError(ErrorCodeInvalidArgument, "notification name should not be empty")
+	}
+
+	resolution := req.GetResolution()
+	if resolution != pb.ResolveNotificationRequest_RESOLUTION_DELIVERED && resolution != pb.ResolveNotificationRequest_RESOLUTION_CANCELLED {
+		return nil, newError(ErrorCodeInvalidArgument, "notification resolution should be RESOLUTION_DELIVERED or RESOLUTION_CANCELLED")
+	}
+
+	tx, err := s.Store.Begin()
+	if err != nil {
+		return nil, clairError(err)
+	}
+	defer tx.Rollback()
+
+	notification, ok, err := tx.FindVulnerabilityNotification(name, 1, pagination.FirstPageToken, pagination.FirstPageToken)
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	if !ok || !notification.Notified.IsZero() || !notification.Deleted.IsZero() {
+		return nil, errorf(ErrorCodeNotFound, "requested pending notification '%s' is not found", name)
+	}
+
+	if resolution == pb.ResolveNotificationRequest_RESOLUTION_DELIVERED {
+		err = tx.MarkNotificationAsRead(name)
+	} else {
+		err = tx.DeleteNotification(name)
+	}
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, clairError(err)
+	}
+
+	return &pb.ResolveNotificationResponse{}, nil
+}
+
+// FlushNotifications implements discarding the pending notifications created
+// more than a number of hours ago via the Clair gRPC service.
+func (s *NotificationServer) FlushNotifications(ctx context.Context, req *pb.FlushNotificationsRequest) (*pb.FlushNotificationsResponse, error) {
+	if err := authorizeUpdate(ctx, s.UpdaterToken); err != nil {
+		return nil, err
+	}
+
+	hours := req.GetOlderThanHours()
+	if hours <= 0 {
+		return nil, newError(ErrorCodeInvalidArgument, "number of hours should be at least 1")
+	}
+
+	names, err := clair.FlushNotificationsBefore(s.Store, time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	return &pb.FlushNotificationsResponse{FlushedNotifications: names}, nil
+}
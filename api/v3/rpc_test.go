@@ -0,0 +1,89 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	pb "github.com/coreos/clair/api/v3/clairpb"
+)
+
+// testAncestryStream records the messages of a streamed ancestry.
+type testAncestryStream struct {
+	grpc.ServerStream
+	responses []*pb.StreamAncestryResponse
+}
+
+func (s *testAncestryStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *testAncestryStream) Send(resp *pb.StreamAncestryResponse) error {
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+func TestStreamAncestry(t *testing.T) {
+	store := openTestAncestryStore(t)
+	defer store.Close()
+	s := &AncestryServer{Store: store}
+
+	for _, req := range []*pb.StreamAncestryRequest{
+		{AncestryName: "ancestry"},
+		{AncestryName: "ancestry", Limit: 1},
+		{AncestryName: "ancestry", Limit: 1, OnlyFixable: true, ExcludedTags: []string{"no-dsa"}},
+	} {
+		expected, err := s.GetAncestry(context.Background(), &pb.GetAncestryRequest{
+			AncestryName:   req.AncestryName,
+			WithSuppressed: req.WithSuppressed,
+			OnlyFixable:    req.OnlyFixable,
+			ExcludedTags:   req.ExcludedTags,
+		})
+		require.Nil(t, err)
+
+		stream := &testAncestryStream{}
+		require.Nil(t, s.StreamAncestry(req, stream))
+		require.NotEmpty(t, stream.responses)
+
+		// Only the first message carries the detectors and the status.
+		assert.Equal(t, expected.Status, stream.responses[0].Status)
+		assert.Equal(t, expected.Ancestry.Detectors, stream.responses[0].Detectors)
+		for _, resp := range stream.responses[1:] {
+			assert.Nil(t, resp.Status)
+			assert.Nil(t, resp.Detectors)
+		}
+
+		// The features of the messages of a layer, put together, are the
+		// ones that GetAncestry returns, in the same order.
+		var layers []*pb.GetAncestryResponse_AncestryLayer
+		for _, resp := range stream.responses {
+			if limit := int(req.Limit); limit > 0 {
+				assert.True(t, len(resp.DetectedFeatures) <= limit, "%v", req)
+			}
+
+			if len(layers) == 0 || layers[len(layers)-1].Layer.Hash != resp.Layer.Hash {
+				layers = append(layers, &pb.GetAncestryResponse_AncestryLayer{Layer: resp.Layer})
+			}
+			last := layers[len(layers)-1]
+			last.DetectedFeatures = append(last.DetectedFeatures, resp.DetectedFeatures...)
+		}
+		assert.Equal(t, expected.Ancestry.Layers, layers, "%v", req)
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/pborman/uuid"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+
+	pb "github.com/coreos/clair/api/v3/clairpb"
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/cyclonedx"
+	"github.com/coreos/clair/pkg/spdx"
+	"github.com/coreos/clair/pkg/version"
+)
+
+// The formats of the bills of materials of the ancestries.
+const (
+	SBOMFormatCycloneDXJSON = "cyclonedx-json"
+	SBOMFormatCycloneDXXML  = "cyclonedx-xml"
+	SBOMFormatSPDXJSON      = "spdx-json"
+)
+
+// gatewayAcceptHeader is the metadata key of the Accept header of the requests
+// forwarded by the Gateway.
+const gatewayAcceptHeader = "grpcgateway-accept"
+
+// sbomMediaTypes are the formats of the bills of materials by the media type
+// that the REST clients may accept instead of giving a format.
+var sbomMediaTypes = map[string]string{
+	"application/vnd.cyclonedx+json": SBOMFormatCycloneDXJSON,
+	"application/vnd.cyclonedx+xml":  SBOMFormatCycloneDXXML,
+	"application/spdx+json":          SBOMFormatSPDXJSON,
+}
+
+// spdxSuppliers are the suppliers of the packages of the namespaces of the
+// operating systems, by operating system.
+var spdxSuppliers = map[string]string{
+	"alpine":     "Alpine Linux",
+	"amzn":       "Amazon Linux",
+	"centos":     "CentOS",
+	"chainguard": "Chainguard",
+	"debian":     "Debian",
+	"fedora":     "Fedora Project",
+	"gentoo":     "Gentoo Linux",
+	"opensuse":   "openSUSE",
+	"oracle":     "Oracle",
+	"photon":     "VMware Photon OS",
+	"rhel":       "Red Hat",
+	"sles":       "SUSE",
+	"ubuntu":     "Canonical",
+	"windows":    "Microsoft",
+	"wolfi":      "Wolfi",
+}
+
+// sbomEncoders encode the result of the scan of an ancestry as a bill of
+// materials, by format, and return its media type.
+var sbomEncoders = map[string]func(*pb.GetAncestryResponse_Ancestry) (string, []byte, error){
+	SBOMFormatCycloneDXJSON: func(ancestry *pb.GetAncestryResponse_Ancestry) (string, []byte, error) {
+		d, err := cycloneDXFromAncestry(ancestry, time.Now()).EncodeJSON()
+		return cyclonedx.MediaTypeJSON, d, err
+	},
+	SBOMFormatCycloneDXXML: func(ancestry *pb.GetAncestryResponse_Ancestry) (string, []byte, error) {
+		d, err := cycloneDXFromAncestry(ancestry, time.Now()).EncodeXML()
+		return cyclonedx.MediaTypeXML, d, err
+	},
+	SBOMFormatSPDXJSON: func(ancestry *pb.GetAncestryResponse_Ancestry) (string, []byte, error) {
+		d, err := spdxFromAncestry(ancestry, time.Now()).EncodeJSON()
+		return spdx.MediaTypeJSON, d, err
+	},
+}
+
+// GetAncestrySBOM implements retrieving the result of the scan of an ancestry
+// as a bill of materials via the Clair gRPC service.
+func (s *AncestryServer) GetAncestrySBOM(ctx context.Context, req *pb.GetAncestrySBOMRequest) (*pb.GetAncestrySBOMResponse, error) {
+	format := req.GetFormat()
+	if format == "" {
+		format = acceptedSBOMFormat(ctx)
+	}
+
+	encode, ok := sbomEncoders[format]
+	if !ok {
+		return nil, errorf(ErrorCodeInvalidArgument, "unknown bill of materials format '%s'", format)
+	}
+
+	resp, err := s.GetAncestry(ctx, &pb.GetAncestryRequest{
+		AncestryName:   req.GetAncestryName(),
+		WithSuppressed: req.GetWithSuppressed(),
+		ExcludedTags:   req.GetExcludedTags(),
+		OnlyFixable:    req.GetOnlyFixable(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, sbom, err := encode(resp.GetAncestry())
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	return &pb.GetAncestrySBOMResponse{MediaType: mediaType, Sbom: sbom}, nil
+}
+
+// acceptedSBOMFormat returns the format of the bill of materials accepted by
+// the REST client of a request, or the default one.
+func acceptedSBOMFormat(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, accept := range md[gatewayAcceptHeader] {
+		for _, mediaType := range strings.Split(accept, ",") {
+			if i := strings.Index(mediaType, ";"); i >= 0 {
+				mediaType = mediaType[:i]
+			}
+			if format, ok := sbomMediaTypes[strings.TrimSpace(mediaType)]; ok {
+				return format
+			}
+		}
+	}
+	return SBOMFormatCycloneDXJSON
+}
+
+// cycloneDXFromAncestry converts the result of the scan of an ancestry to a
+// CycloneDX BOM made at the given time, whose components are its features.
+func cycloneDXFromAncestry(ancestry *pb.GetAncestryResponse_Ancestry, now time.Time) *cyclonedx.BOM {
+	bom := cyclonedx.New(uuid.NewRandom().URN())
+	bom.Metadata = &cyclonedx.Metadata{
+		Timestamp: now.UTC().Format(time.RFC3339),
+		Tools: &cyclonedx.Tools{Components: []cyclonedx.Component{{
+			Type:    cyclonedx.ComponentTypeApplication,
+			Name:    "clair",
+			Version: version.Version,
+		}}},
+		Component: &cyclonedx.Component{
+			Type:   cyclonedx.ComponentTypeContainer,
+			BOMRef: ancestry.GetName(),
+			Name:   ancestry.GetName(),
+		},
+	}
+
+	var (
+		vulnerabilities = make(map[string]*cyclonedx.Vulnerability)
+		fixes           = make(map[string][]string)
+		refs            []string
+	)
+
+	for _, layer := range ancestry.GetLayers() {
+		for _, feature := range layer.GetDetectedFeatures() {
+			ref := fmt.Sprintf("%s/%s@%s", feature.GetNamespace().GetName(), feature.GetName(), feature.GetVersion())
+			bom.Components = append(bom.Components, cyclonedx.Component{
+				Type:    cyclonedx.ComponentTypeLibrary,
+				BOMRef:  ref,
+				Name:    feature.GetName(),
+				Version: feature.GetVersion(),
+				PURL:    feature.GetPurl(),
+				Properties: []cyclonedx.Property{
+					{Name: "clair:namespace", Value: feature.GetNamespace().GetName()},
+					{Name: "clair:version_format", Value: feature.GetVersionFormat()},
+					{Name: "clair:layer", Value: layer.GetLayer().GetHash()},
+				},
+			})
+
+			for _, vuln := range feature.GetVulnerabilities() {
+				key := vuln.GetNamespaceName() + "/" + vuln.GetName()
+				v, ok := vulnerabilities[key]
+				if !ok {
+					v = cycloneDXVulnerability(key, vuln)
+					vulnerabilities[key] = v
+					refs = append(refs, key)
+				}
+
+				v.Affects = append(v.Affects, cyclonedx.Affect{Ref: ref})
+				if vuln.GetFixedBy() != "" {
+					fixes[key] = append(fixes[key], fmt.Sprintf("%s to %s", feature.GetName(), vuln.GetFixedBy()))
+				}
+				if vuln.GetTag() != "" {
+					v.Properties = append(v.Properties, cyclonedx.Property{Name: "clair:tag", Value: ref + " " + vuln.GetTag()})
+				}
+			}
+		}
+	}
+
+	sort.Strings(refs)
+	for _, key := range refs {
+		v := vulnerabilities[key]
+		if len(fixes[key]) > 0 {
+			v.Recommendation = "Upgrade " + strings.Join(fixes[key], ", ")
+		}
+		bom.Vulnerabilities = append(bom.Vulnerabilities, *v)
+	}
+
+	return bom
+}
+
+// spdxFromAncestry converts the result of the scan of an ancestry to an SPDX
+// document created at the given time, which describes the image of the
+// ancestry containing its features as packages.
+func spdxFromAncestry(ancestry *pb.GetAncestryResponse_Ancestry, now time.Time) *spdx.Document {
+	tool := "Tool: clair"
+	if version.Version != "" {
+		tool += "-" + version.Version
+	}
+
+	name := ancestry.GetName()
+	doc := spdx.New(name, "https://github.com/coreos/clair/spdx/"+url.PathEscape(name)+"-"+uuid.New(), now.UTC().Format(time.RFC3339), tool)
+
+	imageID := spdx.ID("Image", name)
+	doc.Packages = append(doc.Packages, spdx.Package{
+		SPDXID:                imageID,
+		Name:                  name,
+		DownloadLocation:      spdx.NoAssertion,
+		PrimaryPackagePurpose: spdx.PurposeContainer,
+	})
+	doc.Relationships = append(doc.Relationships, spdx.Relationship{
+		SPDXElementID:      spdx.DocumentID,
+		RelationshipType:   spdx.RelationshipDescribes,
+		RelatedSPDXElement: imageID,
+	})
+
+	for _, layer := range ancestry.GetLayers() {
+		for _, feature := range layer.GetDetectedFeatures() {
+			namespace := feature.GetNamespace().GetName()
+			pkg := spdx.Package{
+				SPDXID:                spdx.ID("Package", strconv.Itoa(len(doc.Packages)), feature.GetName()),
+				Name:                  feature.GetName(),
+				VersionInfo:           feature.GetVersion(),
+				Supplier:              spdx.NoAssertion,
+				DownloadLocation:      spdx.NoAssertion,
+				PrimaryPackagePurpose: spdx.PurposeLibrary,
+				Comment:               fmt.Sprintf("Detected in the namespace %s of the layer %s.", namespace, layer.GetLayer().GetHash()),
+			}
+
+			osName := strings.SplitN(namespace, ":", 2)[0]
+			if supplier, ok := spdxSuppliers[osName]; ok {
+				pkg.Supplier = "Organization: " + supplier
+			}
+
+			if feature.GetPurl() != "" {
+				pkg.ExternalRefs = []spdx.ExternalRef{spdx.PURLRef(feature.GetPurl())}
+			}
+
+			doc.Packages = append(doc.Packages, pkg)
+			doc.Relationships = append(doc.Relationships, spdx.Relationship{
+				SPDXElementID:      imageID,
+				RelationshipType:   spdx.RelationshipContains,
+				RelatedSPDXElement: pkg.SPDXID,
+			})
+		}
+	}
+
+	return doc
+}
+
+// cycloneDXVulnerability converts a vulnerability of a feature to a CycloneDX
+// vulnerability identified by ref, which does not affect any component yet.
+func cycloneDXVulnerability(ref string, vuln *pb.Vulnerability) *cyclonedx.Vulnerability {
+	source := &cyclonedx.Source{Name: vuln.GetNamespaceName(), URL: vuln.GetLink()}
+	v := &cyclonedx.Vulnerability{
+		BOMRef:      ref,
+		ID:          vuln.GetName(),
+		Source:      source,
+		Ratings:     []cyclonedx.Rating{{Source: source, Severity: cycloneDXSeverity(database.Severity(vuln.GetSeverity()))}},
+		Description: vuln.GetDescription(),
+	}
+
+	if vuln.GetLink() != "" {
+		v.Advisories = []cyclonedx.Advisory{{URL: vuln.GetLink()}}
+	}
+
+	if vuln.GetSuppressed() {
+		v.Properties = append(v.Properties, cyclonedx.Property{Name: "clair:suppressed", Value: "true"})
+	}
+
+	return v
+}
+
+// cycloneDXSeverity converts the severity of a vulnerability to the severity
+// of its CycloneDX rating.
+func cycloneDXSeverity(severity database.Severity) string {
+	switch severity {
+	case database.Defcon1Severity, database.CriticalSeverity:
+		return cyclonedx.SeverityCritical
+	case database.HighSeverity:
+		return cyclonedx.SeverityHigh
+	case database.MediumSeverity:
+		return cyclonedx.SeverityMedium
+	case database.LowSeverity:
+		return cyclonedx.SeverityLow
+	case database.NegligibleSeverity:
+		return cyclonedx.SeverityInfo
+	}
+	return cyclonedx.SeverityUnknown
+}
+
+// sbomMarshaler is the outbound marshaler of the Gateway, which writes the
+// bills of materials as they are encoded instead of as JSON.
+type sbomMarshaler struct {
+	runtime.JSONPb
+}
+
+func (m *sbomMarshaler) Marshal(v interface{}) ([]byte, error) {
+	if resp, ok := v.(*pb.GetAncestrySBOMResponse); ok {
+		return resp.GetSbom(), nil
+	}
+	return m.JSONPb.Marshal(v)
+}
+
+// sbomContentType sets the media type of the bills of materials as the
+// Content-Type header of their REST responses.
+func sbomContentType(ctx context.Context, w http.ResponseWriter, resp proto.Message) error {
+	if resp, ok := resp.(*pb.GetAncestrySBOMResponse); ok {
+		w.Header().Set("Content-Type", resp.GetMediaType())
+	}
+	return nil
+}
+
+// gatewayOptions are the options of the Gateway, which serves the bills of
+// materials as they are encoded.
+var gatewayOptions = []runtime.ServeMuxOption{
+	runtime.WithMarshalerOption(runtime.MIMEWildcard, &sbomMarshaler{runtime.JSONPb{OrigName: true}}),
+	runtime.WithForwardResponseOption(sbomContentType),
+}
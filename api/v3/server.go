@@ -15,17 +15,22 @@
 package v3
 
 import (
+	"crypto/tls"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
 	pb "github.com/coreos/clair/api/v3/clairpb"
 	"github.com/coreos/clair/database"
 	"github.com/coreos/clair/pkg/grpcutil"
+	"github.com/coreos/clair/pkg/logutil"
 )
 
 var (
@@ -38,6 +43,9 @@ var (
 
 func init() {
 	prometheus.MustRegister(promResponseDurationMilliseconds)
+
+	// The Gateway replies with the cause of the errors.
+	runtime.HTTPError = httpError
 }
 
 func prometheusHandler(h http.Handler) http.Handler {
@@ -58,45 +66,100 @@ func (w *httpStatusWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
+// Flush implements http.Flusher, which is required to stream the responses of
+// the gRPC Gateway.
+func (w *httpStatusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// loggingHandler logs the HTTP requests along with their ID, which is taken
+// from the X-Request-Id header or generated, forwarded to the gRPC services and
+// sent back in the response.
 func loggingHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		lrw := &httpStatusWriter{ResponseWriter: w, StatusCode: http.StatusOK}
 
+		id := r.Header.Get(grpcutil.RequestIDHeader)
+		if id == "" {
+			id = logutil.NewRequestID()
+		}
+		r.Header.Set(runtime.MetadataHeaderPrefix+grpcutil.RequestIDHeader, id)
+		w.Header().Set(grpcutil.RequestIDHeader, id)
+
+		fields := log.Fields{
+			logutil.RequestIDField: id,
+			"remote addr":          r.RemoteAddr,
+			"method":               r.Method,
+			"request uri":          r.RequestURI,
+		}
+
+		// Only the identity of the verified client certificate is forwarded.
+		r.Header.Del(grpcutil.ClientIdentityHeader)
+		r.Header.Del(runtime.MetadataHeaderPrefix + grpcutil.ClientIdentityHeader)
+		if identity, ok := grpcutil.ClientIdentity(r.TLS); ok {
+			r.Header.Set(runtime.MetadataHeaderPrefix+grpcutil.ClientIdentityHeader, identity)
+			fields[logutil.ClientIdentityField] = identity
+		}
+
 		h.ServeHTTP(lrw, r)
 
-		log.WithFields(log.Fields{
-			"remote addr":       r.RemoteAddr,
-			"method":            r.Method,
-			"request uri":       r.RequestURI,
-			"status":            strconv.Itoa(lrw.StatusCode),
-			"elapsed time (ms)": float64(time.Since(start).Nanoseconds()) * 1e-6,
-		}).Info("handled HTTP request")
+		fields["status"] = strconv.Itoa(lrw.StatusCode)
+		fields["elapsed time (ms)"] = float64(time.Since(start).Nanoseconds()) * 1e-6
+		log.WithFields(fields).Info("handled HTTP request")
 	})
 }
 
-// ListenAndServe serves the Clair v3 API over gRPC and the gRPC Gateway.
-func ListenAndServe(addr, keyFile, certFile, caPath string, store database.Datastore) error {
+// ListenAndServe serves the Clair v3 API over gRPC and the gRPC Gateway until
+// stop is closed, and then waits for the in-flight requests before returning.
+//
+// The API is served over TLS, configured by tlsConfig, e.g. for its minimum
+// version and cipher suites, when certFile and keyFile are set. The clients
+// must then present a certificate signed by the CA at caPath, if set,
+// according to clientAuth. The analyses of the posted layers are stopped after
+// the given timeout, the updates triggered on demand, the resolution of the
+// pending notifications and the deletion of the results of the scans must be
+// authorized by the updater token and the requests of each client are limited
+// by limits.
+func ListenAndServe(addr, keyFile, certFile, caPath string, tlsConfig *tls.Config, clientAuth tls.ClientAuthType, timeout time.Duration, updaterToken string, limits Limits, store database.Datastore, stop <-chan struct{}) error {
 	srv := grpcutil.MuxedGRPCServer{
-		Addr: addr,
+		Addr:           addr,
+		TLSConfig:      tlsConfig,
+		ClientAuth:     clientAuth,
+		Stop:           stop,
+		Interceptors:   newLimiter(limits).interceptors(),
+		GatewayOptions: gatewayOptions,
 		ServicesFunc: func(gsrv *grpc.Server) {
-			pb.RegisterAncestryServiceServer(gsrv, &AncestryServer{Store: store})
-			pb.RegisterNotificationServiceServer(gsrv, &NotificationServer{Store: store})
-			pb.RegisterStatusServiceServer(gsrv, &StatusServer{Store: store})
+			pb.RegisterAncestryServiceServer(gsrv, &AncestryServer{Store: store, Timeout: timeout, UpdaterToken: updaterToken})
+			pb.RegisterNotificationServiceServer(gsrv, &NotificationServer{Store: store, UpdaterToken: updaterToken})
+			pb.RegisterNamespaceServiceServer(gsrv, &NamespaceServer{Store: store})
+			pb.RegisterFeatureServiceServer(gsrv, &FeatureServer{Store: store})
+			pb.RegisterStatusServiceServer(gsrv, &StatusServer{Store: store, UpdaterToken: updaterToken})
+
+			services := make(map[string]struct{})
+			for name := range gsrv.GetServiceInfo() {
+				services[name] = struct{}{}
+			}
+			healthpb.RegisterHealthServer(gsrv, &HealthServer{Store: store, Services: services})
+			reflection.Register(gsrv)
 		},
 		ServiceHandlerFuncs: []grpcutil.RegisterServiceHandlerFunc{
 			pb.RegisterAncestryServiceHandler,
 			pb.RegisterNotificationServiceHandler,
+			pb.RegisterNamespaceServiceHandler,
+			pb.RegisterFeatureServiceHandler,
 			pb.RegisterStatusServiceHandler,
 		},
 	}
 
 	middleware := func(h http.Handler) http.Handler {
-		return prometheusHandler(loggingHandler(h))
+		return prometheusHandler(loggingHandler(uploadHandler(h, limits.MaxRequestSize)))
 	}
 
 	var err error
-	if caPath == "" {
+	if certFile == "" && keyFile == "" {
 		err = srv.ListenAndServe(middleware)
 	} else {
 		err = srv.ListenAndServeTLS(certFile, keyFile, caPath, middleware)
@@ -0,0 +1,85 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"golang.org/x/net/context"
+
+	pb "github.com/coreos/clair/api/v3/clairpb"
+	"github.com/coreos/clair/database"
+)
+
+// GetAncestrySummary implements counting the vulnerabilities of an ancestry by
+// severity via the Clair gRPC service.
+//
+// The vulnerabilities of all the layers are retrieved at once, without their
+// details, and counted for each feature as GetAncestry would return them
+// without converting them. The unknown severities are counted as Unknown.
+func (s *AncestryServer) GetAncestrySummary(ctx context.Context, req *pb.GetAncestrySummaryRequest) (*pb.GetAncestrySummaryResponse, error) {
+	name := req.GetAncestryName()
+	if name == "" {
+		return nil, newError(ErrorCodeInvalidArgument, "ancestry name should not be empty")
+	}
+
+	tx, err := s.Store.Begin()
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	defer tx.Rollback()
+
+	ancestry, ok, err := tx.FindAncestry(name)
+	if err != nil {
+		return nil, clairError(err)
+	}
+
+	if !ok {
+		return nil, errorf(ErrorCodeNotFound, "requested ancestry '%s' is not found", name)
+	}
+
+	var features []database.AncestryFeature
+	for _, layer := range ancestry.Layers {
+		features = append(features, layer.Features...)
+	}
+
+	affectedBy, err := findAffectedBy(tx.FindAffectedNamespacedFeatureSeverities, features)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetAncestrySummaryResponse{}
+	counts := make(map[database.Severity]int32)
+	for _, layer := range ancestry.Layers {
+		for _, group := range groupAncestryFeatures(layer.Features) {
+			resp.FeatureCount++
+
+			for _, vuln := range filterAncestryVulnerabilities(group, affectedBy, req.GetWithSuppressed(), req.GetOnlyFixable(), req.GetExcludedTags()) {
+				severity, _ := database.NewSeverity(string(vuln.Severity))
+				counts[severity]++
+				resp.VulnerabilityCount++
+			}
+		}
+	}
+
+	for i := len(database.Severities) - 1; i >= 0; i-- {
+		severity := database.Severities[i]
+		resp.Severities = append(resp.Severities, &pb.GetAncestrySummaryResponse_SeverityCount{
+			Severity: string(severity),
+			Count:    counts[severity],
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	pb "github.com/coreos/clair/api/v3/clairpb"
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/dpkg"
+
+	_ "github.com/coreos/clair/database/mem"
+)
+
+// openTestAncestryStore opens a memory datastore with an ancestry named
+// "ancestry" of two layers, whose features are affected by vulnerabilities of
+// every kind filtered by the requests.
+func openTestAncestryStore(t *testing.T) database.Datastore {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "mem"})
+	require.Nil(t, err)
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	namespace := database.Namespace{Name: "debian:8", VersionFormat: dpkg.ParserName}
+	detectors := []database.Detector{
+		database.NewNamespaceDetector("os-release", "1.0"),
+		database.NewFeatureDetector("dpkg", "1.0"),
+	}
+	features := []database.NamespacedFeature{
+		{Feature: database.Feature{Name: "openssl", Version: "1.0", VersionFormat: dpkg.ParserName}, Namespace: namespace},
+		{Feature: database.Feature{Name: "libssl1.0", Version: "1.0", SourceName: "openssl", SourceVersion: "1.0", VersionFormat: dpkg.ParserName}, Namespace: namespace},
+		{Feature: database.Feature{Name: "zlib", Version: "1.2", VersionFormat: dpkg.ParserName}, Namespace: namespace},
+	}
+
+	require.Nil(t, tx.PersistDetectors(detectors))
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{namespace}))
+	for _, f := range features {
+		require.Nil(t, tx.PersistFeatures([]database.Feature{f.Feature}))
+	}
+	require.Nil(t, tx.PersistNamespacedFeatures(features))
+
+	vulnerability := func(name string, severity database.Severity, feature, fixedIn, tag string) database.VulnerabilityWithAffected {
+		affected := fixedIn
+		if affected == "" {
+			affected = versionfmt.MaxVersion
+		}
+
+		return database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{
+				Name:        name,
+				Namespace:   namespace,
+				Description: "description of " + name,
+				Link:        "https://example.com/" + name,
+				Severity:    severity,
+				Metadata:    database.MetadataMap{"NVD": map[string]interface{}{"score": 7.5}},
+			},
+			Affected: []database.AffectedFeature{{
+				AffectedType:    database.AffectSourcePackage,
+				Namespace:       namespace,
+				FeatureName:     feature,
+				AffectedVersion: affected,
+				FixedInVersion:  fixedIn,
+				Tag:             tag,
+			}},
+		}
+	}
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{
+		// Affects both openssl and libssl1.0, through its source package.
+		vulnerability("CVE-2018-0001", database.HighSeverity, "openssl", "2.0", ""),
+		// Has a severity unknown to Clair.
+		vulnerability("CVE-2018-0002", database.Severity("Moderate"), "openssl", "2.0", ""),
+		// Has no fix.
+		vulnerability("CVE-2018-0003", database.LowSeverity, "zlib", "", ""),
+		// Is excluded by its tag.
+		vulnerability("CVE-2018-0004", database.CriticalSeverity, "zlib", "1.3", "no-dsa"),
+	}))
+	require.Nil(t, tx.CacheAffectedNamespacedFeatures(features))
+
+	ancestry := database.Ancestry{Name: "ancestry", By: detectors}
+	for i, layerFeatures := range [][]database.NamespacedFeature{features[:2], features[2:]} {
+		layer := database.AncestryLayer{Hash: fmt.Sprintf("layer-%d", i)}
+		require.Nil(t, tx.PersistLayer(layer.Hash, nil, nil, detectors))
+		for _, f := range layerFeatures {
+			layer.Features = append(layer.Features, database.AncestryFeature{NamespacedFeature: f, FeatureBy: detectors[1], NamespaceBy: detectors[0]})
+		}
+		ancestry.Layers = append(ancestry.Layers, layer)
+	}
+	require.Nil(t, tx.UpsertAncestry(ancestry))
+	require.Nil(t, tx.Commit())
+
+	return store
+}
+
+func TestGetAncestrySummary(t *testing.T) {
+	store := openTestAncestryStore(t)
+	defer store.Close()
+	s := &AncestryServer{Store: store}
+
+	for _, req := range []*pb.GetAncestryRequest{
+		{AncestryName: "ancestry"},
+		{AncestryName: "ancestry", OnlyFixable: true},
+		{AncestryName: "ancestry", ExcludedTags: []string{"no-dsa"}},
+	} {
+		ancestry, err := s.GetAncestry(context.Background(), req)
+		require.Nil(t, err)
+
+		// The vulnerabilities are counted for each feature as GetAncestry
+		// returns them, under Unknown when their severity is unknown.
+		expected := &pb.GetAncestrySummaryResponse{}
+		counts := make(map[database.Severity]int32)
+		for _, layer := range ancestry.GetAncestry().GetLayers() {
+			for _, feature := range layer.GetDetectedFeatures() {
+				expected.FeatureCount++
+				for _, vuln := range feature.GetVulnerabilities() {
+					severity, _ := database.NewSeverity(vuln.GetSeverity())
+					counts[severity]++
+					expected.VulnerabilityCount++
+				}
+			}
+		}
+		for i := len(database.Severities) - 1; i >= 0; i-- {
+			expected.Severities = append(expected.Severities, &pb.GetAncestrySummaryResponse_SeverityCount{
+				Severity: string(database.Severities[i]),
+				Count:    counts[database.Severities[i]],
+			})
+		}
+
+		summary, err := s.GetAncestrySummary(context.Background(), &pb.GetAncestrySummaryRequest{
+			AncestryName:   req.AncestryName,
+			WithSuppressed: req.WithSuppressed,
+			OnlyFixable:    req.OnlyFixable,
+			ExcludedTags:   req.ExcludedTags,
+		})
+		if assert.Nil(t, err) {
+			assert.Equal(t, expected, summary, "%v", req)
+		}
+	}
+
+	// Without filters, the vulnerability of the two features of openssl is
+	// counted twice and the unknown severity is counted as Unknown.
+	summary, err := s.GetAncestrySummary(context.Background(), &pb.GetAncestrySummaryRequest{AncestryName: "ancestry"})
+	require.Nil(t, err)
+	assert.Equal(t, int32(3), summary.FeatureCount)
+	assert.Equal(t, int32(6), summary.VulnerabilityCount)
+
+	counts := make(map[string]int32)
+	for _, count := range summary.Severities {
+		counts[count.Severity] = count.Count
+	}
+	assert.Equal(t, map[string]int32{
+		string(database.UnknownSeverity):    2,
+		string(database.NegligibleSeverity): 0,
+		string(database.LowSeverity):        1,
+		string(database.MediumSeverity):     0,
+		string(database.HighSeverity):       2,
+		string(database.CriticalSeverity):   1,
+		string(database.Defcon1Severity):    0,
+	}, counts)
+}
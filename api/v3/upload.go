@@ -0,0 +1,155 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	pb "github.com/coreos/clair/api/v3/clairpb"
+	"github.com/coreos/clair/pkg/logutil"
+)
+
+// layerUploadPrefix is the prefix of the path of the requests uploading a
+// layer in their body, PUT /layers/{hash}?format={format}.
+const layerUploadPrefix = "/layers/"
+
+// readerError records the error of the reads of its reader, so that the
+// failures to read a request body are told apart from the failures to write
+// it.
+type readerError struct {
+	io.Reader
+	err error
+}
+
+func (r *readerError) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// uploadHandler limits the size of the bodies of the requests to
+// maxRequestSize, unless it is not set, and serves the uploads of layers.
+//
+// As the Gateway reads the whole body of the requests, the body of an upload
+// is streamed to a temporary file instead, which is then analyzed as a layer
+// posted with its path to /layers, and removed once the request is done.
+func uploadHandler(h http.Handler, maxRequestSize int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if maxRequestSize > 0 && r.ContentLength > maxRequestSize {
+			writeHTTPError(w, ErrorCodeRequestTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxRequestSize))
+			return
+		}
+
+		hash := strings.TrimPrefix(r.URL.Path, layerUploadPrefix)
+		if r.Method != http.MethodPut || hash == r.URL.Path || hash == "" || strings.Contains(hash, "/") {
+			// The Gateway reads the whole body anyway, so the body without
+			// length is read first to be rejected like the other ones.
+			if maxRequestSize > 0 && r.ContentLength < 0 {
+				body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+				if err != nil {
+					writeHTTPError(w, ErrorCodeInvalidArgument, "could not read the request body: "+err.Error())
+					return
+				}
+				if int64(len(body)) > maxRequestSize {
+					writeHTTPError(w, ErrorCodeRequestTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxRequestSize))
+					return
+				}
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			writeHTTPError(w, ErrorCodeInvalidArgument, "layer format should not be empty")
+			return
+		}
+
+		f, err := ioutil.TempFile("", "clair-layer-")
+		if err != nil {
+			logutil.FromContext(r.Context()).WithError(err).Error("could not create the file of an uploaded layer")
+			writeHTTPError(w, ErrorCodeInternal, "could not store the uploaded layer")
+			return
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		// The body without length is read up to one byte past the limit, to
+		// tell whether it exceeds it.
+		body := &readerError{Reader: r.Body}
+		var src io.Reader = body
+		if maxRequestSize > 0 {
+			src = io.LimitReader(body, maxRequestSize+1)
+		}
+
+		n, err := io.Copy(f, src)
+		if err != nil {
+			switch {
+			case r.Context().Err() != nil:
+				writeHTTPError(w, ErrorCodeCanceled, "the upload of the layer was canceled")
+			case body.err != nil:
+				writeHTTPError(w, ErrorCodeInvalidArgument, "could not read the uploaded layer: "+body.err.Error())
+			default:
+				logutil.FromContext(r.Context()).WithError(err).Error("could not write the file of an uploaded layer")
+				writeHTTPError(w, ErrorCodeInternal, "could not store the uploaded layer")
+			}
+			return
+		}
+
+		if maxRequestSize > 0 && n > maxRequestSize {
+			writeHTTPError(w, ErrorCodeRequestTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxRequestSize))
+			return
+		}
+
+		if err := f.Close(); err != nil {
+			logutil.FromContext(r.Context()).WithError(err).Error("could not write the file of an uploaded layer")
+			writeHTTPError(w, ErrorCodeInternal, "could not store the uploaded layer")
+			return
+		}
+
+		post, err := json.Marshal(&pb.PostLayersRequest{
+			Format: format,
+			Layers: []*pb.PostAncestryRequest_PostLayer{{Hash: hash, Path: f.Name()}},
+		})
+		if err != nil {
+			writeHTTPError(w, ErrorCodeInternal, err.Error())
+			return
+		}
+
+		forwarded, err := http.NewRequest(http.MethodPost, "/layers", bytes.NewReader(post))
+		if err != nil {
+			writeHTTPError(w, ErrorCodeInternal, err.Error())
+			return
+		}
+		forwarded = forwarded.WithContext(r.Context())
+		forwarded.Header = r.Header
+		forwarded.Header.Set("Content-Type", "application/json")
+		forwarded.Header.Del("Content-Length")
+		forwarded.RemoteAddr = r.RemoteAddr
+		forwarded.TLS = r.TLS
+
+		h.ServeHTTP(w, forwarded)
+	})
+}
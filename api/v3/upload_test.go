@@ -0,0 +1,186 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/coreos/clair/api/v3/clairpb"
+)
+
+// failingReader returns its content and then fails.
+type failingReader struct {
+	io.Reader
+}
+
+func (r failingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+// useTempDir makes the temporary files be created in a new directory, which
+// is returned with a function restoring the previous one.
+func useTempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "clair-upload")
+	require.Nil(t, err)
+
+	previous, ok := os.LookupEnv("TMPDIR")
+	require.Nil(t, os.Setenv("TMPDIR", dir))
+	return dir, func() {
+		if ok {
+			os.Setenv("TMPDIR", previous)
+		} else {
+			os.Unsetenv("TMPDIR")
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+// assertEmptyDir asserts that the temporary files were all removed.
+func assertEmptyDir(t *testing.T, dir string, msgAndArgs ...interface{}) {
+	files, err := ioutil.ReadDir(dir)
+	require.Nil(t, err)
+	assert.Empty(t, files, msgAndArgs...)
+}
+
+func assertErrorCode(t *testing.T, w *httptest.ResponseRecorder, expected ErrorCode, msgAndArgs ...interface{}) {
+	var body httpErrorBody
+	if assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body), msgAndArgs...) {
+		assert.Equal(t, string(expected), body.ErrorCode, msgAndArgs...)
+	}
+}
+
+func TestUploadHandlerStreamsLayer(t *testing.T) {
+	dir, restore := useTempDir(t)
+	defer restore()
+
+	var (
+		forwarded *pb.PostLayersRequest
+		content   string
+	)
+	h := uploadHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/layers", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		forwarded = &pb.PostLayersRequest{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(forwarded))
+		require.Len(t, forwarded.Layers, 1)
+
+		// The layer is analyzed from the temporary file of its body.
+		b, err := ioutil.ReadFile(forwarded.Layers[0].Path)
+		require.Nil(t, err)
+		content = string(b)
+		w.WriteHeader(http.StatusCreated)
+	}), 1024)
+
+	for _, body := range []io.Reader{
+		strings.NewReader("layer content"),
+		// A body without length.
+		ioutil.NopCloser(strings.NewReader("layer content")),
+	} {
+		forwarded, content = nil, ""
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/layers/sha256:abc?format=Docker", body))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		if assert.NotNil(t, forwarded) {
+			assert.Equal(t, "Docker", forwarded.Format)
+			assert.Equal(t, "sha256:abc", forwarded.Layers[0].Hash)
+		}
+		assert.Equal(t, "layer content", content)
+
+		// The temporary file is removed once the request is done.
+		assertEmptyDir(t, dir)
+	}
+}
+
+func TestUploadHandlerRequestTooLarge(t *testing.T) {
+	dir, restore := useTempDir(t)
+	defer restore()
+
+	h := uploadHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected forwarded request %s %s", r.Method, r.URL)
+	}), 4)
+
+	for _, test := range []struct {
+		name   string
+		method string
+		path   string
+		body   io.Reader
+	}{
+		{"upload with length", http.MethodPut, "/layers/sha256:abc?format=Docker", strings.NewReader("layer content")},
+		{"upload without length", http.MethodPut, "/layers/sha256:abc?format=Docker", ioutil.NopCloser(strings.NewReader("layer content"))},
+		{"request with length", http.MethodPost, "/ancestry", strings.NewReader(`{"ancestry_name": "ancestry"}`)},
+		{"request without length", http.MethodPost, "/ancestry", ioutil.NopCloser(strings.NewReader(`{"ancestry_name": "ancestry"}`))},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(test.method, test.path, test.body))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, test.name)
+		assertErrorCode(t, w, ErrorCodeRequestTooLarge, test.name)
+		assertEmptyDir(t, dir, test.name)
+	}
+}
+
+func TestUploadHandlerErrors(t *testing.T) {
+	dir, restore := useTempDir(t)
+	defer restore()
+
+	forwarded := 0
+	h := uploadHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded++
+	}), 0)
+
+	// The body that cannot be read is rejected and its temporary file is
+	// removed.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/layers/sha256:abc?format=Docker", failingReader{strings.NewReader("layer")}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assertErrorCode(t, w, ErrorCodeInvalidArgument)
+	assertEmptyDir(t, dir)
+
+	// The upload without format is rejected before being read.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/layers/sha256:abc", strings.NewReader("layer")))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assertErrorCode(t, w, ErrorCodeInvalidArgument)
+	assertEmptyDir(t, dir)
+
+	// The other requests are forwarded as they are, without limit.
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/layers/sha256:abc", nil),
+		httptest.NewRequest(http.MethodPut, "/layers/", strings.NewReader("layer")),
+		httptest.NewRequest(http.MethodPost, "/layers", strings.NewReader("{}")),
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	assert.Equal(t, 3, forwarded)
+	assertEmptyDir(t, dir)
+}
@@ -1,12 +1,14 @@
 package v3
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/coreos/clair"
 	pb "github.com/coreos/clair/api/v3/clairpb"
 	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
 	"github.com/golang/protobuf/ptypes"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 // GetClairStatus retrieves the current status of Clair and wrap it inside
@@ -31,52 +33,245 @@ func GetClairStatus(store database.Datastore) (*pb.ClairStatus, error) {
 	return status, nil
 }
 
+// GetUpdaterStatus retrieves the current status of the updater and wrap it
+// inside protobuf struct.
+func GetUpdaterStatus(store database.Datastore) (*pb.UpdaterStatus, error) {
+	updaterStatus, err := clair.GetUpdaterStatus(store)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &pb.UpdaterStatus{
+		LastError:  updaterStatus.LastError,
+		InProgress: updaterStatus.InProgress,
+		LockHolder: updaterStatus.LockHolder,
+	}
+
+	switch {
+	case updaterStatus.InProgress:
+		status.Result = pb.UpdaterStatus_UPDATER_STATUS_RESULT_IN_PROGRESS
+	case !updaterStatus.HasRun:
+		status.Result = pb.UpdaterStatus_UPDATER_STATUS_RESULT_NEVER_RUN
+	case updaterStatus.LastError != "":
+		status.Result = pb.UpdaterStatus_UPDATER_STATUS_RESULT_FAILED
+	default:
+		status.Result = pb.UpdaterStatus_UPDATER_STATUS_RESULT_SUCCEEDED
+	}
+
+	if !updaterStatus.LastStart.IsZero() {
+		status.LastStartTime, err = ptypes.TimestampProto(updaterStatus.LastStart)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !updaterStatus.LastSuccess.IsZero() {
+		status.LastSuccessTime, err = ptypes.TimestampProto(updaterStatus.LastSuccess)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !updaterStatus.LockExpiration.IsZero() {
+		status.LockExpirationTime, err = ptypes.TimestampProto(updaterStatus.LockExpiration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+// newPbUpdateJob converts an update job into its protobuf struct.
+func newPbUpdateJob(job clair.UpdateJob) (*pb.UpdateJob, error) {
+	pbJob := &pb.UpdateJob{
+		Id:       job.ID,
+		Updaters: job.Updaters,
+		Error:    job.Error,
+	}
+
+	switch job.State {
+	case clair.UpdateJobPending:
+		pbJob.State = pb.UpdateJob_UPDATE_JOB_STATE_PENDING
+	case clair.UpdateJobRunning:
+		pbJob.State = pb.UpdateJob_UPDATE_JOB_STATE_RUNNING
+	case clair.UpdateJobSucceeded:
+		pbJob.State = pb.UpdateJob_UPDATE_JOB_STATE_SUCCEEDED
+	case clair.UpdateJobFailed:
+		pbJob.State = pb.UpdateJob_UPDATE_JOB_STATE_FAILED
+	}
+
+	var err error
+	if pbJob.CreatedTime, err = ptypes.TimestampProto(job.Created); err != nil {
+		return nil, err
+	}
+
+	if !job.Started.IsZero() {
+		if pbJob.StartTime, err = ptypes.TimestampProto(job.Started); err != nil {
+			return nil, err
+		}
+	}
+
+	if !job.Finished.IsZero() {
+		if pbJob.FinishTime, err = ptypes.TimestampProto(job.Finished); err != nil {
+			return nil, err
+		}
+	}
+
+	return pbJob, nil
+}
+
 // GetPbAncestryLayer retrieves an ancestry layer with vulnerabilities and
 // features in an ancestry based on the provided database layer.
-func GetPbAncestryLayer(tx database.Session, layer database.AncestryLayer) (*pb.GetAncestryResponse_AncestryLayer, error) {
-	pbLayer := &pb.GetAncestryResponse_AncestryLayer{
+//
+// The vulnerabilities suppressed by the allowlist are left out, unless
+// withSuppressed is true, in which case they are flagged as suppressed. The
+// vulnerabilities with any of the excluded tags are always left out, as are
+// those without an available fix when onlyFixable is true.
+func GetPbAncestryLayer(tx database.Session, layer database.AncestryLayer, withSuppressed, onlyFixable bool, excludedTags []string) (*pb.GetAncestryResponse_AncestryLayer, error) {
+	pbFeatures, err := getPbAncestryFeatures(tx, layer.Features, withSuppressed, onlyFixable, excludedTags)
+	if err != nil {
+		return nil, err
+	}
+	pb.SortFeatures(pbFeatures)
+
+	return &pb.GetAncestryResponse_AncestryLayer{
 		Layer: &pb.Layer{
 			Hash: layer.Hash,
 		},
+		DetectedFeatures: pbFeatures,
+	}, nil
+}
+
+// ancestryVulnerability is a vulnerability of a feature of an ancestry, with
+// whether it is suppressed by the allowlist.
+type ancestryVulnerability struct {
+	database.VulnerabilityWithFixedIn
+	suppressed bool
+}
+
+// getPbAncestryFeatures retrieves the vulnerabilities of the features of an
+// ancestry, filtered as by GetPbAncestryLayer.
+//
+// A feature under several candidate namespaces is returned once, under the
+// most likely one, with the vulnerabilities of all of them. A vulnerability
+// of several of these namespaces is only returned for the most likely one.
+func getPbAncestryFeatures(tx database.Session, features []database.AncestryFeature, withSuppressed, onlyFixable bool, excludedTags []string) ([]*pb.Feature, error) {
+	affectedBy, err := findAffectedBy(tx.FindAffectedNamespacedFeatures, features)
+	if err != nil {
+		return nil, err
 	}
 
-	features := layer.GetFeatures()
-	affectedFeatures, err := tx.FindAffectedNamespacedFeatures(features)
+	var pbFeatures []*pb.Feature
+	for _, group := range groupAncestryFeatures(features) {
+		pbFeature := pb.NamespacedFeatureFromDatabaseModel(group[0])
+		for _, vuln := range filterAncestryVulnerabilities(group, affectedBy, withSuppressed, onlyFixable, excludedTags) {
+			pbVuln, err := pb.VulnerabilityWithFixedInFromDatabaseModel(vuln.VulnerabilityWithFixedIn)
+			if err != nil {
+				return nil, clairError(err)
+			}
+			pbVuln.Suppressed = vuln.suppressed
+
+			pbFeature.Vulnerabilities = append(pbFeature.Vulnerabilities, pbVuln)
+		}
+		pb.SortVulnerabilities(pbFeature.Vulnerabilities)
+
+		pbFeatures = append(pbFeatures, pbFeature)
+	}
+
+	return pbFeatures, nil
+}
+
+// findAffectedBy retrieves the vulnerabilities affecting the features of an
+// ancestry with find, by feature.
+func findAffectedBy(find func([]database.NamespacedFeature) ([]database.NullableAffectedNamespacedFeature, error), features []database.AncestryFeature) (map[database.NamespacedFeature][]database.VulnerabilityWithFixedIn, error) {
+	namespacedFeatures := make([]database.NamespacedFeature, 0, len(features))
+	for _, f := range features {
+		namespacedFeatures = append(namespacedFeatures, f.NamespacedFeature)
+	}
+
+	affectedFeatures, err := find(namespacedFeatures)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, clairError(err)
 	}
 
-	// NOTE(sidac): It's quite inefficient, but the easiest way to implement
-	// this feature for now, we should refactor the implementation if there's
-	// any performance issue. It's expected that the number of features is less
-	// than 1000.
+	affectedBy := make(map[database.NamespacedFeature][]database.VulnerabilityWithFixedIn, len(affectedFeatures))
 	for _, feature := range affectedFeatures {
 		if !feature.Valid {
-			return nil, status.Error(codes.Internal, "ancestry feature is not found")
+			return nil, newError(ErrorCodeInternal, "ancestry feature is not found")
 		}
+		affectedBy[feature.NamespacedFeature] = feature.AffectedBy
+	}
 
-		for _, detectedFeature := range layer.Features {
-			if detectedFeature.NamespacedFeature != feature.NamespacedFeature {
+	return affectedBy, nil
+}
+
+// filterAncestryVulnerabilities returns the vulnerabilities of a group of
+// candidate features of an ancestry, filtered as by GetPbAncestryLayer, and
+// only once for the most likely candidate when several of them have it.
+func filterAncestryVulnerabilities(group []database.AncestryFeature, affectedBy map[database.NamespacedFeature][]database.VulnerabilityWithFixedIn, withSuppressed, onlyFixable bool, excludedTags []string) []ancestryVulnerability {
+	var vulns []ancestryVulnerability
+	matched := make(map[string]bool)
+	for _, detectedFeature := range group {
+		for _, vuln := range affectedBy[detectedFeature.NamespacedFeature] {
+			suppressed := clair.IsAllowlisted(vuln.Name, vuln.Namespace.Name, detectedFeature.Feature.Name)
+			if suppressed && !withSuppressed || isExcludedTag(vuln.Tag, excludedTags) || onlyFixable && vuln.FixedInVersion == "" || matched[vuln.Name] {
 				continue
 			}
+			matched[vuln.Name] = true
 
-			var (
-				pbFeature = pb.NamespacedFeatureFromDatabaseModel(detectedFeature)
-				pbVuln    *pb.Vulnerability
-				err       error
-			)
+			vulns = append(vulns, ancestryVulnerability{vuln, suppressed})
+		}
+	}
 
-			for _, vuln := range feature.AffectedBy {
-				if pbVuln, err = pb.VulnerabilityWithFixedInFromDatabaseModel(vuln); err != nil {
-					return nil, status.Error(codes.Internal, err.Error())
-				}
+	return vulns
+}
 
-				pbFeature.Vulnerabilities = append(pbFeature.Vulnerabilities, pbVuln)
-			}
+// groupAncestryFeatures groups the features of an ancestry layer by feature,
+// in the order of their first occurrence, and sorts each group from the most
+// likely candidate namespace of the feature.
+func groupAncestryFeatures(features []database.AncestryFeature) [][]database.AncestryFeature {
+	type groupKey struct {
+		feature database.Feature
+		by      database.Detector
+	}
 
-			pbLayer.DetectedFeatures = append(pbLayer.DetectedFeatures, pbFeature)
+	var groups [][]database.AncestryFeature
+	indexes := make(map[groupKey]int)
+	for _, f := range features {
+		key := groupKey{f.Feature, f.FeatureBy}
+		if i, ok := indexes[key]; ok {
+			groups[i] = append(groups[i], f)
+			continue
 		}
+
+		indexes[key] = len(groups)
+		groups = append(groups, []database.AncestryFeature{f})
 	}
 
-	return pbLayer, nil
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool {
+			return featurens.MoreLikely(
+				database.LayerNamespace{Namespace: group[i].Namespace, By: group[i].NamespaceBy},
+				database.LayerNamespace{Namespace: group[j].Namespace, By: group[j].NamespaceBy},
+			)
+		})
+	}
+
+	return groups
+}
+
+// isExcludedTag returns whether a vulnerability tag is among the excluded
+// tags. Untagged vulnerabilities are never excluded.
+func isExcludedTag(tag string, excludedTags []string) bool {
+	if tag == "" {
+		return false
+	}
+
+	for _, excluded := range excludedTags {
+		if strings.EqualFold(tag, excluded) {
+			return true
+		}
+	}
+	return false
 }
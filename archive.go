@@ -0,0 +1,550 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/pkg/pagination"
+	"github.com/coreos/clair/pkg/version"
+)
+
+const (
+	// ArchiveFormat identifies the archives of vulnerabilities.
+	ArchiveFormat = "clair-vulnerabilities"
+
+	// ArchiveVersion is the version of the layout of the archives of
+	// vulnerabilities written by ExportVulnerabilities, which imports the
+	// archives of this version only.
+	ArchiveVersion = 1
+
+	archiveManifestName        = "manifest.json"
+	archiveVulnerabilitiesName = "vulnerabilities.json"
+
+	// archiveNamespacePageLimit is the number of namespaces read at once when
+	// exporting their vulnerabilities.
+	archiveNamespacePageLimit = 100
+)
+
+// ErrInvalidArchive is returned by ImportVulnerabilities when the archive is
+// not a valid archive of vulnerabilities, in which case nothing is imported.
+var ErrInvalidArchive = errors.New("invalid archive of vulnerabilities")
+
+// archiveManifest describes the content of an archive of vulnerabilities.
+type archiveManifest struct {
+	Format       string    `json:"format"`
+	Version      int       `json:"version"`
+	Created      time.Time `json:"created"`
+	ClairVersion string    `json:"clair_version,omitempty"`
+	// LastUpdate is the time of the last successful update of the exported
+	// vulnerabilities, if any.
+	LastUpdate time.Time          `json:"last_update"`
+	Namespaces []archiveNamespace `json:"namespaces"`
+	// SHA256 is the digest of the vulnerabilities of the archive.
+	SHA256 string `json:"sha256"`
+}
+
+// archiveNamespace is a namespace whose vulnerabilities are in an archive.
+type archiveNamespace struct {
+	Name            string `json:"name"`
+	VersionFormat   string `json:"version_format"`
+	Vulnerabilities int    `json:"vulnerabilities"`
+}
+
+// archiveVulnerability is a vulnerability of an archive, which is a line of
+// JSON.
+type archiveVulnerability struct {
+	Name        string               `json:"name"`
+	Namespace   string               `json:"namespace"`
+	Description string               `json:"description,omitempty"`
+	Link        string               `json:"link,omitempty"`
+	Severity    database.Severity    `json:"severity"`
+	Metadata    database.MetadataMap `json:"metadata,omitempty"`
+	Affected    []archiveAffected    `json:"affected"`
+}
+
+// archiveAffected is a feature affected by a vulnerability of an archive.
+type archiveAffected struct {
+	Type                database.AffectedFeatureType `json:"type"`
+	Namespace           string                       `json:"namespace"`
+	FeatureName         string                       `json:"feature_name"`
+	AffectedVersion     string                       `json:"affected_version"`
+	FixedInVersion      string                       `json:"fixed_in_version,omitempty"`
+	IntroducedInVersion string                       `json:"introduced_in_version,omitempty"`
+	LastAffectedVersion string                       `json:"last_affected_version,omitempty"`
+	Tag                 string                       `json:"tag,omitempty"`
+}
+
+func newArchiveVulnerability(vuln database.VulnerabilityWithAffected) archiveVulnerability {
+	v := archiveVulnerability{
+		Name:        vuln.Name,
+		Namespace:   vuln.Namespace.Name,
+		Description: vuln.Description,
+		Link:        vuln.Link,
+		Severity:    vuln.Severity,
+		Metadata:    vuln.Metadata,
+		Affected:    make([]archiveAffected, 0, len(vuln.Affected)),
+	}
+
+	for _, affected := range vuln.Affected {
+		v.Affected = append(v.Affected, archiveAffected{
+			Type:                affected.AffectedType,
+			Namespace:           affected.Namespace.Name,
+			FeatureName:         affected.FeatureName,
+			AffectedVersion:     affected.AffectedVersion,
+			FixedInVersion:      affected.FixedInVersion,
+			IntroducedInVersion: affected.IntroducedInVersion,
+			LastAffectedVersion: affected.LastAffectedVersion,
+			Tag:                 affected.Tag,
+		})
+	}
+
+	return v
+}
+
+// databaseModel converts the vulnerability to its database model, whose
+// namespaces have the version formats of the manifest.
+func (v archiveVulnerability) databaseModel(namespaces map[string]archiveNamespace) (database.VulnerabilityWithAffected, error) {
+	namespace, ok := namespaces[v.Namespace]
+	if !ok {
+		return database.VulnerabilityWithAffected{}, fmt.Errorf("vulnerability %s of unknown namespace %q", v.Name, v.Namespace)
+	}
+
+	if v.Name == "" {
+		return database.VulnerabilityWithAffected{}, fmt.Errorf("vulnerability of %s without name", v.Namespace)
+	}
+
+	if !v.Severity.Valid() {
+		return database.VulnerabilityWithAffected{}, fmt.Errorf("vulnerability %s of invalid severity %q", v.Name, v.Severity)
+	}
+
+	vuln := database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:        v.Name,
+			Namespace:   database.Namespace{Name: namespace.Name, VersionFormat: namespace.VersionFormat},
+			Description: v.Description,
+			Link:        v.Link,
+			Severity:    v.Severity,
+			Metadata:    v.Metadata,
+		},
+	}
+
+	for _, affected := range v.Affected {
+		affectedNamespace, ok := namespaces[affected.Namespace]
+		if !ok {
+			return database.VulnerabilityWithAffected{}, fmt.Errorf("vulnerability %s affecting %s of unknown namespace %q", v.Name, affected.FeatureName, affected.Namespace)
+		}
+
+		if affected.FeatureName == "" || affected.AffectedVersion == "" {
+			return database.VulnerabilityWithAffected{}, fmt.Errorf("vulnerability %s affecting a feature without name or version", v.Name)
+		}
+
+		vuln.Affected = append(vuln.Affected, database.AffectedFeature{
+			AffectedType:        affected.Type,
+			Namespace:           database.Namespace{Name: affectedNamespace.Name, VersionFormat: affectedNamespace.VersionFormat},
+			FeatureName:         affected.FeatureName,
+			AffectedVersion:     affected.AffectedVersion,
+			FixedInVersion:      affected.FixedInVersion,
+			IntroducedInVersion: affected.IntroducedInVersion,
+			LastAffectedVersion: affected.LastAffectedVersion,
+			Tag:                 affected.Tag,
+		})
+	}
+
+	return vuln, nil
+}
+
+// ExportVulnerabilities writes the vulnerabilities of every namespace of the
+// database, with their metadata, to w as a gzipped tar archive, which
+// ImportVulnerabilities loads into another database, e.g. of an instance
+// without access to the data sources.
+//
+// The archive has a manifest.json, describing its namespaces and the digest
+// of its vulnerabilities, followed by a vulnerabilities.json made of a line of
+// JSON per vulnerability, grouped by namespace. The withdrawn vulnerabilities
+// are not exported.
+func ExportVulnerabilities(datastore database.Datastore, w io.Writer) error {
+	// The last update is read before the vulnerabilities' session begins, as
+	// GetLastUpdateTime begins its own.
+	lastUpdate, firstUpdate, err := GetLastUpdateTime(datastore)
+	if err != nil {
+		return err
+	}
+
+	manifest := archiveManifest{
+		Format:       ArchiveFormat,
+		Version:      ArchiveVersion,
+		Created:      time.Now().UTC(),
+		ClairVersion: version.Version,
+		Namespaces:   []archiveNamespace{},
+	}
+	if !firstUpdate {
+		manifest.LastUpdate = lastUpdate
+	}
+
+	// The vulnerabilities are written to a temporary file first, since the
+	// manifest preceding them gives their digest.
+	f, err := ioutil.TempFile("", "clair-export")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	sha := sha256.New()
+	buffered := bufio.NewWriter(io.MultiWriter(f, sha))
+	encoder := json.NewEncoder(buffered)
+
+	tx, err := datastore.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	for page := pagination.Token(""); ; {
+		namespaces, nextPage, err := tx.FindNamespaces("", archiveNamespacePageLimit, page)
+		if err != nil {
+			return err
+		}
+
+		for _, namespace := range namespaces {
+			exported := archiveNamespace{Name: namespace.Name, VersionFormat: namespace.VersionFormat}
+			err := tx.WalkVulnerabilities(namespace.Name, time.Time{}, func(vuln database.VulnerabilityWithAffected) error {
+				exported.Vulnerabilities++
+				return encoder.Encode(newArchiveVulnerability(vuln))
+			})
+			if err != nil {
+				return err
+			}
+
+			if exported.Vulnerabilities > 0 {
+				manifest.Namespaces = append(manifest.Namespaces, exported)
+				count += exported.Vulnerabilities
+			}
+		}
+
+		if nextPage == "" {
+			break
+		}
+		page = nextPage
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	manifest.SHA256 = hex.EncodeToString(sha.Sum(nil))
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	encodedManifest, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	if err := writeArchiveFile(tw, archiveManifestName, int64(len(encodedManifest)), manifest.Created, bytes.NewReader(encodedManifest)); err != nil {
+		return err
+	}
+	if err := writeArchiveFile(tw, archiveVulnerabilitiesName, size, manifest.Created, f); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"namespaces": len(manifest.Namespaces), "vulnerabilities": count}).Info("exported vulnerabilities")
+	return nil
+}
+
+// writeArchiveFile writes a regular file of the given size to the archive.
+func writeArchiveFile(tw *tar.Writer, name string, size int64, modTime time.Time, r io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+
+	_, err := io.CopyN(tw, r, size)
+	return err
+}
+
+// ImportVulnerabilities loads an archive written by ExportVulnerabilities into
+// the database, as an update of the namespaces of the archive would.
+//
+// The archive is validated as a whole before anything is imported. Each of
+// its namespaces then has the vulnerabilities of the archive only: the stored
+// ones that it does not have are withdrawn, the changes are notified, and the
+// namespaces absent from the archive are left untouched. Importing the same
+// archive again changes nothing.
+//
+// The import holds the lock of the updater, so that it does not run at the
+// same time as an update.
+func ImportVulnerabilities(datastore database.Datastore, r io.Reader) error {
+	f, err := ioutil.TempFile("", "clair-import")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	manifest, err := readArchive(r, f)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	whoAmI := updaterLockOwner()
+	if locked, _ := lock(datastore, updaterLockName, whoAmI, updaterLockDuration, false); !locked {
+		return errors.New("could not import vulnerabilities: the updater lock is taken, an update is in progress")
+	}
+	defer unlock(datastore, updaterLockName, whoAmI)
+
+	_, firstUpdate, err := GetLastUpdateTime(datastore)
+	if err != nil {
+		return err
+	}
+
+	namespaces := make(map[string]archiveNamespace, len(manifest.Namespaces))
+	for _, namespace := range manifest.Namespaces {
+		namespaces[namespace.Name] = namespace
+	}
+
+	// The archive groups the vulnerabilities by namespace, which are imported
+	// one at a time.
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	lastRenewal := time.Now()
+	for _, namespace := range manifest.Namespaces {
+		vulnerabilities := make([]database.VulnerabilityWithAffected, 0, namespace.Vulnerabilities)
+		for i := 0; i < namespace.Vulnerabilities; i++ {
+			var v archiveVulnerability
+			if err := decoder.Decode(&v); err != nil {
+				return err
+			}
+
+			vuln, err := v.databaseModel(namespaces)
+			if err != nil {
+				return err
+			}
+			vulnerabilities = append(vulnerabilities, vuln)
+		}
+
+		if err := persistUpdate(datastore, vulnerabilities, vulnsrc.UpdateResponse{Complete: true}, firstUpdate); err != nil {
+			return fmt.Errorf("could not import the vulnerabilities of %s: %s", namespace.Name, err)
+		}
+
+		if err := updateImportedMetadata(datastore, vulnerabilities); err != nil {
+			return fmt.Errorf("could not import the vulnerability metadata of %s: %s", namespace.Name, err)
+		}
+
+		log.WithFields(log.Fields{"namespace": namespace.Name, "vulnerabilities": namespace.Vulnerabilities}).Info("imported vulnerabilities")
+
+		if time.Since(lastRenewal) > updaterLockRefreshDuration {
+			lock(datastore, updaterLockName, whoAmI, updaterLockDuration, true)
+			lastRenewal = time.Now()
+		}
+	}
+
+	if !manifest.LastUpdate.IsZero() {
+		if err := setImportedLastUpdateTime(datastore, manifest.LastUpdate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readArchive validates the archive read from r, copying its vulnerabilities
+// to f, and returns its manifest.
+func readArchive(r io.Reader, f io.Writer) (archiveManifest, error) {
+	var manifest archiveManifest
+
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return manifest, fmt.Errorf("%s: %s", ErrInvalidArchive, err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+
+	hdr, err := tr.Next()
+	if err != nil {
+		return manifest, fmt.Errorf("%s: %s", ErrInvalidArchive, err)
+	} else if hdr.Name != archiveManifestName {
+		return manifest, fmt.Errorf("%s: the archive does not start with %s", ErrInvalidArchive, archiveManifestName)
+	}
+
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("%s: could not decode %s: %s", ErrInvalidArchive, archiveManifestName, err)
+	}
+
+	if manifest.Format != ArchiveFormat {
+		return manifest, fmt.Errorf("%s: unknown format %q", ErrInvalidArchive, manifest.Format)
+	} else if manifest.Version != ArchiveVersion {
+		return manifest, fmt.Errorf("%s: unsupported version %d, expected %d", ErrInvalidArchive, manifest.Version, ArchiveVersion)
+	}
+
+	namespaces := make(map[string]archiveNamespace, len(manifest.Namespaces))
+	for _, namespace := range manifest.Namespaces {
+		if _, ok := namespaces[namespace.Name]; ok || namespace.Name == "" || namespace.VersionFormat == "" || namespace.Vulnerabilities < 1 {
+			return manifest, fmt.Errorf("%s: invalid namespace %q", ErrInvalidArchive, namespace.Name)
+		}
+		namespaces[namespace.Name] = namespace
+	}
+
+	hdr, err = tr.Next()
+	if err != nil {
+		return manifest, fmt.Errorf("%s: %s", ErrInvalidArchive, err)
+	} else if hdr.Name != archiveVulnerabilitiesName {
+		return manifest, fmt.Errorf("%s: %s does not follow %s", ErrInvalidArchive, archiveVulnerabilitiesName, archiveManifestName)
+	}
+
+	// Every vulnerability is decoded, so that an archive whose digest matches
+	// but which was written by a broken exporter is not partially imported.
+	sha := sha256.New()
+	decoder := json.NewDecoder(io.TeeReader(tr, io.MultiWriter(f, sha)))
+	for _, namespace := range manifest.Namespaces {
+		for i := 0; i < namespace.Vulnerabilities; i++ {
+			var v archiveVulnerability
+			if err := decoder.Decode(&v); err != nil {
+				return manifest, fmt.Errorf("%s: could not decode vulnerability %d of %s: %s", ErrInvalidArchive, i+1, namespace.Name, err)
+			}
+
+			if v.Namespace != namespace.Name {
+				return manifest, fmt.Errorf("%s: vulnerability %s of %s is not grouped with its namespace", ErrInvalidArchive, v.Name, v.Namespace)
+			}
+
+			if _, err := v.databaseModel(namespaces); err != nil {
+				return manifest, fmt.Errorf("%s: %s", ErrInvalidArchive, err)
+			}
+		}
+	}
+
+	// Reaching the end of the file also reads the rest of it into the digest.
+	if _, err := decoder.Token(); err != io.EOF {
+		return manifest, fmt.Errorf("%s: %s has more vulnerabilities than its manifest", ErrInvalidArchive, archiveVulnerabilitiesName)
+	}
+
+	if digest := hex.EncodeToString(sha.Sum(nil)); digest != manifest.SHA256 {
+		return manifest, fmt.Errorf("%s: digest of %s is %s, expected %s", ErrInvalidArchive, archiveVulnerabilitiesName, digest, manifest.SHA256)
+	}
+
+	return manifest, nil
+}
+
+// updateImportedMetadata updates the metadata of the stored vulnerabilities
+// that did not change otherwise, and were thus not replaced on import.
+func updateImportedMetadata(datastore database.Datastore, vulnerabilities []database.VulnerabilityWithAffected) error {
+	ids := make([]database.VulnerabilityID, 0, len(vulnerabilities))
+	for _, vuln := range vulnerabilities {
+		ids = append(ids, database.VulnerabilityID{Name: vuln.Name, Namespace: vuln.Namespace.Name})
+	}
+
+	tx, err := datastore.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stored, err := tx.FindVulnerabilities(ids)
+	if err != nil {
+		return err
+	}
+
+	var updated int
+	for i, vuln := range vulnerabilities {
+		if !stored[i].Valid {
+			continue
+		}
+
+		for key, metadata := range vuln.Metadata {
+			if equalMetadata(stored[i].Metadata[key], metadata) {
+				continue
+			}
+
+			if err := tx.UpdateVulnerabilityMetadata(vuln.Name, key, metadata); err != nil {
+				return err
+			}
+			updated++
+		}
+	}
+
+	if updated == 0 {
+		return nil
+	}
+	return tx.Commit()
+}
+
+// equalMetadata returns whether two metadata have the same JSON encoding, as
+// the ones read from the database and from an archive differ in type.
+func equalMetadata(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+// setImportedLastUpdateTime records the time of the last update of the
+// imported vulnerabilities as the last successful update, unless a later
+// update already succeeded, so that the freshness of the vulnerabilities is
+// the one of the exporting instance.
+func setImportedLastUpdateTime(datastore database.Datastore, lastUpdate time.Time) error {
+	current, firstUpdate, err := GetLastUpdateTime(datastore)
+	if err != nil {
+		return err
+	}
+
+	if !firstUpdate && !lastUpdate.After(current) {
+		return nil
+	}
+
+	return updateUpdaterFlags(datastore, map[string]string{
+		updaterLastFlagName: strconv.FormatInt(lastUpdate.UTC().Unix(), 10),
+	})
+}
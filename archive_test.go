@@ -0,0 +1,210 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt/dpkg"
+	"github.com/coreos/clair/ext/vulnsrc"
+)
+
+func archiveTestVulnerability(name string, ns database.Namespace, severity database.Severity) database.VulnerabilityWithAffected {
+	return database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:      name,
+			Namespace: ns,
+			Link:      "https://example.com/" + name,
+			Severity:  severity,
+			Metadata:  database.MetadataMap{"NVD": map[string]interface{}{"score": 7.5}},
+		},
+		Affected: []database.AffectedFeature{{
+			AffectedType:    database.AffectSourcePackage,
+			Namespace:       ns,
+			FeatureName:     "openssl",
+			AffectedVersion: "1.1.0f-3",
+			FixedInVersion:  "1.1.0f-3",
+			Tag:             "no-dsa",
+		}},
+	}
+}
+
+func findArchiveTestVulnerabilities(t *testing.T, datastore database.Datastore, ids ...database.VulnerabilityID) []database.NullableVulnerability {
+	tx, err := datastore.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	vulns, err := tx.FindVulnerabilities(ids)
+	require.Nil(t, err)
+	return vulns
+}
+
+func TestExportImportVulnerabilities(t *testing.T) {
+	debian := database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+	ubuntu := database.Namespace{Name: "ubuntu:18.04", VersionFormat: dpkg.ParserName}
+
+	source, err := database.Open(database.RegistrableComponentConfig{Type: "mem"})
+	require.Nil(t, err)
+	defer source.Close()
+
+	require.Nil(t, persistUpdate(source, []database.VulnerabilityWithAffected{
+		archiveTestVulnerability("CVE-1", debian, database.HighSeverity),
+		archiveTestVulnerability("CVE-2", debian, database.LowSeverity),
+	}, vulnsrc.UpdateResponse{Complete: true}, true))
+	require.Nil(t, persistUpdate(source, []database.VulnerabilityWithAffected{
+		archiveTestVulnerability("CVE-3", ubuntu, database.MediumSeverity),
+	}, vulnsrc.UpdateResponse{Complete: true}, true))
+	require.Nil(t, setLastUpdateTime(source))
+	lastUpdate, _, err := GetLastUpdateTime(source)
+	require.Nil(t, err)
+
+	var archive bytes.Buffer
+	require.Nil(t, ExportVulnerabilities(source, &archive))
+
+	// The imported vulnerabilities replace the ones of their namespaces, and
+	// the freshness is the one of the exporting instance.
+	target, err := database.Open(database.RegistrableComponentConfig{Type: "mem"})
+	require.Nil(t, err)
+	defer target.Close()
+
+	stale := archiveTestVulnerability("CVE-0", debian, database.HighSeverity)
+	stale.Metadata = nil
+	outdated := archiveTestVulnerability("CVE-1", debian, database.HighSeverity)
+	outdated.Metadata = database.MetadataMap{"NVD": map[string]interface{}{"score": 5.0}}
+	require.Nil(t, persistUpdate(target, []database.VulnerabilityWithAffected{stale, outdated}, vulnsrc.UpdateResponse{Complete: true}, true))
+
+	require.Nil(t, ImportVulnerabilities(target, bytes.NewReader(archive.Bytes())))
+
+	vulns := findArchiveTestVulnerabilities(t, target,
+		database.VulnerabilityID{Name: "CVE-0", Namespace: debian.Name},
+		database.VulnerabilityID{Name: "CVE-1", Namespace: debian.Name},
+		database.VulnerabilityID{Name: "CVE-2", Namespace: debian.Name},
+		database.VulnerabilityID{Name: "CVE-3", Namespace: ubuntu.Name},
+	)
+	assert.False(t, vulns[0].Valid)
+	for i, expected := range []database.VulnerabilityWithAffected{
+		archiveTestVulnerability("CVE-1", debian, database.HighSeverity),
+		archiveTestVulnerability("CVE-2", debian, database.LowSeverity),
+		archiveTestVulnerability("CVE-3", ubuntu, database.MediumSeverity),
+	} {
+		if assert.True(t, vulns[i+1].Valid, expected.Name) {
+			// The memory store does not keep the type of the affected features.
+			expected.Affected[0].AffectedType = ""
+			assert.Equal(t, expected.Severity, vulns[i+1].Severity)
+			assert.Equal(t, expected.Affected, vulns[i+1].Affected)
+			assert.True(t, equalMetadata(expected.Metadata, vulns[i+1].Metadata), expected.Name)
+		}
+	}
+
+	imported, firstUpdate, err := GetLastUpdateTime(target)
+	if assert.Nil(t, err) {
+		assert.False(t, firstUpdate)
+		assert.Equal(t, lastUpdate, imported)
+	}
+
+	// Importing the archive again changes nothing.
+	require.Nil(t, ImportVulnerabilities(target, bytes.NewReader(archive.Bytes())))
+	assert.Equal(t, vulns, findArchiveTestVulnerabilities(t, target,
+		database.VulnerabilityID{Name: "CVE-0", Namespace: debian.Name},
+		database.VulnerabilityID{Name: "CVE-1", Namespace: debian.Name},
+		database.VulnerabilityID{Name: "CVE-2", Namespace: debian.Name},
+		database.VulnerabilityID{Name: "CVE-3", Namespace: ubuntu.Name},
+	))
+}
+
+// rewriteArchive returns a copy of the archive whose files are changed by
+// rewrite.
+func rewriteArchive(t *testing.T, archive []byte, rewrite func(name string, content []byte) []byte) []byte {
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	require.Nil(t, err)
+	tr := tar.NewReader(gr)
+
+	var rewritten bytes.Buffer
+	gw := gzip.NewWriter(&rewritten)
+	tw := tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		require.Nil(t, err)
+		content = rewrite(hdr.Name, content)
+
+		require.Nil(t, writeArchiveFile(tw, hdr.Name, int64(len(content)), time.Now(), bytes.NewReader(content)))
+	}
+	require.Nil(t, tw.Close())
+	require.Nil(t, gw.Close())
+
+	return rewritten.Bytes()
+}
+
+func TestImportInvalidArchive(t *testing.T) {
+	ns := database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+
+	source, err := database.Open(database.RegistrableComponentConfig{Type: "mem"})
+	require.Nil(t, err)
+	defer source.Close()
+
+	require.Nil(t, persistUpdate(source, []database.VulnerabilityWithAffected{
+		archiveTestVulnerability("CVE-1", ns, database.HighSeverity),
+	}, vulnsrc.UpdateResponse{Complete: true}, true))
+
+	var archive bytes.Buffer
+	require.Nil(t, ExportVulnerabilities(source, &archive))
+
+	for name, invalid := range map[string][]byte{
+		"not gzipped": []byte("vulnerabilities"),
+		"unsupported version": rewriteArchive(t, archive.Bytes(), func(name string, content []byte) []byte {
+			return bytes.Replace(content, []byte(`"version": 1`), []byte(`"version": 2`), 1)
+		}),
+		"tampered": rewriteArchive(t, archive.Bytes(), func(name string, content []byte) []byte {
+			return bytes.Replace(content, []byte("High"), []byte("Low"), 1)
+		}),
+		"unknown namespace": rewriteArchive(t, archive.Bytes(), func(name string, content []byte) []byte {
+			return bytes.Replace(content, []byte("debian:9"), []byte("debian:10"), 1)
+		}),
+		"truncated": rewriteArchive(t, archive.Bytes(), func(name string, content []byte) []byte {
+			if name == archiveVulnerabilitiesName {
+				return content[:len(content)/2]
+			}
+			return content
+		}),
+	} {
+		target, err := database.Open(database.RegistrableComponentConfig{Type: "mem"})
+		require.Nil(t, err)
+
+		err = ImportVulnerabilities(target, bytes.NewReader(invalid))
+		if assert.NotNil(t, err, name) {
+			assert.True(t, strings.HasPrefix(err.Error(), ErrInvalidArchive.Error()), name)
+		}
+
+		// Nothing is imported from an invalid archive.
+		vulns := findArchiveTestVulnerabilities(t, target, database.VulnerabilityID{Name: "CVE-1", Namespace: ns.Name})
+		assert.False(t, vulns[0].Valid, name)
+		target.Close()
+	}
+}
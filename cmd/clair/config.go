@@ -15,38 +15,78 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/BurntSushi/toml"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 
 	"github.com/coreos/clair"
 	"github.com/coreos/clair/api"
 	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt"
 	"github.com/coreos/clair/ext/notification"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/vulnmdsrc"
 	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/pkg/httputil"
 	"github.com/coreos/clair/pkg/pagination"
+	"github.com/coreos/clair/pkg/strutil"
+	"github.com/coreos/clair/pkg/tarutil"
 )
 
 // ErrDatasourceNotLoaded is returned when the datasource variable in the
 // configuration file is not loaded properly
 var ErrDatasourceNotLoaded = errors.New("could not load configuration: no database source specified")
 
-// File represents a YAML configuration file that namespaces all Clair
-// configuration under the top-level "clair" key.
+// File represents a YAML, JSON or TOML configuration file that namespaces all
+// Clair configuration under the top-level "clair" key.
 type File struct {
 	Clair Config `yaml:"clair"`
 }
 
 // Config is the global configuration for an instance of Clair.
 type Config struct {
-	Database database.RegistrableComponentConfig
-	Updater  *clair.UpdaterConfig
-	Notifier *notification.Config
-	API      *api.Config
+	Database database.RegistrableComponentConfig `yaml:"database"`
+	Updater  *clair.UpdaterConfig                `yaml:"updater"`
+	Worker   *clair.WorkerConfig                 `yaml:"worker"`
+	Notifier *notification.Config                `yaml:"notifier"`
+	API      *api.Config                         `yaml:"api"`
+	Log      *LogConfig                          `yaml:"log"`
+
+	// Allowlist lists the accepted vulnerabilities, which are suppressed from
+	// the API results and the notifications. It is reloaded on SIGHUP.
+	Allowlist []clair.AllowlistEntry `yaml:"allowlist"`
+
+	// VersionFormats are the formats used to compare the versions of the
+	// features of the namespaces instead of the ones detected with them, by
+	// namespace name, e.g. "alpine:v3.18", or by distribution name, e.g.
+	// "alpine". The detected formats are used when it is empty.
+	VersionFormats map[string]string `yaml:"versionformats"`
+
+	// ShutdownTimeout is how long Clair waits, once it is asked to stop, for
+	// the in-flight API requests and the current update to finish.
+	ShutdownTimeout time.Duration `yaml:"shutdowntimeout"`
+}
+
+// LogConfig is the configuration of the logs of Clair.
+type LogConfig struct {
+	// Format is either "json", which logs an object per line, or "text".
+	Format string `yaml:"format"`
+
+	// Level is the minimum level of the logged entries: debug, info, warn or
+	// error.
+	Level string `yaml:"level"`
 }
 
 // DefaultConfig is a configuration that can be used as a fallback value.
@@ -56,57 +96,973 @@ func DefaultConfig() Config {
 			Type: "pgsql",
 		},
 		Updater: &clair.UpdaterConfig{
-			EnabledUpdaters: vulnsrc.ListUpdaters(),
+			EnabledUpdaters: vulnsrc.ListDefaultUpdaters(),
 			Interval:        1 * time.Hour,
+			LockDuration:    clair.DefaultUpdaterLockDuration,
+			HTTP: httputil.ClientConfig{
+				Timeout:  10 * time.Minute,
+				Attempts: 3,
+			},
+		},
+		Worker: &clair.WorkerConfig{
+			ListerConcurrency:      4,
+			MaxExtractableFileSize: tarutil.MaxExtractableFileSize,
+			MaxExtractedSize:       tarutil.MaxExtractedSize,
+			MaxLayers:              1000,
+			FilesCacheSize:         10 << 30,
 		},
 		API: &api.Config{
 			HealthAddr: "0.0.0.0:6061",
 			Addr:       "0.0.0.0:6060",
 			Timeout:    900 * time.Second,
+			ClientAuth: api.ClientAuthRequire,
+
+			MinTLSVersion: api.DefaultMinTLSVersion,
+
+			PaginationTTL:       pagination.DefaultTokenTTL,
+			PaginationClockSkew: pagination.DefaultClockSkew,
 		},
 		Notifier: &notification.Config{
 			Attempts:         5,
 			RenotifyInterval: 2 * time.Hour,
 		},
+		Log: &LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		ShutdownTimeout: time.Minute,
+	}
+}
+
+// unmarshalConfig decodes the content of a configuration file according to
+// its extension. Unknown extensions are decoded as YAML.
+//
+// JSON and TOML documents are first decoded into generic values and then
+// re-encoded as YAML, so that every format shares the YAML decoding rules
+// (e.g. durations such as "2h" and inlined notifier parameters).
+func unmarshalConfig(path string, d []byte, cfgFile *File) error {
+	var generic map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.Unmarshal(d, &generic); err != nil {
+			return err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(d, &generic); err != nil {
+			return err
+		}
+	default:
+		return yaml.Unmarshal(d, cfgFile)
+	}
+
+	d, err := yaml.Marshal(generic)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(d, cfgFile)
+}
+
+// Environment variables that override the values of a loaded configuration.
+const (
+	EnvDatabaseType           = "CLAIR_DATABASE_TYPE"
+	EnvDatabaseSource         = "CLAIR_DATABASE_SOURCE"
+	EnvDatabaseSourceFile     = "CLAIR_DATABASE_SOURCEFILE"
+	EnvDatabasePasswordFile   = "CLAIR_DATABASE_PASSWORDFILE"
+	EnvDatabaseCacheSize      = "CLAIR_DATABASE_CACHESIZE"
+	EnvDatabasePaginationKey  = "CLAIR_DATABASE_PAGINATIONKEY"
+	EnvDatabasePaginationKeys = "CLAIR_DATABASE_PAGINATIONKEYS"
+	EnvAPIPort                = "CLAIR_API_PORT"
+	EnvAPIHealthPort          = "CLAIR_API_HEALTHPORT"
+	EnvAPITimeout             = "CLAIR_API_TIMEOUT"
+	EnvAPICertFile            = "CLAIR_API_CERTFILE"
+	EnvAPIKeyFile             = "CLAIR_API_KEYFILE"
+	EnvAPICAFile              = "CLAIR_API_CAFILE"
+	EnvAPIClientAuth          = "CLAIR_API_CLIENTAUTH"
+	EnvAPIMinTLSVersion       = "CLAIR_API_MINTLSVERSION"
+	EnvAPICipherSuites        = "CLAIR_API_CIPHERSUITES"
+	EnvAPIUpdaterToken        = "CLAIR_API_UPDATERTOKEN"
+	EnvAPIRateLimitAnalyses   = "CLAIR_API_RATELIMIT_ANALYSES"
+	EnvAPIRateLimitReads      = "CLAIR_API_RATELIMIT_READS"
+	EnvAPIMaxAnalyses         = "CLAIR_API_MAXCONCURRENTANALYSES"
+	EnvAPIMaxQueuedAnalyses   = "CLAIR_API_MAXQUEUEDANALYSES"
+	EnvAPIMaxRequestSize      = "CLAIR_API_MAXREQUESTSIZE"
+	EnvAPIPaginationTTL       = "CLAIR_API_PAGINATIONTTL"
+	EnvAPIPaginationClockSkew = "CLAIR_API_PAGINATIONCLOCKSKEW"
+	EnvUpdaterInterval        = "CLAIR_UPDATER_INTERVAL"
+	EnvUpdaterCron            = "CLAIR_UPDATER_CRON"
+	EnvUpdaterDisabled        = "CLAIR_UPDATER_DISABLED"
+	EnvUpdaterEnabled         = "CLAIR_UPDATER_ENABLEDUPDATERS"
+	EnvUpdaterDisabledList    = "CLAIR_UPDATER_DISABLEDUPDATERS"
+	EnvUpdaterConcurrency     = "CLAIR_UPDATER_CONCURRENCY"
+	EnvUpdaterDryRun          = "CLAIR_UPDATER_DRYRUN"
+	EnvUpdaterLockDuration    = "CLAIR_UPDATER_LOCKDURATION"
+	EnvUpdaterInstanceName    = "CLAIR_UPDATER_INSTANCENAME"
+	EnvUpdaterHTTPTimeout     = "CLAIR_UPDATER_HTTP_TIMEOUT"
+	EnvUpdaterHTTPProxy       = "CLAIR_UPDATER_HTTP_PROXY"
+	EnvUpdaterHTTPCAFile      = "CLAIR_UPDATER_HTTP_CAFILE"
+	EnvWorkerListers          = "CLAIR_WORKER_LISTERCONCURRENCY"
+	EnvWorkerEnabledListers   = "CLAIR_WORKER_ENABLEDLISTERS"
+	EnvWorkerMaxFileSize      = "CLAIR_WORKER_MAXEXTRACTABLEFILESIZE"
+	EnvWorkerMaxSize          = "CLAIR_WORKER_MAXEXTRACTEDSIZE"
+	EnvWorkerMaxLayers        = "CLAIR_WORKER_MAXLAYERS"
+	EnvWorkerFilesCacheDir    = "CLAIR_WORKER_FILESCACHEDIR"
+	EnvWorkerFilesCacheSize   = "CLAIR_WORKER_FILESCACHESIZE"
+	EnvNotifierAttempts       = "CLAIR_NOTIFIER_ATTEMPTS"
+	EnvNotifierRenotify       = "CLAIR_NOTIFIER_RENOTIFYINTERVAL"
+	EnvNotifierMinSeverity    = "CLAIR_NOTIFIER_MINIMUMSEVERITY"
+	EnvNotifierDedupWindow    = "CLAIR_NOTIFIER_DEDUPWINDOW"
+	EnvLogFormat              = "CLAIR_LOG_FORMAT"
+	EnvLogLevel               = "CLAIR_LOG_LEVEL"
+	EnvShutdownTimeout        = "CLAIR_SHUTDOWNTIMEOUT"
+)
+
+// ApplyEnvOverrides overrides the values of the given configuration with the
+// CLAIR_* environment variables that are set.
+//
+// Only the fields of the Env* variables above are overridable; the others,
+// such as the allowlist or the parameters of the notification senders, are
+// only read from the configuration file.
+//
+// Unset or empty variables leave the configuration untouched. A variable that
+// cannot be parsed into the type of its field returns an error naming it.
+func ApplyEnvOverrides(config *Config) error {
+	if v, ok := lookupEnv(EnvDatabaseType); ok {
+		config.Database.Type = v
+	}
+
+	if v, ok := lookupEnv(EnvDatabaseSource); ok {
+		setDatabaseOption(config, "source", v)
+	}
+
+	if v, ok := lookupEnv(EnvDatabaseSourceFile); ok {
+		setDatabaseOption(config, "sourcefile", v)
+	}
+
+	if v, ok := lookupEnv(EnvDatabasePasswordFile); ok {
+		setDatabaseOption(config, "passwordfile", v)
+	}
+
+	if v, ok := lookupEnv(EnvDatabaseCacheSize); ok {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return envError(EnvDatabaseCacheSize, "an integer", v)
+		}
+		setDatabaseOption(config, "cachesize", size)
+	}
+
+	if v, ok := lookupEnv(EnvDatabasePaginationKey); ok {
+		setDatabaseOption(config, "paginationkey", v)
+	}
+
+	if v, ok := lookupEnv(EnvDatabasePaginationKeys); ok {
+		keys := []interface{}{}
+		for _, key := range splitList(v) {
+			keys = append(keys, key)
+		}
+		setDatabaseOption(config, "paginationkeys", keys)
+	}
+
+	if config.API != nil {
+		if v, ok := lookupEnv(EnvAPIPort); ok {
+			addr, err := replacePort(config.API.Addr, v)
+			if err != nil {
+				return envError(EnvAPIPort, "a port number", v)
+			}
+			config.API.Addr = addr
+		}
+
+		if v, ok := lookupEnv(EnvAPIHealthPort); ok {
+			addr, err := replacePort(config.API.HealthAddr, v)
+			if err != nil {
+				return envError(EnvAPIHealthPort, "a port number", v)
+			}
+			config.API.HealthAddr = addr
+		}
+
+		if v, ok := lookupEnv(EnvAPITimeout); ok {
+			timeout, err := time.ParseDuration(v)
+			if err != nil {
+				return envError(EnvAPITimeout, "a duration", v)
+			}
+			config.API.Timeout = timeout
+		}
+
+		if v, ok := lookupEnv(EnvAPICertFile); ok {
+			config.API.CertFile = v
+		}
+
+		if v, ok := lookupEnv(EnvAPIKeyFile); ok {
+			config.API.KeyFile = v
+		}
+
+		if v, ok := lookupEnv(EnvAPICAFile); ok {
+			config.API.CAFile = v
+		}
+
+		if v, ok := lookupEnv(EnvAPIClientAuth); ok {
+			config.API.ClientAuth = v
+		}
+
+		if v, ok := lookupEnv(EnvAPIMinTLSVersion); ok {
+			config.API.MinTLSVersion = v
+		}
+
+		if v, ok := lookupEnv(EnvAPICipherSuites); ok {
+			config.API.CipherSuites = splitList(v)
+		}
+
+		if v, ok := lookupEnv(EnvAPIUpdaterToken); ok {
+			config.API.UpdaterToken = v
+		}
+
+		for _, limit := range []struct {
+			key   string
+			value *int
+		}{
+			{EnvAPIRateLimitAnalyses, &config.API.RateLimit.Analyses},
+			{EnvAPIRateLimitReads, &config.API.RateLimit.Reads},
+			{EnvAPIMaxAnalyses, &config.API.MaxConcurrentAnalyses},
+			{EnvAPIMaxQueuedAnalyses, &config.API.MaxQueuedAnalyses},
+		} {
+			if v, ok := lookupEnv(limit.key); ok {
+				n, err := strconv.Atoi(v)
+				if err != nil {
+					return envError(limit.key, "an integer", v)
+				}
+				*limit.value = n
+			}
+		}
+
+		if v, ok := lookupEnv(EnvAPIMaxRequestSize); ok {
+			size, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return envError(EnvAPIMaxRequestSize, "a number of bytes", v)
+			}
+			config.API.MaxRequestSize = size
+		}
+
+		for _, duration := range []struct {
+			key   string
+			value *time.Duration
+		}{
+			{EnvAPIPaginationTTL, &config.API.PaginationTTL},
+			{EnvAPIPaginationClockSkew, &config.API.PaginationClockSkew},
+		} {
+			if v, ok := lookupEnv(duration.key); ok {
+				d, err := time.ParseDuration(v)
+				if err != nil {
+					return envError(duration.key, "a duration", v)
+				}
+				*duration.value = d
+			}
+		}
+	}
+
+	if config.Updater != nil {
+		if v, ok := lookupEnv(EnvUpdaterInterval); ok {
+			interval, err := time.ParseDuration(v)
+			if err != nil {
+				return envError(EnvUpdaterInterval, "a duration", v)
+			}
+			config.Updater.Interval = interval
+		}
+
+		if v, ok := lookupEnv(EnvUpdaterCron); ok {
+			config.Updater.Cron = v
+		}
+
+		if v, ok := lookupEnv(EnvUpdaterEnabled); ok {
+			config.Updater.EnabledUpdaters = splitList(v)
+		}
+
+		if v, ok := lookupEnv(EnvUpdaterDisabledList); ok {
+			config.Updater.DisabledUpdaters = splitList(v)
+		}
+
+		if v, ok := lookupEnv(EnvUpdaterConcurrency); ok {
+			concurrency, err := strconv.Atoi(v)
+			if err != nil {
+				return envError(EnvUpdaterConcurrency, "an integer", v)
+			}
+			config.Updater.Concurrency = concurrency
+		}
+
+		if v, ok := lookupEnv(EnvUpdaterDryRun); ok {
+			dryRun, err := strconv.ParseBool(v)
+			if err != nil {
+				return envError(EnvUpdaterDryRun, "a boolean", v)
+			}
+			config.Updater.DryRun = dryRun
+		}
+
+		if v, ok := lookupEnv(EnvUpdaterLockDuration); ok {
+			duration, err := time.ParseDuration(v)
+			if err != nil {
+				return envError(EnvUpdaterLockDuration, "a duration", v)
+			}
+			config.Updater.LockDuration = duration
+		}
+
+		if v, ok := lookupEnv(EnvUpdaterInstanceName); ok {
+			config.Updater.InstanceName = v
+		}
+
+		if v, ok := lookupEnv(EnvUpdaterHTTPTimeout); ok {
+			timeout, err := time.ParseDuration(v)
+			if err != nil {
+				return envError(EnvUpdaterHTTPTimeout, "a duration", v)
+			}
+			config.Updater.HTTP.Timeout = timeout
+		}
+
+		if v, ok := lookupEnv(EnvUpdaterHTTPProxy); ok {
+			config.Updater.HTTP.Proxy = v
+		}
+
+		if v, ok := lookupEnv(EnvUpdaterHTTPCAFile); ok {
+			config.Updater.HTTP.CAFile = v
+		}
+
+		if v, ok := lookupEnv(EnvUpdaterDisabled); ok {
+			disabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return envError(EnvUpdaterDisabled, "a boolean", v)
+			}
+			config.Updater.Disabled = disabled
+		}
+	}
+
+	if config.Worker != nil {
+		if v, ok := lookupEnv(EnvWorkerListers); ok {
+			concurrency, err := strconv.Atoi(v)
+			if err != nil {
+				return envError(EnvWorkerListers, "an integer", v)
+			}
+			config.Worker.ListerConcurrency = concurrency
+		}
+
+		if v, ok := lookupEnv(EnvWorkerEnabledListers); ok {
+			config.Worker.EnabledListers = splitList(v)
+		}
+
+		if v, ok := lookupEnv(EnvWorkerMaxFileSize); ok {
+			size, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return envError(EnvWorkerMaxFileSize, "a number of bytes", v)
+			}
+			config.Worker.MaxExtractableFileSize = size
+		}
+
+		if v, ok := lookupEnv(EnvWorkerMaxSize); ok {
+			size, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return envError(EnvWorkerMaxSize, "a number of bytes", v)
+			}
+			config.Worker.MaxExtractedSize = size
+		}
+
+		if v, ok := lookupEnv(EnvWorkerMaxLayers); ok {
+			layers, err := strconv.Atoi(v)
+			if err != nil {
+				return envError(EnvWorkerMaxLayers, "an integer", v)
+			}
+			config.Worker.MaxLayers = layers
+		}
+
+		if v, ok := lookupEnv(EnvWorkerFilesCacheDir); ok {
+			config.Worker.FilesCacheDir = v
+		}
+
+		if v, ok := lookupEnv(EnvWorkerFilesCacheSize); ok {
+			size, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return envError(EnvWorkerFilesCacheSize, "a number of bytes", v)
+			}
+			config.Worker.FilesCacheSize = size
+		}
+	}
+
+	if config.Notifier != nil {
+		if v, ok := lookupEnv(EnvNotifierAttempts); ok {
+			attempts, err := strconv.Atoi(v)
+			if err != nil {
+				return envError(EnvNotifierAttempts, "an integer", v)
+			}
+			config.Notifier.Attempts = attempts
+		}
+
+		if v, ok := lookupEnv(EnvNotifierRenotify); ok {
+			interval, err := time.ParseDuration(v)
+			if err != nil {
+				return envError(EnvNotifierRenotify, "a duration", v)
+			}
+			config.Notifier.RenotifyInterval = interval
+		}
+
+		if v, ok := lookupEnv(EnvNotifierMinSeverity); ok {
+			config.Notifier.MinimumSeverity = database.Severity(v)
+		}
+
+		if v, ok := lookupEnv(EnvNotifierDedupWindow); ok {
+			window, err := time.ParseDuration(v)
+			if err != nil {
+				return envError(EnvNotifierDedupWindow, "a duration", v)
+			}
+			config.Notifier.DedupWindow = window
+		}
+	}
+
+	if config.Log != nil {
+		if v, ok := lookupEnv(EnvLogFormat); ok {
+			config.Log.Format = v
+		}
+
+		if v, ok := lookupEnv(EnvLogLevel); ok {
+			config.Log.Level = v
+		}
+	}
+
+	if v, ok := lookupEnv(EnvShutdownTimeout); ok {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return envError(EnvShutdownTimeout, "a duration", v)
+		}
+		config.ShutdownTimeout = timeout
+	}
+
+	return nil
+}
+
+func lookupEnv(key string) (string, bool) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return "", false
+	}
+	return v, true
+}
+
+// splitList splits a comma-separated list and trims its elements.
+func splitList(v string) []string {
+	l := strings.Split(v, ",")
+	for i := range l {
+		l[i] = strings.TrimSpace(l[i])
 	}
+	return l
+}
+
+func envError(key, expected, value string) error {
+	return fmt.Errorf("could not load configuration: %s must be %s, got %q", key, expected, value)
+}
+
+func setDatabaseOption(config *Config, key string, value interface{}) {
+	if config.Database.Options == nil {
+		config.Database.Options = make(map[string]interface{})
+	}
+	config.Database.Options[key] = value
+}
+
+// replacePort replaces the port of a "host:port" address.
+func replacePort(addr, port string) (string, error) {
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 0 || p > 65535 {
+		return "", fmt.Errorf("invalid port %q", port)
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(p)), nil
+}
+
+// loadConfigFile reads and decodes the configuration file at path into cfgFile.
+func loadConfigFile(path string, cfgFile *File) error {
+	f, err := os.Open(os.ExpandEnv(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	d, err := ioutil.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	return unmarshalConfig(path, d, cfgFile)
+}
+
+// validateAPITLS ensures that the API certificate and key are either both set
+// or both empty, that the CA is only set with them, that every referenced file
+// can be read and that the policy for the client certificates, the minimum
+// version of TLS and the cipher suites are known.
+func validateAPITLS(cfg *api.Config) error {
+	if cfg == nil {
+		return nil
+	}
+
+	if cfg.CertFile != "" && cfg.KeyFile == "" {
+		return errors.New("could not load configuration: api certfile is set but keyfile is empty")
+	}
+
+	if cfg.KeyFile != "" && cfg.CertFile == "" {
+		return errors.New("could not load configuration: api keyfile is set but certfile is empty")
+	}
+
+	// The clients are only verified over TLS, so that a CA without a
+	// certificate would leave the API open over plain HTTP.
+	if cfg.CAFile != "" && cfg.CertFile == "" {
+		return errors.New("could not load configuration: api cafile is set but certfile and keyfile are empty")
+	}
+
+	for _, file := range []struct{ name, path string }{
+		{"certfile", cfg.CertFile},
+		{"keyfile", cfg.KeyFile},
+		{"cafile", cfg.CAFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+
+		f, err := os.Open(file.path)
+		if err != nil {
+			return fmt.Errorf("could not load configuration: api %s %q is not readable: %s", file.name, file.path, err)
+		}
+		f.Close()
+	}
+
+	if _, err := api.ParseClientAuth(cfg.ClientAuth); err != nil {
+		return fmt.Errorf("could not load configuration: api clientauth: %s", err)
+	}
+
+	if _, err := api.ParseTLSVersion(cfg.MinTLSVersion); err != nil {
+		return fmt.Errorf("could not load configuration: api mintlsversion: %s", err)
+	}
+
+	if _, err := api.ParseCipherSuites(cfg.CipherSuites); err != nil {
+		return fmt.Errorf("could not load configuration: api ciphersuites: %s", err)
+	}
+
+	return nil
+}
+
+// validateAPILimits ensures that the limits of the API requests are not
+// negative and that the analyses are only queued when their concurrency is
+// limited.
+func validateAPILimits(cfg *api.Config) error {
+	if cfg == nil {
+		return nil
+	}
+
+	for _, limit := range []struct {
+		name  string
+		value int
+	}{
+		{"ratelimit.analyses", cfg.RateLimit.Analyses},
+		{"ratelimit.reads", cfg.RateLimit.Reads},
+		{"maxconcurrentanalyses", cfg.MaxConcurrentAnalyses},
+		{"maxqueuedanalyses", cfg.MaxQueuedAnalyses},
+	} {
+		if limit.value < 0 {
+			return fmt.Errorf("could not load configuration: api %s must not be negative (0 disables the limit), got %d", limit.name, limit.value)
+		}
+	}
+
+	if cfg.MaxQueuedAnalyses > 0 && cfg.MaxConcurrentAnalyses == 0 {
+		return errors.New("could not load configuration: api maxqueuedanalyses requires maxconcurrentanalyses")
+	}
+
+	if cfg.MaxRequestSize < 0 {
+		return fmt.Errorf("could not load configuration: api maxrequestsize must not be negative (0 disables the limit), got %d", cfg.MaxRequestSize)
+	}
+
+	return nil
+}
+
+// validatePagination ensures that the lifetime of the pagination tokens and
+// the tolerated clock skew are not negative.
+func validatePagination(cfg *api.Config) error {
+	if cfg == nil {
+		return nil
+	}
+
+	if cfg.PaginationTTL < 0 || cfg.PaginationClockSkew < 0 {
+		return fmt.Errorf("could not load configuration: api paginationttl and paginationclockskew must not be negative, got %s and %s", cfg.PaginationTTL, cfg.PaginationClockSkew)
+	}
+
+	return nil
+}
+
+// validateUpdaters ensures that the updater is scheduled by a valid cron
+// expression or interval unless it is disabled, that its concurrency is not
+// negative, that the cron expressions of the updaters are valid, that the
+// enabled, disabled and scheduled updaters are all registered, that the
+// updaters sharing their namespaces are not enabled together and that the
+// credentials and mirrors of the data sources are valid.
+func validateUpdaters(cfg *clair.UpdaterConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	if cfg.Interval < 0 {
+		return fmt.Errorf("could not load configuration: updater interval must not be negative, got %s", cfg.Interval)
+	}
+
+	// The schedule is parsed as the updater parses it, so that an invalid one
+	// fails at startup rather than leaving the vulnerabilities out of date.
+	if !cfg.IsDisabled() {
+		if _, err := cfg.Schedule(); err != nil {
+			return fmt.Errorf("could not load configuration: invalid updater cron %q: %s", cfg.Cron, err)
+		}
+	}
+
+	if cfg.Concurrency < 0 {
+		return fmt.Errorf("could not load configuration: updater concurrency must not be negative (0 fetches every data source at once), got %d", cfg.Concurrency)
+	}
+
+	if cfg.LockDuration != 0 && cfg.LockDuration < time.Minute {
+		return fmt.Errorf("could not load configuration: updater lock duration must be at least 1m (0 uses the default), got %s", cfg.LockDuration)
+	}
+
+	if _, err := cfg.SourceSchedules(); err != nil {
+		return fmt.Errorf("could not load configuration: invalid updater cron of %s", err)
+	}
+
+	names := make([]string, 0, len(cfg.EnabledUpdaters)+len(cfg.DisabledUpdaters)+len(cfg.Crons))
+	names = append(names, cfg.EnabledUpdaters...)
+	names = append(names, cfg.DisabledUpdaters...)
+	for name := range cfg.Crons {
+		names = append(names, name)
+	}
+
+	registered := vulnsrc.ListUpdaters()
+	unknown := strutil.Difference(names, registered)
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		sort.Strings(registered)
+		return fmt.Errorf("could not load configuration: unknown updaters %s (registered updaters: %s)", strings.Join(unknown, ", "), strings.Join(registered, ", "))
+	}
+
+	// The ghsa and osv updaters share their namespaces, so that each of them
+	// would withdraw the vulnerabilities of the other one.
+	enabled := cfg.EnabledUpdaters
+	if len(enabled) == 0 {
+		enabled = vulnsrc.ListDefaultUpdaters()
+	}
+	enabled = strutil.Difference(enabled, cfg.DisabledUpdaters)
+	if len(strutil.Difference([]string{"ghsa", "osv"}, enabled)) == 0 {
+		return errors.New("could not load configuration: the ghsa and osv updaters are mutually exclusive, as they update the same namespaces")
+	}
+
+	// The credentials are given to the updaters and the metadata appenders.
+	sources := append([]string{}, registered...)
+	for name := range vulnmdsrc.Appenders() {
+		sources = append(sources, strings.ToLower(name))
+	}
+
+	sort.Strings(sources)
+	for name, credentials := range cfg.HTTP.Credentials {
+		if len(strutil.Difference([]string{strings.ToLower(name)}, sources)) > 0 {
+			return fmt.Errorf("could not load configuration: updater http credentials of unknown data source %q (data sources: %s)", name, strings.Join(sources, ", "))
+		}
+
+		if err := credentials.Validate(); err != nil {
+			return fmt.Errorf("could not load configuration: updater http credentials of %s: %s", name, err)
+		}
+	}
+
+	for name, base := range cfg.HTTP.Mirrors {
+		if len(strutil.Difference([]string{strings.ToLower(name)}, sources)) > 0 {
+			return fmt.Errorf("could not load configuration: updater http mirror of unknown data source %q (data sources: %s)", name, strings.Join(sources, ", "))
+		}
+
+		if _, err := httputil.ParseMirror(base); err != nil {
+			return fmt.Errorf("could not load configuration: updater http mirror of %s: %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateWorker ensures that the enabled feature listers are all registered
+// and that the default namespace, if any, has a known version format.
+func validateWorker(cfg *clair.WorkerConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	var registered []string
+	for _, d := range featurefmt.ListListers() {
+		registered = append(registered, d.Name)
+	}
+
+	unknown := strutil.Difference(cfg.EnabledListers, registered)
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		sort.Strings(registered)
+		return fmt.Errorf("could not load configuration: unknown feature listers %s (registered listers: %s)", strings.Join(unknown, ", "), strings.Join(registered, ", "))
+	}
+
+	if ns := cfg.DefaultNamespace; ns != nil {
+		if ns.Name == "" {
+			return errors.New("could not load configuration: worker default namespace must have a name")
+		}
+
+		if _, ok := versionfmt.GetParser(ns.VersionFormat); !ok {
+			return fmt.Errorf("could not load configuration: unknown version format %q of the worker default namespace", ns.VersionFormat)
+		}
+	}
+
+	if cfg.FilesCacheDir != "" && cfg.FilesCacheSize <= 0 {
+		return errors.New("could not load configuration: worker files cache size must be positive")
+	}
+
+	return nil
+}
+
+// validateDatabase ensures that the database type is registered, that the
+// PostgreSQL database has a source and that the pagination keys, if any, are
+// valid.
+func validateDatabase(cfg *database.RegistrableComponentConfig) error {
+	registered := database.ListDrivers()
+	if len(strutil.Difference([]string{cfg.Type}, registered)) > 0 {
+		sort.Strings(registered)
+		return fmt.Errorf("could not load configuration: unknown database type %q (registered types: %s)", cfg.Type, strings.Join(registered, ", "))
+	}
+
+	if cfg.Type == "pgsql" && isEmptyOption(cfg.Options["source"]) && isEmptyOption(cfg.Options["sourcefile"]) {
+		return ErrDatasourceNotLoaded
+	}
+
+	if v := cfg.Options["paginationkey"]; !isEmptyOption(v) {
+		key, ok := v.(string)
+		if !ok {
+			return errors.New("could not load configuration: database paginationkey must be a string")
+		}
+
+		if _, err := pagination.KeyFromString(key); err != nil {
+			return fmt.Errorf("could not load configuration: database paginationkey is invalid: %s", err)
+		}
+	}
+
+	if v, ok := cfg.Options["paginationkeys"]; ok && v != nil {
+		keys, ok := v.([]interface{})
+		if !ok {
+			return errors.New("could not load configuration: database paginationkeys must be a list of strings")
+		}
+
+		for i, v := range keys {
+			key, ok := v.(string)
+			if !ok {
+				return errors.New("could not load configuration: database paginationkeys must be a list of strings")
+			}
+
+			if _, err := pagination.KeyFromString(key); err != nil {
+				return fmt.Errorf("could not load configuration: database paginationkeys[%d] is invalid: %s", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasPaginationKeys returns whether the database options have a pagination
+// key.
+func hasPaginationKeys(options map[string]interface{}) bool {
+	if !isEmptyOption(options["paginationkey"]) {
+		return true
+	}
+
+	keys, _ := options["paginationkeys"].([]interface{})
+	return len(keys) > 0
+}
+
+// isEmptyOption returns whether a database option is absent or empty.
+func isEmptyOption(v interface{}) bool {
+	s, ok := v.(string)
+	return v == nil || (ok && s == "")
+}
+
+// validateNotifier ensures that the minimum severity of the notifier, if any,
+// is a known severity, normalizes its case and validates the deduplication
+// window and the configuration of the senders that support it.
+func validateNotifier(cfg *notification.Config) error {
+	if cfg == nil {
+		return nil
+	}
+
+	if cfg.DedupWindow < 0 {
+		return fmt.Errorf("could not load configuration: notifier dedupwindow must not be negative (0 sends every notification), got %s", cfg.DedupWindow)
+	}
+
+	for name, sender := range notification.Senders() {
+		validator, ok := sender.(notification.ConfigValidator)
+		if !ok {
+			continue
+		}
+
+		if err := validator.ValidateConfig(cfg); err != nil {
+			return fmt.Errorf("could not load configuration: notifier %s: %s", name, err)
+		}
+	}
+
+	if cfg.MinimumSeverity == "" {
+		return nil
+	}
+
+	severity, err := database.NewSeverity(string(cfg.MinimumSeverity))
+	if err != nil {
+		return fmt.Errorf("could not load configuration: unknown notifier minimum severity %q", cfg.MinimumSeverity)
+	}
+	cfg.MinimumSeverity = severity
+
+	return nil
+}
+
+// validateLog ensures that the format and the level of the logs are known and
+// normalizes their case.
+func validateLog(cfg *LogConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	cfg.Format = strings.ToLower(cfg.Format)
+	if cfg.Format != "json" && cfg.Format != "text" {
+		return fmt.Errorf("could not load configuration: unknown log format %q", cfg.Format)
+	}
+
+	cfg.Level = strings.ToLower(cfg.Level)
+	if _, err := log.ParseLevel(cfg.Level); err != nil {
+		return fmt.Errorf("could not load configuration: unknown log level %q", cfg.Level)
+	}
+
+	return nil
+}
+
+// validateAllowlist ensures that every entry of the allowlist names a
+// vulnerability.
+func validateAllowlist(entries []clair.AllowlistEntry) error {
+	for i, entry := range entries {
+		if strings.TrimSpace(entry.Name) == "" {
+			return fmt.Errorf("could not load configuration: allowlist[%d] has no vulnerability name", i)
+		}
+	}
+
+	return nil
+}
+
+// validateVersionFormats ensures that the version formats overriding the ones
+// of the namespaces are registered.
+func validateVersionFormats(formats map[string]string) error {
+	for namespace, format := range formats {
+		if strings.TrimSpace(namespace) == "" {
+			return errors.New("could not load configuration: version format has no namespace")
+		}
+
+		if _, ok := versionfmt.GetParser(format); !ok {
+			return fmt.Errorf("could not load configuration: unknown version format %q of namespace %q", format, namespace)
+		}
+	}
+
+	return nil
+}
+
+// LoadAllowlist reads the allowlist of the configuration file at path, without
+// applying the rest of the configuration, so that it can be reloaded while
+// Clair is running.
+func LoadAllowlist(path string) ([]clair.AllowlistEntry, error) {
+	var cfgFile File
+	if err := loadConfigFile(path, &cfgFile); err != nil {
+		return nil, err
+	}
+
+	if err := validateAllowlist(cfgFile.Clair.Allowlist); err != nil {
+		return nil, err
+	}
+
+	return cfgFile.Clair.Allowlist, nil
 }
 
 // LoadConfig is a shortcut to open a file, read it, and generate a Config.
 //
-// It supports relative and absolute paths. Given "", it returns DefaultConfig.
+// It supports relative and absolute paths. Given "", it starts from DefaultConfig.
+// The file is decoded as JSON if it ends with ".json", as TOML if it ends
+// with ".toml" and as YAML otherwise. The CLAIR_* environment variables are
+// then applied on top of the file values (see ApplyEnvOverrides).
 func LoadConfig(path string) (config *Config, err error) {
 	var cfgFile File
 	cfgFile.Clair = DefaultConfig()
-	if path == "" {
-		return &cfgFile.Clair, nil
+	if path != "" {
+		err = loadConfigFile(path, &cfgFile)
+		if err != nil {
+			return
+		}
 	}
+	config = &cfgFile.Clair
 
-	f, err := os.Open(os.ExpandEnv(path))
+	err = ApplyEnvOverrides(config)
 	if err != nil {
 		return
 	}
-	defer f.Close()
 
-	d, err := ioutil.ReadAll(f)
+	err = validateAPITLS(config.API)
 	if err != nil {
 		return
 	}
 
-	err = yaml.Unmarshal(d, &cfgFile)
+	err = validateAPILimits(config.API)
+	if err != nil {
+		return
+	}
+
+	err = validatePagination(config.API)
+	if err != nil {
+		return
+	}
+
+	err = validateUpdaters(config.Updater)
+	if err != nil {
+		return
+	}
+
+	err = validateWorker(config.Worker)
+	if err != nil {
+		return
+	}
+
+	err = validateNotifier(config.Notifier)
+	if err != nil {
+		return
+	}
+
+	err = validateLog(config.Log)
+	if err != nil {
+		return
+	}
+
+	err = validateDatabase(&config.Database)
+	if err != nil {
+		return
+	}
+
+	err = validateAllowlist(config.Allowlist)
+	if err != nil {
+		return
+	}
+
+	err = validateVersionFormats(config.VersionFormats)
 	if err != nil {
 		return
 	}
-	config = &cfgFile.Clair
 
 	// Generate a pagination key if none is provided.
-	if v, ok := config.Database.Options["paginationkey"]; !ok || v == nil || v.(string) == "" {
+	if config.Database.Options == nil {
+		config.Database.Options = make(map[string]interface{})
+	}
+	if !hasPaginationKeys(config.Database.Options) {
 		log.Warn("pagination key is empty, generating...")
 		config.Database.Options["paginationkey"] = pagination.Must(pagination.NewKey()).String()
-	} else {
-		_, err = pagination.KeyFromString(config.Database.Options["paginationkey"].(string))
-		if err != nil {
-			return
-		}
 	}
 
 	return
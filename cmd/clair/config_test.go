@@ -0,0 +1,410 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/api"
+)
+
+// The same configuration in every supported format.
+var configFiles = map[string]string{
+	"config.yaml": `
+clair:
+  database:
+    type: mem
+    options:
+      paginationkey: XxoPtCUzrUv4JV5dS+yQ+MdW7yLEJnRMwigVY/bpgtQ=
+  updater:
+    interval: 2h
+    enabledupdaters:
+      - debian
+  notifier:
+    attempts: 3
+    renotifyinterval: 30m
+    webhook:
+      endpoint: https://example.com/notify
+  api:
+    addr: 0.0.0.0:7070
+    timeout: 30s
+  log:
+    format: text
+  versionformats:
+    alpine: dpkg
+  allowlist:
+    - name: CVE-2018-0001
+      reason: not exploitable
+`,
+	"config.json": `{
+  "clair": {
+    "database": {
+      "type": "mem",
+      "options": {"paginationkey": "XxoPtCUzrUv4JV5dS+yQ+MdW7yLEJnRMwigVY/bpgtQ="}
+    },
+    "updater": {"interval": "2h", "enabledupdaters": ["debian"]},
+    "notifier": {
+      "attempts": 3,
+      "renotifyinterval": "30m",
+      "webhook": {"endpoint": "https://example.com/notify"}
+    },
+    "api": {"addr": "0.0.0.0:7070", "timeout": "30s"},
+    "log": {"format": "text"},
+    "versionformats": {"alpine": "dpkg"},
+    "allowlist": [{"name": "CVE-2018-0001", "reason": "not exploitable"}]
+  }
+}`,
+	"config.toml": `
+[clair.database]
+type = "mem"
+
+[clair.database.options]
+paginationkey = "XxoPtCUzrUv4JV5dS+yQ+MdW7yLEJnRMwigVY/bpgtQ="
+
+[clair.updater]
+interval = "2h"
+enabledupdaters = ["debian"]
+
+[clair.notifier]
+attempts = 3
+renotifyinterval = "30m"
+
+[clair.notifier.webhook]
+endpoint = "https://example.com/notify"
+
+[clair.api]
+addr = "0.0.0.0:7070"
+timeout = "30s"
+
+[clair.log]
+format = "text"
+
+[clair.versionformats]
+alpine = "dpkg"
+
+[[clair.allowlist]]
+name = "CVE-2018-0001"
+reason = "not exploitable"
+`,
+}
+
+func writeConfigFiles(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "clair-config")
+	require.Nil(t, err)
+
+	for name, content := range configFiles {
+		require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	dir, cleanup := writeConfigFiles(t)
+	defer cleanup()
+
+	expected, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	require.Nil(t, err)
+
+	assert.Equal(t, "mem", expected.Database.Type)
+	assert.Equal(t, 2*time.Hour, expected.Updater.Interval)
+	assert.Equal(t, []string{"debian"}, expected.Updater.EnabledUpdaters)
+	assert.Equal(t, 3, expected.Notifier.Attempts)
+	assert.Equal(t, 30*time.Minute, expected.Notifier.RenotifyInterval)
+	assert.Contains(t, expected.Notifier.Params, "webhook")
+	assert.Equal(t, "0.0.0.0:7070", expected.API.Addr)
+	assert.Equal(t, 30*time.Second, expected.API.Timeout)
+	assert.Equal(t, "text", expected.Log.Format)
+	assert.Equal(t, map[string]string{"alpine": "dpkg"}, expected.VersionFormats)
+	if assert.Len(t, expected.Allowlist, 1) {
+		assert.Equal(t, "CVE-2018-0001", expected.Allowlist[0].Name)
+	}
+
+	for _, name := range []string{"config.json", "config.toml"} {
+		config, err := LoadConfig(filepath.Join(dir, name))
+		if assert.Nil(t, err, name) {
+			assert.Equal(t, expected, config, name)
+		}
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	dir, cleanup := writeConfigFiles(t)
+	defer cleanup()
+
+	for name, content := range map[string]string{
+		"invalid.json": `{"clair": `,
+		"invalid.toml": `[clair`,
+		"invalid.yaml": "clair:\n\tdatabase:",
+	} {
+		path := filepath.Join(dir, name)
+		require.Nil(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+		_, err := LoadConfig(path)
+		assert.NotNil(t, err, name)
+	}
+
+	_, err := LoadConfig(filepath.Join(dir, "missing.yaml"))
+	assert.NotNil(t, err)
+}
+
+// setEnv sets the given environment variables and returns a function
+// unsetting them.
+func setEnv(t *testing.T, env map[string]string) func() {
+	for k, v := range env {
+		require.Nil(t, os.Setenv(k, v))
+	}
+
+	return func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	defer setEnv(t, map[string]string{
+		EnvDatabaseType:           "mem",
+		EnvDatabaseSource:         "host=localhost",
+		EnvDatabaseCacheSize:      "4096",
+		EnvDatabasePaginationKeys: "a, b",
+		EnvAPIPort:                "7070",
+		EnvAPIHealthPort:          "7071",
+		EnvAPITimeout:             "30s",
+		EnvAPICipherSuites:        "TLS_AES_128_GCM_SHA256, TLS_AES_256_GCM_SHA384",
+		EnvAPIRateLimitAnalyses:   "5",
+		EnvAPIMaxRequestSize:      "1024",
+		EnvUpdaterEnabled:         "debian,ubuntu",
+		EnvUpdaterCron:            "0 */6 * * *",
+		EnvUpdaterDryRun:          "true",
+		EnvUpdaterHTTPProxy:       "http://proxy:3128",
+		EnvWorkerMaxLayers:        "10",
+		EnvNotifierMinSeverity:    "High",
+		EnvLogLevel:               "debug",
+		EnvShutdownTimeout:        "5s",
+		EnvAPICAFile:              "",
+	})()
+
+	config := DefaultConfig()
+	require.Nil(t, ApplyEnvOverrides(&config))
+
+	assert.Equal(t, "mem", config.Database.Type)
+	assert.Equal(t, "host=localhost", config.Database.Options["source"])
+	assert.Equal(t, 4096, config.Database.Options["cachesize"])
+	assert.Equal(t, []interface{}{"a", "b"}, config.Database.Options["paginationkeys"])
+	assert.Equal(t, "0.0.0.0:7070", config.API.Addr)
+	assert.Equal(t, "0.0.0.0:7071", config.API.HealthAddr)
+	assert.Equal(t, 30*time.Second, config.API.Timeout)
+	assert.Equal(t, []string{"TLS_AES_128_GCM_SHA256", "TLS_AES_256_GCM_SHA384"}, config.API.CipherSuites)
+	assert.Equal(t, 5, config.API.RateLimit.Analyses)
+	assert.Equal(t, int64(1024), config.API.MaxRequestSize)
+	assert.Equal(t, []string{"debian", "ubuntu"}, config.Updater.EnabledUpdaters)
+	assert.Equal(t, "0 */6 * * *", config.Updater.Cron)
+	assert.True(t, config.Updater.DryRun)
+	assert.Equal(t, "http://proxy:3128", config.Updater.HTTP.Proxy)
+	assert.Equal(t, 10, config.Worker.MaxLayers)
+	assert.EqualValues(t, "High", config.Notifier.MinimumSeverity)
+	assert.Equal(t, "debug", config.Log.Level)
+	assert.Equal(t, 5*time.Second, config.ShutdownTimeout)
+
+	// The empty variables are ignored.
+	assert.Equal(t, "", config.API.CAFile)
+	assert.Equal(t, time.Hour, config.Updater.Interval)
+}
+
+func TestApplyEnvOverridesDisabledUpdater(t *testing.T) {
+	defer setEnv(t, map[string]string{
+		EnvUpdaterInterval: "2h",
+		EnvUpdaterDisabled: "true",
+	})()
+
+	config := DefaultConfig()
+	require.Nil(t, ApplyEnvOverrides(&config))
+	assert.True(t, config.Updater.Disabled)
+	assert.Equal(t, 2*time.Hour, config.Updater.Interval)
+}
+
+func TestLoadConfigUpdaterSchedule(t *testing.T) {
+	dir, cleanup := writeConfigFiles(t)
+	defer cleanup()
+
+	for content, expected := range map[string]string{
+		"cron: 0 3 * * *":                 "",
+		"cron: '@every 6h'":               "",
+		"interval: 2h":                    "",
+		"interval: 0\n    disabled: true": "",
+		"cron: 0 3 * *": `could not load configuration: invalid updater cron "0 3 * *": ` +
+			`Expected exactly 5 fields, found 4: 0 3 * *`,
+		"cron: 0 25 * * *": `could not load configuration: invalid updater cron "0 25 * * *": ` +
+			`End of range (25) above maximum (23): 25`,
+		"cron: 0 0 31 2 *": `could not load configuration: invalid updater cron "0 0 31 2 *": ` +
+			`cron expression "0 0 31 2 *" never matches`,
+		"interval: 0":                     "",
+		"crons:\n      debian: '@hourly'": "",
+		"crons:\n      debian: 0 25 * * *": `could not load configuration: invalid updater cron of debian: ` +
+			`End of range (25) above maximum (23): 25`,
+	} {
+		path := filepath.Join(dir, "updater.yaml")
+		require.Nil(t, ioutil.WriteFile(path, []byte("clair:\n  database:\n    type: mem\n  updater:\n    "+content+"\n"), 0600))
+
+		_, err := LoadConfig(path)
+		if expected == "" {
+			assert.Nil(t, err, content)
+		} else if assert.NotNil(t, err, content) {
+			assert.Equal(t, expected, err.Error(), content)
+		}
+	}
+}
+
+func TestApplyEnvOverridesErrors(t *testing.T) {
+	for key, value := range map[string]string{
+		EnvDatabaseCacheSize:      "big",
+		EnvAPIPort:                "65536",
+		EnvAPIHealthPort:          "http",
+		EnvAPITimeout:             "30",
+		EnvAPIMaxQueuedAnalyses:   "1.5",
+		EnvAPIMaxRequestSize:      "1MB",
+		EnvAPIPaginationTTL:       "1d",
+		EnvUpdaterDryRun:          "maybe",
+		EnvUpdaterDisabled:        "yes please",
+		EnvWorkerMaxFileSize:      "-",
+		EnvWorkerFilesCacheSize:   "10GB",
+		EnvNotifierAttempts:       "many",
+		EnvNotifierDedupWindow:    "1 minute",
+		EnvShutdownTimeout:        "forever",
+		EnvUpdaterLockDuration:    "10",
+		EnvUpdaterConcurrency:     "all",
+		EnvWorkerListers:          "four",
+		EnvNotifierRenotify:       "daily",
+		EnvUpdaterHTTPTimeout:     "soon",
+		EnvAPIRateLimitReads:      "unlimited",
+		EnvWorkerMaxLayers:        "1e3",
+		EnvUpdaterInterval:        "hourly",
+		EnvAPIMaxAnalyses:         "two",
+		EnvAPIPaginationClockSkew: "a bit",
+	} {
+		unset := setEnv(t, map[string]string{key: value})
+
+		config := DefaultConfig()
+		err := ApplyEnvOverrides(&config)
+		assert.Equal(t, envError(key, expectedEnvType(key), value), err, key)
+
+		unset()
+	}
+}
+
+// expectedEnvType returns the type that envError reports for a variable.
+func expectedEnvType(key string) string {
+	switch key {
+	case EnvAPIPort, EnvAPIHealthPort:
+		return "a port number"
+	case EnvAPIMaxRequestSize, EnvWorkerMaxFileSize, EnvWorkerMaxSize, EnvWorkerFilesCacheSize:
+		return "a number of bytes"
+	case EnvUpdaterDryRun, EnvUpdaterDisabled:
+		return "a boolean"
+	case EnvDatabaseCacheSize, EnvAPIRateLimitAnalyses, EnvAPIRateLimitReads, EnvAPIMaxAnalyses,
+		EnvAPIMaxQueuedAnalyses, EnvUpdaterConcurrency, EnvWorkerListers, EnvWorkerMaxLayers, EnvNotifierAttempts:
+		return "an integer"
+	default:
+		return "a duration"
+	}
+}
+
+func TestLoadConfigAPITLS(t *testing.T) {
+	dir, cleanup := writeConfigFiles(t)
+	defer cleanup()
+
+	file := filepath.Join(dir, "config.yaml")
+	for content, expected := range map[string]string{
+		"certfile: " + file + "\n    keyfile: " + file:                           "",
+		"certfile: " + file + "\n    keyfile: " + file + "\n    cafile: " + file: "",
+		"certfile: " + file: "could not load configuration: api certfile is set but keyfile is empty",
+		"keyfile: " + file:  "could not load configuration: api keyfile is set but certfile is empty",
+		"cafile: " + file:   "could not load configuration: api cafile is set but certfile and keyfile are empty",
+	} {
+		path := filepath.Join(dir, "api.yaml")
+		require.Nil(t, ioutil.WriteFile(path, []byte("clair:\n  database:\n    type: mem\n  api:\n    "+content+"\n"), 0600))
+
+		_, err := LoadConfig(path)
+		if expected == "" {
+			assert.Nil(t, err, content)
+		} else if assert.NotNil(t, err, content) {
+			assert.Equal(t, expected, err.Error(), content)
+		}
+	}
+}
+
+func TestValidateAPILimits(t *testing.T) {
+	for _, test := range []struct {
+		config   api.Config
+		expected string
+	}{
+		{api.Config{}, ""},
+		{api.Config{MaxConcurrentAnalyses: 2, MaxQueuedAnalyses: 10, MaxRequestSize: 1 << 20}, ""},
+		{api.Config{RateLimit: api.RateLimitConfig{Reads: -1}}, "could not load configuration: api ratelimit.reads must not be negative (0 disables the limit), got -1"},
+		{api.Config{MaxConcurrentAnalyses: -1}, "could not load configuration: api maxconcurrentanalyses must not be negative (0 disables the limit), got -1"},
+		{api.Config{MaxQueuedAnalyses: 10}, "could not load configuration: api maxqueuedanalyses requires maxconcurrentanalyses"},
+		{api.Config{MaxRequestSize: -1}, "could not load configuration: api maxrequestsize must not be negative (0 disables the limit), got -1"},
+	} {
+		config := test.config
+		err := validateAPILimits(&config)
+		if test.expected == "" {
+			assert.Nil(t, err, "%+v", test.config)
+		} else if assert.NotNil(t, err, "%+v", test.config) {
+			assert.Equal(t, test.expected, err.Error())
+		}
+	}
+
+	assert.Nil(t, validateAPILimits(nil))
+}
+
+func TestValidatePagination(t *testing.T) {
+	assert.Nil(t, validatePagination(nil))
+	assert.Nil(t, validatePagination(&api.Config{PaginationTTL: time.Hour, PaginationClockSkew: time.Minute}))
+
+	err := validatePagination(&api.Config{PaginationTTL: -time.Hour})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "could not load configuration: api paginationttl and paginationclockskew must not be negative, got -1h0m0s and 0s", err.Error())
+	}
+	assert.NotNil(t, validatePagination(&api.Config{PaginationClockSkew: -time.Minute}))
+}
+
+func TestLoadConfigNotifierTemplate(t *testing.T) {
+	dir, cleanup := writeConfigFiles(t)
+	defer cleanup()
+
+	for template, expected := range map[string]string{
+		"'{{ json .Name }}'": "",
+		"'{{ json .Name }'": `could not load configuration: notifier webhook: could not parse template: ` +
+			`template: webhook:1: unexpected "}" in operand`,
+	} {
+		path := filepath.Join(dir, "notifier.yaml")
+		require.Nil(t, ioutil.WriteFile(path, []byte("clair:\n  database:\n    type: mem\n  notifier:\n    http:\n      endpoint: https://example.com\n      template: "+template+"\n"), 0600))
+
+		_, err := LoadConfig(path)
+		if expected == "" {
+			assert.Nil(t, err, template)
+		} else if assert.NotNil(t, err, template) {
+			assert.Equal(t, expected, err.Error(), template)
+		}
+	}
+}
@@ -16,12 +16,14 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"runtime/pprof"
-	"strings"
 	"syscall"
 	"time"
 
@@ -33,32 +35,59 @@ import (
 	"github.com/coreos/clair/ext/featurefmt"
 	"github.com/coreos/clair/ext/featurens"
 	"github.com/coreos/clair/ext/imagefmt"
+	"github.com/coreos/clair/ext/versionfmt"
 	"github.com/coreos/clair/ext/vulnsrc"
-	"github.com/coreos/clair/pkg/formatter"
+	"github.com/coreos/clair/pkg/logutil"
+	"github.com/coreos/clair/pkg/pagination"
 	"github.com/coreos/clair/pkg/stopper"
 	"github.com/coreos/clair/pkg/strutil"
 
-	// Register database driver.
+	// Register database drivers.
+	_ "github.com/coreos/clair/database/mem"
 	_ "github.com/coreos/clair/database/pgsql"
 
 	// Register extensions.
 	_ "github.com/coreos/clair/ext/featurefmt/apk"
 	_ "github.com/coreos/clair/ext/featurefmt/dpkg"
+	_ "github.com/coreos/clair/ext/featurefmt/gobinary"
+	_ "github.com/coreos/clair/ext/featurefmt/npm"
+	_ "github.com/coreos/clair/ext/featurefmt/pip"
+	_ "github.com/coreos/clair/ext/featurefmt/portage"
 	_ "github.com/coreos/clair/ext/featurefmt/rpm"
+	_ "github.com/coreos/clair/ext/featurefmt/windows"
 	_ "github.com/coreos/clair/ext/featurens/alpinerelease"
 	_ "github.com/coreos/clair/ext/featurens/aptsources"
+	_ "github.com/coreos/clair/ext/featurens/gentoorelease"
+	_ "github.com/coreos/clair/ext/featurens/gobinary"
 	_ "github.com/coreos/clair/ext/featurens/lsbrelease"
+	_ "github.com/coreos/clair/ext/featurens/mageiarelease"
+	_ "github.com/coreos/clair/ext/featurens/npm"
 	_ "github.com/coreos/clair/ext/featurens/osrelease"
+	_ "github.com/coreos/clair/ext/featurens/photonrelease"
+	_ "github.com/coreos/clair/ext/featurens/python"
 	_ "github.com/coreos/clair/ext/featurens/redhatrelease"
+	_ "github.com/coreos/clair/ext/featurens/windows"
 	_ "github.com/coreos/clair/ext/imagefmt/aci"
 	_ "github.com/coreos/clair/ext/imagefmt/docker"
+	_ "github.com/coreos/clair/ext/imagefmt/oci"
+	_ "github.com/coreos/clair/ext/notification/slack"
 	_ "github.com/coreos/clair/ext/notification/webhook"
+	_ "github.com/coreos/clair/ext/objectstore/gcs"
+	_ "github.com/coreos/clair/ext/objectstore/s3"
 	_ "github.com/coreos/clair/ext/vulnmdsrc/nvd"
 	_ "github.com/coreos/clair/ext/vulnsrc/alpine"
+	_ "github.com/coreos/clair/ext/vulnsrc/amzn"
 	_ "github.com/coreos/clair/ext/vulnsrc/debian"
+	_ "github.com/coreos/clair/ext/vulnsrc/ghsa"
+	_ "github.com/coreos/clair/ext/vulnsrc/mageia"
+	_ "github.com/coreos/clair/ext/vulnsrc/mariner"
 	_ "github.com/coreos/clair/ext/vulnsrc/oracle"
+	_ "github.com/coreos/clair/ext/vulnsrc/osv"
+	_ "github.com/coreos/clair/ext/vulnsrc/photon"
 	_ "github.com/coreos/clair/ext/vulnsrc/rhel"
+	_ "github.com/coreos/clair/ext/vulnsrc/suse"
 	_ "github.com/coreos/clair/ext/vulnsrc/ubuntu"
+	_ "github.com/coreos/clair/ext/vulnsrc/wolfi"
 )
 
 // MaxDBConnectionAttempts is the total number of tries that Clair will use to
@@ -102,8 +131,16 @@ func stopCPUProfiling(f *os.File) {
 }
 
 func configClairVersion(config *Config) {
-	clair.EnabledDetectors = append(featurefmt.ListListers(), featurens.ListDetectors()...)
-	clair.EnabledUpdaters = strutil.Intersect(config.Updater.EnabledUpdaters, vulnsrc.ListUpdaters())
+	clair.EnabledDetectors = append(enabledListers(config.Worker), featurens.ListDetectors()...)
+
+	// An empty list of enabled updaters enables all the registered updaters
+	// but the opt-in ones.
+	enabledUpdaters := config.Updater.EnabledUpdaters
+	if len(enabledUpdaters) == 0 {
+		enabledUpdaters = vulnsrc.ListDefaultUpdaters()
+	}
+	enabledUpdaters = strutil.Intersect(enabledUpdaters, vulnsrc.ListUpdaters())
+	clair.EnabledUpdaters = strutil.Difference(enabledUpdaters, config.Updater.DisabledUpdaters)
 
 	log.WithFields(log.Fields{
 		"Detectors": database.SerializeDetectors(clair.EnabledDetectors),
@@ -111,16 +148,52 @@ func configClairVersion(config *Config) {
 	}).Info("enabled Clair extensions")
 }
 
-// Boot starts Clair instance with the provided config.
-func Boot(config *Config) {
-	rand.Seed(time.Now().UnixNano())
-	st := stopper.NewStopper()
+// enabledListers returns the feature listers enabled by the configuration of
+// the worker. An empty list enables the default listers, which leave out the
+// opt-in ones.
+func enabledListers(config *clair.WorkerConfig) []database.Detector {
+	if config == nil || len(config.EnabledListers) == 0 {
+		return featurefmt.ListDefaultListers()
+	}
+
+	enabled := make(map[string]struct{}, len(config.EnabledListers))
+	for _, name := range config.EnabledListers {
+		enabled[name] = struct{}{}
+	}
 
-	// Open database
+	listers := []database.Detector{}
+	for _, d := range featurefmt.ListListers() {
+		if _, ok := enabled[d.Name]; ok {
+			listers = append(listers, d)
+		}
+	}
+	return listers
+}
+
+// reloadAllowlistOnSignal reloads the allowlist from the configuration file
+// at path whenever Clair receives SIGHUP. An invalid allowlist is logged and
+// the current one is kept.
+func reloadAllowlistOnSignal(path string) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	for range hangups {
+		entries, err := LoadAllowlist(path)
+		if err != nil {
+			log.WithError(err).Error("could not reload allowlist, keeping the current one")
+			continue
+		}
+		clair.SetAllowlist(entries)
+	}
+}
+
+// openDatabase opens the configured database, retrying up to
+// MaxDBConnectionAttempts times.
+func openDatabase(cfg database.RegistrableComponentConfig) database.Datastore {
 	var db database.Datastore
 	var dbError error
 	for attempts := 1; attempts <= MaxDBConnectionAttempts; attempts++ {
-		db, dbError = database.Open(config.Database)
+		db, dbError = database.Open(cfg)
 		if dbError == nil {
 			break
 		}
@@ -128,42 +201,146 @@ func Boot(config *Config) {
 		time.Sleep(time.Duration(attempts) * time.Second)
 	}
 	if dbError != nil {
-		log.Fatal(dbError)
+		log.WithError(dbError).Fatal("could not connect to database")
 	}
 
+	return db
+}
+
+// Boot starts Clair instance with the provided config.
+func Boot(config *Config, configPath string) {
+	rand.Seed(time.Now().UnixNano())
+	st := stopper.NewStopper()
+	healthSt := stopper.NewStopper()
+
+	pagination.SetTokenLifetime(config.API.PaginationTTL, config.API.PaginationClockSkew)
+	db := openDatabase(config.Database)
 	defer db.Close()
 
-	clair.InitWorker(db)
+	clair.SetAllowlist(config.Allowlist)
+	go reloadAllowlistOnSignal(configPath)
+
+	clair.InitWorker(config.Worker, db)
 	// Start notifier
 	st.Begin()
 	go clair.RunNotifier(config.Notifier, db, st)
 
 	// Start API
-	go api.Run(config.API, db)
-
 	st.Begin()
-	go api.RunHealth(config.API, db, st)
+	go api.Run(config.API, db, st)
+
+	// The health API fails its checks while the other services stop, and
+	// stops last.
+	healthSt.Begin()
+	go api.RunHealth(config.API, db, st.Chan(), healthSt)
 
 	// Start updater
 	st.Begin()
 	go clair.RunUpdater(config.Updater, db, st)
 
-	// Wait for interruption and shutdown gracefully.
+	// Wait for interruption and shutdown gracefully, letting the in-flight
+	// requests and the current update finish before closing the database.
 	waitForSignals(syscall.SIGINT, syscall.SIGTERM)
-	log.Info("Received interruption, gracefully stopping ...")
-	st.Stop()
+	log.WithField("timeout", config.ShutdownTimeout).Info("Received interruption, gracefully stopping ...")
+	if !st.StopTimeout(config.ShutdownTimeout) {
+		log.Warning("could not stop gracefully within the shutdown timeout")
+	}
+	healthSt.Stop()
+}
+
+// transferVulnerabilities exports the vulnerabilities of the database to the
+// archive at exportPath, or imports the ones of the archive at importPath, and
+// exits without starting Clair.
+func transferVulnerabilities(config *Config, exportPath, importPath string) {
+	if exportPath != "" && importPath != "" {
+		log.Fatal("-export-vulnerabilities and -import-vulnerabilities are mutually exclusive")
+	}
+
+	db := openDatabase(config.Database)
+
+	var err error
+	if exportPath != "" {
+		err = exportVulnerabilities(db, exportPath)
+	} else {
+		err = importVulnerabilities(db, importPath)
+	}
+	db.Close()
+
+	if err != nil {
+		log.WithError(err).Fatal("could not transfer vulnerabilities")
+	}
+	os.Exit(0)
 }
 
-// Initialize logging system
-func configureLogger(flagLogLevel *string) {
-	logLevel, err := log.ParseLevel(strings.ToUpper(*flagLogLevel))
+// exportVulnerabilities writes the archive of the vulnerabilities of the
+// database to path, which is only replaced once the archive is complete.
+func exportVulnerabilities(db database.Datastore, path string) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".")
 	if err != nil {
-		log.WithError(err).Error("failed to set logger parser level")
+		return err
 	}
+	defer os.Remove(f.Name())
+	defer f.Close()
 
-	log.SetLevel(logLevel)
-	log.SetOutput(os.Stdout)
-	log.SetFormatter(&formatter.JSONExtendedFormatter{ShowLn: true})
+	if err := clair.ExportVulnerabilities(db, f); err != nil {
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// importVulnerabilities loads the archive of vulnerabilities at path into the
+// database.
+func importVulnerabilities(db database.Datastore, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return clair.ImportVulnerabilities(db, f)
+}
+
+// configureLogger initializes the logging system, with the level given on the
+// command line taking precedence over the configured one.
+func configureLogger(cfg *LogConfig, flagLogLevel *string) {
+	format, level := "json", *flagLogLevel
+	if cfg != nil {
+		format = cfg.Format
+		if !isFlagSet("log-level") {
+			level = cfg.Level
+		}
+	}
+
+	if err := logutil.Configure(format, level); err != nil {
+		log.WithError(err).Error("failed to configure logger")
+	}
+}
+
+// validateConfig loads the configuration file at path, without opening the
+// database nor starting any service, and exits with a non-zero status if it is
+// invalid.
+func validateConfig(path string) {
+	if _, err := LoadConfig(path); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s: configuration is valid\n", path)
+	os.Exit(0)
+}
+
+// isFlagSet returns whether the named flag is set on the command line.
+func isFlagSet(name string) (set bool) {
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return
 }
 
 func main() {
@@ -173,9 +350,17 @@ func main() {
 	flagCPUProfilePath := flag.String("cpu-profile", "", "Write a CPU profile to the specified file before exiting.")
 	flagLogLevel := flag.String("log-level", "info", "Define the logging level.")
 	flagInsecureTLS := flag.Bool("insecure-tls", false, "Disable TLS server's certificate chain and hostname verification when pulling layers.")
+	flagUpdaterDryRun := flag.Bool("updater-dry-run", false, "Fetch vulnerabilities once and log the changes without writing them to the database.")
+	flagValidateConfig := flag.Bool("validate-config", false, "Validate the configuration file and exit without starting Clair.")
+	flagExportVulnerabilities := flag.String("export-vulnerabilities", "", "Export the vulnerabilities of the database to the specified archive and exit without starting Clair.")
+	flagImportVulnerabilities := flag.String("import-vulnerabilities", "", "Import the vulnerabilities of the specified archive, written with -export-vulnerabilities, into the database and exit without starting Clair.")
 	flag.Parse()
 
-	configureLogger(flagLogLevel)
+	configureLogger(nil, flagLogLevel)
+	if *flagValidateConfig {
+		validateConfig(*flagConfigPath)
+	}
+
 	// Check for dependencies.
 	for _, bin := range BinaryDependencies {
 		_, err := exec.LookPath(bin)
@@ -188,6 +373,21 @@ func main() {
 	if err != nil {
 		log.WithError(err).Fatal("failed to load configuration")
 	}
+	configureLogger(config.Log, flagLogLevel)
+
+	// The version formats are set before opening the database, which compares
+	// the versions of the features and vulnerabilities that it inserts.
+	if err := versionfmt.SetNamespaceFormats(config.VersionFormats); err != nil {
+		log.WithError(err).Fatal("failed to set the version formats of the namespaces")
+	}
+
+	if *flagExportVulnerabilities != "" || *flagImportVulnerabilities != "" {
+		transferVulnerabilities(config, *flagExportVulnerabilities, *flagImportVulnerabilities)
+	}
+
+	if *flagUpdaterDryRun && config.Updater != nil {
+		config.Updater.DryRun = true
+	}
 
 	// Enable CPU Profiling if specified
 	if *flagCPUProfilePath != "" {
@@ -203,5 +403,5 @@ func main() {
 	// configure updater and worker
 	configClairVersion(config)
 
-	Boot(config)
+	Boot(config, *flagConfigPath)
 }
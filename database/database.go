@@ -41,6 +41,11 @@ var (
 	// entity doesn't exist in the database. This error can indicate a wrong
 	// implementation or corrupted database.
 	ErrMissingEntities = errors.New("database: associated immutable entities are missing in the database")
+
+	// ErrReferenced is an error that occurs when an entity cannot be removed
+	// because other entities still reference it, e.g. a layer contained in an
+	// ancestry.
+	ErrReferenced = errors.New("database: the entity is still referenced by other entities")
 )
 
 // RegistrableComponentConfig is a configuration block that can be used to
@@ -80,6 +85,15 @@ func Open(cfg RegistrableComponentConfig) (Datastore, error) {
 	return driver(cfg)
 }
 
+// ListDrivers returns the names of the registered database drivers.
+func ListDrivers() []string {
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	return names
+}
+
 // Session contains the required operations on a persistent data store for a
 // Clair deployment.
 //
@@ -107,6 +121,10 @@ type Session interface {
 	// namespaced features. If the ancestry is not found, return false.
 	FindAncestry(name string) (ancestry Ancestry, found bool, err error)
 
+	// DeleteAncestry removes an ancestry, whose layers are kept. It returns
+	// commonerr.ErrNotFound if the ancestry is not in the database.
+	DeleteAncestry(name string) error
+
 	// PersistDetector inserts a slice of detectors if not in the database.
 	PersistDetectors(detectors []Detector) error
 
@@ -128,9 +146,33 @@ type Session interface {
 	// with affecting vulnerabilities.
 	FindAffectedNamespacedFeatures(features []NamespacedFeature) ([]NullableAffectedNamespacedFeature, error)
 
+	// FindAffectedNamespacedFeatureSeverities retrieves a set of namespaced
+	// features with affecting vulnerabilities like
+	// FindAffectedNamespacedFeatures, but only with the name, namespace,
+	// severity, fixed in version and tag of the vulnerabilities.
+	FindAffectedNamespacedFeatureSeverities(features []NamespacedFeature) ([]NullableAffectedNamespacedFeature, error)
+
+	// FindFeatureLocations retrieves a page of at most limit locations of the
+	// feature with the given name and version in the ancestries, ordered by
+	// their insertion. If namespace is not empty, only the locations of the
+	// feature in this namespace are retrieved.
+	//
+	// The page is specified by the pagination token, which should be
+	// considered first page when it's empty. The returned token of the next
+	// page is empty when there are no more locations.
+	FindFeatureLocations(name, version, namespace string, limit int, page pagination.Token) (locations []FeatureLocation, nextPage pagination.Token, err error)
+
 	// PersistNamespaces inserts a set of namespaces if not in the database.
 	PersistNamespaces([]Namespace) error
 
+	// FindNamespaces retrieves a page of at most limit namespaces whose name
+	// starts with prefix, ordered by their insertion.
+	//
+	// The page is specified by the pagination token, which should be
+	// considered first page when it's empty. The returned token of the next
+	// page is empty when there are no more namespaces.
+	FindNamespaces(prefix string, limit int, page pagination.Token) (namespaces []Namespace, nextPage pagination.Token, err error)
+
 	// PersistLayer appends a layer's content in the database.
 	//
 	// If any feature, namespace, or detector is not in the database, it returns not found error.
@@ -140,6 +182,16 @@ type Session interface {
 	// namespaces.
 	FindLayer(hash string) (layer Layer, found bool, err error)
 
+	// DeleteLayer removes a layer with its detected features and namespaces.
+	// It returns commonerr.ErrNotFound if the layer is not in the database and
+	// ErrReferenced if an ancestry still contains it.
+	DeleteLayer(hash string) error
+
+	// DeleteAnalysesBefore removes the ancestries analyzed before the given
+	// time, then the layers analyzed before it that no ancestry contains
+	// anymore, and returns the names and hashes of the removed ones.
+	DeleteAnalysesBefore(before time.Time) (ancestries []string, layers []string, err error)
+
 	// InsertVulnerabilities inserts a set of UNIQUE vulnerabilities with
 	// affected features into database, assuming that all vulnerabilities
 	// provided are NOT in database and all vulnerabilities' namespaces are
@@ -150,10 +202,34 @@ type Session interface {
 	// features.
 	FindVulnerabilities([]VulnerabilityID) ([]NullableVulnerability, error)
 
+	// WalkVulnerabilities calls fn with every vulnerability of the namespace,
+	// with its affected features, ordered by insertion. If since is not zero,
+	// only the vulnerabilities inserted after it are walked.
+	//
+	// The vulnerabilities are read from the datastore as they are walked, and
+	// the walk stops at the first error returned by fn.
+	WalkVulnerabilities(namespace string, since time.Time, fn func(VulnerabilityWithAffected) error) error
+
 	// DeleteVulnerability removes a set of Vulnerabilities assuming that the
 	// requested vulnerabilities are in the database.
 	DeleteVulnerabilities([]VulnerabilityID) error
 
+	// WithdrawVulnerabilities removes a set of vulnerabilities as
+	// DeleteVulnerabilities does, recording that their data source withdrew
+	// them for the given reason.
+	WithdrawVulnerabilities(vulnerabilities []VulnerabilityID, reason string) error
+
+	// WalkWithdrawnVulnerabilities calls fn with every withdrawn vulnerability
+	// of the namespace that has not been inserted again, with the affected
+	// features it had, ordered by withdrawal. If since is not zero, only the
+	// vulnerabilities withdrawn after it are walked.
+	WalkWithdrawnVulnerabilities(namespace string, since time.Time, fn func(VulnerabilityWithAffected) error) error
+
+	// UpdateVulnerabilityMetadata sets the metadata stored under the given key
+	// of every vulnerability with the given name, in all namespaces. It does
+	// nothing if there is no such vulnerability.
+	UpdateVulnerabilityMetadata(name, key string, metadata interface{}) error
+
 	// InsertVulnerabilityNotifications inserts a set of unique vulnerability
 	// notifications into datastore, assuming that they are not in the database.
 	InsertVulnerabilityNotifications([]VulnerabilityNotification) error
@@ -177,6 +253,24 @@ type Session interface {
 	// DeleteNotification removes a Notification in the database.
 	DeleteNotification(name string) error
 
+	// FindPendingNotifications retrieves a page of at most limit pending
+	// notifications, which have neither been notified nor deleted, ordered by
+	// their creation.
+	//
+	// The page is specified by the pagination token, which should be
+	// considered first page when it's empty. The returned token of the next
+	// page is empty when there are no more notifications.
+	FindPendingNotifications(limit int, page pagination.Token) (notifications []NotificationHook, nextPage pagination.Token, err error)
+
+	// CountPendingNotifications returns the number of pending notifications
+	// and the creation time of the oldest one, which is zero when there is
+	// none.
+	CountPendingNotifications() (count int, oldest time.Time, err error)
+
+	// DeletePendingNotificationsBefore removes the pending notifications
+	// created before the given time, and returns their names.
+	DeletePendingNotificationsBefore(before time.Time) (names []string, err error)
+
 	// UpdateKeyValue stores or updates a simple key/value pair.
 	UpdateKeyValue(key, value string) error
 
@@ -154,6 +154,26 @@ func DeduplicateNamespacedFeatures(features []NamespacedFeature) []NamespacedFea
 	return uniqueFeatures
 }
 
+// GetAffectedRanges returns the ranges of versions of the given affected
+// features, which are the ones of a vulnerability for a feature, unless they
+// are only bound by the version fixing the vulnerability.
+func GetAffectedRanges(affected []AffectedFeature) []AffectedRange {
+	if len(affected) == 0 || len(affected) == 1 && affected[0].IntroducedInVersion == "" && affected[0].LastAffectedVersion == "" {
+		return nil
+	}
+
+	ranges := make([]AffectedRange, 0, len(affected))
+	for _, af := range affected {
+		ranges = append(ranges, AffectedRange{
+			IntroducedInVersion: af.IntroducedInVersion,
+			FixedInVersion:      af.FixedInVersion,
+			LastAffectedVersion: af.LastAffectedVersion,
+		})
+	}
+
+	return ranges
+}
+
 // GetAncestryFeatures returns a list of unique namespaced features in the
 // ancestry.
 func GetAncestryFeatures(ancestry Ancestry) []NamespacedFeature {
@@ -0,0 +1,398 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/pagination"
+)
+
+type namespaceRow struct {
+	id        int64
+	namespace database.Namespace
+}
+
+// affectedKey identifies a namespaced feature affected by a vulnerability.
+type affectedKey struct {
+	vulnerabilityID int64
+	feature         database.NamespacedFeature
+}
+
+func (tx *memSession) PersistNamespaces(namespaces []database.Namespace) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	for _, ns := range namespaces {
+		if ns.Name == "" || ns.VersionFormat == "" {
+			return commonerr.NewBadRequestError("Empty namespace name or version format is not allowed")
+		}
+	}
+
+	for _, ns := range namespaces {
+		if _, ok := tx.findNamespaceID(ns); !ok {
+			tx.namespaces = append(tx.namespaces, namespaceRow{tx.nextID(), ns})
+		}
+	}
+
+	return nil
+}
+
+// FindNamespaces retrieves a page of the namespaces whose name starts with the
+// given prefix.
+func (tx *memSession) FindNamespaces(prefix string, limit int, pageToken pagination.Token) ([]database.Namespace, pagination.Token, error) {
+	if tx.done {
+		return nil, pagination.FirstPageToken, database.ErrBackendException
+	}
+
+	if limit <= 0 {
+		return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("namespace page limit should be positive")
+	}
+
+	current := page{0}
+	if pageToken != pagination.FirstPageToken {
+		if err := tx.key.UnmarshalToken(pageToken, &current); err == pagination.ErrExpiredToken || err == pagination.ErrFutureToken {
+			return nil, pagination.FirstPageToken, err
+		} else if err != nil {
+			return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("invalid namespace page token")
+		}
+	}
+
+	namespaces := []database.Namespace{}
+	for _, row := range tx.namespaces {
+		if row.id < current.StartID || !strings.HasPrefix(row.namespace.Name, prefix) {
+			continue
+		}
+
+		if len(namespaces) == limit {
+			nextPage, err := tx.key.MarshalToken(page{row.id})
+			if err != nil {
+				return nil, pagination.FirstPageToken, err
+			}
+			return namespaces, nextPage, nil
+		}
+		namespaces = append(namespaces, row.namespace)
+	}
+
+	return namespaces, pagination.FirstPageToken, nil
+}
+
+func (tx *memSession) findNamespaceID(namespace database.Namespace) (int64, bool) {
+	for _, row := range tx.namespaces {
+		if row.namespace == namespace {
+			return row.id, true
+		}
+	}
+	return 0, false
+}
+
+func (tx *memSession) PersistFeatures(features []database.Feature) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	for _, f := range features {
+		if f.Name == "" || f.Version == "" || f.VersionFormat == "" {
+			return commonerr.NewBadRequestError("Empty feature name, version or version format is not allowed")
+		}
+	}
+
+	for _, f := range features {
+		tx.features[f] = struct{}{}
+	}
+
+	return nil
+}
+
+func (tx *memSession) PersistNamespacedFeatures(features []database.NamespacedFeature) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	for _, f := range features {
+		if _, ok := tx.features[f.Feature]; !ok {
+			return database.ErrMissingEntities
+		}
+
+		if _, ok := tx.findNamespaceID(f.Namespace); !ok {
+			return database.ErrMissingEntities
+		}
+	}
+
+	for _, f := range features {
+		tx.namespacedFeatures[f] = struct{}{}
+	}
+
+	return nil
+}
+
+// hasNamespacedFeature returns whether the namespaced feature is persisted.
+//
+// Like in the PostgreSQL implementation, a namespaced feature is only found
+// when the version format of its namespace is the one of its feature.
+func (tx *memSession) hasNamespacedFeature(feature database.NamespacedFeature) bool {
+	if feature.Namespace.VersionFormat != feature.VersionFormat {
+		return false
+	}
+
+	_, ok := tx.namespacedFeatures[feature]
+	return ok
+}
+
+// CacheAffectedNamespacedFeatures relates the namespaced features with the
+// vulnerabilities of their namespace whose affected version range contains
+// their version.
+func (tx *memSession) CacheAffectedNamespacedFeatures(features []database.NamespacedFeature) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	for _, f := range features {
+		if !tx.hasNamespacedFeature(f) {
+			return database.ErrMissingEntities
+		}
+	}
+
+	for _, f := range features {
+		for _, row := range tx.vulnerabilities {
+			if !row.deleted.IsZero() || row.vulnerability.Namespace != f.Namespace {
+				continue
+			}
+
+			if err := tx.cacheAffected(row, f); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// cacheAffected relates the namespaced feature with the vulnerability if any
+// of its affected features, named after the feature or else after its source
+// package, contains it.
+func (tx *memSession) cacheAffected(row vulnerabilityRow, feature database.NamespacedFeature) error {
+	key := affectedKey{row.id, feature}
+	if _, ok := tx.affected[key]; ok {
+		return nil
+	}
+
+	ok, err := tx.cacheAffectedByName(row, feature, feature.Name, feature.Version)
+	if err != nil || ok || feature.SourceName == "" || feature.SourceVersion == "" || feature.SourceName == feature.Name {
+		return err
+	}
+
+	// The source package is compared by its own version, which may differ
+	// from the one of the feature.
+	_, err = tx.cacheAffectedByName(row, feature, feature.SourceName, feature.SourceVersion)
+	return err
+}
+
+// cacheAffectedByName relates the namespaced feature with the vulnerability if
+// any of its affected features with the given name contains the given version.
+func (tx *memSession) cacheAffectedByName(row vulnerabilityRow, feature database.NamespacedFeature, name, version string) (bool, error) {
+	for _, af := range row.vulnerability.Affected {
+		if af.FeatureName != name {
+			continue
+		}
+
+		in, err := versionfmt.InAffectedRange(versionfmt.NamespaceFormat(feature.Namespace.Name, feature.VersionFormat), version, af.IntroducedInVersion, af.LastAffectedVersion, af.AffectedVersion)
+		if err != nil {
+			return false, err
+		}
+
+		if in {
+			tx.affected[affectedKey{row.id, feature}] = af
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// FindAffectedNamespacedFeatures retrieves the cached vulnerabilities
+// affecting the features.
+func (tx *memSession) FindAffectedNamespacedFeatures(features []database.NamespacedFeature) ([]database.NullableAffectedNamespacedFeature, error) {
+	if tx.done {
+		return nil, database.ErrBackendException
+	}
+
+	if len(features) == 0 {
+		return nil, nil
+	}
+
+	affectedFeatures := make([]database.NullableAffectedNamespacedFeature, len(features))
+	for i, f := range features {
+		affectedFeatures[i].NamespacedFeature = f
+		if !tx.hasNamespacedFeature(f) {
+			continue
+		}
+		affectedFeatures[i].Valid = true
+
+		for _, row := range tx.vulnerabilities {
+			af, ok := tx.affected[affectedKey{row.id, f}]
+			if !ok || !row.deleted.IsZero() {
+				continue
+			}
+
+			vulnerability, err := row.find()
+			if err != nil {
+				return nil, err
+			}
+
+			// The ranges are the ones of the package through which the
+			// feature is affected.
+			var ranges []database.AffectedFeature
+			for _, other := range row.vulnerability.Affected {
+				if other.FeatureName == af.FeatureName {
+					ranges = append(ranges, other)
+				}
+			}
+
+			affectedFeatures[i].AffectedBy = append(affectedFeatures[i].AffectedBy, database.VulnerabilityWithFixedIn{
+				Vulnerability:  vulnerability.Vulnerability,
+				FixedInVersion: af.FixedInVersion,
+				Tag:            af.Tag,
+				AffectedRanges: database.GetAffectedRanges(ranges),
+			})
+		}
+	}
+
+	return affectedFeatures, nil
+}
+
+// FindAffectedNamespacedFeatureSeverities retrieves the cached
+// vulnerabilities affecting the features without copying their description,
+// link, metadata and affected ranges.
+func (tx *memSession) FindAffectedNamespacedFeatureSeverities(features []database.NamespacedFeature) ([]database.NullableAffectedNamespacedFeature, error) {
+	if tx.done {
+		return nil, database.ErrBackendException
+	}
+
+	if len(features) == 0 {
+		return nil, nil
+	}
+
+	affectedFeatures := make([]database.NullableAffectedNamespacedFeature, len(features))
+	for i, f := range features {
+		affectedFeatures[i].NamespacedFeature = f
+		if !tx.hasNamespacedFeature(f) {
+			continue
+		}
+		affectedFeatures[i].Valid = true
+
+		for _, row := range tx.vulnerabilities {
+			af, ok := tx.affected[affectedKey{row.id, f}]
+			if !ok || !row.deleted.IsZero() {
+				continue
+			}
+
+			affectedFeatures[i].AffectedBy = append(affectedFeatures[i].AffectedBy, database.VulnerabilityWithFixedIn{
+				Vulnerability: database.Vulnerability{
+					Name:      row.vulnerability.Name,
+					Namespace: row.vulnerability.Namespace,
+					Severity:  row.vulnerability.Severity,
+				},
+				FixedInVersion: af.FixedInVersion,
+				Tag:            af.Tag,
+			})
+		}
+	}
+
+	return affectedFeatures, nil
+}
+
+// featureLocation is a feature location with the ID ordering it.
+type featureLocation struct {
+	id       int64
+	location database.FeatureLocation
+}
+
+func (tx *memSession) FindFeatureLocations(name, version, namespace string, limit int, pageToken pagination.Token) ([]database.FeatureLocation, pagination.Token, error) {
+	if tx.done {
+		return nil, pagination.FirstPageToken, database.ErrBackendException
+	}
+
+	if name == "" || version == "" {
+		return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("feature name and version should not be empty")
+	}
+
+	if limit <= 0 {
+		return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("feature location page limit should be positive")
+	}
+
+	current := page{0}
+	if pageToken != pagination.FirstPageToken {
+		if err := tx.key.UnmarshalToken(pageToken, &current); err == pagination.ErrExpiredToken || err == pagination.ErrFutureToken {
+			return nil, pagination.FirstPageToken, err
+		} else if err != nil {
+			return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("invalid feature location page token")
+		}
+	}
+
+	var matches []featureLocation
+	for _, row := range tx.ancestries {
+		for i, layer := range row.ancestry.Layers {
+			for j, f := range layer.Features {
+				id := row.featureIDs[i][j]
+				if id < current.StartID || f.Name != name || f.Version != version || (namespace != "" && f.Namespace.Name != namespace) {
+					continue
+				}
+
+				matches = append(matches, featureLocation{id, database.FeatureLocation{
+					NamespacedFeature: database.NamespacedFeature{
+						Feature: database.Feature{
+							Name:          f.Name,
+							Version:       f.Version,
+							VersionFormat: f.VersionFormat,
+						},
+						Namespace: f.Namespace,
+					},
+					AncestryName: row.ancestry.Name,
+					LayerHash:    layer.Hash,
+				}})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].id < matches[j].id })
+
+	locations := []database.FeatureLocation{}
+	for _, m := range matches {
+		if len(locations) == limit {
+			nextPage, err := tx.key.MarshalToken(page{m.id})
+			if err != nil {
+				return nil, pagination.FirstPageToken, err
+			}
+			return locations, nextPage, nil
+		}
+		locations = append(locations, m.location)
+	}
+
+	return locations, pagination.FirstPageToken, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/commonerr"
+)
+
+type lockRow struct {
+	owner string
+	until time.Time
+}
+
+func (tx *memSession) UpdateKeyValue(key, value string) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	if key == "" || value == "" {
+		log.Warning("could not insert a flag which has an empty name or value")
+		return commonerr.NewBadRequestError("could not insert a flag which has an empty name or value")
+	}
+
+	tx.keyValues[key] = value
+	return nil
+}
+
+func (tx *memSession) FindKeyValue(key string) (string, bool, error) {
+	if tx.done {
+		return "", false, database.ErrBackendException
+	}
+
+	value, ok := tx.keyValues[key]
+	return value, ok, nil
+}
+
+// Lock tries to set a temporary lock.
+//
+// Lock does not block, instead, it returns true and its expiration time
+// is the lock has been successfully acquired or false otherwise.
+func (tx *memSession) Lock(name string, owner string, duration time.Duration, renew bool) (bool, time.Time, error) {
+	if tx.done {
+		return false, time.Time{}, database.ErrBackendException
+	}
+
+	tx.write()
+
+	if name == "" || owner == "" || duration == 0 {
+		log.Warning("could not create an invalid lock")
+		return false, time.Time{}, commonerr.NewBadRequestError("Invalid Lock Parameters")
+	}
+
+	until := time.Now().Add(duration)
+	if renew {
+		lock, ok := tx.locks[name]
+		if !ok || lock.owner != owner {
+			return false, until, nil
+		}
+
+		tx.locks[name] = lockRow{owner, until}
+		return true, until, nil
+	}
+
+	// The expired locks are removed before locking, as they would prevent it
+	// otherwise.
+	now := time.Now()
+	for n, lock := range tx.locks {
+		if lock.until.Before(now) {
+			delete(tx.locks, n)
+		}
+	}
+
+	if _, ok := tx.locks[name]; ok {
+		return false, until, nil
+	}
+
+	tx.locks[name] = lockRow{owner, until}
+	return true, until, nil
+}
+
+// Unlock unlocks a lock specified by its name if I own it
+func (tx *memSession) Unlock(name, owner string) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	if name == "" || owner == "" {
+		return commonerr.NewBadRequestError("Invalid Lock Parameters")
+	}
+
+	if lock, ok := tx.locks[name]; ok && lock.owner == owner {
+		delete(tx.locks, name)
+	}
+
+	return nil
+}
+
+// FindLock returns the owner of a lock specified by its name and its
+// expiration time.
+func (tx *memSession) FindLock(name string) (string, time.Time, bool, error) {
+	if tx.done {
+		return "", time.Time{}, false, database.ErrBackendException
+	}
+
+	if name == "" {
+		return "", time.Time{}, false, commonerr.NewBadRequestError("could not find an invalid lock")
+	}
+
+	lock, ok := tx.locks[name]
+	if !ok {
+		return "", time.Time{}, false, commonerr.ErrNotFound
+	}
+
+	return lock.owner, lock.until, true, nil
+}
@@ -0,0 +1,382 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem
+
+import (
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/commonerr"
+)
+
+type layerRow struct {
+	// created is when the layer was first persisted.
+	created    time.Time
+	by         []database.Detector
+	features   []database.LayerFeature
+	namespaces []database.LayerNamespace
+}
+
+type ancestryRow struct {
+	id       int64
+	created  time.Time
+	ancestry database.Ancestry
+	// featureIDs are the IDs of the features of every layer of the ancestry,
+	// which order the feature locations.
+	featureIDs [][]int64
+}
+
+func (tx *memSession) PersistDetectors(detectors []database.Detector) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	for _, d := range detectors {
+		if !d.Valid() {
+			log.WithField("detector", d).Debug("Invalid Detector")
+			return database.ErrInvalidParameters
+		}
+	}
+
+	for _, d := range detectors {
+		tx.detectors[d] = struct{}{}
+	}
+
+	return nil
+}
+
+func (tx *memSession) FindLayer(hash string) (database.Layer, bool, error) {
+	layer := database.Layer{Hash: hash}
+	if tx.done {
+		return layer, false, database.ErrBackendException
+	}
+
+	if hash == "" {
+		return layer, false, commonerr.NewBadRequestError("non empty layer hash is expected.")
+	}
+
+	row, ok := tx.layers[hash]
+	if !ok {
+		return layer, false, nil
+	}
+
+	layer.By = append([]database.Detector{}, row.by...)
+	layer.Features = append([]database.LayerFeature{}, row.features...)
+	layer.Namespaces = append([]database.LayerNamespace{}, row.namespaces...)
+	return layer, true, nil
+}
+
+// PersistLayer saves the content of a layer, which is merged with the content
+// already saved.
+func (tx *memSession) PersistLayer(hash string, features []database.LayerFeature, namespaces []database.LayerNamespace, detectedBy []database.Detector) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	if hash == "" {
+		return commonerr.NewBadRequestError("expected non-empty layer hash")
+	}
+
+	detected := make(map[database.Detector]struct{}, len(detectedBy))
+	for _, d := range detectedBy {
+		detected[d] = struct{}{}
+	}
+
+	for _, f := range features {
+		if _, ok := detected[f.By]; !ok {
+			return database.ErrInvalidParameters
+		}
+	}
+
+	for _, n := range namespaces {
+		if _, ok := detected[n.By]; !ok {
+			return database.ErrInvalidParameters
+		}
+	}
+
+	for _, d := range detectedBy {
+		if _, ok := tx.detectors[d]; !ok {
+			return database.ErrMissingEntities
+		}
+	}
+
+	for _, f := range features {
+		if _, ok := tx.features[f.Feature]; !ok {
+			return database.ErrMissingEntities
+		}
+	}
+
+	for _, n := range namespaces {
+		if _, ok := tx.findNamespaceID(n.Namespace); !ok {
+			return database.ErrMissingEntities
+		}
+	}
+
+	// The detectors, features and namespaces that the layer already has are
+	// kept as they are.
+	old, ok := tx.layers[hash]
+	if !ok {
+		old.created = tx.now
+	}
+
+	var (
+		row = layerRow{
+			created:    old.created,
+			by:         append([]database.Detector{}, old.by...),
+			features:   append([]database.LayerFeature{}, old.features...),
+			namespaces: append([]database.LayerNamespace{}, old.namespaces...),
+		}
+	)
+
+	for _, d := range detectedBy {
+		if !containsDetector(row.by, d) {
+			row.by = append(row.by, d)
+		}
+	}
+
+	for _, f := range features {
+		if !containsFeature(row.features, f.Feature) {
+			row.features = append(row.features, f)
+		}
+	}
+
+	for _, n := range namespaces {
+		if !containsNamespace(row.namespaces, n.Namespace) {
+			row.namespaces = append(row.namespaces, n)
+		}
+	}
+
+	tx.layers[hash] = row
+	return nil
+}
+
+func (tx *memSession) FindAncestry(name string) (database.Ancestry, bool, error) {
+	ancestry := database.Ancestry{Name: name}
+	if tx.done {
+		return ancestry, false, database.ErrBackendException
+	}
+
+	row, ok := tx.ancestries[name]
+	if !ok {
+		return ancestry, false, nil
+	}
+
+	ancestry.By = append([]database.Detector{}, row.ancestry.By...)
+	ancestry.Layers = make([]database.AncestryLayer, 0, len(row.ancestry.Layers))
+	for _, layer := range row.ancestry.Layers {
+		ancestry.Layers = append(ancestry.Layers, database.AncestryLayer{
+			Hash:     layer.Hash,
+			Features: append([]database.AncestryFeature(nil), layer.Features...),
+		})
+	}
+
+	return ancestry, true, nil
+}
+
+func (tx *memSession) UpsertAncestry(ancestry database.Ancestry) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	if !ancestry.Valid() {
+		return database.ErrInvalidParameters
+	}
+
+	for _, d := range ancestry.By {
+		if _, ok := tx.detectors[d]; !ok {
+			return database.ErrMissingEntities
+		}
+	}
+
+	for _, layer := range ancestry.Layers {
+		if _, ok := tx.layers[layer.Hash]; !ok {
+			log.Error("layer cannot be found, this indicates that the internal logic of calling UpsertAncestry is wrong or the database is corrupted.")
+			return database.ErrMissingEntities
+		}
+
+		for _, f := range layer.Features {
+			if !tx.hasNamespacedFeature(f.NamespacedFeature) {
+				return database.ErrMissingEntities
+			}
+
+			if _, ok := tx.detectors[f.FeatureBy]; !ok {
+				return database.ErrMissingEntities
+			}
+
+			if _, ok := tx.detectors[f.NamespaceBy]; !ok {
+				return database.ErrMissingEntities
+			}
+		}
+	}
+
+	// The ancestry is replaced by a new one, which is the last one in the
+	// insertion order.
+	row := ancestryRow{
+		id:      tx.nextID(),
+		created: tx.now,
+		ancestry: database.Ancestry{
+			Name:   ancestry.Name,
+			Layers: make([]database.AncestryLayer, 0, len(ancestry.Layers)),
+		},
+		featureIDs: make([][]int64, 0, len(ancestry.Layers)),
+	}
+
+	for _, d := range ancestry.By {
+		if !containsDetector(row.ancestry.By, d) {
+			row.ancestry.By = append(row.ancestry.By, d)
+		}
+	}
+
+	for _, layer := range ancestry.Layers {
+		var (
+			features = make([]database.AncestryFeature, 0, len(layer.Features))
+			ids      = make([]int64, 0, len(layer.Features))
+			seen     = make(map[database.NamespacedFeature]struct{}, len(layer.Features))
+		)
+
+		for _, f := range layer.Features {
+			if _, ok := seen[f.NamespacedFeature]; ok {
+				continue
+			}
+			seen[f.NamespacedFeature] = struct{}{}
+
+			features = append(features, f)
+			ids = append(ids, tx.nextID())
+		}
+
+		row.ancestry.Layers = append(row.ancestry.Layers, database.AncestryLayer{Hash: layer.Hash, Features: features})
+		row.featureIDs = append(row.featureIDs, ids)
+	}
+
+	tx.ancestries[ancestry.Name] = row
+	return nil
+}
+
+func (tx *memSession) DeleteAncestry(name string) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	if name == "" {
+		return commonerr.NewBadRequestError("Empty ancestry name is not allowed")
+	}
+
+	if _, ok := tx.ancestries[name]; !ok {
+		return commonerr.ErrNotFound
+	}
+
+	delete(tx.ancestries, name)
+	return nil
+}
+
+func (tx *memSession) DeleteLayer(hash string) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	if hash == "" {
+		return commonerr.NewBadRequestError("non empty layer hash is expected.")
+	}
+
+	if _, ok := tx.layers[hash]; !ok {
+		return commonerr.ErrNotFound
+	}
+
+	if tx.isLayerInAncestry(hash) {
+		return database.ErrReferenced
+	}
+
+	delete(tx.layers, hash)
+	return nil
+}
+
+func (tx *memSession) DeleteAnalysesBefore(before time.Time) ([]string, []string, error) {
+	if tx.done {
+		return nil, nil, database.ErrBackendException
+	}
+
+	tx.write()
+
+	var ancestries, layers []string
+	for name, row := range tx.ancestries {
+		if row.created.Before(before) {
+			delete(tx.ancestries, name)
+			ancestries = append(ancestries, name)
+		}
+	}
+
+	for hash, row := range tx.layers {
+		if row.created.Before(before) && !tx.isLayerInAncestry(hash) {
+			delete(tx.layers, hash)
+			layers = append(layers, hash)
+		}
+	}
+
+	sort.Strings(ancestries)
+	sort.Strings(layers)
+	return ancestries, layers, nil
+}
+
+// isLayerInAncestry returns whether an ancestry contains the layer.
+func (tx *memSession) isLayerInAncestry(hash string) bool {
+	for _, row := range tx.ancestries {
+		for _, layer := range row.ancestry.Layers {
+			if layer.Hash == hash {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsDetector(detectors []database.Detector, d database.Detector) bool {
+	for _, o := range detectors {
+		if o == d {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFeature(features []database.LayerFeature, f database.Feature) bool {
+	for _, o := range features {
+		if o.Feature == f {
+			return true
+		}
+	}
+	return false
+}
+
+func containsNamespace(namespaces []database.LayerNamespace, n database.Namespace) bool {
+	for _, o := range namespaces {
+		if o.Namespace == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/pagination"
+)
+
+func TestPersistLayer(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	features := []database.LayerFeature{{Feature: testFeatures[0], By: testDetectors[1]}}
+	namespaces := []database.LayerNamespace{{Namespace: testNamespaces[0], By: testDetectors[0]}}
+
+	// The features and namespaces must be found by the given detectors.
+	assert.Equal(t, database.ErrInvalidParameters, tx.PersistLayer("layer", features, nil, nil))
+	assert.IsType(t, &commonerr.ErrBadRequest{}, tx.PersistLayer("", nil, nil, nil))
+
+	unknownDetector := database.NewFeatureDetector("rpm", "1.0")
+	assert.Equal(t, database.ErrMissingEntities, tx.PersistLayer("layer", nil, nil, []database.Detector{unknownDetector}))
+
+	// A layer is saved in several parts.
+	require.Nil(t, tx.PersistLayer("layer", nil, namespaces, testDetectors[:1]))
+	require.Nil(t, tx.PersistLayer("layer", features, nil, testDetectors[1:]))
+	tx = restartSession(t, store, tx, true)
+
+	layer, ok, err := tx.FindLayer("layer")
+	if assert.Nil(t, err) && assert.True(t, ok) {
+		database.AssertLayerEqual(t, &database.Layer{
+			Hash:       "layer",
+			By:         testDetectors,
+			Features:   features,
+			Namespaces: namespaces,
+		}, &layer)
+	}
+
+	_, ok, err = tx.FindLayer("unknown")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestUpsertAncestry(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	require.Nil(t, tx.PersistLayer("layer-0", nil, nil, testDetectors))
+	require.Nil(t, tx.PersistLayer("layer-1", nil, nil, testDetectors))
+
+	ancestry := database.Ancestry{
+		Name: "ancestry",
+		By:   testDetectors,
+		Layers: []database.AncestryLayer{
+			{Hash: "layer-0", Features: []database.AncestryFeature{
+				{NamespacedFeature: testNamespacedFeatures[0], FeatureBy: testDetectors[1], NamespaceBy: testDetectors[0]},
+			}},
+			{Hash: "layer-1", Features: []database.AncestryFeature{
+				{NamespacedFeature: testNamespacedFeatures[2], FeatureBy: testDetectors[1], NamespaceBy: testDetectors[0]},
+			}},
+		},
+	}
+
+	// The layers must be persisted.
+	missingLayer := ancestry
+	missingLayer.Layers = append(missingLayer.Layers, database.AncestryLayer{Hash: "layer-2"})
+	assert.Equal(t, database.ErrMissingEntities, tx.UpsertAncestry(missingLayer))
+	assert.Equal(t, database.ErrInvalidParameters, tx.UpsertAncestry(database.Ancestry{}))
+
+	require.Nil(t, tx.UpsertAncestry(ancestry))
+	tx = restartSession(t, store, tx, true)
+
+	found, ok, err := tx.FindAncestry("ancestry")
+	if assert.Nil(t, err) && assert.True(t, ok) {
+		database.AssertAncestryEqual(t, &ancestry, &found)
+	}
+
+	// The locations of the features are ordered by insertion.
+	locations, nextPage, err := tx.FindFeatureLocations("openssl", "1.0", "", 1, pagination.FirstPageToken)
+	if assert.Nil(t, err) && assert.Len(t, locations, 1) {
+		assert.Equal(t, "layer-0", locations[0].LayerHash)
+		assert.Equal(t, testNamespacedFeatures[0], locations[0].NamespacedFeature)
+	}
+
+	locations, nextPage, err = tx.FindFeatureLocations("openssl", "1.0", "", 1, nextPage)
+	if assert.Nil(t, err) && assert.Len(t, locations, 1) {
+		assert.Equal(t, "ancestry", locations[0].AncestryName)
+		assert.Equal(t, "layer-1", locations[0].LayerHash)
+		assert.Equal(t, pagination.FirstPageToken, nextPage)
+	}
+
+	locations, _, err = tx.FindFeatureLocations("openssl", "1.0", "debian:8", 10, pagination.FirstPageToken)
+	if assert.Nil(t, err) && assert.Len(t, locations, 1) {
+		assert.Equal(t, "layer-1", locations[0].LayerHash)
+	}
+
+	// The ancestry is replaced.
+	ancestry.Layers = ancestry.Layers[:1]
+	require.Nil(t, tx.UpsertAncestry(ancestry))
+
+	found, ok, err = tx.FindAncestry("ancestry")
+	if assert.Nil(t, err) && assert.True(t, ok) {
+		database.AssertAncestryEqual(t, &ancestry, &found)
+	}
+
+	_, ok, err = tx.FindAncestry("unknown")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestDeleteLayersAndAncestries(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	require.Nil(t, tx.PersistLayer("layer-0", nil, nil, testDetectors))
+	require.Nil(t, tx.PersistLayer("layer-1", nil, nil, testDetectors))
+	require.Nil(t, tx.PersistLayer("layer-2", nil, nil, testDetectors))
+	require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+		Name:   "ancestry",
+		By:     testDetectors,
+		Layers: []database.AncestryLayer{{Hash: "layer-0"}, {Hash: "layer-1"}},
+	}))
+	tx = restartSession(t, store, tx, true)
+
+	// The layers of an ancestry are kept until it is deleted.
+	assert.Equal(t, database.ErrReferenced, tx.DeleteLayer("layer-0"))
+	assert.Equal(t, commonerr.ErrNotFound, tx.DeleteLayer("unknown"))
+	assert.Nil(t, tx.DeleteLayer("layer-2"))
+
+	_, ok, err := tx.FindLayer("layer-2")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, tx.DeleteAncestry("ancestry"))
+	assert.Equal(t, commonerr.ErrNotFound, tx.DeleteAncestry("ancestry"))
+	assert.Nil(t, tx.DeleteLayer("layer-0"))
+
+	_, ok, err = tx.FindLayer("layer-1")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func TestDeleteAnalysesBefore(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	require.Nil(t, tx.PersistLayer("base", nil, nil, testDetectors))
+	require.Nil(t, tx.PersistLayer("old", nil, nil, testDetectors))
+	require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+		Name:   "old",
+		By:     testDetectors,
+		Layers: []database.AncestryLayer{{Hash: "base"}, {Hash: "old"}},
+	}))
+	tx = restartSession(t, store, tx, true)
+	before := time.Now()
+	tx = restartSession(t, store, tx, false)
+
+	// The base layer is shared with a newer ancestry, which keeps it.
+	require.Nil(t, tx.PersistLayer("new", nil, nil, testDetectors))
+	require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+		Name:   "new",
+		By:     testDetectors,
+		Layers: []database.AncestryLayer{{Hash: "base"}, {Hash: "new"}},
+	}))
+	tx = restartSession(t, store, tx, true)
+
+	ancestries, layers, err := tx.DeleteAnalysesBefore(before)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"old"}, ancestries)
+		assert.Equal(t, []string{"old"}, layers)
+	}
+
+	_, ok, err := tx.FindLayer("base")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = tx.FindAncestry("new")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,287 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mem implements database.Datastore in memory.
+//
+// It is meant for tests and ephemeral deployments: its content is lost when
+// the process exits and the sessions changing it are serialized, so a
+// goroutine must end a session that changed it before changing it in another
+// one, but it behaves like the PostgreSQL implementation otherwise.
+package mem
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/pagination"
+)
+
+func init() {
+	database.Register("mem", openDatabase)
+}
+
+// Config is the configuration that is used by openDatabase.
+type Config struct {
+	// PaginationKey is the key used to sign the pagination tokens, and
+	// PaginationKeys are previous keys that verify the tokens that they
+	// signed. The first of PaginationKeys signs the tokens when PaginationKey
+	// is empty, and a key is generated if both are empty.
+	PaginationKey  string
+	PaginationKeys []string
+}
+
+type memStore struct {
+	// mu protects state, which is replaced by the sessions committing their
+	// changes. A committed state is never changed, so that the sessions read
+	// it without holding mu.
+	mu    sync.RWMutex
+	state *state
+	// writer is held by the session changing the state, from its first change
+	// to its end, which makes the changes serializable.
+	writer sync.Mutex
+	key    pagination.Key
+	// closed is accessed atomically, as it is not protected by mu.
+	closed int32
+}
+
+type memSession struct {
+	store *memStore
+	// state is the store's state when the session began, until the session
+	// changes it: it is then a copy of the latest store's state, which
+	// replaces it on Commit.
+	*state
+	writing bool
+
+	key pagination.Key
+	// now is the time at which the session began, which is used as the
+	// creation and modification time of the rows like the CURRENT_TIMESTAMP of
+	// a PostgreSQL transaction.
+	now  time.Time
+	done bool
+}
+
+// page is the representation of a page, which starts with the row whose ID is
+// StartID.
+type page struct {
+	StartID int64
+}
+
+// state holds the rows of the datastore.
+//
+// The state is copied by copying its maps and slices, which are shared with
+// the copy, so the maps and slices of a row are replaced rather than changed
+// in place.
+type state struct {
+	// lastID is the ID of the last inserted row, which orders the rows by
+	// insertion.
+	lastID int64
+
+	detectors          map[database.Detector]struct{}
+	namespaces         []namespaceRow
+	features           map[database.Feature]struct{}
+	namespacedFeatures map[database.NamespacedFeature]struct{}
+	layers             map[string]layerRow
+	ancestries         map[string]ancestryRow
+	vulnerabilities    []vulnerabilityRow
+	// affected caches the affected features through which the namespaced
+	// features are affected by the vulnerabilities.
+	affected      map[affectedKey]database.AffectedFeature
+	notifications []notificationRow
+	keyValues     map[string]string
+	locks         map[string]lockRow
+}
+
+func newState() *state {
+	return &state{
+		detectors:          make(map[database.Detector]struct{}),
+		features:           make(map[database.Feature]struct{}),
+		namespacedFeatures: make(map[database.NamespacedFeature]struct{}),
+		layers:             make(map[string]layerRow),
+		ancestries:         make(map[string]ancestryRow),
+		affected:           make(map[affectedKey]database.AffectedFeature),
+		keyValues:          make(map[string]string),
+		locks:              make(map[string]lockRow),
+	}
+}
+
+func (s *state) clone() *state {
+	c := newState()
+	c.lastID = s.lastID
+	for k, v := range s.detectors {
+		c.detectors[k] = v
+	}
+	c.namespaces = append(c.namespaces, s.namespaces...)
+	for k, v := range s.features {
+		c.features[k] = v
+	}
+	for k, v := range s.namespacedFeatures {
+		c.namespacedFeatures[k] = v
+	}
+	for k, v := range s.layers {
+		c.layers[k] = v
+	}
+	for k, v := range s.ancestries {
+		c.ancestries[k] = v
+	}
+	c.vulnerabilities = append(c.vulnerabilities, s.vulnerabilities...)
+	for k, v := range s.affected {
+		c.affected[k] = v
+	}
+	c.notifications = append(c.notifications, s.notifications...)
+	for k, v := range s.keyValues {
+		c.keyValues[k] = v
+	}
+	for k, v := range s.locks {
+		c.locks[k] = v
+	}
+	return c
+}
+
+func (s *state) nextID() int64 {
+	s.lastID++
+	return s.lastID
+}
+
+// openDatabase opens an empty in-memory Datastore using the given
+// configuration.
+func openDatabase(registrableComponentConfig database.RegistrableComponentConfig) (database.Datastore, error) {
+	var config Config
+	bytes, err := yaml.Marshal(registrableComponentConfig.Options)
+	if err != nil {
+		return nil, fmt.Errorf("mem: could not load configuration: %v", err)
+	}
+	err = yaml.Unmarshal(bytes, &config)
+	if err != nil {
+		return nil, fmt.Errorf("mem: could not load configuration: %v", err)
+	}
+
+	keys := config.PaginationKeys
+	if config.PaginationKey != "" {
+		keys = append([]string{config.PaginationKey}, keys...)
+	}
+
+	var key pagination.Key
+	if len(keys) == 0 {
+		key, err = pagination.NewKey()
+	} else {
+		key, err = pagination.KeyFromStrings(keys)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mem: could not load pagination key: %v", err)
+	}
+
+	return &memStore{state: newState(), key: key}, nil
+}
+
+// Begin initiates a session, which reads the committed state without waiting
+// for the other sessions.
+func (store *memStore) Begin() (database.Session, error) {
+	if atomic.LoadInt32(&store.closed) != 0 {
+		return nil, database.ErrBackendException
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return &memSession{
+		store: store,
+		state: store.state,
+		key:   store.key,
+		now:   time.Now(),
+	}, nil
+}
+
+// Ping verifies that the datastore is not closed.
+func (store *memStore) Ping() bool {
+	return atomic.LoadInt32(&store.closed) == 0
+}
+
+// Close prevents new sessions from beginning, without waiting for the active
+// sessions.
+func (store *memStore) Close() {
+	atomic.StoreInt32(&store.closed, 1)
+}
+
+func (tx *memSession) Commit() error {
+	if tx.done {
+		return nil
+	}
+
+	if tx.writing {
+		tx.store.mu.Lock()
+		tx.store.state = tx.state
+		tx.store.mu.Unlock()
+	}
+	tx.end()
+	return nil
+}
+
+func (tx *memSession) Rollback() error {
+	if tx.done {
+		return nil
+	}
+
+	tx.end()
+	return nil
+}
+
+func (tx *memSession) end() {
+	tx.done = true
+	tx.state = nil
+	if tx.writing {
+		tx.store.writer.Unlock()
+	}
+}
+
+// write is called before the session changes its state, which it copies from
+// the latest store's state once it waited for the other session changing it
+// to end.
+func (tx *memSession) write() {
+	if tx.writing {
+		return
+	}
+
+	tx.store.writer.Lock()
+	tx.writing = true
+
+	tx.store.mu.RLock()
+	tx.state = tx.store.state.clone()
+	tx.store.mu.RUnlock()
+}
+
+// copyMetadata copies the metadata through its JSON encoding, which is how the
+// PostgreSQL implementation stores it.
+func copyMetadata(metadata database.MetadataMap) (database.MetadataMap, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var c database.MetadataMap
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
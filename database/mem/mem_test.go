@@ -0,0 +1,326 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/pagination"
+)
+
+var (
+	testDetectors = []database.Detector{
+		database.NewNamespaceDetector("os-release", "1.0"),
+		database.NewFeatureDetector("dpkg", "1.0"),
+	}
+
+	testNamespaces = []database.Namespace{
+		{Name: "debian:7", VersionFormat: "dpkg"},
+		{Name: "debian:8", VersionFormat: "dpkg"},
+	}
+
+	testFeatures = []database.Feature{
+		{Name: "openssl", Version: "1.0", VersionFormat: "dpkg"},
+		{Name: "openssl", Version: "2.0", VersionFormat: "dpkg"},
+	}
+
+	testNamespacedFeatures = []database.NamespacedFeature{
+		{Feature: testFeatures[0], Namespace: testNamespaces[0]},
+		{Feature: testFeatures[1], Namespace: testNamespaces[0]},
+		{Feature: testFeatures[0], Namespace: testNamespaces[1]},
+	}
+)
+
+func openSessionForTest(t *testing.T, loadFixture bool) (database.Datastore, database.Session) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "mem"})
+	require.Nil(t, err)
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+
+	if loadFixture {
+		require.Nil(t, tx.PersistDetectors(testDetectors))
+		require.Nil(t, tx.PersistNamespaces(testNamespaces))
+		require.Nil(t, tx.PersistFeatures(testFeatures))
+		require.Nil(t, tx.PersistNamespacedFeatures(testNamespacedFeatures))
+		tx = restartSession(t, store, tx, true)
+	}
+
+	return store, tx
+}
+
+func restartSession(t *testing.T, store database.Datastore, tx database.Session, commit bool) database.Session {
+	var err error
+	if commit {
+		err = tx.Commit()
+	} else {
+		err = tx.Rollback()
+	}
+	require.Nil(t, err)
+
+	tx, err = store.Begin()
+	require.Nil(t, err)
+	return tx
+}
+
+func TestSession(t *testing.T) {
+	store, tx := openSessionForTest(t, false)
+	defer store.Close()
+
+	// The changes are dropped by Rollback.
+	require.Nil(t, tx.UpdateKeyValue("key", "rolled back"))
+	tx = restartSession(t, store, tx, false)
+
+	_, ok, err := tx.FindKeyValue("key")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// The changes are kept by Commit.
+	require.Nil(t, tx.UpdateKeyValue("key", "committed"))
+	tx = restartSession(t, store, tx, true)
+
+	value, ok, err := tx.FindKeyValue("key")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "committed", value)
+
+	// The session cannot be used once it is terminated, and is terminated
+	// once.
+	assert.Nil(t, tx.Rollback())
+	assert.Nil(t, tx.Commit())
+	_, _, err = tx.FindKeyValue("key")
+	assert.Equal(t, database.ErrBackendException, err)
+
+	// The sessions read the committed state while another session changes
+	// it.
+	tx, err = store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.UpdateKeyValue("key", "changed"))
+
+	reader, err := store.Begin()
+	require.Nil(t, err)
+	value, _, err = reader.FindKeyValue("key")
+	assert.Nil(t, err)
+	assert.Equal(t, "committed", value)
+
+	// The sessions changing the state are serialized, and change the latest
+	// committed state.
+	written := make(chan error)
+	go func() {
+		tx, err := store.Begin()
+		if err == nil {
+			err = tx.UpdateKeyValue("other", "value")
+			value, _, _ := tx.FindKeyValue("key")
+			assert.Equal(t, "changed", value)
+			tx.Commit()
+		}
+		written <- err
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("a session changed the state before the session changing it ended")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.Nil(t, tx.Commit())
+	assert.Nil(t, <-written)
+
+	// A session keeps reading the state committed when it began.
+	value, _, err = reader.FindKeyValue("key")
+	assert.Nil(t, err)
+	assert.Equal(t, "committed", value)
+	assert.Nil(t, reader.Rollback())
+
+	tx, err = store.Begin()
+	require.Nil(t, err)
+	value, _, err = tx.FindKeyValue("other")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", value)
+	assert.Nil(t, tx.Rollback())
+
+	assert.True(t, store.Ping())
+	store.Close()
+	assert.False(t, store.Ping())
+	_, err = store.Begin()
+	assert.Equal(t, database.ErrBackendException, err)
+}
+
+func TestConcurrentSessions(t *testing.T) {
+	store, tx := openSessionForTest(t, false)
+	defer store.Close()
+	require.Nil(t, tx.Rollback())
+
+	// The sessions reading the state do not wait for the ones changing it,
+	// which all keep their changes.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			tx, err := store.Begin()
+			if !assert.Nil(t, err) {
+				return
+			}
+			assert.Nil(t, tx.UpdateKeyValue(fmt.Sprint(i), "value"))
+			assert.Nil(t, tx.Commit())
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			tx, err := store.Begin()
+			if !assert.Nil(t, err) {
+				return
+			}
+			_, _, err = tx.FindKeyValue(fmt.Sprint(i))
+			assert.Nil(t, err)
+			assert.Nil(t, tx.Rollback())
+		}(i)
+	}
+	wg.Wait()
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+	for i := 0; i < 10; i++ {
+		_, ok, err := tx.FindKeyValue(fmt.Sprint(i))
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+}
+
+func TestFindNamespaces(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{{Name: "alpine:v3.8", VersionFormat: "dpkg"}}))
+
+	namespaces, nextPage, err := tx.FindNamespaces("debian", 1, pagination.FirstPageToken)
+	if assert.Nil(t, err) {
+		assert.Equal(t, testNamespaces[:1], namespaces)
+		assert.NotEqual(t, pagination.FirstPageToken, nextPage)
+	}
+
+	namespaces, nextPage, err = tx.FindNamespaces("debian", 1, nextPage)
+	if assert.Nil(t, err) {
+		assert.Equal(t, testNamespaces[1:], namespaces)
+		assert.Equal(t, pagination.FirstPageToken, nextPage)
+	}
+
+	namespaces, _, err = tx.FindNamespaces("", 10, pagination.FirstPageToken)
+	if assert.Nil(t, err) {
+		assert.Len(t, namespaces, 3)
+	}
+
+	_, _, err = tx.FindNamespaces("", 10, "invalid")
+	assert.IsType(t, &commonerr.ErrBadRequest{}, err)
+
+	_, _, err = tx.FindNamespaces("", 0, pagination.FirstPageToken)
+	assert.IsType(t, &commonerr.ErrBadRequest{}, err)
+
+	assert.IsType(t, &commonerr.ErrBadRequest{}, tx.PersistNamespaces([]database.Namespace{{Name: "debian:9"}}))
+}
+
+func TestPersistNamespacedFeatures(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	unknownFeature := database.NamespacedFeature{
+		Feature:   database.Feature{Name: "unknown", Version: "1.0", VersionFormat: "dpkg"},
+		Namespace: testNamespaces[0],
+	}
+	assert.Equal(t, database.ErrMissingEntities, tx.PersistNamespacedFeatures([]database.NamespacedFeature{unknownFeature}))
+
+	unknownNamespace := database.NamespacedFeature{
+		Feature:   testFeatures[0],
+		Namespace: database.Namespace{Name: "unknown", VersionFormat: "dpkg"},
+	}
+	assert.Equal(t, database.ErrMissingEntities, tx.PersistNamespacedFeatures([]database.NamespacedFeature{unknownNamespace}))
+
+	assert.IsType(t, &commonerr.ErrBadRequest{}, tx.PersistFeatures([]database.Feature{{Name: "openssl"}}))
+}
+
+func TestKeyValue(t *testing.T) {
+	store, tx := openSessionForTest(t, false)
+	defer store.Close()
+
+	assert.IsType(t, &commonerr.ErrBadRequest{}, tx.UpdateKeyValue("", "value"))
+	assert.IsType(t, &commonerr.ErrBadRequest{}, tx.UpdateKeyValue("key", ""))
+
+	require.Nil(t, tx.UpdateKeyValue("key", "value1"))
+	require.Nil(t, tx.UpdateKeyValue("key", "value2"))
+
+	value, ok, err := tx.FindKeyValue("key")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "value2", value)
+}
+
+func TestLock(t *testing.T) {
+	store, tx := openSessionForTest(t, false)
+	defer store.Close()
+
+	l, _, err := tx.Lock("test1", "owner1", time.Minute, false)
+	assert.Nil(t, err)
+	assert.True(t, l)
+
+	// The lock is not expired yet.
+	l, _, err = tx.Lock("test1", "owner2", time.Minute, false)
+	assert.Nil(t, err)
+	assert.False(t, l)
+
+	// It is only renewed by its owner.
+	l, _, err = tx.Lock("test1", "owner2", time.Minute, true)
+	assert.Nil(t, err)
+	assert.False(t, l)
+
+	l, until, err := tx.Lock("test1", "owner1", 2*time.Minute, true)
+	assert.Nil(t, err)
+	assert.True(t, l)
+
+	owner, expiration, ok, err := tx.FindLock("test1")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "owner1", owner)
+	assert.Equal(t, until, expiration)
+
+	// It is only unlocked by its owner.
+	assert.Nil(t, tx.Unlock("test1", "owner2"))
+	_, _, ok, _ = tx.FindLock("test1")
+	assert.True(t, ok)
+
+	assert.Nil(t, tx.Unlock("test1", "owner1"))
+	_, _, ok, err = tx.FindLock("test1")
+	assert.False(t, ok)
+	assert.Equal(t, commonerr.ErrNotFound, err)
+
+	// An expired lock is taken over.
+	l, _, err = tx.Lock("test2", "owner1", -time.Minute, false)
+	assert.Nil(t, err)
+	assert.True(t, l)
+
+	l, _, err = tx.Lock("test2", "owner2", time.Minute, false)
+	assert.Nil(t, err)
+	assert.True(t, l)
+
+	assert.Nil(t, tx.Rollback())
+}
@@ -0,0 +1,353 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/pagination"
+)
+
+var (
+	errNotificationNotFound = errors.New("requested notification is not found")
+)
+
+type notificationRow struct {
+	id   int64
+	hook database.NotificationHook
+	// oldID and newID are the IDs of the vulnerabilities of the notification,
+	// which are 0 when there is none.
+	oldID int64
+	newID int64
+}
+
+func (tx *memSession) InsertVulnerabilityNotifications(notifications []database.VulnerabilityNotification) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	names := make(map[string]struct{}, len(tx.notifications)+len(notifications))
+	for _, row := range tx.notifications {
+		names[row.hook.Name] = struct{}{}
+	}
+
+	rows := make([]notificationRow, 0, len(notifications))
+	for _, noti := range notifications {
+		if noti.Name == "" {
+			return commonerr.NewBadRequestError("notification should not have empty name")
+		}
+		if noti.Created.IsZero() {
+			return commonerr.NewBadRequestError("notification should not have empty created time")
+		}
+
+		if _, ok := names[noti.Name]; ok {
+			return database.ErrInconsistent
+		}
+		names[noti.Name] = struct{}{}
+
+		row := notificationRow{id: tx.nextID(), hook: database.NotificationHook{Name: noti.Name, Created: noti.Created}}
+		if noti.New != nil {
+			vuln, ok := tx.findVulnerability(database.VulnerabilityID{Name: noti.New.Name, Namespace: noti.New.Namespace.Name})
+			if !ok {
+				return errVulnerabilityNotFound
+			}
+			row.newID = vuln.id
+		}
+
+		if noti.Old != nil {
+			vuln, ok := tx.findLatestDeletedVulnerability(database.VulnerabilityID{Name: noti.Old.Name, Namespace: noti.Old.Namespace.Name})
+			if !ok {
+				return errVulnerabilityNotFound
+			}
+			row.oldID = vuln.id
+		}
+
+		rows = append(rows, row)
+	}
+
+	tx.notifications = append(tx.notifications, rows...)
+	return nil
+}
+
+func (tx *memSession) FindNewNotification(notifiedBefore time.Time) (database.NotificationHook, bool, error) {
+	if tx.done {
+		return database.NotificationHook{}, false, database.ErrBackendException
+	}
+
+	for _, row := range tx.notifications {
+		if !row.hook.Deleted.IsZero() || (!row.hook.Notified.IsZero() && !row.hook.Notified.Before(notifiedBefore)) {
+			continue
+		}
+
+		// The notifications being sent, or coalesced, are locked, while the
+		// expired locks are ignored.
+		if lock, ok := tx.locks[row.hook.Name]; ok && !lock.until.Before(time.Now()) {
+			continue
+		}
+
+		return row.hook, true, nil
+	}
+
+	return database.NotificationHook{}, false, nil
+}
+
+func (tx *memSession) FindVulnerabilityNotification(name string, limit int, oldPageToken pagination.Token, newPageToken pagination.Token) (
+	database.VulnerabilityNotificationWithVulnerable, bool, error) {
+	noti := database.VulnerabilityNotificationWithVulnerable{NotificationHook: database.NotificationHook{Name: name}}
+	if tx.done {
+		return noti, false, database.ErrBackendException
+	}
+
+	if name == "" {
+		return noti, false, commonerr.NewBadRequestError("Empty notification name is not allowed")
+	}
+
+	i, ok := tx.findNotification(name)
+	if !ok {
+		return noti, false, nil
+	}
+
+	row := tx.notifications[i]
+	noti.NotificationHook = row.hook
+
+	if row.oldID != 0 {
+		page, err := tx.findPagedVulnerableAncestries(row.oldID, limit, oldPageToken)
+		if err != nil {
+			return noti, false, err
+		}
+		noti.Old = &page
+	}
+
+	if row.newID != 0 {
+		page, err := tx.findPagedVulnerableAncestries(row.newID, limit, newPageToken)
+		if err != nil {
+			return noti, false, err
+		}
+		noti.New = &page
+	}
+
+	return noti, true, nil
+}
+
+func (tx *memSession) findPagedVulnerableAncestries(vulnID int64, limit int, currentToken pagination.Token) (database.PagedVulnerableAncestries, error) {
+	vulnPage := database.PagedVulnerableAncestries{Limit: limit}
+	currentPage := page{0}
+	if currentToken != pagination.FirstPageToken {
+		if err := tx.key.UnmarshalToken(currentToken, &currentPage); err != nil {
+			return vulnPage, err
+		}
+	}
+
+	row, ok := tx.findVulnerabilityByID(vulnID)
+	if !ok {
+		return vulnPage, commonerr.ErrNotFound
+	}
+
+	vulnerability, err := row.find()
+	if err != nil {
+		return vulnPage, err
+	}
+	vulnPage.Vulnerability = vulnerability.Vulnerability
+
+	// The ancestries are ordered by insertion and one more ancestry is
+	// retrieved to find the start of the next page.
+	var ancestries []ancestryRow
+	for _, ancestry := range tx.ancestries {
+		if ancestry.id >= currentPage.StartID && tx.isAffected(vulnID, ancestry) {
+			ancestries = append(ancestries, ancestry)
+		}
+	}
+	sort.Slice(ancestries, func(i, j int) bool { return ancestries[i].id < ancestries[j].id })
+	if len(ancestries) > limit+1 {
+		ancestries = ancestries[:limit+1]
+	}
+
+	lastIndex := 0
+	if len(ancestries)-1 < limit {
+		lastIndex = len(ancestries)
+		vulnPage.End = true
+	} else {
+		// Use the last ancestry's ID as the next page.
+		lastIndex = len(ancestries) - 1
+		vulnPage.Next, err = tx.key.MarshalToken(page{ancestries[len(ancestries)-1].id})
+		if err != nil {
+			return vulnPage, err
+		}
+	}
+
+	vulnPage.Affected = map[int]string{}
+	for _, ancestry := range ancestries[0:lastIndex] {
+		vulnPage.Affected[int(ancestry.id)] = ancestry.ancestry.Name
+	}
+
+	vulnPage.Current, err = tx.key.MarshalToken(currentPage)
+	if err != nil {
+		return vulnPage, err
+	}
+
+	return vulnPage, nil
+}
+
+// isAffected returns whether a feature of the ancestry is affected by the
+// vulnerability.
+func (tx *memSession) isAffected(vulnID int64, ancestry ancestryRow) bool {
+	for _, layer := range ancestry.ancestry.Layers {
+		for _, f := range layer.Features {
+			if _, ok := tx.affected[affectedKey{vulnID, f.NamespacedFeature}]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (tx *memSession) findNotification(name string) (int, bool) {
+	for i, row := range tx.notifications {
+		if row.hook.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (tx *memSession) MarkNotificationAsRead(name string) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	if name == "" {
+		return commonerr.NewBadRequestError("Empty notification name is not allowed")
+	}
+
+	i, ok := tx.findNotification(name)
+	if !ok {
+		return errNotificationNotFound
+	}
+
+	tx.notifications[i].hook.Notified = tx.now
+	return nil
+}
+
+func (tx *memSession) DeleteNotification(name string) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	if name == "" {
+		return commonerr.NewBadRequestError("Empty notification name is not allowed")
+	}
+
+	i, ok := tx.findNotification(name)
+	if !ok || !tx.notifications[i].hook.Deleted.IsZero() {
+		return commonerr.ErrNotFound
+	}
+
+	tx.notifications[i].hook.Deleted = tx.now
+	return nil
+}
+
+// isPending returns whether the notification has neither been notified nor
+// deleted.
+func (row notificationRow) isPending() bool {
+	return row.hook.Notified.IsZero() && row.hook.Deleted.IsZero()
+}
+
+func (tx *memSession) FindPendingNotifications(limit int, pageToken pagination.Token) ([]database.NotificationHook, pagination.Token, error) {
+	if tx.done {
+		return nil, pagination.FirstPageToken, database.ErrBackendException
+	}
+
+	if limit <= 0 {
+		return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("notification page limit should be positive")
+	}
+
+	current := page{0}
+	if pageToken != pagination.FirstPageToken {
+		if err := tx.key.UnmarshalToken(pageToken, &current); err == pagination.ErrExpiredToken || err == pagination.ErrFutureToken {
+			return nil, pagination.FirstPageToken, err
+		} else if err != nil {
+			return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("invalid notification page token")
+		}
+	}
+
+	notifications := []database.NotificationHook{}
+	for _, row := range tx.notifications {
+		if row.id < current.StartID || !row.isPending() {
+			continue
+		}
+
+		if len(notifications) == limit {
+			nextPage, err := tx.key.MarshalToken(page{row.id})
+			if err != nil {
+				return nil, pagination.FirstPageToken, err
+			}
+			return notifications, nextPage, nil
+		}
+		notifications = append(notifications, row.hook)
+	}
+
+	return notifications, pagination.FirstPageToken, nil
+}
+
+func (tx *memSession) CountPendingNotifications() (int, time.Time, error) {
+	if tx.done {
+		return 0, time.Time{}, database.ErrBackendException
+	}
+
+	var (
+		count  int
+		oldest time.Time
+	)
+	for _, row := range tx.notifications {
+		if !row.isPending() {
+			continue
+		}
+
+		count++
+		if oldest.IsZero() || row.hook.Created.Before(oldest) {
+			oldest = row.hook.Created
+		}
+	}
+
+	return count, oldest, nil
+}
+
+func (tx *memSession) DeletePendingNotificationsBefore(before time.Time) ([]string, error) {
+	if tx.done {
+		return nil, database.ErrBackendException
+	}
+
+	tx.write()
+
+	var names []string
+	for i, row := range tx.notifications {
+		if row.isPending() && row.hook.Created.Before(before) {
+			tx.notifications[i].hook.Deleted = tx.now
+			names = append(names, row.hook.Name)
+		}
+	}
+
+	return names, nil
+}
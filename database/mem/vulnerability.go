@@ -0,0 +1,328 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/commonerr"
+)
+
+var (
+	errVulnerabilityNotFound = errors.New("vulnerability is not in database")
+)
+
+// vulnerabilityRow is a vulnerability, which is kept when it is deleted so that
+// the notifications can refer to it.
+type vulnerabilityRow struct {
+	id            int64
+	created       time.Time
+	deleted       time.Time
+	vulnerability database.VulnerabilityWithAffected
+}
+
+// find returns a copy of the vulnerability of the row.
+func (row vulnerabilityRow) find() (database.VulnerabilityWithAffected, error) {
+	vulnerability := row.vulnerability
+	metadata, err := copyMetadata(vulnerability.Metadata)
+	if err != nil {
+		return vulnerability, err
+	}
+	vulnerability.Metadata = metadata
+	vulnerability.Affected = append([]database.AffectedFeature(nil), vulnerability.Affected...)
+	if vulnerability.Withdrawn != nil {
+		withdrawal := *vulnerability.Withdrawn
+		vulnerability.Withdrawn = &withdrawal
+	}
+	return vulnerability, nil
+}
+
+func (tx *memSession) FindVulnerabilities(ids []database.VulnerabilityID) ([]database.NullableVulnerability, error) {
+	if tx.done {
+		return nil, database.ErrBackendException
+	}
+
+	vulnerabilities := make([]database.NullableVulnerability, len(ids))
+	for i, id := range ids {
+		vulnerabilities[i].Name = id.Name
+		vulnerabilities[i].Namespace.Name = id.Namespace
+
+		row, ok := tx.findVulnerability(id)
+		if !ok {
+			continue
+		}
+
+		vulnerability, err := row.find()
+		if err != nil {
+			return nil, err
+		}
+
+		vulnerabilities[i].VulnerabilityWithAffected = vulnerability
+		vulnerabilities[i].Valid = true
+	}
+
+	return vulnerabilities, nil
+}
+
+// findVulnerability returns the vulnerability with the given ID, which is not
+// deleted.
+func (tx *memSession) findVulnerability(id database.VulnerabilityID) (vulnerabilityRow, bool) {
+	for _, row := range tx.vulnerabilities {
+		if row.deleted.IsZero() && row.vulnerability.Name == id.Name && row.vulnerability.Namespace.Name == id.Namespace {
+			return row, true
+		}
+	}
+	return vulnerabilityRow{}, false
+}
+
+// findLatestDeletedVulnerability returns the vulnerability with the given ID
+// which has been deleted last.
+func (tx *memSession) findLatestDeletedVulnerability(id database.VulnerabilityID) (vulnerabilityRow, bool) {
+	var (
+		latest vulnerabilityRow
+		found  bool
+	)
+
+	for _, row := range tx.vulnerabilities {
+		if row.deleted.IsZero() || row.vulnerability.Name != id.Name || row.vulnerability.Namespace.Name != id.Namespace {
+			continue
+		}
+
+		if !found || !row.deleted.Before(latest.deleted) {
+			latest, found = row, true
+		}
+	}
+
+	return latest, found
+}
+
+func (tx *memSession) findVulnerabilityByID(id int64) (vulnerabilityRow, bool) {
+	for _, row := range tx.vulnerabilities {
+		if row.id == id {
+			return row, true
+		}
+	}
+	return vulnerabilityRow{}, false
+}
+
+func (tx *memSession) WalkVulnerabilities(namespace string, since time.Time, fn func(database.VulnerabilityWithAffected) error) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	for _, row := range tx.vulnerabilities {
+		if !row.deleted.IsZero() || row.vulnerability.Namespace.Name != namespace || row.created.Before(since) {
+			continue
+		}
+
+		vulnerability, err := row.find()
+		if err != nil {
+			return err
+		}
+
+		if err := fn(vulnerability); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tx *memSession) WalkWithdrawnVulnerabilities(namespace string, since time.Time, fn func(database.VulnerabilityWithAffected) error) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	var withdrawn []vulnerabilityRow
+	for _, row := range tx.vulnerabilities {
+		if row.vulnerability.Withdrawn == nil || row.vulnerability.Namespace.Name != namespace || row.deleted.Before(since) {
+			continue
+		}
+
+		// The vulnerability is not withdrawn anymore once it is inserted or
+		// deleted again.
+		id := database.VulnerabilityID{Name: row.vulnerability.Name, Namespace: namespace}
+		if _, ok := tx.findVulnerability(id); ok {
+			continue
+		}
+
+		if latest, _ := tx.findLatestDeletedVulnerability(id); latest.id != row.id {
+			continue
+		}
+
+		withdrawn = append(withdrawn, row)
+	}
+
+	sort.SliceStable(withdrawn, func(i, j int) bool { return withdrawn[i].deleted.Before(withdrawn[j].deleted) })
+	for _, row := range withdrawn {
+		vulnerability, err := row.find()
+		if err != nil {
+			return err
+		}
+
+		if err := fn(vulnerability); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tx *memSession) InsertVulnerabilities(vulnerabilities []database.VulnerabilityWithAffected) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	ids := make(map[database.VulnerabilityID]struct{}, len(vulnerabilities))
+	for _, v := range vulnerabilities {
+		id := database.VulnerabilityID{Name: v.Name, Namespace: v.Namespace.Name}
+		if _, ok := ids[id]; ok {
+			return errors.New("inserting duplicated vulnerabilities is not allowed")
+		}
+		ids[id] = struct{}{}
+
+		if _, ok := tx.findNamespaceID(v.Namespace); !ok {
+			return database.ErrMissingEntities
+		}
+	}
+
+	rows := make([]vulnerabilityRow, 0, len(vulnerabilities))
+	for _, v := range vulnerabilities {
+		metadata, err := copyMetadata(v.Metadata)
+		if err != nil {
+			return err
+		}
+
+		row := vulnerabilityRow{id: tx.nextID(), created: tx.now, vulnerability: v}
+		row.vulnerability.Metadata = metadata
+		row.vulnerability.Withdrawn = nil
+
+		// The type of the affected features is not kept.
+		row.vulnerability.Affected = make([]database.AffectedFeature, 0, len(v.Affected))
+		for _, f := range v.Affected {
+			row.vulnerability.Affected = append(row.vulnerability.Affected, database.AffectedFeature{
+				Namespace:           v.Namespace,
+				FeatureName:         f.FeatureName,
+				AffectedVersion:     f.AffectedVersion,
+				FixedInVersion:      f.FixedInVersion,
+				IntroducedInVersion: f.IntroducedInVersion,
+				LastAffectedVersion: f.LastAffectedVersion,
+				Tag:                 f.Tag,
+			})
+		}
+
+		if len(row.vulnerability.Affected) == 0 {
+			row.vulnerability.Affected = nil
+		}
+
+		rows = append(rows, row)
+	}
+
+	count := len(tx.affected)
+	for _, row := range rows {
+		for f := range tx.namespacedFeatures {
+			if f.Namespace == row.vulnerability.Namespace && tx.hasNamespacedFeature(f) {
+				if err := tx.cacheAffected(row, f); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	tx.vulnerabilities = append(tx.vulnerabilities, rows...)
+	log.WithField("count", len(tx.affected)-count).Debug("cached features in vulnerability_affected_namespaced_feature")
+	return nil
+}
+
+func (tx *memSession) DeleteVulnerabilities(ids []database.VulnerabilityID) error {
+	return tx.deleteVulnerabilities(ids, nil)
+}
+
+func (tx *memSession) WithdrawVulnerabilities(ids []database.VulnerabilityID, reason string) error {
+	return tx.deleteVulnerabilities(ids, &database.Withdrawal{Time: tx.now, Reason: reason})
+}
+
+// deleteVulnerabilities marks the vulnerabilities with the given IDs as
+// deleted, and as withdrawn if withdrawal is not nil.
+func (tx *memSession) deleteVulnerabilities(ids []database.VulnerabilityID, withdrawal *database.Withdrawal) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	deleted := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		found := false
+		for i, row := range tx.vulnerabilities {
+			if row.deleted.IsZero() && row.vulnerability.Name == id.Name && row.vulnerability.Namespace.Name == id.Namespace {
+				row.deleted = tx.now
+				row.vulnerability.Withdrawn = withdrawal
+				tx.vulnerabilities[i] = row
+				deleted[row.id] = struct{}{}
+				found = true
+			}
+		}
+
+		if !found {
+			return commonerr.ErrNotFound
+		}
+	}
+
+	for key := range tx.affected {
+		if _, ok := deleted[key.vulnerabilityID]; ok {
+			delete(tx.affected, key)
+		}
+	}
+
+	return nil
+}
+
+func (tx *memSession) UpdateVulnerabilityMetadata(name, key string, metadata interface{}) error {
+	if tx.done {
+		return database.ErrBackendException
+	}
+
+	tx.write()
+
+	for i, row := range tx.vulnerabilities {
+		if !row.deleted.IsZero() || row.vulnerability.Name != name {
+			continue
+		}
+
+		vulnerabilityMetadata := database.MetadataMap{}
+		for k, v := range row.vulnerability.Metadata {
+			vulnerabilityMetadata[k] = v
+		}
+		vulnerabilityMetadata[key] = metadata
+
+		m, err := copyMetadata(vulnerabilityMetadata)
+		if err != nil {
+			return err
+		}
+
+		row.vulnerability.Metadata = m
+		tx.vulnerabilities[i] = row
+	}
+
+	return nil
+}
@@ -0,0 +1,556 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/pagination"
+
+	// dpkg versions are compared in the tests.
+	_ "github.com/coreos/clair/ext/versionfmt/dpkg"
+)
+
+func testVulnerability(name string) database.VulnerabilityWithAffected {
+	return database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:      name,
+			Namespace: testNamespaces[0],
+			Severity:  database.HighSeverity,
+			Metadata:  database.MetadataMap{"score": 7},
+		},
+		Affected: []database.AffectedFeature{{
+			AffectedType:    database.AffectBinaryPackage,
+			Namespace:       testNamespaces[0],
+			FeatureName:     "openssl",
+			AffectedVersion: "2.0",
+			FixedInVersion:  "2.0",
+		}},
+	}
+}
+
+func TestInsertVulnerabilities(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	vulnerability := testVulnerability("CVE-2018-0001")
+	assert.NotNil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability, vulnerability}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+	tx = restartSession(t, store, tx, true)
+
+	vulnerabilities, err := tx.FindVulnerabilities([]database.VulnerabilityID{
+		{Name: "CVE-2018-0001", Namespace: "debian:7"},
+		{Name: "CVE-2018-0001", Namespace: "debian:8"},
+	})
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 2) {
+		assert.True(t, vulnerabilities[0].Valid)
+		assert.False(t, vulnerabilities[1].Valid)
+
+		// The metadata is stored as JSON and the type of the affected features
+		// is not kept.
+		expected := vulnerability
+		expected.Metadata = database.MetadataMap{"score": float64(7)}
+		expected.Affected = []database.AffectedFeature{vulnerability.Affected[0]}
+		expected.Affected[0].AffectedType = ""
+		assert.Equal(t, expected, vulnerabilities[0].VulnerabilityWithAffected)
+	}
+
+	// The namespaced features whose version is in range are affected.
+	affected, err := tx.FindAffectedNamespacedFeatures(testNamespacedFeatures)
+	if assert.Nil(t, err) && assert.Len(t, affected, 3) {
+		assert.True(t, affected[0].Valid)
+		if assert.Len(t, affected[0].AffectedBy, 1) {
+			assert.Equal(t, "CVE-2018-0001", affected[0].AffectedBy[0].Name)
+			assert.Equal(t, "2.0", affected[0].AffectedBy[0].FixedInVersion)
+		}
+		assert.Empty(t, affected[1].AffectedBy)
+		assert.Empty(t, affected[2].AffectedBy)
+	}
+
+	// The namespaced features persisted later are cached explicitly.
+	feature := database.Feature{Name: "openssl", Version: "0.9", VersionFormat: "dpkg"}
+	namespacedFeature := database.NamespacedFeature{Feature: feature, Namespace: testNamespaces[0]}
+	require.Nil(t, tx.PersistFeatures([]database.Feature{feature}))
+	require.Nil(t, tx.PersistNamespacedFeatures([]database.NamespacedFeature{namespacedFeature}))
+	require.Nil(t, tx.CacheAffectedNamespacedFeatures([]database.NamespacedFeature{namespacedFeature}))
+
+	affected, err = tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{namespacedFeature})
+	if assert.Nil(t, err) && assert.Len(t, affected, 1) {
+		assert.Len(t, affected[0].AffectedBy, 1)
+	}
+
+	unknown := database.NamespacedFeature{Feature: feature, Namespace: testNamespaces[1]}
+	assert.Equal(t, database.ErrMissingEntities, tx.CacheAffectedNamespacedFeatures([]database.NamespacedFeature{unknown}))
+
+	affected, err = tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{unknown})
+	if assert.Nil(t, err) && assert.Len(t, affected, 1) {
+		assert.False(t, affected[0].Valid)
+	}
+
+	// The severities only retrieve what the summaries count.
+	affected, err = tx.FindAffectedNamespacedFeatureSeverities([]database.NamespacedFeature{testNamespacedFeatures[0], unknown})
+	if assert.Nil(t, err) && assert.Len(t, affected, 2) {
+		assert.True(t, affected[0].Valid)
+		assert.Equal(t, []database.VulnerabilityWithFixedIn{{
+			Vulnerability: database.Vulnerability{
+				Name:      "CVE-2018-0001",
+				Namespace: testNamespaces[0],
+				Severity:  database.HighSeverity,
+			},
+			FixedInVersion: "2.0",
+		}}, affected[0].AffectedBy)
+		assert.False(t, affected[1].Valid)
+	}
+}
+
+func TestInsertVulnerabilitiesIntroducedIn(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	// openssl 1.0 is older than the version introducing the vulnerability.
+	vulnerability := testVulnerability("CVE-2018-0001")
+	vulnerability.Affected[0].AffectedVersion = "3.0"
+	vulnerability.Affected[0].FixedInVersion = "3.0"
+	vulnerability.Affected[0].IntroducedInVersion = "1.5"
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+
+	affected, err := tx.FindAffectedNamespacedFeatures(testNamespacedFeatures[:2])
+	if assert.Nil(t, err) && assert.Len(t, affected, 2) {
+		assert.Empty(t, affected[0].AffectedBy)
+		assert.Len(t, affected[1].AffectedBy, 1)
+	}
+
+	vulnerabilities, err := tx.FindVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2018-0001", Namespace: "debian:7"}})
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) && assert.Len(t, vulnerabilities[0].Affected, 1) {
+		assert.Equal(t, "1.5", vulnerabilities[0].Affected[0].IntroducedInVersion)
+	}
+}
+
+func TestInsertVulnerabilitiesLastAffected(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	// openssl 1.0 is the last version affected by the first range and 2.0 is
+	// in the second one, but the openssl 1.0 of debian:8 is not affected.
+	vulnerability := testVulnerability("CVE-2018-0001")
+	vulnerability.Affected = []database.AffectedFeature{
+		{Namespace: testNamespaces[0], FeatureName: "openssl", AffectedVersion: versionfmt.MaxVersion, LastAffectedVersion: "1.0"},
+		{Namespace: testNamespaces[0], FeatureName: "openssl", AffectedVersion: "2.1", FixedInVersion: "2.1", IntroducedInVersion: "1.9"},
+	}
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+
+	ranges := []database.AffectedRange{
+		{LastAffectedVersion: "1.0"},
+		{IntroducedInVersion: "1.9", FixedInVersion: "2.1"},
+	}
+
+	affected, err := tx.FindAffectedNamespacedFeatures(testNamespacedFeatures)
+	if assert.Nil(t, err) && assert.Len(t, affected, 3) {
+		if assert.Len(t, affected[0].AffectedBy, 1) {
+			assert.Empty(t, affected[0].AffectedBy[0].FixedInVersion)
+			assert.Equal(t, ranges, affected[0].AffectedBy[0].AffectedRanges)
+		}
+		if assert.Len(t, affected[1].AffectedBy, 1) {
+			assert.Equal(t, "2.1", affected[1].AffectedBy[0].FixedInVersion)
+			assert.Equal(t, ranges, affected[1].AffectedBy[0].AffectedRanges)
+		}
+		assert.Empty(t, affected[2].AffectedBy)
+	}
+
+	// A vulnerability whose only range ends at openssl 1.0 does not affect
+	// openssl 2.0.
+	vulnerability.Affected = vulnerability.Affected[:1]
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability("CVE-2018-0002")}))
+	vulnerability.Name = "CVE-2018-0003"
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+
+	affected, err = tx.FindAffectedNamespacedFeatures(testNamespacedFeatures[1:2])
+	if assert.Nil(t, err) && assert.Len(t, affected, 1) && assert.Len(t, affected[0].AffectedBy, 1) {
+		assert.Equal(t, "CVE-2018-0001", affected[0].AffectedBy[0].Name)
+	}
+
+	// A vulnerability only fixed by a version has no ranges.
+	affected, err = tx.FindAffectedNamespacedFeatures(testNamespacedFeatures[:1])
+	if assert.Nil(t, err) && assert.Len(t, affected, 1) {
+		for _, vuln := range affected[0].AffectedBy {
+			if vuln.Name == "CVE-2018-0002" {
+				assert.Nil(t, vuln.AffectedRanges)
+			} else {
+				assert.NotEmpty(t, vuln.AffectedRanges)
+			}
+		}
+	}
+
+	vulnerabilities, err := tx.FindVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2018-0003", Namespace: "debian:7"}})
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) && assert.Len(t, vulnerabilities[0].Affected, 1) {
+		assert.Equal(t, "1.0", vulnerabilities[0].Affected[0].LastAffectedVersion)
+	}
+}
+
+func TestInsertVulnerabilitiesTag(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	vulnerability := testVulnerability("CVE-2018-0001")
+	vulnerability.Affected[0].Tag = "no-dsa"
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+
+	affected, err := tx.FindAffectedNamespacedFeatures(testNamespacedFeatures[:1])
+	if assert.Nil(t, err) && assert.Len(t, affected, 1) && assert.Len(t, affected[0].AffectedBy, 1) {
+		assert.Equal(t, "no-dsa", affected[0].AffectedBy[0].Tag)
+	}
+
+	vulnerabilities, err := tx.FindVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2018-0001", Namespace: "debian:7"}})
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) && assert.Len(t, vulnerabilities[0].Affected, 1) {
+		assert.Equal(t, "no-dsa", vulnerabilities[0].Affected[0].Tag)
+	}
+}
+
+func TestInsertVulnerabilitiesSourceName(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	// libssl1.0 is a subpackage of openssl, whose vulnerabilities affect it.
+	libssl := database.NamespacedFeature{
+		Feature:   database.Feature{Name: "libssl1.0", Version: "1.0", SourceName: "openssl", SourceVersion: "1.0", VersionFormat: "dpkg"},
+		Namespace: testNamespaces[0],
+	}
+	require.Nil(t, tx.PersistFeatures([]database.Feature{libssl.Feature}))
+	require.Nil(t, tx.PersistNamespacedFeatures([]database.NamespacedFeature{libssl}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability("CVE-2018-0001")}))
+
+	affected, err := tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{libssl})
+	if assert.Nil(t, err) && assert.Len(t, affected, 1) && assert.Len(t, affected[0].AffectedBy, 1) {
+		assert.Equal(t, "CVE-2018-0001", affected[0].AffectedBy[0].Name)
+		assert.Equal(t, "2.0", affected[0].AffectedBy[0].FixedInVersion)
+	}
+}
+
+func TestInsertVulnerabilitiesSourceVersion(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	// The version of util-linux, not the one of bsdutils, with its epoch, is
+	// compared with the vulnerabilities of util-linux.
+	bsdutils := database.NamespacedFeature{
+		Feature:   database.Feature{Name: "bsdutils", Version: "1:2.25.2-6", SourceName: "util-linux", SourceVersion: "2.25.2-6", VersionFormat: "dpkg"},
+		Namespace: testNamespaces[0],
+	}
+	// A feature without the version of its source package is only affected
+	// by the vulnerabilities of its own name.
+	noVersion := database.NamespacedFeature{
+		Feature:   database.Feature{Name: "mount", Version: "2.25.2-6", SourceName: "util-linux", VersionFormat: "dpkg"},
+		Namespace: testNamespaces[0],
+	}
+	require.Nil(t, tx.PersistFeatures([]database.Feature{bsdutils.Feature, noVersion.Feature}))
+	require.Nil(t, tx.PersistNamespacedFeatures([]database.NamespacedFeature{bsdutils, noVersion}))
+
+	vulnerability := testVulnerability("CVE-2018-0001")
+	vulnerability.Affected[0].FeatureName = "util-linux"
+	vulnerability.Affected[0].AffectedVersion = "2.25.2-7"
+	vulnerability.Affected[0].FixedInVersion = "2.25.2-7"
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+
+	affected, err := tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{bsdutils, noVersion})
+	if assert.Nil(t, err) && assert.Len(t, affected, 2) {
+		if assert.Len(t, affected[0].AffectedBy, 1) {
+			assert.Equal(t, "2.25.2-7", affected[0].AffectedBy[0].FixedInVersion)
+		}
+		assert.Empty(t, affected[1].AffectedBy)
+	}
+}
+
+func TestDeleteVulnerabilities(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability("CVE-2018-0001")}))
+
+	id := database.VulnerabilityID{Name: "CVE-2018-0001", Namespace: "debian:7"}
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{id}))
+	assert.Equal(t, commonerr.ErrNotFound, tx.DeleteVulnerabilities([]database.VulnerabilityID{id}))
+
+	vulnerabilities, err := tx.FindVulnerabilities([]database.VulnerabilityID{id})
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) {
+		assert.False(t, vulnerabilities[0].Valid)
+	}
+
+	affected, err := tx.FindAffectedNamespacedFeatures(testNamespacedFeatures[:1])
+	if assert.Nil(t, err) && assert.Len(t, affected, 1) {
+		assert.Empty(t, affected[0].AffectedBy)
+	}
+}
+
+func TestWalkVulnerabilities(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability("CVE-2018-0001")}))
+	// The vulnerabilities are created when their session begins.
+	since := time.Now()
+	tx = restartSession(t, store, tx, true)
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability("CVE-2018-0002")}))
+
+	walk := func(since time.Time) (names []string) {
+		require.Nil(t, tx.WalkVulnerabilities("debian:7", since, func(v database.VulnerabilityWithAffected) error {
+			names = append(names, v.Name)
+			return nil
+		}))
+		return
+	}
+
+	assert.Equal(t, []string{"CVE-2018-0001", "CVE-2018-0002"}, walk(time.Time{}))
+	assert.Equal(t, []string{"CVE-2018-0002"}, walk(since))
+}
+
+func TestWithdrawVulnerabilities(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{
+		testVulnerability("CVE-2018-0001"),
+		testVulnerability("CVE-2018-0002"),
+		testVulnerability("CVE-2018-0003"),
+	}))
+	tx = restartSession(t, store, tx, true)
+
+	ids := []database.VulnerabilityID{{Name: "CVE-2018-0001", Namespace: "debian:7"}, {Name: "CVE-2018-0002", Namespace: "debian:7"}}
+	require.Nil(t, tx.WithdrawVulnerabilities(ids, "rejected"))
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2018-0003", Namespace: "debian:7"}}))
+	assert.Equal(t, commonerr.ErrNotFound, tx.WithdrawVulnerabilities(ids[:1], "rejected"))
+	tx = restartSession(t, store, tx, true)
+
+	// A withdrawn vulnerability is not active anymore.
+	vulnerabilities, err := tx.FindVulnerabilities(ids[:1])
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) {
+		assert.False(t, vulnerabilities[0].Valid)
+	}
+
+	// The second one is inserted again, which cancels its withdrawal.
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability("CVE-2018-0002")}))
+
+	var withdrawn []database.VulnerabilityWithAffected
+	require.Nil(t, tx.WalkWithdrawnVulnerabilities("debian:7", time.Time{}, func(v database.VulnerabilityWithAffected) error {
+		withdrawn = append(withdrawn, v)
+		return nil
+	}))
+	if assert.Len(t, withdrawn, 1) {
+		assert.Equal(t, "CVE-2018-0001", withdrawn[0].Name)
+		assert.Len(t, withdrawn[0].Affected, 1)
+		if assert.NotNil(t, withdrawn[0].Withdrawn) {
+			assert.Equal(t, "rejected", withdrawn[0].Withdrawn.Reason)
+			assert.False(t, withdrawn[0].Withdrawn.Time.IsZero())
+		}
+	}
+
+	require.Nil(t, tx.WalkWithdrawnVulnerabilities("debian:7", time.Now().Add(time.Minute), func(v database.VulnerabilityWithAffected) error {
+		t.Errorf("unexpected withdrawn vulnerability %s", v.Name)
+		return nil
+	}))
+}
+
+func TestUpdateVulnerabilityMetadata(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability("CVE-2018-0001")}))
+	require.Nil(t, tx.UpdateVulnerabilityMetadata("CVE-2018-0001", "NVD", map[string]interface{}{"score": 9.8}))
+	require.Nil(t, tx.UpdateVulnerabilityMetadata("CVE-2018-0002", "NVD", nil))
+
+	vulnerabilities, err := tx.FindVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2018-0001", Namespace: "debian:7"}})
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) {
+		assert.Equal(t, database.MetadataMap{
+			"score": float64(7),
+			"NVD":   map[string]interface{}{"score": 9.8},
+		}, vulnerabilities[0].Metadata)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	require.Nil(t, tx.PersistLayer("layer", nil, nil, testDetectors))
+	require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+		Name: "ancestry",
+		By:   testDetectors,
+		Layers: []database.AncestryLayer{{Hash: "layer", Features: []database.AncestryFeature{
+			{NamespacedFeature: testNamespacedFeatures[0], FeatureBy: testDetectors[1], NamespaceBy: testDetectors[0]},
+		}}},
+	}))
+
+	old := testVulnerability("CVE-2018-0001")
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{old}))
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: old.Name, Namespace: old.Namespace.Name}}))
+	tx = restartSession(t, store, tx, true)
+
+	updated := old
+	updated.Affected = nil
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{updated}))
+
+	notification := database.VulnerabilityNotification{
+		NotificationHook: database.NotificationHook{Name: "notification", Created: time.Now()},
+		Old:              &old.Vulnerability,
+		New:              &updated.Vulnerability,
+	}
+	assert.IsType(t, &commonerr.ErrBadRequest{}, tx.InsertVulnerabilityNotifications([]database.VulnerabilityNotification{{}}))
+	require.Nil(t, tx.InsertVulnerabilityNotifications([]database.VulnerabilityNotification{notification}))
+	assert.Equal(t, database.ErrInconsistent, tx.InsertVulnerabilityNotifications([]database.VulnerabilityNotification{notification}))
+	tx = restartSession(t, store, tx, true)
+
+	hook, ok, err := tx.FindNewNotification(time.Now())
+	if assert.Nil(t, err) && assert.True(t, ok) {
+		assert.Equal(t, "notification", hook.Name)
+	}
+
+	// The ancestries affected by the old vulnerability are not cached once it
+	// is deleted.
+	noti, ok, err := tx.FindVulnerabilityNotification("notification", 1, pagination.FirstPageToken, pagination.FirstPageToken)
+	if assert.Nil(t, err) && assert.True(t, ok) && assert.NotNil(t, noti.Old) && assert.NotNil(t, noti.New) {
+		assert.Equal(t, "CVE-2018-0001", noti.Old.Name)
+		assert.True(t, noti.Old.End)
+		assert.Empty(t, noti.Old.Affected)
+		assert.True(t, noti.New.End)
+	}
+
+	// A locked notification is being sent.
+	_, _, err = tx.Lock("notification", "owner", time.Minute, false)
+	require.Nil(t, err)
+	_, ok, err = tx.FindNewNotification(time.Now())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	require.Nil(t, tx.Unlock("notification", "owner"))
+
+	require.Nil(t, tx.MarkNotificationAsRead("notification"))
+	_, ok, err = tx.FindNewNotification(time.Time{})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	require.Nil(t, tx.DeleteNotification("notification"))
+	assert.Equal(t, commonerr.ErrNotFound, tx.DeleteNotification("notification"))
+
+	noti, ok, err = tx.FindVulnerabilityNotification("notification", 1, pagination.FirstPageToken, pagination.FirstPageToken)
+	if assert.Nil(t, err) && assert.True(t, ok) {
+		assert.False(t, noti.Deleted.IsZero())
+	}
+}
+
+func TestPendingNotifications(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	vuln := testVulnerability("CVE-2018-0001")
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vuln}))
+
+	now := time.Now()
+	var notifications []database.VulnerabilityNotification
+	for _, hook := range []database.NotificationHook{
+		{Name: "recent", Created: now.Add(-time.Minute)},
+		{Name: "stale", Created: now.Add(-24 * time.Hour)},
+		{Name: "new", Created: now},
+		{Name: "sent", Created: now.Add(-48 * time.Hour)},
+	} {
+		notifications = append(notifications, database.VulnerabilityNotification{NotificationHook: hook, New: &vuln.Vulnerability})
+	}
+	require.Nil(t, tx.InsertVulnerabilityNotifications(notifications))
+	require.Nil(t, tx.MarkNotificationAsRead("sent"))
+
+	// The notified notifications are not pending anymore.
+	count, oldest, err := tx.CountPendingNotifications()
+	if assert.Nil(t, err) {
+		assert.Equal(t, 3, count)
+		assert.Equal(t, notifications[1].Created, oldest)
+	}
+
+	hooks, nextPage, err := tx.FindPendingNotifications(2, pagination.FirstPageToken)
+	if assert.Nil(t, err) && assert.Len(t, hooks, 2) {
+		assert.Equal(t, "recent", hooks[0].Name)
+		assert.Equal(t, "stale", hooks[1].Name)
+		assert.NotEqual(t, pagination.FirstPageToken, nextPage)
+	}
+
+	hooks, nextPage, err = tx.FindPendingNotifications(2, nextPage)
+	if assert.Nil(t, err) && assert.Len(t, hooks, 1) {
+		assert.Equal(t, "new", hooks[0].Name)
+		assert.Equal(t, pagination.FirstPageToken, nextPage)
+	}
+
+	_, _, err = tx.FindPendingNotifications(0, pagination.FirstPageToken)
+	assert.IsType(t, &commonerr.ErrBadRequest{}, err)
+
+	// Only the pending notifications are flushed.
+	names, err := tx.DeletePendingNotificationsBefore(now.Add(-time.Hour))
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"stale"}, names)
+	}
+
+	count, oldest, err = tx.CountPendingNotifications()
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, count)
+		assert.Equal(t, notifications[0].Created, oldest)
+	}
+}
+
+func TestFindVulnerableAncestries(t *testing.T) {
+	store, tx := openSessionForTest(t, true)
+	defer store.Close()
+
+	require.Nil(t, tx.PersistLayer("layer", nil, nil, testDetectors))
+	for _, name := range []string{"ancestry-1", "ancestry-2"} {
+		require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+			Name: name,
+			By:   testDetectors,
+			Layers: []database.AncestryLayer{{Hash: "layer", Features: []database.AncestryFeature{
+				{NamespacedFeature: testNamespacedFeatures[0], FeatureBy: testDetectors[1], NamespaceBy: testDetectors[0]},
+			}}},
+		}))
+	}
+
+	vulnerability := testVulnerability("CVE-2018-0001")
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+	require.Nil(t, tx.InsertVulnerabilityNotifications([]database.VulnerabilityNotification{{
+		NotificationHook: database.NotificationHook{Name: "notification", Created: time.Now()},
+		New:              &vulnerability.Vulnerability,
+	}}))
+
+	var (
+		names []string
+		page  = pagination.FirstPageToken
+	)
+	for {
+		noti, ok, err := tx.FindVulnerabilityNotification("notification", 1, pagination.FirstPageToken, page)
+		require.Nil(t, err)
+		require.True(t, ok)
+		require.Nil(t, noti.Old)
+
+		for _, name := range noti.New.Affected {
+			names = append(names, name)
+		}
+
+		if noti.New.End {
+			break
+		}
+		page = noti.New.Next
+	}
+
+	assert.Equal(t, []string{"ancestry-1", "ancestry-2"}, names)
+}
@@ -23,32 +23,45 @@ import (
 // MockSession implements Session and enables overriding each available method.
 // The default behavior of each method is to simply panic.
 type MockSession struct {
-	FctCommit                           func() error
-	FctRollback                         func() error
-	FctUpsertAncestry                   func(Ancestry) error
-	FctFindAncestry                     func(name string) (Ancestry, bool, error)
-	FctFindAffectedNamespacedFeatures   func(features []NamespacedFeature) ([]NullableAffectedNamespacedFeature, error)
-	FctPersistNamespaces                func([]Namespace) error
-	FctPersistFeatures                  func([]Feature) error
-	FctPersistDetectors                 func(detectors []Detector) error
-	FctPersistNamespacedFeatures        func([]NamespacedFeature) error
-	FctCacheAffectedNamespacedFeatures  func([]NamespacedFeature) error
-	FctPersistLayer                     func(hash string, features []LayerFeature, namespaces []LayerNamespace, by []Detector) error
-	FctFindLayer                        func(name string) (Layer, bool, error)
-	FctInsertVulnerabilities            func([]VulnerabilityWithAffected) error
-	FctFindVulnerabilities              func([]VulnerabilityID) ([]NullableVulnerability, error)
-	FctDeleteVulnerabilities            func([]VulnerabilityID) error
-	FctInsertVulnerabilityNotifications func([]VulnerabilityNotification) error
-	FctFindNewNotification              func(lastNotified time.Time) (NotificationHook, bool, error)
-	FctFindVulnerabilityNotification    func(name string, limit int, oldPage pagination.Token, newPage pagination.Token) (
+	FctCommit                                  func() error
+	FctRollback                                func() error
+	FctUpsertAncestry                          func(Ancestry) error
+	FctFindAncestry                            func(name string) (Ancestry, bool, error)
+	FctDeleteAncestry                          func(name string) error
+	FctFindAffectedNamespacedFeatures          func(features []NamespacedFeature) ([]NullableAffectedNamespacedFeature, error)
+	FctFindAffectedNamespacedFeatureSeverities func(features []NamespacedFeature) ([]NullableAffectedNamespacedFeature, error)
+	FctFindFeatureLocations                    func(name, version, namespace string, limit int, page pagination.Token) ([]FeatureLocation, pagination.Token, error)
+	FctPersistNamespaces                       func([]Namespace) error
+	FctFindNamespaces                          func(prefix string, limit int, page pagination.Token) ([]Namespace, pagination.Token, error)
+	FctPersistFeatures                         func([]Feature) error
+	FctPersistDetectors                        func(detectors []Detector) error
+	FctPersistNamespacedFeatures               func([]NamespacedFeature) error
+	FctCacheAffectedNamespacedFeatures         func([]NamespacedFeature) error
+	FctPersistLayer                            func(hash string, features []LayerFeature, namespaces []LayerNamespace, by []Detector) error
+	FctFindLayer                               func(name string) (Layer, bool, error)
+	FctDeleteLayer                             func(hash string) error
+	FctDeleteAnalysesBefore                    func(before time.Time) ([]string, []string, error)
+	FctInsertVulnerabilities                   func([]VulnerabilityWithAffected) error
+	FctFindVulnerabilities                     func([]VulnerabilityID) ([]NullableVulnerability, error)
+	FctWalkVulnerabilities                     func(namespace string, since time.Time, fn func(VulnerabilityWithAffected) error) error
+	FctDeleteVulnerabilities                   func([]VulnerabilityID) error
+	FctWithdrawVulnerabilities                 func(vulnerabilities []VulnerabilityID, reason string) error
+	FctWalkWithdrawnVulnerabilities            func(namespace string, since time.Time, fn func(VulnerabilityWithAffected) error) error
+	FctUpdateVulnerabilityMetadata             func(name, key string, metadata interface{}) error
+	FctInsertVulnerabilityNotifications        func([]VulnerabilityNotification) error
+	FctFindNewNotification                     func(lastNotified time.Time) (NotificationHook, bool, error)
+	FctFindVulnerabilityNotification           func(name string, limit int, oldPage pagination.Token, newPage pagination.Token) (
 		vuln VulnerabilityNotificationWithVulnerable, ok bool, err error)
-	FctMarkNotificationAsRead func(name string) error
-	FctDeleteNotification     func(name string) error
-	FctUpdateKeyValue         func(key, value string) error
-	FctFindKeyValue           func(key string) (string, bool, error)
-	FctLock                   func(name string, owner string, duration time.Duration, renew bool) (bool, time.Time, error)
-	FctUnlock                 func(name, owner string) error
-	FctFindLock               func(name string) (string, time.Time, bool, error)
+	FctMarkNotificationAsRead           func(name string) error
+	FctDeleteNotification               func(name string) error
+	FctFindPendingNotifications         func(limit int, page pagination.Token) ([]NotificationHook, pagination.Token, error)
+	FctCountPendingNotifications        func() (int, time.Time, error)
+	FctDeletePendingNotificationsBefore func(before time.Time) ([]string, error)
+	FctUpdateKeyValue                   func(key, value string) error
+	FctFindKeyValue                     func(key string) (string, bool, error)
+	FctLock                             func(name string, owner string, duration time.Duration, renew bool) (bool, time.Time, error)
+	FctUnlock                           func(name, owner string) error
+	FctFindLock                         func(name string) (string, time.Time, bool, error)
 }
 
 func (ms *MockSession) Commit() error {
@@ -79,6 +92,13 @@ func (ms *MockSession) FindAncestry(name string) (Ancestry, bool, error) {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) DeleteAncestry(name string) error {
+	if ms.FctDeleteAncestry != nil {
+		return ms.FctDeleteAncestry(name)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) FindAffectedNamespacedFeatures(features []NamespacedFeature) ([]NullableAffectedNamespacedFeature, error) {
 	if ms.FctFindAffectedNamespacedFeatures != nil {
 		return ms.FctFindAffectedNamespacedFeatures(features)
@@ -86,6 +106,13 @@ func (ms *MockSession) FindAffectedNamespacedFeatures(features []NamespacedFeatu
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) FindAffectedNamespacedFeatureSeverities(features []NamespacedFeature) ([]NullableAffectedNamespacedFeature, error) {
+	if ms.FctFindAffectedNamespacedFeatureSeverities != nil {
+		return ms.FctFindAffectedNamespacedFeatureSeverities(features)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) PersistDetectors(detectors []Detector) error {
 	if ms.FctPersistDetectors != nil {
 		return ms.FctPersistDetectors(detectors)
@@ -93,6 +120,13 @@ func (ms *MockSession) PersistDetectors(detectors []Detector) error {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) FindFeatureLocations(name, version, namespace string, limit int, page pagination.Token) ([]FeatureLocation, pagination.Token, error) {
+	if ms.FctFindFeatureLocations != nil {
+		return ms.FctFindFeatureLocations(name, version, namespace, limit, page)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) PersistNamespaces(namespaces []Namespace) error {
 	if ms.FctPersistNamespaces != nil {
 		return ms.FctPersistNamespaces(namespaces)
@@ -100,6 +134,13 @@ func (ms *MockSession) PersistNamespaces(namespaces []Namespace) error {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) FindNamespaces(prefix string, limit int, page pagination.Token) ([]Namespace, pagination.Token, error) {
+	if ms.FctFindNamespaces != nil {
+		return ms.FctFindNamespaces(prefix, limit, page)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) PersistFeatures(features []Feature) error {
 	if ms.FctPersistFeatures != nil {
 		return ms.FctPersistFeatures(features)
@@ -135,6 +176,20 @@ func (ms *MockSession) FindLayer(name string) (Layer, bool, error) {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) DeleteLayer(hash string) error {
+	if ms.FctDeleteLayer != nil {
+		return ms.FctDeleteLayer(hash)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) DeleteAnalysesBefore(before time.Time) ([]string, []string, error) {
+	if ms.FctDeleteAnalysesBefore != nil {
+		return ms.FctDeleteAnalysesBefore(before)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) InsertVulnerabilities(vulnerabilities []VulnerabilityWithAffected) error {
 	if ms.FctInsertVulnerabilities != nil {
 		return ms.FctInsertVulnerabilities(vulnerabilities)
@@ -149,6 +204,13 @@ func (ms *MockSession) FindVulnerabilities(vulnerabilityIDs []VulnerabilityID) (
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) WalkVulnerabilities(namespace string, since time.Time, fn func(VulnerabilityWithAffected) error) error {
+	if ms.FctWalkVulnerabilities != nil {
+		return ms.FctWalkVulnerabilities(namespace, since, fn)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) DeleteVulnerabilities(VulnerabilityIDs []VulnerabilityID) error {
 	if ms.FctDeleteVulnerabilities != nil {
 		return ms.FctDeleteVulnerabilities(VulnerabilityIDs)
@@ -156,6 +218,27 @@ func (ms *MockSession) DeleteVulnerabilities(VulnerabilityIDs []VulnerabilityID)
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) WithdrawVulnerabilities(vulnerabilities []VulnerabilityID, reason string) error {
+	if ms.FctWithdrawVulnerabilities != nil {
+		return ms.FctWithdrawVulnerabilities(vulnerabilities, reason)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) WalkWithdrawnVulnerabilities(namespace string, since time.Time, fn func(VulnerabilityWithAffected) error) error {
+	if ms.FctWalkWithdrawnVulnerabilities != nil {
+		return ms.FctWalkWithdrawnVulnerabilities(namespace, since, fn)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) UpdateVulnerabilityMetadata(name, key string, metadata interface{}) error {
+	if ms.FctUpdateVulnerabilityMetadata != nil {
+		return ms.FctUpdateVulnerabilityMetadata(name, key, metadata)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) InsertVulnerabilityNotifications(vulnerabilityNotifications []VulnerabilityNotification) error {
 	if ms.FctInsertVulnerabilityNotifications != nil {
 		return ms.FctInsertVulnerabilityNotifications(vulnerabilityNotifications)
@@ -192,6 +275,27 @@ func (ms *MockSession) DeleteNotification(name string) error {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) FindPendingNotifications(limit int, page pagination.Token) ([]NotificationHook, pagination.Token, error) {
+	if ms.FctFindPendingNotifications != nil {
+		return ms.FctFindPendingNotifications(limit, page)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) CountPendingNotifications() (int, time.Time, error) {
+	if ms.FctCountPendingNotifications != nil {
+		return ms.FctCountPendingNotifications()
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) DeletePendingNotificationsBefore(before time.Time) ([]string, error) {
+	if ms.FctDeletePendingNotificationsBefore != nil {
+		return ms.FctDeletePendingNotificationsBefore(before)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) UpdateKeyValue(key, value string) error {
 	if ms.FctUpdateKeyValue != nil {
 		return ms.FctUpdateKeyValue(key, value)
@@ -179,6 +179,15 @@ type NamespacedFeature struct {
 	Namespace Namespace
 }
 
+// FeatureLocation is an occurrence of a namespaced feature in an ancestry,
+// with the layer that introduced it.
+type FeatureLocation struct {
+	NamespacedFeature
+
+	AncestryName string
+	LayerHash    string
+}
+
 // AffectedNamespacedFeature is a namespaced feature affected by the
 // vulnerabilities with fixed-in versions for this feature.
 type AffectedNamespacedFeature struct {
@@ -193,6 +202,23 @@ type VulnerabilityWithFixedIn struct {
 	Vulnerability
 
 	FixedInVersion string
+
+	// Tag is the tag of the affected feature through which the vulnerability
+	// affects the feature.
+	Tag string
+
+	// AffectedRanges are the ranges of versions of the feature affected by
+	// the vulnerability, when its data source gives more than the version
+	// fixing it.
+	AffectedRanges []AffectedRange
+}
+
+// AffectedRange is a range of versions of a feature affected by a
+// vulnerability, whose bounds are empty when they are unknown.
+type AffectedRange struct {
+	IntroducedInVersion string
+	FixedInVersion      string
+	LastAffectedVersion string
 }
 
 // AffectedFeature is used to determine whether a namespaced feature is affected
@@ -210,6 +236,19 @@ type AffectedFeature struct {
 	// AffectedVersion contains the version range to determine whether or not a
 	// feature is affected.
 	AffectedVersion string
+	// IntroducedInVersion is the first feature version affected by the
+	// vulnerability. Empty IntroducedInVersion means that every version in
+	// AffectedVersion is affected.
+	IntroducedInVersion string
+	// LastAffectedVersion is the last feature version affected by the
+	// vulnerability, when the data source gives it instead of the version
+	// fixing it. Empty LastAffectedVersion means that every version in
+	// AffectedVersion is affected.
+	LastAffectedVersion string
+	// Tag is how the data source triages the vulnerability for this feature,
+	// e.g. "no-dsa" when Debian does not plan a security advisory for it. Empty
+	// Tag means that the data source did not tag it.
+	Tag string
 }
 
 // VulnerabilityID is an identifier for every vulnerability. Every vulnerability
@@ -229,6 +268,20 @@ type Vulnerability struct {
 	Severity    Severity
 
 	Metadata MetadataMap
+
+	// Withdrawn is only set on the vulnerabilities withdrawn by their data
+	// source.
+	Withdrawn *Withdrawal
+}
+
+// Withdrawal is the rescission of a vulnerability by its data source. The
+// withdrawn vulnerability is kept for history, but affects no feature anymore.
+type Withdrawal struct {
+	// Time is when the vulnerability was found to be withdrawn.
+	Time time.Time
+
+	// Reason explains why the vulnerability was withdrawn.
+	Reason string
 }
 
 // VulnerabilityWithAffected is a vulnerability with all known affected
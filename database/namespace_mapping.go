@@ -17,12 +17,15 @@ package database
 // DebianReleasesMapping translates Debian code names and class names to version numbers
 var DebianReleasesMapping = map[string]string{
 	// Code names
-	"squeeze": "6",
-	"wheezy":  "7",
-	"jessie":  "8",
-	"stretch": "9",
-	"buster":  "10",
-	"sid":     "unstable",
+	"squeeze":  "6",
+	"wheezy":   "7",
+	"jessie":   "8",
+	"stretch":  "9",
+	"buster":   "10",
+	"bullseye": "11",
+	"bookworm": "12",
+	"trixie":   "13",
+	"sid":      "unstable",
 
 	// Class names
 	"oldoldstable": "7",
@@ -34,17 +37,31 @@ var DebianReleasesMapping = map[string]string{
 
 // UbuntuReleasesMapping translates Ubuntu code names to version numbers
 var UbuntuReleasesMapping = map[string]string{
-	"precise": "12.04",
-	"quantal": "12.10",
-	"raring":  "13.04",
-	"trusty":  "14.04",
-	"utopic":  "14.10",
-	"vivid":   "15.04",
-	"wily":    "15.10",
-	"xenial":  "16.04",
-	"yakkety": "16.10",
-	"zesty":   "17.04",
-	"artful":  "17.10",
-	"bionic":  "18.04",
-	"cosmic":  "18.10",
+	"precise":  "12.04",
+	"quantal":  "12.10",
+	"raring":   "13.04",
+	"trusty":   "14.04",
+	"utopic":   "14.10",
+	"vivid":    "15.04",
+	"wily":     "15.10",
+	"xenial":   "16.04",
+	"yakkety":  "16.10",
+	"zesty":    "17.04",
+	"artful":   "17.10",
+	"bionic":   "18.04",
+	"cosmic":   "18.10",
+	"disco":    "19.04",
+	"eoan":     "19.10",
+	"focal":    "20.04",
+	"groovy":   "20.10",
+	"hirsute":  "21.04",
+	"impish":   "21.10",
+	"jammy":    "22.04",
+	"kinetic":  "22.10",
+	"lunar":    "23.04",
+	"mantic":   "23.10",
+	"noble":    "24.04",
+	"oracular": "24.10",
+	"plucky":   "25.04",
+	"questing": "25.10",
 }
@@ -44,6 +44,8 @@ const (
 )
 
 func (tx *pgSession) FindAncestry(name string) (database.Ancestry, bool, error) {
+	defer tx.useReplica()()
+
 	var (
 		ancestry = database.Ancestry{Name: name}
 		err      error
@@ -66,6 +68,8 @@ func (tx *pgSession) FindAncestry(name string) (database.Ancestry, bool, error)
 }
 
 func (tx *pgSession) UpsertAncestry(ancestry database.Ancestry) error {
+	tx.markWritten()
+
 	if !ancestry.Valid() {
 		return database.ErrInvalidParameters
 	}
@@ -118,6 +122,31 @@ func (tx *pgSession) UpsertAncestry(ancestry database.Ancestry) error {
 	return nil
 }
 
+func (tx *pgSession) DeleteAncestry(name string) error {
+	tx.markWritten()
+
+	if name == "" {
+		return commonerr.NewBadRequestError("Empty ancestry name is not allowed")
+	}
+
+	result, err := tx.Exec(removeAncestry, name)
+	if err != nil {
+		return handleError("removeAncestry", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return handleError("removeAncestry", err)
+	}
+
+	if affected <= 0 {
+		return commonerr.ErrNotFound
+	}
+
+	log.WithField("ancestry", name).Debug("deleted ancestry")
+	return nil
+}
+
 func (tx *pgSession) insertAncestry(name string) (int64, error) {
 	var id int64
 	err := tx.QueryRow(insertAncestry, name).Scan(&id)
@@ -59,6 +59,8 @@ type detectorMap struct {
 }
 
 func (tx *pgSession) PersistDetectors(detectors []database.Detector) error {
+	tx.markWritten()
+
 	for _, d := range detectors {
 		if !d.Valid() {
 			log.WithField("detector", d).Debug("Invalid Detector")
@@ -24,6 +24,7 @@ import (
 	"github.com/coreos/clair/database"
 	"github.com/coreos/clair/ext/versionfmt"
 	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/pagination"
 )
 
 const (
@@ -38,20 +39,37 @@ const (
 		UNION
 		SELECT id FROM new_feature_ns`
 
+	searchFeatureLocationPage = `
+		SELECT af.id, a.name, l.hash, n.name, n.version_format, f.version_format
+		FROM ancestry_feature AS af
+			JOIN ancestry_layer AS al ON af.ancestry_layer_id = al.id
+			JOIN ancestry AS a ON al.ancestry_id = a.id
+			JOIN layer AS l ON al.layer_id = l.id
+			JOIN namespaced_feature AS nf ON af.namespaced_feature_id = nf.id
+			JOIN namespace AS n ON nf.namespace_id = n.id
+			JOIN feature AS f ON nf.feature_id = f.id
+		WHERE f.name = $1
+			AND f.version = $2
+			AND ($3 = '' OR n.name = $3)
+			AND af.id >= $4
+		ORDER BY af.id
+		LIMIT $5`
+
 	searchPotentialAffectingVulneraibilities = `
-		SELECT nf.id, v.id, vaf.affected_version, vaf.id
+		SELECT nf.id, v.id, vaf.affected_version, vaf.introducedin, vaf.lastaffected, vaf.id,
+			CASE WHEN vaf.feature_name = f.name THEN f.version ELSE f.source_version END
 		FROM vulnerability_affected_feature AS vaf, vulnerability AS v,
 			namespaced_feature AS nf, feature AS f
 		WHERE nf.id = ANY($1)
 			AND nf.feature_id = f.id
 			AND nf.namespace_id = v.namespace_id
-			AND vaf.feature_name = f.name
+			AND (vaf.feature_name = f.name OR (vaf.feature_name = f.source_name AND f.source_version <> ''))
 			AND vaf.vulnerability_id = v.id
 			AND v.deleted_at IS NULL`
 
 	searchNamespacedFeaturesVulnerabilities = `
-		SELECT vanf.namespaced_feature_id, v.name, v.description, v.link, 
-			v.severity, v.metadata, vaf.fixedin, n.name, n.version_format
+		SELECT vanf.namespaced_feature_id, v.id, v.name, v.description, v.link, 
+			v.severity, v.metadata, vaf.feature_name, vaf.fixedin, vaf.tag, n.name, n.version_format
 		FROM vulnerability_affected_namespaced_feature AS vanf, 
 			Vulnerability AS v,
 			vulnerability_affected_feature AS vaf,
@@ -61,9 +79,29 @@ const (
 			AND v.id = vanf.vulnerability_id
 			AND n.id = v.namespace_id
 			AND v.deleted_at IS NULL`
+
+	searchNamespacedFeaturesVulnerabilitySeverities = `
+		SELECT vanf.namespaced_feature_id, v.name, v.severity, vaf.fixedin, vaf.tag, n.name, n.version_format
+		FROM vulnerability_affected_namespaced_feature AS vanf,
+			Vulnerability AS v,
+			vulnerability_affected_feature AS vaf,
+			namespace AS n
+		WHERE vanf.namespaced_feature_id = ANY($1)
+			AND vaf.id = vanf.added_by
+			AND v.id = vanf.vulnerability_id
+			AND n.id = v.namespace_id
+			AND v.deleted_at IS NULL`
+
+	searchVulnerabilityAffectedRanges = `
+		SELECT vulnerability_id, feature_name, fixedin, introducedin, lastaffected
+		FROM vulnerability_affected_feature
+		WHERE vulnerability_id = ANY($1)
+		ORDER BY id`
 )
 
 func (tx *pgSession) PersistFeatures(features []database.Feature) error {
+	tx.markWritten()
+
 	if len(features) == 0 {
 		return nil
 	}
@@ -75,12 +113,28 @@ func (tx *pgSession) PersistFeatures(features []database.Feature) error {
 			features[i].VersionFormat < features[j].VersionFormat
 	})
 
+	// A feature is only inserted once, as the conflicting rows of an insertion
+	// can only be updated once.
+	unique := features[:0:0]
+	seen := make(map[database.Feature]struct{}, len(features))
+	for _, f := range features {
+		key := database.Feature{Name: f.Name, Version: f.Version, VersionFormat: f.VersionFormat}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, f)
+	}
+	features = unique
+
 	// TODO(Sida): A better interface for bulk insertion is needed.
-	keys := make([]interface{}, len(features)*3)
+	keys := make([]interface{}, len(features)*5)
 	for i, f := range features {
-		keys[i*3] = f.Name
-		keys[i*3+1] = f.Version
-		keys[i*3+2] = f.VersionFormat
+		keys[i*5] = f.Name
+		keys[i*5+1] = f.Version
+		keys[i*5+2] = f.VersionFormat
+		keys[i*5+3] = f.SourceName
+		keys[i*5+4] = f.SourceVersion
 		if f.Name == "" || f.Version == "" || f.VersionFormat == "" {
 			return commonerr.NewBadRequestError("Empty feature name, version or version format is not allowed")
 		}
@@ -129,16 +183,20 @@ func (tx *pgSession) searchAffectingVulnerabilities(features []database.Namespac
 	defer rows.Close()
 	for rows.Next() {
 		var (
-			cache    vulnerabilityCache
-			affected string
+			cache                      vulnerabilityCache
+			affected, version          string
+			introducedIn, lastAffected sql.NullString
 		)
 
-		err := rows.Scan(&cache.nsFeatureID, &cache.vulnID, &affected, &cache.vulnAffectingID)
+		// The version is the one of the source package when the vulnerability
+		// affects the source package of the feature.
+		err := rows.Scan(&cache.nsFeatureID, &cache.vulnID, &affected, &introducedIn, &lastAffected, &cache.vulnAffectingID, &version)
 		if err != nil {
 			return nil, err
 		}
 
-		if ok, err := versionfmt.InRange(fMap[cache.nsFeatureID].VersionFormat, fMap[cache.nsFeatureID].Version, affected); err != nil {
+		f := fMap[cache.nsFeatureID]
+		if ok, err := versionfmt.InAffectedRange(versionfmt.NamespaceFormat(f.Namespace.Name, f.VersionFormat), version, introducedIn.String, lastAffected.String, affected); err != nil {
 			return nil, err
 		} else if ok {
 			cacheTable = append(cacheTable, cache)
@@ -149,6 +207,8 @@ func (tx *pgSession) searchAffectingVulnerabilities(features []database.Namespac
 }
 
 func (tx *pgSession) CacheAffectedNamespacedFeatures(features []database.NamespacedFeature) error {
+	tx.markWritten()
+
 	if len(features) == 0 {
 		return nil
 	}
@@ -176,12 +236,14 @@ func (tx *pgSession) CacheAffectedNamespacedFeatures(features []database.Namespa
 		return handleError("persistVulnerabilityAffectedNamespacedFeature", err)
 	}
 	if count, err := affected.RowsAffected(); err != nil {
-		log.Debugf("Cached %d features in vulnerability_affected_namespaced_feature", count)
+		log.WithField("count", count).Debug("cached features in vulnerability_affected_namespaced_feature")
 	}
 	return nil
 }
 
 func (tx *pgSession) PersistNamespacedFeatures(features []database.NamespacedFeature) error {
+	tx.markWritten()
+
 	if len(features) == 0 {
 		return nil
 	}
@@ -248,10 +310,132 @@ func (tx *pgSession) PersistNamespacedFeatures(features []database.NamespacedFea
 // FindAffectedNamespacedFeatures looks up cache table and retrieves all
 // vulnerabilities associated with the features.
 func (tx *pgSession) FindAffectedNamespacedFeatures(features []database.NamespacedFeature) ([]database.NullableAffectedNamespacedFeature, error) {
+	defer tx.useReplica()()
+
+	if len(features) == 0 {
+		return nil, nil
+	}
+
+	returnFeatures, featureIDMap, toQuery, err := tx.findAffectedNamespacedFeatureIDs(features)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(searchNamespacedFeaturesVulnerabilities, pq.Array(toQuery))
+	if err != nil {
+		return nil, handleError("searchNamespacedFeaturesVulnerabilities", err)
+	}
+	defer rows.Close()
+
+	type affectingVulnerability struct {
+		featureID   int64
+		vulnID      int64
+		featureName string
+		vuln        database.VulnerabilityWithFixedIn
+	}
+
+	var (
+		affecting []affectingVulnerability
+		vulnIDs   []int64
+	)
+	for rows.Next() {
+		var a affectingVulnerability
+		err := rows.Scan(&a.featureID,
+			&a.vulnID,
+			&a.vuln.Name,
+			&a.vuln.Description,
+			&a.vuln.Link,
+			&a.vuln.Severity,
+			&a.vuln.Metadata,
+			&a.featureName,
+			&a.vuln.FixedInVersion,
+			&a.vuln.Tag,
+			&a.vuln.Namespace.Name,
+			&a.vuln.Namespace.VersionFormat,
+		)
+		if err != nil {
+			return nil, handleError("searchNamespacedFeaturesVulnerabilities", err)
+		}
+
+		affecting = append(affecting, a)
+		vulnIDs = append(vulnIDs, a.vulnID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, handleError("searchNamespacedFeaturesVulnerabilities", err)
+	}
+
+	ranges, err := tx.findVulnerabilityAffectedRanges(vulnIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range affecting {
+		// The ranges are the ones of the package through which the feature
+		// is affected.
+		a.vuln.AffectedRanges = database.GetAffectedRanges(ranges[affectedRangesKey{a.vulnID, a.featureName}])
+		for _, f := range featureIDMap[a.featureID] {
+			f.AffectedBy = append(f.AffectedBy, a.vuln)
+		}
+	}
+
+	return returnFeatures, nil
+}
+
+// FindAffectedNamespacedFeatureSeverities looks up cache table like
+// FindAffectedNamespacedFeatures, but only retrieves the name, namespace,
+// severity, fixed in version and tag of the vulnerabilities.
+func (tx *pgSession) FindAffectedNamespacedFeatureSeverities(features []database.NamespacedFeature) ([]database.NullableAffectedNamespacedFeature, error) {
+	defer tx.useReplica()()
+
 	if len(features) == 0 {
 		return nil, nil
 	}
 
+	returnFeatures, featureIDMap, toQuery, err := tx.findAffectedNamespacedFeatureIDs(features)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(searchNamespacedFeaturesVulnerabilitySeverities, pq.Array(toQuery))
+	if err != nil {
+		return nil, handleError("searchNamespacedFeaturesVulnerabilitySeverities", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			featureID int64
+			vuln      database.VulnerabilityWithFixedIn
+		)
+		err := rows.Scan(&featureID,
+			&vuln.Name,
+			&vuln.Severity,
+			&vuln.FixedInVersion,
+			&vuln.Tag,
+			&vuln.Namespace.Name,
+			&vuln.Namespace.VersionFormat,
+		)
+		if err != nil {
+			return nil, handleError("searchNamespacedFeaturesVulnerabilitySeverities", err)
+		}
+
+		for _, f := range featureIDMap[featureID] {
+			f.AffectedBy = append(f.AffectedBy, vuln)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, handleError("searchNamespacedFeaturesVulnerabilitySeverities", err)
+	}
+
+	return returnFeatures, nil
+}
+
+// findAffectedNamespacedFeatureIDs initializes the affected features returned
+// for the given features, and returns the IDs of the ones found in the
+// database, with the returned features that each of them identifies.
+func (tx *pgSession) findAffectedNamespacedFeatureIDs(features []database.NamespacedFeature) ([]database.NullableAffectedNamespacedFeature, map[int64][]*database.NullableAffectedNamespacedFeature, []int64, error) {
 	returnFeatures := make([]database.NullableAffectedNamespacedFeature, len(features))
 
 	// featureMap is used to keep track of duplicated features.
@@ -275,7 +459,7 @@ func (tx *pgSession) FindAffectedNamespacedFeatures(features []database.Namespac
 
 	nsFeatureIDs, err := tx.findNamespacedFeatureIDs(distinctFeatures)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	toQuery := []int64{}
@@ -290,37 +474,52 @@ func (tx *pgSession) FindAffectedNamespacedFeatures(features []database.Namespac
 		}
 	}
 
-	rows, err := tx.Query(searchNamespacedFeaturesVulnerabilities, pq.Array(toQuery))
+	return returnFeatures, featureIDMap, toQuery, nil
+}
+
+// affectedRangesKey identifies the affected features of a vulnerability for a
+// package.
+type affectedRangesKey struct {
+	vulnID      int64
+	featureName string
+}
+
+// findVulnerabilityAffectedRanges returns the affected features of the
+// vulnerabilities, by package, whose only fields set are the bounds of their
+// ranges.
+func (tx *pgSession) findVulnerabilityAffectedRanges(vulnIDs []int64) (map[affectedRangesKey][]database.AffectedFeature, error) {
+	ranges := map[affectedRangesKey][]database.AffectedFeature{}
+	if len(vulnIDs) == 0 {
+		return ranges, nil
+	}
+
+	rows, err := tx.Query(searchVulnerabilityAffectedRanges, pq.Array(vulnIDs))
 	if err != nil {
-		return nil, handleError("searchNamespacedFeaturesVulnerabilities", err)
+		return nil, handleError("searchVulnerabilityAffectedRanges", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var (
-			featureID int64
-			vuln      database.VulnerabilityWithFixedIn
+			key                        affectedRangesKey
+			f                          database.AffectedFeature
+			introducedIn, lastAffected sql.NullString
 		)
-		err := rows.Scan(&featureID,
-			&vuln.Name,
-			&vuln.Description,
-			&vuln.Link,
-			&vuln.Severity,
-			&vuln.Metadata,
-			&vuln.FixedInVersion,
-			&vuln.Namespace.Name,
-			&vuln.Namespace.VersionFormat,
-		)
-		if err != nil {
-			return nil, handleError("searchNamespacedFeaturesVulnerabilities", err)
-		}
 
-		for _, f := range featureIDMap[featureID] {
-			f.AffectedBy = append(f.AffectedBy, vuln)
+		if err := rows.Scan(&key.vulnID, &key.featureName, &f.FixedInVersion, &introducedIn, &lastAffected); err != nil {
+			return nil, handleError("searchVulnerabilityAffectedRanges", err)
 		}
+		f.IntroducedInVersion = introducedIn.String
+		f.LastAffectedVersion = lastAffected.String
+
+		ranges[key] = append(ranges[key], f)
 	}
 
-	return returnFeatures, nil
+	if err := rows.Err(); err != nil {
+		return nil, handleError("searchVulnerabilityAffectedRanges", err)
+	}
+
+	return ranges, nil
 }
 
 func (tx *pgSession) findNamespacedFeatureIDs(nfs []database.NamespacedFeature) ([]sql.NullInt64, error) {
@@ -406,3 +605,71 @@ func (tx *pgSession) findFeatureIDs(fs []database.Feature) ([]sql.NullInt64, err
 
 	return ids, nil
 }
+
+func (tx *pgSession) FindFeatureLocations(name, version, namespace string, limit int, pageToken pagination.Token) ([]database.FeatureLocation, pagination.Token, error) {
+	defer tx.useReplica()()
+
+	if name == "" || version == "" {
+		return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("feature name and version should not be empty")
+	}
+
+	if limit <= 0 {
+		return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("feature location page limit should be positive")
+	}
+
+	page := Page{0}
+	if pageToken != pagination.FirstPageToken {
+		if err := tx.key.UnmarshalToken(pageToken, &page); err == pagination.ErrExpiredToken || err == pagination.ErrFutureToken {
+			return nil, pagination.FirstPageToken, err
+		} else if err != nil {
+			return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("invalid feature location page token")
+		}
+	}
+
+	// One more location is retrieved to find the start of the next page.
+	rows, err := tx.Query(searchFeatureLocationPage, name, version, namespace, page.StartID, limit+1)
+	if err != nil {
+		return nil, pagination.FirstPageToken, handleError("searchFeatureLocationPage", err)
+	}
+	defer rows.Close()
+
+	var (
+		locations = []database.FeatureLocation{}
+		nextID    int64
+	)
+	for rows.Next() {
+		var (
+			id       int64
+			location = database.FeatureLocation{
+				NamespacedFeature: database.NamespacedFeature{
+					Feature: database.Feature{Name: name, Version: version},
+				},
+			}
+		)
+
+		if err := rows.Scan(&id, &location.AncestryName, &location.LayerHash, &location.Namespace.Name, &location.Namespace.VersionFormat, &location.VersionFormat); err != nil {
+			return nil, pagination.FirstPageToken, handleError("searchFeatureLocationPage", err)
+		}
+
+		if len(locations) == limit {
+			nextID = id
+			break
+		}
+		locations = append(locations, location)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, pagination.FirstPageToken, handleError("searchFeatureLocationPage", err)
+	}
+
+	if nextID == 0 {
+		return locations, pagination.FirstPageToken, nil
+	}
+
+	nextPage, err := tx.key.MarshalToken(Page{nextID})
+	if err != nil {
+		return nil, pagination.FirstPageToken, err
+	}
+
+	return locations, nextPage, nil
+}
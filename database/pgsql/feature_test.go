@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/pagination"
 
 	// register dpkg feature lister for testing
 	_ "github.com/coreos/clair/ext/featurefmt/dpkg"
@@ -44,6 +45,19 @@ func TestPersistFeatures(t *testing.T) {
 	fs := listFeatures(t, tx)
 	assert.Len(t, fs, 1)
 	assert.Equal(t, f2, fs[0])
+
+	// The source package of an existing feature stored without it is filled
+	// in, but never replaced.
+	f3 := f2
+	f3.SourceName, f3.SourceVersion = "s", "sv"
+	assert.Nil(t, tx.PersistFeatures([]database.Feature{f3}))
+	f4 := f3
+	f4.SourceName, f4.SourceVersion = "other", "ov"
+	assert.Nil(t, tx.PersistFeatures([]database.Feature{f4}))
+
+	fs = listFeatures(t, tx)
+	assert.Len(t, fs, 1)
+	assert.Equal(t, f3, fs[0])
 }
 
 func TestPersistNamespacedFeatures(t *testing.T) {
@@ -116,6 +130,54 @@ func TestPersistNamespacedFeatures(t *testing.T) {
 	assert.Contains(t, all, nf3)
 }
 
+func TestFindFeatureLocations(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "FindFeatureLocations", true)
+	defer closeTest(t, datastore, tx)
+
+	// Invalid Case
+	_, _, err := tx.FindFeatureLocations("", "1.0", "", 10, pagination.FirstPageToken)
+	assert.NotNil(t, err)
+	_, _, err = tx.FindFeatureLocations("openssl", "1.0", "", 0, pagination.FirstPageToken)
+	assert.NotNil(t, err)
+	_, _, err = tx.FindFeatureLocations("openssl", "1.0", "", 10, pagination.Token("invalid"))
+	assert.NotNil(t, err)
+
+	// Unknown Feature
+	locations, next, err := tx.FindFeatureLocations("openssl", "3.0", "", 10, pagination.FirstPageToken)
+	if assert.Nil(t, err) {
+		assert.Empty(t, locations)
+		assert.Equal(t, pagination.FirstPageToken, next)
+	}
+
+	// Paginated Case
+	locations, next, err = tx.FindFeatureLocations("openssl", "1.0", "", 2, pagination.FirstPageToken)
+	if assert.Nil(t, err) && assert.Len(t, locations, 2) {
+		assert.Equal(t, database.FeatureLocation{
+			NamespacedFeature: database.NamespacedFeature{
+				Feature:   database.Feature{Name: "openssl", Version: "1.0", VersionFormat: "dpkg"},
+				Namespace: database.Namespace{Name: "debian:8", VersionFormat: "dpkg"},
+			},
+			AncestryName: "ancestry-2",
+			LayerHash:    "layer-3b",
+		}, locations[0])
+		assert.Equal(t, "ancestry-3", locations[1].AncestryName)
+		assert.Equal(t, "layer-1", locations[1].LayerHash)
+		assert.NotEqual(t, pagination.FirstPageToken, next)
+	}
+
+	locations, next, err = tx.FindFeatureLocations("openssl", "1.0", "", 2, next)
+	if assert.Nil(t, err) && assert.Len(t, locations, 1) {
+		assert.Equal(t, "ancestry-4", locations[0].AncestryName)
+		assert.Equal(t, pagination.FirstPageToken, next)
+	}
+
+	// Namespace Case
+	locations, _, err = tx.FindFeatureLocations("openssl", "1.0", "debian:8", 10, pagination.FirstPageToken)
+	if assert.Nil(t, err) && assert.Len(t, locations, 1) {
+		assert.Equal(t, "ancestry-2", locations[0].AncestryName)
+	}
+}
+
 func TestVulnerableFeature(t *testing.T) {
 	datastore, tx := openSessionForTest(t, "VulnerableFeature", true)
 	defer closeTest(t, datastore, tx)
@@ -170,6 +232,22 @@ func TestFindAffectedNamespacedFeatures(t *testing.T) {
 		assert.Len(t, ans[0].AffectedBy, 1) {
 		assert.Equal(t, "CVE-OPENSSL-1-DEB7", ans[0].AffectedBy[0].Name)
 	}
+
+	// The severities only retrieve what the summaries count.
+	severities, err := tx.FindAffectedNamespacedFeatureSeverities([]database.NamespacedFeature{ns})
+	if assert.Nil(t, err) &&
+		assert.Len(t, severities, 1) &&
+		assert.True(t, severities[0].Valid) &&
+		assert.Len(t, severities[0].AffectedBy, 1) {
+		expected := ans[0].AffectedBy[0]
+		actual := severities[0].AffectedBy[0]
+		assert.Equal(t, expected.Name, actual.Name)
+		assert.Equal(t, expected.Namespace, actual.Namespace)
+		assert.Equal(t, expected.Severity, actual.Severity)
+		assert.Equal(t, expected.FixedInVersion, actual.FixedInVersion)
+		assert.Empty(t, actual.Description)
+		assert.Nil(t, actual.Metadata)
+	}
 }
 
 func listNamespacedFeatures(t *testing.T, tx *pgSession) []database.NamespacedFeature {
@@ -196,7 +274,7 @@ func listNamespacedFeatures(t *testing.T, tx *pgSession) []database.NamespacedFe
 }
 
 func listFeatures(t *testing.T, tx *pgSession) []database.Feature {
-	rows, err := tx.Query("SELECT name, version, version_format FROM feature")
+	rows, err := tx.Query("SELECT name, version, version_format, source_name, source_version FROM feature")
 	if err != nil {
 		t.FailNow()
 	}
@@ -204,7 +282,7 @@ func listFeatures(t *testing.T, tx *pgSession) []database.Feature {
 	fs := []database.Feature{}
 	for rows.Next() {
 		f := database.Feature{}
-		err := rows.Scan(&f.Name, &f.Version, &f.VersionFormat)
+		err := rows.Scan(&f.Name, &f.Version, &f.VersionFormat, &f.SourceName, &f.SourceVersion)
 		if err != nil {
 			t.FailNow()
 		}
@@ -33,6 +33,8 @@ const (
 )
 
 func (tx *pgSession) UpdateKeyValue(key, value string) (err error) {
+	tx.markWritten()
+
 	if key == "" || value == "" {
 		log.Warning("could not insert a flag which has an empty name or value")
 		return commonerr.NewBadRequestError("could not insert a flag which has an empty name or value")
@@ -17,6 +17,7 @@ package pgsql
 import (
 	"database/sql"
 	"sort"
+	"time"
 
 	"github.com/deckarep/golang-set"
 
@@ -49,6 +50,16 @@ const (
 				AND ln.layer_id = $1`
 
 	findLayerID = `SELECT id FROM layer WHERE hash = $1`
+
+	isLayerInAncestry = `SELECT EXISTS (SELECT 1 FROM ancestry_layer WHERE layer_id = $1)`
+	removeLayer       = `DELETE FROM layer WHERE id = $1`
+
+	removeAncestriesBefore = `DELETE FROM ancestry WHERE created_at < $1 RETURNING name`
+	removeLayersBefore     = `
+		DELETE FROM layer
+		WHERE created_at < $1
+			AND NOT EXISTS (SELECT 1 FROM ancestry_layer WHERE ancestry_layer.layer_id = layer.id)
+		RETURNING hash`
 )
 
 // dbLayerNamespace represents the layer_namespace table.
@@ -66,6 +77,8 @@ type dbLayerFeature struct {
 }
 
 func (tx *pgSession) FindLayer(hash string) (database.Layer, bool, error) {
+	defer tx.useReplica()()
+
 	layer := database.Layer{Hash: hash}
 	if hash == "" {
 		return layer, false, commonerr.NewBadRequestError("non empty layer hash is expected.")
@@ -96,6 +109,81 @@ func (tx *pgSession) FindLayer(hash string) (database.Layer, bool, error) {
 	return layer, true, nil
 }
 
+func (tx *pgSession) DeleteLayer(hash string) error {
+	tx.markWritten()
+
+	if hash == "" {
+		return commonerr.NewBadRequestError("non empty layer hash is expected.")
+	}
+
+	layerID, ok, err := tx.findLayerID(hash)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return commonerr.ErrNotFound
+	}
+
+	// The layers of the ancestries are not removed along with them, as their
+	// results would be incomplete.
+	var referenced bool
+	if err := tx.QueryRow(isLayerInAncestry, layerID).Scan(&referenced); err != nil {
+		return handleError("isLayerInAncestry", err)
+	}
+
+	if referenced {
+		return database.ErrReferenced
+	}
+
+	if _, err := tx.Exec(removeLayer, layerID); err != nil {
+		return handleError("removeLayer", err)
+	}
+
+	return nil
+}
+
+func (tx *pgSession) DeleteAnalysesBefore(before time.Time) ([]string, []string, error) {
+	tx.markWritten()
+
+	ancestries, err := tx.queryStrings("removeAncestriesBefore", removeAncestriesBefore, before)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	layers, err := tx.queryStrings("removeLayersBefore", removeLayersBefore, before)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ancestries, layers, nil
+}
+
+// queryStrings runs a query returning a single string column, described by
+// desc in its errors.
+func (tx *pgSession) queryStrings(desc, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, handleError(desc, err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, handleError(desc, err)
+		}
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, handleError(desc, err)
+	}
+
+	return values, nil
+}
+
 func sanitizePersistLayerInput(hash string, features []database.LayerFeature, namespaces []database.LayerNamespace, detectedBy []database.Detector) error {
 	if hash == "" {
 		return commonerr.NewBadRequestError("expected non-empty layer hash")
@@ -123,6 +211,8 @@ func sanitizePersistLayerInput(hash string, features []database.LayerFeature, na
 
 // PersistLayer saves the content of a layer to the database.
 func (tx *pgSession) PersistLayer(hash string, features []database.LayerFeature, namespaces []database.LayerNamespace, detectedBy []database.Detector) error {
+	tx.markWritten()
+
 	var (
 		err         error
 		id          int64
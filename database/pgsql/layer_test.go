@@ -16,10 +16,12 @@ package pgsql
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/commonerr"
 )
 
 var persistLayerTests = []struct {
@@ -188,3 +190,43 @@ func TestFindLayer(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteLayer(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "DeleteLayer", true)
+	defer closeTest(t, datastore, tx)
+
+	// The layers contained in ancestries are kept.
+	assert.Equal(t, database.ErrReferenced, tx.DeleteLayer("layer-1"))
+	assert.Equal(t, commonerr.ErrNotFound, tx.DeleteLayer("unknown"))
+	assert.IsType(t, &commonerr.ErrBadRequest{}, tx.DeleteLayer(""))
+
+	assert.Nil(t, tx.DeleteLayer("layer-4"))
+	_, ok, err := tx.FindLayer("layer-4")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, tx.DeleteAncestry("ancestry-1"))
+	assert.Equal(t, commonerr.ErrNotFound, tx.DeleteAncestry("ancestry-1"))
+	assert.Nil(t, tx.DeleteLayer("layer-3a"))
+}
+
+func TestDeleteAnalysesBefore(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "DeleteAnalysesBefore", true)
+	defer closeTest(t, datastore, tx)
+
+	ancestries, layers, err := tx.DeleteAnalysesBefore(time.Now().Add(-time.Hour))
+	if assert.Nil(t, err) {
+		assert.Empty(t, ancestries)
+		assert.Empty(t, layers)
+	}
+
+	ancestries, layers, err = tx.DeleteAnalysesBefore(time.Now().Add(time.Hour))
+	if assert.Nil(t, err) {
+		assert.Len(t, ancestries, 4)
+		assert.Len(t, layers, 6)
+	}
+
+	_, ok, err := tx.FindLayer("layer-0")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
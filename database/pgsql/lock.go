@@ -40,6 +40,8 @@ var (
 // Lock does not block, instead, it returns true and its expiration time
 // is the lock has been successfully acquired or false otherwise.
 func (tx *pgSession) Lock(name string, owner string, duration time.Duration, renew bool) (bool, time.Time, error) {
+	tx.markWritten()
+
 	if name == "" || owner == "" || duration == 0 {
 		log.Warning("could not create an invalid lock")
 		return false, time.Time{}, commonerr.NewBadRequestError("Invalid Lock Parameters")
@@ -76,6 +78,8 @@ func (tx *pgSession) Lock(name string, owner string, duration time.Duration, ren
 
 // Unlock unlocks a lock specified by its name if I own it
 func (tx *pgSession) Unlock(name, owner string) error {
+	tx.markWritten()
+
 	if name == "" || owner == "" {
 		return commonerr.NewBadRequestError("Invalid Lock Parameters")
 	}
@@ -114,7 +118,7 @@ func (tx *pgSession) pruneLocks() error {
 	} else if affected, err := r.RowsAffected(); err != nil {
 		return handleError("removeLockExpired", err)
 	} else {
-		log.Debugf("Pruned %d Locks", affected)
+		log.WithField("count", affected).Debug("pruned expired locks")
 	}
 
 	return nil
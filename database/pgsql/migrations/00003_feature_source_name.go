@@ -0,0 +1,36 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+// featureSourceName stores the name of the source package of the features,
+// e.g. the origin of the Alpine subpackages, so that they are affected by the
+// vulnerabilities of their source package. It is empty for the features that
+// were stored before or whose lister does not report it.
+var featureSourceName = MigrationQuery{
+	Up: []string{
+		`ALTER TABLE feature ADD COLUMN source_name TEXT NOT NULL DEFAULT '';`,
+		`CREATE INDEX ON feature(source_name);`,
+	},
+	Down: []string{
+		`ALTER TABLE feature DROP COLUMN source_name;`,
+	},
+}
+
+func init() {
+	RegisterMigration(NewSimpleMigration(3,
+		[]MigrationQuery{
+			featureSourceName,
+		}))
+}
@@ -0,0 +1,34 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+// vulnerabilityAffectedTag stores the tag given by the data source to the
+// vulnerability affected features, e.g. Debian's no-dsa, which is empty for the
+// features that were not tagged.
+var vulnerabilityAffectedTag = MigrationQuery{
+	Up: []string{
+		`ALTER TABLE vulnerability_affected_feature ADD COLUMN tag TEXT NOT NULL DEFAULT '';`,
+	},
+	Down: []string{
+		`ALTER TABLE vulnerability_affected_feature DROP COLUMN tag;`,
+	},
+}
+
+func init() {
+	RegisterMigration(NewSimpleMigration(4,
+		[]MigrationQuery{
+			vulnerabilityAffectedTag,
+		}))
+}
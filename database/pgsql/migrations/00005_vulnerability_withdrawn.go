@@ -0,0 +1,34 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+// vulnerabilityWithdrawn stores the reason why the data source withdrew a
+// deleted vulnerability, which is NULL for the vulnerabilities that were
+// deleted because they changed.
+var vulnerabilityWithdrawn = MigrationQuery{
+	Up: []string{
+		`ALTER TABLE vulnerability ADD COLUMN withdrawn_reason TEXT NULL;`,
+	},
+	Down: []string{
+		`ALTER TABLE vulnerability DROP COLUMN withdrawn_reason;`,
+	},
+}
+
+func init() {
+	RegisterMigration(NewSimpleMigration(5,
+		[]MigrationQuery{
+			vulnerabilityWithdrawn,
+		}))
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+// analysisCreatedAt stores when the layers and the ancestries were analyzed,
+// so that the old analyses can be deleted. The existing ones are considered
+// analyzed when the migration runs.
+var analysisCreatedAt = MigrationQuery{
+	Up: []string{
+		`ALTER TABLE layer ADD COLUMN created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();`,
+		`ALTER TABLE ancestry ADD COLUMN created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();`,
+		`CREATE INDEX ON layer(created_at);`,
+		`CREATE INDEX ON ancestry(created_at);`,
+		`CREATE INDEX ON ancestry_layer(layer_id);`,
+	},
+	Down: []string{
+		`DROP INDEX IF EXISTS ancestry_layer_layer_id_idx;`,
+		`ALTER TABLE ancestry DROP COLUMN created_at;`,
+		`ALTER TABLE layer DROP COLUMN created_at;`,
+	},
+}
+
+func init() {
+	RegisterMigration(NewSimpleMigration(6,
+		[]MigrationQuery{
+			analysisCreatedAt,
+		}))
+}
@@ -0,0 +1,34 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+// vulnerabilityLastAffected stores the last affected version of the
+// vulnerability affected features, which is NULL for the features whose
+// versions are all affected below their affected version.
+var vulnerabilityLastAffected = MigrationQuery{
+	Up: []string{
+		`ALTER TABLE vulnerability_affected_feature ADD COLUMN lastaffected TEXT NULL;`,
+	},
+	Down: []string{
+		`ALTER TABLE vulnerability_affected_feature DROP COLUMN lastaffected;`,
+	},
+}
+
+func init() {
+	RegisterMigration(NewSimpleMigration(7,
+		[]MigrationQuery{
+			vulnerabilityLastAffected,
+		}))
+}
@@ -0,0 +1,36 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+// featureSourceVersion stores the version of the source package of the
+// features, which the vulnerabilities of their source package are compared
+// with, as it may differ from the version of the feature, e.g. by its epoch.
+// The features without it are only affected by the vulnerabilities of their
+// own name.
+var featureSourceVersion = MigrationQuery{
+	Up: []string{
+		`ALTER TABLE feature ADD COLUMN source_version TEXT NOT NULL DEFAULT '';`,
+	},
+	Down: []string{
+		`ALTER TABLE feature DROP COLUMN source_version;`,
+	},
+}
+
+func init() {
+	RegisterMigration(NewSimpleMigration(8,
+		[]MigrationQuery{
+			featureSourceVersion,
+		}))
+}
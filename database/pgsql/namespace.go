@@ -17,17 +17,30 @@ package pgsql
 import (
 	"database/sql"
 	"sort"
+	"strings"
 
 	"github.com/coreos/clair/database"
 	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/pagination"
 )
 
 const (
 	searchNamespaceID = `SELECT id FROM Namespace WHERE name = $1 AND version_format = $2`
+
+	searchNamespacePage = `
+		SELECT id, name, version_format FROM Namespace
+			WHERE id >= $1 AND name LIKE $2 ESCAPE '\'
+			ORDER BY id
+			LIMIT $3`
 )
 
+// likeEscaper escapes the wildcards of the LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 // PersistNamespaces soi namespaces into database.
 func (tx *pgSession) PersistNamespaces(namespaces []database.Namespace) error {
+	tx.markWritten()
+
 	if len(namespaces) == 0 {
 		return nil
 	}
@@ -54,6 +67,67 @@ func (tx *pgSession) PersistNamespaces(namespaces []database.Namespace) error {
 	return nil
 }
 
+// FindNamespaces retrieves a page of the namespaces whose name starts with the
+// given prefix.
+func (tx *pgSession) FindNamespaces(prefix string, limit int, pageToken pagination.Token) ([]database.Namespace, pagination.Token, error) {
+	defer tx.useReplica()()
+
+	if limit <= 0 {
+		return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("namespace page limit should be positive")
+	}
+
+	page := Page{0}
+	if pageToken != pagination.FirstPageToken {
+		if err := tx.key.UnmarshalToken(pageToken, &page); err == pagination.ErrExpiredToken || err == pagination.ErrFutureToken {
+			return nil, pagination.FirstPageToken, err
+		} else if err != nil {
+			return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("invalid namespace page token")
+		}
+	}
+
+	// One more namespace is retrieved to find the start of the next page.
+	rows, err := tx.Query(searchNamespacePage, page.StartID, likeEscaper.Replace(prefix)+"%", limit+1)
+	if err != nil {
+		return nil, pagination.FirstPageToken, handleError("searchNamespacePage", err)
+	}
+	defer rows.Close()
+
+	var (
+		namespaces = []database.Namespace{}
+		nextID     int64
+	)
+	for rows.Next() {
+		var (
+			id int64
+			ns database.Namespace
+		)
+		if err := rows.Scan(&id, &ns.Name, &ns.VersionFormat); err != nil {
+			return nil, pagination.FirstPageToken, handleError("searchNamespacePage", err)
+		}
+
+		if len(namespaces) == limit {
+			nextID = id
+			break
+		}
+		namespaces = append(namespaces, ns)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, pagination.FirstPageToken, handleError("searchNamespacePage", err)
+	}
+
+	if nextID == 0 {
+		return namespaces, pagination.FirstPageToken, nil
+	}
+
+	nextPage, err := tx.key.MarshalToken(Page{nextID})
+	if err != nil {
+		return nil, pagination.FirstPageToken, err
+	}
+
+	return namespaces, nextPage, nil
+}
+
 func (tx *pgSession) findNamespaceIDs(namespaces []database.Namespace) ([]sql.NullInt64, error) {
 	if len(namespaces) == 0 {
 		return nil, nil
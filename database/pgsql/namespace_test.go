@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/pagination"
 )
 
 func TestPersistNamespaces(t *testing.T) {
@@ -42,3 +43,39 @@ func TestPersistNamespaces(t *testing.T) {
 	assert.Len(t, nsList, 1)
 	assert.Equal(t, ns2, nsList[0])
 }
+
+func TestFindNamespaces(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "FindNamespaces", true)
+	defer closeTest(t, datastore, tx)
+
+	// Invalid Case
+	_, _, err := tx.FindNamespaces("", 0, pagination.FirstPageToken)
+	assert.NotNil(t, err)
+	_, _, err = tx.FindNamespaces("", 1, pagination.Token("invalid"))
+	assert.NotNil(t, err)
+
+	// Paginated Case
+	namespaces, next, err := tx.FindNamespaces("", 2, pagination.FirstPageToken)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []database.Namespace{{Name: "debian:7", VersionFormat: "dpkg"}, {Name: "debian:8", VersionFormat: "dpkg"}}, namespaces)
+		assert.NotEqual(t, pagination.FirstPageToken, next)
+	}
+
+	namespaces, next, err = tx.FindNamespaces("", 2, next)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []database.Namespace{{Name: "fake:1.0", VersionFormat: "rpm"}}, namespaces)
+		assert.Equal(t, pagination.FirstPageToken, next)
+	}
+
+	// Prefix Case
+	namespaces, next, err = tx.FindNamespaces("debian:", 10, pagination.FirstPageToken)
+	if assert.Nil(t, err) {
+		assert.Len(t, namespaces, 2)
+		assert.Equal(t, pagination.FirstPageToken, next)
+	}
+
+	namespaces, _, err = tx.FindNamespaces("debian_", 10, pagination.FirstPageToken)
+	if assert.Nil(t, err) {
+		assert.Empty(t, namespaces)
+	}
+}
@@ -46,10 +46,28 @@ const (
 		FROM Vulnerability_Notification
 		WHERE (notified_at IS NULL OR notified_at < $1)
 					AND deleted_at IS NULL
-					AND name NOT IN (SELECT name FROM Lock)
+					AND name NOT IN (SELECT name FROM Lock WHERE until >= CURRENT_TIMESTAMP)
 		ORDER BY Random()
 		LIMIT 1`
 
+	searchPendingNotificationPage = `
+		SELECT id, name, created_at
+		FROM Vulnerability_Notification
+		WHERE id >= $1 AND notified_at IS NULL AND deleted_at IS NULL
+		ORDER BY id
+		LIMIT $2`
+
+	countPendingNotifications = `
+		SELECT COUNT(*), MIN(created_at)
+		FROM Vulnerability_Notification
+		WHERE notified_at IS NULL AND deleted_at IS NULL`
+
+	removePendingNotificationsBefore = `
+		UPDATE Vulnerability_Notification
+		SET deleted_at = CURRENT_TIMESTAMP
+		WHERE created_at < $1 AND notified_at IS NULL AND deleted_at IS NULL
+		RETURNING name`
+
 	searchNotification = `
 		SELECT created_at, notified_at, deleted_at, old_vulnerability_id, new_vulnerability_id
 		FROM Vulnerability_Notification
@@ -74,6 +92,8 @@ var (
 )
 
 func (tx *pgSession) InsertVulnerabilityNotifications(notifications []database.VulnerabilityNotification) error {
+	tx.markWritten()
+
 	if len(notifications) == 0 {
 		return nil
 	}
@@ -216,6 +236,11 @@ func (tx *pgSession) findPagedVulnerableAncestries(vulnID int64, limit int, curr
 		}
 	}
 
+	var (
+		deleted         zero.Time
+		withdrawnReason sql.NullString
+	)
+
 	if err := tx.QueryRow(searchVulnerabilityByID, vulnID).Scan(
 		&vulnPage.Name,
 		&vulnPage.Description,
@@ -224,10 +249,16 @@ func (tx *pgSession) findPagedVulnerableAncestries(vulnID int64, limit int, curr
 		&vulnPage.Metadata,
 		&vulnPage.Namespace.Name,
 		&vulnPage.Namespace.VersionFormat,
+		&deleted,
+		&withdrawnReason,
 	); err != nil {
 		return vulnPage, handleError("searchVulnerabilityByID", err)
 	}
 
+	if withdrawnReason.Valid {
+		vulnPage.Withdrawn = &database.Withdrawal{Time: deleted.Time, Reason: withdrawnReason.String}
+	}
+
 	// the last result is used for the next page's startID
 	rows, err := tx.Query(searchNotificationVulnerableAncestry, vulnID, currentPage.StartID, limit+1)
 	if err != nil {
@@ -273,6 +304,8 @@ func (tx *pgSession) findPagedVulnerableAncestries(vulnID int64, limit int, curr
 
 func (tx *pgSession) FindVulnerabilityNotification(name string, limit int, oldPageToken pagination.Token, newPageToken pagination.Token) (
 	database.VulnerabilityNotificationWithVulnerable, bool, error) {
+	defer tx.useReplica()()
+
 	var (
 		noti      database.VulnerabilityNotificationWithVulnerable
 		oldVulnID sql.NullInt64
@@ -329,6 +362,8 @@ func (tx *pgSession) FindVulnerabilityNotification(name string, limit int, oldPa
 }
 
 func (tx *pgSession) MarkNotificationAsRead(name string) error {
+	tx.markWritten()
+
 	if name == "" {
 		return commonerr.NewBadRequestError("Empty notification name is not allowed")
 	}
@@ -350,6 +385,8 @@ func (tx *pgSession) MarkNotificationAsRead(name string) error {
 }
 
 func (tx *pgSession) DeleteNotification(name string) error {
+	tx.markWritten()
+
 	if name == "" {
 		return commonerr.NewBadRequestError("Empty notification name is not allowed")
 	}
@@ -370,3 +407,87 @@ func (tx *pgSession) DeleteNotification(name string) error {
 
 	return nil
 }
+
+func (tx *pgSession) FindPendingNotifications(limit int, pageToken pagination.Token) ([]database.NotificationHook, pagination.Token, error) {
+	defer tx.useReplica()()
+
+	if limit <= 0 {
+		return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("notification page limit should be positive")
+	}
+
+	page := Page{0}
+	if pageToken != pagination.FirstPageToken {
+		if err := tx.key.UnmarshalToken(pageToken, &page); err == pagination.ErrExpiredToken || err == pagination.ErrFutureToken {
+			return nil, pagination.FirstPageToken, err
+		} else if err != nil {
+			return nil, pagination.FirstPageToken, commonerr.NewBadRequestError("invalid notification page token")
+		}
+	}
+
+	// One more notification is retrieved to find the start of the next page.
+	rows, err := tx.Query(searchPendingNotificationPage, page.StartID, limit+1)
+	if err != nil {
+		return nil, pagination.FirstPageToken, handleError("searchPendingNotificationPage", err)
+	}
+	defer rows.Close()
+
+	var (
+		notifications = []database.NotificationHook{}
+		nextID        int64
+	)
+	for rows.Next() {
+		var (
+			id      int64
+			hook    database.NotificationHook
+			created zero.Time
+		)
+		if err := rows.Scan(&id, &hook.Name, &created); err != nil {
+			return nil, pagination.FirstPageToken, handleError("searchPendingNotificationPage", err)
+		}
+
+		if len(notifications) == limit {
+			nextID = id
+			break
+		}
+
+		if created.Valid {
+			hook.Created = created.Time
+		}
+		notifications = append(notifications, hook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, pagination.FirstPageToken, handleError("searchPendingNotificationPage", err)
+	}
+
+	if nextID == 0 {
+		return notifications, pagination.FirstPageToken, nil
+	}
+
+	nextPage, err := tx.key.MarshalToken(Page{nextID})
+	if err != nil {
+		return nil, pagination.FirstPageToken, err
+	}
+
+	return notifications, nextPage, nil
+}
+
+func (tx *pgSession) CountPendingNotifications() (int, time.Time, error) {
+	defer tx.useReplica()()
+
+	var (
+		count  int
+		oldest zero.Time
+	)
+	if err := tx.QueryRow(countPendingNotifications).Scan(&count, &oldest); err != nil {
+		return 0, time.Time{}, handleError("countPendingNotifications", err)
+	}
+
+	return count, oldest.Time, nil
+}
+
+func (tx *pgSession) DeletePendingNotificationsBefore(before time.Time) ([]string, error) {
+	tx.markWritten()
+
+	return tx.queryStrings("removePendingNotificationsBefore", removePendingNotificationsBefore, before)
+}
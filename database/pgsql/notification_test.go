@@ -271,3 +271,47 @@ func TestDeleteNotification(t *testing.T) {
 	// invalid case: notification is already deleted
 	assert.NotNil(t, tx.DeleteNotification("test"))
 }
+
+func TestPendingNotifications(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "PendingNotifications", true)
+	defer closeTest(t, datastore, tx)
+
+	hooks, nextPage, err := tx.FindPendingNotifications(10, pagination.FirstPageToken)
+	if assert.Nil(t, err) && assert.Len(t, hooks, 1) {
+		assert.Equal(t, "test", hooks[0].Name)
+		assert.Equal(t, pagination.FirstPageToken, nextPage)
+	}
+
+	count, _, err := tx.CountPendingNotifications()
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, count)
+	}
+
+	_, _, err = tx.FindPendingNotifications(0, pagination.FirstPageToken)
+	assert.NotNil(t, err)
+
+	// The notifications without creation time are never flushed.
+	names, err := tx.DeletePendingNotificationsBefore(time.Now())
+	if assert.Nil(t, err) {
+		assert.Empty(t, names)
+	}
+
+	n := database.VulnerabilityNotification{
+		NotificationHook: database.NotificationHook{Name: "stale", Created: time.Now().Add(-time.Hour)},
+		New:              &database.Vulnerability{Name: "CVE-OPENSSL-1-DEB7", Namespace: database.Namespace{Name: "debian:7", VersionFormat: "dpkg"}},
+	}
+	require.Nil(t, tx.InsertVulnerabilityNotifications([]database.VulnerabilityNotification{n}))
+
+	names, err = tx.DeletePendingNotificationsBefore(time.Now())
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"stale"}, names)
+	}
+
+	// The notified notifications are not pending anymore.
+	require.Nil(t, tx.MarkNotificationAsRead("test"))
+	count, oldest, err := tx.CountPendingNotifications()
+	if assert.Nil(t, err) {
+		assert.Equal(t, 0, count)
+		assert.True(t, oldest.IsZero())
+	}
+}
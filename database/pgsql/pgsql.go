@@ -16,9 +16,12 @@
 package pgsql
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/url"
 	"strings"
 	"time"
@@ -74,6 +77,25 @@ func init() {
 	database.Register("pgsql", openDatabase)
 }
 
+const (
+	// defaultBatchSize is the default number of rows inserted by a query when
+	// inserting the vulnerabilities.
+	defaultBatchSize = 1000
+
+	// maxBatchSize keeps the queries inserting the vulnerabilities, which
+	// have 6 parameters per row, under the limit of 65535 parameters per
+	// query.
+	maxBatchSize = 65535 / 6
+
+	// defaultMaxIdleConns is the default maximum number of idle connections
+	// to each database, the one of database/sql.
+	defaultMaxIdleConns = 2
+
+	// defaultReplicaLag is the default time during which the reads are sent
+	// to the primary database after a write.
+	defaultReplicaLag = 5 * time.Second
+)
+
 // pgSessionCache is the session's cache, which holds the pgSQL's cache and the
 // individual session's cache. Only when session.Commit is called, all the
 // changes to pgSQL cache will be applied.
@@ -84,14 +106,27 @@ type pgSessionCache struct {
 type pgSQL struct {
 	*sql.DB
 
-	cache  *lru.ARCCache
-	config Config
+	key      pagination.Key
+	replicas *replicaSet
+	cache    *lru.ARCCache
+	config   Config
 }
 
 type pgSession struct {
 	*sql.Tx
 
 	key pagination.Key
+
+	// replicas is used by the read-only methods of the session as long as it
+	// has not written anything. See useReplica.
+	replicas      *replicaSet
+	replica       *sql.Tx
+	replicaFailed bool
+	written       bool
+
+	// batchSize is the number of rows inserted by a query when inserting
+	// vulnerabilities.
+	batchSize int
 }
 
 // Begin initiates a transaction to database.
@@ -101,16 +136,25 @@ type pgSession struct {
 func (pgSQL *pgSQL) Begin() (database.Session, error) {
 	tx, err := pgSQL.DB.Begin()
 	if err != nil {
-		return nil, err
+		return nil, handleError("Begin()", err)
 	}
 	return &pgSession{
-		Tx:  tx,
-		key: pagination.Must(pagination.KeyFromString(pgSQL.config.PaginationKey)),
+		Tx:        tx,
+		key:       pgSQL.key,
+		replicas:  pgSQL.replicas,
+		batchSize: pgSQL.config.VulnerabilityBatchSize,
 	}, nil
 }
 
 func (tx *pgSession) Commit() error {
-	return tx.Tx.Commit()
+	err := tx.Tx.Commit()
+	tx.endReplica(err == nil)
+	return err
+}
+
+func (tx *pgSession) Rollback() error {
+	tx.endReplica(false)
+	return tx.Tx.Rollback()
 }
 
 // Close closes the database and destroys if ManageDatabaseLifecycle has been specified in
@@ -120,6 +164,10 @@ func (pgSQL *pgSQL) Close() {
 		pgSQL.DB.Close()
 	}
 
+	if pgSQL.replicas != nil {
+		pgSQL.replicas.close()
+	}
+
 	if pgSQL.config.ManageDatabaseLifecycle {
 		dbName, pgSourceURL, _ := parseConnectionString(pgSQL.config.Source)
 		dropDatabase(pgSourceURL, dbName)
@@ -142,12 +190,127 @@ type Page struct {
 
 // Config is the configuration that is used by openDatabase.
 type Config struct {
-	Source    string
+	Source string
+
+	// SourceFile is the path of a file containing the connection string,
+	// which is used instead of Source.
+	SourceFile string
+
+	// PasswordFile is the path of a file containing the password of the
+	// database user, which replaces the one of the connection string.
+	PasswordFile string
+
 	CacheSize int
 
+	// Replicas are the connection strings of read replicas of Source, to which
+	// the read-only queries are sent.
+	Replicas []string
+
+	// ReplicaLag is the time during which the read-only queries are sent to
+	// the primary database after a write was committed, which should exceed
+	// the replication lag of the replicas.
+	ReplicaLag time.Duration
+
+	// VulnerabilityBatchSize is the number of rows inserted by a query when
+	// inserting vulnerabilities, their affected features and the features
+	// that they affect.
+	VulnerabilityBatchSize int
+
+	// MaxOpenConns and MaxIdleConns are the maximum numbers of open and idle
+	// connections to each database, and ConnMaxLifetime is the duration after
+	// which a connection is closed. The numbers of open connections and the
+	// lifetime are not limited when they are 0.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
 	ManageDatabaseLifecycle bool
 	FixturePath             string
-	PaginationKey           string
+
+	// PaginationKey is the key used to sign the pagination tokens, and
+	// PaginationKeys are previous keys that verify the tokens that they
+	// signed, so that the key can be rotated. The first of PaginationKeys
+	// signs the tokens when PaginationKey is not set.
+	PaginationKey  string
+	PaginationKeys []string
+}
+
+// paginationKeys returns the pagination keys of the configuration, starting
+// with the one that signs the tokens.
+func (c Config) paginationKeys() []string {
+	if c.PaginationKey == "" {
+		return c.PaginationKeys
+	}
+	return append([]string{c.PaginationKey}, c.PaginationKeys...)
+}
+
+// configurePool applies the limits of the connection pool of the
+// configuration to the given database.
+func (c Config) configurePool(db *sql.DB) {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+}
+
+// source returns the connection string of the configuration, reading its
+// files so that the secrets that they contain can be rotated.
+func (c Config) source() (string, error) {
+	source := c.Source
+	if c.SourceFile != "" {
+		b, err := ioutil.ReadFile(c.SourceFile)
+		if err != nil {
+			return "", fmt.Errorf("pgsql: could not read database connection string file: %v", err)
+		}
+		source = strings.TrimSpace(string(b))
+	}
+
+	if c.PasswordFile == "" {
+		return source, nil
+	}
+
+	b, err := ioutil.ReadFile(c.PasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("pgsql: could not read database password file: %v", err)
+	}
+	password := strings.TrimRight(string(b), "\r\n")
+
+	// The connection string is either a URL or a list of key/value pairs, in
+	// which the last value of a key is the one used.
+	if strings.Contains(source, "://") {
+		sourceURL, err := url.Parse(source)
+		if err != nil {
+			return "", commonerr.NewBadRequestError("pgsql: database connection string is not a valid URL")
+		}
+
+		var username string
+		if sourceURL.User != nil {
+			username = sourceURL.User.Username()
+		}
+		sourceURL.User = url.UserPassword(username, password)
+		return sourceURL.String(), nil
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return source + " password='" + escaper.Replace(password) + "'", nil
+}
+
+// sourceConnector opens the connections of a pgSQL database, building their
+// connection string from the configuration every time so that the connections
+// reopened after a secret rotation use the new secret.
+type sourceConnector struct {
+	config Config
+}
+
+func (c sourceConnector) Connect(context.Context) (driver.Conn, error) {
+	source, err := c.config.source()
+	if err != nil {
+		return nil, err
+	}
+	return c.Driver().Open(source)
+}
+
+func (c sourceConnector) Driver() driver.Driver {
+	return &pq.Driver{}
 }
 
 // openDatabase opens a PostgresSQL-backed Datastore using the given
@@ -162,7 +325,10 @@ func openDatabase(registrableComponentConfig database.RegistrableComponentConfig
 
 	// Parse configuration.
 	pg.config = Config{
-		CacheSize: 16384,
+		CacheSize:              16384,
+		VulnerabilityBatchSize: defaultBatchSize,
+		MaxIdleConns:           defaultMaxIdleConns,
+		ReplicaLag:             defaultReplicaLag,
 	}
 	bytes, err := yaml.Marshal(registrableComponentConfig.Options)
 	if err != nil {
@@ -173,11 +339,32 @@ func openDatabase(registrableComponentConfig database.RegistrableComponentConfig
 		return nil, fmt.Errorf("pgsql: could not load configuration: %v", err)
 	}
 
-	if pg.config.PaginationKey == "" {
+	if len(pg.config.paginationKeys()) == 0 {
 		panic("pagination key should be given")
 	}
 
-	dbName, pgSourceURL, err := parseConnectionString(pg.config.Source)
+	if pg.key, err = pagination.KeyFromStrings(pg.config.paginationKeys()); err != nil {
+		return nil, fmt.Errorf("pgsql: could not load pagination keys: %v", err)
+	}
+
+	if pg.config.VulnerabilityBatchSize <= 0 || pg.config.VulnerabilityBatchSize > maxBatchSize {
+		return nil, fmt.Errorf("pgsql: vulnerabilitybatchsize must be between 1 and %d", maxBatchSize)
+	}
+
+	if pg.config.MaxOpenConns < 0 || pg.config.MaxIdleConns < 0 || pg.config.ConnMaxLifetime < 0 {
+		return nil, fmt.Errorf("pgsql: maxopenconns, maxidleconns and connmaxlifetime must not be negative")
+	}
+
+	if pg.config.ReplicaLag < 0 {
+		return nil, fmt.Errorf("pgsql: replicalag must not be negative")
+	}
+
+	source, err := pg.config.source()
+	if err != nil {
+		return nil, err
+	}
+
+	dbName, pgSourceURL, err := parseConnectionString(source)
 	if err != nil {
 		return nil, err
 	}
@@ -191,11 +378,8 @@ func openDatabase(registrableComponentConfig database.RegistrableComponentConfig
 	}
 
 	// Open database.
-	pg.DB, err = sql.Open("postgres", pg.config.Source)
-	if err != nil {
-		pg.Close()
-		return nil, fmt.Errorf("pgsql: could not open database: %v", err)
-	}
+	pg.DB = sql.OpenDB(sourceConnector{pg.config})
+	pg.config.configurePool(pg.DB)
 
 	// Verify database state.
 	if err = pg.DB.Ping(); err != nil {
@@ -209,6 +393,12 @@ func openDatabase(registrableComponentConfig database.RegistrableComponentConfig
 		return nil, err
 	}
 
+	// Open read replicas.
+	if pg.replicas, err = openReplicas(pg.config); err != nil {
+		pg.Close()
+		return nil, err
+	}
+
 	// Load fixture data.
 	if pg.config.FixturePath != "" {
 		log.Info("pgsql: loading fixtures")
@@ -333,9 +523,31 @@ func handleError(desc string, err error) error {
 		return database.ErrBackendException
 	}
 
+	// The database cannot be reached.
+	if _, o := err.(net.Error); o || err == driver.ErrBadConn {
+		return database.ErrBackendException
+	}
+
 	return err
 }
 
+// inBatches calls fn with the bounds of consecutive batches of at most
+// tx.batchSize of the count rows.
+func (tx *pgSession) inBatches(count int, fn func(start, end int) error) error {
+	for start := 0; start < count; start += tx.batchSize {
+		end := start + tx.batchSize
+		if end > count {
+			end = count
+		}
+
+		if err := fn(start, end); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // isErrUniqueViolation determines is the given error is a unique contraint violation.
 func isErrUniqueViolation(err error) bool {
 	pqErr, ok := err.(*pq.Error)
@@ -107,13 +107,19 @@ func queryInsertNotifications(count int) string {
 	)
 }
 
+// queryPersistFeature inserts the features, filling in the source package of
+// the ones that were stored without it, e.g. before their lister reported it.
 func queryPersistFeature(count int) string {
-	return queryPersist(count,
+	return queryInsert(count,
 		"feature",
-		"feature_name_version_version_format_key",
 		"name",
 		"version",
-		"version_format")
+		"version_format",
+		"source_name",
+		"source_version") + `
+		ON CONFLICT ON CONSTRAINT feature_name_version_version_format_key DO UPDATE
+		SET source_name = EXCLUDED.source_name, source_version = EXCLUDED.source_version
+		WHERE feature.source_version = '' AND EXCLUDED.source_version <> ''`
 }
 
 func queryPersistLayerFeature(count int) string {
@@ -177,6 +183,31 @@ func queryPersistLayer(count int) string {
 	return queryPersist(count, "layer", "", "hash")
 }
 
+func queryInsertVulnerabilities(count int) string {
+	values := make([]string, count)
+	for i := range values {
+		values[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, CURRENT_TIMESTAMP)",
+			i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6)
+	}
+
+	return fmt.Sprintf(`
+		INSERT INTO vulnerability(namespace_id, name, description, link, severity, metadata, created_at)
+		VALUES %s
+		RETURNING id, namespace_id, name`,
+		strings.Join(values, ","))
+}
+
+func queryInsertVulnerabilityAffected(count int) string {
+	return queryInsert(count, "vulnerability_affected_feature",
+		"vulnerability_id",
+		"feature_name",
+		"affected_version",
+		"fixedin",
+		"introducedin",
+		"lastaffected",
+		"tag") + " RETURNING id, vulnerability_id, feature_name, affected_version, fixedin, introducedin, lastaffected, tag"
+}
+
 func queryInvalidateVulnerabilityCache(count int) string {
 	return fmt.Sprintf(`DELETE FROM vulnerability_affected_feature 
 		WHERE vulnerability_id IN (%s)`,
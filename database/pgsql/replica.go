@@ -0,0 +1,159 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsql
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// replicaSet is a set of read replicas of the primary database that are
+// chosen in a round-robin fashion.
+type replicaSet struct {
+	dbs  []*sql.DB
+	next uint32
+
+	// lag is the time during which the sessions read from the primary
+	// database after a session committed a write, so that a write is read
+	// by the next sessions even before the replicas have replayed it.
+	lag time.Duration
+
+	// lastWrite is the time, in Unix nanoseconds, at which the last session
+	// that wrote to the primary database committed.
+	lastWrite int64
+}
+
+// openReplicas opens the read replicas of the configuration, whose connection
+// pools have the same limits as the primary database.
+//
+// Unreachable replicas are kept, as their sessions fall back to the primary
+// database until they become available.
+func openReplicas(config Config) (*replicaSet, error) {
+	if len(config.Replicas) == 0 {
+		return nil, nil
+	}
+
+	replicas := &replicaSet{lag: config.ReplicaLag}
+	for i, source := range config.Replicas {
+		db, err := sql.Open("postgres", source)
+		if err != nil {
+			replicas.close()
+			return nil, fmt.Errorf("pgsql: could not open read replica #%d: %v", i, err)
+		}
+		config.configurePool(db)
+
+		if err := db.Ping(); err != nil {
+			log.WithError(err).WithField("replica", i).Warning("pgsql: read replica is not reachable")
+		}
+
+		replicas.dbs = append(replicas.dbs, db)
+	}
+
+	return replicas, nil
+}
+
+// begin initiates a read-only transaction on the next replica.
+func (r *replicaSet) begin() (*sql.Tx, error) {
+	i := int(atomic.AddUint32(&r.next, 1) % uint32(len(r.dbs)))
+
+	tx, err := r.dbs[i].Begin()
+	if err != nil {
+		promErrorsTotal.WithLabelValues("beginReplica").Inc()
+		log.WithError(err).WithField("replica", i).Warning("pgsql: could not begin transaction on read replica, using primary")
+		return nil, err
+	}
+
+	if _, err := tx.Exec("SET TRANSACTION READ ONLY"); err != nil {
+		tx.Rollback()
+		promErrorsTotal.WithLabelValues("beginReplica").Inc()
+		log.WithError(err).WithField("replica", i).Warning("pgsql: could not begin transaction on read replica, using primary")
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// written records that a session committed a write at the given time.
+func (r *replicaSet) written(at time.Time) {
+	atomic.StoreInt64(&r.lastWrite, at.UnixNano())
+}
+
+// lagging returns whether the replicas may not have replayed the last
+// committed write yet at the given time.
+func (r *replicaSet) lagging(at time.Time) bool {
+	lastWrite := atomic.LoadInt64(&r.lastWrite)
+	return lastWrite != 0 && at.Sub(time.Unix(0, lastWrite)) < r.lag
+}
+
+func (r *replicaSet) close() {
+	for _, db := range r.dbs {
+		db.Close()
+	}
+}
+
+// useReplica routes the queries of the session to a read replica until the
+// returned function is called.
+//
+// The session keeps using the primary database if there is no replica, if no
+// transaction could be started on a replica or if the session has already
+// written to the primary database, so that it always reads its own writes.
+// The sessions reading within the replica lag of a committed write, e.g. the
+// lookup of an ancestry right after its analysis, also read from the primary
+// database, so that they read the writes of the other sessions too.
+func (tx *pgSession) useReplica() (restore func()) {
+	if tx.replicas == nil || tx.written || tx.replicaFailed {
+		return func() {}
+	}
+
+	if tx.replica == nil && tx.replicas.lagging(time.Now()) {
+		return func() {}
+	}
+
+	if tx.replica == nil {
+		replica, err := tx.replicas.begin()
+		if err != nil {
+			tx.replicaFailed = true
+			return func() {}
+		}
+		tx.replica = replica
+	}
+
+	primary := tx.Tx
+	tx.Tx = tx.replica
+	return func() { tx.Tx = primary }
+}
+
+// markWritten records that the session writes to the primary database.
+func (tx *pgSession) markWritten() {
+	tx.written = true
+}
+
+// endReplica terminates the read-only transaction of the session, if any.
+// When the session committed a write, the next sessions read from the primary
+// database for the replica lag.
+func (tx *pgSession) endReplica(committed bool) {
+	if committed && tx.written && tx.replicas != nil {
+		tx.replicas.written(time.Now())
+	}
+
+	if tx.replica != nil {
+		tx.replica.Rollback()
+		tx.replica = nil
+	}
+}
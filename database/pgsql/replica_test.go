@@ -0,0 +1,150 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriver opens connections whose transactions accept any statement, or
+// fail to begin for the "unavailable" source, so that the routing of the
+// sessions is tested without databases.
+type fakeDriver struct{}
+
+type fakeConn struct{ unavailable bool }
+
+type fakeTx struct{}
+
+type fakeStmt struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{unavailable: name == "unavailable"}, nil
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	if c.unavailable {
+		return nil, errors.New("unavailable")
+	}
+	return fakeTx{}, nil
+}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func (fakeStmt) Close() error                                    { return nil }
+func (fakeStmt) NumInput() int                                   { return -1 }
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) { return driver.RowsAffected(0), nil }
+func (fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func init() {
+	sql.Register("fakereplica", fakeDriver{})
+}
+
+func openFakeDB(t *testing.T, name string) *sql.DB {
+	db, err := sql.Open("fakereplica", name)
+	require.Nil(t, err)
+	return db
+}
+
+func beginFakeSession(t *testing.T, primary *sql.DB, replicas *replicaSet) *pgSession {
+	tx, err := primary.Begin()
+	require.Nil(t, err)
+	return &pgSession{Tx: tx, replicas: replicas}
+}
+
+func TestUseReplica(t *testing.T) {
+	primary := openFakeDB(t, "primary")
+	defer primary.Close()
+
+	replicas := &replicaSet{dbs: []*sql.DB{openFakeDB(t, "replica")}, lag: time.Minute}
+	defer replicas.close()
+
+	// The reads of a session go to the replica until it writes.
+	session := beginFakeSession(t, primary, replicas)
+	primaryTx := session.Tx
+
+	restore := session.useReplica()
+	assert.NotNil(t, session.replica)
+	assert.Equal(t, session.replica, session.Tx)
+	restore()
+	assert.Equal(t, primaryTx, session.Tx)
+
+	session.markWritten()
+	restore = session.useReplica()
+	assert.Equal(t, primaryTx, session.Tx, "a session that wrote should read from the primary")
+	restore()
+
+	// The sessions that follow a committed write read from the primary.
+	require.Nil(t, session.Commit())
+	assert.Nil(t, session.replica)
+	assert.True(t, replicas.lagging(time.Now()))
+
+	next := beginFakeSession(t, primary, replicas)
+	restore = next.useReplica()
+	assert.Nil(t, next.replica, "a session following a write should read from the primary")
+	restore()
+	require.Nil(t, next.Rollback())
+
+	// Once the lag has elapsed, the reads go to the replica again.
+	replicas.written(time.Now().Add(-2 * time.Minute))
+	assert.False(t, replicas.lagging(time.Now()))
+
+	next = beginFakeSession(t, primary, replicas)
+	restore = next.useReplica()
+	assert.NotNil(t, next.replica)
+	restore()
+	require.Nil(t, next.Rollback())
+}
+
+func TestUseReplicaRolledBackWrite(t *testing.T) {
+	primary := openFakeDB(t, "primary")
+	defer primary.Close()
+
+	replicas := &replicaSet{dbs: []*sql.DB{openFakeDB(t, "replica")}, lag: time.Minute}
+	defer replicas.close()
+
+	session := beginFakeSession(t, primary, replicas)
+	session.markWritten()
+	require.Nil(t, session.Rollback())
+	assert.False(t, replicas.lagging(time.Now()), "a rolled back write should not pin the reads to the primary")
+}
+
+func TestUseReplicaUnavailable(t *testing.T) {
+	primary := openFakeDB(t, "primary")
+	defer primary.Close()
+
+	replicas := &replicaSet{dbs: []*sql.DB{openFakeDB(t, "unavailable")}, lag: time.Minute}
+	defer replicas.close()
+
+	session := beginFakeSession(t, primary, replicas)
+	primaryTx := session.Tx
+
+	restore := session.useReplica()
+	assert.Equal(t, primaryTx, session.Tx)
+	assert.True(t, session.replicaFailed)
+	restore()
+	require.Nil(t, session.Rollback())
+}
@@ -38,20 +38,43 @@ const (
 		AND v.deleted_at IS NULL
 		`
 
-	insertVulnerabilityAffected = `
-		INSERT INTO vulnerability_affected_feature(vulnerability_id, feature_name, affected_version, fixedin)
-		VALUES ($1, $2, $3, $4)
-		RETURNING ID
-	`
+	searchNamespaceVulnerabilities = `
+		SELECT v.id, v.name, v.description, v.link, v.severity, v.metadata, n.version_format,
+			vaf.feature_name, vaf.affected_version, vaf.fixedin, vaf.introducedin, vaf.lastaffected, vaf.tag
+		FROM vulnerability AS v
+			JOIN namespace AS n ON v.namespace_id = n.id
+			LEFT JOIN vulnerability_affected_feature AS vaf ON vaf.vulnerability_id = v.id
+		WHERE n.name = $1
+			AND v.deleted_at IS NULL
+			AND ($2::TIMESTAMP WITH TIME ZONE IS NULL OR v.created_at >= $2)
+		ORDER BY v.id, vaf.id`
 
 	searchVulnerabilityAffected = `
-		SELECT vulnerability_id, feature_name, affected_version, fixedin 
+		SELECT vulnerability_id, feature_name, affected_version, fixedin, introducedin, lastaffected, tag
 		FROM vulnerability_affected_feature
 		WHERE vulnerability_id = ANY($1)
 	`
 
+	searchWithdrawnNamespaceVulnerabilities = `
+		SELECT v.id, v.name, v.description, v.link, v.severity, v.metadata, n.version_format,
+			v.deleted_at, v.withdrawn_reason,
+			vaf.feature_name, vaf.affected_version, vaf.fixedin, vaf.introducedin, vaf.lastaffected, vaf.tag
+		FROM vulnerability AS v
+			JOIN namespace AS n ON v.namespace_id = n.id
+			LEFT JOIN vulnerability_affected_feature AS vaf ON vaf.vulnerability_id = v.id
+		WHERE n.name = $1
+			AND v.withdrawn_reason IS NOT NULL
+			AND ($2::TIMESTAMP WITH TIME ZONE IS NULL OR v.deleted_at >= $2)
+			AND NOT EXISTS (
+				SELECT 1 FROM vulnerability AS later
+				WHERE later.namespace_id = v.namespace_id
+					AND later.name = v.name
+					AND later.id > v.id)
+		ORDER BY v.deleted_at, v.id, vaf.id`
+
 	searchVulnerabilityByID = `
-		SELECT v.name, v.description, v.link, v.severity, v.metadata, n.name, n.version_format
+		SELECT v.name, v.description, v.link, v.severity, v.metadata, n.name, n.version_format,
+			v.deleted_at, v.withdrawn_reason
 		FROM vulnerability AS v, namespace AS n
 		WHERE v.namespace_id = n.id
 			AND v.id = $1`
@@ -66,27 +89,23 @@ const (
 			AND v.id = vaf.vulnerability_id
 			AND n.id = v.namespace_id
 			)
-		SELECT req.vulnerability_id, nf.id, f.version, req.vaf_id AS added_by
+		SELECT req.vulnerability_id, nf.id,
+			CASE WHEN f.name = req.name THEN f.version ELSE f.source_version END,
+			req.vaf_id AS added_by
 		FROM feature AS f, namespaced_feature AS nf, req
-		WHERE f.name = req.name
+		WHERE (f.name = req.name OR (f.source_name = req.name AND f.source_version <> ''))
 		AND nf.namespace_id = req.n_id
 		AND nf.feature_id = f.id`
 
-	insertVulnerabilityAffectedNamespacedFeature = `
-		INSERT INTO vulnerability_affected_namespaced_feature(vulnerability_id, namespaced_feature_id, added_by)
-		VALUES ($1, $2, $3)`
+	searchVulnerabilityMetadataByName = `
+		SELECT id, metadata FROM vulnerability
+		WHERE name = $1 AND deleted_at IS NULL`
 
-	insertVulnerability = `
-		WITH ns AS (
-			SELECT id FROM namespace WHERE name = $6 AND version_format = $7
-		)
-		INSERT INTO Vulnerability(namespace_id, name, description, link, severity, metadata, created_at)
-		VALUES((SELECT id FROM ns), $1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
-		RETURNING id`
+	updateVulnerabilityMetadata = `UPDATE vulnerability SET metadata = $2 WHERE id = $1`
 
 	removeVulnerability = `
 		UPDATE Vulnerability
-		SET deleted_at = CURRENT_TIMESTAMP
+		SET deleted_at = CURRENT_TIMESTAMP, withdrawn_reason = $3
 		WHERE namespace_id = (SELECT id FROM Namespace WHERE name = $1)
 			AND name = $2
 			AND deleted_at IS NULL
@@ -113,6 +132,8 @@ type affectedFeatureRows struct {
 }
 
 func (tx *pgSession) FindVulnerabilities(vulnerabilities []database.VulnerabilityID) ([]database.NullableVulnerability, error) {
+	defer tx.useReplica()()
+
 	defer observeQueryTime("findVulnerabilities", "", time.Now())
 	resultVuln := make([]database.NullableVulnerability, len(vulnerabilities))
 	vulnIDMap := map[int64][]*database.NullableVulnerability{}
@@ -176,14 +197,17 @@ func (tx *pgSession) FindVulnerabilities(vulnerabilities []database.Vulnerabilit
 
 	for rows.Next() {
 		var (
-			id int64
-			f  database.AffectedFeature
+			id                         int64
+			f                          database.AffectedFeature
+			introducedIn, lastAffected sql.NullString
 		)
 
-		err := rows.Scan(&id, &f.FeatureName, &f.AffectedVersion, &f.FixedInVersion)
+		err := rows.Scan(&id, &f.FeatureName, &f.AffectedVersion, &f.FixedInVersion, &introducedIn, &lastAffected, &f.Tag)
 		if err != nil {
 			return nil, handleError("searchVulnerabilityAffected", err)
 		}
+		f.IntroducedInVersion = introducedIn.String
+		f.LastAffectedVersion = lastAffected.String
 
 		for _, vuln := range vulnIDMap[id] {
 			f.Namespace = vuln.Namespace
@@ -194,8 +218,125 @@ func (tx *pgSession) FindVulnerabilities(vulnerabilities []database.Vulnerabilit
 	return resultVuln, nil
 }
 
+func (tx *pgSession) WalkVulnerabilities(namespace string, since time.Time, fn func(database.VulnerabilityWithAffected) error) error {
+	defer tx.useReplica()()
+
+	defer observeQueryTime("walkVulnerabilities", "all", time.Now())
+
+	return tx.walkVulnerabilities("searchNamespaceVulnerabilities", searchNamespaceVulnerabilities, false, namespace, since, fn)
+}
+
+func (tx *pgSession) WalkWithdrawnVulnerabilities(namespace string, since time.Time, fn func(database.VulnerabilityWithAffected) error) error {
+	defer tx.useReplica()()
+
+	defer observeQueryTime("walkWithdrawnVulnerabilities", "all", time.Now())
+
+	return tx.walkVulnerabilities("searchWithdrawnNamespaceVulnerabilities", searchWithdrawnNamespaceVulnerabilities, true, namespace, since, fn)
+}
+
+// walkVulnerabilities calls fn with every vulnerability of the namespace
+// returned by the given query, whose rows have the time and the reason of the
+// withdrawal of the vulnerability if withdrawn is true.
+func (tx *pgSession) walkVulnerabilities(queryName, query string, withdrawn bool, namespace string, since time.Time, fn func(database.VulnerabilityWithAffected) error) error {
+	var sinceParam interface{}
+	if !since.IsZero() {
+		sinceParam = since
+	}
+
+	rows, err := tx.Query(query, namespace, sinceParam)
+	if err != nil {
+		return handleError(queryName, err)
+	}
+	defer rows.Close()
+
+	// The affected features of a vulnerability are on consecutive rows, so a
+	// vulnerability is complete once a row of another one is read.
+	var (
+		current   *database.VulnerabilityWithAffected
+		currentID int64
+	)
+
+	for rows.Next() {
+		var (
+			id                                                                     int64
+			vuln                                                                   database.VulnerabilityWithAffected
+			featureName, affectedVersion, fixedIn, introducedIn, lastAffected, tag sql.NullString
+		)
+
+		vuln.Namespace.Name = namespace
+		dest := []interface{}{
+			&id,
+			&vuln.Name,
+			&vuln.Description,
+			&vuln.Link,
+			&vuln.Severity,
+			&vuln.Metadata,
+			&vuln.Namespace.VersionFormat,
+		}
+
+		withdrawal := database.Withdrawal{}
+		if withdrawn {
+			dest = append(dest, &withdrawal.Time, &withdrawal.Reason)
+			vuln.Withdrawn = &withdrawal
+		}
+
+		dest = append(dest, &featureName, &affectedVersion, &fixedIn, &introducedIn, &lastAffected, &tag)
+		if err := rows.Scan(dest...); err != nil {
+			return handleError(queryName, err)
+		}
+
+		if current == nil || id != currentID {
+			if current != nil {
+				if err := fn(*current); err != nil {
+					return err
+				}
+			}
+
+			current, currentID = &vuln, id
+		}
+
+		if featureName.Valid {
+			current.Affected = append(current.Affected, database.AffectedFeature{
+				Namespace:           current.Namespace,
+				FeatureName:         featureName.String,
+				AffectedVersion:     affectedVersion.String,
+				FixedInVersion:      fixedIn.String,
+				IntroducedInVersion: introducedIn.String,
+				LastAffectedVersion: lastAffected.String,
+				Tag:                 tag.String,
+			})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return handleError(queryName, err)
+	}
+
+	if current != nil {
+		return fn(*current)
+	}
+
+	return nil
+}
+
 func (tx *pgSession) InsertVulnerabilities(vulnerabilities []database.VulnerabilityWithAffected) error {
+	tx.markWritten()
+
 	defer observeQueryTime("insertVulnerabilities", "all", time.Now())
+	vulnMap := map[database.VulnerabilityID]struct{}{}
+	for _, v := range vulnerabilities {
+		key := database.VulnerabilityID{
+			Name:      v.Name,
+			Namespace: v.Namespace.Name,
+		}
+
+		// Ensure uniqueness of vulnerability IDs
+		if _, ok := vulnMap[key]; ok {
+			return errors.New("inserting duplicated vulnerabilities is not allowed")
+		}
+		vulnMap[key] = struct{}{}
+	}
+
 	// bulk insert vulnerabilities
 	vulnIDs, err := tx.insertVulnerabilities(vulnerabilities)
 	if err != nil {
@@ -215,29 +356,72 @@ func (tx *pgSession) InsertVulnerabilities(vulnerabilities []database.Vulnerabil
 //
 // i_th vulnerabilityIDs corresponds to i_th vulnerabilities provided.
 func (tx *pgSession) insertVulnerabilityAffected(vulnerabilityIDs []int64, vulnerabilities []database.VulnerabilityWithAffected) (map[int64]affectedFeatureRows, error) {
+	type affectedFeatureKey struct {
+		vulnerabilityID int64
+		featureName     string
+		affectedVersion string
+		fixedIn         string
+		introducedIn    string
+		lastAffected    string
+		tag             string
+	}
+
 	var (
 		vulnFeature = map[int64]affectedFeatureRows{}
-		affectedID  int64
+		keys        []affectedFeatureKey
+		features    = map[affectedFeatureKey]database.AffectedFeature{}
 	)
 
-	//TODO(Sida): Change to bulk insert.
-	stmt, err := tx.Prepare(insertVulnerabilityAffected)
-	if err != nil {
-		return nil, handleError("insertVulnerabilityAffected", err)
-	}
-
-	defer stmt.Close()
 	for i, vuln := range vulnerabilities {
 		// affected feature row ID -> affected feature
-		affectedFeatures := map[int64]database.AffectedFeature{}
+		vulnFeature[vulnerabilityIDs[i]] = affectedFeatureRows{rows: map[int64]database.AffectedFeature{}}
 		for _, f := range vuln.Affected {
-			err := stmt.QueryRow(vulnerabilityIDs[i], f.FeatureName, f.AffectedVersion, f.FixedInVersion).Scan(&affectedID)
-			if err != nil {
-				return nil, handleError("insertVulnerabilityAffected", err)
+			key := affectedFeatureKey{vulnerabilityIDs[i], f.FeatureName, f.AffectedVersion, f.FixedInVersion, f.IntroducedInVersion, f.LastAffectedVersion, f.Tag}
+			keys = append(keys, key)
+			features[key] = f
+		}
+	}
+
+	err := tx.inBatches(len(keys), func(start, end int) error {
+		values := make([]interface{}, 0, (end-start)*7)
+		for _, k := range keys[start:end] {
+			// The features without lower bound have no introducedin, and the
+			// ones without last affected version no lastaffected.
+			introducedIn := sql.NullString{String: k.introducedIn, Valid: k.introducedIn != ""}
+			lastAffected := sql.NullString{String: k.lastAffected, Valid: k.lastAffected != ""}
+			values = append(values, k.vulnerabilityID, k.featureName, k.affectedVersion, k.fixedIn, introducedIn, lastAffected, k.tag)
+		}
+
+		rows, err := tx.Query(queryInsertVulnerabilityAffected(end-start), values...)
+		if err != nil {
+			return handleError("insertVulnerabilityAffected", err)
+		}
+
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				affectedID                 int64
+				key                        affectedFeatureKey
+				introducedIn, lastAffected sql.NullString
+			)
+
+			if err := rows.Scan(&affectedID, &key.vulnerabilityID, &key.featureName, &key.affectedVersion, &key.fixedIn, &introducedIn, &lastAffected, &key.tag); err != nil {
+				return handleError("insertVulnerabilityAffected", err)
 			}
-			affectedFeatures[affectedID] = f
+			key.introducedIn = introducedIn.String
+			key.lastAffected = lastAffected.String
+
+			vulnFeature[key.vulnerabilityID].rows[affectedID] = features[key]
+		}
+
+		if err := rows.Err(); err != nil {
+			return handleError("insertVulnerabilityAffected", err)
 		}
-		vulnFeature[vulnerabilityIDs[i]] = affectedFeatureRows{rows: affectedFeatures}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return vulnFeature, nil
@@ -246,41 +430,65 @@ func (tx *pgSession) insertVulnerabilityAffected(vulnerabilityIDs []int64, vulne
 // insertVulnerabilities inserts a set of unique vulnerabilities into database,
 // under the assumption that all vulnerabilities are valid.
 func (tx *pgSession) insertVulnerabilities(vulnerabilities []database.VulnerabilityWithAffected) ([]int64, error) {
-	var (
-		vulnID  int64
-		vulnIDs = make([]int64, 0, len(vulnerabilities))
-		vulnMap = map[database.VulnerabilityID]struct{}{}
-	)
-
-	for _, v := range vulnerabilities {
-		key := database.VulnerabilityID{
-			Name:      v.Name,
-			Namespace: v.Namespace.Name,
-		}
+	type vulnerabilityKey struct {
+		namespaceID int64
+		name        string
+	}
 
-		// Ensure uniqueness of vulnerability IDs
-		if _, ok := vulnMap[key]; ok {
-			return nil, errors.New("inserting duplicated vulnerabilities is not allowed")
-		}
-		vulnMap[key] = struct{}{}
+	namespaces := make([]database.Namespace, 0, len(vulnerabilities))
+	for _, vuln := range vulnerabilities {
+		namespaces = append(namespaces, vuln.Namespace)
 	}
 
-	//TODO(Sida): Change to bulk insert.
-	stmt, err := tx.Prepare(insertVulnerability)
+	namespaceIDs, err := tx.findNamespaceIDs(namespaces)
 	if err != nil {
-		return nil, handleError("insertVulnerability", err)
+		return nil, err
 	}
 
-	defer stmt.Close()
-	for _, vuln := range vulnerabilities {
-		err := stmt.QueryRow(vuln.Name, vuln.Description,
-			vuln.Link, &vuln.Severity, &vuln.Metadata,
-			vuln.Namespace.Name, vuln.Namespace.VersionFormat).Scan(&vulnID)
+	indexes := make(map[vulnerabilityKey]int, len(vulnerabilities))
+	for i, id := range namespaceIDs {
+		if !id.Valid {
+			return nil, database.ErrMissingEntities
+		}
+		indexes[vulnerabilityKey{id.Int64, vulnerabilities[i].Name}] = i
+	}
+
+	vulnIDs := make([]int64, len(vulnerabilities))
+	err = tx.inBatches(len(vulnerabilities), func(start, end int) error {
+		values := make([]interface{}, 0, (end-start)*6)
+		for i := start; i < end; i++ {
+			vuln := &vulnerabilities[i]
+			values = append(values, namespaceIDs[i].Int64, vuln.Name, vuln.Description,
+				vuln.Link, &vuln.Severity, &vuln.Metadata)
+		}
+
+		rows, err := tx.Query(queryInsertVulnerabilities(end-start), values...)
 		if err != nil {
-			return nil, handleError("insertVulnerability", err)
+			return handleError("insertVulnerability", err)
 		}
 
-		vulnIDs = append(vulnIDs, vulnID)
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				vulnID int64
+				key    vulnerabilityKey
+			)
+
+			if err := rows.Scan(&vulnID, &key.namespaceID, &key.name); err != nil {
+				return handleError("insertVulnerability", err)
+			}
+
+			vulnIDs[indexes[key]] = vulnID
+		}
+
+		if err := rows.Err(); err != nil {
+			return handleError("insertVulnerability", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return vulnIDs, nil
@@ -335,8 +543,10 @@ func (tx *pgSession) cacheVulnerabiltyAffectedNamespacedFeature(affected map[int
 			return errors.New("vulnerability affected feature not found")
 		}
 
-		if in, err := versionfmt.InRange(candidate.Namespace.VersionFormat,
+		if in, err := versionfmt.InAffectedRange(versionfmt.NamespaceFormat(candidate.Namespace.Name, candidate.Namespace.VersionFormat),
 			fVersion,
+			candidate.IntroducedInVersion,
+			candidate.LastAffectedVersion,
 			candidate.AffectedVersion); err == nil {
 			if in {
 				relation = append(relation,
@@ -351,30 +561,46 @@ func (tx *pgSession) cacheVulnerabiltyAffectedNamespacedFeature(affected map[int
 		}
 	}
 
-	//TODO(Sida): Change to bulk insert.
-	for _, r := range relation {
-		result, err := tx.Exec(insertVulnerabilityAffectedNamespacedFeature, r.vulnerabilityID, r.namespacedFeatureID, r.addedBy)
-		if err != nil {
-			return handleError("insertVulnerabilityAffectedNamespacedFeature", err)
+	err = tx.inBatches(len(relation), func(start, end int) error {
+		values := make([]interface{}, 0, (end-start)*3)
+		for _, r := range relation[start:end] {
+			values = append(values, r.vulnerabilityID, r.namespacedFeatureID, r.addedBy)
 		}
 
-		if num, err := result.RowsAffected(); err == nil {
-			if num <= 0 {
-				return errors.New("Nothing cached in database")
-			}
-		} else {
-			return err
+		// A feature in several affected ranges of a vulnerability is only
+		// related to it once.
+		if _, err := tx.Exec(queryPersistVulnerabilityAffectedNamespacedFeature(end-start), values...); err != nil {
+			return handleError("persistVulnerabilityAffectedNamespacedFeature", err)
 		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	log.Debugf("Cached %d features in vulnerability_affected_namespaced_feature", len(relation))
+	log.WithField("count", len(relation)).Debug("cached features in vulnerability_affected_namespaced_feature")
 	return nil
 }
 
 func (tx *pgSession) DeleteVulnerabilities(vulnerabilities []database.VulnerabilityID) error {
 	defer observeQueryTime("DeleteVulnerability", "all", time.Now())
 
-	vulnIDs, err := tx.markVulnerabilitiesAsDeleted(vulnerabilities)
+	return tx.deleteVulnerabilities(vulnerabilities, sql.NullString{})
+}
+
+func (tx *pgSession) WithdrawVulnerabilities(vulnerabilities []database.VulnerabilityID, reason string) error {
+	defer observeQueryTime("WithdrawVulnerabilities", "all", time.Now())
+
+	return tx.deleteVulnerabilities(vulnerabilities, sql.NullString{String: reason, Valid: true})
+}
+
+// deleteVulnerabilities marks the vulnerabilities as deleted, and as withdrawn
+// for the given reason if it is not NULL, then invalidates their cache.
+func (tx *pgSession) deleteVulnerabilities(vulnerabilities []database.VulnerabilityID, withdrawnReason sql.NullString) error {
+	tx.markWritten()
+
+	vulnIDs, err := tx.markVulnerabilitiesAsDeleted(vulnerabilities, withdrawnReason)
 	if err != nil {
 		return err
 	}
@@ -385,6 +611,45 @@ func (tx *pgSession) DeleteVulnerabilities(vulnerabilities []database.Vulnerabil
 	return nil
 }
 
+func (tx *pgSession) UpdateVulnerabilityMetadata(name, key string, metadata interface{}) error {
+	tx.markWritten()
+
+	defer observeQueryTime("UpdateVulnerabilityMetadata", "all", time.Now())
+
+	rows, err := tx.Query(searchVulnerabilityMetadataByName, name)
+	if err != nil {
+		return handleError("searchVulnerabilityMetadataByName", err)
+	}
+
+	metadataByID := map[int64]database.MetadataMap{}
+	for rows.Next() {
+		var (
+			id           int64
+			vulnMetadata database.MetadataMap
+		)
+
+		if err := rows.Scan(&id, &vulnMetadata); err != nil {
+			rows.Close()
+			return handleError("searchVulnerabilityMetadataByName", err)
+		}
+		metadataByID[id] = vulnMetadata
+	}
+	rows.Close()
+
+	for id, vulnMetadata := range metadataByID {
+		if vulnMetadata == nil {
+			vulnMetadata = database.MetadataMap{}
+		}
+		vulnMetadata[key] = metadata
+
+		if _, err := tx.Exec(updateVulnerabilityMetadata, id, &vulnMetadata); err != nil {
+			return handleError("updateVulnerabilityMetadata", err)
+		}
+	}
+
+	return nil
+}
+
 func (tx *pgSession) invalidateVulnerabilityCache(vulnerabilityIDs []int64) error {
 	if len(vulnerabilityIDs) == 0 {
 		return nil
@@ -410,7 +675,7 @@ func (tx *pgSession) invalidateVulnerabilityCache(vulnerabilityIDs []int64) erro
 	return nil
 }
 
-func (tx *pgSession) markVulnerabilitiesAsDeleted(vulnerabilities []database.VulnerabilityID) ([]int64, error) {
+func (tx *pgSession) markVulnerabilitiesAsDeleted(vulnerabilities []database.VulnerabilityID, withdrawnReason sql.NullString) ([]int64, error) {
 	var (
 		vulnID  sql.NullInt64
 		vulnIDs []int64
@@ -424,7 +689,7 @@ func (tx *pgSession) markVulnerabilitiesAsDeleted(vulnerabilities []database.Vul
 
 	defer stmt.Close()
 	for _, vuln := range vulnerabilities {
-		err := stmt.QueryRow(vuln.Namespace, vuln.Name).Scan(&vulnID)
+		err := stmt.QueryRow(vuln.Namespace, vuln.Name, withdrawnReason).Scan(&vulnID)
 		if err != nil {
 			return nil, handleError("removeVulnerability", err)
 		}
@@ -15,11 +15,14 @@
 package pgsql
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
 	"github.com/coreos/clair/ext/versionfmt/dpkg"
 )
 
@@ -182,6 +185,332 @@ func TestCachingVulnerable(t *testing.T) {
 	}
 }
 
+func TestInsertVulnerabilitiesInBatches(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "InsertVulnerabilitiesInBatches", true)
+	defer closeTest(t, datastore, tx)
+
+	// Every row is inserted by its own query.
+	tx.batchSize = 1
+
+	ns := database.Namespace{
+		Name:          "debian:8",
+		VersionFormat: dpkg.ParserName,
+	}
+
+	vulns := []database.VulnerabilityWithAffected{
+		{
+			Vulnerability: database.Vulnerability{
+				Name:      "CVE-BATCH-1",
+				Namespace: ns,
+				Severity:  database.HighSeverity,
+			},
+			Affected: []database.AffectedFeature{
+				{Namespace: ns, FeatureName: "openssl", AffectedVersion: "2.0", FixedInVersion: "2.0"},
+				{Namespace: ns, FeatureName: "libssl", AffectedVersion: "1.9", FixedInVersion: "1.9"},
+			},
+		},
+		{
+			Vulnerability: database.Vulnerability{
+				Name:      "CVE-BATCH-2",
+				Namespace: ns,
+				Severity:  database.LowSeverity,
+			},
+			Affected: []database.AffectedFeature{
+				{Namespace: ns, FeatureName: "openssl", AffectedVersion: "3.0", FixedInVersion: "3.0"},
+			},
+		},
+	}
+
+	// The vulnerabilities are checked for duplicates across the batches.
+	assert.NotNil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulns[0], vulns[1], vulns[0]}))
+	tx = restartSession(t, datastore, tx, false)
+	tx.batchSize = 1
+
+	// The namespaces must exist.
+	unknown := vulns[1]
+	unknown.Namespace = database.Namespace{Name: "unknown", VersionFormat: dpkg.ParserName}
+	assert.Equal(t, database.ErrMissingEntities, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulns[0], unknown}))
+	tx = restartSession(t, datastore, tx, false)
+	tx.batchSize = 1
+
+	if !assert.Nil(t, tx.InsertVulnerabilities(vulns)) {
+		t.FailNow()
+	}
+
+	found, err := tx.FindVulnerabilities([]database.VulnerabilityID{
+		{Name: "CVE-BATCH-1", Namespace: "debian:8"},
+		{Name: "CVE-BATCH-2", Namespace: "debian:8"},
+	})
+	if assert.Nil(t, err) && assert.Len(t, found, 2) {
+		for i, v := range found {
+			if assert.True(t, v.Valid) {
+				assertVulnerabilityWithAffectedEqual(t, vulns[i], v.VulnerabilityWithAffected)
+			}
+		}
+	}
+
+	// The features affected by the vulnerabilities are cached.
+	affected, err := tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{{
+		Feature:   database.Feature{Name: "openssl", Version: "1.0", VersionFormat: dpkg.ParserName},
+		Namespace: ns,
+	}})
+	if assert.Nil(t, err) && assert.Len(t, affected, 1) && assert.True(t, affected[0].Valid) {
+		assert.Len(t, affected[0].AffectedBy, 2)
+	}
+}
+
+func TestCachingVulnerableIntroducedIn(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "CachingVulnerableIntroducedIn", true)
+	defer closeTest(t, datastore, tx)
+
+	ns := database.Namespace{
+		Name:          "debian:8",
+		VersionFormat: dpkg.ParserName,
+	}
+
+	f := database.NamespacedFeature{
+		Feature: database.Feature{
+			Name:          "openssl",
+			Version:       "1.0",
+			VersionFormat: dpkg.ParserName,
+		},
+		Namespace: ns,
+	}
+
+	// openssl 1.0 is older than the version introducing CVE-YAY2.
+	vulns := []database.VulnerabilityWithAffected{
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-YAY", Namespace: ns, Severity: database.HighSeverity},
+			Affected: []database.AffectedFeature{
+				{Namespace: ns, FeatureName: "openssl", AffectedVersion: "2.0", FixedInVersion: "2.0", IntroducedInVersion: "0.9"},
+			},
+		},
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-YAY2", Namespace: ns, Severity: database.HighSeverity},
+			Affected: []database.AffectedFeature{
+				{Namespace: ns, FeatureName: "openssl", AffectedVersion: "2.0", FixedInVersion: "2.0", IntroducedInVersion: "1.5"},
+			},
+		},
+	}
+
+	if !assert.Nil(t, tx.InsertVulnerabilities(vulns)) {
+		t.FailNow()
+	}
+
+	r, err := tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{f})
+	if assert.Nil(t, err) && assert.Len(t, r, 1) && assert.True(t, r[0].Valid) && assert.Len(t, r[0].AffectedBy, 1) {
+		assert.Equal(t, "CVE-YAY", r[0].AffectedBy[0].Name)
+	}
+
+	found, err := tx.FindVulnerabilities([]database.VulnerabilityID{{Name: "CVE-YAY2", Namespace: "debian:8"}})
+	if assert.Nil(t, err) && assert.Len(t, found, 1) && assert.True(t, found[0].Valid) {
+		assertAffectedFeaturesEqual(t, vulns[1].Affected, found[0].Affected)
+	}
+}
+
+func TestCachingVulnerableLastAffected(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "CachingVulnerableLastAffected", true)
+	defer closeTest(t, datastore, tx)
+
+	ns := database.Namespace{
+		Name:          "debian:8",
+		VersionFormat: dpkg.ParserName,
+	}
+
+	f := database.NamespacedFeature{
+		Feature: database.Feature{
+			Name:          "openssl",
+			Version:       "1.0",
+			VersionFormat: dpkg.ParserName,
+		},
+		Namespace: ns,
+	}
+
+	// openssl 1.0 is newer than the last version affected by CVE-YAY2.
+	vulns := []database.VulnerabilityWithAffected{
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-YAY", Namespace: ns, Severity: database.HighSeverity},
+			Affected: []database.AffectedFeature{
+				{Namespace: ns, FeatureName: "openssl", AffectedVersion: versionfmt.MaxVersion, LastAffectedVersion: "1.0"},
+				{Namespace: ns, FeatureName: "openssl", AffectedVersion: "2.1", FixedInVersion: "2.1", IntroducedInVersion: "1.9"},
+			},
+		},
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-YAY2", Namespace: ns, Severity: database.HighSeverity},
+			Affected: []database.AffectedFeature{
+				{Namespace: ns, FeatureName: "openssl", AffectedVersion: versionfmt.MaxVersion, LastAffectedVersion: "0.9"},
+			},
+		},
+	}
+
+	if !assert.Nil(t, tx.InsertVulnerabilities(vulns)) {
+		t.FailNow()
+	}
+
+	r, err := tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{f})
+	if assert.Nil(t, err) && assert.Len(t, r, 1) && assert.True(t, r[0].Valid) && assert.Len(t, r[0].AffectedBy, 1) {
+		assert.Equal(t, "CVE-YAY", r[0].AffectedBy[0].Name)
+		assert.Equal(t, []database.AffectedRange{
+			{LastAffectedVersion: "1.0"},
+			{IntroducedInVersion: "1.9", FixedInVersion: "2.1"},
+		}, r[0].AffectedBy[0].AffectedRanges)
+	}
+
+	found, err := tx.FindVulnerabilities([]database.VulnerabilityID{{Name: "CVE-YAY2", Namespace: "debian:8"}})
+	if assert.Nil(t, err) && assert.Len(t, found, 1) && assert.True(t, found[0].Valid) {
+		assertAffectedFeaturesEqual(t, vulns[1].Affected, found[0].Affected)
+	}
+}
+
+func TestCachingVulnerableSourceName(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "CachingVulnerableSourceName", true)
+	defer closeTest(t, datastore, tx)
+
+	ns := database.Namespace{
+		Name:          "debian:8",
+		VersionFormat: dpkg.ParserName,
+	}
+
+	// libssl1.0 is a subpackage of openssl, whose vulnerabilities affect it.
+	f := database.NamespacedFeature{
+		Feature: database.Feature{
+			Name:          "libssl1.0",
+			Version:       "1.0",
+			SourceName:    "openssl",
+			SourceVersion: "1.0",
+			VersionFormat: dpkg.ParserName,
+		},
+		Namespace: ns,
+	}
+
+	if !assert.Nil(t, tx.PersistFeatures([]database.Feature{f.Feature})) ||
+		!assert.Nil(t, tx.PersistNamespacedFeatures([]database.NamespacedFeature{f})) {
+		t.FailNow()
+	}
+
+	vulns := []database.VulnerabilityWithAffected{
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-YAY", Namespace: ns, Severity: database.HighSeverity},
+			Affected: []database.AffectedFeature{
+				{Namespace: ns, FeatureName: "openssl", AffectedVersion: "2.0", FixedInVersion: "2.0"},
+			},
+		},
+	}
+
+	if !assert.Nil(t, tx.InsertVulnerabilities(vulns)) {
+		t.FailNow()
+	}
+
+	r, err := tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{f})
+	if assert.Nil(t, err) && assert.Len(t, r, 1) && assert.True(t, r[0].Valid) && assert.Len(t, r[0].AffectedBy, 1) {
+		assert.Equal(t, "CVE-YAY", r[0].AffectedBy[0].Name)
+	}
+}
+
+func TestCachingVulnerableSourceVersion(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "CachingVulnerableSourceVersion", true)
+	defer closeTest(t, datastore, tx)
+
+	ns := database.Namespace{
+		Name:          "debian:8",
+		VersionFormat: dpkg.ParserName,
+	}
+
+	// The version of util-linux, not the one of bsdutils, with its epoch, is
+	// compared with the vulnerabilities of util-linux.
+	bsdutils := database.NamespacedFeature{
+		Feature: database.Feature{
+			Name:          "bsdutils",
+			Version:       "1:2.25.2-6",
+			SourceName:    "util-linux",
+			SourceVersion: "2.25.2-6",
+			VersionFormat: dpkg.ParserName,
+		},
+		Namespace: ns,
+	}
+	// A feature without the version of its source package is only affected
+	// by the vulnerabilities of its own name.
+	mount := database.NamespacedFeature{
+		Feature: database.Feature{
+			Name:          "mount",
+			Version:       "2.25.2-6",
+			SourceName:    "util-linux",
+			VersionFormat: dpkg.ParserName,
+		},
+		Namespace: ns,
+	}
+
+	if !assert.Nil(t, tx.PersistFeatures([]database.Feature{bsdutils.Feature, mount.Feature})) ||
+		!assert.Nil(t, tx.PersistNamespacedFeatures([]database.NamespacedFeature{bsdutils, mount})) {
+		t.FailNow()
+	}
+
+	vulns := []database.VulnerabilityWithAffected{
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-UTIL", Namespace: ns, Severity: database.HighSeverity},
+			Affected: []database.AffectedFeature{
+				{Namespace: ns, FeatureName: "util-linux", AffectedVersion: "2.25.2-7", FixedInVersion: "2.25.2-7"},
+			},
+		},
+	}
+
+	if !assert.Nil(t, tx.InsertVulnerabilities(vulns)) {
+		t.FailNow()
+	}
+
+	r, err := tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{bsdutils, mount})
+	if assert.Nil(t, err) && assert.Len(t, r, 2) {
+		if assert.True(t, r[0].Valid) && assert.Len(t, r[0].AffectedBy, 1) {
+			assert.Equal(t, "CVE-UTIL", r[0].AffectedBy[0].Name)
+		}
+		if assert.True(t, r[1].Valid) {
+			assert.Empty(t, r[1].AffectedBy)
+		}
+	}
+}
+
+func TestCachingVulnerableTag(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "CachingVulnerableTag", true)
+	defer closeTest(t, datastore, tx)
+
+	ns := database.Namespace{
+		Name:          "debian:8",
+		VersionFormat: dpkg.ParserName,
+	}
+
+	f := database.NamespacedFeature{
+		Feature: database.Feature{
+			Name:          "openssl",
+			Version:       "1.0",
+			VersionFormat: dpkg.ParserName,
+		},
+		Namespace: ns,
+	}
+
+	vulns := []database.VulnerabilityWithAffected{
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-YAY", Namespace: ns, Severity: database.HighSeverity},
+			Affected: []database.AffectedFeature{
+				{Namespace: ns, FeatureName: "openssl", AffectedVersion: "2.0", FixedInVersion: "2.0", Tag: "no-dsa"},
+			},
+		},
+	}
+
+	if !assert.Nil(t, tx.InsertVulnerabilities(vulns)) {
+		t.FailNow()
+	}
+
+	r, err := tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{f})
+	if assert.Nil(t, err) && assert.Len(t, r, 1) && assert.True(t, r[0].Valid) && assert.Len(t, r[0].AffectedBy, 1) {
+		assert.Equal(t, "no-dsa", r[0].AffectedBy[0].Tag)
+	}
+
+	found, err := tx.FindVulnerabilities([]database.VulnerabilityID{{Name: "CVE-YAY", Namespace: "debian:8"}})
+	if assert.Nil(t, err) && assert.Len(t, found, 1) && assert.True(t, found[0].Valid) && assert.Len(t, found[0].Affected, 1) {
+		assert.Equal(t, "no-dsa", found[0].Affected[0].Tag)
+	}
+}
+
 func TestFindVulnerabilities(t *testing.T) {
 	datastore, tx := openSessionForTest(t, "FindVulnerabilities", true)
 	defer closeTest(t, datastore, tx)
@@ -300,6 +629,132 @@ func TestDeleteVulnerabilities(t *testing.T) {
 	}
 }
 
+func TestWithdrawVulnerabilities(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "WithdrawVulnerabilities", true)
+	defer closeTest(t, datastore, tx)
+
+	walk := func(since time.Time) ([]database.VulnerabilityWithAffected, error) {
+		vulns := []database.VulnerabilityWithAffected{}
+		err := tx.WalkWithdrawnVulnerabilities("debian:7", since, func(v database.VulnerabilityWithAffected) error {
+			vulns = append(vulns, v)
+			return nil
+		})
+		return vulns, err
+	}
+
+	// The vulnerabilities deleted because they changed are not withdrawn
+	vulns, err := walk(time.Time{})
+	if assert.Nil(t, err) {
+		assert.Empty(t, vulns)
+	}
+
+	withdraw := []database.VulnerabilityID{{Name: "CVE-OPENSSL-1-DEB7", Namespace: "debian:7"}}
+	assert.Nil(t, tx.WithdrawVulnerabilities(withdraw, "rejected"))
+
+	vuln, err := tx.FindVulnerabilities(withdraw)
+	if assert.Nil(t, err) && assert.Len(t, vuln, 1) {
+		assert.False(t, vuln[0].Valid)
+	}
+
+	vulns, err = walk(time.Time{})
+	if assert.Nil(t, err) && assert.Len(t, vulns, 1) {
+		assert.Equal(t, "CVE-OPENSSL-1-DEB7", vulns[0].Name)
+		assert.Len(t, vulns[0].Affected, 2)
+		if assert.NotNil(t, vulns[0].Withdrawn) {
+			assert.Equal(t, "rejected", vulns[0].Withdrawn.Reason)
+			assert.False(t, vulns[0].Withdrawn.Time.IsZero())
+		}
+	}
+
+	// A vulnerability inserted again is not withdrawn anymore
+	assert.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{{
+		Vulnerability: database.Vulnerability{
+			Name:      "CVE-OPENSSL-1-DEB7",
+			Namespace: database.Namespace{Name: "debian:7", VersionFormat: "dpkg"},
+			Severity:  database.HighSeverity,
+		},
+	}}))
+
+	vulns, err = walk(time.Time{})
+	if assert.Nil(t, err) {
+		assert.Empty(t, vulns)
+	}
+}
+
+func TestUpdateVulnerabilityMetadata(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "UpdateVulnerabilityMetadata", true)
+	defer closeTest(t, datastore, tx)
+
+	// unknown vulnerabilities are ignored
+	assert.Nil(t, tx.UpdateVulnerabilityMetadata("CVE-NOPE-NOPE", "key", "value"))
+
+	assert.Nil(t, tx.UpdateVulnerabilityMetadata("CVE-OPENSSL-1-DEB7", "key", map[string]interface{}{"score": 4.0}))
+	assert.Nil(t, tx.UpdateVulnerabilityMetadata("CVE-OPENSSL-1-DEB7", "other", "value"))
+
+	vulns, err := tx.FindVulnerabilities([]database.VulnerabilityID{{Name: "CVE-OPENSSL-1-DEB7", Namespace: "debian:7"}})
+	if assert.Nil(t, err) && assert.Len(t, vulns, 1) && assert.True(t, vulns[0].Valid) {
+		assert.Equal(t, database.MetadataMap{
+			"key":   map[string]interface{}{"score": 4.0},
+			"other": "value",
+		}, vulns[0].Metadata)
+	}
+}
+
+func TestWalkVulnerabilities(t *testing.T) {
+	datastore, tx := openSessionForTest(t, "WalkVulnerabilities", true)
+	defer closeTest(t, datastore, tx)
+
+	walk := func(namespace string, since time.Time) ([]database.VulnerabilityWithAffected, error) {
+		vulns := []database.VulnerabilityWithAffected{}
+		err := tx.WalkVulnerabilities(namespace, since, func(v database.VulnerabilityWithAffected) error {
+			vulns = append(vulns, v)
+			return nil
+		})
+		return vulns, err
+	}
+
+	// Unknown namespace
+	vulns, err := walk("unknown:1", time.Time{})
+	if assert.Nil(t, err) {
+		assert.Empty(t, vulns)
+	}
+
+	// Deleted vulnerabilities are not walked
+	vulns, err = walk("debian:7", time.Time{})
+	if assert.Nil(t, err) && assert.Len(t, vulns, 2) {
+		assert.Equal(t, "CVE-OPENSSL-1-DEB7", vulns[0].Name)
+		assert.Equal(t, database.Namespace{Name: "debian:7", VersionFormat: "dpkg"}, vulns[0].Namespace)
+		assert.Len(t, vulns[0].Affected, 2)
+		assert.Equal(t, "CVE-NOPE", vulns[1].Name)
+		assert.Empty(t, vulns[1].Affected)
+	}
+
+	// Only the vulnerabilities inserted since the given time are walked
+	since := time.Now()
+	assert.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{{
+		Vulnerability: database.Vulnerability{
+			Name:      "CVE-NEW",
+			Namespace: database.Namespace{Name: "debian:7", VersionFormat: "dpkg"},
+			Severity:  database.HighSeverity,
+		},
+	}}))
+
+	vulns, err = walk("debian:7", since.Add(-time.Minute))
+	if assert.Nil(t, err) && assert.Len(t, vulns, 1) {
+		assert.Equal(t, "CVE-NEW", vulns[0].Name)
+	}
+
+	// The walk stops at the first error
+	expectedErr := errors.New("stop")
+	count := 0
+	err = tx.WalkVulnerabilities("debian:7", time.Time{}, func(database.VulnerabilityWithAffected) error {
+		count++
+		return expectedErr
+	})
+	assert.Equal(t, expectedErr, err)
+	assert.Equal(t, 1, count)
+}
+
 func TestFindVulnerabilityIDs(t *testing.T) {
 	store, tx := openSessionForTest(t, "FindVulnerabilityIDs", true)
 	defer closeTest(t, store, tx)
@@ -30,7 +30,7 @@ import (
 )
 
 func init() {
-	featurefmt.RegisterLister("apk", "1.0", &lister{})
+	featurefmt.RegisterLister("apk", "1.1", &lister{})
 }
 
 type lister struct{}
@@ -39,6 +39,14 @@ func valid(pkg *database.Feature) bool {
 	return pkg.Name != "" && pkg.Version != ""
 }
 
+// addSourcePackage sets the version of the origin package, which is the one of
+// the package.
+func addSourcePackage(pkg *database.Feature) {
+	if pkg.SourceName != "" {
+		pkg.SourceVersion = pkg.Version
+	}
+}
+
 func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error) {
 	file, exists := files["lib/apk/db/installed"]
 	if !exists {
@@ -55,18 +63,22 @@ func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error)
 		line := scanner.Text()
 		if len(line) < 2 {
 			if valid(&pkg) {
+				addSourcePackage(&pkg)
 				packages.Add(pkg)
 				pkg = database.Feature{VersionFormat: dpkg.ParserName}
 			}
 			continue
 		}
 
-		// Parse the package name or version.
-		// Alpine package doesn't have specific source package. The "origin"
-		// package is sub package.
+		// Parse the package name, version or origin.
+		// The subpackages built from the same APKBUILD share its origin
+		// package, which is the source package that the advisories refer to
+		// and has the version of its subpackages.
 		switch line[:2] {
 		case "P:":
 			pkg.Name = line[2:]
+		case "o:":
+			pkg.SourceName = line[2:]
 		case "V:":
 			version := string(line[2:])
 			err := versionfmt.Valid(dpkg.ParserName, version)
@@ -81,6 +93,7 @@ func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error)
 
 	// in case of no terminal line
 	if valid(&pkg) {
+		addSourcePackage(&pkg)
 		packages.Add(pkg)
 	}
 
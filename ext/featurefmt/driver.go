@@ -17,6 +17,7 @@
 package featurefmt
 
 import (
+	"sort"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
@@ -38,10 +39,17 @@ type Lister interface {
 	// RequiredFilenames returns the list of files required to be in the FilesMap
 	// provided to the ListFeatures method.
 	//
-	// Filenames must not begin with "/".
+	// Filenames must not begin with "/". They are matched against the files of
+	// the layer by tarutil.MatchFilename.
 	RequiredFilenames() []string
 }
 
+// OptIn is implemented by the Listers that are only run when they are enabled
+// explicitly, e.g. because the files they read are expensive to extract.
+type OptIn interface {
+	OptIn()
+}
+
 type lister struct {
 	Lister
 
@@ -72,38 +80,91 @@ func RegisterLister(name string, version string, l Lister) {
 
 // ListFeatures produces the list of Features in an image layer using
 // every registered Lister.
-func ListFeatures(files tarutil.FilesMap, toUse []database.Detector) ([]database.LayerFeature, error) {
+//
+// The listers are run concurrently, at most concurrency of them at a time,
+// and the features are sorted so that the result does not depend on the order
+// in which they finish.
+func ListFeatures(files tarutil.FilesMap, toUse []database.Detector, concurrency int) ([]database.LayerFeature, error) {
 	listersM.RLock()
 	defer listersM.RUnlock()
 
-	features := []database.LayerFeature{}
+	toRun := []lister{}
 	for _, d := range toUse {
 		// Only use the detector with the same type
 		if d.DType != database.FeatureDetectorType {
 			continue
 		}
 
-		if lister, ok := listers[d.Name]; ok {
-			fs, err := lister.ListFeatures(files)
-			if err != nil {
-				return nil, err
-			}
+		lister, ok := listers[d.Name]
+		if !ok {
+			log.WithField("Name", d).Fatal("unknown feature detector")
+		}
+
+		toRun = append(toRun, lister)
+	}
 
-			for _, f := range fs {
-				features = append(features, database.LayerFeature{
-					Feature: f,
-					By:      lister.info,
-				})
-			}
+	if concurrency < 1 {
+		concurrency = 1
+	}
 
-		} else {
-			log.WithField("Name", d).Fatal("unknown feature detector")
+	var (
+		wg      sync.WaitGroup
+		tokens  = make(chan struct{}, concurrency)
+		results = make([][]database.Feature, len(toRun))
+		errs    = make([]error, len(toRun))
+	)
+
+	for i := range toRun {
+		wg.Add(1)
+		tokens <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = toRun[i].ListFeatures(files)
+			<-tokens
+		}(i)
+	}
+	wg.Wait()
+
+	features := []database.LayerFeature{}
+	for i, fs := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		for _, f := range fs {
+			features = append(features, database.LayerFeature{
+				Feature: f,
+				By:      toRun[i].info,
+			})
 		}
 	}
 
+	sort.Slice(features, func(i, j int) bool {
+		return lessLayerFeature(features[i], features[j])
+	})
+
 	return features, nil
 }
 
+// lessLayerFeature orders the layer features by lister, then by feature.
+func lessLayerFeature(a, b database.LayerFeature) bool {
+	for _, c := range [][2]string{
+		{a.By.Name, b.By.Name},
+		{a.By.Version, b.By.Version},
+		{a.Name, b.Name},
+		{a.Version, b.Version},
+		{a.SourceName, b.SourceName},
+		{a.SourceVersion, b.SourceVersion},
+		{a.VersionFormat, b.VersionFormat},
+	} {
+		if c[0] != c[1] {
+			return c[0] < c[1]
+		}
+	}
+
+	return false
+}
+
 // RequiredFilenames returns all files required by the give extensions. Any
 // extension metadata that has non feature-detector type will be skipped.
 func RequiredFilenames(toUse []database.Detector) (files []string) {
@@ -129,3 +190,16 @@ func ListListers() []database.Detector {
 	}
 	return r
 }
+
+// ListDefaultListers returns the names of the feature listers that are run
+// when none is enabled explicitly, which are all the registered ones but the
+// OptIn ones.
+func ListDefaultListers() []database.Detector {
+	r := []database.Detector{}
+	for _, d := range listers {
+		if _, optIn := d.Lister.(OptIn); !optIn {
+			r = append(r, d.info)
+		}
+	}
+	return r
+}
@@ -0,0 +1,67 @@
+package featurefmt_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt"
+	"github.com/coreos/clair/pkg/tarutil"
+
+	_ "github.com/coreos/clair/ext/featurefmt/apk"
+	_ "github.com/coreos/clair/ext/featurefmt/dpkg"
+	_ "github.com/coreos/clair/ext/featurefmt/gobinary"
+)
+
+func TestListFeatures(t *testing.T) {
+	files := tarutil.FilesMap{
+		"lib/apk/db/installed": featurefmt.LoadFileForTest("apk/testdata/valid"),
+		"var/lib/dpkg/status":  featurefmt.LoadFileForTest("dpkg/testdata/valid"),
+	}
+
+	var expected []database.LayerFeature
+	for _, concurrency := range []int{0, 1, 2, 8} {
+		features, err := featurefmt.ListFeatures(files, featurefmt.ListListers(), concurrency)
+		require.Nil(t, err)
+
+		// The features of every lister are listed, ordered by lister and by
+		// feature.
+		assert.True(t, sort.SliceIsSorted(features, func(i, j int) bool {
+			if features[i].By.Name != features[j].By.Name {
+				return features[i].By.Name < features[j].By.Name
+			}
+			return features[i].Name < features[j].Name
+		}), "concurrency=%d", concurrency)
+
+		if expected == nil {
+			expected = features
+			assert.NotEmpty(t, expected)
+			continue
+		}
+		assert.Equal(t, expected, features, "concurrency=%d", concurrency)
+	}
+
+	listers := map[string]bool{}
+	for _, f := range expected {
+		listers[f.By.Name] = true
+	}
+	assert.Equal(t, map[string]bool{"apk": true, "dpkg": true}, listers)
+}
+
+func TestListDefaultListers(t *testing.T) {
+	names := func(detectors []database.Detector) []string {
+		var names []string
+		for _, d := range detectors {
+			names = append(names, d.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	// The gobinary lister is opt-in.
+	assert.Equal(t, []string{"apk", "dpkg", "gobinary"}, names(featurefmt.ListListers()))
+	assert.Equal(t, []string{"apk", "dpkg"}, names(featurefmt.ListDefaultListers()))
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gobinary implements a featurefmt.Lister for the Go modules built
+// into the Go binaries, read from their embedded build information.
+//
+// The lister is opt-in, as it extracts the executables of the usual binary
+// directories of the layers.
+package gobinary
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"runtime/debug"
+	"strings"
+
+	"github.com/deckarep/golang-set"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/gomod"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const (
+	// stdlibName is the name of the feature of the standard library the
+	// binaries were built with, as named by the Go vulnerability database.
+	stdlibName = "stdlib"
+
+	// maxBinarySize is the size of the largest binary that is read. The larger
+	// files are skipped, before being extracted.
+	maxBinarySize = 128 * 1024 * 1024
+)
+
+// BinaryFilenames are the patterns of the executables that are read: the files
+// of the root directory, such as the binaries of the scratch images, and of the
+// usual binary directories.
+var BinaryFilenames = []string{
+	"*",
+	"bin/*",
+	"sbin/*",
+	"usr/bin/*",
+	"usr/sbin/*",
+	"usr/local/bin/*",
+	"usr/local/sbin/*",
+	"**/go/bin/*",
+	"app/*",
+	"ko-app/*",
+}
+
+func init() {
+	featurefmt.RegisterLister("gobinary", "1.0", &lister{})
+
+	// The binaries are read on a best-effort basis: the broad patterns must
+	// not make the layers with large files fail to be extracted.
+	tarutil.RegisterOptionalFilenames(BinaryFilenames, maxBinarySize)
+}
+
+type lister struct{}
+
+func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error) {
+	// Binaries built from the same modules list them several times, which are
+	// stored in a set to guarantee uniqueness.
+	modules := mapset.NewSet()
+	for filename, file := range files {
+		if !tarutil.MatchFilename(filename, BinaryFilenames) {
+			continue
+		}
+
+		info, ok := ReadBuildInfo(file)
+		if !ok {
+			continue
+		}
+
+		for _, f := range featuresFromBuildInfo(info) {
+			if err := versionfmt.Valid(gomod.ParserName, f.Version); err != nil {
+				log.WithError(err).WithFields(log.Fields{"file": filename, "module": f.Name, "version": f.Version}).Debug("could not parse module version. skipping")
+				continue
+			}
+
+			modules.Add(f)
+		}
+	}
+
+	return database.ConvertFeatureSetToFeatures(modules), nil
+}
+
+func (l lister) RequiredFilenames() []string {
+	return BinaryFilenames
+}
+
+// OptIn makes the lister run only when it is enabled explicitly.
+func (l lister) OptIn() {}
+
+// ReadBuildInfo reads the build information of a Go binary. The files that are
+// not Go binaries, the binaries whose build information was stripped and the
+// files larger than the maximum binary size are skipped.
+func ReadBuildInfo(file []byte) (*debug.BuildInfo, bool) {
+	if len(file) > maxBinarySize {
+		return nil, false
+	}
+
+	info, err := buildinfo.Read(bytes.NewReader(file))
+	if err != nil {
+		return nil, false
+	}
+	return info, true
+}
+
+// featuresFromBuildInfo returns the modules of a binary, replaced as they were
+// built, along with its main module when it is versioned and the standard
+// library.
+func featuresFromBuildInfo(info *debug.BuildInfo) []database.Feature {
+	features := []database.Feature{}
+	if info.Main.Path != "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		features = append(features, moduleFeature(&info.Main))
+	}
+
+	for _, dep := range info.Deps {
+		features = append(features, moduleFeature(dep))
+	}
+
+	// The Go version may be followed by the experiments it was built with,
+	// e.g. "go1.20.3 X:boringcrypto".
+	if fields := strings.Fields(info.GoVersion); len(fields) > 0 && strings.HasPrefix(fields[0], "go") {
+		version := strings.TrimPrefix(fields[0], "go")
+		if strings.Count(version, ".") == 1 {
+			version += ".0"
+		}
+
+		features = append(features, database.Feature{
+			Name:          stdlibName,
+			Version:       "v" + version,
+			VersionFormat: gomod.ParserName,
+		})
+	}
+
+	return features
+}
+
+func moduleFeature(module *debug.Module) database.Feature {
+	if module.Replace != nil {
+		module = module.Replace
+	}
+
+	return database.Feature{
+		Name:          module.Path,
+		Version:       module.Version,
+		VersionFormat: gomod.ParserName,
+	}
+}
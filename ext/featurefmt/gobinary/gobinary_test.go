@@ -0,0 +1,89 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gobinary
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt/gomod"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+func TestListFeatures(t *testing.T) {
+	// The test binary is a Go binary built with the current toolchain.
+	executable, err := os.Executable()
+	require.Nil(t, err)
+	binary, err := ioutil.ReadFile(executable)
+	require.Nil(t, err)
+
+	features, err := lister{}.ListFeatures(tarutil.FilesMap{
+		"usr/local/bin/app": binary,
+		"app/server":        binary,
+		"usr/bin/script":    []byte("#!/bin/sh\necho hello\n"),
+		"usr/share/app":     binary,
+	})
+	require.Nil(t, err)
+
+	stdlib := database.Feature{Name: "stdlib", Version: "v" + strings.TrimPrefix(strings.Fields(runtime.Version())[0], "go"), VersionFormat: gomod.ParserName}
+	if strings.Count(stdlib.Version, ".") == 1 {
+		stdlib.Version += ".0"
+	}
+	assert.Contains(t, features, stdlib)
+
+	for _, f := range features {
+		assert.Equal(t, gomod.ParserName, f.VersionFormat)
+	}
+}
+
+func TestFeaturesFromBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.20 X:boringcrypto",
+		Main:      debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/text", Version: "v0.3.7"},
+			{Path: "github.com/old/module", Version: "v1.0.0", Replace: &debug.Module{Path: "github.com/new/module", Version: "v1.1.0"}},
+		},
+	}
+
+	assert.Equal(t, []database.Feature{
+		{Name: "golang.org/x/text", Version: "v0.3.7", VersionFormat: gomod.ParserName},
+		{Name: "github.com/new/module", Version: "v1.1.0", VersionFormat: gomod.ParserName},
+		{Name: "stdlib", Version: "v1.20.0", VersionFormat: gomod.ParserName},
+	}, featuresFromBuildInfo(info))
+
+	info.Main.Version = "v2.0.0"
+	info.GoVersion = ""
+	assert.Equal(t, []database.Feature{
+		{Name: "example.com/app", Version: "v2.0.0", VersionFormat: gomod.ParserName},
+		{Name: "golang.org/x/text", Version: "v0.3.7", VersionFormat: gomod.ParserName},
+		{Name: "github.com/new/module", Version: "v1.1.0", VersionFormat: gomod.ParserName},
+	}, featuresFromBuildInfo(info))
+}
+
+func TestReadBuildInfo(t *testing.T) {
+	for _, file := range [][]byte{nil, []byte("\x7fELF not really"), []byte("#!/bin/sh\n")} {
+		_, ok := ReadBuildInfo(file)
+		assert.False(t, ok)
+	}
+}
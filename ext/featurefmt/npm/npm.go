@@ -0,0 +1,113 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package npm implements a featurefmt.Lister for npm packages.
+package npm
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/deckarep/golang-set"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/semver"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const (
+	manifestName    = "package.json"
+	nodeModulesName = "node_modules"
+)
+
+// packageFilenames are the patterns of the manifests of the packages installed
+// in a node_modules directory, including the scoped ones.
+var packageFilenames = []string{
+	"**/node_modules/*/" + manifestName,
+	"**/node_modules/@*/*/" + manifestName,
+}
+
+type manifest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+func init() {
+	featurefmt.RegisterLister("npm", "1.0", &lister{})
+}
+
+type lister struct{}
+
+func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error) {
+	// The top-level packages are the ones whose dependencies are installed
+	// next to their manifest.
+	roots := make(map[string]struct{})
+	for filename := range files {
+		elements := strings.Split(filename, "/")
+		for i, element := range elements {
+			if element == nodeModulesName {
+				dir, _ := path.Split(strings.Join(elements[:i+1], "/"))
+				roots[dir] = struct{}{}
+			}
+		}
+	}
+
+	// Nested node_modules directories may contain the same package several
+	// times, which is stored in a set to guarantee uniqueness.
+	packages := mapset.NewSet()
+	for filename, file := range files {
+		dir, base := path.Split(filename)
+		if base != manifestName {
+			continue
+		}
+
+		if _, isRoot := roots[dir]; !isRoot && !tarutil.MatchFilename(filename, packageFilenames) {
+			continue
+		}
+
+		var m manifest
+		if err := json.Unmarshal(file, &m); err != nil {
+			log.WithError(err).WithField("file", filename).Warning("could not parse npm package manifest. skipping")
+			continue
+		}
+
+		// Private packages may not have a name or a version.
+		if m.Name == "" || m.Version == "" {
+			continue
+		}
+
+		if err := versionfmt.Valid(semver.ParserName, m.Version); err != nil {
+			log.WithError(err).WithField("version", m.Version).Warning("could not parse package version. skipping")
+			continue
+		}
+
+		packages.Add(database.Feature{
+			Name:          m.Name,
+			Version:       m.Version,
+			VersionFormat: semver.ParserName,
+		})
+	}
+
+	return database.ConvertFeatureSetToFeatures(packages), nil
+}
+
+// RequiredFilenames returns all the package manifests, as the top-level ones
+// can only be told apart from the files of the layer.
+func (l lister) RequiredFilenames() []string {
+	return []string{"**/" + manifestName}
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package npm
+
+import (
+	"testing"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt"
+	"github.com/coreos/clair/ext/versionfmt/semver"
+)
+
+func TestNpmFeatureDetection(t *testing.T) {
+	for _, test := range []featurefmt.TestCase{
+		{
+			"valid case",
+			map[string]string{
+				"package.json":                                                     "npm/testdata/root-package.json",
+				"node_modules/debug/package.json":                                  "npm/testdata/debug-3.1.0-package.json",
+				"usr/src/app/package.json":                                         "npm/testdata/app-package.json",
+				"usr/src/app/node_modules/express/package.json":                    "npm/testdata/express-package.json",
+				"usr/src/app/node_modules/debug/package.json":                      "npm/testdata/debug-3.1.0-package.json",
+				"usr/src/app/node_modules/express/node_modules/debug/package.json": "npm/testdata/debug-2.6.9-package.json",
+				"usr/src/app/node_modules/@types/node/package.json":                "npm/testdata/types-node-package.json",
+			},
+			[]database.Feature{
+				{"my-app", "1.0.0", "", "", semver.ParserName},
+				{"server", "2.0.0-rc.1", "", "", semver.ParserName},
+				{"express", "4.16.3", "", "", semver.ParserName},
+				{"debug", "3.1.0", "", "", semver.ParserName},
+				{"debug", "2.6.9", "", "", semver.ParserName},
+				{"@types/node", "10.5.2", "", "", semver.ParserName},
+			},
+		},
+		{
+			"duplicated, malformed and ignored manifests",
+			map[string]string{
+				"usr/src/app/node_modules/debug/package.json":                      "npm/testdata/debug-2.6.9-package.json",
+				"usr/src/app/node_modules/express/node_modules/debug/package.json": "npm/testdata/debug-2.6.9-package.json",
+				"usr/src/app/node_modules/broken/package.json":                     "npm/testdata/malformed-package.json",
+				"usr/src/app/node_modules/invalid/package.json":                    "npm/testdata/invalid-package.json",
+				"usr/src/app/node_modules/debug/test/fixture/package.json":         "npm/testdata/fixture-package.json",
+				"usr/src/other/package.json":                                       "npm/testdata/app-package.json",
+			},
+			[]database.Feature{
+				{"debug", "2.6.9", "", "", semver.ParserName},
+			},
+		},
+	} {
+		featurefmt.RunTest(t, test, lister{}, semver.ParserName)
+	}
+}
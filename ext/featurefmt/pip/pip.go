@@ -0,0 +1,114 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pip implements a featurefmt.Lister for Python packages installed by
+// pip.
+package pip
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/deckarep/golang-set"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/pep440"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+// packageFilenames are the patterns of the metadata files of the installed
+// packages: the METADATA file of the wheels and the PKG-INFO file of the eggs,
+// which may also be the egg-info itself.
+var packageFilenames = []string{
+	"**/site-packages/*.dist-info/METADATA",
+	"**/dist-packages/*.dist-info/METADATA",
+	"**/site-packages/*.egg-info",
+	"**/dist-packages/*.egg-info",
+	"**/site-packages/*.egg-info/PKG-INFO",
+	"**/dist-packages/*.egg-info/PKG-INFO",
+}
+
+// separatorsRegexp matches the separators that are equivalent in the names
+// of the packages, as specified by PEP 503.
+var separatorsRegexp = regexp.MustCompile(`[-_.]+`)
+
+func init() {
+	featurefmt.RegisterLister("pip", "1.0", &lister{})
+}
+
+type lister struct{}
+
+func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error) {
+	packages := mapset.NewSet()
+	for filename, file := range files {
+		if !tarutil.MatchFilename(filename, packageFilenames) {
+			continue
+		}
+
+		pkg, ok := parseMetadata(file)
+		if !ok {
+			log.WithField("file", filename).Warning("could not parse Python package metadata. skipping")
+			continue
+		}
+
+		if err := versionfmt.Valid(pep440.ParserName, pkg.Version); err != nil {
+			log.WithError(err).WithField("version", pkg.Version).Warning("could not parse package version. skipping")
+			continue
+		}
+
+		packages.Add(pkg)
+	}
+
+	return database.ConvertFeatureSetToFeatures(packages), nil
+}
+
+func (l lister) RequiredFilenames() []string {
+	return packageFilenames
+}
+
+// parseMetadata parses the name and the version of a package from the headers
+// of its metadata file.
+func parseMetadata(file []byte) (database.Feature, bool) {
+	pkg := database.Feature{VersionFormat: pep440.ParserName}
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(file))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// The headers end at the first empty line, followed by the description.
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+
+		switch {
+		case strings.HasPrefix(line, "Name:"):
+			pkg.Name = normalizeName(strings.TrimPrefix(line, "Name:"))
+		case strings.HasPrefix(line, "Version:"):
+			pkg.Version = strings.TrimSpace(strings.TrimPrefix(line, "Version:"))
+		}
+	}
+
+	return pkg, pkg.Name != "" && pkg.Version != ""
+}
+
+// normalizeName returns the normalized form of the name of a package, which is
+// how the package indexes and the advisories refer to it.
+func normalizeName(name string) string {
+	return separatorsRegexp.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-")
+}
@@ -0,0 +1,57 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pip
+
+import (
+	"testing"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt"
+	"github.com/coreos/clair/ext/versionfmt/pep440"
+)
+
+func TestPipFeatureDetection(t *testing.T) {
+	for _, test := range []featurefmt.TestCase{
+		{
+			"valid case",
+			map[string]string{
+				"usr/lib/python3.6/site-packages/requests-2.18.4.dist-info/METADATA":      "pip/testdata/requests-METADATA",
+				"usr/lib/python3.6/site-packages/zope.interface-4.5.0.dist-info/METADATA": "pip/testdata/zope.interface-METADATA",
+				"usr/lib/python2.7/dist-packages/six-1.11.0.egg-info":                     "pip/testdata/six-PKG-INFO",
+				"opt/app/venv/lib/python3.6/site-packages/PyYAML-3.12.egg-info/PKG-INFO":  "pip/testdata/PyYAML-PKG-INFO",
+				"usr/lib/python3.6/site-packages/requests-2.18.4.dist-info/RECORD":        "pip/testdata/requests-METADATA",
+			},
+			[]database.Feature{
+				{"requests", "2.18.4", "", "", pep440.ParserName},
+				{"zope-interface", "4.5.0", "", "", pep440.ParserName},
+				{"six", "1.11.0", "", "", pep440.ParserName},
+				{"pyyaml", "3.12", "", "", pep440.ParserName},
+			},
+		},
+		{
+			"duplicated and invalid packages",
+			map[string]string{
+				"usr/lib/python3.6/site-packages/requests-2.18.4.dist-info/METADATA":          "pip/testdata/requests-METADATA",
+				"opt/app/venv/lib/python3.6/site-packages/requests-2.18.4.dist-info/METADATA": "pip/testdata/requests-METADATA",
+				"usr/lib/python3.6/site-packages/broken-1.0.egg-info/PKG-INFO":                "pip/testdata/invalid-PKG-INFO",
+			},
+			[]database.Feature{
+				{"requests", "2.18.4", "", "", pep440.ParserName},
+			},
+		},
+	} {
+		featurefmt.RunTest(t, test, lister{}, pep440.ParserName)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package portage implements a featurefmt.Lister for the packages installed by
+// Portage, the package manager of Gentoo.
+package portage
+
+import (
+	"strings"
+
+	"github.com/deckarep/golang-set"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/gentoo"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+// pfPattern matches the PF file of every installed package, which contains
+// the name and the version of the package, as the name of its directory
+// var/db/pkg/<category>/<name>-<version>.
+const pfPattern = "var/db/pkg/*/*/PF"
+
+func init() {
+	featurefmt.RegisterLister("portage", "1.0", &lister{})
+}
+
+type lister struct{}
+
+func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error) {
+	packages := mapset.NewSet()
+	for filename, file := range files {
+		if !tarutil.MatchFilename(filename, []string{pfPattern}) {
+			continue
+		}
+
+		// The pattern ensures that the path is var/db/pkg/<category>/<pf>/PF.
+		elements := strings.Split(filename, "/")
+		category := elements[3]
+
+		pf := strings.TrimSpace(string(file))
+		if pf == "" {
+			pf = elements[4]
+		}
+
+		name, version, ok := splitPF(pf)
+		if !ok {
+			log.WithField("package", category+"/"+pf).Warning("could not parse package version. skipping")
+			continue
+		}
+
+		packages.Add(database.Feature{
+			Name:          category + "/" + name,
+			Version:       version,
+			VersionFormat: gentoo.ParserName,
+		})
+	}
+
+	return database.ConvertFeatureSetToFeatures(packages), nil
+}
+
+func (l lister) RequiredFilenames() []string {
+	return []string{pfPattern}
+}
+
+// splitPF splits the PF of a package, e.g. openssl-1.0.2o-r3, into its name
+// and its version, which starts after the first hyphen followed by a valid
+// version.
+func splitPF(pf string) (string, string, bool) {
+	for i := strings.Index(pf, "-"); i > 0; {
+		if versionfmt.Valid(gentoo.ParserName, pf[i+1:]) == nil {
+			return pf[:i], pf[i+1:], true
+		}
+
+		next := strings.Index(pf[i+1:], "-")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,57 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package portage
+
+import (
+	"testing"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt"
+	"github.com/coreos/clair/ext/versionfmt/gentoo"
+)
+
+func TestPortageFeatureDetection(t *testing.T) {
+	for _, test := range []featurefmt.TestCase{
+		{
+			"valid case",
+			map[string]string{
+				"var/db/pkg/dev-libs/openssl-1.0.2o-r3/PF":          "portage/testdata/openssl-PF",
+				"var/db/pkg/media-fonts/font-adobe-100dpi-1.0.3/PF": "portage/testdata/font-adobe-100dpi-PF",
+				"var/db/pkg/sys-libs/glibc-2.26-r7/PF":              "portage/testdata/glibc-PF",
+				"var/db/pkg/dev-lang/python-3.6.5_p1/PF":            "portage/testdata/python-PF",
+				"var/db/pkg/dev-lang/python-3.6.5_p1/CONTENTS":      "portage/testdata/python-PF",
+				"usr/portage/dev-libs/openssl/openssl-1.1.0h-r1/PF": "portage/testdata/openssl-PF",
+			},
+			[]database.Feature{
+				{"dev-libs/openssl", "1.0.2o-r3", "", "", gentoo.ParserName},
+				{"media-fonts/font-adobe-100dpi", "1.0.3", "", "", gentoo.ParserName},
+				{"sys-libs/glibc", "2.26-r7", "", "", gentoo.ParserName},
+				{"dev-lang/python", "3.6.5_p1", "", "", gentoo.ParserName},
+			},
+		},
+		{
+			"invalid packages",
+			map[string]string{
+				"var/db/pkg/dev-libs/openssl-1.0.2o-r3/PF": "portage/testdata/openssl-PF",
+				"var/db/pkg/app-misc/mycustompkg/PF":       "portage/testdata/invalid-PF",
+			},
+			[]database.Feature{
+				{"dev-libs/openssl", "1.0.2o-r3", "", "", gentoo.ParserName},
+			},
+		},
+	} {
+		featurefmt.RunTest(t, test, lister{}, gentoo.ParserName)
+	}
+}
@@ -35,6 +35,14 @@ import (
 	"github.com/coreos/clair/pkg/tarutil"
 )
 
+const (
+	// bdbPath is the Berkeley DB database of rpm.
+	bdbPath = "var/lib/rpm/Packages"
+	// sqlitePath is the SQLite database used by rpm 4.16 and later, such as
+	// on Photon OS 4.0 or Fedora 33.
+	sqlitePath = "var/lib/rpm/rpmdb.sqlite"
+)
+
 var ignoredPackages = []string{
 	"gpg-pubkey", // Ignore gpg-pubkey packages which are fake packages used to store GPG keys - they are not versionned properly.
 }
@@ -62,12 +70,18 @@ func valid(pkg *database.Feature) bool {
 }
 
 func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error) {
-	f, hasFile := files["var/lib/rpm/Packages"]
+	// Find the database, and tell rpm which backend reads it.
+	dbFilename, args := "Packages", []string{}
+	f, hasFile := files[bdbPath]
 	if !hasFile {
-		return []database.Feature{}, nil
+		f, hasFile = files[sqlitePath]
+		if !hasFile {
+			return []database.Feature{}, nil
+		}
+		dbFilename, args = "rpmdb.sqlite", []string{"--define", "_db_backend sqlite"}
 	}
 
-	// Write the required "Packages" file to disk
+	// Write the required database file to disk
 	tmpDir, err := ioutil.TempDir(os.TempDir(), "rpm")
 	defer os.RemoveAll(tmpDir)
 	if err != nil {
@@ -75,14 +89,15 @@ func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error)
 		return []database.Feature{}, commonerr.ErrFilesystem
 	}
 
-	err = ioutil.WriteFile(tmpDir+"/Packages", f, 0700)
+	err = ioutil.WriteFile(tmpDir+"/"+dbFilename, f, 0700)
 	if err != nil {
 		log.WithError(err).Error("could not create temporary file for RPM detection")
 		return []database.Feature{}, commonerr.ErrFilesystem
 	}
 
 	// Extract binary package names because RHSA refers to binary package names.
-	out, err := exec.Command("rpm", "--dbpath", tmpDir, "-qa", "--qf", "%{NAME} %{EPOCH}:%{VERSION}-%{RELEASE} %{SOURCERPM}\n").CombinedOutput()
+	args = append(args, "--dbpath", tmpDir, "-qa", "--qf", "%{NAME} %{EPOCH}:%{VERSION}-%{RELEASE} %{SOURCERPM}\n")
+	out, err := exec.Command("rpm", args...).CombinedOutput()
 	if err != nil {
 		log.WithError(err).WithField("output", string(out)).Error("could not query RPM")
 		// Do not bubble up because we probably won't be able to fix it,
@@ -105,7 +120,8 @@ func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error)
 		}
 
 		pkg := database.Feature{Name: line[0], VersionFormat: rpm.ParserName}
-		pkg.Version = strings.Replace(line[1], "(none):", "", -1)
+		// The packages without epoch have the epoch 0.
+		pkg.Version = rpm.NormalizeVersion(strings.Replace(line[1], "(none):", "", -1))
 		if err := versionfmt.Valid(rpm.ParserName, pkg.Version); err != nil {
 			log.WithError(err).WithField("version", line[1]).Warning("skipped unparseable package")
 			continue
@@ -125,7 +141,7 @@ func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error)
 }
 
 func (l lister) RequiredFilenames() []string {
-	return []string{"var/lib/rpm/Packages"}
+	return []string{bdbPath, sqlitePath}
 }
 
 type rpmParserState string
@@ -140,6 +156,9 @@ const (
 
 // parseSourceRPM parses the source rpm package representation string
 // http://ftp.rpm.org/max-rpm/ch-rpm-file-format.html
+//
+// The source package has the epoch of the version of the package, which its
+// file name leaves out.
 func parseSourceRPM(sourceRPM string, pkg *database.Feature) error {
 	state := parseRPM
 	previousCheckPoint := len(sourceRPM)
@@ -202,6 +221,10 @@ func parseSourceRPM(sourceRPM string, pkg *database.Feature) error {
 		return fmt.Errorf("unexpected package name, expect: not empty")
 	}
 
+	if i := strings.Index(pkg.Version, ":"); i >= 0 {
+		concatVersion = pkg.Version[:i+1] + concatVersion
+	}
+
 	pkg.SourceName = name
 	pkg.SourceVersion = concatVersion
 	return nil
@@ -57,11 +57,11 @@ var expectedBigCaseInfo = []database.Feature{
 	{"keyutils-libs", "1.5.10-6.fc28", "keyutils", "1.5.10-6.fc28", rpm.ParserName},
 	{"glib2", "2.56.1-4.fc28", "glib2", "2.56.1-4.fc28", rpm.ParserName},
 	{"systemd-libs", "238-9.git0e0aa59.fc28", "systemd", "238-9.git0e0aa59.fc28", rpm.ParserName},
-	{"dbus-libs", "1:1.12.10-1.fc28", "dbus", "1.12.10-1.fc28", rpm.ParserName},
+	{"dbus-libs", "1:1.12.10-1.fc28", "dbus", "1:1.12.10-1.fc28", rpm.ParserName},
 	{"libtasn1", "4.13-2.fc28", "libtasn1", "4.13-2.fc28", rpm.ParserName},
 	{"ca-certificates", "2018.2.24-1.0.fc28", "ca-certificates", "2018.2.24-1.0.fc28", rpm.ParserName},
 	{"libarchive", "3.3.1-4.fc28", "libarchive", "3.3.1-4.fc28", rpm.ParserName},
-	{"openssl", "1:1.1.0h-3.fc28", "openssl", "1.1.0h-3.fc28", rpm.ParserName},
+	{"openssl", "1:1.1.0h-3.fc28", "openssl", "1:1.1.0h-3.fc28", rpm.ParserName},
 	{"libusbx", "1.0.22-1.fc28", "libusbx", "1.0.22-1.fc28", rpm.ParserName},
 	{"libsemanage", "2.8-2.fc28", "libsemanage", "2.8-2.fc28", rpm.ParserName},
 	{"libutempter", "1.1.6-14.fc28", "libutempter", "1.1.6-14.fc28", rpm.ParserName},
@@ -108,7 +108,7 @@ var expectedBigCaseInfo = []database.Feature{
 	{"deltarpm", "3.6-25.fc28", "deltarpm", "3.6-25.fc28", rpm.ParserName},
 	{"sssd-client", "1.16.3-2.fc28", "sssd", "1.16.3-2.fc28", rpm.ParserName},
 	{"cracklib-dicts", "2.9.6-13.fc28", "cracklib", "2.9.6-13.fc28", rpm.ParserName},
-	{"tar", "2:1.30-3.fc28", "tar", "1.30-3.fc28", rpm.ParserName},
+	{"tar", "2:1.30-3.fc28", "tar", "2:1.30-3.fc28", rpm.ParserName},
 	{"diffutils", "3.6-4.fc28", "diffutils", "3.6-4.fc28", rpm.ParserName},
 	{"langpacks-en", "1.0-12.fc28", "langpacks", "1.0-12.fc28", rpm.ParserName},
 	{"libgcc", "8.1.1-5.fc28", "gcc", "8.1.1-5.fc28", rpm.ParserName},
@@ -135,7 +135,7 @@ var expectedBigCaseInfo = []database.Feature{
 	{"libattr", "2.4.48-3.fc28", "attr", "2.4.48-3.fc28", rpm.ParserName},
 	{"coreutils-single", "8.29-7.fc28", "coreutils", "8.29-7.fc28", rpm.ParserName},
 	{"libblkid", "2.32.1-1.fc28", "util-linux", "2.32.1-1.fc28", rpm.ParserName},
-	{"gmp", "1:6.1.2-7.fc28", "gmp", "6.1.2-7.fc28", rpm.ParserName},
+	{"gmp", "1:6.1.2-7.fc28", "gmp", "1:6.1.2-7.fc28", rpm.ParserName},
 	{"libunistring", "0.9.10-1.fc28", "libunistring", "0.9.10-1.fc28", rpm.ParserName},
 	{"sqlite-libs", "3.22.0-4.fc28", "sqlite", "3.22.0-4.fc28", rpm.ParserName},
 	{"audit-libs", "2.8.4-2.fc28", "audit", "2.8.4-2.fc28", rpm.ParserName},
@@ -144,13 +144,13 @@ var expectedBigCaseInfo = []database.Feature{
 	{"pcre", "8.42-3.fc28", "pcre", "8.42-3.fc28", rpm.ParserName},
 	{"grep", "3.1-5.fc28", "grep", "3.1-5.fc28", rpm.ParserName},
 	{"crypto-policies", "20180425-5.git6ad4018.fc28", "crypto-policies", "20180425-5.git6ad4018.fc28", rpm.ParserName},
-	{"gdbm-libs", "1:1.14.1-4.fc28", "gdbm", "1.14.1-4.fc28", rpm.ParserName},
+	{"gdbm-libs", "1:1.14.1-4.fc28", "gdbm", "1:1.14.1-4.fc28", rpm.ParserName},
 	{"p11-kit-trust", "0.23.12-1.fc28", "p11-kit", "0.23.12-1.fc28", rpm.ParserName},
-	{"openssl-libs", "1:1.1.0h-3.fc28", "openssl", "1.1.0h-3.fc28", rpm.ParserName},
+	{"openssl-libs", "1:1.1.0h-3.fc28", "openssl", "1:1.1.0h-3.fc28", rpm.ParserName},
 	{"ima-evm-utils", "1.1-2.fc28", "ima-evm-utils", "1.1-2.fc28", rpm.ParserName},
-	{"gdbm", "1:1.14.1-4.fc28", "gdbm", "1.14.1-4.fc28", rpm.ParserName},
+	{"gdbm", "1:1.14.1-4.fc28", "gdbm", "1:1.14.1-4.fc28", rpm.ParserName},
 	{"gobject-introspection", "1.56.1-1.fc28", "gobject-introspection", "1.56.1-1.fc28", rpm.ParserName},
-	{"shadow-utils", "2:4.6-1.fc28", "shadow-utils", "4.6-1.fc28", rpm.ParserName},
+	{"shadow-utils", "2:4.6-1.fc28", "shadow-utils", "2:4.6-1.fc28", rpm.ParserName},
 	{"libpsl", "0.20.2-2.fc28", "libpsl", "0.20.2-2.fc28", rpm.ParserName},
 	{"nettle", "3.4-2.fc28", "nettle", "3.4-2.fc28", rpm.ParserName},
 	{"libfdisk", "2.32.1-1.fc28", "util-linux", "2.32.1-1.fc28", rpm.ParserName},
@@ -178,11 +178,11 @@ var expectedBigCaseInfo = []database.Feature{
 	{"libargon2", "20161029-5.fc28", "argon2", "20161029-5.fc28", rpm.ParserName},
 	{"libmodulemd", "1.6.2-2.fc28", "libmodulemd", "1.6.2-2.fc28", rpm.ParserName},
 	{"pkgconf", "1.4.2-1.fc28", "pkgconf", "1.4.2-1.fc28", rpm.ParserName},
-	{"libpcap", "14:1.9.0-1.fc28", "libpcap", "1.9.0-1.fc28", rpm.ParserName},
+	{"libpcap", "14:1.9.0-1.fc28", "libpcap", "14:1.9.0-1.fc28", rpm.ParserName},
 	{"device-mapper", "1.02.146-5.fc28", "lvm2", "2.02.177-5.fc28", rpm.ParserName},
 	{"cryptsetup-libs", "2.0.4-1.fc28", "cryptsetup", "2.0.4-1.fc28", rpm.ParserName},
 	{"elfutils-libs", "0.173-1.fc28", "elfutils", "0.173-1.fc28", rpm.ParserName},
-	{"dbus", "1:1.12.10-1.fc28", "dbus", "1.12.10-1.fc28", rpm.ParserName},
+	{"dbus", "1:1.12.10-1.fc28", "dbus", "1:1.12.10-1.fc28", rpm.ParserName},
 	{"libnghttp2", "1.32.1-1.fc28", "nghttp2", "1.32.1-1.fc28", rpm.ParserName},
 	{"librepo", "1.8.1-7.fc28", "librepo", "1.8.1-7.fc28", rpm.ParserName},
 	{"curl", "7.59.0-6.fc28", "curl", "7.59.0-6.fc28", rpm.ParserName},
@@ -195,7 +195,7 @@ var expectedBigCaseInfo = []database.Feature{
 	{"rpm-plugin-systemd-inhibit", "4.14.1-9.fc28", "rpm", "4.14.1-9.fc28", rpm.ParserName},
 	{"nss-tools", "3.38.0-1.0.fc28", "nss", "3.38.0-1.0.fc28", rpm.ParserName},
 	{"openssl-pkcs11", "0.4.8-1.fc28", "openssl-pkcs11", "0.4.8-1.fc28", rpm.ParserName},
-	{"vim-minimal", "2:8.1.328-1.fc28", "vim", "8.1.328-1.fc28", rpm.ParserName},
+	{"vim-minimal", "2:8.1.328-1.fc28", "vim", "2:8.1.328-1.fc28", rpm.ParserName},
 	{"glibc-langpack-en", "2.27-32.fc28", "glibc", "2.27-32.fc28", rpm.ParserName},
 	{"rootfiles", "8.1-22.fc28", "rootfiles", "8.1-22.fc28", rpm.ParserName},
 }
@@ -223,22 +223,26 @@ func TestRpmFeatureDetection(t *testing.T) {
 func TestParseSourceRPM(t *testing.T) {
 	for _, test := range [...]struct {
 		sourceRPM string
+		version   string
 
 		expectedName    string
 		expectedVersion string
 		expectedErr     string
 	}{
 		// valid cases
-		{"publicsuffix-list-20180514-1.fc28.src.rpm", "publicsuffix-list", "20180514-1.fc28", ""},
-		{"libreport-2.9.5-1.fc28.src.rpm", "libreport", "2.9.5-1.fc28", ""},
-		{"lua-5.3.4-10.fc28.src.rpm", "lua", "5.3.4-10.fc28", ""},
-		{"crypto-policies-20180425-5.git6ad4018.fc28.src.rpm", "crypto-policies", "20180425-5.git6ad4018.fc28", ""},
+		{"publicsuffix-list-20180514-1.fc28.src.rpm", "", "publicsuffix-list", "20180514-1.fc28", ""},
+		{"libreport-2.9.5-1.fc28.src.rpm", "", "libreport", "2.9.5-1.fc28", ""},
+		{"lua-5.3.4-10.fc28.src.rpm", "", "lua", "5.3.4-10.fc28", ""},
+		{"crypto-policies-20180425-5.git6ad4018.fc28.src.rpm", "", "crypto-policies", "20180425-5.git6ad4018.fc28", ""},
+		// the source package has the epoch of the package
+		{"dbus-1.12.10-1.fc28.src.rpm", "1:1.12.10-1.fc28", "dbus", "1:1.12.10-1.fc28", ""},
+		{"lua-5.3.4-10.fc28.src.rpm", "5.3.4-10.fc28", "lua", "5.3.4-10.fc28", ""},
 
 		// invalid cases
-		{"crypto-policies-20180425-5.git6ad4018.fc28.src.dpkg", "", "", "unexpected package type, expect: 'rpm', got: 'dpkg'"},
-		{"crypto-policies-20180425-5.git6ad4018.fc28.debian-8.rpm", "", "", "unexpected package architecture, expect: 'src' or 'nosrc', got: 'debian-8'"},
-		{"fc28.src.rpm", "", "", "unexpected termination while parsing 'Release Token'"},
-		{"...", "", "", "unexpected package type, expect: 'rpm', got: ''"},
+		{"crypto-policies-20180425-5.git6ad4018.fc28.src.dpkg", "", "", "", "unexpected package type, expect: 'rpm', got: 'dpkg'"},
+		{"crypto-policies-20180425-5.git6ad4018.fc28.debian-8.rpm", "", "", "", "unexpected package architecture, expect: 'src' or 'nosrc', got: 'debian-8'"},
+		{"fc28.src.rpm", "", "", "", "unexpected termination while parsing 'Release Token'"},
+		{"...", "", "", "", "unexpected package type, expect: 'rpm', got: ''"},
 
 		// impossible case
 		// This illustrates the limitation of this parser, it will not find the
@@ -246,9 +250,9 @@ func TestParseSourceRPM(t *testing.T) {
 		// on the documentation, this case should never happen and indicates a
 		// corrupted rpm database.
 		// actual expected: name="lua", version="5.3.4", release="10.fc-28"
-		{"lua-5.3.4-10.fc-28.src.rpm", "lua-5.3.4", "10.fc-28", ""},
+		{"lua-5.3.4-10.fc-28.src.rpm", "", "lua-5.3.4", "10.fc-28", ""},
 	} {
-		pkg := database.Feature{}
+		pkg := database.Feature{Version: test.version}
 		err := parseSourceRPM(test.sourceRPM, &pkg)
 		if test.expectedErr != "" {
 			require.EqualError(t, err, test.expectedErr)
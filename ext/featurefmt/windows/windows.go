@@ -0,0 +1,108 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package windows implements a featurefmt.Lister for the components installed
+// on Windows container image layers.
+//
+// The components are the servicing packages of the layer, e.g. the cumulative
+// updates, whose manifests (.mum files) name and version the package. Only
+// the filesystem of the container, under the Files directory of the layer, is
+// read: the utility VM of the base layers is ignored.
+package windows
+
+import (
+	"bytes"
+	"encoding/xml"
+
+	"github.com/deckarep/golang-set"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/windows"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const manifestPattern = "Files/Windows/servicing/Packages/*.mum"
+
+// utf8BOM is the byte order mark that the manifests usually start with, and
+// which encoding/xml does not skip.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// manifest is the part of a servicing package manifest identifying the
+// package.
+type manifest struct {
+	Identity struct {
+		Name                  string `xml:"name,attr"`
+		Version               string `xml:"version,attr"`
+		Language              string `xml:"language,attr"`
+		ProcessorArchitecture string `xml:"processorArchitecture,attr"`
+	} `xml:"assemblyIdentity"`
+}
+
+func init() {
+	featurefmt.RegisterLister("windows", "1.0", &lister{})
+}
+
+type lister struct{}
+
+func (l lister) ListFeatures(files tarutil.FilesMap) ([]database.Feature, error) {
+	// The manifests of the superseded versions of a package stay in the
+	// layer, so only the latest version of each package is installed.
+	latest := make(map[manifest]database.Feature)
+	for filename, file := range files {
+		if !tarutil.MatchFilename(filename, []string{manifestPattern}) {
+			continue
+		}
+
+		var m manifest
+		if err := xml.Unmarshal(bytes.TrimPrefix(file, utf8BOM), &m); err != nil {
+			log.WithError(err).WithField("manifest", filename).Warning("could not parse servicing package manifest. skipping")
+			continue
+		}
+
+		if m.Identity.Name == "" || versionfmt.Valid(windows.ParserName, m.Identity.Version) != nil {
+			log.WithField("manifest", filename).Warning("could not parse servicing package identity. skipping")
+			continue
+		}
+
+		version := m.Identity.Version
+		m.Identity.Version = ""
+		if f, ok := latest[m]; ok {
+			if cmp, err := versionfmt.Compare(windows.ParserName, f.Version, version); err != nil || cmp >= 0 {
+				continue
+			}
+		}
+
+		latest[m] = database.Feature{
+			Name:          m.Identity.Name,
+			Version:       version,
+			VersionFormat: windows.ParserName,
+		}
+	}
+
+	// The packages of several languages or architectures may have the same
+	// name and version.
+	packages := mapset.NewSet()
+	for _, f := range latest {
+		packages.Add(f)
+	}
+
+	return database.ConvertFeatureSetToFeatures(packages), nil
+}
+
+func (l lister) RequiredFilenames() []string {
+	return []string{manifestPattern}
+}
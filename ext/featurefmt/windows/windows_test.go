@@ -0,0 +1,57 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"testing"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt"
+	"github.com/coreos/clair/ext/versionfmt/windows"
+)
+
+const packagesPath = "Files/Windows/servicing/Packages/"
+
+func TestWindowsFeatureDetection(t *testing.T) {
+	for _, test := range []featurefmt.TestCase{
+		{
+			"valid case",
+			map[string]string{
+				packagesPath + "Package_for_RollupFix~31bf3856ad364e35~amd64~~17763.1457.1.5.mum":                   "windows/testdata/rollup.mum",
+				packagesPath + "Package_for_RollupFix~31bf3856ad364e35~amd64~~17763.1369.1.8.mum":                   "windows/testdata/rollup-superseded.mum",
+				packagesPath + "Microsoft-Windows-ServerCore-Package~31bf3856ad364e35~amd64~~10.0.17763.1.mum":      "windows/testdata/servercore.mum",
+				packagesPath + "Microsoft-Windows-ServerCore-Package~31bf3856ad364e35~amd64~en-US~10.0.17763.1.mum": "windows/testdata/servercore-en-us.mum",
+				packagesPath + "Package_for_RollupFix~31bf3856ad364e35~amd64~~17763.1457.1.5.cat":                   "windows/testdata/rollup.mum",
+			},
+			[]database.Feature{
+				{"Package_for_RollupFix", "17763.1457.1.5", "", "", windows.ParserName},
+				{"Microsoft-Windows-ServerCore-Package", "10.0.17763.1", "", "", windows.ParserName},
+			},
+		},
+		{
+			"utility VM and invalid manifests",
+			map[string]string{
+				packagesPath + "Microsoft-Windows-ServerCore-Package~31bf3856ad364e35~amd64~~10.0.17763.1.mum":   "windows/testdata/servercore.mum",
+				packagesPath + "Broken~31bf3856ad364e35~amd64~~latest.mum":                                       "windows/testdata/invalid.mum",
+				"UtilityVM/" + packagesPath + "Package_for_RollupFix~31bf3856ad364e35~amd64~~17763.1457.1.5.mum": "windows/testdata/rollup.mum",
+			},
+			[]database.Feature{
+				{"Microsoft-Windows-ServerCore-Package", "10.0.17763.1", "", "", windows.ParserName},
+			},
+		},
+	} {
+		featurefmt.RunTest(t, test, lister{}, windows.ParserName)
+	}
+}
@@ -90,3 +90,10 @@ func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
 func (d detector) RequiredFilenames() []string {
 	return []string{"etc/apt/sources.list"}
 }
+
+// Confidence implements featurens.Confident: the repositories of a layer may
+// be the ones of another release than the installed one, e.g. during an
+// upgrade.
+func (d detector) Confidence() int {
+	return featurens.LowConfidence
+}
@@ -17,6 +17,7 @@
 package featurens
 
 import (
+	"sort"
 	"sync"
 	"testing"
 
@@ -42,10 +43,32 @@ type Detector interface {
 	// RequiredFilenames returns the list of files required to be in the FilesMap
 	// provided to the Detect method.
 	//
-	// Filenames must not begin with "/".
+	// Filenames must not begin with "/". They are matched against the files of
+	// the layer by tarutil.MatchFilename.
 	RequiredFilenames() []string
 }
 
+// The confidences of the namespaces of the Detectors, which order the
+// candidate namespaces of the features of a layer.
+const (
+	// LowConfidence is the confidence of the namespaces inferred indirectly,
+	// e.g. from the package repositories of a layer or from a legacy file.
+	LowConfidence = 1
+
+	// DefaultConfidence is the confidence of the Detectors that do not
+	// implement Confident, such as the ones reading a release file.
+	DefaultConfidence = 2
+)
+
+// Confident is implemented by the Detectors whose namespaces are more or less
+// reliable than the ones of the other Detectors.
+type Confident interface {
+	// Confidence returns the confidence of the detected namespaces: when the
+	// detectors disagree on the namespace of a layer, the namespaces with the
+	// highest confidence are preferred.
+	Confidence() int
+}
+
 type detector struct {
 	Detector
 
@@ -105,9 +128,57 @@ func Detect(files tarutil.FilesMap, toUse []database.Detector) ([]database.Layer
 		}
 	}
 
+	sortNamespaces(namespaces)
 	return namespaces, nil
 }
 
+// confidence returns the confidence of the namespaces of the given detector,
+// which is DefaultConfidence if it is not a registered namespace detector.
+func confidence(d database.Detector) int {
+	if detector, ok := detectors[d.Name]; ok && d.DType == database.NamespaceDetectorType {
+		if c, ok := detector.Detector.(Confident); ok {
+			return c.Confidence()
+		}
+	}
+	return DefaultConfidence
+}
+
+// MoreLikely reports whether the namespace a is more likely to be the one of a
+// layer than the namespace b, as their detector has a higher confidence, or
+// else as it sorts first by name, so that the order is stable.
+func MoreLikely(a, b database.LayerNamespace) bool {
+	detectorsM.RLock()
+	defer detectorsM.RUnlock()
+
+	return moreLikely(a, b)
+}
+
+func moreLikely(a, b database.LayerNamespace) bool {
+	if ca, cb := confidence(a.By), confidence(b.By); ca != cb {
+		return ca > cb
+	}
+
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.By.Name < b.By.Name
+}
+
+// SortNamespaces sorts the namespaces of a layer from the most likely to the
+// least likely, as by MoreLikely.
+func SortNamespaces(namespaces []database.LayerNamespace) {
+	detectorsM.RLock()
+	defer detectorsM.RUnlock()
+
+	sortNamespaces(namespaces)
+}
+
+func sortNamespaces(namespaces []database.LayerNamespace) {
+	sort.SliceStable(namespaces, func(i, j int) bool {
+		return moreLikely(namespaces[i], namespaces[j])
+	})
+}
+
 // RequiredFilenames returns all files required by the give extensions. Any
 // extension metadata that has non namespace-detector type will be skipped.
 func RequiredFilenames(toUse []database.Detector) (files []string) {
@@ -52,3 +52,24 @@ func TestNamespaceDetector(t *testing.T) {
 		database.AssertLayerNamespacesEqual(t, test.out, out)
 	}
 }
+
+func TestSortNamespaces(t *testing.T) {
+	lsbRelease := database.NewNamespaceDetector("lsb-release", "1.0")
+	osRelease := database.NewNamespaceDetector("os-release", "1.0")
+	alpineRelease := database.NewNamespaceDetector("alpine-release", "1.0")
+
+	namespaces := []database.LayerNamespace{
+		{Namespace: database.Namespace{Name: "ubuntu:20.04", VersionFormat: "dpkg"}, By: lsbRelease},
+		{Namespace: database.Namespace{Name: "debian:8", VersionFormat: "dpkg"}, By: osRelease},
+		{Namespace: database.Namespace{Name: "alpine:v3.3", VersionFormat: "dpkg"}, By: alpineRelease},
+	}
+
+	// The namespaces of the detectors with a low confidence come last, and the
+	// other ones are sorted by name.
+	featurens.SortNamespaces(namespaces)
+	assert.Equal(t, "alpine:v3.3", namespaces[0].Name)
+	assert.Equal(t, "debian:8", namespaces[1].Name)
+	assert.Equal(t, "ubuntu:20.04", namespaces[2].Name)
+	assert.True(t, featurens.MoreLikely(namespaces[1], namespaces[2]))
+	assert.False(t, featurens.MoreLikely(namespaces[2], namespaces[1]))
+}
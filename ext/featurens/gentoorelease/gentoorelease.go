@@ -0,0 +1,57 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gentoorelease implements a featurens.Detector for Gentoo based
+// container image layers.
+package gentoorelease
+
+import (
+	"regexp"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/ext/versionfmt/gentoo"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const (
+	// Gentoo is a rolling distribution: the release in gentoo-release is the
+	// version of its base system, which does not identify a set of packages.
+	namespaceName     = "gentoo:rolling"
+	gentooReleasePath = "etc/gentoo-release"
+)
+
+var gentooReleaseRegexp = regexp.MustCompile(`^Gentoo Base System release `)
+
+func init() {
+	featurens.RegisterDetector("gentoo-release", "1.0", &detector{})
+}
+
+type detector struct{}
+
+func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
+	f, hasFile := files[gentooReleasePath]
+	if !hasFile || !gentooReleaseRegexp.Match(f) {
+		return nil, nil
+	}
+
+	return &database.Namespace{
+		Name:          namespaceName,
+		VersionFormat: gentoo.ParserName,
+	}, nil
+}
+
+func (d detector) RequiredFilenames() []string {
+	return []string{gentooReleasePath}
+}
@@ -0,0 +1,60 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gobinary implements a featurens.Detector for container image layers
+// containing Go binaries.
+//
+// The Go modules do not depend on the distribution of the layer, so they all
+// belong to the same namespace.
+package gobinary
+
+import (
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurefmt/gobinary"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/ext/versionfmt/gomod"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const namespaceName = "go"
+
+func init() {
+	featurens.RegisterDetector("gobinary", "1.0", &detector{})
+}
+
+type detector struct{}
+
+func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
+	for filename, file := range files {
+		if !tarutil.MatchFilename(filename, gobinary.BinaryFilenames) {
+			continue
+		}
+
+		if _, ok := gobinary.ReadBuildInfo(file); ok {
+			return &database.Namespace{
+				Name:          namespaceName,
+				VersionFormat: gomod.ParserName,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// RequiredFilenames requires no file: the binaries are only extracted for the
+// gobinary lister, which is opt-in, so that they are not extracted when it is
+// not enabled.
+func (d detector) RequiredFilenames() []string {
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gobinary
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+func TestDetector(t *testing.T) {
+	// The test binary is a Go binary.
+	executable, err := os.Executable()
+	require.Nil(t, err)
+	binary, err := ioutil.ReadFile(executable)
+	require.Nil(t, err)
+
+	testData := []featurens.TestData{
+		{
+			ExpectedNamespace: &database.Namespace{Name: "go"},
+			Files:             tarutil.FilesMap{"usr/local/bin/app": binary},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "go"},
+			Files:             tarutil.FilesMap{"manager": binary},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files:             tarutil.FilesMap{"usr/share/doc/app": binary},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files:             tarutil.FilesMap{"usr/bin/script": []byte("#!/bin/sh\n")},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files:             tarutil.FilesMap{},
+		},
+	}
+
+	featurens.TestDetector(t, &detector{}, testData)
+}
@@ -96,3 +96,9 @@ func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
 func (d *detector) RequiredFilenames() []string {
 	return []string{"etc/lsb-release"}
 }
+
+// Confidence implements featurens.Confident: the lsb-release file is a legacy
+// one, which is left behind by some upgrades.
+func (d *detector) Confidence() int {
+	return featurens.LowConfidence
+}
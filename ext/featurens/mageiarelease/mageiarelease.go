@@ -0,0 +1,59 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mageiarelease implements a featurens.Detector for Mageia based
+// container image layers.
+package mageiarelease
+
+import (
+	"regexp"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const mageiaReleasePath = "etc/mageia-release"
+
+// mageiaReleaseRegexp matches the first line of mageia-release, such as
+// "Mageia release 8 (Official) for x86_64".
+var mageiaReleaseRegexp = regexp.MustCompile(`^Mageia release (\d+)`)
+
+func init() {
+	featurens.RegisterDetector("mageia-release", "1.0", &detector{})
+}
+
+type detector struct{}
+
+func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
+	f, hasFile := files[mageiaReleasePath]
+	if !hasFile {
+		return nil, nil
+	}
+
+	r := mageiaReleaseRegexp.FindSubmatch(f)
+	if len(r) != 2 {
+		return nil, nil
+	}
+
+	return &database.Namespace{
+		Name:          "mageia:" + string(r[1]),
+		VersionFormat: rpm.ParserName,
+	}, nil
+}
+
+func (d detector) RequiredFilenames() []string {
+	return []string{mageiaReleasePath}
+}
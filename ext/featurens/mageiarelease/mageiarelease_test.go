@@ -0,0 +1,52 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mageiarelease
+
+import (
+	"testing"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+func TestDetector(t *testing.T) {
+	testData := []featurens.TestData{
+		{
+			ExpectedNamespace: &database.Namespace{Name: "mageia:8"},
+			Files: tarutil.FilesMap{
+				"etc/mageia-release": []byte("Mageia release 8 (Official) for x86_64\n"),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "mageia:9"},
+			Files: tarutil.FilesMap{
+				"etc/mageia-release": []byte("Mageia release 9 (Official) for aarch64\n"),
+			},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files: tarutil.FilesMap{
+				"etc/mageia-release": []byte("Mandriva Linux release 2011.0 (Official) for x86_64\n"),
+			},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files:             tarutil.FilesMap{},
+		},
+	}
+
+	featurens.TestDetector(t, &detector{}, testData)
+}
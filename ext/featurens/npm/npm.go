@@ -0,0 +1,59 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package npm implements a featurens.Detector for container image layers
+// containing npm packages.
+//
+// The npm packages do not depend on the distribution of the layer, so they all
+// belong to the same namespace.
+package npm
+
+import (
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/ext/versionfmt/semver"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const namespaceName = "npm"
+
+// packageFilenames are the patterns of the manifests of the installed npm
+// packages, including the scoped ones.
+var packageFilenames = []string{
+	"**/node_modules/*/package.json",
+	"**/node_modules/@*/*/package.json",
+}
+
+func init() {
+	featurens.RegisterDetector("npm", "1.0", &detector{})
+}
+
+type detector struct{}
+
+func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
+	for filename := range files {
+		if tarutil.MatchFilename(filename, packageFilenames) {
+			return &database.Namespace{
+				Name:          namespaceName,
+				VersionFormat: semver.ParserName,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (d detector) RequiredFilenames() []string {
+	return packageFilenames
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package npm
+
+import (
+	"testing"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+func TestDetector(t *testing.T) {
+	testData := []featurens.TestData{
+		{
+			ExpectedNamespace: &database.Namespace{Name: "npm"},
+			Files: tarutil.FilesMap{
+				"usr/src/app/node_modules/express/package.json": []byte(`{"name": "express"}`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "npm"},
+			Files: tarutil.FilesMap{
+				"usr/lib/node_modules/@angular/core/package.json": []byte(`{"name": "@angular/core"}`),
+			},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files: tarutil.FilesMap{
+				"usr/src/app/package.json": []byte(`{"name": "app"}`),
+			},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files:             tarutil.FilesMap{},
+		},
+	}
+
+	featurens.TestDetector(t, &detector{}, testData)
+}
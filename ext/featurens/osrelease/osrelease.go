@@ -15,7 +15,14 @@
 // Package osrelease implements a featurens.Detector for container image
 // layers containing an os-release file.
 //
-// This detector is typically useful for detecting Debian or Ubuntu.
+// This detector is typically useful for detecting Debian, Ubuntu, Wolfi,
+// Gentoo, SLES, Photon OS, Mageia, CBL-Mariner or Azure Linux.
+//
+// Rocky Linux and AlmaLinux are detected as the CentOS release of the same
+// major version, like the redhatrelease detector does.
+//
+// The Debian and Ubuntu releases whose VERSION_ID is missing or unknown, as in
+// some minimal images, are detected from their VERSION_CODENAME.
 package osrelease
 
 import (
@@ -26,13 +33,25 @@ import (
 	"github.com/coreos/clair/database"
 	"github.com/coreos/clair/ext/featurens"
 	"github.com/coreos/clair/ext/versionfmt/dpkg"
+	"github.com/coreos/clair/ext/versionfmt/gentoo"
 	"github.com/coreos/clair/ext/versionfmt/rpm"
 	"github.com/coreos/clair/pkg/tarutil"
 )
 
+// rollingVersion is the version of the namespaces of rolling distributions.
+const rollingVersion = "rolling"
+
 var (
-	osReleaseOSRegexp      = regexp.MustCompile(`^ID=(.*)`)
-	osReleaseVersionRegexp = regexp.MustCompile(`^VERSION_ID=(.*)`)
+	osReleaseOSRegexp       = regexp.MustCompile(`^ID=(.*)`)
+	osReleaseVersionRegexp  = regexp.MustCompile(`^VERSION_ID=(.*)`)
+	osReleaseCodenameRegexp = regexp.MustCompile(`^VERSION_CODENAME=(.*)`)
+
+	// codenames are the versions of the releases of the operating systems
+	// that can be detected from their VERSION_CODENAME, by code name.
+	codenames = map[string]map[string]string{
+		"debian": database.DebianReleasesMapping,
+		"ubuntu": database.UbuntuReleasesMapping,
+	}
 
 	// blacklistFilenames are files that should exclude this detector.
 	blacklistFilenames = []string{
@@ -49,7 +68,7 @@ func init() {
 }
 
 func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
-	var OS, version string
+	var OS, version, codename string
 
 	for _, filePath := range blacklistFilenames {
 		if _, hasFile := files[filePath]; hasFile {
@@ -76,6 +95,17 @@ func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
 			if len(r) == 2 {
 				version = strings.Replace(strings.ToLower(r[1]), "\"", "", -1)
 			}
+
+			r = osReleaseCodenameRegexp.FindStringSubmatch(line)
+			if len(r) == 2 {
+				codename = strings.Replace(strings.ToLower(r[1]), "\"", "", -1)
+			}
+		}
+	}
+
+	if releases, ok := codenames[OS]; ok && !isKnownVersion(releases, version) {
+		if v, ok := releases[codename]; ok {
+			version = v
 		}
 	}
 
@@ -84,7 +114,29 @@ func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
 	switch OS {
 	case "debian", "ubuntu":
 		versionFormat = dpkg.ParserName
-	case "centos", "rhel", "fedora", "amzn", "ol", "oracle":
+	case "wolfi", "chainguard":
+		// Wolfi and Chainguard are rolling distributions whose VERSION_ID is
+		// only the build date of the image. Their apk versions are compared
+		// like Alpine's.
+		version = rollingVersion
+		versionFormat = dpkg.ParserName
+	case "gentoo":
+		// Gentoo is a rolling distribution, whose os-release may not even have
+		// a VERSION_ID.
+		version = rollingVersion
+		versionFormat = gentoo.ParserName
+	case "centos", "rhel", "fedora", "amzn", "ol", "oracle", "photon", "mageia", "mariner", "azurelinux":
+		versionFormat = rpm.ParserName
+	case "rocky", "almalinux":
+		// The Red Hat vulnerabilities of the RHEL rebuilds are in the CentOS
+		// namespaces, whose versions are major versions.
+		OS = "centos"
+		version = strings.Split(version, ".")[0]
+		versionFormat = rpm.ParserName
+	case "sles":
+		// The service packs of a SLES release share its namespace, as its
+		// vulnerability sources do not tell them apart.
+		version = strings.Split(version, ".")[0]
 		versionFormat = rpm.ParserName
 	default:
 		return nil, nil
@@ -99,6 +151,17 @@ func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
 	return nil, nil
 }
 
+// isKnownVersion returns whether the version is the one of a release with a
+// code name.
+func isKnownVersion(releases map[string]string, version string) bool {
+	for _, v := range releases {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
 func (d detector) RequiredFilenames() []string {
 	return []string{"etc/os-release", "usr/lib/os-release"}
 }
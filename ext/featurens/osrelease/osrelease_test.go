@@ -72,6 +72,170 @@ REDHAT_SUPPORT_PRODUCT="Fedora"
 REDHAT_SUPPORT_PRODUCT_VERSION=20`),
 			},
 		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "wolfi:rolling"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`ID=wolfi
+NAME="Wolfi"
+PRETTY_NAME="Wolfi"
+VERSION_ID="20230201"
+HOME_URL="https://wolfi.dev"`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "gentoo:rolling"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`NAME=Gentoo
+ID=gentoo
+PRETTY_NAME="Gentoo/Linux"
+ANSI_COLOR="1;32"
+HOME_URL="https://www.gentoo.org/"`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "chainguard:rolling"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`ID=chainguard
+NAME="Chainguard"
+PRETTY_NAME="Chainguard"
+VERSION_ID="20230214"
+HOME_URL="https://chainguard.dev/"`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "sles:15"},
+			Files: tarutil.FilesMap{
+				"usr/lib/os-release": []byte(
+					`NAME="SLES"
+VERSION="15-SP1"
+VERSION_ID="15.1"
+PRETTY_NAME="SUSE Linux Enterprise Server 15 SP1"
+ID="sles"
+ID_LIKE="suse"
+CPE_NAME="cpe:/o:suse:sles:15:sp1"`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "photon:3.0"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`NAME="VMware Photon OS"
+VERSION="3.0"
+ID=photon
+VERSION_ID=3.0
+PRETTY_NAME="VMware Photon OS/Linux"`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "mageia:8"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`NAME="Mageia"
+VERSION="8"
+ID=mageia
+VERSION_ID=8
+ID_LIKE="mandriva fedora"
+PRETTY_NAME="Mageia 8"`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "mariner:2.0"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`NAME="Common Base Linux Mariner"
+VERSION="2.0.20230126"
+ID=mariner
+VERSION_ID="2.0"
+PRETTY_NAME="CBL-Mariner/Linux"
+ANSI_COLOR="1;34"
+HOME_URL="https://aka.ms/cbl-mariner"`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "azurelinux:3.0"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`NAME="Microsoft Azure Linux"
+VERSION="3.0.20240727"
+ID=azurelinux
+VERSION_ID="3.0"
+PRETTY_NAME="Microsoft Azure Linux 3.0"
+ANSI_COLOR="1;34"
+HOME_URL="https://aka.ms/azurelinux"`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "centos:9"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`NAME="Rocky Linux"
+VERSION="9.2 (Blue Onyx)"
+ID="rocky"
+ID_LIKE="rhel centos fedora"
+VERSION_ID="9.2"
+PLATFORM_ID="platform:el9"`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "centos:8"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`NAME="AlmaLinux"
+VERSION="8.8 (Sapphire Caracal)"
+ID="almalinux"
+ID_LIKE="rhel centos fedora"
+VERSION_ID="8.8"`),
+			},
+		},
+		{ // Minimal images may not have a VERSION_ID.
+			ExpectedNamespace: &database.Namespace{Name: "debian:12"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`PRETTY_NAME="Debian GNU/Linux bookworm"
+NAME="Debian GNU/Linux"
+VERSION_CODENAME=bookworm
+ID=debian`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "ubuntu:22.04"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`NAME="Ubuntu"
+ID=ubuntu
+VERSION_ID="22.04.3"
+VERSION_CODENAME=jammy`),
+			},
+		},
+		{ // An explicit VERSION_ID is kept.
+			ExpectedNamespace: &database.Namespace{Name: "debian:11"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`ID=debian
+VERSION_ID="11"
+VERSION_CODENAME=bookworm`),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "ubuntu:26.04"},
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`ID=ubuntu
+VERSION_ID="26.04"
+VERSION_CODENAME=unknown`),
+			},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte(
+					`ID=debian
+VERSION_CODENAME=unknown`),
+			},
+		},
 		{
 			ExpectedNamespace: nil,
 			Files:             tarutil.FilesMap{},
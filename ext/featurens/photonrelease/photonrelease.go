@@ -0,0 +1,59 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package photonrelease implements a featurens.Detector for VMware Photon OS
+// based container image layers.
+package photonrelease
+
+import (
+	"regexp"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const photonReleasePath = "etc/photon-release"
+
+// photonReleaseRegexp matches the first line of photon-release, such as
+// "VMware Photon OS 3.0" or "VMware Photon Linux 1.0".
+var photonReleaseRegexp = regexp.MustCompile(`^VMware Photon (?:OS|Linux) (\d+\.\d+)`)
+
+func init() {
+	featurens.RegisterDetector("photon-release", "1.0", &detector{})
+}
+
+type detector struct{}
+
+func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
+	f, hasFile := files[photonReleasePath]
+	if !hasFile {
+		return nil, nil
+	}
+
+	r := photonReleaseRegexp.FindSubmatch(f)
+	if len(r) != 2 {
+		return nil, nil
+	}
+
+	return &database.Namespace{
+		Name:          "photon:" + string(r[1]),
+		VersionFormat: rpm.ParserName,
+	}, nil
+}
+
+func (d detector) RequiredFilenames() []string {
+	return []string{photonReleasePath}
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package photonrelease
+
+import (
+	"testing"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+func TestDetector(t *testing.T) {
+	testData := []featurens.TestData{
+		{
+			ExpectedNamespace: &database.Namespace{Name: "photon:3.0"},
+			Files: tarutil.FilesMap{
+				"etc/photon-release": []byte("VMware Photon OS 3.0\nPHOTON_BUILD_NUMBER=a383732\n"),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "photon:4.0"},
+			Files: tarutil.FilesMap{
+				"etc/photon-release": []byte("VMware Photon OS 4.0\nPHOTON_BUILD_NUMBER=1526e30ba\n"),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "photon:1.0"},
+			Files: tarutil.FilesMap{
+				"etc/photon-release": []byte("VMware Photon Linux 1.0\nPHOTON_BUILD_NUMBER=62c543d\n"),
+			},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files: tarutil.FilesMap{
+				"etc/photon-release": []byte("PHOTON_BUILD_NUMBER=a383732\n"),
+			},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files:             tarutil.FilesMap{},
+		},
+	}
+
+	featurens.TestDetector(t, &detector{}, testData)
+}
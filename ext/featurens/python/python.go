@@ -0,0 +1,63 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package python implements a featurens.Detector for container image layers
+// containing Python packages installed by pip.
+//
+// The Python packages do not depend on the distribution of the layer, so they
+// all belong to the same namespace.
+package python
+
+import (
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/ext/versionfmt/pep440"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const namespaceName = "python"
+
+// packageFilenames are the patterns of the metadata files of the installed
+// Python packages.
+var packageFilenames = []string{
+	"**/site-packages/*.dist-info/METADATA",
+	"**/dist-packages/*.dist-info/METADATA",
+	"**/site-packages/*.egg-info",
+	"**/dist-packages/*.egg-info",
+	"**/site-packages/*.egg-info/PKG-INFO",
+	"**/dist-packages/*.egg-info/PKG-INFO",
+}
+
+func init() {
+	featurens.RegisterDetector("python", "1.0", &detector{})
+}
+
+type detector struct{}
+
+func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
+	for filename := range files {
+		if tarutil.MatchFilename(filename, packageFilenames) {
+			return &database.Namespace{
+				Name:          namespaceName,
+				VersionFormat: pep440.ParserName,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (d detector) RequiredFilenames() []string {
+	return packageFilenames
+}
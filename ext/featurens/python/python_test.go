@@ -0,0 +1,52 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import (
+	"testing"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+func TestDetector(t *testing.T) {
+	testData := []featurens.TestData{
+		{
+			ExpectedNamespace: &database.Namespace{Name: "python"},
+			Files: tarutil.FilesMap{
+				"usr/lib/python3.6/site-packages/requests-2.18.4.dist-info/METADATA": []byte("Name: requests"),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "python"},
+			Files: tarutil.FilesMap{
+				"usr/lib/python2.7/dist-packages/six-1.10.0.egg-info": []byte("Name: six"),
+			},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte("ID=debian"),
+			},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files:             tarutil.FilesMap{},
+		},
+	}
+
+	featurens.TestDetector(t, &detector{}, testData)
+}
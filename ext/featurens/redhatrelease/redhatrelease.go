@@ -16,7 +16,9 @@
 // layers containing an redhat-release-like files.
 //
 // This detector is typically useful for detecting CentOS and Red-Hat like
-// systems.
+// systems. Rocky Linux and AlmaLinux, which are rebuilds of RHEL, are detected
+// as the CentOS release of the same major version, whose namespace has the Red
+// Hat vulnerabilities.
 package redhatrelease
 
 import (
@@ -30,9 +32,10 @@ import (
 )
 
 var (
-	oracleReleaseRegexp = regexp.MustCompile(`(?P<os>Oracle) (Linux Server release) (?P<version>[\d]+)`)
-	centosReleaseRegexp = regexp.MustCompile(`(?P<os>[^\s]*) (Linux release|release) (?P<version>[\d]+)`)
-	redhatReleaseRegexp = regexp.MustCompile(`(?P<os>Red Hat Enterprise Linux) (Client release|Server release|Workstation release) (?P<version>[\d]+)`)
+	oracleReleaseRegexp  = regexp.MustCompile(`(?P<os>Oracle) (Linux Server release) (?P<version>[\d]+)`)
+	centosReleaseRegexp  = regexp.MustCompile(`(?P<os>[^\s]*) (Linux release|release) (?P<version>[\d]+)`)
+	redhatReleaseRegexp  = regexp.MustCompile(`(?P<os>Red Hat Enterprise Linux) (Client release|Server release|Workstation release) (?P<version>[\d]+)`)
+	rebuildReleaseRegexp = regexp.MustCompile(`(?P<os>Rocky Linux|AlmaLinux) (release) (?P<version>[\d]+)`)
 )
 
 type detector struct{}
@@ -69,6 +72,15 @@ func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
 			}, nil
 		}
 
+		// Attempt to match the RHEL rebuilds, before CentOS matches them too.
+		r = rebuildReleaseRegexp.FindStringSubmatch(string(f))
+		if len(r) == 4 {
+			return &database.Namespace{
+				Name:          "centos" + ":" + r[3],
+				VersionFormat: rpm.ParserName,
+			}, nil
+		}
+
 		// Atempt to match CentOS.
 		r = centosReleaseRegexp.FindStringSubmatch(string(f))
 		if len(r) == 4 {
@@ -83,5 +95,5 @@ func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
 }
 
 func (d detector) RequiredFilenames() []string {
-	return []string{"etc/oracle-release", "etc/centos-release", "etc/redhat-release", "etc/system-release"}
+	return []string{"etc/oracle-release", "etc/centos-release", "etc/rocky-release", "etc/almalinux-release", "etc/redhat-release", "etc/system-release"}
 }
@@ -54,6 +54,19 @@ func TestDetector(t *testing.T) {
 				"etc/system-release": []byte(`CentOS Linux release 7.1.1503 (Core)`),
 			},
 		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "centos:9"},
+			Files: tarutil.FilesMap{
+				"etc/rocky-release":  []byte(`Rocky Linux release 9.2 (Blue Onyx)`),
+				"etc/redhat-release": []byte(``),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "centos:8"},
+			Files: tarutil.FilesMap{
+				"etc/almalinux-release": []byte(`AlmaLinux release 8.8 (Sapphire Caracal)`),
+			},
+		},
 		{
 			ExpectedNamespace: nil,
 			Files:             tarutil.FilesMap{},
@@ -0,0 +1,62 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package windows implements a featurens.Detector for Windows container image
+// layers.
+//
+// The filesystem of a Windows layer is under its Files directory and its
+// registry hives under its Hives directory, rather than at the root of the
+// layer. The base layers are recognized by their hives, and the other ones by
+// the servicing packages that they install.
+package windows
+
+import (
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/ext/versionfmt/windows"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const namespaceName = "windows"
+
+// markerFilenames are the files of the Windows layers. Only the smallest
+// hives are extracted, as the software and system ones are large.
+var markerFilenames = []string{
+	"Hives/Sam_Base",
+	"Hives/Security_Base",
+	"Files/Windows/servicing/Packages/*.mum",
+}
+
+func init() {
+	featurens.RegisterDetector("windows", "1.0", &detector{})
+}
+
+type detector struct{}
+
+func (d detector) Detect(files tarutil.FilesMap) (*database.Namespace, error) {
+	for filename := range files {
+		if tarutil.MatchFilename(filename, markerFilenames) {
+			return &database.Namespace{
+				Name:          namespaceName,
+				VersionFormat: windows.ParserName,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (d detector) RequiredFilenames() []string {
+	return markerFilenames
+}
@@ -0,0 +1,60 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"testing"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/featurens"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+func TestDetector(t *testing.T) {
+	testData := []featurens.TestData{
+		{
+			ExpectedNamespace: &database.Namespace{Name: "windows"},
+			Files: tarutil.FilesMap{
+				"Hives/Sam_Base":      []byte("regf"),
+				"Hives/Security_Base": []byte("regf"),
+			},
+		},
+		{
+			ExpectedNamespace: &database.Namespace{Name: "windows"},
+			Files: tarutil.FilesMap{
+				"Files/Windows/servicing/Packages/Package_for_RollupFix~31bf3856ad364e35~amd64~~17763.1457.1.5.mum": []byte("<assembly/>"),
+			},
+		},
+		{
+			// The utility VM of a base layer is not the container's system.
+			ExpectedNamespace: nil,
+			Files: tarutil.FilesMap{
+				"UtilityVM/Files/Windows/servicing/Packages/Package_for_RollupFix~31bf3856ad364e35~amd64~~17763.1457.1.5.mum": []byte("<assembly/>"),
+			},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files: tarutil.FilesMap{
+				"etc/os-release": []byte("ID=debian\n"),
+			},
+		},
+		{
+			ExpectedNamespace: nil,
+			Files:             tarutil.FilesMap{},
+		},
+	}
+
+	featurens.TestDetector(t, &detector{}, testData)
+}
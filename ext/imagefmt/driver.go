@@ -21,6 +21,7 @@
 package imagefmt
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -30,15 +31,17 @@ import (
 	"strings"
 	"sync"
 
-	log "github.com/sirupsen/logrus"
-
+	"github.com/coreos/clair/ext/objectstore"
 	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/logutil"
 	"github.com/coreos/clair/pkg/strutil"
 	"github.com/coreos/clair/pkg/tarutil"
 )
 
 var (
-	// ErrCouldNotFindLayer is returned when we could not download or open the layer file.
+	// ErrCouldNotFindLayer is returned when we could not find or open the layer
+	// file. commonerr.ErrCouldNotDownload is returned instead when its download
+	// failed and might succeed later.
 	ErrCouldNotFindLayer = commonerr.NewBadRequestError("could not find layer from given path")
 
 	// insecureTLS controls whether TLS server's certificate chain and hostname are verified
@@ -102,12 +105,29 @@ func UnregisterExtractor(name string) {
 	delete(extractors, name)
 }
 
+// contextReader fails with the error of its context once it is done, which
+// stops the extraction of the layer of a cancelled analysis.
+type contextReader struct {
+	io.ReadCloser
+	ctx context.Context
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.ReadCloser.Read(p)
+}
+
 // Extract streams an image layer from disk or over HTTP, determines the
 // image format, then extracts the files specified.
-func Extract(format, path string, headers map[string]string, toExtract []string) (tarutil.FilesMap, error) {
+//
+// The download and the extraction are stopped once ctx is done, in which case
+// the error of ctx is returned.
+func Extract(ctx context.Context, format, path string, headers map[string]string, toExtract []string) (tarutil.FilesMap, error) {
 	var layerReader io.ReadCloser
 	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-		log.WithField("path", strutil.CleanURL(path)).Debug("start downloading layer blob...")
+		logutil.FromContext(ctx).WithField("path", strutil.CleanURL(path)).Debug("start downloading layer blob...")
 		request, err := http.NewRequest("GET", path, nil)
 		if err != nil {
 			return nil, ErrCouldNotFindLayer
@@ -120,38 +140,57 @@ func Extract(format, path string, headers map[string]string, toExtract []string)
 			}
 		}
 
-		// Send the request and handle the response.
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureTLS},
-			Proxy:           http.ProxyFromEnvironment,
-		}
-		client := &http.Client{Transport: tr}
-		r, err := client.Do(request)
+		r, err := doRequest(ctx, request)
 		if err != nil {
-			log.WithError(err).Error("could not download layer")
-			return nil, ErrCouldNotFindLayer
+			return nil, err
 		}
 
-		// Fail if we don't receive a 2xx HTTP status code.
-		if math.Floor(float64(r.StatusCode/100)) != 2 {
-			log.WithError(ErrCouldNotFindLayer).WithField("status code", r.StatusCode).Error("could not download layer: expected 2XX")
-			return nil, ErrCouldNotFindLayer
+		if err := checkResponse(ctx, r); err != nil {
+			return nil, err
 		}
 
 		layerReader = r.Body
 	} else {
-		log.WithField("path", strutil.CleanURL(path)).Debug("start reading layer blob from local file system...")
+		logutil.FromContext(ctx).WithField("path", strutil.CleanURL(path)).Debug("start reading layer blob from local file system...")
 		var err error
 		layerReader, err = os.Open(path)
 		if err != nil {
-			log.WithError(ErrCouldNotFindLayer).Error("could not open layer")
+			logutil.FromContext(ctx).WithError(ErrCouldNotFindLayer).Error("could not open layer")
 			return nil, ErrCouldNotFindLayer
 		}
 	}
+
+	return extract(ctx, format, layerReader, toExtract)
+}
+
+// ExtractFromObjectStorage reads an image layer from the bucket of an object
+// storage service by its URI, e.g. "s3://bucket/key", determines the image
+// format, then extracts the files specified.
+//
+// The credentials are only used by the backend of the scheme of the URI.
+func ExtractFromObjectStorage(ctx context.Context, format, uri string, credentials objectstore.Credentials, toExtract []string) (tarutil.FilesMap, error) {
+	logutil.FromContext(ctx).WithField("path", uri).Debug("start reading layer blob from object storage...")
+	layerReader, err := objectstore.Open(ctx, uri, credentials)
+	if err == objectstore.ErrObjectNotFound {
+		return nil, ErrCouldNotFindLayer
+	} else if err != nil {
+		return nil, err
+	}
+
+	return extract(ctx, format, layerReader, toExtract)
+}
+
+// extract extracts the files specified from a layer of the given image format
+// and closes it.
+func extract(ctx context.Context, format string, layerReader io.ReadCloser, toExtract []string) (tarutil.FilesMap, error) {
 	defer layerReader.Close()
 
 	if extractor, exists := Extractors()[strings.ToLower(format)]; exists {
-		files, err := extractor.ExtractFiles(layerReader, toExtract)
+		files, err := extractor.ExtractFiles(contextReader{layerReader, ctx}, toExtract)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
 		if err != nil {
 			return nil, err
 		}
@@ -161,6 +200,47 @@ func Extract(format, path string, headers map[string]string, toExtract []string)
 	return nil, commonerr.NewBadRequestError(fmt.Sprintf("unsupported image format '%s'", format))
 }
 
+// doRequest sends a request bound to ctx. A request that could not be sent
+// returns commonerr.ErrCouldNotDownload, or the error of ctx once it is done.
+func doRequest(ctx context.Context, request *http.Request) (*http.Response, error) {
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureTLS},
+		Proxy:           http.ProxyFromEnvironment,
+	}
+	client := &http.Client{Transport: tr}
+	r, err := client.Do(request.WithContext(ctx))
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if err == nil {
+			r.Body.Close()
+		}
+		return nil, ctxErr
+	}
+
+	if err != nil {
+		logutil.FromContext(ctx).WithError(err).Error("could not download layer")
+		return nil, commonerr.ErrCouldNotDownload
+	}
+
+	return r, nil
+}
+
+// checkResponse fails, closing the body of the response, if we don't receive
+// a 2xx HTTP status code. The server errors and the rate limits may be
+// temporary, unlike the other client errors.
+func checkResponse(ctx context.Context, r *http.Response) error {
+	if math.Floor(float64(r.StatusCode/100)) == 2 {
+		return nil
+	}
+
+	r.Body.Close()
+	err := ErrCouldNotFindLayer
+	if r.StatusCode >= http.StatusInternalServerError || r.StatusCode == http.StatusTooManyRequests {
+		err = commonerr.ErrCouldNotDownload
+	}
+	logutil.FromContext(ctx).WithError(err).WithField("status code", r.StatusCode).Error("could not download layer: expected 2XX")
+	return err
+}
+
 // SetInsecureTLS sets the insecureTLS to control whether TLS server's certificate chain
 // and hostname are verified when pulling layers.
 func SetInsecureTLS(insecure bool) {
@@ -0,0 +1,373 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oci implements an imagefmt.Extractor for container images stored as
+// tarballs of an OCI image layout.
+//
+// The files are extracted from the layers of the first image of the layout,
+// applied in order, so that the result is the content of the image's root
+// filesystem.
+package oci
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/ext/imagefmt"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const (
+	indexFileName = "index.json"
+	blobsDir      = "blobs/"
+
+	mediaTypeIndex          = "application/vnd.oci.image.index.v1+json"
+	mediaTypeManifest       = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// whiteoutPrefix marks the files of a layer that remove the file of the
+	// same name from the previous layers. An opaque whiteout removes all the
+	// content of its directory from the previous layers.
+	whiteoutPrefix = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+
+	// maxIndexDepth is the maximum number of nested indexes followed to find
+	// the image manifest.
+	maxIndexDepth = 8
+)
+
+var (
+	// ErrInvalidLayout occurs when the tarball is not a valid OCI image
+	// layout.
+	ErrInvalidLayout = errors.New("oci: could not find an image in the OCI image layout")
+
+	// maxMetadataBlobSize is the maximum size of the index and manifests read
+	// from the layout.
+	maxMetadataBlobSize int64 = 4 * 1024 * 1024
+)
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+type index struct {
+	Manifests []descriptor `json:"manifests"`
+}
+
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Layers    []descriptor `json:"layers"`
+}
+
+type format struct{}
+
+func init() {
+	imagefmt.RegisterExtractor("oci", &format{})
+}
+
+func (f format) ExtractFiles(layerReader io.ReadCloser, toExtract []string) (tarutil.FilesMap, error) {
+	// The blobs may come before the index in the tarball, so they are stored
+	// on disk until the layers are known.
+	blobsPath, err := ioutil.TempDir(os.TempDir(), "oci-blobs")
+	if err != nil {
+		log.WithError(err).Error("could not create a temporary directory for OCI blobs")
+		return nil, tarutil.ErrCouldNotExtract
+	}
+	defer os.RemoveAll(blobsPath)
+
+	idx, err := storeBlobs(layerReader, blobsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := findLayers(blobsPath, idx.Manifests, make(map[string]bool), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		files     = make(tarutil.FilesMap)
+		links     = make(tarutil.Links)
+		extracted int64
+	)
+	for _, layer := range layers {
+		if err := extractLayer(blobsPath, layer, toExtract, files, links, &extracted); err != nil {
+			return nil, err
+		}
+	}
+
+	// The links are resolved once all the layers are applied, as they may
+	// point to the files of any of them.
+	links.Resolve(files, toExtract)
+	return files, nil
+}
+
+// storeBlobs writes the blobs of the OCI image layout tarball to the given
+// directory and returns the index of the layout.
+func storeBlobs(r io.Reader, blobsPath string) (*index, error) {
+	tr := tar.NewReader(r)
+
+	var idx *index
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, tarutil.ErrCouldNotExtract
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		filename := strings.TrimPrefix(path.Clean(hdr.Name), "./")
+		switch {
+		case filename == indexFileName:
+			idx = &index{}
+			if err := json.NewDecoder(io.LimitReader(tr, maxMetadataBlobSize)).Decode(idx); err != nil {
+				log.WithError(err).Error("could not decode OCI image index")
+				return nil, ErrInvalidLayout
+			}
+		case strings.HasPrefix(filename, blobsDir):
+			digest, ok := blobDigest(strings.TrimPrefix(filename, blobsDir))
+			if !ok {
+				continue
+			}
+
+			if err := storeBlob(tr, filepath.Join(blobsPath, digest), digest); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if idx == nil {
+		return nil, ErrInvalidLayout
+	}
+
+	return idx, nil
+}
+
+// blobDigest converts the path of a blob in the layout, e.g. sha256/abc, into
+// its digest, e.g. sha256:abc.
+func blobDigest(blobPath string) (string, bool) {
+	parts := strings.Split(blobPath, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || parts[0] == ".." || parts[1] == ".." {
+		return "", false
+	}
+
+	return parts[0] + ":" + parts[1], true
+}
+
+// storeBlob writes a blob of the layout to the given file, and removes it when
+// its content does not match its digest.
+func storeBlob(r io.Reader, fileName, digest string) error {
+	h, ok := newDigester(digest)
+	if !ok {
+		log.WithField("digest", digest).Warning("skipping OCI blob with unsupported digest algorithm")
+		return nil
+	}
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		log.WithError(err).WithField("Filename", fileName).Error("could not store OCI blob")
+		return tarutil.ErrCouldNotExtract
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(io.MultiWriter(f, h), r); err != nil {
+		log.WithError(err).WithField("Filename", fileName).Error("could not store OCI blob")
+		return tarutil.ErrCouldNotExtract
+	}
+
+	if encoded := digest[strings.Index(digest, ":")+1:]; hex.EncodeToString(h.Sum(nil)) != encoded {
+		os.Remove(fileName)
+		log.WithField("digest", digest).Error("could not verify OCI blob: content does not match its digest")
+		return ErrInvalidLayout
+	}
+
+	return nil
+}
+
+// newDigester returns the hash of the algorithm of the given digest, e.g.
+// sha256:abc.
+func newDigester(digest string) (hash.Hash, bool) {
+	switch digest[:strings.Index(digest, ":")] {
+	case "sha256":
+		return sha256.New(), true
+	case "sha512":
+		return sha512.New(), true
+	}
+	return nil, false
+}
+
+// findLayers returns the layers of the first image manifest found in the
+// given descriptors, following nested indexes up to maxIndexDepth. visited
+// holds the digests of the indexes already followed, which are skipped.
+func findLayers(blobsPath string, descriptors []descriptor, visited map[string]bool, depth int) ([]descriptor, error) {
+	if depth > maxIndexDepth {
+		log.WithField("depth", depth).Error("could not find OCI image manifest: too many nested indexes")
+		return nil, ErrInvalidLayout
+	}
+
+	for _, desc := range descriptors {
+		switch desc.MediaType {
+		case mediaTypeIndex:
+			if visited[desc.Digest] {
+				continue
+			}
+			visited[desc.Digest] = true
+
+			var idx index
+			if err := readBlob(blobsPath, desc.Digest, &idx); err != nil {
+				return nil, err
+			}
+
+			layers, err := findLayers(blobsPath, idx.Manifests, visited, depth+1)
+			if err == ErrInvalidLayout {
+				continue
+			}
+			return layers, err
+		case mediaTypeManifest, mediaTypeDockerManifest, "":
+			var m manifest
+			if err := readBlob(blobsPath, desc.Digest, &m); err != nil {
+				return nil, err
+			}
+
+			// A descriptor without media type could be an index.
+			if desc.MediaType == "" && m.MediaType == mediaTypeIndex {
+				continue
+			}
+			return m.Layers, nil
+		}
+	}
+
+	return nil, ErrInvalidLayout
+}
+
+// openBlob opens a blob stored by storeBlobs.
+func openBlob(blobsPath, digest string) (*os.File, error) {
+	if digest == "" || strings.ContainsAny(digest, `/\`) {
+		return nil, errors.New("invalid digest")
+	}
+
+	return os.Open(filepath.Join(blobsPath, digest))
+}
+
+func readBlob(blobsPath, digest string, v interface{}) error {
+	f, err := openBlob(blobsPath, digest)
+	if err != nil {
+		log.WithError(err).WithField("digest", digest).Error("could not find OCI blob in the image layout")
+		return ErrInvalidLayout
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(io.LimitReader(f, maxMetadataBlobSize)).Decode(v); err != nil {
+		log.WithError(err).WithField("digest", digest).Error("could not decode OCI blob")
+		return ErrInvalidLayout
+	}
+
+	return nil
+}
+
+// extractLayer extracts the specified files of a layer into files, and its
+// links into links, applying its whiteouts to the files of the previous
+// layers. extracted accumulates the size of the files extracted from all the
+// layers.
+func extractLayer(blobsPath string, layer descriptor, toExtract []string, files tarutil.FilesMap, links tarutil.Links, extracted *int64) error {
+	f, err := openBlob(blobsPath, layer.Digest)
+	if err != nil {
+		log.WithError(err).WithField("digest", layer.Digest).Error("could not find OCI layer in the image layout")
+		return ErrInvalidLayout
+	}
+	defer f.Close()
+
+	// The compression of the layer is detected by tarutil.
+	tr, err := tarutil.NewTarReadCloser(f)
+	if err != nil {
+		return tarutil.ErrCouldNotExtract
+	}
+	defer tr.Close()
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return tarutil.ErrCouldNotExtract
+		}
+
+		filename, ok := tarutil.CleanFilename(hdr.Name)
+		if !ok {
+			log.WithFields(log.Fields{"digest": layer.Digest, "path": hdr.Name}).Warning("skipping OCI layer element with invalid path")
+			continue
+		}
+		dir, base := path.Split(filename)
+
+		// Apply the whiteouts.
+		if base == opaqueWhiteout {
+			removeFiles(files, links, dir)
+			continue
+		}
+		if strings.HasPrefix(base, whiteoutPrefix) {
+			removed := dir + strings.TrimPrefix(base, whiteoutPrefix)
+			delete(files, removed)
+			links.Remove(removed)
+			removeFiles(files, links, removed+"/")
+			continue
+		}
+
+		// Determine if we should extract the element
+		if tarutil.MatchFilename(filename, toExtract) || links.IsTarget(filename) {
+			// File size limits
+			if err := tarutil.CheckExtractedSize(hdr.Size, extracted); err != nil {
+				return err
+			}
+
+			// Extract the element, which replaces a link of a previous layer.
+			if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink || hdr.Typeflag == tar.TypeReg {
+				d, _ := ioutil.ReadAll(tr)
+				files[filename] = d
+				links.Add(hdr, filename)
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeFiles removes the files and the links under the given directory.
+func removeFiles(files tarutil.FilesMap, links tarutil.Links, dir string) {
+	for filename := range files {
+		if strings.HasPrefix(filename, dir) {
+			delete(files, filename)
+			links.Remove(filename)
+		}
+	}
+}
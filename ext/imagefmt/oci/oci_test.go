@@ -0,0 +1,112 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testfilepath(filename string) string {
+	_, path, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(path), "testdata", filename)
+}
+
+func extractTestLayout(t *testing.T, filename string, toExtract []string) (map[string][]byte, error) {
+	f, err := os.Open(testfilepath(filename))
+	require.Nil(t, err)
+	return format{}.ExtractFiles(f, toExtract)
+}
+
+func TestExtractFiles(t *testing.T) {
+	// The layout has an index nesting the index of a manifest, whose gzip
+	// layer is followed by a zstd layer removing some of its files.
+	files, err := extractTestLayout(t, "layout.tar", []string{"etc/", "opt/", "var/lib/dpkg/status"})
+	require.Nil(t, err)
+
+	assert.Equal(t, "ID=debian\nVERSION_ID=\"9\"\n", string(files["etc/os-release"]))
+	assert.Equal(t, "Package: base\n", string(files["var/lib/dpkg/status"]))
+	assert.Equal(t, "b\n", string(files["opt/dir/b"]))
+	assert.Equal(t, files["etc/os-release"], files["etc/issue"])
+
+	// The whiteouts remove the files of the previous layer.
+	assert.NotContains(t, files, "etc/removed")
+	assert.NotContains(t, files, "opt/dir/a")
+	assert.NotContains(t, files, "etc/.wh.removed")
+	assert.NotContains(t, files, "opt/dir/.wh..wh..opq")
+}
+
+func TestExtractFilesInvalidLayout(t *testing.T) {
+	// The manifest references a layer missing from the layout.
+	_, err := extractTestLayout(t, "missing.tar", []string{"etc/"})
+	assert.Equal(t, ErrInvalidLayout, err)
+
+	// A layer does not match its digest.
+	_, err = extractTestLayout(t, "corrupt.tar", []string{"etc/"})
+	assert.Equal(t, ErrInvalidLayout, err)
+}
+
+// writeTestBlob stores a blob as storeBlobs, without verifying its digest, so
+// that indexes can reference each other.
+func writeTestBlob(t *testing.T, blobsPath, digest string, v interface{}) {
+	content, err := json.Marshal(v)
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(blobsPath, digest), content, 0600))
+}
+
+func TestFindLayersNestedIndexes(t *testing.T) {
+	blobsPath, err := ioutil.TempDir("", "oci-blobs")
+	require.Nil(t, err)
+	defer os.RemoveAll(blobsPath)
+
+	indexOf := func(digest string) index {
+		return index{Manifests: []descriptor{{MediaType: mediaTypeIndex, Digest: digest}}}
+	}
+
+	// An index referencing itself through another one.
+	writeTestBlob(t, blobsPath, "sha256:a", indexOf("sha256:b"))
+	writeTestBlob(t, blobsPath, "sha256:b", indexOf("sha256:a"))
+
+	_, err = findLayers(blobsPath, indexOf("sha256:a").Manifests, make(map[string]bool), 0)
+	assert.Equal(t, ErrInvalidLayout, err)
+
+	// Too many nested indexes.
+	for i := 0; i < maxIndexDepth; i++ {
+		writeTestBlob(t, blobsPath, testDigest(i), indexOf(testDigest(i+1)))
+	}
+	writeTestBlob(t, blobsPath, testDigest(maxIndexDepth), index{Manifests: []descriptor{{MediaType: mediaTypeManifest, Digest: "sha256:manifest"}}})
+	writeTestBlob(t, blobsPath, "sha256:manifest", manifest{Layers: []descriptor{{Digest: "sha256:layer"}}})
+
+	_, err = findLayers(blobsPath, indexOf(testDigest(0)).Manifests, make(map[string]bool), 0)
+	assert.Equal(t, ErrInvalidLayout, err)
+
+	layers, err := findLayers(blobsPath, indexOf(testDigest(1)).Manifests, make(map[string]bool), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []descriptor{{Digest: "sha256:layer"}}, layers)
+}
+
+func testDigest(i int) string {
+	sum := sha256.Sum256([]byte{byte(i)})
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,273 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagefmt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/logutil"
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+// ErrRegistryUnauthorized is returned when the registry refuses the
+// credentials of a pull, or when it asks for credentials that were not given.
+var ErrRegistryUnauthorized = commonerr.NewBadRequestError("could not authenticate with the registry")
+
+// Registry is a repository of a registry implementing the Docker Registry
+// HTTP API V2, such as the OCI distribution registries, from which the layer
+// blobs are pulled.
+//
+// Its credentials are only used for the pull of the layers of the request
+// that gave them, and are never logged.
+type Registry struct {
+	// Host is the host, and optional port, of the registry, e.g. "quay.io".
+	Host string
+
+	// Repository is the name of the repository, e.g. "coreos/clair".
+	Repository string
+
+	// Insecure pulls the blobs over plain HTTP instead of HTTPS, and allows
+	// the tokens to be requested from a plain HTTP realm.
+	Insecure bool
+
+	// Token is a bearer token authorizing the pull of the repository.
+	Token string
+
+	// Username and Password are exchanged for a bearer token, or sent as basic
+	// authentication, when the registry asks for credentials.
+	Username string
+	Password string
+}
+
+// String describes the registry without its credentials, so that it is safe
+// to log.
+func (r Registry) String() string {
+	return r.Host + "/" + r.Repository
+}
+
+// BlobURL returns the URL of the blob with the given digest.
+func (r Registry) BlobURL(digest string) string {
+	scheme := "https"
+	if r.Insecure {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/blobs/%s", scheme, r.Host, r.Repository, digest)
+}
+
+// ExtractFromRegistry pulls the blob of a layer from a registry by its digest,
+// determines the image format, then extracts the files specified.
+//
+// The blob is first requested with the bearer token, if any. When the
+// registry challenges the request, the token it asks for is requested with the
+// username and password, or they are sent directly for basic authentication.
+func ExtractFromRegistry(ctx context.Context, format string, registry Registry, digest string, toExtract []string) (tarutil.FilesMap, error) {
+	logger := logutil.FromContext(ctx).WithFields(log.Fields{"registry": registry.String(), "digest": digest})
+	logger.Debug("start pulling layer blob from registry...")
+
+	blobURL := registry.BlobURL(digest)
+	authorization := ""
+	if registry.Token != "" {
+		authorization = "Bearer " + registry.Token
+	}
+
+	r, err := registry.get(ctx, blobURL, authorization)
+	if err != nil {
+		return nil, err
+	}
+
+	// A given token is used as is, the challenges are only answered with the
+	// username and password.
+	if r.StatusCode == http.StatusUnauthorized && registry.Token == "" {
+		challenge := r.Header.Get("WWW-Authenticate")
+		r.Body.Close()
+
+		if authorization, err = registry.authorize(ctx, challenge); err != nil {
+			return nil, err
+		}
+
+		if r, err = registry.get(ctx, blobURL, authorization); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden {
+		r.Body.Close()
+		logger.WithField("status code", r.StatusCode).Error("registry refused the pull of the layer")
+		return nil, ErrRegistryUnauthorized
+	}
+
+	if err := checkResponse(ctx, r); err != nil {
+		return nil, err
+	}
+
+	return extract(ctx, format, r.Body, toExtract)
+}
+
+// get requests the given URL of the registry with the given Authorization
+// header, if any.
+func (r Registry) get(ctx context.Context, u, authorization string) (*http.Response, error) {
+	request, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, ErrCouldNotFindLayer
+	}
+
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+
+	return doRequest(ctx, request)
+}
+
+// authorize answers the challenge of the registry, returning the value of the
+// Authorization header of the next request.
+func (r Registry) authorize(ctx context.Context, challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if r.Username == "" {
+			return "", ErrRegistryUnauthorized
+		}
+
+		request := &http.Request{Header: make(http.Header)}
+		request.SetBasicAuth(r.Username, r.Password)
+		return request.Header.Get("Authorization"), nil
+	case "bearer":
+		token, err := r.requestToken(ctx, params)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	}
+
+	logutil.FromContext(ctx).WithField("scheme", scheme).Error("unsupported registry authentication scheme")
+	return "", ErrRegistryUnauthorized
+}
+
+// requestToken requests a bearer token to pull the repository from the realm
+// of a challenge, authenticated with the username and password if they are
+// given. The realm must be https, unless the registry is insecure.
+func (r Registry) requestToken(ctx context.Context, params map[string]string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme == "" || realm.Host == "" {
+		logutil.FromContext(ctx).Error("registry challenge has no valid realm")
+		return "", ErrRegistryUnauthorized
+	}
+
+	// The credentials are only sent over plain HTTP to an insecure registry.
+	if realm.Scheme != "https" && !(r.Insecure && realm.Scheme == "http") {
+		logutil.FromContext(ctx).WithField("scheme", realm.Scheme).Error("registry challenge realm is not https")
+		return "", ErrRegistryUnauthorized
+	}
+
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + r.Repository + ":pull"
+	}
+
+	query := realm.Query()
+	query.Set("scope", scope)
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	realm.RawQuery = query.Encode()
+
+	request, err := http.NewRequest("GET", realm.String(), nil)
+	if err != nil {
+		return "", ErrRegistryUnauthorized
+	}
+
+	if r.Username != "" {
+		request.SetBasicAuth(r.Username, r.Password)
+	}
+
+	resp, err := doRequest(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "", ErrRegistryUnauthorized
+	case resp.StatusCode/100 != 2:
+		logutil.FromContext(ctx).WithField("status code", resp.StatusCode).Error("could not request registry token: expected 2XX")
+		return "", commonerr.ErrCouldNotDownload
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		logutil.FromContext(ctx).WithError(err).Error("could not decode registry token")
+		return "", commonerr.ErrCouldNotDownload
+	}
+
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", ErrRegistryUnauthorized
+}
+
+// parseChallenge parses the scheme and the parameters of a WWW-Authenticate
+// header, e.g. `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`.
+func parseChallenge(challenge string) (string, map[string]string) {
+	challenge = strings.TrimSpace(challenge)
+	scheme, rest := challenge, ""
+	if i := strings.IndexByte(challenge, ' '); i >= 0 {
+		scheme, rest = challenge[:i], challenge[i+1:]
+	}
+
+	params := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		i := strings.IndexByte(rest, '=')
+		if i < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:i]))
+		rest = strings.TrimSpace(rest[i+1:])
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if end := strings.IndexByte(rest, ','); end >= 0 {
+			value, rest = rest[:end], rest[end:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = strings.TrimSpace(value)
+
+		rest = strings.TrimLeft(rest, ", ")
+	}
+
+	return scheme, params
+}
@@ -0,0 +1,213 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagefmt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+const testBlob = "layer"
+
+// testExtractor returns the content of the layer as the only file.
+type testExtractor struct{}
+
+func (testExtractor) ExtractFiles(layer io.ReadCloser, filenames []string) (tarutil.FilesMap, error) {
+	content, err := ioutil.ReadAll(layer)
+	if err != nil {
+		return nil, err
+	}
+	return tarutil.FilesMap{"layer": content}, nil
+}
+
+func init() {
+	RegisterExtractor("test", testExtractor{})
+}
+
+func TestParseChallenge(t *testing.T) {
+	for _, test := range []struct {
+		challenge string
+		scheme    string
+		params    map[string]string
+	}{
+		{
+			`Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`,
+			"Bearer",
+			map[string]string{"realm": "https://auth.docker.io/token", "service": "registry.docker.io"},
+		},
+		{
+			`Bearer realm="https://quay.io/v2/auth", service=quay.io, scope="repository:coreos/clair:pull,push"`,
+			"Bearer",
+			map[string]string{"realm": "https://quay.io/v2/auth", "service": "quay.io", "scope": "repository:coreos/clair:pull,push"},
+		},
+		{`Basic realm="Registry"`, "Basic", map[string]string{"realm": "Registry"}},
+		{`Basic`, "Basic", map[string]string{}},
+		{`Bearer REALM="unterminated`, "Bearer", map[string]string{"realm": "unterminated"}},
+		{``, "", map[string]string{}},
+	} {
+		scheme, params := parseChallenge(test.challenge)
+		assert.Equal(t, test.scheme, scheme, test.challenge)
+		assert.Equal(t, test.params, params, test.challenge)
+	}
+}
+
+// testRegistry serves the blob of a repository to the requests authorized as
+// expected, challenging the other ones.
+func testRegistry(t *testing.T, challenge func(*http.Request) string, authorization string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !assert.Equal(t, "/v2/coreos/clair/blobs/sha256:digest", r.URL.Path) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != authorization {
+			w.Header().Set("WWW-Authenticate", challenge(r))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, testBlob)
+	}))
+}
+
+func testRegistryOf(server *httptest.Server) Registry {
+	return Registry{
+		Host:       strings.TrimPrefix(server.URL, "http://"),
+		Repository: "coreos/clair",
+		Insecure:   true,
+	}
+}
+
+func TestExtractFromRegistryBearer(t *testing.T) {
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "repository:coreos/clair:pull", r.URL.Query().Get("scope"))
+		assert.Equal(t, "registry", r.URL.Query().Get("service"))
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"token": "secret"}`)
+	}))
+	defer auth.Close()
+
+	registry := testRegistry(t, func(*http.Request) string {
+		return fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, auth.URL)
+	}, "Bearer secret")
+	defer registry.Close()
+
+	r := testRegistryOf(registry)
+	r.Username, r.Password = "user", "pass"
+	files, err := ExtractFromRegistry(context.Background(), "test", r, "sha256:digest", nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, testBlob, string(files["layer"]))
+	}
+
+	// The token service refuses the credentials.
+	r.Password = "wrong"
+	_, err = ExtractFromRegistry(context.Background(), "test", r, "sha256:digest", nil)
+	assert.Equal(t, ErrRegistryUnauthorized, err)
+
+	// A given token is used as is.
+	r = testRegistryOf(registry)
+	r.Token = "secret"
+	_, err = ExtractFromRegistry(context.Background(), "test", r, "sha256:digest", nil)
+	assert.Nil(t, err)
+}
+
+func TestExtractFromRegistryBasic(t *testing.T) {
+	request := &http.Request{Header: make(http.Header)}
+	request.SetBasicAuth("user", "pass")
+	registry := testRegistry(t, func(*http.Request) string { return `Basic realm="Registry"` }, request.Header.Get("Authorization"))
+	defer registry.Close()
+
+	r := testRegistryOf(registry)
+	r.Username, r.Password = "user", "pass"
+	files, err := ExtractFromRegistry(context.Background(), "test", r, "sha256:digest", nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, testBlob, string(files["layer"]))
+	}
+
+	// The registry refuses the credentials.
+	r.Password = "wrong"
+	_, err = ExtractFromRegistry(context.Background(), "test", r, "sha256:digest", nil)
+	assert.Equal(t, ErrRegistryUnauthorized, err)
+
+	// The registry asks for credentials that were not given.
+	_, err = ExtractFromRegistry(context.Background(), "test", testRegistryOf(registry), "sha256:digest", nil)
+	assert.Equal(t, ErrRegistryUnauthorized, err)
+}
+
+func TestExtractFromRegistryRefused(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+		r := testRegistryOf(registry)
+		r.Token = "secret"
+		_, err := ExtractFromRegistry(context.Background(), "test", r, "sha256:digest", nil)
+		assert.Equal(t, ErrRegistryUnauthorized, err, status)
+		registry.Close()
+	}
+}
+
+func TestRequestTokenRealm(t *testing.T) {
+	requested := false
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		fmt.Fprint(w, `{"access_token": "secret"}`)
+	}))
+	defer auth.Close()
+
+	// The credentials are not sent to a plain HTTP realm of a secure registry.
+	r := Registry{Repository: "coreos/clair", Username: "user", Password: "pass"}
+	_, err := r.requestToken(context.Background(), map[string]string{"realm": auth.URL})
+	assert.Equal(t, ErrRegistryUnauthorized, err)
+	assert.False(t, requested)
+
+	for _, realm := range []string{"", "auth.docker.io/token", "ftp://auth.docker.io/token"} {
+		r.Insecure = true
+		_, err = r.requestToken(context.Background(), map[string]string{"realm": realm})
+		assert.Equal(t, ErrRegistryUnauthorized, err, realm)
+	}
+	assert.False(t, requested)
+
+	// They are sent to the realm of an insecure registry.
+	r.Insecure = true
+	token, err := r.requestToken(context.Background(), map[string]string{"realm": auth.URL})
+	require.Nil(t, err)
+	assert.Equal(t, "secret", token)
+	assert.True(t, requested)
+
+	// The realm of a secure registry is https.
+	secure := httptest.NewTLSServer(auth.Config.Handler)
+	defer secure.Close()
+	SetInsecureTLS(true)
+	defer SetInsecureTLS(false)
+
+	r.Insecure = false
+	token, err = r.requestToken(context.Background(), map[string]string{"realm": secure.URL})
+	require.Nil(t, err)
+	assert.Equal(t, "secret", token)
+}
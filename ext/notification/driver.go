@@ -23,6 +23,8 @@ package notification
 import (
 	"sync"
 	"time"
+
+	"github.com/coreos/clair/database"
 )
 
 var (
@@ -35,7 +37,35 @@ var (
 type Config struct {
 	Attempts         int
 	RenotifyInterval time.Duration
-	Params           map[string]interface{} `yaml:",inline"`
+
+	// MaxBackoff caps the delay between two attempts to send a notification.
+	// The delay never exceeds RenotifyInterval.
+	MaxBackoff time.Duration
+
+	// BackoffFactor multiplies the delay between two attempts to send a
+	// notification after each failure.
+	BackoffFactor float64
+
+	// DedupWindow is the duration during which the notifications about the
+	// same vulnerability are coalesced: the latest of those received after
+	// one was sent is sent once the window has elapsed. They are all sent
+	// when it is zero.
+	DedupWindow time.Duration
+
+	// MinimumSeverity is the severity that the old or the new vulnerability
+	// of a notification must reach for it to be sent. Notifications below it
+	// are marked as read without being sent. All of them are sent when it
+	// is empty.
+	MinimumSeverity database.Severity
+	Params          map[string]interface{} `yaml:",inline"`
+
+	// Datastore is set by the notifier service before configuring the
+	// senders so that they can look up the content of notifications.
+	Datastore database.Datastore `yaml:"-"`
+
+	// Stop is closed by the notifier service once it is asked to stop, so
+	// that the senders stop waiting, e.g. for a rate limit to expire.
+	Stop <-chan struct{} `yaml:"-"`
 }
 
 // Sender represents anything that can transmit notifications.
@@ -48,6 +78,22 @@ type Sender interface {
 	Send(notificationName string) error
 }
 
+// ConfigValidator is implemented by the Senders that can check their
+// configuration when it is loaded, before being configured.
+type ConfigValidator interface {
+	// ValidateConfig returns an error if the provided configuration of the
+	// Sender is invalid.
+	ValidateConfig(*Config) error
+}
+
+// Forgetter is implemented by the Senders that keep a state per notification
+// across its attempts, such as the endpoints that already received it.
+type Forgetter interface {
+	// Forget drops the state of the notification once the notifier gave up
+	// on sending it.
+	Forget(notificationName string)
+}
+
 // RegisterSender makes a Sender available by the provided name.
 //
 // If called twice with the same name, the name is blank, or if the provided
@@ -0,0 +1,263 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slack implements a notification sender for Slack incoming webhooks.
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/notification"
+	"github.com/coreos/clair/pkg/pagination"
+)
+
+const (
+	timeout = 5 * time.Second
+
+	// maxRateLimitRetries is the number of times a rate-limited message is
+	// retried before the attempt is considered failed.
+	maxRateLimitRetries = 5
+
+	// maxRetryAfter caps the delay requested by Slack's Retry-After header.
+	maxRetryAfter = time.Minute
+
+	// defaultRetryAfter is used when Slack rate-limits without specifying a
+	// Retry-After delay.
+	defaultRetryAfter = time.Second
+)
+
+// severityColors maps severities to the colors of the Slack attachments.
+var severityColors = map[database.Severity]string{
+	database.UnknownSeverity:    "#9e9e9e",
+	database.NegligibleSeverity: "#9e9e9e",
+	database.LowSeverity:        "#2196f3",
+	database.MediumSeverity:     "#ffc107",
+	database.HighSeverity:       "#ff5722",
+	database.CriticalSeverity:   "#d50000",
+	database.Defcon1Severity:    "#000000",
+}
+
+type sender struct {
+	webhookURL string
+	channel    string
+	username   string
+	client     *http.Client
+	datastore  database.Datastore
+	stop       <-chan struct{}
+}
+
+// Config represents the configuration of a Slack Sender.
+type Config struct {
+	// WebhookURL is the URL of the Slack incoming webhook.
+	WebhookURL string
+
+	// Channel and Username optionally override the defaults of the webhook.
+	Channel  string
+	Username string
+
+	Proxy string
+}
+
+func init() {
+	notification.RegisterSender("slack", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	var slackConfig Config
+	if config == nil {
+		return false, nil
+	}
+	if _, ok := config.Params["slack"]; !ok {
+		return false, nil
+	}
+	yamlConfig, err := yaml.Marshal(config.Params["slack"])
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+	err = yaml.Unmarshal(yamlConfig, &slackConfig)
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+
+	// Validate webhook URL.
+	if slackConfig.WebhookURL == "" {
+		return false, nil
+	}
+	if _, err := url.ParseRequestURI(slackConfig.WebhookURL); err != nil {
+		return false, fmt.Errorf("could not parse webhook URL: %s", err)
+	}
+	s.webhookURL = slackConfig.WebhookURL
+	s.channel = slackConfig.Channel
+	s.username = slackConfig.Username
+	s.datastore = config.Datastore
+	s.stop = config.Stop
+
+	// Setup HTTP client.
+	transport := &http.Transport{}
+	s.client = &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+
+	// Set proxy.
+	if slackConfig.Proxy != "" {
+		proxyURL, err := url.ParseRequestURI(slackConfig.Proxy)
+		if err != nil {
+			return false, fmt.Errorf("could not parse proxy URL: %s", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return true, nil
+}
+
+type message struct {
+	Channel     string       `json:"channel,omitempty"`
+	Username    string       `json:"username,omitempty"`
+	Text        string       `json:"text"`
+	Attachments []attachment `json:"attachments,omitempty"`
+}
+
+type attachment struct {
+	Fallback  string  `json:"fallback"`
+	Color     string  `json:"color,omitempty"`
+	Pretext   string  `json:"pretext,omitempty"`
+	Title     string  `json:"title"`
+	TitleLink string  `json:"title_link,omitempty"`
+	Fields    []field `json:"fields,omitempty"`
+}
+
+type field struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+func (s *sender) Send(notificationName string) error {
+	msg, err := s.newMessage(notificationName)
+	if err != nil {
+		return err
+	}
+
+	jsonMessage, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	for retries := 0; ; retries++ {
+		resp, err := s.client.Post(s.webhookURL, "application/json", bytes.NewBuffer(jsonMessage))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return nil
+		case resp.StatusCode == http.StatusTooManyRequests && retries < maxRateLimitRetries:
+			// Being rate-limited is not a failure of the notification: wait for as
+			// long as requested instead of consuming one of the notifier attempts.
+			delay := retryAfter(resp.Header.Get("Retry-After"))
+			log.WithFields(log.Fields{"notification name": notificationName, "duration": delay}).Warning("rate-limited by Slack, waiting before retrying")
+			select {
+			case <-time.After(delay):
+			case <-s.stop:
+				return errors.New("notifier stopped while rate-limited by Slack")
+			}
+		default:
+			return fmt.Errorf("got status %d, expected 200", resp.StatusCode)
+		}
+	}
+}
+
+// newMessage builds the Slack message of a notification.
+//
+// The content of the notification is included when the datastore is
+// available, otherwise only its name is sent.
+func (s *sender) newMessage(notificationName string) (*message, error) {
+	msg := &message{
+		Channel:  s.channel,
+		Username: s.username,
+		Text:     fmt.Sprintf("Clair notification `%s`", notificationName),
+	}
+
+	if s.datastore == nil {
+		return msg, nil
+	}
+
+	tx, err := s.datastore.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	noti, ok, err := tx.FindVulnerabilityNotification(notificationName, 1, pagination.FirstPageToken, pagination.FirstPageToken)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return msg, nil
+	}
+
+	if noti.New != nil {
+		msg.Attachments = append(msg.Attachments, newAttachment("New vulnerability", noti.New.Vulnerability))
+	}
+
+	if noti.Old != nil {
+		msg.Attachments = append(msg.Attachments, newAttachment("Old vulnerability", noti.Old.Vulnerability))
+	}
+
+	return msg, nil
+}
+
+func newAttachment(pretext string, vuln database.Vulnerability) attachment {
+	return attachment{
+		Fallback:  fmt.Sprintf("%s: %s (%s)", pretext, vuln.Name, vuln.Severity),
+		Color:     severityColors[vuln.Severity],
+		Pretext:   pretext,
+		Title:     vuln.Name,
+		TitleLink: vuln.Link,
+		Fields: []field{
+			{Title: "Severity", Value: string(vuln.Severity), Short: true},
+			{Title: "Namespace", Value: vuln.Namespace.Name, Short: true},
+		},
+	}
+}
+
+// retryAfter parses the value of a Retry-After header expressed in seconds.
+func retryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return defaultRetryAfter
+	}
+
+	delay := time.Duration(seconds) * time.Second
+	if delay > maxRetryAfter {
+		return maxRetryAfter
+	}
+
+	return delay
+}
@@ -0,0 +1,184 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/notification"
+)
+
+// testWebhook is a Slack webhook that rate-limits its first requests with the
+// given Retry-After header.
+type testWebhook struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	rateLimited int
+	retryAfter  string
+	messages    []message
+}
+
+func newTestWebhook(rateLimited int, retryAfter string) *testWebhook {
+	w := &testWebhook{rateLimited: rateLimited, retryAfter: retryAfter}
+	w.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		var msg message
+		json.NewDecoder(r.Body).Decode(&msg)
+		w.messages = append(w.messages, msg)
+
+		if w.rateLimited > 0 {
+			w.rateLimited--
+			rw.Header().Set("Retry-After", w.retryAfter)
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+	}))
+	return w
+}
+
+func (w *testWebhook) received() []message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]message(nil), w.messages...)
+}
+
+func newTestSender(t *testing.T, webhookURL string, stop <-chan struct{}) *sender {
+	s := &sender{}
+	enabled, err := s.Configure(&notification.Config{
+		Params: map[string]interface{}{"slack": map[string]interface{}{
+			"webhookurl": webhookURL,
+			"channel":    "#security",
+			"username":   "clair",
+		}},
+		Stop: stop,
+	})
+	require.Nil(t, err)
+	require.True(t, enabled)
+	return s
+}
+
+func TestConfigure(t *testing.T) {
+	s := &sender{}
+	enabled, err := s.Configure(&notification.Config{})
+	assert.Nil(t, err)
+	assert.False(t, enabled)
+
+	enabled, err = s.Configure(&notification.Config{
+		Params: map[string]interface{}{"slack": map[string]interface{}{"webhookurl": "not a url"}},
+	})
+	assert.NotNil(t, err)
+	assert.False(t, enabled)
+}
+
+func TestSend(t *testing.T) {
+	webhook := newTestWebhook(0, "")
+	defer webhook.Close()
+
+	s := newTestSender(t, webhook.URL, nil)
+	require.Nil(t, s.Send("notification"))
+
+	if messages := webhook.received(); assert.Len(t, messages, 1) {
+		assert.Equal(t, message{
+			Channel:  "#security",
+			Username: "clair",
+			Text:     "Clair notification `notification`",
+		}, messages[0])
+	}
+}
+
+func TestNewAttachment(t *testing.T) {
+	assert.Equal(t, attachment{
+		Fallback:  "New vulnerability: CVE-2018-0001 (High)",
+		Color:     "#ff5722",
+		Pretext:   "New vulnerability",
+		Title:     "CVE-2018-0001",
+		TitleLink: "https://security-tracker.debian.org/tracker/CVE-2018-0001",
+		Fields: []field{
+			{Title: "Severity", Value: "High", Short: true},
+			{Title: "Namespace", Value: "debian:9", Short: true},
+		},
+	}, newAttachment("New vulnerability", database.Vulnerability{
+		Name:      "CVE-2018-0001",
+		Link:      "https://security-tracker.debian.org/tracker/CVE-2018-0001",
+		Severity:  database.HighSeverity,
+		Namespace: database.Namespace{Name: "debian:9", VersionFormat: "dpkg"},
+	}))
+}
+
+func TestRetryAfter(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"":      defaultRetryAfter,
+		"soon":  defaultRetryAfter,
+		"0":     defaultRetryAfter,
+		"-5":    defaultRetryAfter,
+		"3":     3 * time.Second,
+		"60":    time.Minute,
+		"86400": maxRetryAfter,
+	} {
+		assert.Equal(t, expected, retryAfter(value), value)
+	}
+}
+
+func TestSendRateLimited(t *testing.T) {
+	webhook := newTestWebhook(1, "1")
+	defer webhook.Close()
+
+	s := newTestSender(t, webhook.URL, nil)
+	start := time.Now()
+	require.Nil(t, s.Send("notification"))
+	assert.True(t, time.Since(start) >= time.Second, "the retry should wait for Retry-After")
+	assert.Len(t, webhook.received(), 2)
+
+	// A webhook that keeps rate-limiting fails the attempt.
+	webhook = newTestWebhook(maxRateLimitRetries+1, "1")
+	defer webhook.Close()
+
+	s = newTestSender(t, webhook.URL, nil)
+	assert.NotNil(t, s.Send("notification"))
+	assert.Len(t, webhook.received(), maxRateLimitRetries+1)
+}
+
+func TestSendRateLimitedStop(t *testing.T) {
+	webhook := newTestWebhook(1, "60")
+	defer webhook.Close()
+
+	stop := make(chan struct{})
+	s := newTestSender(t, webhook.URL, stop)
+
+	done := make(chan error)
+	go func() { done <- s.Send("notification") }()
+
+	time.Sleep(100 * time.Millisecond)
+	close(stop)
+
+	select {
+	case err := <-done:
+		assert.NotNil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send should stop waiting for the rate limit once the notifier stops")
+	}
+}
@@ -17,29 +17,62 @@ package webhook
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
+	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 
+	"github.com/coreos/clair/database"
 	"github.com/coreos/clair/ext/notification"
+	"github.com/coreos/clair/pkg/pagination"
 )
 
-const timeout = 5 * time.Second
+const (
+	timeout = 5 * time.Second
+
+	// defaultSignatureHeader is the header carrying the signature of the
+	// notification bodies when none is configured.
+	defaultSignatureHeader = "X-Clair-Signature"
+)
 
 type sender struct {
-	endpoint string
-	client   *http.Client
+	endpoints []endpoint
+	template  *template.Template
+	datastore database.Datastore
+
+	secret          []byte
+	signatureHeader string
+
+	// delivered keeps, per notification, the endpoints that have already
+	// received it so that retries only target the endpoints that failed.
+	deliveredM sync.Mutex
+	delivered  map[string]map[int]bool
+}
+
+type endpoint struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
 }
 
 // Config represents the configuration of a Webhook Sender.
+//
+// The top-level Endpoint and its PKI configuration are kept for backward
+// compatibility and are used as an additional endpoint when set.
 type Config struct {
 	Endpoint   string
 	ServerName string
@@ -47,61 +80,176 @@ type Config struct {
 	KeyFile    string
 	CAFile     string
 	Proxy      string
+	Endpoints  []EndpointConfig
+
+	// Template is an optional text/template that renders the body of the
+	// notifications instead of the default JSON envelope. Its data is a
+	// TemplateData and its "json" function encodes a value as JSON.
+	Template string
+
+	// Secret is an optional key shared with the endpoints. When set, the
+	// HMAC-SHA256 of the body of every notification is sent in the
+	// SignatureHeader header (X-Clair-Signature by default) as
+	// "sha256=<hex digest>".
+	Secret          string
+	SignatureHeader string
+}
+
+// TemplateData is the data given to the template of a Webhook Sender.
+//
+// New and Old are the vulnerabilities of the notification. They are nil when
+// the notification has none or when its content cannot be looked up.
+type TemplateData struct {
+	Name string
+	New  *database.Vulnerability
+	Old  *database.Vulnerability
+}
+
+// EndpointConfig represents the configuration of one of the endpoints to
+// which a Webhook Sender delivers notifications.
+type EndpointConfig struct {
+	Endpoint   string
+	Headers    map[string]string
+	ServerName string
+	CertFile   string
+	KeyFile    string
+	CAFile     string
 }
 
 func init() {
 	notification.RegisterSender("webhook", &sender{})
 }
 
-func (s *sender) Configure(config *notification.Config) (bool, error) {
-	// Get configuration
-	var httpConfig Config
+// loadConfig decodes the configuration of the Webhook Sender, which is absent
+// if the notifier has no "http" parameters.
+func loadConfig(config *notification.Config) (*Config, error) {
 	if config == nil {
-		return false, nil
+		return nil, nil
 	}
 	if _, ok := config.Params["http"]; !ok {
-		return false, nil
+		return nil, nil
 	}
+
+	var httpConfig Config
 	yamlConfig, err := yaml.Marshal(config.Params["http"])
 	if err != nil {
-		return false, errors.New("invalid configuration")
+		return nil, errors.New("invalid configuration")
 	}
 	err = yaml.Unmarshal(yamlConfig, &httpConfig)
 	if err != nil {
-		return false, errors.New("invalid configuration")
+		return nil, errors.New("invalid configuration")
 	}
 
-	// Validate endpoint URL.
-	if httpConfig.Endpoint == "" {
-		return false, nil
+	return &httpConfig, nil
+}
+
+// parseTemplate parses the template of the notification bodies, which is nil
+// if text is empty.
+func parseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
 	}
-	if _, err := url.ParseRequestURI(httpConfig.Endpoint); err != nil {
-		return false, fmt.Errorf("could not parse endpoint URL: %s\n", err)
+
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{"json": toJSON}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template: %s", err)
 	}
-	s.endpoint = httpConfig.Endpoint
 
-	// Setup HTTP client.
-	transport := &http.Transport{}
-	s.client = &http.Client{
-		Transport: transport,
-		Timeout:   timeout,
+	return tmpl, nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// ValidateConfig ensures that the template of the configuration, if any, can
+// be parsed.
+func (s *sender) ValidateConfig(config *notification.Config) error {
+	httpConfig, err := loadConfig(config)
+	if err != nil || httpConfig == nil {
+		return err
 	}
 
-	// Initialize TLS.
-	transport.TLSClientConfig, err = loadTLSClientConfig(&httpConfig)
+	_, err = parseTemplate(httpConfig.Template)
+	return err
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	httpConfig, err := loadConfig(config)
 	if err != nil {
-		return false, fmt.Errorf("could not initialize client cert auth: %s\n", err)
+		return false, err
+	}
+	if httpConfig == nil {
+		return false, nil
 	}
 
-	// Set proxy.
+	endpointConfigs := httpConfig.Endpoints
+	if httpConfig.Endpoint != "" {
+		endpointConfigs = append([]EndpointConfig{{
+			Endpoint:   httpConfig.Endpoint,
+			ServerName: httpConfig.ServerName,
+			CertFile:   httpConfig.CertFile,
+			KeyFile:    httpConfig.KeyFile,
+			CAFile:     httpConfig.CAFile,
+		}}, endpointConfigs...)
+	}
+	if len(endpointConfigs) == 0 {
+		return false, nil
+	}
+
+	// Parse proxy URL.
+	var proxyURL *url.URL
 	if httpConfig.Proxy != "" {
-		proxyURL, err := url.ParseRequestURI(httpConfig.Proxy)
+		proxyURL, err = url.ParseRequestURI(httpConfig.Proxy)
 		if err != nil {
 			return false, fmt.Errorf("could not parse proxy URL: %s\n", err)
 		}
-		transport.Proxy = http.ProxyURL(proxyURL)
 	}
 
+	s.template, err = parseTemplate(httpConfig.Template)
+	if err != nil {
+		return false, err
+	}
+	s.datastore = config.Datastore
+
+	s.secret = []byte(httpConfig.Secret)
+	s.signatureHeader = httpConfig.SignatureHeader
+	if s.signatureHeader == "" {
+		s.signatureHeader = defaultSignatureHeader
+	}
+
+	s.endpoints = make([]endpoint, 0, len(endpointConfigs))
+	for _, endpointConfig := range endpointConfigs {
+		// Validate endpoint URL.
+		if _, err := url.ParseRequestURI(endpointConfig.Endpoint); err != nil {
+			return false, fmt.Errorf("could not parse endpoint URL %q: %s\n", endpointConfig.Endpoint, err)
+		}
+
+		// Setup HTTP client.
+		transport := &http.Transport{}
+		if proxyURL != nil {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+
+		// Initialize TLS.
+		transport.TLSClientConfig, err = loadTLSClientConfig(&endpointConfig)
+		if err != nil {
+			return false, fmt.Errorf("could not initialize client cert auth for %q: %s\n", endpointConfig.Endpoint, err)
+		}
+
+		s.endpoints = append(s.endpoints, endpoint{
+			url:     endpointConfig.Endpoint,
+			headers: endpointConfig.Headers,
+			client: &http.Client{
+				Transport: transport,
+				Timeout:   timeout,
+			},
+		})
+	}
+	s.delivered = make(map[string]map[int]bool)
+
 	return true, nil
 }
 
@@ -111,17 +259,154 @@ type notificationEnvelope struct {
 	}
 }
 
+// Send delivers the notification to every endpoint that has not received it
+// yet. It only succeeds once all the endpoints have received the
+// notification.
 func (s *sender) Send(notificationName string) error {
-	// Marshal notification.
-	jsonNotification, err := json.Marshal(notificationEnvelope{struct{ Name string }{notificationName}})
+	jsonNotification, err := s.newBody(notificationName)
+	if err != nil {
+		return err
+	}
+
+	headers := make(map[string]string)
+	if len(s.secret) > 0 {
+		headers[s.signatureHeader] = sign(s.secret, jsonNotification)
+	}
+
+	var failed []string
+	for i, e := range s.endpoints {
+		if s.isDelivered(notificationName, i) {
+			continue
+		}
+
+		if err := e.send(jsonNotification, headers); err != nil {
+			log.WithError(err).WithFields(log.Fields{"endpoint": e.url, "notification name": notificationName}).Warning("could not send notification to webhook endpoint")
+			failed = append(failed, e.url)
+			continue
+		}
+
+		s.setDelivered(notificationName, i)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("could not send notification to %d/%d endpoints: %s", len(failed), len(s.endpoints), strings.Join(failed, ", "))
+	}
+
+	s.Forget(notificationName)
+	return nil
+}
+
+// Forget drops the endpoints that received the notification, once it was
+// delivered to all of them or once the notifier gave up on it.
+func (s *sender) Forget(notificationName string) {
+	s.deliveredM.Lock()
+	defer s.deliveredM.Unlock()
+
+	delete(s.delivered, notificationName)
+}
+
+func (s *sender) isDelivered(notificationName string, i int) bool {
+	s.deliveredM.Lock()
+	defer s.deliveredM.Unlock()
+
+	return s.delivered[notificationName][i]
+}
+
+func (s *sender) setDelivered(notificationName string, i int) {
+	s.deliveredM.Lock()
+	defer s.deliveredM.Unlock()
+
+	if _, ok := s.delivered[notificationName]; !ok {
+		s.delivered[notificationName] = make(map[int]bool)
+	}
+	s.delivered[notificationName][i] = true
+}
+
+// newBody builds the body of a notification, which is rendered by the template
+// if there is one.
+func (s *sender) newBody(notificationName string) ([]byte, error) {
+	if s.template == nil {
+		// Marshal notification.
+		jsonNotification, err := json.Marshal(notificationEnvelope{struct{ Name string }{notificationName}})
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal: %s", err)
+		}
+		return jsonNotification, nil
+	}
+
+	data, err := s.newTemplateData(notificationName)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	if err := s.template.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("could not render template: %s", err)
+	}
+
+	return body.Bytes(), nil
+}
+
+// newTemplateData looks up the content of a notification when the datastore
+// is available, otherwise only its name is given to the template.
+func (s *sender) newTemplateData(notificationName string) (*TemplateData, error) {
+	data := &TemplateData{Name: notificationName}
+	if s.datastore == nil {
+		return data, nil
+	}
+
+	tx, err := s.datastore.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	noti, ok, err := tx.FindVulnerabilityNotification(notificationName, 1, pagination.FirstPageToken, pagination.FirstPageToken)
 	if err != nil {
-		return fmt.Errorf("could not marshal: %s", err)
+		return nil, err
+	}
+	if !ok {
+		return data, nil
+	}
+
+	if noti.New != nil {
+		data.New = &noti.New.Vulnerability
+	}
+
+	if noti.Old != nil {
+		data.Old = &noti.Old.Vulnerability
+	}
+
+	return data, nil
+}
+
+// sign returns the HMAC-SHA256 signature of the body of a notification.
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// send posts the notification to the endpoint with the given headers, which
+// take precedence over the headers of the endpoint.
+func (e *endpoint) send(jsonNotification []byte, headers map[string]string) error {
+	req, err := http.NewRequest("POST", e.url, bytes.NewBuffer(jsonNotification))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
 	}
 
 	// Send notification via HTTP POST.
-	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewBuffer(jsonNotification))
+	resp, err := e.client.Do(req)
 	if err != nil || resp == nil || (resp.StatusCode != 200 && resp.StatusCode != 201) {
 		if resp != nil {
+			resp.Body.Close()
 			return fmt.Errorf("got status %d, expected 200/201", resp.StatusCode)
 		}
 		return err
@@ -131,11 +416,11 @@ func (s *sender) Send(notificationName string) error {
 	return nil
 }
 
-// loadTLSClientConfig initializes a *tls.Config using the given Config.
+// loadTLSClientConfig initializes a *tls.Config using the given EndpointConfig.
 //
 // If no certificates are given, (nil, nil) is returned.
 // The CA certificate is optional and falls back to the system default.
-func loadTLSClientConfig(cfg *Config) (*tls.Config, error) {
+func loadTLSClientConfig(cfg *EndpointConfig) (*tls.Config, error) {
 	if cfg.CertFile == "" || cfg.KeyFile == "" {
 		return nil, nil
 	}
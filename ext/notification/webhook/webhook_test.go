@@ -0,0 +1,203 @@
+// Copyright 2017 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/notification"
+	"github.com/coreos/clair/pkg/pagination"
+)
+
+// testEndpoint is a webhook endpoint that fails its first failures requests.
+type testEndpoint struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	failures int
+	received []string
+}
+
+func newTestEndpoint(failures int) *testEndpoint {
+	e := &testEndpoint{failures: failures}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		body, _ := ioutil.ReadAll(r.Body)
+		var envelope notificationEnvelope
+		json.Unmarshal(body, &envelope)
+		e.received = append(e.received, envelope.Notification.Name)
+
+		if e.failures > 0 {
+			e.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	return e
+}
+
+func (e *testEndpoint) requests() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.received...)
+}
+
+func newTestSender(t *testing.T, endpoints ...*testEndpoint) *sender {
+	var configs []interface{}
+	for _, e := range endpoints {
+		configs = append(configs, map[string]interface{}{"endpoint": e.URL})
+	}
+
+	s := &sender{}
+	enabled, err := s.Configure(&notification.Config{
+		Params: map[string]interface{}{"http": map[string]interface{}{"endpoints": configs}},
+	})
+	require.Nil(t, err)
+	require.True(t, enabled)
+	return s
+}
+
+func TestSendRetriesFailedEndpoints(t *testing.T) {
+	healthy, flaky := newTestEndpoint(0), newTestEndpoint(1)
+	defer healthy.Close()
+	defer flaky.Close()
+
+	s := newTestSender(t, healthy, flaky)
+
+	// The first attempt only reaches the healthy endpoint.
+	assert.NotNil(t, s.Send("notification"))
+	assert.Equal(t, []string{"notification"}, healthy.requests())
+	assert.Equal(t, []string{"notification"}, flaky.requests())
+	assert.True(t, s.isDelivered("notification", 0))
+	assert.False(t, s.isDelivered("notification", 1))
+
+	// The retry only targets the endpoint that failed.
+	assert.Nil(t, s.Send("notification"))
+	assert.Equal(t, []string{"notification"}, healthy.requests())
+	assert.Equal(t, []string{"notification", "notification"}, flaky.requests())
+
+	// The delivered endpoints are forgotten once all of them received it.
+	assert.Empty(t, s.delivered)
+}
+
+func TestForget(t *testing.T) {
+	healthy, broken := newTestEndpoint(0), newTestEndpoint(100)
+	defer healthy.Close()
+	defer broken.Close()
+
+	s := newTestSender(t, healthy, broken)
+
+	assert.NotNil(t, s.Send("notification"))
+	assert.Contains(t, s.delivered, "notification")
+
+	// Once the notifier gave up, the notification is sent again to every
+	// endpoint when it is renotified.
+	s.Forget("notification")
+	assert.Empty(t, s.delivered)
+
+	assert.NotNil(t, s.Send("notification"))
+	assert.Len(t, healthy.requests(), 2)
+}
+
+func TestSendSignature(t *testing.T) {
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(defaultSignatureHeader)
+	}))
+	defer server.Close()
+
+	s := &sender{}
+	_, err := s.Configure(&notification.Config{
+		Params: map[string]interface{}{"http": map[string]interface{}{"endpoint": server.URL, "secret": "secret"}},
+	})
+	require.Nil(t, err)
+
+	require.Nil(t, s.Send("notification"))
+	body, _ := json.Marshal(notificationEnvelope{struct{ Name string }{"notification"}})
+	assert.Equal(t, sign([]byte("secret"), body), signature)
+}
+
+func TestSendTemplate(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	datastore := &database.MockDatastore{FctBegin: func() (database.Session, error) {
+		return &database.MockSession{
+			FctRollback: func() error { return nil },
+			FctFindVulnerabilityNotification: func(name string, limit int, oldPage, newPage pagination.Token) (database.VulnerabilityNotificationWithVulnerable, bool, error) {
+				if name != "notification" {
+					return database.VulnerabilityNotificationWithVulnerable{}, false, nil
+				}
+
+				return database.VulnerabilityNotificationWithVulnerable{
+					New: &database.PagedVulnerableAncestries{Vulnerability: database.Vulnerability{
+						Name:     "CVE-2018-0001",
+						Severity: database.HighSeverity,
+					}},
+				}, true, nil
+			},
+		}, nil
+	}}
+
+	s := &sender{}
+	_, err := s.Configure(&notification.Config{
+		Datastore: datastore,
+		Params: map[string]interface{}{"http": map[string]interface{}{
+			"endpoint": server.URL,
+			"template": `{"text": {{ json (printf "%s: %s (%s)" .Name .New.Name .New.Severity) }}, "old": {{ json .Old }}}`,
+		}},
+	})
+	require.Nil(t, err)
+
+	require.Nil(t, s.Send("notification"))
+	assert.Equal(t, `{"text": "notification: CVE-2018-0001 (High)", "old": null}`, string(body))
+
+	// The template only gets the name of the notifications that cannot be
+	// looked up, so that rendering their missing vulnerability fails.
+	assert.NotNil(t, s.Send("unknown"))
+}
+
+func TestValidateConfigTemplate(t *testing.T) {
+	s := &sender{}
+	for template, valid := range map[string]bool{
+		"":                      true,
+		`{{ json .Name }}`:      true,
+		`{{ json .Name }`:       false,
+		`{{ .Name | unknown }}`: false,
+	} {
+		err := s.ValidateConfig(&notification.Config{
+			Params: map[string]interface{}{"http": map[string]interface{}{"endpoint": "https://example.com", "template": template}},
+		})
+		assert.Equal(t, valid, err == nil, "%q: %v", template, err)
+	}
+
+	// The notifier without a webhook has no template to validate.
+	assert.Nil(t, s.ValidateConfig(&notification.Config{}))
+}
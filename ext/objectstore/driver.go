@@ -0,0 +1,196 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package objectstore exposes functions to dynamically register backends
+// reading the layers stored in the buckets of object storage services, by the
+// scheme of their URIs, e.g. "s3://bucket/key".
+package objectstore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/logutil"
+)
+
+var (
+	// ErrObjectNotFound is returned when the object does not exist, or its URI
+	// is malformed.
+	ErrObjectNotFound = commonerr.NewBadRequestError("could not find the object in the bucket")
+
+	// ErrUnauthorized is returned when the storage service refuses the
+	// credentials of a request, or when it needs credentials that were not
+	// given.
+	ErrUnauthorized = commonerr.NewBadRequestError("could not authenticate with the object storage")
+
+	backendsM sync.RWMutex
+	backends  = make(map[string]Backend)
+)
+
+// Credentials are the credentials of the objects of a request, each backend
+// using the ones of its service. They are never logged.
+type Credentials struct {
+	// AccessKeyID and SecretAccessKey are the keys signing the S3 requests,
+	// along with the SessionToken of temporary credentials.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Region is the region of the S3 bucket.
+	Region string
+
+	// Endpoint, e.g. "https://minio.example.com", replaces the endpoint of the
+	// service, such as the ones of the S3-compatible stores.
+	Endpoint string
+
+	// Token is an OAuth 2.0 access token authorizing the GCS requests.
+	Token string
+}
+
+// Backend reads the objects of an object storage service.
+type Backend interface {
+	// Open returns the content of the object with the given key in the given
+	// bucket, which must be closed. It returns ErrObjectNotFound or
+	// ErrUnauthorized when the object cannot be read, or
+	// commonerr.ErrCouldNotDownload when it might be read later.
+	Open(ctx context.Context, bucket, key string, credentials Credentials) (io.ReadCloser, error)
+}
+
+// RegisterBackend makes a Backend available for the URIs with the provided
+// scheme, e.g. "s3".
+//
+// If called twice with the same scheme, the scheme is blank, or if the
+// provided Backend is nil, this function panics.
+func RegisterBackend(scheme string, b Backend) {
+	if scheme == "" {
+		panic("objectstore: could not register a Backend with an empty scheme")
+	}
+
+	if b == nil {
+		panic("objectstore: could not register a nil Backend")
+	}
+
+	backendsM.Lock()
+	defer backendsM.Unlock()
+
+	// Enforce lowercase schemes, as the URIs are parsed as such.
+	scheme = strings.ToLower(scheme)
+
+	if _, dup := backends[scheme]; dup {
+		panic("objectstore: RegisterBackend called twice for " + scheme)
+	}
+
+	backends[scheme] = b
+}
+
+// Backends returns the list of the registered Backends, by scheme.
+func Backends() map[string]Backend {
+	backendsM.RLock()
+	defer backendsM.RUnlock()
+
+	ret := make(map[string]Backend)
+	for k, v := range backends {
+		ret[k] = v
+	}
+
+	return ret
+}
+
+// UnregisterBackend removes the Backend of a particular scheme from the list.
+func UnregisterBackend(scheme string) {
+	backendsM.Lock()
+	defer backendsM.Unlock()
+
+	delete(backends, scheme)
+}
+
+// IsObjectURI returns whether the given path of a layer is the URI of an
+// object, whose scheme is the one of a registered Backend.
+func IsObjectURI(path string) bool {
+	_, _, _, ok := parseURI(path)
+	return ok
+}
+
+// Open returns the content of the object with the given URI, e.g.
+// "s3://bucket/key", read by the Backend of its scheme with the given
+// credentials.
+func Open(ctx context.Context, uri string, credentials Credentials) (io.ReadCloser, error) {
+	backend, bucket, key, ok := parseURI(uri)
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+
+	if bucket == "" || key == "" {
+		return nil, ErrObjectNotFound
+	}
+
+	return backend.Open(ctx, bucket, key, credentials)
+}
+
+// parseURI returns the Backend of the scheme of the URI, and its bucket and
+// key.
+func parseURI(uri string) (Backend, string, string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || u.Opaque != "" {
+		return nil, "", "", false
+	}
+
+	backend, ok := Backends()[u.Scheme]
+	if !ok {
+		return nil, "", "", false
+	}
+
+	return backend, u.Host, strings.TrimPrefix(u.Path, "/"), true
+}
+
+// Do sends a request of a Backend bound to ctx, and returns the body of its
+// response when it succeeded, or the error of ctx once it is done. The
+// refusals are returned as ErrUnauthorized and the other client errors as
+// ErrObjectNotFound, while the server errors and the rate limits may be
+// temporary.
+func Do(ctx context.Context, req *http.Request) (io.ReadCloser, error) {
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}}
+	r, err := client.Do(req.WithContext(ctx))
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if err == nil {
+			r.Body.Close()
+		}
+		return nil, ctxErr
+	}
+
+	if err != nil {
+		logutil.FromContext(ctx).WithError(err).Error("could not download object")
+		return nil, commonerr.ErrCouldNotDownload
+	}
+
+	if r.StatusCode/100 == 2 {
+		return r.Body, nil
+	}
+
+	r.Body.Close()
+	err = ErrObjectNotFound
+	switch {
+	case r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden:
+		err = ErrUnauthorized
+	case r.StatusCode >= http.StatusInternalServerError || r.StatusCode == http.StatusTooManyRequests:
+		err = commonerr.ErrCouldNotDownload
+	}
+	logutil.FromContext(ctx).WithError(err).WithField("status code", r.StatusCode).Error("could not download object: expected 2XX")
+	return nil, err
+}
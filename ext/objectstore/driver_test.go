@@ -0,0 +1,112 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstore
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/pkg/commonerr"
+)
+
+type testBackend struct {
+	bucket, key string
+	credentials Credentials
+}
+
+func (b *testBackend) Open(ctx context.Context, bucket, key string, credentials Credentials) (io.ReadCloser, error) {
+	b.bucket, b.key, b.credentials = bucket, key, credentials
+	return ioutil.NopCloser(strings.NewReader("layer")), nil
+}
+
+func TestOpen(t *testing.T) {
+	b := &testBackend{}
+	RegisterBackend("test", b)
+	defer UnregisterBackend("test")
+
+	assert.True(t, IsObjectURI("test://bucket/path/to/layer.tar"))
+	assert.False(t, IsObjectURI("unknown://bucket/layer.tar"))
+	assert.False(t, IsObjectURI("/var/lib/layer.tar"))
+	assert.False(t, IsObjectURI("test:bucket/layer.tar"))
+
+	r, err := Open(context.Background(), "test://bucket/path/to/layer.tar", Credentials{Token: "token"})
+	if assert.Nil(t, err) {
+		r.Close()
+		assert.Equal(t, "bucket", b.bucket)
+		assert.Equal(t, "path/to/layer.tar", b.key)
+		assert.Equal(t, "token", b.credentials.Token)
+	}
+
+	for _, uri := range []string{"test://bucket", "test://bucket/", "test:///layer.tar", "unknown://bucket/layer.tar"} {
+		_, err := Open(context.Background(), uri, Credentials{})
+		assert.Equal(t, ErrObjectNotFound, err, uri)
+	}
+}
+
+func TestRegisterBackend(t *testing.T) {
+	b := &testBackend{}
+	RegisterBackend("Test", b)
+	defer UnregisterBackend("test")
+
+	assert.Equal(t, b, Backends()["test"])
+	assert.Panics(t, func() { RegisterBackend("test", b) })
+	assert.Panics(t, func() { RegisterBackend("", b) })
+	assert.Panics(t, func() { RegisterBackend("other", nil) })
+}
+
+func TestDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			io.WriteString(w, "layer")
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	for path, expected := range map[string]error{
+		"/ok":          nil,
+		"/forbidden":   ErrUnauthorized,
+		"/unavailable": commonerr.ErrCouldNotDownload,
+		"/missing":     ErrObjectNotFound,
+	} {
+		req, _ := http.NewRequest("GET", server.URL+path, nil)
+		body, err := Do(context.Background(), req)
+		assert.Equal(t, expected, err, path)
+		if err == nil {
+			content, _ := ioutil.ReadAll(body)
+			body.Close()
+			assert.Equal(t, "layer", string(content))
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequest("GET", server.URL+"/ok", nil)
+	_, err := Do(ctx, req)
+	assert.Equal(t, context.Canceled, err)
+}
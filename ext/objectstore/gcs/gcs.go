@@ -0,0 +1,62 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcs implements an object storage backend reading the layers of the
+// "gs://bucket/key" URIs from Google Cloud Storage, through its JSON API.
+//
+// The requests are authorized with the OAuth 2.0 access token given with the
+// request, and are anonymous otherwise, which only reads the public objects.
+package gcs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/coreos/clair/ext/objectstore"
+)
+
+const defaultEndpoint = "https://storage.googleapis.com"
+
+type backend struct{}
+
+func init() {
+	objectstore.RegisterBackend("gs", &backend{})
+}
+
+func (b *backend) Open(ctx context.Context, bucket, key string, credentials objectstore.Credentials) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", objectURL(bucket, key, credentials), nil)
+	if err != nil {
+		return nil, objectstore.ErrObjectNotFound
+	}
+
+	if credentials.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+credentials.Token)
+	}
+
+	return objectstore.Do(ctx, req)
+}
+
+// objectURL returns the URL downloading the content of the object, whose
+// name is a single escaped segment of the path.
+func objectURL(bucket, key string, credentials objectstore.Credentials) string {
+	endpoint := defaultEndpoint
+	if credentials.Endpoint != "" {
+		endpoint = strings.TrimSuffix(credentials.Endpoint, "/")
+	}
+
+	return endpoint + "/storage/v1/b/" + url.PathEscape(bucket) + "/o/" + url.PathEscape(key) + "?alt=media"
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcs
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/ext/objectstore"
+)
+
+func TestObjectURL(t *testing.T) {
+	assert.Equal(t, "https://storage.googleapis.com/storage/v1/b/bucket/o/layers%2Flayer.tar?alt=media", objectURL("bucket", "layers/layer.tar", objectstore.Credentials{}))
+}
+
+func TestOpen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Authorization") != "Bearer token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.RawPath != "/storage/v1/b/bucket/o/layers%2Flayer.tar" || r.URL.Query().Get("alt") != "media":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			io.WriteString(w, "layer")
+		}
+	}))
+	defer server.Close()
+
+	r, err := objectstore.Open(context.Background(), "gs://bucket/layers/layer.tar", objectstore.Credentials{Token: "token", Endpoint: server.URL})
+	if assert.Nil(t, err) {
+		content, _ := ioutil.ReadAll(r)
+		r.Close()
+		assert.Equal(t, "layer", string(content))
+	}
+
+	_, err = objectstore.Open(context.Background(), "gs://bucket/layers/layer.tar", objectstore.Credentials{Endpoint: server.URL})
+	assert.Equal(t, objectstore.ErrUnauthorized, err)
+
+	_, err = objectstore.Open(context.Background(), "gs://bucket/missing.tar", objectstore.Credentials{Token: "token", Endpoint: server.URL})
+	assert.Equal(t, objectstore.ErrObjectNotFound, err)
+}
@@ -0,0 +1,182 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package s3 implements an object storage backend reading the layers of the
+// "s3://bucket/key" URIs from Amazon S3, or from an S3-compatible store.
+//
+// The requests are signed with AWS Signature Version 4 when an access key is
+// given with the request, or found in the AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY environment variables for the requests to Amazon S3,
+// and are anonymous otherwise.
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coreos/clair/ext/objectstore"
+)
+
+const (
+	defaultRegion = "us-east-1"
+
+	// emptyPayloadHash is the SHA-256 of the empty body of the GET requests.
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	timeFormat = "20060102T150405Z"
+	dateFormat = "20060102"
+)
+
+type backend struct{}
+
+func init() {
+	objectstore.RegisterBackend("s3", &backend{})
+}
+
+func (b *backend) Open(ctx context.Context, bucket, key string, credentials objectstore.Credentials) (io.ReadCloser, error) {
+	credentials = withEnvironment(credentials)
+
+	req, err := http.NewRequest("GET", objectURL(bucket, key, credentials), nil)
+	if err != nil {
+		return nil, objectstore.ErrObjectNotFound
+	}
+
+	if credentials.AccessKeyID != "" {
+		sign(req, credentials, time.Now())
+	}
+
+	return objectstore.Do(ctx, req)
+}
+
+// withEnvironment completes the credentials that were not given with the
+// request with the ones of the environment. The access key of the environment
+// is only used for Amazon S3, never sent to the endpoint given with a request.
+func withEnvironment(credentials objectstore.Credentials) objectstore.Credentials {
+	if credentials.AccessKeyID == "" && credentials.Endpoint == "" {
+		credentials.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		credentials.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		credentials.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if credentials.Region == "" {
+			credentials.Region = os.Getenv(env)
+		}
+	}
+
+	if credentials.Region == "" {
+		credentials.Region = defaultRegion
+	}
+
+	return credentials
+}
+
+// objectURL returns the virtual-hosted URL of the object, or its path-style
+// URL on the endpoint of the credentials, which the S3-compatible stores
+// generally expect.
+func objectURL(bucket, key string, credentials objectstore.Credentials) string {
+	if credentials.Endpoint != "" {
+		return strings.TrimSuffix(credentials.Endpoint, "/") + "/" + bucket + "/" + escapePath(key)
+	}
+
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, credentials.Region, escapePath(key))
+}
+
+// escapePath escapes each segment of the key as S3 expects it, leaving the
+// slashes as is.
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.Replace(url.QueryEscape(segment), "+", "%20", -1)
+	}
+	return strings.Join(segments, "/")
+}
+
+// sign signs the request with AWS Signature Version 4, at the given time.
+func sign(req *http.Request, credentials objectstore.Credentials, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("X-Amz-Date", now.Format(timeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	// The host, and the range of partial requests, are signed along with the
+	// x-amz-* headers.
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || name == "range" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders string
+	for _, name := range names {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(dateFormat), credentials.Region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format(timeFormat),
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), now.Format(dateFormat))
+	for _, part := range []string{credentials.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
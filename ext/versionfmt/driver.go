@@ -18,6 +18,7 @@ package versionfmt
 
 import (
 	"errors"
+	"strings"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
@@ -42,6 +43,11 @@ var (
 
 	parsersM sync.Mutex
 	parsers  = make(map[string]Parser)
+
+	// namespaceFormats are the formats overriding the ones of the namespaces,
+	// by namespace or distribution name.
+	namespaceFormatsM sync.RWMutex
+	namespaceFormats  map[string]string
 )
 
 // Parser represents any format that can compare two version strings.
@@ -99,6 +105,53 @@ func GetParser(name string) (p Parser, exists bool) {
 	return
 }
 
+// SetNamespaceFormats replaces the formats used to compare the versions of the
+// features of the namespaces instead of the ones detected with them, by
+// namespace name, e.g. "alpine:v3.18", or by distribution name, the part of
+// the namespace name before the colon, e.g. "alpine".
+//
+// It returns ErrUnknownVersionFormat, and keeps the current formats, when any
+// of the formats is not registered.
+func SetNamespaceFormats(formats map[string]string) error {
+	for _, format := range formats {
+		if _, exists := GetParser(format); !exists {
+			return ErrUnknownVersionFormat
+		}
+	}
+
+	copied := make(map[string]string, len(formats))
+	for namespace, format := range formats {
+		copied[namespace] = format
+	}
+
+	namespaceFormatsM.Lock()
+	defer namespaceFormatsM.Unlock()
+
+	namespaceFormats = copied
+	return nil
+}
+
+// NamespaceFormat returns the format of the versions of the features of a
+// namespace: the one set for its name, or else for its distribution, by
+// SetNamespaceFormats, and otherwise the given format, detected with the
+// namespace.
+func NamespaceFormat(namespace, format string) string {
+	namespaceFormatsM.RLock()
+	defer namespaceFormatsM.RUnlock()
+
+	if f, ok := namespaceFormats[namespace]; ok {
+		return f
+	}
+
+	if i := strings.Index(namespace, ":"); i >= 0 {
+		if f, ok := namespaceFormats[namespace[:i]]; ok {
+			return f
+		}
+	}
+
+	return format
+}
+
 // Valid is a helper function that will return an error if the version fails to
 // validate with a given format.
 func Valid(format, version string) error {
@@ -139,6 +192,40 @@ func InRange(format, version, versionRange string) (bool, error) {
 	return in, err
 }
 
+// InRangeSince is a helper function that checks if `version` is in
+// `versionRange` and not lower than `introducedIn`, which has no lower bound
+// when empty.
+func InRangeSince(format, version, introducedIn, versionRange string) (bool, error) {
+	in, err := InRange(format, version, versionRange)
+	if err != nil || !in || introducedIn == "" {
+		return in, err
+	}
+
+	cmp, err := Compare(format, version, introducedIn)
+	if err != nil {
+		return false, err
+	}
+
+	return cmp >= 0, nil
+}
+
+// InAffectedRange is a helper function that checks if `version` is in
+// `versionRange`, not lower than `introducedIn` and not greater than
+// `lastAffected`, which have no bound when they are empty.
+func InAffectedRange(format, version, introducedIn, lastAffected, versionRange string) (bool, error) {
+	in, err := InRangeSince(format, version, introducedIn, versionRange)
+	if err != nil || !in || lastAffected == "" {
+		return in, err
+	}
+
+	cmp, err := Compare(format, version, lastAffected)
+	if err != nil {
+		return false, err
+	}
+
+	return cmp <= 0, nil
+}
+
 // GetFixedIn is a helper function that computes the next fixed in version given
 // a affected version range `rangeA`.
 func GetFixedIn(format, rangeA string) (string, error) {
@@ -0,0 +1,89 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versionfmt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lengthParser compares the versions by length, unlike the lexicographic order
+// of stringParser, to tell which one is used.
+type lengthParser struct{ stringParser }
+
+func (p lengthParser) Compare(a, b string) (int, error) {
+	switch {
+	case len(a) < len(b):
+		return -1, nil
+	case len(a) > len(b):
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (p lengthParser) InRange(versionA, rangeB string) (bool, error) {
+	cmp, err := p.Compare(versionA, rangeB)
+	return cmp < 0, err
+}
+
+type stringParser struct{}
+
+func (p stringParser) Valid(v string) bool { return v != "" }
+
+func (p stringParser) Compare(a, b string) (int, error) { return strings.Compare(a, b), nil }
+
+func (p stringParser) InRange(versionA, rangeB string) (bool, error) {
+	return versionA < rangeB, nil
+}
+
+func (p stringParser) GetFixedIn(rangeA string) (string, error) { return rangeA, nil }
+
+func init() {
+	RegisterParser("test-string", stringParser{})
+	RegisterParser("test-length", lengthParser{})
+}
+
+func TestNamespaceFormat(t *testing.T) {
+	defer SetNamespaceFormats(nil)
+
+	// The detected format is used by default.
+	assert.Equal(t, "test-string", NamespaceFormat("alpine:v3.18", "test-string"))
+
+	assert.Equal(t, ErrUnknownVersionFormat, SetNamespaceFormats(map[string]string{"alpine": "unknown"}))
+	assert.Equal(t, "test-string", NamespaceFormat("alpine:v3.18", "test-string"))
+
+	assert.Nil(t, SetNamespaceFormats(map[string]string{
+		"alpine":        "test-length",
+		"debian:11":     "test-length",
+		"debian":        "test-string",
+		"alpine:v3.17":  "test-string",
+		"ubuntu:bionic": "test-length",
+	}))
+	assert.Equal(t, "test-length", NamespaceFormat("alpine:v3.18", "test-string"))
+	assert.Equal(t, "test-string", NamespaceFormat("alpine:v3.17", "test-length"))
+	assert.Equal(t, "test-length", NamespaceFormat("debian:11", "test-string"))
+	assert.Equal(t, "test-string", NamespaceFormat("debian:12", "test-length"))
+	assert.Equal(t, "test-string", NamespaceFormat("ubuntu:focal", "test-string"))
+
+	// "10" is lower than "9" as a string, but not by length.
+	in, err := InRange(NamespaceFormat("debian:12", "test-length"), "10", "9")
+	assert.Nil(t, err)
+	assert.True(t, in)
+	in, err = InRange(NamespaceFormat("alpine:v3.18", "test-string"), "10", "9")
+	assert.Nil(t, err)
+	assert.False(t, in)
+}
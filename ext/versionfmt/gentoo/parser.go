@@ -0,0 +1,227 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gentoo implements a versionfmt.Parser for version numbers used in
+// Gentoo packages, as specified by the Package Manager Specification.
+package gentoo
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/coreos/clair/ext/versionfmt"
+)
+
+// ParserName is the name by which the gentoo parser is registered.
+const ParserName = "gentoo"
+
+var (
+	versionRegexp = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)*)([a-z]?)((?:_(?:alpha|beta|pre|rc|p)[0-9]*)*)(?:-r([0-9]+))?$`)
+	suffixRegexp  = regexp.MustCompile(`_(alpha|beta|pre|rc|p)([0-9]*)`)
+
+	// suffixRanks orders the suffixes, a release without suffix being between
+	// _rc and _p.
+	suffixRanks = map[string]int{
+		"alpha": -4,
+		"beta":  -3,
+		"pre":   -2,
+		"rc":    -1,
+		"p":     1,
+	}
+)
+
+type suffix struct {
+	rank   int
+	number string
+}
+
+type version struct {
+	numbers  []string
+	letter   string
+	suffixes []suffix
+	revision string
+
+	// special is set to versionfmt.MinVersion or versionfmt.MaxVersion.
+	special string
+}
+
+var (
+	minVersion = version{special: versionfmt.MinVersion}
+	maxVersion = version{special: versionfmt.MaxVersion}
+)
+
+func newVersion(str string) (version, error) {
+	str = strings.TrimSpace(str)
+
+	if len(str) == 0 {
+		return version{}, errors.New("Version string is empty")
+	}
+
+	// Max/Min versions
+	if str == versionfmt.MaxVersion {
+		return maxVersion, nil
+	}
+	if str == versionfmt.MinVersion {
+		return minVersion, nil
+	}
+
+	match := versionRegexp.FindStringSubmatch(str)
+	if match == nil {
+		return version{}, errors.New("version does not follow the Gentoo version format")
+	}
+
+	v := version{
+		numbers:  strings.Split(match[1], "."),
+		letter:   match[2],
+		revision: match[4],
+	}
+
+	for _, s := range suffixRegexp.FindAllStringSubmatch(match[3], -1) {
+		v.suffixes = append(v.suffixes, suffix{suffixRanks[s[1]], s[2]})
+	}
+
+	return v, nil
+}
+
+type parser struct{}
+
+func (p parser) Valid(str string) bool {
+	_, err := newVersion(str)
+	return err == nil
+}
+
+func (p parser) InRange(versionA, rangeB string) (bool, error) {
+	cmp, err := p.Compare(versionA, rangeB)
+	if err != nil {
+		return false, err
+	}
+	return cmp < 0, nil
+}
+
+func (p parser) GetFixedIn(fixedIn string) (string, error) {
+	return fixedIn, nil
+}
+
+// Compare function compares two Gentoo versions, following the algorithm of
+// the section "Version Comparison" of the Package Manager Specification.
+func (p parser) Compare(a, b string) (int, error) {
+	v1, err := newVersion(a)
+	if err != nil {
+		return 0, err
+	}
+
+	v2, err := newVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	// Max/Min comparison
+	if v1.special != "" || v2.special != "" {
+		switch {
+		case v1.special == v2.special:
+			return 0, nil
+		case v1.special == versionfmt.MinVersion || v2.special == versionfmt.MaxVersion:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	}
+
+	if c := compareNumbers(v1.numbers, v2.numbers); c != 0 {
+		return c, nil
+	}
+
+	if c := strings.Compare(v1.letter, v2.letter); c != 0 {
+		return c, nil
+	}
+
+	if c := compareSuffixes(v1.suffixes, v2.suffixes); c != 0 {
+		return c, nil
+	}
+
+	return compareDigits(v1.revision, v2.revision), nil
+}
+
+// compareNumbers compares the numeric components of two versions. The first
+// component is compared as an integer, while the next ones are compared as
+// strings when they have a leading zero, as in a decimal part.
+func compareNumbers(n1, n2 []string) int {
+	if c := compareDigits(n1[0], n2[0]); c != 0 {
+		return c
+	}
+
+	for i := 1; i < len(n1) && i < len(n2); i++ {
+		var c int
+		if strings.HasPrefix(n1[i], "0") || strings.HasPrefix(n2[i], "0") {
+			c = strings.Compare(strings.TrimRight(n1[i], "0"), strings.TrimRight(n2[i], "0"))
+		} else {
+			c = compareDigits(n1[i], n2[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return compareInt(len(n1), len(n2))
+}
+
+// compareSuffixes compares the suffixes of two versions. When a version has
+// more suffixes than the other, it is more recent only if its next suffix is a
+// patch level.
+func compareSuffixes(s1, s2 []suffix) int {
+	for i := 0; i < len(s1) && i < len(s2); i++ {
+		if c := compareInt(s1[i].rank, s2[i].rank); c != 0 {
+			return c
+		}
+
+		if c := compareDigits(s1[i].number, s2[i].number); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(s1) > len(s2):
+		return compareInt(s1[len(s2)].rank, 0)
+	case len(s1) < len(s2):
+		return compareInt(0, s2[len(s1)].rank)
+	}
+
+	return 0
+}
+
+// compareDigits compares two strings of digits as integers, an empty string
+// being 0.
+func compareDigits(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if c := compareInt(len(a), len(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	versionfmt.RegisterParser(ParserName, parser{})
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gentoo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/ext/versionfmt"
+)
+
+const (
+	LESS    = -1
+	EQUAL   = 0
+	GREATER = 1
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		str string
+		ver version
+		err bool
+	}{
+		{"1.2.3", version{numbers: []string{"1", "2", "3"}}, false},
+		{"1.0.2k", version{numbers: []string{"1", "0", "2"}, letter: "k"}, false},
+		{"2.27-r6", version{numbers: []string{"2", "27"}, revision: "6"}, false},
+		{"1.0_rc1_p2", version{numbers: []string{"1", "0"}, suffixes: []suffix{{-1, "1"}, {1, "2"}}}, false},
+		{"5.4_beta", version{numbers: []string{"5", "4"}, suffixes: []suffix{{-3, ""}}}, false},
+		{versionfmt.MaxVersion, maxVersion, false},
+		{"", version{}, true},
+		{"1.", version{}, true},
+		{"a1.0", version{}, true},
+		{"1.0-1", version{}, true},
+		{"1.0_foo", version{}, true},
+		{"1.0ab", version{}, true},
+	}
+
+	for _, c := range cases {
+		v, err := newVersion(c.str)
+
+		if c.err {
+			assert.Error(t, err, "When parsing '%s'", c.str)
+		} else {
+			assert.Nil(t, err, "When parsing '%s'", c.str)
+		}
+		assert.Equal(t, c.ver, v, "When parsing '%s'", c.str)
+	}
+}
+
+func TestParseAndCompare(t *testing.T) {
+	cases := []struct {
+		v1       string
+		expected int
+		v2       string
+	}{
+		{"1.0", EQUAL, "1.0"},
+		{"1.0", EQUAL, "1.0-r0"},
+		{"01.0", EQUAL, "1.0"},
+		{"1.0", LESS, "1.0.0"},
+		{"1.2", LESS, "1.10"},
+		{"1.01", LESS, "1.1"},
+		{"1.010", EQUAL, "1.01"},
+		{"1.0", LESS, "1.0a"},
+		{"1.0a", LESS, "1.0b"},
+		{"1.0_alpha", LESS, "1.0_beta"},
+		{"1.0_beta", LESS, "1.0_pre"},
+		{"1.0_pre", LESS, "1.0_rc"},
+		{"1.0_rc", LESS, "1.0"},
+		{"1.0", LESS, "1.0_p"},
+		{"1.0_rc1", LESS, "1.0_rc2"},
+		{"1.0_rc", EQUAL, "1.0_rc0"},
+		{"1.0_rc1", LESS, "1.0_rc1_p1"},
+		{"1.0_rc1_beta", LESS, "1.0_rc1"},
+		{"1.0_p1", LESS, "1.0_p1-r1"},
+		{"1.0-r2", LESS, "1.0-r10"},
+		{"1.0.2k", LESS, "1.0.2l"},
+		{"1.0", LESS, versionfmt.MaxVersion},
+		{versionfmt.MinVersion, LESS, "0"},
+	}
+
+	var p parser
+	for _, c := range cases {
+		cmp, err := p.Compare(c.v1, c.v2)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, cmp, "%s vs. %s, = %d, expected %d", c.v1, c.v2, cmp, c.expected)
+
+		cmp, err = p.Compare(c.v2, c.v1)
+		assert.Nil(t, err)
+		assert.Equal(t, -c.expected, cmp, "%s vs. %s, = %d, expected %d", c.v2, c.v1, cmp, -c.expected)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gomod implements a versionfmt.Parser for the versions of the Go
+// modules.
+//
+// The module versions are semantic versions with a leading "v", including the
+// pseudo-versions of untagged commits and the "+incompatible" suffix, and are
+// compared as such. They have their own format so that the namespace of the Go
+// modules of a layer is told apart from the npm one.
+package gomod
+
+import (
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/semver"
+)
+
+// ParserName is the name by which the gomod parser is registered.
+const ParserName = "gomod"
+
+type parser struct{}
+
+func (p parser) Valid(str string) bool {
+	return versionfmt.Valid(semver.ParserName, str) == nil
+}
+
+func (p parser) Compare(a, b string) (int, error) {
+	return versionfmt.Compare(semver.ParserName, a, b)
+}
+
+func (p parser) InRange(versionA, rangeB string) (bool, error) {
+	cmp, err := p.Compare(versionA, rangeB)
+	if err != nil {
+		return false, err
+	}
+	return cmp < 0, nil
+}
+
+func (p parser) GetFixedIn(fixedIn string) (string, error) {
+	return fixedIn, nil
+}
+
+func init() {
+	versionfmt.RegisterParser(ParserName, parser{})
+}
@@ -0,0 +1,51 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/ext/versionfmt"
+)
+
+func TestParseAndCompare(t *testing.T) {
+	cases := []struct {
+		v1       string
+		expected int
+		v2       string
+	}{
+		{"v1.0.0", 0, "1.0.0"},
+		{"v2.0.0+incompatible", 0, "2.0.0"},
+		{"v0.0.0-20191109021931-daa7c04131f5", -1, "v0.0.1"},
+		{"v0.0.0-20191109021931-daa7c04131f5", 1, "v0.0.0-20180101000000-000000000000"},
+		{"v1.2.3-pre", -1, "v1.2.3"},
+		{"v1.10.0", 1, "v1.9.9"},
+		{versionfmt.MinVersion, -1, "v0.0.0"},
+		{"v1.0.0", -1, versionfmt.MaxVersion},
+	}
+
+	for _, c := range cases {
+		cmp, err := parser{}.Compare(c.v1, c.v2)
+		if assert.Nil(t, err, "When comparing %s and %s", c.v1, c.v2) {
+			assert.Equal(t, c.expected, cmp, "When comparing %s and %s", c.v1, c.v2)
+		}
+	}
+
+	for _, invalid := range []string{"", "(devel)", "v1.2", "go1.21rc2"} {
+		assert.False(t, parser{}.Valid(invalid), invalid)
+	}
+}
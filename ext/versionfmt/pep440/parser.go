@@ -0,0 +1,305 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pep440 implements a versionfmt.Parser for version numbers used in
+// Python packages, as specified by PEP 440.
+package pep440
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/clair/ext/versionfmt"
+)
+
+// ParserName is the name by which the pep440 parser is registered.
+const ParserName = "pep440"
+
+// versionRegexp is the regular expression given in the appendix B of PEP 440,
+// which accepts the non-normalized forms of the versions.
+var versionRegexp = regexp.MustCompile(`^v?` +
+	`(?:(?P<epoch>[0-9]+)!)?` +
+	`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?P<pre>[-_\.]?(?P<pre_l>a|b|c|rc|alpha|beta|pre|preview)[-_\.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?P<post>(?:-(?P<post_n1>[0-9]+))|(?:[-_\.]?(?P<post_l>post|rev|r)[-_\.]?(?P<post_n2>[0-9]+)?))?` +
+	`(?P<dev>[-_\.]?(?P<dev_l>dev)[-_\.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_\.][a-z0-9]+)*))?$`)
+
+// segment is an optional numbered part of a version, such as a pre-release.
+type segment struct {
+	present bool
+	label   string
+	number  int
+}
+
+type version struct {
+	epoch   int
+	release []int
+	pre     segment
+	post    segment
+	dev     segment
+	local   []string
+
+	// special is set to versionfmt.MinVersion or versionfmt.MaxVersion.
+	special string
+}
+
+var (
+	minVersion = version{special: versionfmt.MinVersion}
+	maxVersion = version{special: versionfmt.MaxVersion}
+
+	// preReleaseLabels gives the normalized form of the pre-release labels.
+	preReleaseLabels = map[string]string{
+		"a":       "a",
+		"alpha":   "a",
+		"b":       "b",
+		"beta":    "b",
+		"c":       "rc",
+		"rc":      "rc",
+		"pre":     "rc",
+		"preview": "rc",
+	}
+)
+
+func newVersion(str string) (version, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+
+	if len(str) == 0 {
+		return version{}, errors.New("Version string is empty")
+	}
+
+	// Max/Min versions
+	if str == strings.ToLower(versionfmt.MaxVersion) {
+		return maxVersion, nil
+	}
+	if str == strings.ToLower(versionfmt.MinVersion) {
+		return minVersion, nil
+	}
+
+	match := versionRegexp.FindStringSubmatch(str)
+	if match == nil {
+		return version{}, errors.New("version does not follow PEP 440")
+	}
+
+	groups := make(map[string]string)
+	for i, name := range versionRegexp.SubexpNames() {
+		if name != "" {
+			groups[name] = match[i]
+		}
+	}
+
+	var v version
+	if groups["epoch"] != "" {
+		v.epoch, _ = strconv.Atoi(groups["epoch"])
+	}
+
+	for _, part := range strings.Split(groups["release"], ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return version{}, errors.New("release number is too large")
+		}
+		v.release = append(v.release, n)
+	}
+
+	// Trailing zeros are not significant: 1.0 == 1.0.0.
+	for len(v.release) > 1 && v.release[len(v.release)-1] == 0 {
+		v.release = v.release[:len(v.release)-1]
+	}
+
+	if groups["pre"] != "" {
+		v.pre = segment{present: true, label: preReleaseLabels[groups["pre_l"]]}
+		v.pre.number, _ = strconv.Atoi(groups["pre_n"])
+	}
+
+	if groups["post"] != "" {
+		v.post = segment{present: true, label: "post"}
+		v.post.number, _ = strconv.Atoi(groups["post_n1"] + groups["post_n2"])
+	}
+
+	if groups["dev"] != "" {
+		v.dev = segment{present: true, label: "dev"}
+		v.dev.number, _ = strconv.Atoi(groups["dev_n"])
+	}
+
+	if groups["local"] != "" {
+		v.local = strings.FieldsFunc(groups["local"], func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+	}
+
+	return v, nil
+}
+
+type parser struct{}
+
+func (p parser) Valid(str string) bool {
+	_, err := newVersion(str)
+	return err == nil
+}
+
+func (p parser) InRange(versionA, rangeB string) (bool, error) {
+	cmp, err := p.Compare(versionA, rangeB)
+	if err != nil {
+		return false, err
+	}
+	return cmp < 0, nil
+}
+
+func (p parser) GetFixedIn(fixedIn string) (string, error) {
+	return fixedIn, nil
+}
+
+// Compare function compares two PEP 440 versions, following the ordering of
+// the section "Summary of permitted suffixes and relative ordering".
+func (p parser) Compare(a, b string) (int, error) {
+	v1, err := newVersion(a)
+	if err != nil {
+		return 0, err
+	}
+
+	v2, err := newVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	// Max/Min comparison
+	if v1.special != "" || v2.special != "" {
+		switch {
+		case v1.special == v2.special:
+			return 0, nil
+		case v1.special == versionfmt.MinVersion || v2.special == versionfmt.MaxVersion:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	}
+
+	if c := compareInt(v1.epoch, v2.epoch); c != 0 {
+		return c, nil
+	}
+
+	for i := 0; i < len(v1.release) || i < len(v2.release); i++ {
+		var r1, r2 int
+		if i < len(v1.release) {
+			r1 = v1.release[i]
+		}
+		if i < len(v2.release) {
+			r2 = v2.release[i]
+		}
+
+		if c := compareInt(r1, r2); c != 0 {
+			return c, nil
+		}
+	}
+
+	if c := comparePre(v1, v2); c != 0 {
+		return c, nil
+	}
+
+	// A post-release is more recent than its release.
+	if c := compareSegment(v1.post, v2.post, -1); c != 0 {
+		return c, nil
+	}
+
+	// A developmental release is older than its release.
+	if c := compareSegment(v1.dev, v2.dev, 1); c != 0 {
+		return c, nil
+	}
+
+	return compareLocal(v1.local, v2.local), nil
+}
+
+// comparePre compares the pre-releases of two versions that have the same
+// release number.
+func comparePre(v1, v2 version) int {
+	if c := compareInt(preRank(v1), preRank(v2)); c != 0 {
+		return c
+	}
+	return compareSegment(v1.pre, v2.pre, 0)
+}
+
+// preRank orders the versions of a same release: developmental releases
+// without pre-release come first, then the pre-releases and finally the
+// releases themselves.
+func preRank(v version) int {
+	switch {
+	case v.pre.present:
+		return 1
+	case v.dev.present && !v.post.present:
+		return 0
+	default:
+		return 2
+	}
+}
+
+// compareSegment compares two segments, considering that a missing segment
+// compares to a present one as given by missing.
+func compareSegment(s1, s2 segment, missing int) int {
+	switch {
+	case !s1.present && !s2.present:
+		return 0
+	case !s1.present:
+		return missing
+	case !s2.present:
+		return -missing
+	}
+
+	if c := strings.Compare(s1.label, s2.label); c != 0 {
+		return c
+	}
+	return compareInt(s1.number, s2.number)
+}
+
+// compareLocal compares local version labels, where numeric parts are more
+// recent than alphanumeric ones and a version without label is the oldest.
+func compareLocal(l1, l2 []string) int {
+	for i := 0; i < len(l1) && i < len(l2); i++ {
+		n1, err1 := strconv.Atoi(l1[i])
+		n2, err2 := strconv.Atoi(l2[i])
+
+		var c int
+		switch {
+		case err1 == nil && err2 == nil:
+			c = compareInt(n1, n2)
+		case err1 == nil:
+			c = 1
+		case err2 == nil:
+			c = -1
+		default:
+			c = strings.Compare(l1[i], l2[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return compareInt(len(l1), len(l2))
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	versionfmt.RegisterParser(ParserName, parser{})
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pep440
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/ext/versionfmt"
+)
+
+const (
+	LESS    = -1
+	EQUAL   = 0
+	GREATER = 1
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		str string
+		ver version
+		err bool
+	}{
+		{"1", version{release: []int{1}}, false},
+		{"1.0.0", version{release: []int{1}}, false},
+		{"v2.10", version{release: []int{2, 10}}, false},
+		{"1!2.0", version{epoch: 1, release: []int{2}}, false},
+		{"1.0a1", version{release: []int{1}, pre: segment{true, "a", 1}}, false},
+		{"1.0-Alpha.2", version{release: []int{1}, pre: segment{true, "a", 2}}, false},
+		{"1.0c1", version{release: []int{1}, pre: segment{true, "rc", 1}}, false},
+		{"1.0-1", version{release: []int{1}, post: segment{true, "post", 1}}, false},
+		{"1.0.post", version{release: []int{1}, post: segment{true, "post", 0}}, false},
+		{"1.0.dev3", version{release: []int{1}, dev: segment{true, "dev", 3}}, false},
+		{"1.0+ubuntu.1", version{release: []int{1}, local: []string{"ubuntu", "1"}}, false},
+		{"  1.2  ", version{release: []int{1, 2}}, false},
+		{versionfmt.MaxVersion, maxVersion, false},
+		{"", version{}, true},
+		{"1.0-beta-x", version{}, true},
+		{"a.b", version{}, true},
+		{"1.0~rc1", version{}, true},
+	}
+
+	for _, c := range cases {
+		v, err := newVersion(c.str)
+
+		if c.err {
+			assert.Error(t, err, "When parsing '%s'", c.str)
+		} else {
+			assert.Nil(t, err, "When parsing '%s'", c.str)
+		}
+		assert.Equal(t, c.ver, v, "When parsing '%s'", c.str)
+	}
+}
+
+func TestParseAndCompare(t *testing.T) {
+	cases := []struct {
+		v1       string
+		expected int
+		v2       string
+	}{
+		{"1.0", EQUAL, "1.0.0"},
+		{"1.0", LESS, "1.0.1"},
+		{"1.10", GREATER, "1.9"},
+		{"1!1.0", GREATER, "2.0"},
+		{"1.0.dev1", LESS, "1.0a1"},
+		{"1.0a1", LESS, "1.0a2"},
+		{"1.0a2", LESS, "1.0b1"},
+		{"1.0b1", LESS, "1.0rc1"},
+		{"1.0rc1", EQUAL, "1.0c1"},
+		{"1.0a1.dev1", LESS, "1.0a1"},
+		{"1.0rc1", LESS, "1.0"},
+		{"1.0", LESS, "1.0.post1"},
+		{"1.0.post1.dev1", LESS, "1.0.post1"},
+		{"1.0.post1", LESS, "1.0.post2"},
+		{"1.0", LESS, "1.0+local"},
+		{"1.0+abc", LESS, "1.0+1"},
+		{"1.0+1", LESS, "1.0+1.1"},
+		{"1.0", LESS, versionfmt.MaxVersion},
+		{versionfmt.MinVersion, LESS, "0.0.1"},
+		{versionfmt.MaxVersion, EQUAL, versionfmt.MaxVersion},
+	}
+
+	var p parser
+	for _, c := range cases {
+		cmp, err := p.Compare(c.v1, c.v2)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, cmp, "%s vs. %s, = %d, expected %d", c.v1, c.v2, cmp, c.expected)
+
+		cmp, err = p.Compare(c.v2, c.v1)
+		assert.Nil(t, err)
+		assert.Equal(t, -c.expected, cmp, "%s vs. %s, = %d, expected %d", c.v2, c.v1, cmp, -c.expected)
+	}
+}
@@ -114,6 +114,19 @@ func newVersion(str string) (version, error) {
 	return v, nil
 }
 
+// NormalizeVersion returns the version without its epoch when it is 0, which
+// is the epoch of the versions without one, so that the versions of the
+// packages and of the advisories are written the same way whether they give
+// their epoch or not. The unparseable versions, and the versions whose epoch
+// cannot be left out because their version has a colon, are returned as is.
+func NormalizeVersion(str string) string {
+	v, err := newVersion(str)
+	if err != nil || strings.Contains(v.version, ":") {
+		return str
+	}
+	return v.String()
+}
+
 type parser struct{}
 
 func (p parser) Valid(str string) bool {
@@ -80,12 +80,34 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestNormalizeVersion(t *testing.T) {
+	for _, c := range []struct {
+		str      string
+		expected string
+	}{
+		{"0:1.2-3", "1.2-3"},
+		{"1.2-3", "1.2-3"},
+		{"1:1.2-3", "1:1.2-3"},
+		{"0:1.2", "1.2"},
+		{"0:0:0-0", "0:0:0-0"},
+		{"a:0-0", "a:0-0"},
+	} {
+		assert.Equal(t, c.expected, NormalizeVersion(c.str), "When normalizing '%s'", c.str)
+	}
+}
+
 func TestParseAndCompare(t *testing.T) {
 	cases := []struct {
 		v1       string
 		expected int
 		v2       string
 	}{
+		// Epochs take precedence, and the versions without one have the epoch
+		// 0.
+		{"0:1.2-3", EQUAL, "1.2-3"},
+		{"1:1.0-1", GREATER, "2.0-1"},
+		{"1:1.0-1", GREATER, "0:2.0-1"},
+
 		// Oracle Linux corner cases.
 		{"2.9.1-6.0.1.el7_2.3", GREATER, "2.9.1-6.el7_2.3"},
 		{"3.10.0-327.28.3.el7", GREATER, "3.10.0-327.el7"},
@@ -0,0 +1,192 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package semver implements a versionfmt.Parser for version numbers following
+// Semantic Versioning 2.0.0, as used by the npm packages.
+package semver
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/clair/ext/versionfmt"
+)
+
+// ParserName is the name by which the semver parser is registered.
+const ParserName = "semver"
+
+// versionRegexp is the regular expression of a semantic version. The leading
+// "v" or "=" accepted by npm is allowed.
+var versionRegexp = regexp.MustCompile(`^[v=]?` +
+	`(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)` +
+	`(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?` +
+	`(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+type version struct {
+	major, minor, patch uint64
+	prerelease          []string
+
+	// special is set to versionfmt.MinVersion or versionfmt.MaxVersion.
+	special string
+}
+
+var (
+	minVersion = version{special: versionfmt.MinVersion}
+	maxVersion = version{special: versionfmt.MaxVersion}
+)
+
+func newVersion(str string) (version, error) {
+	str = strings.TrimSpace(str)
+
+	if len(str) == 0 {
+		return version{}, errors.New("Version string is empty")
+	}
+
+	// Max/Min versions
+	if str == versionfmt.MaxVersion {
+		return maxVersion, nil
+	}
+	if str == versionfmt.MinVersion {
+		return minVersion, nil
+	}
+
+	match := versionRegexp.FindStringSubmatch(str)
+	if match == nil {
+		return version{}, errors.New("version is not a semantic version")
+	}
+
+	var v version
+	var err error
+	for i, n := range []*uint64{&v.major, &v.minor, &v.patch} {
+		if *n, err = strconv.ParseUint(match[i+1], 10, 64); err != nil {
+			return version{}, errors.New("version number is too large")
+		}
+	}
+
+	if match[4] != "" {
+		v.prerelease = strings.Split(match[4], ".")
+	}
+
+	return v, nil
+}
+
+type parser struct{}
+
+func (p parser) Valid(str string) bool {
+	_, err := newVersion(str)
+	return err == nil
+}
+
+func (p parser) InRange(versionA, rangeB string) (bool, error) {
+	cmp, err := p.Compare(versionA, rangeB)
+	if err != nil {
+		return false, err
+	}
+	return cmp < 0, nil
+}
+
+func (p parser) GetFixedIn(fixedIn string) (string, error) {
+	return fixedIn, nil
+}
+
+// Compare function compares two semantic versions, following the precedence
+// rules of the specification. The build metadata is ignored.
+func (p parser) Compare(a, b string) (int, error) {
+	v1, err := newVersion(a)
+	if err != nil {
+		return 0, err
+	}
+
+	v2, err := newVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	// Max/Min comparison
+	if v1.special != "" || v2.special != "" {
+		switch {
+		case v1.special == v2.special:
+			return 0, nil
+		case v1.special == versionfmt.MinVersion || v2.special == versionfmt.MaxVersion:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	}
+
+	for _, c := range []int{
+		compareUint(v1.major, v2.major),
+		compareUint(v1.minor, v2.minor),
+		compareUint(v1.patch, v2.patch),
+	} {
+		if c != 0 {
+			return c, nil
+		}
+	}
+
+	return comparePrerelease(v1.prerelease, v2.prerelease), nil
+}
+
+// comparePrerelease compares the pre-release identifiers of two versions. A
+// version without pre-release is more recent than its pre-releases.
+func comparePrerelease(p1, p2 []string) int {
+	switch {
+	case len(p1) == 0 && len(p2) == 0:
+		return 0
+	case len(p1) == 0:
+		return 1
+	case len(p2) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(p1) && i < len(p2); i++ {
+		n1, err1 := strconv.ParseUint(p1[i], 10, 64)
+		n2, err2 := strconv.ParseUint(p2[i], 10, 64)
+
+		// Numeric identifiers have a lower precedence than alphanumeric ones.
+		var c int
+		switch {
+		case err1 == nil && err2 == nil:
+			c = compareUint(n1, n2)
+		case err1 == nil:
+			c = -1
+		case err2 == nil:
+			c = 1
+		default:
+			c = strings.Compare(p1[i], p2[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return compareUint(uint64(len(p1)), uint64(len(p2)))
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	versionfmt.RegisterParser(ParserName, parser{})
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/ext/versionfmt"
+)
+
+const (
+	LESS    = -1
+	EQUAL   = 0
+	GREATER = 1
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		str string
+		ver version
+		err bool
+	}{
+		{"1.2.3", version{major: 1, minor: 2, patch: 3}, false},
+		{"v0.10.0", version{minor: 10}, false},
+		{"=2.0.0", version{major: 2}, false},
+		{"1.0.0-beta.2", version{major: 1, prerelease: []string{"beta", "2"}}, false},
+		{"1.0.0+build.5", version{major: 1}, false},
+		{"1.0.0-rc.1+build.5", version{major: 1, prerelease: []string{"rc", "1"}}, false},
+		{versionfmt.MinVersion, minVersion, false},
+		{"", version{}, true},
+		{"1.0", version{}, true},
+		{"01.0.0", version{}, true},
+		{"1.0.0-", version{}, true},
+		{"1.0.0-beta..1", version{}, true},
+		{"99999999999999999999.0.0", version{}, true},
+	}
+
+	for _, c := range cases {
+		v, err := newVersion(c.str)
+
+		if c.err {
+			assert.Error(t, err, "When parsing '%s'", c.str)
+		} else {
+			assert.Nil(t, err, "When parsing '%s'", c.str)
+		}
+		assert.Equal(t, c.ver, v, "When parsing '%s'", c.str)
+	}
+}
+
+func TestParseAndCompare(t *testing.T) {
+	cases := []struct {
+		v1       string
+		expected int
+		v2       string
+	}{
+		{"1.0.0", EQUAL, "v1.0.0"},
+		{"1.0.0", EQUAL, "1.0.0+build.1"},
+		{"1.0.0", LESS, "2.0.0"},
+		{"2.0.0", LESS, "2.1.0"},
+		{"2.1.0", LESS, "2.1.1"},
+		{"1.9.0", LESS, "1.10.0"},
+		{"1.0.0-alpha", LESS, "1.0.0-alpha.1"},
+		{"1.0.0-alpha.1", LESS, "1.0.0-alpha.beta"},
+		{"1.0.0-alpha.beta", LESS, "1.0.0-beta"},
+		{"1.0.0-beta", LESS, "1.0.0-beta.2"},
+		{"1.0.0-beta.2", LESS, "1.0.0-beta.11"},
+		{"1.0.0-beta.11", LESS, "1.0.0-rc.1"},
+		{"1.0.0-rc.1", LESS, "1.0.0"},
+		{"1.0.0", LESS, versionfmt.MaxVersion},
+		{versionfmt.MinVersion, LESS, "0.0.0"},
+		{versionfmt.MinVersion, EQUAL, versionfmt.MinVersion},
+	}
+
+	var p parser
+	for _, c := range cases {
+		cmp, err := p.Compare(c.v1, c.v2)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, cmp, "%s vs. %s, = %d, expected %d", c.v1, c.v2, cmp, c.expected)
+
+		cmp, err = p.Compare(c.v2, c.v1)
+		assert.Nil(t, err)
+		assert.Equal(t, -c.expected, cmp, "%s vs. %s, = %d, expected %d", c.v2, c.v1, cmp, -c.expected)
+	}
+}
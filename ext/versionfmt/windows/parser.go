@@ -0,0 +1,128 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package windows implements a versionfmt.Parser for the versions of the
+// components installed on Windows, such as 10.0.17763.1457.
+//
+// The versions are dot-separated numbers, compared one after the other. A
+// missing number is 0, so that 10.0 is equal to 10.0.0.0.
+package windows
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/clair/ext/versionfmt"
+)
+
+// ParserName is the name by which the windows parser is registered.
+const ParserName = "windows"
+
+// version is the numbers of a version, or nil for versionfmt.MinVersion and
+// versionfmt.MaxVersion, which are then kept in special.
+type version struct {
+	numbers []uint64
+	special string
+}
+
+func newVersion(str string) (version, error) {
+	str = strings.TrimSpace(str)
+
+	if len(str) == 0 {
+		return version{}, errors.New("Version string is empty")
+	}
+
+	// Max/Min versions
+	if str == versionfmt.MaxVersion || str == versionfmt.MinVersion {
+		return version{special: str}, nil
+	}
+
+	var v version
+	for _, s := range strings.Split(str, ".") {
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return version{}, errors.New("version is not made of dot-separated numbers")
+		}
+		v.numbers = append(v.numbers, n)
+	}
+
+	return v, nil
+}
+
+type parser struct{}
+
+func (p parser) Valid(str string) bool {
+	_, err := newVersion(str)
+	return err == nil
+}
+
+func (p parser) InRange(versionA, rangeB string) (bool, error) {
+	cmp, err := p.Compare(versionA, rangeB)
+	if err != nil {
+		return false, err
+	}
+	return cmp < 0, nil
+}
+
+func (p parser) GetFixedIn(fixedIn string) (string, error) {
+	return fixedIn, nil
+}
+
+func (p parser) Compare(a, b string) (int, error) {
+	v1, err := newVersion(a)
+	if err != nil {
+		return 0, err
+	}
+
+	v2, err := newVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	// Max/Min comparison
+	if v1.special != "" || v2.special != "" {
+		switch {
+		case v1.special == v2.special:
+			return 0, nil
+		case v1.special == versionfmt.MinVersion || v2.special == versionfmt.MaxVersion:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	}
+
+	for i := 0; i < len(v1.numbers) || i < len(v2.numbers); i++ {
+		var n1, n2 uint64
+		if i < len(v1.numbers) {
+			n1 = v1.numbers[i]
+		}
+		if i < len(v2.numbers) {
+			n2 = v2.numbers[i]
+		}
+
+		switch {
+		case n1 < n2:
+			return -1, nil
+		case n1 > n2:
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func init() {
+	versionfmt.RegisterParser(ParserName, parser{})
+}
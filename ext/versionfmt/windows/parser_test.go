@@ -0,0 +1,56 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/ext/versionfmt"
+)
+
+func TestParseAndCompare(t *testing.T) {
+	cases := []struct {
+		v1       string
+		expected int
+		v2       string
+	}{
+		{"10.0.17763.1457", 0, "10.0.17763.1457"},
+		{"10.0", 0, "10.0.0.0"},
+		{"10.0.17763.1", -1, "10.0.17763.1457"},
+		{"10.0.17763.1457", -1, "10.0.20348.169"},
+		{"17763.1457.1.5", 1, "17763.1369.1.8"},
+		{"6.3.9600.19000", -1, "10.0.14393.0"},
+		{versionfmt.MinVersion, -1, "0"},
+		{"10.0.17763.1457", -1, versionfmt.MaxVersion},
+	}
+
+	for _, c := range cases {
+		cmp, err := parser{}.Compare(c.v1, c.v2)
+		if assert.Nil(t, err, "When comparing %s and %s", c.v1, c.v2) {
+			assert.Equal(t, c.expected, cmp, "When comparing %s and %s", c.v1, c.v2)
+		}
+
+		cmp, err = parser{}.Compare(c.v2, c.v1)
+		if assert.Nil(t, err, "When comparing %s and %s", c.v2, c.v1) {
+			assert.Equal(t, -c.expected, cmp, "When comparing %s and %s", c.v2, c.v1)
+		}
+	}
+
+	for _, invalid := range []string{"", "10.0.", "10..0", "v10.0", "10.0.17763.1457-1", "99999999999999999999"} {
+		assert.False(t, parser{}.Valid(invalid), invalid)
+	}
+}
@@ -51,6 +51,17 @@ type Appender interface {
 	Clean()
 }
 
+// IncrementalAppender is an Appender that keeps its metadata between updates
+// and can tell which vulnerabilities had their metadata modified, so that the
+// metadata of the vulnerabilities already in the database can be refreshed.
+type IncrementalAppender interface {
+	Appender
+
+	// Modified returns the names of the vulnerabilities whose metadata has
+	// changed during the last call to BuildCache. It is reset by PurgeCache.
+	Modified() []string
+}
+
 // RegisterAppender makes an Appender available by the provided name.
 //
 // If called twice with the same name, the name is blank, or if the provided
@@ -65,7 +65,10 @@ type nvdBaseMetricV3 struct {
 }
 
 type nvdCVSSv3 struct {
+	Version            string  `json:"version"`
+	VectorString       string  `json:"vectorString"`
 	Score              float64 `json:"baseScore"`
+	Severity           string  `json:"baseSeverity"`
 	AttackVector       string  `json:"attackVector"`
 	AttackComplexity   string  `json:"attackComplexity"`
 	PrivilegesRequired string  `json:"privilegesRequired"`
@@ -104,14 +107,16 @@ func (n nvdEntry) Metadata() *NVDMetadata {
 			Score:             n.Impact.BaseMetricV2.CVSSv2.Score,
 		},
 		CVSSv3: NVDmetadataCVSSv3{
+			Version:             n.Impact.BaseMetricV3.CVSSv3.Version,
 			Vectors:             n.Impact.BaseMetricV3.CVSSv3.String(),
 			Score:               n.Impact.BaseMetricV3.CVSSv3.Score,
+			Severity:            n.Impact.BaseMetricV3.CVSSv3.Severity,
 			ExploitabilityScore: n.Impact.BaseMetricV3.ExploitabilityScore,
 			ImpactScore:         n.Impact.BaseMetricV3.ImpactScore,
 		},
 	}
 
-	if metadata.CVSSv2.Vectors == "" {
+	if metadata.CVSSv2.Vectors == "" && metadata.CVSSv3.Vectors == "" {
 		return nil
 	}
 
@@ -135,6 +140,12 @@ func (n nvdCVSSv2) String() string {
 }
 
 func (n nvdCVSSv3) String() string {
+	// The vector string of the feed carries the version of the specification
+	// that the metrics follow, e.g. CVSS:3.1.
+	if strings.HasPrefix(n.VectorString, "CVSS:") {
+		return n.VectorString
+	}
+
 	var str string
 	addVec(&str, "AV", n.AttackVector)
 	addVec(&str, "AC", n.AttackComplexity)
@@ -147,7 +158,11 @@ func (n nvdCVSSv3) String() string {
 	str = strings.TrimSuffix(str, "/")
 
 	if len(str) > 0 {
-		return fmt.Sprintf("CVSS:3.0/%s", str)
+		version := n.Version
+		if version == "" {
+			version = "3.0"
+		}
+		return fmt.Sprintf("CVSS:%s/%s", version, str)
 	}
 	return str
 }
@@ -26,6 +26,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -42,8 +43,25 @@ const (
 	dataFeedURL     string = "https://nvd.nist.gov/feeds/json/cve/1.0/nvdcve-1.0-%s.json.gz"
 	dataFeedMetaURL string = "https://nvd.nist.gov/feeds/json/cve/1.0/nvdcve-1.0-%s.meta"
 
+	// modifiedDataFeedName is the data feed containing the CVEs that have been
+	// added or modified during the last eight days.
+	modifiedDataFeedName string = "modified"
+
+	// maxModifiedDataFeedAge is the longest time since the last download for
+	// which the modified data feed is enough to bring the metadata up to date.
+	// Older metadata is fully downloaded again.
+	maxModifiedDataFeedAge = 7 * 24 * time.Hour
+
+	// metadataFileName is the file, in the local path, storing the metadata
+	// of the last download and the time at which it started.
+	metadataFileName string = "metadata.json"
+
 	appenderName string = "NVD"
 
+	// sourceName is the name of the data source of NVD, under which its
+	// credentials are configured.
+	sourceName string = "nvd"
+
 	logDataFeedName string = "data feed name"
 )
 
@@ -51,6 +69,15 @@ type appender struct {
 	localPath      string
 	dataFeedHashes map[string]string
 	metadata       map[string]NVDMetadata
+	modified       []string
+}
+
+// storedMetadata is the content of the metadata file. The time of the download
+// is stored along with its metadata, rather than in the database, as the local
+// path is not shared by the instances of Clair.
+type storedMetadata struct {
+	LastModified time.Time
+	Metadata     map[string]NVDMetadata
 }
 
 type NVDMetadata struct {
@@ -65,8 +92,12 @@ type NVDmetadataCVSSv2 struct {
 }
 
 type NVDmetadataCVSSv3 struct {
-	Vectors             string
-	Score               float64
+	// Version is the version of the CVSS v3 specification, e.g. 3.1.
+	Version string
+	Vectors string
+	Score   float64
+	// Severity is the qualitative rating of Score, e.g. CRITICAL.
+	Severity            string
 	ExploitabilityScore float64
 	ImpactScore         float64
 }
@@ -75,9 +106,15 @@ func init() {
 	vulnmdsrc.RegisterAppender(appenderName, &appender{})
 }
 
+// BuildCache loads the NVD metadata into memory.
+//
+// Once the data feeds have been downloaded, only the modified data feed is
+// downloaded and merged into the metadata stored in the local path, unless the
+// stored metadata is too old or missing, e.g. after a restart.
 func (a *appender) BuildCache(datastore database.Datastore) error {
 	var err error
 	a.metadata = make(map[string]NVDMetadata)
+	a.modified = nil
 
 	// Init if necessary.
 	if a.localPath == "" {
@@ -89,6 +126,38 @@ func (a *appender) BuildCache(datastore database.Datastore) error {
 		a.dataFeedHashes = make(map[string]string)
 	}
 
+	previousMetadata, lastModified := a.loadMetadata()
+	start := time.Now()
+
+	if previousMetadata != nil && !lastModified.IsZero() && start.Sub(lastModified) < maxModifiedDataFeedAge {
+		log.WithField("last modified", lastModified).Debug("downloading modified NVD data feed")
+		if err := a.parseModifiedDataFeed(); err != nil {
+			return err
+		}
+
+		// Merge the modified entries into the previous metadata.
+		a.modified = modifiedMetadata(previousMetadata, a.metadata)
+		for name, metadata := range a.metadata {
+			previousMetadata[name] = metadata
+		}
+		a.metadata = previousMetadata
+	} else {
+		if err := a.parseDataFeeds(); err != nil {
+			return err
+		}
+
+		if previousMetadata != nil {
+			a.modified = modifiedMetadata(previousMetadata, a.metadata)
+		}
+	}
+
+	a.storeMetadata(start)
+
+	return nil
+}
+
+// parseDataFeeds downloads the data feeds of every year and parses them.
+func (a *appender) parseDataFeeds() error {
 	// Get data feeds.
 	dataFeedReaders, dataFeedHashes, err := getDataFeeds(a.dataFeedHashes, a.localPath)
 	if err != nil {
@@ -98,18 +167,43 @@ func (a *appender) BuildCache(datastore database.Datastore) error {
 
 	// Parse data feeds.
 	for dataFeedName, dataFileName := range dataFeedReaders {
-		f, err := os.Open(dataFileName)
-		if err != nil {
-			log.WithError(err).WithField(logDataFeedName, dataFeedName).Error("could not open NVD data file")
-			return commonerr.ErrCouldNotParse
-		}
-
-		r := bufio.NewReader(f)
-		if err := a.parseDataFeed(r); err != nil {
-			log.WithError(err).WithField(logDataFeedName, dataFeedName).Error("could not parse NVD data file")
+		if err := a.parseDataFeedFile(dataFeedName, dataFileName); err != nil {
 			return err
 		}
-		f.Close()
+	}
+
+	return nil
+}
+
+// parseModifiedDataFeed downloads the modified data feed and parses it.
+func (a *appender) parseModifiedDataFeed() error {
+	fileName := filepath.Join(a.localPath, fmt.Sprintf("%s.json", modifiedDataFeedName))
+
+	// The modified data feed is downloaded even if its hash is unknown.
+	hash, err := getHashFromMetaURL(fmt.Sprintf(dataFeedMetaURL, modifiedDataFeedName))
+	if err != nil {
+		log.WithError(err).WithField(logDataFeedName, modifiedDataFeedName).Warning("could not get NVD data feed hash")
+	}
+
+	if err := downloadFeed(modifiedDataFeedName, fileName, hash); err != nil {
+		return err
+	}
+
+	return a.parseDataFeedFile(modifiedDataFeedName, fileName)
+}
+
+func (a *appender) parseDataFeedFile(dataFeedName, dataFileName string) error {
+	f, err := os.Open(dataFileName)
+	if err != nil {
+		log.WithError(err).WithField(logDataFeedName, dataFeedName).Error("could not open NVD data file")
+		return commonerr.ErrCouldNotParse
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if err := a.parseDataFeed(r); err != nil {
+		log.WithError(err).WithField(logDataFeedName, dataFeedName).Error("could not parse NVD data file")
+		return err
 	}
 
 	return nil
@@ -132,16 +226,85 @@ func (a *appender) parseDataFeed(r io.Reader) error {
 	return nil
 }
 
+// loadMetadata returns the metadata stored in the local path by the previous
+// call to BuildCache and the time at which its download started, or nil if
+// there is none.
+func (a *appender) loadMetadata() (map[string]NVDMetadata, time.Time) {
+	f, err := os.Open(filepath.Join(a.localPath, metadataFileName))
+	if err != nil {
+		return nil, time.Time{}
+	}
+	defer f.Close()
+
+	var stored storedMetadata
+	if err := json.NewDecoder(bufio.NewReader(f)).Decode(&stored); err != nil {
+		log.WithError(err).Warning("could not read stored NVD metadata")
+		return nil, time.Time{}
+	}
+
+	return stored.Metadata, stored.LastModified
+}
+
+// storeMetadata stores the metadata in the local path, with the time at which
+// its download started. Failing to do so is not fatal: the data feeds are then
+// fully downloaded again on the next call to BuildCache.
+func (a *appender) storeMetadata(lastModified time.Time) {
+	fileName := filepath.Join(a.localPath, metadataFileName)
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		log.WithError(err).WithField("Filename", fileName).Warning("could not store NVD metadata to filesystem")
+		return
+	}
+
+	w := bufio.NewWriter(f)
+	err = json.NewEncoder(w).Encode(storedMetadata{LastModified: lastModified.UTC(), Metadata: a.metadata})
+	if err == nil {
+		err = w.Flush()
+	}
+	f.Close()
+
+	if err != nil {
+		log.WithError(err).WithField("Filename", fileName).Warning("could not store NVD metadata to filesystem")
+		os.Remove(fileName)
+	}
+}
+
+// modifiedMetadata returns the names of the vulnerabilities of previous whose
+// metadata is different in current.
+func modifiedMetadata(previous, current map[string]NVDMetadata) []string {
+	var modified []string
+	for name, metadata := range current {
+		if previousMetadata, ok := previous[name]; ok && previousMetadata != metadata {
+			modified = append(modified, name)
+		}
+	}
+
+	sort.Strings(modified)
+	return modified
+}
+
 func (a *appender) Append(vulnName string, appendFunc vulnmdsrc.AppendFunc) error {
 	if nvdMetadata, ok := a.metadata[vulnName]; ok {
-		appendFunc(appenderName, nvdMetadata, SeverityFromCVSS(nvdMetadata.CVSSv2.Score))
+		// Prefer the CVSS v3 score, which most recent entries only have.
+		score := nvdMetadata.CVSSv2.Score
+		if nvdMetadata.CVSSv3.Vectors != "" {
+			score = nvdMetadata.CVSSv3.Score
+		}
+
+		appendFunc(appenderName, nvdMetadata, SeverityFromCVSS(score))
 	}
 
 	return nil
 }
 
+func (a *appender) Modified() []string {
+	return a.modified
+}
+
 func (a *appender) PurgeCache() {
 	a.metadata = nil
+	a.modified = nil
 }
 
 func (a *appender) Clean() {
@@ -155,6 +318,7 @@ func getDataFeeds(dataFeedHashes map[string]string, localPath string) (map[strin
 	}
 
 	// Get hashes for these feeds.
+	newDataFeedHashes := make(map[string]string)
 	for _, dataFeedName := range dataFeedNames {
 		hash, err := getHashFromMetaURL(fmt.Sprintf(dataFeedMetaURL, dataFeedName))
 		if err != nil {
@@ -164,7 +328,7 @@ func getDataFeeds(dataFeedHashes map[string]string, localPath string) (map[strin
 			continue
 		}
 
-		dataFeedHashes[dataFeedName] = hash
+		newDataFeedHashes[dataFeedName] = hash
 	}
 
 	// Create map containing the name and filename for every data feed.
@@ -172,7 +336,7 @@ func getDataFeeds(dataFeedHashes map[string]string, localPath string) (map[strin
 	for _, dataFeedName := range dataFeedNames {
 		fileName := filepath.Join(localPath, fmt.Sprintf("%s.json", dataFeedName))
 
-		if h, ok := dataFeedHashes[dataFeedName]; ok && h == dataFeedHashes[dataFeedName] {
+		if h, ok := newDataFeedHashes[dataFeedName]; ok && h == dataFeedHashes[dataFeedName] {
 			// The hash is known, the disk should contains the feed. Try to read from it.
 			if localPath != "" {
 				if f, err := os.Open(fileName); err == nil {
@@ -181,40 +345,56 @@ func getDataFeeds(dataFeedHashes map[string]string, localPath string) (map[strin
 					continue
 				}
 			}
+		}
 
-			err := downloadFeed(dataFeedName, fileName)
-			if err != nil {
-				return dataFeedReaders, dataFeedHashes, err
-			}
-			dataFeedReaders[dataFeedName] = fileName
+		err := downloadFeed(dataFeedName, fileName, newDataFeedHashes[dataFeedName])
+		if err != nil {
+			return dataFeedReaders, newDataFeedHashes, err
 		}
+		dataFeedReaders[dataFeedName] = fileName
 	}
 
-	return dataFeedReaders, dataFeedHashes, nil
+	return dataFeedReaders, newDataFeedHashes, nil
 }
 
-func downloadFeed(dataFeedName, fileName string) error {
-	// Download data feed.
-	r, err := httputil.GetWithUserAgent(fmt.Sprintf(dataFeedURL, dataFeedName))
+// downloadFeed downloads a data feed and stores it, decompressed, in fileName.
+//
+// The download is verified with the hash of its metadata file, which is the
+// hash of the decompressed data feed, unless the hash is empty.
+func downloadFeed(dataFeedName, fileName, hash string) error {
+	// Download the compressed data feed next to its destination, so that it
+	// can be resumed.
+	gzf, err := os.Create(fileName + ".gz")
 	if err != nil {
-		log.WithError(err).WithField(logDataFeedName, dataFeedName).Error("could not download NVD data feed")
-		return commonerr.ErrCouldNotDownload
+		log.WithError(err).WithField("Filename", fileName).Warning("could not store NVD data feed to filesystem")
+		return commonerr.ErrFilesystem
 	}
-	defer r.Body.Close()
+	defer os.Remove(gzf.Name())
+	defer gzf.Close()
 
-	if !httputil.Status2xx(r) {
-		log.WithFields(log.Fields{"StatusCode": r.StatusCode, "DataFeedName": dataFeedName}).Error("Failed to download NVD data feed")
+	var checksum httputil.Checksum
+	if hash != "" {
+		checksum = func(r io.Reader) error {
+			gr, err := gzip.NewReader(r)
+			if err != nil {
+				return httputil.ErrChecksumMismatch
+			}
+			return httputil.SHA256Checksum(hash)(gr)
+		}
+	}
+
+	if err := httputil.Source(sourceName).Download(fmt.Sprintf(dataFeedURL, dataFeedName), gzf, checksum); err != nil {
+		log.WithError(err).WithField(logDataFeedName, dataFeedName).Error("could not download NVD data feed")
 		return commonerr.ErrCouldNotDownload
 	}
 
 	// Un-gzip it.
-	gr, err := gzip.NewReader(r.Body)
+	gr, err := gzip.NewReader(gzf)
 	if err != nil {
-		log.WithError(err).WithFields(log.Fields{"StatusCode": r.StatusCode, "DataFeedName": dataFeedName}).Error("could not read NVD data feed")
+		log.WithError(err).WithField("DataFeedName", dataFeedName).Error("could not read NVD data feed")
 		return commonerr.ErrCouldNotDownload
 	}
 
-	// Store it to a file at the same time if possible.
 	f, err := os.Create(fileName)
 	if err != nil {
 		log.WithError(err).WithField("Filename", fileName).Warning("could not store NVD data feed to filesystem")
@@ -232,14 +412,14 @@ func downloadFeed(dataFeedName, fileName string) error {
 }
 
 func getHashFromMetaURL(metaURL string) (string, error) {
-	r, err := httputil.GetWithUserAgent(metaURL)
+	r, err := httputil.Source(sourceName).GetWithUserAgent(metaURL)
 	if err != nil {
 		return "", err
 	}
 	defer r.Body.Close()
 
 	if !httputil.Status2xx(r) {
-		return "", errors.New(metaURL + " failed status code: " + string(r.StatusCode))
+		return "", errors.New(metaURL + " failed status code: " + strconv.Itoa(r.StatusCode))
 	}
 
 	scanner := bufio.NewScanner(r.Body)
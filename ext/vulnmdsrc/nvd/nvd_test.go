@@ -15,12 +15,16 @@
 package nvd
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/database"
 )
 
 func TestNVDParser(t *testing.T) {
@@ -44,8 +48,8 @@ func TestNVDParser(t *testing.T) {
 
 	var gotMetadata, wantMetadata NVDMetadata
 
-	// Items without CVSSv2 aren't returned.
-	assert.Len(t, a.metadata, 2)
+	// Items without CVSSv2 or CVSSv3 aren't returned.
+	assert.Len(t, a.metadata, 3)
 	gotMetadata, ok := a.metadata["CVE-2002-0001"]
 	assert.False(t, ok)
 
@@ -69,13 +73,53 @@ func TestNVDParser(t *testing.T) {
 			Score:   7.5,
 		},
 		CVSSv3: NVDmetadataCVSSv3{
+			Version:             "3.0",
 			Vectors:             "CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
 			Score:               9.8,
+			Severity:            "CRITICAL",
 			ExploitabilityScore: 3.9,
 			ImpactScore:         5.9,
 		},
 	}
 	assert.Equal(t, wantMetadata, gotMetadata)
+
+	// Item with only CVSSv3.1.
+	gotMetadata, ok = a.metadata["CVE-2020-0001"]
+	assert.True(t, ok)
+	wantMetadata = NVDMetadata{
+		CVSSv2: NVDmetadataCVSSv2{
+			PublishedDateTime: "2020-01-08T19:15Z",
+		},
+		CVSSv3: NVDmetadataCVSSv3{
+			Version:             "3.1",
+			Vectors:             "CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H",
+			Score:               7.8,
+			Severity:            "HIGH",
+			ExploitabilityScore: 1.8,
+			ImpactScore:         5.9,
+		},
+	}
+	assert.Equal(t, wantMetadata, gotMetadata)
+}
+
+func TestNVDAppendSeverity(t *testing.T) {
+	a := &appender{metadata: map[string]NVDMetadata{
+		"CVE-2012-0001": {CVSSv2: NVDmetadataCVSSv2{Vectors: "AV:N/AC:L/Au:S/C:P/I:N/A:N", Score: 4.0}},
+		"CVE-2018-0001": {
+			CVSSv2: NVDmetadataCVSSv2{Vectors: "AV:N/AC:L/Au:N/C:P/I:P/A:P", Score: 7.5},
+			CVSSv3: NVDmetadataCVSSv3{Vectors: "CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", Score: 9.8},
+		},
+	}}
+
+	severities := make(map[string]database.Severity)
+	for name := range a.metadata {
+		a.Append(name, func(_ string, _ interface{}, severity database.Severity) {
+			severities[name] = severity
+		})
+	}
+
+	assert.Equal(t, database.MediumSeverity, severities["CVE-2012-0001"])
+	assert.Equal(t, database.CriticalSeverity, severities["CVE-2018-0001"])
 }
 
 func TestNVDParserErrors(t *testing.T) {
@@ -97,3 +141,48 @@ func TestNVDParserErrors(t *testing.T) {
 		t.Fatalf("Expected error parsing NVD data file: %q", dataFilePath)
 	}
 }
+
+func TestNVDModifiedMetadata(t *testing.T) {
+	previous := map[string]NVDMetadata{
+		"CVE-2012-0001": {CVSSv2: NVDmetadataCVSSv2{Vectors: "AV:N/AC:L/Au:S/C:P/I:N/A:N", Score: 4.0}},
+		"CVE-2018-0001": {CVSSv2: NVDmetadataCVSSv2{Vectors: "AV:N/AC:L/Au:N/C:P/I:P/A:P", Score: 7.5}},
+	}
+	current := map[string]NVDMetadata{
+		"CVE-2012-0001": {CVSSv2: NVDmetadataCVSSv2{Vectors: "AV:N/AC:L/Au:S/C:P/I:N/A:N", Score: 4.0}},
+		"CVE-2018-0001": {CVSSv2: NVDmetadataCVSSv2{Vectors: "AV:N/AC:L/Au:N/C:C/I:C/A:C", Score: 10.0}},
+		"CVE-2018-0002": {CVSSv2: NVDmetadataCVSSv2{Vectors: "AV:N/AC:L/Au:N/C:P/I:P/A:P", Score: 7.5}},
+	}
+
+	// New entries aren't modified: they aren't stored yet.
+	assert.Equal(t, []string{"CVE-2018-0001"}, modifiedMetadata(previous, current))
+	assert.Empty(t, modifiedMetadata(previous, previous))
+}
+
+func TestNVDStoredMetadata(t *testing.T) {
+	localPath, err := ioutil.TempDir(os.TempDir(), "nvd-data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(localPath)
+
+	a := &appender{localPath: localPath}
+	metadata, lastModified := a.loadMetadata()
+	assert.Nil(t, metadata)
+	assert.True(t, lastModified.IsZero())
+
+	// The time of the download is stored along with the metadata, so that a
+	// missing local path is fully downloaded again.
+	a.metadata = map[string]NVDMetadata{
+		"CVE-2012-0001": {CVSSv2: NVDmetadataCVSSv2{Vectors: "AV:N/AC:L/Au:S/C:P/I:N/A:N", Score: 4.0}},
+	}
+	start := time.Date(2018, 3, 1, 10, 20, 0, 0, time.UTC)
+	a.storeMetadata(start)
+	metadata, lastModified = a.loadMetadata()
+	assert.Equal(t, a.metadata, metadata)
+	assert.Equal(t, start, lastModified)
+
+	a.localPath = filepath.Join(localPath, "missing")
+	metadata, lastModified = a.loadMetadata()
+	assert.Nil(t, metadata)
+	assert.True(t, lastModified.IsZero())
+}
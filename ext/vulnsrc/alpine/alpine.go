@@ -30,12 +30,14 @@ import (
 	"github.com/coreos/clair/ext/vulnsrc"
 	"github.com/coreos/clair/pkg/fsutil"
 	"github.com/coreos/clair/pkg/gitutil"
+	"github.com/coreos/clair/pkg/httputil"
 )
 
 const (
 	// This Alpine vulnerability database affects origin packages, which has
 	// `origin` field of itself.
 	secdbGitURL  = "https://github.com/alpinelinux/alpine-secdb"
+	updaterName  = "alpine"
 	updaterFlag  = "alpine-secdbUpdater"
 	nvdURLPrefix = "https://cve.mitre.org/cgi-bin/cvename.cgi?name="
 	// affected type indicates if the affected feature hint is for binary or
@@ -44,7 +46,7 @@ const (
 )
 
 func init() {
-	vulnsrc.RegisterUpdater("alpine", &updater{})
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
 }
 
 type updater struct {
@@ -62,7 +64,7 @@ func (u *updater) Update(db database.Datastore) (resp vulnsrc.UpdateResponse, er
 		vulns          []database.VulnerabilityWithAffected
 	)
 
-	if u.repositoryLocalPath, commit, err = gitutil.CloneOrPull(secdbGitURL, u.repositoryLocalPath, updaterFlag); err != nil {
+	if u.repositoryLocalPath, commit, err = gitutil.CloneOrPull(httputil.Source(updaterName).URL(secdbGitURL), u.repositoryLocalPath, updaterFlag); err != nil {
 		return
 	}
 
@@ -92,6 +94,7 @@ func (u *updater) Update(db database.Datastore) (resp vulnsrc.UpdateResponse, er
 
 		resp.Vulnerabilities = append(resp.Vulnerabilities, vulns...)
 	}
+	resp.Complete = true
 
 	return
 }
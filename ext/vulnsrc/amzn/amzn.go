@@ -0,0 +1,356 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package amzn implements a vulnerability source updater using the Amazon
+// Linux Security Center (ALAS) advisories published in the updateinfo of the
+// Amazon Linux repositories.
+package amzn
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/httputil"
+)
+
+const (
+	updaterFlag  = "amznUpdater"
+	updaterName  = "amzn"
+	affectedType = database.AffectBinaryPackage
+)
+
+// release is an Amazon Linux release whose repository publishes an
+// updateinfo.
+type release struct {
+	// name is the version of the release in its namespace, e.g. "2023" for
+	// "amzn:2023".
+	name string
+
+	// mirrorListURI lists the mirrors of the repository of the release.
+	mirrorListURI string
+
+	// alasURI is the format of the link to an advisory given its ID.
+	alasURI string
+}
+
+var releases = []release{
+	{
+		name:          "2023",
+		mirrorListURI: "https://cdn.amazonlinux.com/al2023/core/mirrors/latest/x86_64/mirror.list",
+		alasURI:       "https://alas.aws.amazon.com/AL2023/%s.html",
+	},
+}
+
+type repoMD struct {
+	Data []repoMDData `xml:"data"`
+}
+
+type repoMDData struct {
+	Type     string `xml:"type,attr"`
+	Checksum string `xml:"checksum"`
+	Location struct {
+		Href string `xml:"href,attr"`
+	} `xml:"location"`
+}
+
+type updateInfo struct {
+	Updates []update `xml:"update"`
+}
+
+type update struct {
+	Type        string      `xml:"type,attr"`
+	ID          string      `xml:"id"`
+	Title       string      `xml:"title"`
+	Severity    string      `xml:"severity"`
+	Description string      `xml:"description"`
+	References  []reference `xml:"references>reference"`
+	Packages    []pkg       `xml:"pkglist>collection>package"`
+}
+
+type reference struct {
+	Href string `xml:"href,attr"`
+	ID   string `xml:"id,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type pkg struct {
+	Name    string `xml:"name,attr"`
+	Epoch   string `xml:"epoch,attr"`
+	Version string `xml:"version,attr"`
+	Release string `xml:"release,attr"`
+}
+
+type updater struct{}
+
+func init() {
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
+}
+
+func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
+	log.WithField("package", "Amazon Linux").Info("Start fetching vulnerabilities")
+
+	// The flag contains the checksums of the last processed updateinfo of each
+	// release, in the order of releases.
+	flagValue, _, err := database.FindKeyValueAndRollback(datastore, updaterFlag)
+	if err != nil {
+		return
+	}
+	knownChecksums := strings.Split(flagValue, ",")
+
+	var (
+		checksums = make([]string, 0, len(releases))
+		changed   bool
+	)
+	for i, r := range releases {
+		var knownChecksum string
+		if i < len(knownChecksums) {
+			knownChecksum = knownChecksums[i]
+		}
+
+		vulnerabilities, checksum, err := fetchRelease(r, knownChecksum)
+		if err != nil {
+			return resp, err
+		}
+
+		checksums = append(checksums, checksum)
+		if checksum != knownChecksum {
+			changed = true
+			resp.Vulnerabilities = append(resp.Vulnerabilities, vulnerabilities...)
+		}
+	}
+
+	// Each changed updateinfo lists all the advisories of its release.
+	if changed {
+		resp.Complete = true
+		resp.FlagName = updaterFlag
+		resp.FlagValue = strings.Join(checksums, ",")
+	} else {
+		log.WithField("package", "Amazon Linux").Debug("no update")
+	}
+
+	return resp, nil
+}
+
+func (u *updater) Clean() {}
+
+// fetchRelease downloads and parses the updateinfo of a release, unless its
+// checksum is knownChecksum.
+func fetchRelease(r release, knownChecksum string) ([]database.VulnerabilityWithAffected, string, error) {
+	body, err := get(r.mirrorListURI)
+	if err != nil {
+		return nil, "", err
+	}
+	mirror, err := parseMirrorList(body)
+	body.Close()
+	if err != nil {
+		return nil, "", err
+	}
+
+	body, err = get(mirror + "/repodata/repomd.xml")
+	if err != nil {
+		return nil, "", err
+	}
+	location, checksum, err := parseRepoMD(body)
+	body.Close()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if checksum == knownChecksum {
+		return nil, checksum, nil
+	}
+
+	body, err = get(mirror + "/" + location)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	var reader io.Reader = body
+	if strings.HasSuffix(location, ".gz") {
+		gzipReader, err := gzip.NewReader(body)
+		if err != nil {
+			log.WithError(err).Error("could not decompress Amazon Linux's updateinfo")
+			return nil, "", commonerr.ErrCouldNotParse
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	vulnerabilities, err := parseUpdateInfo(r, reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return vulnerabilities, checksum, nil
+}
+
+func get(uri string) (io.ReadCloser, error) {
+	r, err := httputil.Source(updaterName).GetWithUserAgent(uri)
+	if err != nil {
+		log.WithError(err).WithField("uri", uri).Error("could not download Amazon Linux's data")
+		return nil, commonerr.ErrCouldNotDownload
+	}
+
+	if !httputil.Status2xx(r) {
+		r.Body.Close()
+		log.WithFields(log.Fields{"StatusCode": r.StatusCode, "uri": uri}).Error("Failed to update Amazon Linux")
+		return nil, commonerr.ErrCouldNotDownload
+	}
+
+	return r.Body, nil
+}
+
+// parseMirrorList returns the first mirror of a mirror list.
+func parseMirrorList(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			return strings.TrimSuffix(line, "/"), nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.WithError(err).Error("could not read Amazon Linux's mirror list")
+		return "", commonerr.ErrCouldNotDownload
+	}
+
+	log.Error("Amazon Linux's mirror list is empty")
+	return "", commonerr.ErrCouldNotParse
+}
+
+// parseRepoMD returns the location and the checksum of the updateinfo listed
+// in a repomd.xml file.
+func parseRepoMD(r io.Reader) (string, string, error) {
+	var md repoMD
+	if err := xml.NewDecoder(r).Decode(&md); err != nil {
+		log.WithError(err).Error("could not decode Amazon Linux's repomd.xml")
+		return "", "", commonerr.ErrCouldNotParse
+	}
+
+	for _, data := range md.Data {
+		if data.Type == "updateinfo" {
+			return data.Location.Href, strings.TrimSpace(data.Checksum), nil
+		}
+	}
+
+	log.Error("Amazon Linux's repomd.xml has no updateinfo")
+	return "", "", commonerr.ErrCouldNotParse
+}
+
+func parseUpdateInfo(r release, updateInfoReader io.Reader) (vulnerabilities []database.VulnerabilityWithAffected, err error) {
+	var info updateInfo
+	if err = xml.NewDecoder(updateInfoReader).Decode(&info); err != nil {
+		log.WithError(err).Error("could not decode Amazon Linux's updateinfo")
+		return nil, commonerr.ErrCouldNotParse
+	}
+
+	namespace := database.Namespace{
+		Name:          "amzn:" + r.name,
+		VersionFormat: rpm.ParserName,
+	}
+
+	for _, u := range info.Updates {
+		if u.Type != "security" || u.ID == "" {
+			continue
+		}
+
+		vulnerability := database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{
+				Name:        u.ID,
+				Namespace:   namespace,
+				Link:        fmt.Sprintf(r.alasURI, u.ID),
+				Severity:    severity(u.Severity),
+				Description: strings.TrimSpace(u.Description),
+			},
+		}
+
+		// The same package is listed once per architecture.
+		seen := make(map[string]struct{})
+		for _, p := range u.Packages {
+			if _, ok := seen[p.Name]; ok {
+				continue
+			}
+
+			version, err := p.fullVersion()
+			if err != nil {
+				log.WithError(err).WithFields(log.Fields{"advisory": u.ID, "package": p.Name}).Warning("could not parse package version. skipping")
+				continue
+			}
+			seen[p.Name] = struct{}{}
+
+			vulnerability.Affected = append(vulnerability.Affected, database.AffectedFeature{
+				AffectedType:    affectedType,
+				Namespace:       namespace,
+				FeatureName:     p.Name,
+				AffectedVersion: version,
+				FixedInVersion:  version,
+			})
+		}
+
+		if len(vulnerability.Affected) > 0 {
+			vulnerabilities = append(vulnerabilities, vulnerability)
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// fullVersion returns the version of the package in the rpm format, including
+// its epoch if it is not 0.
+func (p pkg) fullVersion() (string, error) {
+	if p.Name == "" || p.Version == "" || p.Release == "" {
+		return "", errors.New("incomplete package")
+	}
+
+	version := p.Version + "-" + p.Release
+	if p.Epoch != "" && p.Epoch != "0" {
+		version = p.Epoch + ":" + version
+	}
+
+	if err := versionfmt.Valid(rpm.ParserName, version); err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
+
+func severity(sev string) database.Severity {
+	switch strings.ToLower(sev) {
+	case "low":
+		return database.LowSeverity
+	case "medium":
+		return database.MediumSeverity
+	case "important":
+		return database.HighSeverity
+	case "critical":
+		return database.CriticalSeverity
+	default:
+		log.WithField("severity", sev).Warning("could not determine vulnerability severity")
+		return database.UnknownSeverity
+	}
+}
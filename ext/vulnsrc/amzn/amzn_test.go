@@ -0,0 +1,104 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amzn
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+)
+
+func TestAmazonLinuxParseMirrorList(t *testing.T) {
+	mirror, err := parseMirrorList(strings.NewReader("\nhttps://cdn.amazonlinux.com/al2023/core/guids/abc/x86_64/\nhttps://other.example.com/\n"))
+	if assert.Nil(t, err) {
+		assert.Equal(t, "https://cdn.amazonlinux.com/al2023/core/guids/abc/x86_64", mirror)
+	}
+
+	_, err = parseMirrorList(strings.NewReader("\n"))
+	assert.NotNil(t, err)
+}
+
+func TestAmazonLinuxParseRepoMD(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	path := filepath.Join(filepath.Dir(filename))
+
+	testFile, _ := os.Open(filepath.Join(path, "/testdata/repomd.xml"))
+	defer testFile.Close()
+
+	location, checksum, err := parseRepoMD(testFile)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "repodata/updateinfo.xml.gz", location)
+		assert.Equal(t, "8f9b3c2c3a1b7f00d1c62a1d4f7ab06a3d2b0bb6af9a1f3a9f2789d43e6e1d0a", checksum)
+	}
+}
+
+func TestAmazonLinux2023Parser(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	path := filepath.Join(filepath.Dir(filename))
+
+	testFile, _ := os.Open(filepath.Join(path, "/testdata/updateinfo.xml"))
+	defer testFile.Close()
+
+	namespace := database.Namespace{
+		Name:          "amzn:2023",
+		VersionFormat: rpm.ParserName,
+	}
+
+	vulnerabilities, err := parseUpdateInfo(releases[0], testFile)
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 2) {
+		assert.Equal(t, "ALAS2023-2023-001", vulnerabilities[0].Name)
+		assert.Equal(t, namespace, vulnerabilities[0].Namespace)
+		assert.Equal(t, "https://alas.aws.amazon.com/AL2023/ALAS2023-2023-001.html", vulnerabilities[0].Link)
+		assert.Equal(t, database.HighSeverity, vulnerabilities[0].Severity)
+		assert.True(t, strings.HasPrefix(vulnerabilities[0].Description, "Package updates are available for Amazon Linux 2023"))
+
+		expectedFeatures := []database.AffectedFeature{
+			{
+				AffectedType:    affectedType,
+				Namespace:       namespace,
+				FeatureName:     "openssl",
+				FixedInVersion:  "1:3.0.8-1.amzn2023.0.5",
+				AffectedVersion: "1:3.0.8-1.amzn2023.0.5",
+			},
+			{
+				AffectedType:    affectedType,
+				Namespace:       namespace,
+				FeatureName:     "openssl-libs",
+				FixedInVersion:  "1:3.0.8-1.amzn2023.0.5",
+				AffectedVersion: "1:3.0.8-1.amzn2023.0.5",
+			},
+		}
+		assert.Equal(t, expectedFeatures, vulnerabilities[0].Affected)
+
+		assert.Equal(t, "ALAS2023-2023-002", vulnerabilities[1].Name)
+		assert.Equal(t, database.MediumSeverity, vulnerabilities[1].Severity)
+		assert.Equal(t, []database.AffectedFeature{
+			{
+				AffectedType:    affectedType,
+				Namespace:       namespace,
+				FeatureName:     "curl",
+				FixedInVersion:  "7.88.1-1.amzn2023.0.1",
+				AffectedVersion: "7.88.1-1.amzn2023.0.1",
+			},
+		}, vulnerabilities[1].Affected)
+	}
+}
@@ -20,11 +20,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
 
 	"github.com/coreos/clair/database"
 	"github.com/coreos/clair/ext/versionfmt"
@@ -38,9 +40,43 @@ const (
 	url          = "https://security-tracker.debian.org/tracker/data/json"
 	cveURLPrefix = "https://security-tracker.debian.org/tracker"
 	updaterFlag  = "debianUpdater"
+	updaterName  = "debian"
 	affectedType = database.AffectSourcePackage
 )
 
+// The tags of the issues that Debian triaged, as shown by the Security
+// Tracker.
+const (
+	// TagNoDSA is an issue that is not important enough for a security
+	// advisory, and may be fixed in a point release.
+	TagNoDSA = "no-dsa"
+	// TagIgnored is a no-dsa issue that will not be fixed.
+	TagIgnored = "ignored"
+	// TagPostponed is a no-dsa issue that will be fixed in a later update.
+	TagPostponed = "postponed"
+	// TagEndOfLife is an issue of a package that is no longer supported.
+	TagEndOfLife = "end-of-life"
+	// TagUnimportant is an issue that has no security impact.
+	TagUnimportant = "unimportant"
+)
+
+// How the no-dsa issues, tagged no-dsa, ignored or postponed, are matched.
+const (
+	// NoDSAReport reports the no-dsa issues like the other ones.
+	NoDSAReport = "report"
+	// NoDSADowngrade reports the no-dsa issues with at most a low severity.
+	NoDSADowngrade = "downgrade"
+	// NoDSAExclude does not report the no-dsa issues.
+	NoDSAExclude = "exclude"
+)
+
+// Config is the configuration of the Debian updater.
+type Config struct {
+	// NoDSA is how the no-dsa issues are matched, among report, downgrade and
+	// exclude. They are reported by default.
+	NoDSA string
+}
+
 type jsonData map[string]map[string]jsonVuln
 
 type jsonVuln struct {
@@ -52,12 +88,41 @@ type jsonRel struct {
 	FixedVersion string `json:"fixed_version"`
 	Status       string `json:"status"`
 	Urgency      string `json:"urgency"`
+	NoDSA        string `json:"nodsa"`
+	NoDSAReason  string `json:"nodsa_reason"`
 }
 
-type updater struct{}
+type updater struct {
+	noDSA string
+}
 
 func init() {
-	vulnsrc.RegisterUpdater("debian", &updater{})
+	vulnsrc.RegisterUpdater(updaterName, &updater{noDSA: NoDSAReport})
+}
+
+func (u *updater) Configure(params map[string]interface{}) error {
+	if _, ok := params["debian"]; !ok {
+		return nil
+	}
+
+	var config Config
+	yamlConfig, err := yaml.Marshal(params["debian"])
+	if err != nil {
+		return errors.New("invalid configuration")
+	}
+	if err := yaml.Unmarshal(yamlConfig, &config); err != nil {
+		return errors.New("invalid configuration")
+	}
+
+	switch config.NoDSA {
+	case "":
+	case NoDSAReport, NoDSADowngrade, NoDSAExclude:
+		u.noDSA = config.NoDSA
+	default:
+		return fmt.Errorf("unsupported nodsa %q (expected report, downgrade or exclude)", config.NoDSA)
+	}
+
+	return nil
 }
 
 func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
@@ -72,7 +137,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 	}
 
 	// Download JSON.
-	r, err := httputil.GetWithUserAgent(url)
+	r, err := httputil.Source(updaterName).GetWithUserAgent(url)
 	if err != nil {
 		log.WithError(err).Error("could not download Debian's update")
 		return resp, commonerr.ErrCouldNotDownload
@@ -86,7 +151,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 	}
 
 	// Parse the JSON.
-	resp, err = buildResponse(r.Body, latestHash)
+	resp, err = buildResponse(r.Body, latestHash, u.noDSA)
 	if err != nil {
 		return resp, err
 	}
@@ -96,7 +161,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 
 func (u *updater) Clean() {}
 
-func buildResponse(jsonReader io.Reader, latestKnownHash string) (resp vulnsrc.UpdateResponse, err error) {
+func buildResponse(jsonReader io.Reader, latestKnownHash, noDSA string) (resp vulnsrc.UpdateResponse, err error) {
 	hash := latestKnownHash
 
 	// Defer the addition of flag information to the response.
@@ -121,7 +186,12 @@ func buildResponse(jsonReader io.Reader, latestKnownHash string) (resp vulnsrc.U
 	}
 
 	// Calculate the hash and skip updating if the hash has been seen before.
+	// The matching of the no-dsa issues is part of the flag, so that changing
+	// it updates the vulnerabilities.
 	hash = hex.EncodeToString(jsonSHA.Sum(nil))
+	if noDSA != NoDSAReport {
+		hash += ":" + noDSA
+	}
 	if latestKnownHash == hash {
 		log.WithField("package", "Debian").Debug("no update, skip")
 		return resp, nil
@@ -129,19 +199,20 @@ func buildResponse(jsonReader io.Reader, latestKnownHash string) (resp vulnsrc.U
 
 	// Extract vulnerability data from Debian's JSON schema.
 	var unknownReleases map[string]struct{}
-	resp.Vulnerabilities, unknownReleases = parseDebianJSON(&data)
+	resp.Vulnerabilities, unknownReleases = parseDebianJSON(&data, noDSA)
+	resp.Complete = true
 
 	// Log unknown releases
 	for k := range unknownReleases {
 		note := fmt.Sprintf("Debian %s is not mapped to any version number (eg. Jessie->8). Please update me.", k)
 		resp.Notes = append(resp.Notes, note)
-		log.Warning(note)
+		log.WithField("release", k).Warning("unknown Debian release")
 	}
 
 	return resp, nil
 }
 
-func parseDebianJSON(data *jsonData) (vulnerabilities []database.VulnerabilityWithAffected, unknownReleases map[string]struct{}) {
+func parseDebianJSON(data *jsonData, noDSA string) (vulnerabilities []database.VulnerabilityWithAffected, unknownReleases map[string]struct{}) {
 	mvulnerabilities := make(map[string]*database.VulnerabilityWithAffected)
 	unknownReleases = make(map[string]struct{})
 
@@ -160,6 +231,11 @@ func parseDebianJSON(data *jsonData) (vulnerabilities []database.VulnerabilityWi
 					continue
 				}
 
+				tag := releaseTag(releaseNode)
+				if isNoDSA(tag) && noDSA == NoDSAExclude {
+					continue
+				}
+
 				// Get or create the vulnerability.
 				vulnerability, vulnerabilityAlreadyExists := mvulnerabilities[vulnName]
 				if !vulnerabilityAlreadyExists {
@@ -176,6 +252,9 @@ func parseDebianJSON(data *jsonData) (vulnerabilities []database.VulnerabilityWi
 				// Set the priority of the vulnerability.
 				// In the JSON, a vulnerability has one urgency per package it affects.
 				severity := SeverityFromUrgency(releaseNode.Urgency)
+				if isNoDSA(tag) && noDSA == NoDSADowngrade && severity.Compare(database.LowSeverity) > 0 {
+					severity = database.LowSeverity
+				}
 				if severity.Compare(vulnerability.Severity) > 0 {
 					// The highest urgency should be the one set.
 					vulnerability.Severity = severity
@@ -219,6 +298,7 @@ func parseDebianJSON(data *jsonData) (vulnerabilities []database.VulnerabilityWi
 					FeatureName:     pkgName,
 					AffectedVersion: version,
 					FixedInVersion:  fixedInVersion,
+					Tag:             tag,
 					Namespace: database.Namespace{
 						Name:          "debian:" + database.DebianReleasesMapping[releaseName],
 						VersionFormat: dpkg.ParserName,
@@ -240,6 +320,25 @@ func parseDebianJSON(data *jsonData) (vulnerabilities []database.VulnerabilityWi
 	return
 }
 
+// releaseTag returns the tag of an issue in a release, if Debian triaged it.
+func releaseTag(release jsonRel) string {
+	switch {
+	case release.NoDSAReason == TagIgnored || release.NoDSAReason == TagPostponed:
+		return release.NoDSAReason
+	case release.NoDSA != "" || release.NoDSAReason != "":
+		return TagNoDSA
+	case release.Urgency == TagEndOfLife || release.Urgency == TagUnimportant:
+		return release.Urgency
+	}
+	return ""
+}
+
+// isNoDSA returns whether a tag is one of the no-dsa issues, for which Debian
+// does not plan a security advisory.
+func isNoDSA(tag string) bool {
+	return tag == TagNoDSA || tag == TagIgnored || tag == TagPostponed
+}
+
 // SeverityFromUrgency converts the urgency scale used by the Debian Security
 // Bug Tracker into a database.Severity.
 func SeverityFromUrgency(urgency string) database.Severity {
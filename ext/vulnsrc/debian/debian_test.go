@@ -18,6 +18,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/coreos/clair/database"
@@ -31,7 +32,7 @@ func TestDebianParser(t *testing.T) {
 
 	// Test parsing testdata/fetcher_debian_test.json
 	testFile, _ := os.Open(filepath.Join(filepath.Dir(filename), "/testdata/fetcher_debian_test.json"))
-	response, err := buildResponse(testFile, "")
+	response, err := buildResponse(testFile, "", NoDSAReport)
 	if assert.Nil(t, err) && assert.Len(t, response.Vulnerabilities, 2) {
 		for _, vulnerability := range response.Vulnerabilities {
 			if vulnerability.Name == "CVE-2015-1323" {
@@ -111,3 +112,94 @@ func TestDebianParser(t *testing.T) {
 		}
 	}
 }
+
+func TestDebianParserNoDSA(t *testing.T) {
+	const data = `{
+		"openssl": {
+			"CVE-2018-0001": {
+				"description": "Tagged no-dsa in jessie.",
+				"releases": {
+					"jessie": {"status": "open", "urgency": "high", "nodsa": "Minor issue"},
+					"stretch": {"status": "open", "urgency": "medium"}
+				}
+			},
+			"CVE-2018-0002": {
+				"description": "Postponed in stretch.",
+				"releases": {
+					"stretch": {"status": "open", "urgency": "high", "nodsa": "Minor issue", "nodsa_reason": "postponed"}
+				}
+			},
+			"CVE-2018-0003": {
+				"description": "Unimportant in stretch.",
+				"releases": {
+					"stretch": {"status": "open", "urgency": "unimportant"}
+				}
+			}
+		}
+	}`
+
+	tags := func(v database.VulnerabilityWithAffected) map[string]string {
+		tags := make(map[string]string)
+		for _, affected := range v.Affected {
+			tags[affected.Namespace.Name] = affected.Tag
+		}
+		return tags
+	}
+
+	response, err := buildResponse(strings.NewReader(data), "", NoDSAReport)
+	if assert.Nil(t, err) && assert.Len(t, response.Vulnerabilities, 3) {
+		for _, v := range response.Vulnerabilities {
+			switch v.Name {
+			case "CVE-2018-0001":
+				assert.Equal(t, database.HighSeverity, v.Severity)
+				assert.Equal(t, map[string]string{"debian:8": TagNoDSA, "debian:9": ""}, tags(v))
+			case "CVE-2018-0002":
+				assert.Equal(t, database.HighSeverity, v.Severity)
+				assert.Equal(t, map[string]string{"debian:9": TagPostponed}, tags(v))
+			case "CVE-2018-0003":
+				assert.Equal(t, map[string]string{"debian:9": TagUnimportant}, tags(v))
+			}
+		}
+	}
+
+	response, err = buildResponse(strings.NewReader(data), "", NoDSADowngrade)
+	if assert.Nil(t, err) && assert.Len(t, response.Vulnerabilities, 3) {
+		for _, v := range response.Vulnerabilities {
+			switch v.Name {
+			case "CVE-2018-0001":
+				assert.Equal(t, database.MediumSeverity, v.Severity)
+			case "CVE-2018-0002":
+				assert.Equal(t, database.LowSeverity, v.Severity)
+			}
+		}
+	}
+
+	// The unimportant issues are not no-dsa issues, and the flag changes with
+	// the matching of the no-dsa issues.
+	response, err = buildResponse(strings.NewReader(data), "", NoDSAExclude)
+	if assert.Nil(t, err) && assert.Len(t, response.Vulnerabilities, 2) {
+		for _, v := range response.Vulnerabilities {
+			switch v.Name {
+			case "CVE-2018-0001":
+				assert.Equal(t, database.MediumSeverity, v.Severity)
+				assert.Equal(t, map[string]string{"debian:9": ""}, tags(v))
+			case "CVE-2018-0003":
+				assert.Equal(t, map[string]string{"debian:9": TagUnimportant}, tags(v))
+			default:
+				assert.Fail(t, "unexpected vulnerability", v.Name)
+			}
+		}
+		assert.True(t, strings.HasSuffix(response.FlagValue, ":"+NoDSAExclude))
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	u := &updater{noDSA: NoDSAReport}
+	assert.Nil(t, u.Configure(map[string]interface{}{}))
+	assert.Equal(t, NoDSAReport, u.noDSA)
+
+	assert.Nil(t, u.Configure(map[string]interface{}{"debian": map[string]interface{}{"nodsa": "exclude"}}))
+	assert.Equal(t, NoDSAExclude, u.noDSA)
+
+	assert.NotNil(t, u.Configure(map[string]interface{}{"debian": map[string]interface{}{"nodsa": "hide"}}))
+}
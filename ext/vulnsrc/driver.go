@@ -37,6 +37,11 @@ type UpdateResponse struct {
 	FlagValue       string
 	Notes           []string
 	Vulnerabilities []database.VulnerabilityWithAffected
+
+	// Complete is set when Vulnerabilities holds all the vulnerabilities of
+	// the namespaces it covers, so that the stored vulnerabilities of these
+	// namespaces that it does not have anymore are deleted.
+	Complete bool
 }
 
 // Updater represents anything that can fetch vulnerabilities.
@@ -49,6 +54,22 @@ type Updater interface {
 	Clean()
 }
 
+// Configurable is implemented by the Updaters that take parameters from the
+// configuration of the updater service.
+type Configurable interface {
+	// Configure initializes the Updater with the parameters of the updater
+	// service, among which it looks up its own. It is called once, before the
+	// first update.
+	Configure(params map[string]interface{}) error
+}
+
+// OptIn is implemented by the Updaters that are only run when they are enabled
+// explicitly, e.g. because their vulnerabilities overlap with the ones of
+// another Updater.
+type OptIn interface {
+	OptIn()
+}
+
 // RegisterUpdater makes an Updater available by the provided name.
 //
 // If called twice with the same name, the name is blank, or if the provided
@@ -93,3 +114,16 @@ func ListUpdaters() []string {
 	}
 	return r
 }
+
+// ListDefaultUpdaters returns the names of the vulnerability updaters that are
+// run when none is enabled explicitly, which are all the registered ones but
+// the OptIn ones.
+func ListDefaultUpdaters() []string {
+	r := []string{}
+	for name, u := range updaters {
+		if _, optIn := u.(OptIn); !optIn {
+			r = append(r, name)
+		}
+	}
+	return r
+}
@@ -0,0 +1,196 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ghsa implements a vulnerability source updater using the reviewed
+// advisories of the GitHub Advisory Database, exported in the OSV format to a
+// git repository.
+//
+// The advisories are parsed like the ones of the osv updater, in the same
+// namespaces. As OSV.dev also exports the GitHub advisories, the updater
+// replaces the osv one rather than complementing it, and only runs when it is
+// enabled explicitly.
+package ghsa
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/ext/vulnsrc/osv"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/gitutil"
+	"github.com/coreos/clair/pkg/httputil"
+)
+
+const (
+	updaterFlag   = "ghsaUpdater"
+	updaterName   = "ghsa"
+	repositoryURL = "https://github.com/github/advisory-database"
+	advisoryURL   = "https://github.com/advisories/"
+
+	// reviewedPath is the directory of the reviewed advisories in the
+	// repository. The other ones rarely name the affected packages.
+	reviewedPath = "advisories/github-reviewed"
+)
+
+// defaultEcosystems are the ecosystems fetched when none is configured.
+var defaultEcosystems = []string{"Go", "PyPI", "npm"}
+
+// Config is the configuration of the GHSA updater, under the "ghsa" key of the
+// updater configuration.
+type Config struct {
+	// Ecosystems are the OSV names of the ecosystems whose advisories are
+	// fetched, among Go, PyPI and npm.
+	Ecosystems []string
+}
+
+type updater struct {
+	ecosystems          []string
+	repositoryLocalPath string
+}
+
+func init() {
+	vulnsrc.RegisterUpdater(updaterName, &updater{ecosystems: defaultEcosystems})
+}
+
+// OptIn implements vulnsrc.OptIn: the updater is only run in place of the osv
+// one, when it is enabled explicitly.
+func (u *updater) OptIn() {}
+
+func (u *updater) Configure(params map[string]interface{}) error {
+	if _, ok := params["ghsa"]; !ok {
+		return nil
+	}
+
+	var config Config
+	yamlConfig, err := yaml.Marshal(params["ghsa"])
+	if err != nil {
+		return errors.New("invalid configuration")
+	}
+	if err := yaml.Unmarshal(yamlConfig, &config); err != nil {
+		return errors.New("invalid configuration")
+	}
+
+	if len(config.Ecosystems) == 0 {
+		return nil
+	}
+
+	if err := osv.ValidateEcosystems(config.Ecosystems); err != nil {
+		return err
+	}
+	u.ecosystems = config.Ecosystems
+
+	return nil
+}
+
+func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
+	log.WithField("package", "GHSA").Info("Start fetching vulnerabilities")
+
+	// The repository is cloned with the credentials of the data source, e.g. a
+	// GitHub token, to avoid the rate limits of the anonymous clones. Only its
+	// latest commit is fetched, as its history is large.
+	authorization, err := httputil.Source(updaterName).Authorization()
+	if err != nil {
+		log.WithError(err).Error("could not authenticate to the GitHub Advisory Database")
+		return resp, commonerr.ErrCouldNotDownload
+	}
+
+	var commit string
+	opts := gitutil.Options{Authorization: authorization, Shallow: true}
+	if u.repositoryLocalPath, commit, err = gitutil.CloneOrPullWithOptions(httputil.Source(updaterName).URL(repositoryURL), u.repositoryLocalPath, updaterFlag, opts); err != nil {
+		return
+	}
+
+	// The flag contains the fetched ecosystems along with the commit, so that
+	// a change of the configuration does not wait for the next commit.
+	flagValue := strings.Join(u.ecosystems, ",") + "@" + commit
+	existingValue, found, err := database.FindKeyValueAndRollback(datastore, updaterFlag)
+	if err != nil {
+		return
+	}
+
+	if found && existingValue == flagValue {
+		log.WithField("package", "GHSA").Debug("no update, skip")
+		return
+	}
+
+	if resp.Vulnerabilities, err = parseAdvisories(filepath.Join(u.repositoryLocalPath, reviewedPath), u.ecosystems); err != nil {
+		return
+	}
+
+	// The repository has all the advisories of the ecosystems.
+	resp.Complete = true
+	resp.FlagName = updaterFlag
+	resp.FlagValue = flagValue
+
+	return
+}
+
+func (u *updater) Clean() {
+	if u.repositoryLocalPath != "" {
+		os.RemoveAll(u.repositoryLocalPath)
+	}
+}
+
+// parseAdvisories parses the advisories of the given ecosystems under root,
+// which are in a file each.
+func parseAdvisories(root string, ecosystems []string) ([]database.VulnerabilityWithAffected, error) {
+	var vulnerabilities []database.VulnerabilityWithAffected
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		vulns, err := osv.ParseAdvisory(f, ecosystems, advisoryURL)
+		if err != nil {
+			log.WithError(err).WithField("advisory", filepath.Base(path)).Error("could not unmarshal GHSA's advisory")
+			return commonerr.ErrCouldNotParse
+		}
+
+		vulnerabilities = append(vulnerabilities, vulns...)
+		return nil
+	})
+	if err == commonerr.ErrCouldNotParse {
+		return nil, err
+	} else if err != nil {
+		log.WithError(err).Error("could not read GHSA's advisories")
+		return nil, vulnsrc.ErrFilesystem
+	}
+
+	// Sort the vulnerabilities so that the response is stable.
+	sort.Slice(vulnerabilities, func(i, j int) bool {
+		if vulnerabilities[i].Name != vulnerabilities[j].Name {
+			return vulnerabilities[i].Name < vulnerabilities[j].Name
+		}
+		return vulnerabilities[i].Namespace.Name < vulnerabilities[j].Namespace.Name
+	})
+	return vulnerabilities, nil
+}
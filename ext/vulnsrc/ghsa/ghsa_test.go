@@ -0,0 +1,101 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghsa
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt/gomod"
+	"github.com/coreos/clair/ext/versionfmt/pep440"
+	"github.com/coreos/clair/ext/versionfmt/semver"
+)
+
+func TestGHSAParser(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	root := filepath.Join(filepath.Dir(filename), "testdata", reviewedPath)
+
+	vulns, err := parseAdvisories(root, defaultEcosystems)
+	require.Nil(t, err)
+
+	// The withdrawn advisory and the packages of the other ecosystems are
+	// ignored.
+	if assert.Len(t, vulns, 3) {
+		goNamespace := database.Namespace{Name: "go", VersionFormat: gomod.ParserName}
+		assert.Equal(t, "GHSA-4v7x-pqxf-cx7m", vulns[0].Name)
+		assert.Equal(t, goNamespace, vulns[0].Namespace)
+		assert.Equal(t, "Path traversal in golang.org/x/image", vulns[0].Description)
+		assert.Equal(t, "https://github.com/advisories/GHSA-4v7x-pqxf-cx7m", vulns[0].Link)
+		assert.Equal(t, database.HighSeverity, vulns[0].Severity)
+		assert.Equal(t, database.MetadataMap{"OSV": map[string]interface{}{"Aliases": []string{"CVE-2023-3001"}}}, vulns[0].Metadata)
+		assert.Equal(t, []database.AffectedFeature{
+			{
+				AffectedType:    database.AffectBinaryPackage,
+				FeatureName:     "golang.org/x/image",
+				AffectedVersion: "0.10.0",
+				FixedInVersion:  "0.10.0",
+				Namespace:       goNamespace,
+			},
+		}, vulns[0].Affected)
+
+		// An advisory affecting several ecosystems has a vulnerability in each
+		// of their namespaces.
+		npmNamespace := database.Namespace{Name: "npm", VersionFormat: semver.ParserName}
+		assert.Equal(t, "GHSA-9wx4-h78v-vm56", vulns[1].Name)
+		assert.Equal(t, npmNamespace, vulns[1].Namespace)
+		assert.Equal(t, database.CriticalSeverity, vulns[1].Severity)
+		assert.Nil(t, vulns[1].Metadata)
+		assert.Equal(t, []database.AffectedFeature{
+			{
+				AffectedType:        database.AffectBinaryPackage,
+				FeatureName:         "merge-deep",
+				AffectedVersion:     "3.0.3",
+				FixedInVersion:      "3.0.3",
+				IntroducedInVersion: "2.0.0",
+				Namespace:           npmNamespace,
+			},
+		}, vulns[1].Affected)
+
+		pythonNamespace := database.Namespace{Name: "python", VersionFormat: pep440.ParserName}
+		assert.Equal(t, "GHSA-9wx4-h78v-vm56", vulns[2].Name)
+		assert.Equal(t, pythonNamespace, vulns[2].Namespace)
+		if assert.Len(t, vulns[2].Affected, 1) {
+			assert.Equal(t, "merge-deep", vulns[2].Affected[0].FeatureName)
+		}
+	}
+
+	// Only the configured ecosystems are parsed.
+	vulns, err = parseAdvisories(root, []string{"PyPI"})
+	require.Nil(t, err)
+	if assert.Len(t, vulns, 1) {
+		assert.Equal(t, "python", vulns[0].Namespace.Name)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	u := &updater{ecosystems: defaultEcosystems}
+	require.Nil(t, u.Configure(map[string]interface{}{"osv": map[string]interface{}{"ecosystems": []string{"npm"}}}))
+	assert.Equal(t, defaultEcosystems, u.ecosystems)
+
+	require.Nil(t, u.Configure(map[string]interface{}{"ghsa": map[string]interface{}{"ecosystems": []string{"Go", "npm"}}}))
+	assert.Equal(t, []string{"Go", "npm"}, u.ecosystems)
+
+	assert.NotNil(t, u.Configure(map[string]interface{}{"ghsa": map[string]interface{}{"ecosystems": []string{"Maven"}}}))
+}
@@ -0,0 +1,322 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mageia implements a vulnerability source updater using the Mageia
+// security advisories (MGASA), which Mageia publishes in the OSV format.
+//
+// The advisories affect the source packages of a release, e.g. "Mageia:8",
+// whose vulnerabilities are in the "mageia:8" namespace.
+package mageia
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/httputil"
+)
+
+const (
+	updaterFlag = "mageiaUpdater"
+	updaterName = "mageia"
+	exportURL   = "https://osv-vulnerabilities.storage.googleapis.com/Mageia/all.zip"
+	advisoryURL = "https://advisories.mageia.org/"
+
+	// The advisories list the source packages fixed by their updates.
+	affectedType = database.AffectSourcePackage
+
+	// ecosystemPrefix is the prefix of the OSV ecosystems of the Mageia
+	// releases, which are followed by the version of the release.
+	ecosystemPrefix = "Mageia:"
+	rangeEcosystem  = "ECOSYSTEM"
+)
+
+// advisory is a Mageia advisory in the OSV format.
+type advisory struct {
+	ID        string   `json:"id"`
+	Summary   string   `json:"summary"`
+	Details   string   `json:"details"`
+	Withdrawn string   `json:"withdrawn"`
+	Aliases   []string `json:"aliases"`
+	Related   []string `json:"related"`
+	Affected  []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced string `json:"introduced"`
+				Fixed      string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+type updater struct{}
+
+func init() {
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
+}
+
+func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
+	log.WithField("package", "Mageia").Info("Start fetching vulnerabilities")
+
+	flagValue, _, err := database.FindKeyValueAndRollback(datastore, updaterFlag)
+	if err != nil {
+		return
+	}
+
+	// An unreachable export fails the update of this source only, which is
+	// retried by the next update.
+	vulnerabilities, hash, err := fetchExport(flagValue)
+	if err != nil {
+		return resp, err
+	}
+
+	if hash == flagValue {
+		log.WithField("package", "Mageia").Debug("no update")
+		return resp, nil
+	}
+
+	// The export has all the advisories of all the releases.
+	resp.Vulnerabilities = vulnerabilities
+	resp.Complete = true
+	resp.FlagName = updaterFlag
+	resp.FlagValue = hash
+	return resp, nil
+}
+
+func (u *updater) Clean() {}
+
+// fetchExport downloads the export of the advisories, which must be written
+// to a file to be read as a zip archive, and parses it unless its hash is
+// knownHash.
+func fetchExport(knownHash string) ([]database.VulnerabilityWithAffected, string, error) {
+	f, err := ioutil.TempFile(os.TempDir(), "mageia")
+	if err != nil {
+		log.WithError(err).Error("could not create Mageia's export file")
+		return nil, "", vulnsrc.ErrFilesystem
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := httputil.Source(updaterName).Download(exportURL, f, nil); err != nil {
+		log.WithError(err).Error("could not download Mageia's advisories")
+		return nil, "", commonerr.ErrCouldNotDownload
+	}
+
+	sha := sha256.New()
+	size, err := io.Copy(sha, f)
+	if err != nil {
+		log.WithError(err).Error("could not read Mageia's export file")
+		return nil, "", vulnsrc.ErrFilesystem
+	}
+
+	hash := hex.EncodeToString(sha.Sum(nil))
+	if hash == knownHash {
+		return nil, hash, nil
+	}
+
+	vulnerabilities, err := parseExport(f, size)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return vulnerabilities, hash, nil
+}
+
+// parseExport parses the advisories of an export, which is a zip archive with
+// an advisory per file.
+func parseExport(r io.ReaderAt, size int64) ([]database.VulnerabilityWithAffected, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		log.WithError(err).Error("could not open Mageia's export")
+		return nil, commonerr.ErrCouldNotParse
+	}
+
+	var vulnerabilities []database.VulnerabilityWithAffected
+	for _, file := range archive.File {
+		rc, err := file.Open()
+		if err != nil {
+			log.WithError(err).WithField("advisory", file.Name).Error("could not open Mageia's advisory")
+			return nil, commonerr.ErrCouldNotParse
+		}
+
+		var adv advisory
+		err = json.NewDecoder(rc).Decode(&adv)
+		rc.Close()
+		if err != nil {
+			log.WithError(err).WithField("advisory", file.Name).Error("could not unmarshal Mageia's advisory")
+			return nil, commonerr.ErrCouldNotParse
+		}
+		vulnerabilities = append(vulnerabilities, adv.vulnerabilities()...)
+	}
+
+	// Sort the vulnerabilities so that the response is stable.
+	sort.Slice(vulnerabilities, func(i, j int) bool {
+		if vulnerabilities[i].Name != vulnerabilities[j].Name {
+			return vulnerabilities[i].Name < vulnerabilities[j].Name
+		}
+		return vulnerabilities[i].Namespace.Name < vulnerabilities[j].Namespace.Name
+	})
+	return vulnerabilities, nil
+}
+
+// vulnerabilities returns the vulnerability of the advisory in the namespace
+// of each release that it affects, or nothing if it is withdrawn.
+func (adv *advisory) vulnerabilities() []database.VulnerabilityWithAffected {
+	if adv.Withdrawn != "" {
+		return nil
+	}
+
+	var (
+		vulnsByNamespace = make(map[string]*database.VulnerabilityWithAffected)
+		namespaceNames   []string
+	)
+	for _, affected := range adv.Affected {
+		if !strings.HasPrefix(affected.Package.Ecosystem, ecosystemPrefix) {
+			continue
+		}
+
+		namespace := database.Namespace{
+			Name:          "mageia:" + strings.TrimPrefix(affected.Package.Ecosystem, ecosystemPrefix),
+			VersionFormat: rpm.ParserName,
+		}
+
+		vuln, ok := vulnsByNamespace[namespace.Name]
+		if !ok {
+			vuln = adv.vulnerability(namespace)
+			vulnsByNamespace[namespace.Name] = vuln
+			namespaceNames = append(namespaceNames, namespace.Name)
+		}
+
+		for _, rng := range affected.Ranges {
+			if rng.Type != rangeEcosystem {
+				continue
+			}
+
+			// The events of a range alternate between an introducing version
+			// and a fixing version, an introducing version without a
+			// following event being affected up to the latest version.
+			var introduced string
+			var open bool
+			for _, event := range rng.Events {
+				switch {
+				case event.Introduced != "":
+					introduced, open = event.Introduced, true
+				case event.Fixed != "" && open:
+					vuln.Affected = appendAffected(vuln.Affected, namespace, affected.Package.Name, introduced, event.Fixed)
+					open = false
+				}
+			}
+
+			if open {
+				vuln.Affected = appendAffected(vuln.Affected, namespace, affected.Package.Name, introduced, "")
+			}
+		}
+	}
+
+	var vulnerabilities []database.VulnerabilityWithAffected
+	for _, name := range namespaceNames {
+		if vuln := vulnsByNamespace[name]; len(vuln.Affected) > 0 {
+			vulnerabilities = append(vulnerabilities, *vuln)
+		}
+	}
+	return vulnerabilities
+}
+
+// vulnerability returns the vulnerability of the advisory in the namespace,
+// without its affected features.
+func (adv *advisory) vulnerability(namespace database.Namespace) *database.VulnerabilityWithAffected {
+	vuln := &database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:        adv.ID,
+			Namespace:   namespace,
+			Description: adv.Summary,
+			Link:        advisoryURL + adv.ID + ".html",
+			Severity:    database.UnknownSeverity,
+		},
+	}
+
+	if vuln.Description == "" {
+		vuln.Description = adv.Details
+	}
+
+	// The CVEs fixed by an advisory are either its aliases or its related
+	// vulnerabilities.
+	var cves []string
+	for _, id := range append(append([]string{}, adv.Aliases...), adv.Related...) {
+		if strings.HasPrefix(id, "CVE-") {
+			cves = append(cves, id)
+		}
+	}
+	if len(cves) > 0 {
+		vuln.Metadata = database.MetadataMap{"Mageia": map[string]interface{}{"CVEs": cves}}
+	}
+
+	return vuln
+}
+
+// appendAffected appends the feature affected from the introduced version to
+// the fixed version, which is the latest version if it is empty.
+func appendAffected(affected []database.AffectedFeature, namespace database.Namespace, packageName, introduced, fixed string) []database.AffectedFeature {
+	// Every version is affected when the vulnerability is introduced in 0.
+	if introduced == "0" {
+		introduced = ""
+	}
+
+	introduced, fixed = rpm.NormalizeVersion(introduced), rpm.NormalizeVersion(fixed)
+	affectedVersion := fixed
+	if fixed == "" {
+		affectedVersion = versionfmt.MaxVersion
+	}
+
+	for _, version := range []string{introduced, fixed} {
+		if version == "" {
+			continue
+		}
+
+		if err := versionfmt.Valid(rpm.ParserName, version); err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"version":      version,
+				"package name": packageName,
+			}).Warning("could not parse package version, skipping")
+			return affected
+		}
+	}
+
+	return append(affected, database.AffectedFeature{
+		AffectedType:        affectedType,
+		FeatureName:         strings.TrimSpace(packageName),
+		AffectedVersion:     affectedVersion,
+		FixedInVersion:      fixed,
+		IntroducedInVersion: introduced,
+		Namespace:           namespace,
+	})
+}
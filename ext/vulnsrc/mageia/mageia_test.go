@@ -0,0 +1,107 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mageia
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+)
+
+// exportTestdata builds an export with the advisories of the testdata.
+func exportTestdata(t *testing.T) *bytes.Reader {
+	_, filename, _, _ := runtime.Caller(0)
+	filenames, err := filepath.Glob(filepath.Join(filepath.Dir(filename), "testdata", "*.json"))
+	require.Nil(t, err)
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, filename := range filenames {
+		d, err := ioutil.ReadFile(filename)
+		require.Nil(t, err)
+
+		f, err := w.Create(filepath.Base(filename))
+		require.Nil(t, err)
+		_, err = f.Write(d)
+		require.Nil(t, err)
+	}
+	require.Nil(t, w.Close())
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestMageiaParser(t *testing.T) {
+	export := exportTestdata(t)
+	vulns, err := parseExport(export, export.Size())
+	require.Nil(t, err)
+
+	mageia8 := database.Namespace{Name: "mageia:8", VersionFormat: rpm.ParserName}
+	mageia9 := database.Namespace{Name: "mageia:9", VersionFormat: rpm.ParserName}
+
+	// The withdrawn advisory and the packages of the other ecosystems are
+	// skipped.
+	require.Len(t, vulns, 3)
+
+	assert.Equal(t, "MGASA-2023-0001", vulns[0].Name)
+	assert.Equal(t, mageia8, vulns[0].Namespace)
+	assert.Equal(t, "Updated curl packages fix security vulnerabilities", vulns[0].Description)
+	assert.Equal(t, "https://advisories.mageia.org/MGASA-2023-0001.html", vulns[0].Link)
+	assert.Equal(t, database.UnknownSeverity, vulns[0].Severity)
+	assert.Equal(t, database.MetadataMap{"Mageia": map[string]interface{}{"CVEs": []string{"CVE-2022-43551", "CVE-2022-43552"}}}, vulns[0].Metadata)
+	assert.Equal(t, []database.AffectedFeature{
+		{
+			AffectedType:    affectedType,
+			Namespace:       mageia8,
+			FeatureName:     "curl",
+			AffectedVersion: "7.74.0-1.11.mga8",
+			FixedInVersion:  "7.74.0-1.11.mga8",
+		},
+	}, vulns[0].Affected)
+
+	// An advisory affecting several releases has a vulnerability per release,
+	// and its details describe it when its summary is empty.
+	assert.Equal(t, "MGASA-2023-0002", vulns[1].Name)
+	assert.Equal(t, mageia8, vulns[1].Namespace)
+	assert.Equal(t, "Updated sudo packages fix a privilege escalation through sudoedit.", vulns[1].Description)
+	assert.Equal(t, database.MetadataMap{"Mageia": map[string]interface{}{"CVEs": []string{"CVE-2023-22809"}}}, vulns[1].Metadata)
+
+	assert.Equal(t, "MGASA-2023-0002", vulns[2].Name)
+	assert.Equal(t, mageia9, vulns[2].Namespace)
+	assert.Equal(t, []database.AffectedFeature{
+		{
+			AffectedType:        affectedType,
+			Namespace:           mageia9,
+			FeatureName:         "sudo",
+			AffectedVersion:     versionfmt.MaxVersion,
+			IntroducedInVersion: "1.9.0-1.mga9",
+		},
+	}, vulns[2].Affected)
+}
+
+func TestMageiaParserInvalidExport(t *testing.T) {
+	export := bytes.NewReader([]byte("not a zip archive"))
+	_, err := parseExport(export, export.Size())
+	assert.Error(t, err)
+}
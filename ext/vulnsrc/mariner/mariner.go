@@ -0,0 +1,371 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mariner implements a vulnerability source updater using the OVAL
+// definitions that Microsoft publishes for each CBL-Mariner and Azure Linux
+// release.
+//
+// The definitions affect the source packages of a release, whose
+// vulnerabilities are in the namespace detected from its os-release, e.g.
+// "mariner:2.0" or "azurelinux:3.0".
+package mariner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/httputil"
+)
+
+const (
+	updaterFlag  = "marinerUpdater"
+	updaterName  = "mariner"
+	nvdURLPrefix = "https://nvd.nist.gov/vuln/detail/"
+
+	// The definitions test the source packages, which are named after their
+	// spec files.
+	affectedType = database.AffectSourcePackage
+
+	// The operations of the states of the tests: the packages are fixed by
+	// the version of the state, or affected up to it when they are not fixed
+	// yet.
+	operationLessThan        = "less than"
+	operationLessThanOrEqual = "less than or equal"
+)
+
+// release is a CBL-Mariner or Azure Linux release whose OVAL definitions are
+// published as a single file.
+type release struct {
+	// namespace is the namespace of the release, e.g. "mariner:2.0".
+	namespace string
+	url       string
+}
+
+var releases = []release{
+	{namespace: "mariner:2.0", url: "https://raw.githubusercontent.com/microsoft/AzureLinuxVulnerabilityData/main/cbl-mariner-2.0-oval.xml"},
+	{namespace: "azurelinux:3.0", url: "https://raw.githubusercontent.com/microsoft/AzureLinuxVulnerabilityData/main/azurelinux-3.0-oval.xml"},
+}
+
+type oval struct {
+	Definitions []definition `xml:"definitions>definition"`
+	Tests       []test       `xml:"tests>rpminfo_test"`
+	Objects     []object     `xml:"objects>rpminfo_object"`
+	States      []state      `xml:"states>rpminfo_state"`
+}
+
+type definition struct {
+	Class       string      `xml:"class,attr"`
+	Description string      `xml:"metadata>description"`
+	References  []reference `xml:"metadata>reference"`
+	Severity    string      `xml:"metadata>severity"`
+	Criteria    criteria    `xml:"criteria"`
+}
+
+type reference struct {
+	Source string `xml:"source,attr"`
+	URI    string `xml:"ref_url,attr"`
+	ID     string `xml:"ref_id,attr"`
+}
+
+type criteria struct {
+	Criterias  []criteria  `xml:"criteria"`
+	Criterions []criterion `xml:"criterion"`
+}
+
+type criterion struct {
+	TestRef string `xml:"test_ref,attr"`
+}
+
+// test tests whether the package of its object is in its state.
+type test struct {
+	ID     string `xml:"id,attr"`
+	Object struct {
+		Ref string `xml:"object_ref,attr"`
+	} `xml:"object"`
+	State struct {
+		Ref string `xml:"state_ref,attr"`
+	} `xml:"state"`
+}
+
+type object struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"name"`
+}
+
+type state struct {
+	ID  string `xml:"id,attr"`
+	EVR struct {
+		Operation string `xml:"operation,attr"`
+		Value     string `xml:",chardata"`
+	} `xml:"evr"`
+}
+
+type updater struct{}
+
+func init() {
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
+}
+
+func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
+	log.WithField("package", "CBL-Mariner").Info("Start fetching vulnerabilities")
+
+	// The flag contains the hashes of the last processed files, in the order
+	// of releases.
+	flagValue, _, err := database.FindKeyValueAndRollback(datastore, updaterFlag)
+	if err != nil {
+		return
+	}
+	knownHashes := strings.Split(flagValue, ",")
+
+	var (
+		hashes  = make([]string, 0, len(releases))
+		changed bool
+	)
+	for i, r := range releases {
+		var knownHash string
+		if i < len(knownHashes) {
+			knownHash = knownHashes[i]
+		}
+
+		vulnerabilities, hash, err := fetchRelease(r, knownHash)
+		if err != nil {
+			// An unreachable file only skips its release, which is fetched
+			// again by the next update.
+			log.WithError(err).WithField("release", r.namespace).Warning("skipping CBL-Mariner release")
+			resp.Notes = append(resp.Notes, fmt.Sprintf("%s vulnerabilities could not be updated.", r.namespace))
+			hashes = append(hashes, knownHash)
+			continue
+		}
+
+		hashes = append(hashes, hash)
+		if hash != knownHash {
+			changed = true
+			resp.Vulnerabilities = append(resp.Vulnerabilities, vulnerabilities...)
+		}
+	}
+
+	// Each changed file defines all the vulnerabilities of its release.
+	if changed {
+		resp.Complete = true
+		resp.FlagName = updaterFlag
+		resp.FlagValue = strings.Join(hashes, ",")
+	} else {
+		log.WithField("package", "CBL-Mariner").Debug("no update")
+	}
+
+	return resp, nil
+}
+
+func (u *updater) Clean() {}
+
+func fetchRelease(r release, knownHash string) ([]database.VulnerabilityWithAffected, string, error) {
+	resp, err := httputil.Source(updaterName).GetWithUserAgent(r.url)
+	if err != nil {
+		log.WithError(err).WithField("release", r.namespace).Error("could not download CBL-Mariner's OVAL definitions")
+		return nil, "", commonerr.ErrCouldNotDownload
+	}
+	defer resp.Body.Close()
+
+	if !httputil.Status2xx(resp) {
+		log.WithFields(log.Fields{"StatusCode": resp.StatusCode, "release": r.namespace}).Error("Failed to update CBL-Mariner")
+		return nil, "", commonerr.ErrCouldNotDownload
+	}
+
+	return parseOVAL(r, resp.Body, knownHash)
+}
+
+// parseOVAL parses the OVAL definitions of a release and returns its
+// vulnerabilities, unless their hash is knownHash.
+func parseOVAL(r release, ovalReader io.Reader, knownHash string) ([]database.VulnerabilityWithAffected, string, error) {
+	xmlSHA := sha256.New()
+	teedXMLReader := io.TeeReader(ovalReader, xmlSHA)
+
+	var ov oval
+	if err := xml.NewDecoder(teedXMLReader).Decode(&ov); err != nil {
+		log.WithError(err).WithField("release", r.namespace).Error("could not decode CBL-Mariner's XML")
+		return nil, "", commonerr.ErrCouldNotParse
+	}
+
+	hash := hex.EncodeToString(xmlSHA.Sum(nil))
+	if hash == knownHash {
+		return nil, hash, nil
+	}
+
+	return vulnerabilities(r, ov), hash, nil
+}
+
+// vulnerabilities groups the packages tested by the definitions by CVE.
+func vulnerabilities(r release, ov oval) (vulns []database.VulnerabilityWithAffected) {
+	namespace := database.Namespace{Name: r.namespace, VersionFormat: rpm.ParserName}
+
+	var (
+		tests   = make(map[string]test)
+		objects = make(map[string]object)
+		states  = make(map[string]state)
+	)
+	for _, t := range ov.Tests {
+		tests[t.ID] = t
+	}
+	for _, o := range ov.Objects {
+		objects[o.ID] = o
+	}
+	for _, s := range ov.States {
+		states[s.ID] = s
+	}
+
+	vulnsByName := make(map[string]*database.VulnerabilityWithAffected)
+	seen := make(map[string]struct{})
+	for _, def := range ov.Definitions {
+		if def.Class != "vulnerability" {
+			continue
+		}
+
+		name, link := cve(def)
+		if name == "" {
+			continue
+		}
+
+		for _, c := range criterions(def.Criteria) {
+			t, ok := tests[c.TestRef]
+			if !ok {
+				continue
+			}
+
+			pkg := strings.TrimSpace(objects[t.Object.Ref].Name)
+			s, ok := states[t.State.Ref]
+			if pkg == "" || !ok {
+				log.WithFields(log.Fields{"test": c.TestRef, "vulnerability": name}).Warning("could not find the package of the test, skipping")
+				continue
+			}
+
+			// A package may be tested by several definitions of the same CVE.
+			key := name + ":" + pkg
+			if _, ok := seen[key]; ok {
+				continue
+			}
+
+			affected := database.AffectedFeature{
+				AffectedType: affectedType,
+				FeatureName:  pkg,
+				Namespace:    namespace,
+			}
+
+			version := strings.TrimSpace(s.EVR.Value)
+			switch s.EVR.Operation {
+			case operationLessThan:
+				if err := versionfmt.Valid(rpm.ParserName, version); err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"version":      version,
+						"package name": pkg,
+					}).Warning("could not parse package version, skipping")
+					continue
+				}
+				version = rpm.NormalizeVersion(version)
+				affected.AffectedVersion = version
+				affected.FixedInVersion = version
+			case operationLessThanOrEqual:
+				// The package is not fixed yet.
+				affected.AffectedVersion = versionfmt.MaxVersion
+			default:
+				log.WithFields(log.Fields{"operation": s.EVR.Operation, "package name": pkg}).Warning("could not understand the state of the package, skipping")
+				continue
+			}
+			seen[key] = struct{}{}
+
+			vuln, ok := vulnsByName[name]
+			if !ok {
+				vuln = &database.VulnerabilityWithAffected{
+					Vulnerability: database.Vulnerability{
+						Name:        name,
+						Link:        link,
+						Severity:    severity(def.Severity),
+						Description: strings.TrimSpace(def.Description),
+						Namespace:   namespace,
+					},
+				}
+				vulnsByName[name] = vuln
+			} else if sev := severity(def.Severity); sev.Compare(vuln.Severity) > 0 {
+				vuln.Severity = sev
+			}
+
+			vuln.Affected = append(vuln.Affected, affected)
+		}
+	}
+
+	for _, vuln := range vulnsByName {
+		vulns = append(vulns, *vuln)
+	}
+
+	// Sort the vulnerabilities so that the response is stable.
+	sort.Slice(vulns, func(i, j int) bool { return vulns[i].Name < vulns[j].Name })
+	return
+}
+
+// cve returns the CVE of a definition and its link, which is the NVD page of
+// the CVE when the definition has none.
+func cve(def definition) (name, link string) {
+	for _, ref := range def.References {
+		if ref.ID == "" {
+			continue
+		}
+
+		name, link = strings.TrimSpace(ref.ID), ref.URI
+		if link == "" {
+			link = nvdURLPrefix + name
+		}
+		return
+	}
+
+	return
+}
+
+// criterions returns all the criterions of the criteria of a definition, each
+// of which tests a package.
+func criterions(node criteria) []criterion {
+	cs := node.Criterions
+	for _, c := range node.Criterias {
+		cs = append(cs, criterions(c)...)
+	}
+	return cs
+}
+
+func severity(sev string) database.Severity {
+	switch strings.ToLower(strings.TrimSpace(sev)) {
+	case "none":
+		return database.NegligibleSeverity
+	case "low":
+		return database.LowSeverity
+	case "medium", "moderate":
+		return database.MediumSeverity
+	case "high", "important":
+		return database.HighSeverity
+	case "critical":
+		return database.CriticalSeverity
+	default:
+		log.WithField("severity", sev).Warning("could not determine vulnerability severity")
+		return database.UnknownSeverity
+	}
+}
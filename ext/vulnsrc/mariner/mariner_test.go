@@ -0,0 +1,93 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mariner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+)
+
+func TestMarinerParser(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	path := filepath.Join(filepath.Dir(filename))
+
+	testFile, _ := os.Open(filepath.Join(path, "/testdata/cbl-mariner-2.0-oval.xml"))
+	defer testFile.Close()
+
+	namespace := database.Namespace{
+		Name:          "mariner:2.0",
+		VersionFormat: rpm.ParserName,
+	}
+
+	vulnerabilities, hash, err := parseOVAL(releases[0], testFile, "")
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 2) {
+		assert.NotEmpty(t, hash)
+
+		// The packages of the definitions of a CVE are grouped, and the
+		// severity is the highest one of the definitions.
+		assert.Equal(t, "CVE-2023-0466", vulnerabilities[0].Name)
+		assert.Equal(t, namespace, vulnerabilities[0].Namespace)
+		assert.Equal(t, "https://nvd.nist.gov/vuln/detail/CVE-2023-0466", vulnerabilities[0].Link)
+		assert.Equal(t, database.HighSeverity, vulnerabilities[0].Severity)
+		assert.Equal(t, "CVE-2023-0466 affecting package openssl for versions less than 1:1.1.1k-23. A patched version of the package is available.", vulnerabilities[0].Description)
+		assert.Equal(t, []database.AffectedFeature{
+			{
+				AffectedType:    affectedType,
+				Namespace:       namespace,
+				FeatureName:     "openssl",
+				AffectedVersion: "1:1.1.1k-23.cm2",
+				FixedInVersion:  "1:1.1.1k-23.cm2",
+			},
+			{
+				// The packages that are not fixed yet are affected in all
+				// their versions.
+				AffectedType:    affectedType,
+				Namespace:       namespace,
+				FeatureName:     "edk2",
+				AffectedVersion: versionfmt.MaxVersion,
+			},
+		}, vulnerabilities[0].Affected)
+
+		// The package tested by several definitions of a CVE is only affected
+		// once, and the 0 epoch is left out.
+		assert.Equal(t, "CVE-2023-28487", vulnerabilities[1].Name)
+		assert.Equal(t, database.MediumSeverity, vulnerabilities[1].Severity)
+		assert.Equal(t, []database.AffectedFeature{
+			{
+				AffectedType:    affectedType,
+				Namespace:       namespace,
+				FeatureName:     "sudo",
+				AffectedVersion: "1.9.13p3-1.cm2",
+				FixedInVersion:  "1.9.13p3-1.cm2",
+			},
+		}, vulnerabilities[1].Affected)
+	}
+
+	// Unchanged definitions are not parsed again.
+	testFile.Seek(0, 0)
+	vulnerabilities, knownHash, err := parseOVAL(releases[0], testFile, hash)
+	if assert.Nil(t, err) {
+		assert.Equal(t, hash, knownHash)
+		assert.Len(t, vulnerabilities, 0)
+	}
+}
@@ -41,6 +41,7 @@ const (
 	ovalURI          = "https://linux.oracle.com/oval/"
 	elsaFilePrefix   = "com.oracle.elsa-"
 	updaterFlag      = "oracleUpdater"
+	updaterName      = "oracle"
 	affectedType     = database.AffectBinaryPackage
 )
 
@@ -91,7 +92,7 @@ type criterion struct {
 type updater struct{}
 
 func init() {
-	vulnsrc.RegisterUpdater("oracle", &updater{})
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
 }
 
 func compareELSA(left, right int) int {
@@ -142,7 +143,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 	}
 
 	// Fetch the update list.
-	r, err := httputil.GetWithUserAgent(ovalURI)
+	r, err := httputil.Source(updaterName).GetWithUserAgent(ovalURI)
 	if err != nil {
 		log.WithError(err).Error("could not download Oracle's update list")
 		return resp, commonerr.ErrCouldNotDownload
@@ -170,7 +171,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 
 	for _, elsa := range elsaList {
 		// Download the ELSA's XML file.
-		r, err := httputil.GetWithUserAgent(ovalURI + elsaFilePrefix + strconv.Itoa(elsa) + ".xml")
+		r, err := httputil.Source(updaterName).GetWithUserAgent(ovalURI + elsaFilePrefix + strconv.Itoa(elsa) + ".xml")
 		if err != nil {
 			log.WithError(err).Error("could not download Oracle's update list")
 			return resp, commonerr.ErrCouldNotDownload
@@ -371,6 +372,7 @@ func toFeatures(criteria criteria) []database.AffectedFeature {
 				if err != nil {
 					log.WithError(err).WithField("version", version).Warning("could not parse package version. skipping")
 				} else {
+					version = rpm.NormalizeVersion(version)
 					featureVersion.AffectedVersion = version
 					if version != versionfmt.MaxVersion {
 						featureVersion.FixedInVersion = version
@@ -49,8 +49,8 @@ func TestOracleParserOneCve(t *testing.T) {
 					VersionFormat: rpm.ParserName,
 				},
 				FeatureName:     "xerces-c",
-				FixedInVersion:  "0:3.1.1-7.el7_1",
-				AffectedVersion: "0:3.1.1-7.el7_1",
+				FixedInVersion:  "3.1.1-7.el7_1",
+				AffectedVersion: "3.1.1-7.el7_1",
 			},
 			{
 				AffectedType: affectedType,
@@ -59,8 +59,8 @@ func TestOracleParserOneCve(t *testing.T) {
 					VersionFormat: rpm.ParserName,
 				},
 				FeatureName:     "xerces-c-devel",
-				FixedInVersion:  "0:3.1.1-7.el7_1",
-				AffectedVersion: "0:3.1.1-7.el7_1",
+				FixedInVersion:  "3.1.1-7.el7_1",
+				AffectedVersion: "3.1.1-7.el7_1",
 			},
 			{
 				AffectedType: affectedType,
@@ -69,8 +69,8 @@ func TestOracleParserOneCve(t *testing.T) {
 					VersionFormat: rpm.ParserName,
 				},
 				FeatureName:     "xerces-c-doc",
-				FixedInVersion:  "0:3.1.1-7.el7_1",
-				AffectedVersion: "0:3.1.1-7.el7_1",
+				FixedInVersion:  "3.1.1-7.el7_1",
+				AffectedVersion: "3.1.1-7.el7_1",
 			},
 		}
 
@@ -0,0 +1,432 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package osv implements a vulnerability source updater using the advisories
+// of the language ecosystems exported by OSV.dev.
+//
+// The affected ranges of the advisories are mapped to affected features going
+// from the version introducing the vulnerability to the version fixing it, or
+// to the last affected version for the ranges that only give it.
+package osv
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/gomod"
+	"github.com/coreos/clair/ext/versionfmt/pep440"
+	"github.com/coreos/clair/ext/versionfmt/semver"
+	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/httputil"
+)
+
+const (
+	updaterFlag = "osvUpdater"
+	updaterName = "osv"
+	exportURL   = "https://osv-vulnerabilities.storage.googleapis.com/%s/all.zip"
+	osvURL      = "https://osv.dev/vulnerability/"
+
+	// affected type indicates if the affected feature hint is for binary or
+	// source package.
+	affectedType = database.AffectBinaryPackage
+
+	// The types of the ranges whose versions are versions of the packages, as
+	// opposed to commits.
+	rangeSemver    = "SEMVER"
+	rangeEcosystem = "ECOSYSTEM"
+)
+
+// ecosystem is an OSV ecosystem whose advisories are fetched.
+type ecosystem struct {
+	// namespace is the namespace of the features of the ecosystem, which
+	// matches the one given by its featurens detector.
+	namespace database.Namespace
+	// normalizeName returns the name of a package as it is listed by its
+	// featurefmt lister.
+	normalizeName func(string) string
+}
+
+// ecosystems are the supported ecosystems, by OSV name.
+var ecosystems = map[string]ecosystem{
+	"Go":   {namespace: database.Namespace{Name: "go", VersionFormat: gomod.ParserName}, normalizeName: strings.TrimSpace},
+	"PyPI": {namespace: database.Namespace{Name: "python", VersionFormat: pep440.ParserName}, normalizeName: normalizePythonName},
+	"npm":  {namespace: database.Namespace{Name: "npm", VersionFormat: semver.ParserName}, normalizeName: strings.TrimSpace},
+}
+
+// defaultEcosystems are the ecosystems fetched when none is configured.
+var defaultEcosystems = []string{"Go", "PyPI"}
+
+// separatorsRegexp matches the separators that are equivalent in the names
+// of the Python packages, as specified by PEP 503.
+var separatorsRegexp = regexp.MustCompile(`[-_.]+`)
+
+// severities maps the severities given by the GitHub advisories.
+var severities = map[string]database.Severity{
+	"LOW":      database.LowSeverity,
+	"MODERATE": database.MediumSeverity,
+	"HIGH":     database.HighSeverity,
+	"CRITICAL": database.CriticalSeverity,
+}
+
+// Config is the configuration of the OSV updater, under the "osv" key of the
+// updater configuration.
+type Config struct {
+	// Ecosystems are the OSV names of the ecosystems whose advisories are
+	// fetched, among Go, PyPI and npm.
+	Ecosystems []string
+}
+
+// advisory is an advisory in the OSV format.
+type advisory struct {
+	ID               string   `json:"id"`
+	Summary          string   `json:"summary"`
+	Details          string   `json:"details"`
+	Withdrawn        string   `json:"withdrawn"`
+	Aliases          []string `json:"aliases"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced   string `json:"introduced"`
+				Fixed        string `json:"fixed"`
+				LastAffected string `json:"last_affected"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+type updater struct {
+	ecosystems []string
+}
+
+func init() {
+	vulnsrc.RegisterUpdater(updaterName, &updater{ecosystems: defaultEcosystems})
+}
+
+func (u *updater) Configure(params map[string]interface{}) error {
+	if _, ok := params["osv"]; !ok {
+		return nil
+	}
+
+	var config Config
+	yamlConfig, err := yaml.Marshal(params["osv"])
+	if err != nil {
+		return errors.New("invalid configuration")
+	}
+	if err := yaml.Unmarshal(yamlConfig, &config); err != nil {
+		return errors.New("invalid configuration")
+	}
+
+	if len(config.Ecosystems) == 0 {
+		return nil
+	}
+
+	if err := ValidateEcosystems(config.Ecosystems); err != nil {
+		return err
+	}
+	u.ecosystems = config.Ecosystems
+
+	return nil
+}
+
+func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
+	log.WithField("package", "OSV").Info("Start fetching vulnerabilities")
+
+	// The flag contains the hashes of the last processed exports, by
+	// ecosystem.
+	flagValue, _, err := database.FindKeyValueAndRollback(datastore, updaterFlag)
+	if err != nil {
+		return
+	}
+	knownHashes := parseHashes(flagValue)
+
+	var (
+		hashes  = make([]string, 0, len(u.ecosystems))
+		changed bool
+	)
+	for _, name := range u.ecosystems {
+		vulnerabilities, hash, err := fetchExport(name, knownHashes[name])
+		if err != nil {
+			return resp, err
+		}
+
+		hashes = append(hashes, name+"="+hash)
+		if hash != knownHashes[name] {
+			changed = true
+			resp.Vulnerabilities = append(resp.Vulnerabilities, vulnerabilities...)
+		}
+	}
+
+	// Each changed export has all the advisories of its ecosystem.
+	if changed {
+		resp.Complete = true
+		resp.FlagName = updaterFlag
+		resp.FlagValue = strings.Join(hashes, ",")
+	} else {
+		log.WithField("package", "OSV").Debug("no update, skip")
+	}
+
+	return resp, nil
+}
+
+func (u *updater) Clean() {}
+
+// parseHashes parses the hashes of the flag, in the "ecosystem=hash" form.
+func parseHashes(flagValue string) map[string]string {
+	hashes := make(map[string]string)
+	for _, field := range strings.Split(flagValue, ",") {
+		if i := strings.Index(field, "="); i >= 0 {
+			hashes[field[:i]] = field[i+1:]
+		}
+	}
+	return hashes
+}
+
+// fetchExport downloads the export of an ecosystem, which must be written to a
+// file to be read as a zip archive, and parses it unless its hash is
+// knownHash.
+func fetchExport(name, knownHash string) ([]database.VulnerabilityWithAffected, string, error) {
+	f, err := ioutil.TempFile(os.TempDir(), "osv-"+name)
+	if err != nil {
+		log.WithError(err).Error("could not create OSV's export file")
+		return nil, "", vulnsrc.ErrFilesystem
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := httputil.Source(updaterName).Download(fmt.Sprintf(exportURL, name), f, nil); err != nil {
+		log.WithError(err).WithField("ecosystem", name).Error("could not download OSV's advisories")
+		return nil, "", commonerr.ErrCouldNotDownload
+	}
+
+	sha := sha256.New()
+	size, err := io.Copy(sha, f)
+	if err != nil {
+		log.WithError(err).WithField("ecosystem", name).Error("could not read OSV's export file")
+		return nil, "", vulnsrc.ErrFilesystem
+	}
+
+	hash := hex.EncodeToString(sha.Sum(nil))
+	if hash == knownHash {
+		return nil, hash, nil
+	}
+
+	vulnerabilities, err := parseExport(name, f, size)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return vulnerabilities, hash, nil
+}
+
+// parseExport parses the advisories of an export, which is a zip archive with
+// an advisory per file.
+func parseExport(name string, r io.ReaderAt, size int64) ([]database.VulnerabilityWithAffected, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		log.WithError(err).WithField("ecosystem", name).Error("could not open OSV's export")
+		return nil, commonerr.ErrCouldNotParse
+	}
+
+	var vulnerabilities []database.VulnerabilityWithAffected
+	for _, file := range archive.File {
+		rc, err := file.Open()
+		if err != nil {
+			log.WithError(err).WithField("advisory", file.Name).Error("could not open OSV's advisory")
+			return nil, commonerr.ErrCouldNotParse
+		}
+
+		vulns, err := ParseAdvisory(rc, []string{name}, osvURL)
+		rc.Close()
+		if err != nil {
+			log.WithError(err).WithField("advisory", file.Name).Error("could not unmarshal OSV's advisory")
+			return nil, commonerr.ErrCouldNotParse
+		}
+		vulnerabilities = append(vulnerabilities, vulns...)
+	}
+
+	// Sort the vulnerabilities so that the response is stable.
+	sort.Slice(vulnerabilities, func(i, j int) bool { return vulnerabilities[i].Name < vulnerabilities[j].Name })
+	return vulnerabilities, nil
+}
+
+// ValidateEcosystems ensures that the given OSV names of ecosystems are all
+// supported, among Go, PyPI and npm.
+func ValidateEcosystems(names []string) error {
+	for _, name := range names {
+		if _, ok := ecosystems[name]; !ok {
+			return fmt.Errorf("unsupported ecosystem %q", name)
+		}
+	}
+	return nil
+}
+
+// ParseAdvisory parses an advisory in the OSV format and returns its
+// vulnerability in the namespace of each of the given ecosystems that it
+// affects, which link to the given base URL followed by the ID of the
+// advisory. Nothing is returned for a withdrawn advisory.
+//
+// It is shared with the other data sources of advisories in the OSV format,
+// whose ecosystems must be valid.
+func ParseAdvisory(r io.Reader, ecosystemNames []string, link string) ([]database.VulnerabilityWithAffected, error) {
+	var adv advisory
+	if err := json.NewDecoder(r).Decode(&adv); err != nil {
+		return nil, err
+	}
+
+	var vulnerabilities []database.VulnerabilityWithAffected
+	for _, name := range ecosystemNames {
+		if vulnerability, ok := adv.vulnerability(name, link); ok {
+			vulnerabilities = append(vulnerabilities, vulnerability)
+		}
+	}
+	return vulnerabilities, nil
+}
+
+// vulnerability returns the vulnerability of the advisory in the namespace of
+// the ecosystem, which is absent if the advisory is withdrawn or affects no
+// package of the ecosystem.
+func (adv *advisory) vulnerability(name, link string) (database.VulnerabilityWithAffected, bool) {
+	eco := ecosystems[name]
+	vulnerability := database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:        adv.ID,
+			Namespace:   eco.namespace,
+			Description: adv.Summary,
+			Link:        link + adv.ID,
+			Severity:    database.UnknownSeverity,
+		},
+	}
+
+	if adv.Withdrawn != "" {
+		return vulnerability, false
+	}
+
+	if vulnerability.Description == "" {
+		vulnerability.Description = adv.Details
+	}
+
+	if severity, ok := severities[strings.ToUpper(adv.DatabaseSpecific.Severity)]; ok {
+		vulnerability.Severity = severity
+	}
+
+	if len(adv.Aliases) > 0 {
+		vulnerability.Metadata = database.MetadataMap{"OSV": map[string]interface{}{"Aliases": adv.Aliases}}
+	}
+
+	for _, affected := range adv.Affected {
+		if affected.Package.Ecosystem != name {
+			continue
+		}
+
+		for _, rng := range affected.Ranges {
+			if rng.Type != rangeSemver && rng.Type != rangeEcosystem {
+				continue
+			}
+
+			// The events of a range alternate between an introducing version
+			// and a fixing or last affected version, an introducing version
+			// without a following event being affected up to the latest
+			// version.
+			var introduced string
+			var open bool
+			for _, event := range rng.Events {
+				switch {
+				case event.Introduced != "":
+					introduced, open = event.Introduced, true
+				case event.Fixed != "" && open:
+					vulnerability.Affected = appendAffected(vulnerability.Affected, eco, affected.Package.Name, introduced, event.Fixed, "")
+					open = false
+				case event.LastAffected != "" && open:
+					vulnerability.Affected = appendAffected(vulnerability.Affected, eco, affected.Package.Name, introduced, "", event.LastAffected)
+					open = false
+				}
+			}
+
+			if open {
+				vulnerability.Affected = appendAffected(vulnerability.Affected, eco, affected.Package.Name, introduced, "", "")
+			}
+		}
+	}
+
+	return vulnerability, len(vulnerability.Affected) > 0
+}
+
+// appendAffected appends the feature affected from the introduced version to
+// the fixed version or else to the last affected version, which is the latest
+// version if both are empty.
+func appendAffected(affected []database.AffectedFeature, eco ecosystem, packageName, introduced, fixed, lastAffected string) []database.AffectedFeature {
+	// Every version is affected when the vulnerability is introduced in 0.
+	if introduced == "0" {
+		introduced = ""
+	}
+
+	affectedVersion := fixed
+	if fixed == "" {
+		affectedVersion = versionfmt.MaxVersion
+	}
+
+	for _, version := range []string{introduced, fixed, lastAffected} {
+		if version == "" {
+			continue
+		}
+
+		if err := versionfmt.Valid(eco.namespace.VersionFormat, version); err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"version":      version,
+				"package name": packageName,
+			}).Warning("could not parse package version, skipping")
+			return affected
+		}
+	}
+
+	return append(affected, database.AffectedFeature{
+		AffectedType:        affectedType,
+		FeatureName:         eco.normalizeName(packageName),
+		AffectedVersion:     affectedVersion,
+		FixedInVersion:      fixed,
+		IntroducedInVersion: introduced,
+		LastAffectedVersion: lastAffected,
+		Namespace:           eco.namespace,
+	})
+}
+
+// normalizePythonName returns the normalized form of the name of a Python
+// package, like the pip lister does.
+func normalizePythonName(name string) string {
+	return separatorsRegexp.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-")
+}
@@ -0,0 +1,134 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package osv
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/pep440"
+)
+
+// exportTestdata builds an export with the advisories of the testdata.
+func exportTestdata(t *testing.T) *bytes.Reader {
+	_, filename, _, _ := runtime.Caller(0)
+	filenames, err := filepath.Glob(filepath.Join(filepath.Dir(filename), "testdata", "*.json"))
+	require.Nil(t, err)
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, filename := range filenames {
+		d, err := ioutil.ReadFile(filename)
+		require.Nil(t, err)
+
+		f, err := w.Create(filepath.Base(filename))
+		require.Nil(t, err)
+		_, err = f.Write(d)
+		require.Nil(t, err)
+	}
+	require.Nil(t, w.Close())
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestOSVParser(t *testing.T) {
+	export := exportTestdata(t)
+	vulns, err := parseExport("PyPI", export, export.Size())
+	require.Nil(t, err)
+
+	namespace := database.Namespace{Name: "python", VersionFormat: pep440.ParserName}
+	if assert.Len(t, vulns, 2) {
+		assert.Equal(t, "GHSA-2023-0001", vulns[0].Name)
+		assert.Equal(t, namespace, vulns[0].Namespace)
+		assert.Equal(t, "Denial of service in Requests_Toolbelt", vulns[0].Description)
+		assert.Equal(t, "https://osv.dev/vulnerability/GHSA-2023-0001", vulns[0].Link)
+		assert.Equal(t, database.MediumSeverity, vulns[0].Severity)
+		assert.Equal(t, database.MetadataMap{"OSV": map[string]interface{}{"Aliases": []string{"CVE-2023-0001", "PYSEC-2023-1"}}}, vulns[0].Metadata)
+
+		// The range of commits and the packages of the other ecosystems are
+		// ignored.
+		assert.Equal(t, []database.AffectedFeature{
+			{
+				AffectedType:    affectedType,
+				FeatureName:     "requests-toolbelt",
+				AffectedVersion: "0.9.1",
+				FixedInVersion:  "0.9.1",
+				Namespace:       namespace,
+			},
+			{
+				AffectedType:        affectedType,
+				FeatureName:         "requests-toolbelt",
+				AffectedVersion:     "1.0.2",
+				FixedInVersion:      "1.0.2",
+				IntroducedInVersion: "1.0.0",
+				Namespace:           namespace,
+			},
+		}, vulns[0].Affected)
+
+		// The unfixed range affects the latest version and the range with a
+		// last affected version affects the versions up to it.
+		assert.Equal(t, "GHSA-2023-0002", vulns[1].Name)
+		assert.Equal(t, database.CriticalSeverity, vulns[1].Severity)
+		assert.Equal(t, []database.AffectedFeature{
+			{
+				AffectedType:        affectedType,
+				FeatureName:         "yaml-loader",
+				AffectedVersion:     versionfmt.MaxVersion,
+				IntroducedInVersion: "2.1",
+				Namespace:           namespace,
+			},
+			{
+				AffectedType:        affectedType,
+				FeatureName:         "yaml-loader",
+				AffectedVersion:     versionfmt.MaxVersion,
+				IntroducedInVersion: "1.0",
+				LastAffectedVersion: "1.4",
+				Namespace:           namespace,
+			},
+		}, vulns[1].Affected)
+	}
+
+	vulns, err = parseExport("npm", export, export.Size())
+	require.Nil(t, err)
+	if assert.Len(t, vulns, 1) {
+		assert.Equal(t, "npm", vulns[0].Namespace.Name)
+		assert.Equal(t, "toolbelt", vulns[0].Affected[0].FeatureName)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	u := &updater{ecosystems: defaultEcosystems}
+	require.Nil(t, u.Configure(map[string]interface{}{"http": map[string]interface{}{}}))
+	assert.Equal(t, defaultEcosystems, u.ecosystems)
+
+	require.Nil(t, u.Configure(map[string]interface{}{"osv": map[string]interface{}{"ecosystems": []string{"npm"}}}))
+	assert.Equal(t, []string{"npm"}, u.ecosystems)
+
+	assert.NotNil(t, u.Configure(map[string]interface{}{"osv": map[string]interface{}{"ecosystems": []string{"crates.io"}}}))
+}
+
+func TestParseHashes(t *testing.T) {
+	assert.Equal(t, map[string]string{"Go": "a", "PyPI": "b"}, parseHashes("Go=a,PyPI=b"))
+	assert.Empty(t, parseHashes(""))
+}
@@ -0,0 +1,239 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package photon implements a vulnerability source updater using the CVE
+// metadata that VMware publishes for each Photon OS release.
+package photon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+	"github.com/coreos/clair/ext/vulnmdsrc/nvd"
+	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/httputil"
+)
+
+const (
+	updaterFlag  = "photonUpdater"
+	updaterName  = "photon"
+	nvdURLPrefix = "https://cve.mitre.org/cgi-bin/cvename.cgi?name="
+	affectedType = database.AffectBinaryPackage
+
+	// unfixedVersion is the resolved version of the packages that are not
+	// fixed yet.
+	unfixedVersion = "NA"
+)
+
+// release is a Photon OS release whose CVE metadata is published as a JSON
+// feed.
+type release struct {
+	// name is the version of the release in its namespace, e.g. "3.0" for
+	// "photon:3.0".
+	name string
+	url  string
+}
+
+var releases = []release{
+	{name: "3.0", url: "https://packages.vmware.com/photon/photon_cve_metadata/cve_data_photon3.0.json"},
+	{name: "4.0", url: "https://packages.vmware.com/photon/photon_cve_metadata/cve_data_photon4.0.json"},
+	{name: "5.0", url: "https://packages.vmware.com/photon/photon_cve_metadata/cve_data_photon5.0.json"},
+}
+
+// cve is an entry of a feed, which tells how a CVE affects a package.
+type cve struct {
+	ID              string  `json:"cve_id"`
+	Package         string  `json:"pkg"`
+	Score           float64 `json:"cve_score"`
+	ResolvedVersion string  `json:"res_ver"`
+}
+
+type updater struct{}
+
+func init() {
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
+}
+
+func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
+	log.WithField("package", "Photon OS").Info("Start fetching vulnerabilities")
+
+	// The flag contains the hashes of the last processed feeds, in the order
+	// of releases.
+	flagValue, _, err := database.FindKeyValueAndRollback(datastore, updaterFlag)
+	if err != nil {
+		return
+	}
+	knownHashes := strings.Split(flagValue, ",")
+
+	var (
+		hashes  = make([]string, 0, len(releases))
+		changed bool
+	)
+	for i, r := range releases {
+		var knownHash string
+		if i < len(knownHashes) {
+			knownHash = knownHashes[i]
+		}
+
+		vulnerabilities, hash, err := fetchRelease(r, knownHash)
+		if err != nil {
+			// An unreachable feed only skips its release, which is fetched
+			// again by the next update.
+			log.WithError(err).WithField("release", r.name).Warning("skipping Photon OS release")
+			resp.Notes = append(resp.Notes, fmt.Sprintf("Photon OS %s vulnerabilities could not be updated.", r.name))
+			hashes = append(hashes, knownHash)
+			continue
+		}
+
+		hashes = append(hashes, hash)
+		if hash != knownHash {
+			changed = true
+			resp.Vulnerabilities = append(resp.Vulnerabilities, vulnerabilities...)
+		}
+	}
+
+	// Each changed feed lists all the vulnerabilities of its release.
+	if changed {
+		resp.Complete = true
+		resp.FlagName = updaterFlag
+		resp.FlagValue = strings.Join(hashes, ",")
+	} else {
+		log.WithField("package", "Photon OS").Debug("no update")
+	}
+
+	return resp, nil
+}
+
+func (u *updater) Clean() {}
+
+func fetchRelease(r release, knownHash string) ([]database.VulnerabilityWithAffected, string, error) {
+	resp, err := httputil.Source(updaterName).GetWithUserAgent(r.url)
+	if err != nil {
+		log.WithError(err).WithField("release", r.name).Error("could not download Photon OS's CVE metadata")
+		return nil, "", commonerr.ErrCouldNotDownload
+	}
+	defer resp.Body.Close()
+
+	if !httputil.Status2xx(resp) {
+		log.WithFields(log.Fields{"StatusCode": resp.StatusCode, "release": r.name}).Error("Failed to update Photon OS")
+		return nil, "", commonerr.ErrCouldNotDownload
+	}
+
+	return parseFeed(r, resp.Body, knownHash)
+}
+
+// parseFeed parses the feed of a release and returns its vulnerabilities,
+// unless its hash is knownHash.
+func parseFeed(r release, feedReader io.Reader, knownHash string) ([]database.VulnerabilityWithAffected, string, error) {
+	jsonSHA := sha256.New()
+	teedJSONReader := io.TeeReader(feedReader, jsonSHA)
+
+	var cves []cve
+	if err := json.NewDecoder(teedJSONReader).Decode(&cves); err != nil {
+		log.WithError(err).WithField("release", r.name).Error("could not unmarshal Photon OS's CVE metadata")
+		return nil, "", commonerr.ErrCouldNotParse
+	}
+
+	hash := hex.EncodeToString(jsonSHA.Sum(nil))
+	if hash == knownHash {
+		return nil, hash, nil
+	}
+
+	return vulnerabilities(r, cves), hash, nil
+}
+
+// vulnerabilities groups the entries of a feed by CVE.
+func vulnerabilities(r release, cves []cve) (vulns []database.VulnerabilityWithAffected) {
+	namespace := database.Namespace{Name: "photon:" + r.name, VersionFormat: rpm.ParserName}
+
+	vulnsByName := make(map[string]*database.VulnerabilityWithAffected)
+	seen := make(map[string]struct{})
+	for _, c := range cves {
+		name, pkg := strings.TrimSpace(c.ID), strings.TrimSpace(c.Package)
+		if name == "" || pkg == "" {
+			continue
+		}
+
+		// A package may be listed several times for the same CVE.
+		key := name + ":" + pkg
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		affected := database.AffectedFeature{
+			AffectedType: affectedType,
+			FeatureName:  pkg,
+			Namespace:    namespace,
+		}
+
+		version := strings.TrimSpace(c.ResolvedVersion)
+		if version == unfixedVersion || version == "" {
+			affected.AffectedVersion = versionfmt.MaxVersion
+		} else {
+			if err := versionfmt.Valid(rpm.ParserName, version); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"version":      version,
+					"package name": pkg,
+				}).Warning("could not parse package version, skipping")
+				continue
+			}
+			version = rpm.NormalizeVersion(version)
+			affected.AffectedVersion = version
+			affected.FixedInVersion = version
+		}
+		seen[key] = struct{}{}
+
+		vuln, ok := vulnsByName[name]
+		if !ok {
+			vuln = &database.VulnerabilityWithAffected{
+				Vulnerability: database.Vulnerability{
+					Name:      name,
+					Link:      nvdURLPrefix + name,
+					Severity:  database.UnknownSeverity,
+					Namespace: namespace,
+				},
+			}
+			vulnsByName[name] = vuln
+		}
+
+		// The feed has the CVSS score of the CVE, which is missing for the
+		// CVEs that are not scored yet.
+		if c.Score > 0 {
+			if severity := nvd.SeverityFromCVSS(c.Score); severity.Compare(vuln.Severity) > 0 {
+				vuln.Severity = severity
+			}
+		}
+
+		vuln.Affected = append(vuln.Affected, affected)
+	}
+
+	for _, vuln := range vulnsByName {
+		vulns = append(vulns, *vuln)
+	}
+
+	// Sort the vulnerabilities so that the response is stable.
+	sort.Slice(vulns, func(i, j int) bool { return vulns[i].Name < vulns[j].Name })
+	return
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package photon
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+)
+
+func TestPhotonParser(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	path := filepath.Join(filepath.Dir(filename))
+
+	testFile, _ := os.Open(filepath.Join(path, "/testdata/cve_data_photon3.0.json"))
+	defer testFile.Close()
+
+	namespace := database.Namespace{
+		Name:          "photon:3.0",
+		VersionFormat: rpm.ParserName,
+	}
+
+	vulnerabilities, hash, err := parseFeed(releases[0], testFile, "")
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 3) {
+		assert.NotEmpty(t, hash)
+
+		assert.Equal(t, "CVE-2019-1547", vulnerabilities[0].Name)
+		assert.Equal(t, namespace, vulnerabilities[0].Namespace)
+		assert.Equal(t, "https://cve.mitre.org/cgi-bin/cvename.cgi?name=CVE-2019-1547", vulnerabilities[0].Link)
+		assert.Equal(t, database.MediumSeverity, vulnerabilities[0].Severity)
+		assert.Equal(t, []database.AffectedFeature{
+			{
+				AffectedType:    affectedType,
+				Namespace:       namespace,
+				FeatureName:     "openssl",
+				AffectedVersion: "1.0.2t-1.ph3",
+				FixedInVersion:  "1.0.2t-1.ph3",
+			},
+			{
+				AffectedType:    affectedType,
+				Namespace:       namespace,
+				FeatureName:     "openssl-c_rehash",
+				AffectedVersion: "1.0.2t-1.ph3",
+				FixedInVersion:  "1.0.2t-1.ph3",
+			},
+		}, vulnerabilities[0].Affected)
+
+		assert.Equal(t, "CVE-2020-8177", vulnerabilities[1].Name)
+		assert.Equal(t, database.HighSeverity, vulnerabilities[1].Severity)
+
+		// The CVEs that are not fixed affect all the versions of the package.
+		assert.Equal(t, "CVE-2021-3156", vulnerabilities[2].Name)
+		assert.Equal(t, database.UnknownSeverity, vulnerabilities[2].Severity)
+		assert.Equal(t, []database.AffectedFeature{
+			{
+				AffectedType:    affectedType,
+				Namespace:       namespace,
+				FeatureName:     "sudo",
+				AffectedVersion: versionfmt.MaxVersion,
+			},
+		}, vulnerabilities[2].Affected)
+	}
+
+	// An unchanged feed is not parsed again.
+	testFile.Seek(0, 0)
+	vulnerabilities, knownHash, err := parseFeed(releases[0], testFile, hash)
+	if assert.Nil(t, err) {
+		assert.Equal(t, hash, knownHash)
+		assert.Len(t, vulnerabilities, 0)
+	}
+}
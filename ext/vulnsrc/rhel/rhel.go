@@ -43,6 +43,7 @@ const (
 	ovalURI        = "https://www.redhat.com/security/data/oval/"
 	rhsaFilePrefix = "com.redhat.rhsa-"
 	updaterFlag    = "rhelUpdater"
+	updaterName    = "rhel"
 	affectedType   = database.AffectBinaryPackage
 )
 
@@ -95,7 +96,7 @@ type criterion struct {
 type updater struct{}
 
 func init() {
-	vulnsrc.RegisterUpdater("rhel", &updater{})
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
 }
 
 func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
@@ -117,7 +118,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 	}
 
 	// Fetch the update list.
-	r, err := httputil.GetWithUserAgent(ovalURI)
+	r, err := httputil.Source(updaterName).GetWithUserAgent(ovalURI)
 	if err != nil {
 		log.WithError(err).Error("could not download RHEL's update list")
 		return resp, commonerr.ErrCouldNotDownload
@@ -145,7 +146,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 
 	for _, rhsa := range rhsaList {
 		// Download the RHSA's XML file.
-		r, err := httputil.GetWithUserAgent(ovalURI + rhsaFilePrefix + strconv.Itoa(rhsa) + ".xml")
+		r, err := httputil.Source(updaterName).GetWithUserAgent(ovalURI + rhsaFilePrefix + strconv.Itoa(rhsa) + ".xml")
 		if err != nil {
 			log.WithError(err).Error("could not download RHEL's update list")
 			return resp, commonerr.ErrCouldNotDownload
@@ -339,6 +340,7 @@ func toFeatures(criteria criteria) []database.AffectedFeature {
 				if err != nil {
 					log.WithError(err).WithField("version", version).Warning("could not parse package version. skipping")
 				} else {
+					version = rpm.NormalizeVersion(version)
 					featureVersion.AffectedVersion = version
 					if version != versionfmt.MaxVersion {
 						featureVersion.FixedInVersion = version
@@ -390,7 +392,7 @@ func severity(sev string) database.Severity {
 	case "Critical":
 		return database.CriticalSeverity
 	default:
-		log.Warningf("could not determine vulnerability severity from: %s.", sev)
+		log.WithField("severity", sev).Warning("could not determine vulnerability severity")
 		return database.UnknownSeverity
 	}
 }
@@ -52,8 +52,8 @@ func TestRHELParserMultipleCVE(t *testing.T) {
 				VersionFormat: rpm.ParserName,
 			},
 			FeatureName:     "firefox",
-			FixedInVersion:  "0:38.1.0-1.el6_6",
-			AffectedVersion: "0:38.1.0-1.el6_6",
+			FixedInVersion:  "38.1.0-1.el6_6",
+			AffectedVersion: "38.1.0-1.el6_6",
 		},
 		{
 			AffectedType: affectedType,
@@ -62,8 +62,8 @@ func TestRHELParserMultipleCVE(t *testing.T) {
 				VersionFormat: rpm.ParserName,
 			},
 			FeatureName:     "firefox",
-			FixedInVersion:  "0:38.1.0-1.el7_1",
-			AffectedVersion: "0:38.1.0-1.el7_1",
+			FixedInVersion:  "38.1.0-1.el7_1",
+			AffectedVersion: "38.1.0-1.el7_1",
 		},
 	}
 
@@ -102,8 +102,8 @@ func TestRHELParserOneCVE(t *testing.T) {
 					VersionFormat: rpm.ParserName,
 				},
 				FeatureName:     "xerces-c",
-				AffectedVersion: "0:3.1.1-7.el7_1",
-				FixedInVersion:  "0:3.1.1-7.el7_1",
+				AffectedVersion: "3.1.1-7.el7_1",
+				FixedInVersion:  "3.1.1-7.el7_1",
 			},
 			{
 				AffectedType: affectedType,
@@ -112,8 +112,8 @@ func TestRHELParserOneCVE(t *testing.T) {
 					VersionFormat: rpm.ParserName,
 				},
 				FeatureName:     "xerces-c-devel",
-				AffectedVersion: "0:3.1.1-7.el7_1",
-				FixedInVersion:  "0:3.1.1-7.el7_1",
+				AffectedVersion: "3.1.1-7.el7_1",
+				FixedInVersion:  "3.1.1-7.el7_1",
 			},
 			{
 				AffectedType: affectedType,
@@ -122,8 +122,8 @@ func TestRHELParserOneCVE(t *testing.T) {
 					VersionFormat: rpm.ParserName,
 				},
 				FeatureName:     "xerces-c-doc",
-				AffectedVersion: "0:3.1.1-7.el7_1",
-				FixedInVersion:  "0:3.1.1-7.el7_1",
+				AffectedVersion: "3.1.1-7.el7_1",
+				FixedInVersion:  "3.1.1-7.el7_1",
 			},
 		}
 
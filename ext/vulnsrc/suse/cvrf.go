@@ -0,0 +1,137 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package suse
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/commonerr"
+)
+
+type cvrf struct {
+	AggregateSeverity string              `xml:"AggregateSeverity"`
+	Relationships     []relationship      `xml:"ProductTree>Relationship"`
+	Vulnerabilities   []cvrfVulnerability `xml:"Vulnerability"`
+}
+
+// relationship identifies a package, given by its name, version and release,
+// as part of a product.
+type relationship struct {
+	Package string `xml:"ProductReference,attr"`
+	Product string `xml:"RelatesToProductReference,attr"`
+	ID      struct {
+		Value string `xml:"ProductID,attr"`
+	} `xml:"FullProductName"`
+}
+
+type cvrfVulnerability struct {
+	CVE      string   `xml:"CVE"`
+	Notes    []note   `xml:"Notes>Note"`
+	Threats  []threat `xml:"Threats>Threat"`
+	Statuses []status `xml:"ProductStatuses>Status"`
+}
+
+type note struct {
+	Type  string `xml:"Type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type threat struct {
+	Type        string `xml:"Type,attr"`
+	Description string `xml:"Description"`
+}
+
+type status struct {
+	Type       string   `xml:"Type,attr"`
+	ProductIDs []string `xml:"ProductID"`
+}
+
+func parseCVRF(cvrfReader io.Reader) (vulnerabilities []database.VulnerabilityWithAffected, err error) {
+	// Decode the XML.
+	var doc cvrf
+	err = xml.NewDecoder(cvrfReader).Decode(&doc)
+	if err != nil {
+		log.WithError(err).Error("could not decode SUSE's CVRF advisory")
+		err = commonerr.ErrCouldNotParse
+		return
+	}
+
+	// Index the packages of the SLES products.
+	packages := make(map[string]relationship)
+	for _, r := range doc.Relationships {
+		if namespace(r.Product) != "" {
+			packages[r.ID.Value] = r
+		}
+	}
+
+	for _, v := range doc.Vulnerabilities {
+		name := strings.TrimSpace(v.CVE)
+		if name == "" {
+			continue
+		}
+
+		vulnerability := database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{
+				Name:     name,
+				Link:     link(name),
+				Severity: severity(doc.AggregateSeverity),
+			},
+		}
+
+		for _, n := range v.Notes {
+			if n.Type == "General" {
+				vulnerability.Description = strings.Join(strings.Fields(n.Value), " ")
+				break
+			}
+		}
+
+		for _, t := range v.Threats {
+			if t.Type == "Impact" {
+				if sev := severity(t.Description); sev != database.UnknownSeverity {
+					vulnerability.Severity = sev
+				}
+				break
+			}
+		}
+
+		for _, s := range v.Statuses {
+			if s.Type != "Fixed" {
+				continue
+			}
+
+			for _, id := range s.ProductIDs {
+				r, ok := packages[strings.TrimSpace(id)]
+				if !ok {
+					continue
+				}
+
+				if feature, ok := newAffectedFeature(namespace(r.Product), r.Package); ok {
+					vulnerability.Affected = append(vulnerability.Affected, feature)
+				}
+			}
+		}
+
+		if len(vulnerability.Affected) > 0 {
+			vulnerabilities = append(vulnerabilities, vulnerability)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,199 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package suse
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/commonerr"
+)
+
+const (
+	installedSuffix = " is installed"
+	productPrefix   = "SUSE "
+)
+
+type oval struct {
+	Definitions []definition `xml:"definitions>definition"`
+}
+
+type definition struct {
+	Title       string   `xml:"metadata>title"`
+	Description string   `xml:"metadata>description"`
+	Criteria    criteria `xml:"criteria"`
+	Severity    string   `xml:"metadata>advisory>severity"`
+}
+
+type criteria struct {
+	Operator   string      `xml:"operator,attr"`
+	Criterias  []*criteria `xml:"criteria"`
+	Criterions []criterion `xml:"criterion"`
+}
+
+type criterion struct {
+	Comment string `xml:"comment,attr"`
+}
+
+func parseOVAL(ovalReader io.Reader) (vulnerabilities []database.VulnerabilityWithAffected, err error) {
+	// Decode the XML.
+	var ov oval
+	err = xml.NewDecoder(ovalReader).Decode(&ov)
+	if err != nil {
+		log.WithError(err).Error("could not decode SUSE's OVAL definitions")
+		err = commonerr.ErrCouldNotParse
+		return
+	}
+
+	// Iterate over the definitions, which are named after the CVE they
+	// describe, and collect the ones that affect at least one package.
+	for _, definition := range ov.Definitions {
+		name := strings.TrimSpace(definition.Title)
+		if !strings.HasPrefix(name, "CVE-") {
+			continue
+		}
+
+		pkgs := toFeatures(definition.Criteria)
+		if len(pkgs) > 0 {
+			vulnerabilities = append(vulnerabilities, database.VulnerabilityWithAffected{
+				Vulnerability: database.Vulnerability{
+					Name:        name,
+					Link:        link(name),
+					Severity:    severity(definition.Severity),
+					Description: strings.Join(strings.Fields(definition.Description), " "),
+				},
+				Affected: pkgs,
+			})
+		}
+	}
+
+	return
+}
+
+func getCriterions(node criteria) [][]criterion {
+	// Only keep the criterions about installed products and packages.
+	var criterions []criterion
+	for _, c := range node.Criterions {
+		if strings.HasSuffix(c.Comment, installedSuffix) {
+			criterions = append(criterions, c)
+		}
+	}
+
+	if node.Operator == "AND" {
+		return [][]criterion{criterions}
+	} else if node.Operator == "OR" {
+		var possibilities [][]criterion
+		for _, c := range criterions {
+			possibilities = append(possibilities, []criterion{c})
+		}
+		return possibilities
+	}
+
+	return [][]criterion{}
+}
+
+func getPossibilities(node criteria) [][]criterion {
+	if len(node.Criterias) == 0 {
+		return getCriterions(node)
+	}
+
+	var possibilitiesToCompose [][][]criterion
+	for _, criteria := range node.Criterias {
+		possibilitiesToCompose = append(possibilitiesToCompose, getPossibilities(*criteria))
+	}
+	if len(node.Criterions) > 0 {
+		possibilitiesToCompose = append(possibilitiesToCompose, getCriterions(node))
+	}
+
+	var possibilities [][]criterion
+	if node.Operator == "AND" {
+		for _, possibility := range possibilitiesToCompose[0] {
+			possibilities = append(possibilities, possibility)
+		}
+
+		for _, possibilityGroup := range possibilitiesToCompose[1:] {
+			var newPossibilities [][]criterion
+
+			for _, possibility := range possibilities {
+				for _, possibilityInGroup := range possibilityGroup {
+					var p []criterion
+					p = append(p, possibility...)
+					p = append(p, possibilityInGroup...)
+					newPossibilities = append(newPossibilities, p)
+				}
+			}
+
+			possibilities = newPossibilities
+		}
+	} else if node.Operator == "OR" {
+		for _, possibilityGroup := range possibilitiesToCompose {
+			for _, possibility := range possibilityGroup {
+				possibilities = append(possibilities, possibility)
+			}
+		}
+	}
+
+	return possibilities
+}
+
+func toFeatures(criteria criteria) []database.AffectedFeature {
+	// A definition lists the same package for several service packs, which is
+	// deduplicated by keeping the latest fix.
+	features := make(map[string]database.AffectedFeature)
+
+	for _, criterions := range getPossibilities(criteria) {
+		var ns, nvr string
+		for _, c := range criterions {
+			subject := strings.TrimSuffix(c.Comment, installedSuffix)
+			if strings.HasPrefix(subject, productPrefix) {
+				ns = namespace(subject)
+			} else {
+				nvr = subject
+			}
+		}
+
+		// Products other than SLES are skipped.
+		if ns == "" || nvr == "" {
+			continue
+		}
+
+		feature, ok := newAffectedFeature(ns, nvr)
+		if !ok {
+			continue
+		}
+
+		key := feature.Namespace.Name + ":" + feature.FeatureName
+		if current, ok := features[key]; !ok || compareFixedIn(feature, current) > 0 {
+			features[key] = feature
+		}
+	}
+
+	keys := make([]string, 0, len(features))
+	for key := range features {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var affected []database.AffectedFeature
+	for _, key := range keys {
+		affected = append(affected, features[key])
+	}
+	return affected
+}
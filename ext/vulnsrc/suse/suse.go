@@ -0,0 +1,431 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package suse implements a vulnerability source updater using the OVAL
+// definitions and the CVRF advisories of SUSE Linux Enterprise Server.
+package suse
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/httputil"
+)
+
+const (
+	ovalURI         = "https://ftp.suse.com/pub/projects/security/oval/"
+	ovalFilePrefix  = "suse.linux.enterprise.server."
+	cvrfURI         = "https://ftp.suse.com/pub/projects/security/cvrf/"
+	cveURI          = "https://www.suse.com/security/cve/"
+	updaterFlag     = "suseUpdater"
+	updaterName     = "suse"
+	affectedType    = database.AffectBinaryPackage
+	namespacePrefix = "sles:"
+)
+
+// The sources of the fixed versions of a package, from the least to the most
+// specific. OVAL definitions aggregate all the service packs of a release,
+// whereas a CVRF advisory gives the exact package that fixed it.
+const (
+	sourceOVAL = iota
+	sourceCVRF
+)
+
+var (
+	// ovalReleases are the major releases of SLES whose OVAL definitions are
+	// fetched.
+	ovalReleases = []int{12, 15}
+
+	cvrfRegexp = regexp.MustCompile(`cvrf-suse-su-\d{4}-\d+-\d+\.xml`)
+
+	// productRegexp matches the SLES products of both sources, including the
+	// service packs and their long term support.
+	productRegexp = regexp.MustCompile(`^SUSE Linux Enterprise Server (\d+)(?: SP\d+)?(?:-LTSS)?$`)
+)
+
+type updater struct {
+	cvrfLocalPath string
+}
+
+func init() {
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
+}
+
+func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
+	log.WithField("package", "SUSE").Info("Start fetching vulnerabilities")
+
+	latestHash, ok, err := database.FindKeyValueAndRollback(datastore, updaterFlag)
+	if err != nil {
+		return
+	}
+
+	if !ok {
+		latestHash = ""
+	}
+
+	// The hash covers the OVAL definitions and the names of the CVRF
+	// advisories, which are never modified once published.
+	sha := sha256.New()
+	merged := make(advisories)
+	for _, release := range ovalReleases {
+		if err = fetchOVAL(release, merged, sha); err != nil {
+			return
+		}
+	}
+
+	filenames, err := u.syncCVRF()
+	if err != nil {
+		return
+	}
+
+	for _, filename := range filenames {
+		io.WriteString(sha, filename)
+		if err = u.parseCachedCVRF(filename, merged); err != nil {
+			return
+		}
+	}
+
+	hash := hex.EncodeToString(sha.Sum(nil))
+	if latestHash == hash {
+		log.WithField("package", "SUSE").Debug("no update")
+		return resp, nil
+	}
+
+	resp.Vulnerabilities = merged.vulnerabilities()
+	resp.Complete = true
+	resp.FlagName = updaterFlag
+	resp.FlagValue = hash
+	return resp, nil
+}
+
+func (u *updater) Clean() {
+	if u.cvrfLocalPath != "" {
+		os.RemoveAll(u.cvrfLocalPath)
+	}
+}
+
+// fetchOVAL downloads the OVAL definitions of a SLES release and merges them.
+//
+// The definitions are verified with their companion SHA-256 file when it is
+// available.
+func fetchOVAL(release int, merged advisories, sha hash.Hash) error {
+	uri := ovalURI + ovalFilePrefix + strconv.Itoa(release) + ".xml.gz"
+
+	var checksum httputil.Checksum
+	if digest, err := httputil.Source(updaterName).FetchSHA256(uri + ".sha256"); err != nil {
+		log.WithError(err).Debug("could not get the checksum of SUSE's OVAL definitions")
+	} else {
+		checksum = httputil.SHA256Checksum(digest)
+	}
+
+	f, err := ioutil.TempFile(os.TempDir(), "suse-oval")
+	if err != nil {
+		log.WithError(err).Error("could not create SUSE's OVAL definitions file")
+		return vulnsrc.ErrFilesystem
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := httputil.Source(updaterName).Download(uri, f, checksum); err != nil {
+		log.WithError(err).Error("could not download SUSE's OVAL definitions")
+		return commonerr.ErrCouldNotDownload
+	}
+
+	gz, err := gzip.NewReader(io.TeeReader(f, sha))
+	if err != nil {
+		log.WithError(err).Error("could not decompress SUSE's OVAL definitions")
+		return commonerr.ErrCouldNotParse
+	}
+	defer gz.Close()
+
+	vulnerabilities, err := parseOVAL(gz)
+	if err != nil {
+		return err
+	}
+
+	merged.add(vulnerabilities, sourceOVAL)
+	return nil
+}
+
+// syncCVRF downloads the CVRF advisories that are not cached yet and returns
+// the names of all the advisories, in order.
+func (u *updater) syncCVRF() ([]string, error) {
+	if u.cvrfLocalPath == "" {
+		var err error
+		if u.cvrfLocalPath, err = ioutil.TempDir(os.TempDir(), "suse-cvrf"); err != nil {
+			log.WithError(err).Error("could not create SUSE's advisories directory")
+			return nil, vulnsrc.ErrFilesystem
+		}
+	}
+
+	r, err := httputil.Source(updaterName).GetWithUserAgent(cvrfURI)
+	if err != nil {
+		log.WithError(err).Error("could not download SUSE's advisories list")
+		return nil, commonerr.ErrCouldNotDownload
+	}
+	defer r.Body.Close()
+
+	if !httputil.Status2xx(r) {
+		log.WithField("StatusCode", r.StatusCode).Error("Failed to update SUSE")
+		return nil, commonerr.ErrCouldNotDownload
+	}
+
+	filenameSet := make(map[string]struct{})
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		for _, filename := range cvrfRegexp.FindAllString(scanner.Text(), -1) {
+			filenameSet[filename] = struct{}{}
+		}
+	}
+
+	filenames := make([]string, 0, len(filenameSet))
+	for filename := range filenameSet {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		if _, err := os.Stat(filepath.Join(u.cvrfLocalPath, filename)); err == nil {
+			continue
+		}
+
+		if err := u.downloadCVRF(filename); err != nil {
+			return nil, err
+		}
+	}
+
+	return filenames, nil
+}
+
+func (u *updater) downloadCVRF(filename string) error {
+	r, err := httputil.Source(updaterName).GetWithUserAgent(cvrfURI + filename)
+	if err != nil {
+		log.WithError(err).WithField("advisory", filename).Error("could not download SUSE's advisory")
+		return commonerr.ErrCouldNotDownload
+	}
+	defer r.Body.Close()
+
+	if !httputil.Status2xx(r) {
+		log.WithField("StatusCode", r.StatusCode).Error("Failed to update SUSE")
+		return commonerr.ErrCouldNotDownload
+	}
+
+	// Write to a temporary file first so that an interrupted download is not
+	// mistaken for a cached advisory.
+	f, err := ioutil.TempFile(u.cvrfLocalPath, filename)
+	if err != nil {
+		log.WithError(err).Error("could not create SUSE's advisory file")
+		return vulnsrc.ErrFilesystem
+	}
+
+	_, err = io.Copy(f, r.Body)
+	f.Close()
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(u.cvrfLocalPath, filename))
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+		log.WithError(err).WithField("advisory", filename).Error("could not write SUSE's advisory")
+		return vulnsrc.ErrFilesystem
+	}
+
+	return nil
+}
+
+func (u *updater) parseCachedCVRF(filename string, merged advisories) error {
+	f, err := os.Open(filepath.Join(u.cvrfLocalPath, filename))
+	if err != nil {
+		log.WithError(err).WithField("advisory", filename).Error("could not open SUSE's advisory")
+		return vulnsrc.ErrFilesystem
+	}
+	defer f.Close()
+
+	vulnerabilities, err := parseCVRF(f)
+	if err != nil {
+		return err
+	}
+
+	merged.add(vulnerabilities, sourceCVRF)
+	return nil
+}
+
+// advisories merges the vulnerabilities of both sources by name.
+type advisories map[string]*advisory
+
+type advisory struct {
+	database.Vulnerability
+
+	// affected is indexed by namespace and feature name.
+	affected map[string]sourcedFeature
+}
+
+type sourcedFeature struct {
+	database.AffectedFeature
+	source int
+}
+
+func (a advisories) add(vulnerabilities []database.VulnerabilityWithAffected, source int) {
+	for _, v := range vulnerabilities {
+		adv, ok := a[v.Name]
+		if !ok {
+			adv = &advisory{Vulnerability: v.Vulnerability, affected: make(map[string]sourcedFeature)}
+			a[v.Name] = adv
+		}
+
+		if adv.Description == "" {
+			adv.Description = v.Description
+		}
+
+		if adv.Severity == database.UnknownSeverity {
+			adv.Severity = v.Severity
+		}
+
+		for _, feature := range v.Affected {
+			key := feature.Namespace.Name + ":" + feature.FeatureName
+			if current, ok := adv.affected[key]; ok {
+				if source < current.source {
+					continue
+				}
+
+				// A source lists the fix of every service pack of a release,
+				// of which the namespace only keeps the latest.
+				if source == current.source && compareFixedIn(feature, current.AffectedFeature) <= 0 {
+					continue
+				}
+			}
+
+			adv.affected[key] = sourcedFeature{feature, source}
+		}
+	}
+}
+
+func compareFixedIn(a, b database.AffectedFeature) int {
+	cmp, err := versionfmt.Compare(rpm.ParserName, a.FixedInVersion, b.FixedInVersion)
+	if err != nil {
+		log.WithError(err).WithField("feature", a.FeatureName).Warning("could not compare fixed versions")
+		return 0
+	}
+	return cmp
+}
+
+// vulnerabilities returns the merged vulnerabilities, sorted by name.
+func (a advisories) vulnerabilities() []database.VulnerabilityWithAffected {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vulnerabilities := make([]database.VulnerabilityWithAffected, 0, len(a))
+	for _, name := range names {
+		adv := a[name]
+
+		keys := make([]string, 0, len(adv.affected))
+		for key := range adv.affected {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		vulnerability := database.VulnerabilityWithAffected{Vulnerability: adv.Vulnerability}
+		for _, key := range keys {
+			vulnerability.Affected = append(vulnerability.Affected, adv.affected[key].AffectedFeature)
+		}
+
+		vulnerabilities = append(vulnerabilities, vulnerability)
+	}
+
+	return vulnerabilities
+}
+
+// namespace returns the namespace of a SLES product, or an empty string if the
+// product is not SLES.
+func namespace(product string) string {
+	r := productRegexp.FindStringSubmatch(strings.TrimSpace(product))
+	if len(r) != 2 {
+		return ""
+	}
+	return namespacePrefix + r[1]
+}
+
+// newAffectedFeature returns the feature fixed by a package, given by its
+// name, version and release.
+func newAffectedFeature(ns, nvr string) (database.AffectedFeature, bool) {
+	// The name of a package may contain dashes, but not its version nor its
+	// release.
+	release := strings.LastIndex(nvr, "-")
+	if release <= 0 {
+		return database.AffectedFeature{}, false
+	}
+
+	version := strings.LastIndex(nvr[:release], "-")
+	if version <= 0 {
+		return database.AffectedFeature{}, false
+	}
+
+	fixedIn := nvr[version+1:]
+	if err := versionfmt.Valid(rpm.ParserName, fixedIn); err != nil {
+		log.WithError(err).WithField("version", fixedIn).Warning("could not parse package version. skipping")
+		return database.AffectedFeature{}, false
+	}
+	fixedIn = rpm.NormalizeVersion(fixedIn)
+
+	return database.AffectedFeature{
+		AffectedType: affectedType,
+		Namespace: database.Namespace{
+			Name:          ns,
+			VersionFormat: rpm.ParserName,
+		},
+		FeatureName:     nvr[:version],
+		AffectedVersion: fixedIn,
+		FixedInVersion:  fixedIn,
+	}, true
+}
+
+func link(name string) string {
+	return cveURI + name + "/"
+}
+
+func severity(sev string) database.Severity {
+	switch strings.ToLower(strings.TrimSpace(sev)) {
+	case "low":
+		return database.LowSeverity
+	case "moderate":
+		return database.MediumSeverity
+	case "important":
+		return database.HighSeverity
+	case "critical":
+		return database.CriticalSeverity
+	default:
+		return database.UnknownSeverity
+	}
+}
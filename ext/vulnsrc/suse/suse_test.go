@@ -0,0 +1,132 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package suse
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt/rpm"
+)
+
+func openTestData(t *testing.T, name string) *os.File {
+	_, filename, _, _ := runtime.Caller(0)
+	f, err := os.Open(filepath.Join(filepath.Dir(filename), "testdata", name))
+	require.Nil(t, err)
+	return f
+}
+
+func sles(release, name, version string) database.AffectedFeature {
+	return database.AffectedFeature{
+		AffectedType: affectedType,
+		Namespace: database.Namespace{
+			Name:          "sles:" + release,
+			VersionFormat: rpm.ParserName,
+		},
+		FeatureName:     name,
+		AffectedVersion: version,
+		FixedInVersion:  version,
+	}
+}
+
+func TestParseOVAL(t *testing.T) {
+	f := openTestData(t, "oval.xml")
+	defer f.Close()
+
+	vulnerabilities, err := parseOVAL(f)
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) {
+		assert.Equal(t, "CVE-2018-0739", vulnerabilities[0].Name)
+		assert.Equal(t, "https://www.suse.com/security/cve/CVE-2018-0739/", vulnerabilities[0].Link)
+		assert.Equal(t, database.MediumSeverity, vulnerabilities[0].Severity)
+		assert.Equal(t, "Constructed ASN.1 types with a recursive definition could exceed the stack given malicious input with excessive recursion.", vulnerabilities[0].Description)
+
+		// The latest service pack wins and SLED is ignored.
+		assert.Equal(t, []database.AffectedFeature{
+			sles("12", "libopenssl1_0_0", "1.0.2j-60.24.1"),
+			sles("12", "openssl", "1.0.2j-60.24.1"),
+		}, vulnerabilities[0].Affected)
+	}
+}
+
+func TestParseCVRF(t *testing.T) {
+	f := openTestData(t, "cvrf.xml")
+	defer f.Close()
+
+	vulnerabilities, err := parseCVRF(f)
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 2) {
+		assert.Equal(t, "CVE-2018-0739", vulnerabilities[0].Name)
+		assert.Equal(t, database.MediumSeverity, vulnerabilities[0].Severity)
+		assert.Equal(t, "Constructed ASN.1 types with a recursive definition could exceed the stack.", vulnerabilities[0].Description)
+		assert.Equal(t, []database.AffectedFeature{
+			sles("12", "libopenssl1_0_0", "1.0.2j-60.24.2"),
+			sles("12", "openssl", "1.0.2j-60.24.2"),
+		}, vulnerabilities[0].Affected)
+
+		assert.Equal(t, "CVE-2018-0737", vulnerabilities[1].Name)
+		assert.Equal(t, database.LowSeverity, vulnerabilities[1].Severity)
+		assert.Equal(t, []database.AffectedFeature{sles("15", "openssl", "1.1.0h-2.3.1")}, vulnerabilities[1].Affected)
+	}
+}
+
+func TestMergeAdvisories(t *testing.T) {
+	merged := make(advisories)
+	merged.add([]database.VulnerabilityWithAffected{
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-2018-0739", Description: "from OVAL", Severity: database.UnknownSeverity},
+			Affected: []database.AffectedFeature{
+				sles("12", "openssl", "1.0.2j-60.30.1"),
+				sles("12", "libopenssl1_0_0", "1.0.2j-60.24.1"),
+			},
+		},
+	}, sourceOVAL)
+	merged.add([]database.VulnerabilityWithAffected{
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-2018-0739", Description: "from CVRF", Severity: database.MediumSeverity},
+			Affected: []database.AffectedFeature{
+				sles("12", "openssl", "1.0.2j-60.24.2"),
+				sles("12", "openssl", "1.0.2j-60.20.2"),
+				sles("15", "openssl", "1.1.0h-2.3.1"),
+			},
+		},
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-2018-0737", Severity: database.LowSeverity},
+			Affected:      []database.AffectedFeature{sles("15", "openssl", "1.1.0h-2.3.1")},
+		},
+	}, sourceCVRF)
+
+	vulnerabilities := merged.vulnerabilities()
+	if assert.Len(t, vulnerabilities, 2) {
+		assert.Equal(t, "CVE-2018-0737", vulnerabilities[0].Name)
+		assert.Equal(t, []database.AffectedFeature{sles("15", "openssl", "1.1.0h-2.3.1")}, vulnerabilities[0].Affected)
+
+		assert.Equal(t, "CVE-2018-0739", vulnerabilities[1].Name)
+		assert.Equal(t, "from OVAL", vulnerabilities[1].Description)
+		assert.Equal(t, database.MediumSeverity, vulnerabilities[1].Severity)
+
+		// The advisories override the OVAL definitions, which are kept for the
+		// packages they do not mention.
+		assert.Equal(t, []database.AffectedFeature{
+			sles("12", "libopenssl1_0_0", "1.0.2j-60.24.1"),
+			sles("12", "openssl", "1.0.2j-60.24.2"),
+			sles("15", "openssl", "1.1.0h-2.3.1"),
+		}, vulnerabilities[1].Affected)
+	}
+}
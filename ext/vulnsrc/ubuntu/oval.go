@@ -0,0 +1,296 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ubuntu
+
+import (
+	"compress/bzip2"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/dpkg"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/httputil"
+)
+
+// ovalURL is the feed of the OVAL definitions of the CVEs of a release, by
+// code name.
+const ovalURL = "https://security-metadata.canonical.com/oval/com.ubuntu.%s.cve.oval.xml.bz2"
+
+// The formats of the OVAL feeds of Canonical.
+const (
+	// ovalV1 is the format of the older feeds, whose tests check the source
+	// packages, named by their objects.
+	ovalV1 = "v1"
+	// ovalV2 is the format of the newer feeds, whose tests check the binary
+	// packages of a source package, listed by a variable of their objects,
+	// while the source package is named by the comment of their criterion.
+	ovalV2 = "v2"
+)
+
+var (
+	// ovalV1PackageRegexp and ovalV2PackageRegexp capture the source package
+	// of the comment of a criterion, e.g. "The 'libmspack' package in xenial
+	// is affected and needs fixing." in the v1 feeds, and "(CVE-2022-0001)
+	// libfoo package in jammy was vulnerable but has been fixed (note:
+	// '1.2-3')." in the v2 feeds.
+	ovalV1PackageRegexp = regexp.MustCompile(`^The '([^']+)' package in `)
+	ovalV2PackageRegexp = regexp.MustCompile(`^(?:\([^)]*\) )?(\S+) package in `)
+)
+
+type ovalDefinitions struct {
+	Definitions []ovalDefinition `xml:"definitions>definition"`
+	Tests       []ovalTest       `xml:"tests>dpkginfo_test"`
+	Objects     []ovalObject     `xml:"objects>dpkginfo_object"`
+	States      []ovalState      `xml:"states>dpkginfo_state"`
+}
+
+type ovalDefinition struct {
+	Class       string          `xml:"class,attr"`
+	Description string          `xml:"metadata>description"`
+	References  []ovalReference `xml:"metadata>reference"`
+	Severity    string          `xml:"metadata>advisory>severity"`
+	Criteria    ovalCriteria    `xml:"criteria"`
+}
+
+type ovalReference struct {
+	Source string `xml:"source,attr"`
+	ID     string `xml:"ref_id,attr"`
+}
+
+type ovalCriteria struct {
+	Criterias  []ovalCriteria  `xml:"criteria"`
+	Criterions []ovalCriterion `xml:"criterion"`
+}
+
+type ovalCriterion struct {
+	TestRef string `xml:"test_ref,attr"`
+	Comment string `xml:"comment,attr"`
+}
+
+type ovalTest struct {
+	ID        string `xml:"id,attr"`
+	ObjectRef struct {
+		Ref string `xml:"object_ref,attr"`
+	} `xml:"object"`
+	StateRef struct {
+		Ref string `xml:"state_ref,attr"`
+	} `xml:"state"`
+}
+
+type ovalObject struct {
+	ID   string `xml:"id,attr"`
+	Name struct {
+		VarRef string `xml:"var_ref,attr"`
+		Value  string `xml:",chardata"`
+	} `xml:"name"`
+}
+
+type ovalState struct {
+	ID  string `xml:"id,attr"`
+	EVR struct {
+		Operation string `xml:"operation,attr"`
+		Value     string `xml:",chardata"`
+	} `xml:"evr"`
+}
+
+// fetchOVAL downloads and parses the OVAL feed of the given release.
+func fetchOVAL(release string) ([]database.VulnerabilityWithAffected, error) {
+	r, err := httputil.Source(updaterName).GetWithUserAgent(fmt.Sprintf(ovalURL, release))
+	if err != nil {
+		log.WithError(err).WithField("release", release).Error("could not download Ubuntu's OVAL feed")
+		return nil, commonerr.ErrCouldNotDownload
+	}
+	defer r.Body.Close()
+
+	if !httputil.Status2xx(r) {
+		log.WithField("StatusCode", r.StatusCode).WithField("release", release).Error("could not download Ubuntu's OVAL feed")
+		return nil, commonerr.ErrCouldNotDownload
+	}
+
+	return parseOVAL(bzip2.NewReader(r.Body), release)
+}
+
+// parseOVAL parses the OVAL definitions of the given release, in either
+// format.
+func parseOVAL(r io.Reader, release string) ([]database.VulnerabilityWithAffected, error) {
+	var ov ovalDefinitions
+	if err := xml.NewDecoder(r).Decode(&ov); err != nil {
+		log.WithError(err).WithField("release", release).Error("could not decode Ubuntu's OVAL feed")
+		return nil, commonerr.ErrCouldNotParse
+	}
+
+	version, ok := database.UbuntuReleasesMapping[release]
+	if !ok {
+		return nil, fmt.Errorf("%s: %s", errUnknownRelease, release)
+	}
+	namespace := database.Namespace{Name: "ubuntu:" + version, VersionFormat: dpkg.ParserName}
+
+	format := ov.format()
+	log.WithFields(log.Fields{"release": release, "format": format}).Debug("parsing Ubuntu's OVAL feed")
+
+	tests := make(map[string]ovalTest, len(ov.Tests))
+	for _, t := range ov.Tests {
+		tests[t.ID] = t
+	}
+	objects := make(map[string]ovalObject, len(ov.Objects))
+	for _, o := range ov.Objects {
+		objects[o.ID] = o
+	}
+	states := make(map[string]ovalState, len(ov.States))
+	for _, s := range ov.States {
+		states[s.ID] = s
+	}
+
+	var vulnerabilities []database.VulnerabilityWithAffected
+	for _, definition := range ov.Definitions {
+		name := definition.cve()
+		if definition.Class != "vulnerability" || name == "" {
+			continue
+		}
+
+		vulnerability := database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{
+				Name:        name,
+				Link:        fmt.Sprintf(cveURL, name),
+				Description: strings.TrimSpace(definition.Description),
+				Severity:    database.UnknownSeverity,
+			},
+		}
+		if definition.Severity != "" {
+			vulnerability.Severity = SeverityFromPriority(strings.ToLower(definition.Severity))
+		}
+
+		// Only the first criterion of a package is considered, as in the
+		// Ubuntu CVE Tracker.
+		seen := make(map[string]struct{})
+		for _, c := range definition.Criteria.criterions() {
+			test, ok := tests[c.TestRef]
+			if !ok {
+				continue
+			}
+
+			feature, ok := ovalFeature(format, c, objects[test.ObjectRef.Ref], states, test.StateRef.Ref)
+			if !ok {
+				continue
+			}
+
+			// Ignore Linux kernels.
+			if strings.HasPrefix(feature.FeatureName, "linux") {
+				continue
+			}
+
+			if _, ok := seen[feature.FeatureName]; ok {
+				continue
+			}
+			seen[feature.FeatureName] = struct{}{}
+
+			feature.Namespace = namespace
+			vulnerability.Affected = append(vulnerability.Affected, feature)
+		}
+
+		if len(vulnerability.Affected) > 0 {
+			vulnerabilities = append(vulnerabilities, vulnerability)
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// format detects the format of the feed: the objects of the v2 feeds refer to
+// the variables listing the binary packages of a source package.
+func (ov ovalDefinitions) format() string {
+	for _, o := range ov.Objects {
+		if o.Name.VarRef != "" {
+			return ovalV2
+		}
+	}
+	return ovalV1
+}
+
+// cve returns the name of the CVE of the definition, if any.
+func (d ovalDefinition) cve() string {
+	for _, r := range d.References {
+		if r.Source == "CVE" && r.ID != "" {
+			return r.ID
+		}
+	}
+	return ""
+}
+
+// criterions returns the criterions of the criteria and of its nested
+// criterias, in order.
+func (c ovalCriteria) criterions() []ovalCriterion {
+	criterions := append([]ovalCriterion(nil), c.Criterions...)
+	for _, nested := range c.Criterias {
+		criterions = append(criterions, nested.criterions()...)
+	}
+	return criterions
+}
+
+// ovalFeature returns the source package affected according to a criterion
+// and the object and state of its test. The packages of the tests without a
+// state are affected in every version, while the other ones are fixed in the
+// version that the state compares to.
+func ovalFeature(format string, c ovalCriterion, object ovalObject, states map[string]ovalState, stateRef string) (database.AffectedFeature, bool) {
+	feature := database.AffectedFeature{AffectedType: affectedType}
+
+	switch format {
+	case ovalV1:
+		feature.FeatureName = strings.TrimSpace(object.Name.Value)
+		if feature.FeatureName == "" {
+			if m := ovalV1PackageRegexp.FindStringSubmatch(c.Comment); m != nil {
+				feature.FeatureName = m[1]
+			}
+		}
+	case ovalV2:
+		if m := ovalV2PackageRegexp.FindStringSubmatch(c.Comment); m != nil {
+			feature.FeatureName = m[1]
+		}
+	}
+	if feature.FeatureName == "" {
+		log.WithField("comment", c.Comment).Warning("could not determine the package of an Ubuntu OVAL criterion")
+		return feature, false
+	}
+
+	if stateRef == "" {
+		feature.AffectedVersion = versionfmt.MaxVersion
+		return feature, true
+	}
+
+	state, ok := states[stateRef]
+	if !ok || state.EVR.Operation != "less than" {
+		log.WithFields(log.Fields{"package": feature.FeatureName, "state": stateRef}).Warning("could not determine the fixed version of an Ubuntu OVAL criterion")
+		return feature, false
+	}
+
+	// The versions are compared with an epoch, which is omitted when it is 0,
+	// as in the Ubuntu CVE Tracker.
+	version := strings.TrimPrefix(strings.TrimSpace(state.EVR.Value), "0:")
+	if err := versionfmt.Valid(dpkg.ParserName, version); err != nil {
+		log.WithError(err).WithField("version", version).Warning("could not parse package version. skipping")
+		return feature, false
+	}
+
+	feature.AffectedVersion = version
+	feature.FixedInVersion = version
+	return feature, true
+}
@@ -0,0 +1,118 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ubuntu
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/dpkg"
+	"github.com/coreos/clair/pkg/commonerr"
+)
+
+func parseTestOVAL(t *testing.T, filename, release string) []database.VulnerabilityWithAffected {
+	_, path, _, _ := runtime.Caller(0)
+	f, err := os.Open(filepath.Join(filepath.Dir(path), "testdata", filename))
+	require.Nil(t, err)
+	defer f.Close()
+
+	vulnerabilities, err := parseOVAL(f, release)
+	require.Nil(t, err)
+	return vulnerabilities
+}
+
+func TestParseOVALV1(t *testing.T) {
+	vulnerabilities := parseTestOVAL(t, "oval_v1_xenial.xml", "xenial")
+
+	// The vulnerabilities without any package are left out, as well as the
+	// Linux kernels.
+	require.Len(t, vulnerabilities, 1)
+	assert.Equal(t, database.Vulnerability{
+		Name:        "CVE-2015-4471",
+		Link:        "http://people.ubuntu.com/~ubuntu-security/cve/CVE-2015-4471",
+		Description: "Off-by-one error in the lzxd_decompress function in lzxd.c in libmspack before 0.5 allows remote attackers to cause a denial of service (buffer under-read and application crash) via a crafted CAB archive.",
+		Severity:    database.MediumSeverity,
+	}, vulnerabilities[0].Vulnerability)
+
+	namespace := database.Namespace{Name: "ubuntu:16.04", VersionFormat: dpkg.ParserName}
+	assert.Equal(t, []database.AffectedFeature{
+		{
+			AffectedType:    affectedType,
+			Namespace:       namespace,
+			FeatureName:     "libmspack",
+			FixedInVersion:  "0.5-1ubuntu0.16.04.1",
+			AffectedVersion: "0.5-1ubuntu0.16.04.1",
+		},
+		{
+			AffectedType:    affectedType,
+			Namespace:       namespace,
+			FeatureName:     "cabextract",
+			AffectedVersion: versionfmt.MaxVersion,
+		},
+	}, vulnerabilities[0].Affected)
+}
+
+func TestParseOVALV2(t *testing.T) {
+	vulnerabilities := parseTestOVAL(t, "oval_v2_jammy.xml", "jammy")
+	require.Len(t, vulnerabilities, 2)
+
+	namespace := database.Namespace{Name: "ubuntu:22.04", VersionFormat: dpkg.ParserName}
+
+	// The source packages are named by the criteria, not by the binary
+	// packages of their variables.
+	assert.Equal(t, "CVE-2022-0001", vulnerabilities[0].Name)
+	assert.Equal(t, database.HighSeverity, vulnerabilities[0].Severity)
+	assert.Equal(t, []database.AffectedFeature{
+		{
+			AffectedType:    affectedType,
+			Namespace:       namespace,
+			FeatureName:     "libfoo",
+			FixedInVersion:  "1.2-3ubuntu0.1",
+			AffectedVersion: "1.2-3ubuntu0.1",
+		},
+		{
+			AffectedType:    affectedType,
+			Namespace:       namespace,
+			FeatureName:     "bar",
+			AffectedVersion: versionfmt.MaxVersion,
+		},
+	}, vulnerabilities[0].Affected)
+
+	// The epochs other than 0 are kept.
+	assert.Equal(t, "CVE-2022-0002", vulnerabilities[1].Name)
+	assert.Equal(t, database.NegligibleSeverity, vulnerabilities[1].Severity)
+	assert.Equal(t, []database.AffectedFeature{
+		{
+			AffectedType:    affectedType,
+			Namespace:       namespace,
+			FeatureName:     "libfoo",
+			FixedInVersion:  "1:2.0-1",
+			AffectedVersion: "1:2.0-1",
+		},
+	}, vulnerabilities[1].Affected)
+}
+
+func TestParseOVALInvalid(t *testing.T) {
+	_, err := parseOVAL(strings.NewReader("<oval_definitions>"), "jammy")
+	assert.Equal(t, commonerr.ErrCouldNotParse, err)
+}
@@ -13,7 +13,8 @@
 // limitations under the License.
 
 // Package ubuntu implements a vulnerability source updater using the
-// Ubuntu CVE Tracker.
+// Ubuntu CVE Tracker, or the OVAL feeds of Canonical for the configured
+// releases.
 package ubuntu
 
 import (
@@ -33,10 +34,13 @@ import (
 	"github.com/coreos/clair/ext/versionfmt/dpkg"
 	"github.com/coreos/clair/ext/vulnsrc"
 	"github.com/coreos/clair/pkg/gitutil"
+	"github.com/coreos/clair/pkg/httputil"
+	"gopkg.in/yaml.v2"
 )
 
 const (
 	trackerURI   = "https://git.launchpad.net/ubuntu-cve-tracker"
+	updaterName  = "ubuntu"
 	updaterFlag  = "ubuntuUpdater"
 	cveURL       = "http://people.ubuntu.com/~ubuntu-security/cve/%s"
 	affectedType = database.AffectSourcePackage
@@ -76,12 +80,46 @@ var (
 	errUnknownRelease = errors.New("found packages with CVEs for a verison of Ubuntu that Clair doesn't know about")
 )
 
+// Config is the configuration of the Ubuntu updater.
+type Config struct {
+	// OVAL lists the code names of the releases, e.g. jammy, whose
+	// vulnerabilities are read from the OVAL feeds of Canonical instead of the
+	// Ubuntu CVE Tracker.
+	OVAL []string
+}
+
 type updater struct {
 	repositoryLocalPath string
+	ovalReleases        map[string]struct{}
 }
 
 func init() {
-	vulnsrc.RegisterUpdater("ubuntu", &updater{})
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
+}
+
+func (u *updater) Configure(params map[string]interface{}) error {
+	if _, ok := params["ubuntu"]; !ok {
+		return nil
+	}
+
+	var config Config
+	yamlConfig, err := yaml.Marshal(params["ubuntu"])
+	if err != nil {
+		return errors.New("invalid configuration")
+	}
+	if err := yaml.Unmarshal(yamlConfig, &config); err != nil {
+		return errors.New("invalid configuration")
+	}
+
+	u.ovalReleases = make(map[string]struct{}, len(config.OVAL))
+	for _, release := range config.OVAL {
+		if _, ok := database.UbuntuReleasesMapping[release]; !ok {
+			return fmt.Errorf("unknown Ubuntu release %q in oval", release)
+		}
+		u.ovalReleases[release] = struct{}{}
+	}
+
+	return nil
 }
 
 func (u *updater) Update(db database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
@@ -89,18 +127,11 @@ func (u *updater) Update(db database.Datastore) (resp vulnsrc.UpdateResponse, er
 
 	// Pull the master branch.
 	var commit string
-	u.repositoryLocalPath, commit, err = gitutil.CloneOrPull(trackerURI, u.repositoryLocalPath, updaterFlag)
+	u.repositoryLocalPath, commit, err = gitutil.CloneOrPull(httputil.Source(updaterName).URL(trackerURI), u.repositoryLocalPath, updaterFlag)
 	if err != nil {
 		return resp, err
 	}
 
-	// Open a database transaction.
-	tx, err := db.Begin()
-	if err != nil {
-		return resp, err
-	}
-	defer tx.Rollback()
-
 	// Ask the database for the latest commit we successfully applied.
 	dbCommit, ok, err := database.FindKeyValueAndRollback(db, updaterFlag)
 	if err != nil {
@@ -115,29 +146,37 @@ func (u *updater) Update(db database.Datastore) (resp vulnsrc.UpdateResponse, er
 	resp.FlagName = updaterFlag
 	resp.FlagValue = commit
 
-	// Short-circuit if there have been no updates.
+	// The tracker is only read if there have been updates, while the OVAL
+	// feeds, which are not versioned, are read at every update.
 	if commit == dbCommit {
 		log.WithField("package", "ubuntu").Debug("no update")
-		return
-	}
+	} else {
+		// Get the list of vulnerabilities that we have to update.
+		var modifiedCVE map[string]struct{}
+		modifiedCVE, err = collectModifiedVulnerabilities(commit, dbCommit, u.repositoryLocalPath)
+		if err != nil {
+			return
+		}
 
-	// Get the list of vulnerabilities that we have to update.
-	var modifiedCVE map[string]struct{}
-	modifiedCVE, err = collectModifiedVulnerabilities(commit, dbCommit, u.repositoryLocalPath)
-	if err != nil {
-		return
-	}
+		// Get the list of vulnerabilities.
+		resp.Vulnerabilities, resp.Notes, err = collectVulnerabilitiesAndNotes(u.repositoryLocalPath, modifiedCVE, u.ovalReleases)
+		if err != nil {
+			return
+		}
 
-	// Get the list of vulnerabilities.
-	resp.Vulnerabilities, resp.Notes, err = collectVulnerabilitiesAndNotes(u.repositoryLocalPath, modifiedCVE)
-	if err != nil {
-		return
+		// The only notes we take are if we encountered unknown Ubuntu release.
+		// We don't want the commit to be considered as managed in that case.
+		if len(resp.Notes) != 0 {
+			resp.FlagValue = dbCommit
+		}
 	}
 
-	// The only notes we take are if we encountered unknown Ubuntu release.
-	// We don't want the commit to be considered as managed in that case.
-	if len(resp.Notes) != 0 {
-		resp.FlagValue = dbCommit
+	for release := range u.ovalReleases {
+		var vulns []database.VulnerabilityWithAffected
+		if vulns, err = fetchOVAL(release); err != nil {
+			return
+		}
+		resp.Vulnerabilities = append(resp.Vulnerabilities, vulns...)
 	}
 
 	return
@@ -185,7 +224,9 @@ func processDirectory(repositoryLocalPath, dirName string, modifiedCVE map[strin
 	return nil
 }
 
-func collectVulnerabilitiesAndNotes(repositoryLocalPath string, modifiedCVE map[string]struct{}) ([]database.VulnerabilityWithAffected, []string, error) {
+// collectVulnerabilitiesAndNotes parses the modified CVEs of the tracker,
+// leaving out the given releases, which are read from their OVAL feeds.
+func collectVulnerabilitiesAndNotes(repositoryLocalPath string, modifiedCVE, ovalReleases map[string]struct{}) ([]database.VulnerabilityWithAffected, []string, error) {
 	vulns := make([]database.VulnerabilityWithAffected, 0)
 	noteSet := make(map[string]struct{})
 
@@ -198,7 +239,7 @@ func collectVulnerabilitiesAndNotes(repositoryLocalPath string, modifiedCVE map[
 		}
 
 		// Parse the vulnerability.
-		v, unknownReleases, err := parseUbuntuCVE(file)
+		v, unknownReleases, err := parseUbuntuCVE(file, ovalReleases)
 		if err != nil {
 			file.Close()
 			return nil, nil, err
@@ -224,7 +265,7 @@ func collectVulnerabilitiesAndNotes(repositoryLocalPath string, modifiedCVE map[
 	return vulns, notes, nil
 }
 
-func parseUbuntuCVE(fileContent io.Reader) (vulnerability database.VulnerabilityWithAffected, unknownReleases map[string]struct{}, err error) {
+func parseUbuntuCVE(fileContent io.Reader, ovalReleases map[string]struct{}) (vulnerability database.VulnerabilityWithAffected, unknownReleases map[string]struct{}, err error) {
 	unknownReleases = make(map[string]struct{})
 	readingDescription := false
 	scanner := bufio.NewScanner(fileContent)
@@ -298,6 +339,9 @@ func parseUbuntuCVE(fileContent io.Reader) (vulnerability database.Vulnerability
 				if _, isReleaseIgnored := ubuntuIgnoredReleases[md["release"]]; isReleaseIgnored {
 					continue
 				}
+				if _, isOVALRelease := ovalReleases[md["release"]]; isOVALRelease {
+					continue
+				}
 				if _, isReleaseKnown := database.UbuntuReleasesMapping[md["release"]]; !isReleaseKnown {
 					unknownReleases[md["release"]] = struct{}{}
 					continue
@@ -382,7 +426,7 @@ func SeverityFromPriority(priority string) database.Severity {
 	case "critical":
 		return database.CriticalSeverity
 	default:
-		log.Warningf("could not determine a vulnerability severity from: %s", priority)
+		log.WithField("priority", priority).Warning("could not determine a vulnerability severity")
 		return database.UnknownSeverity
 	}
 }
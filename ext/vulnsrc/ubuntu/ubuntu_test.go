@@ -34,7 +34,7 @@ func TestUbuntuParser(t *testing.T) {
 	// Test parsing testdata/fetcher_
 	testData, _ := os.Open(filepath.Join(path, "/testdata/fetcher_ubuntu_test.txt"))
 	defer testData.Close()
-	vulnerability, unknownReleases, err := parseUbuntuCVE(testData)
+	vulnerability, unknownReleases, err := parseUbuntuCVE(testData, nil)
 	if assert.Nil(t, err) {
 		assert.Equal(t, "CVE-2015-4471", vulnerability.Name)
 		assert.Equal(t, database.MediumSeverity, vulnerability.Severity)
@@ -81,3 +81,29 @@ func TestUbuntuParser(t *testing.T) {
 		}
 	}
 }
+
+func TestUbuntuParserOVALReleases(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	testData, _ := os.Open(filepath.Join(filepath.Dir(filename), "/testdata/fetcher_ubuntu_test.txt"))
+	defer testData.Close()
+
+	// The releases read from their OVAL feeds are left out.
+	vulnerability, _, err := parseUbuntuCVE(testData, map[string]struct{}{"vivid": {}})
+	if assert.Nil(t, err) {
+		for _, affected := range vulnerability.Affected {
+			assert.NotEqual(t, "ubuntu:15.04", affected.Namespace.Name)
+		}
+		assert.Len(t, vulnerability.Affected, 3)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	u := &updater{}
+	assert.Nil(t, u.Configure(map[string]interface{}{}))
+	assert.Empty(t, u.ovalReleases)
+
+	assert.Nil(t, u.Configure(map[string]interface{}{"ubuntu": map[string]interface{}{"oval": []string{"jammy", "noble"}}}))
+	assert.Equal(t, map[string]struct{}{"jammy": {}, "noble": {}}, u.ovalReleases)
+
+	assert.NotNil(t, u.Configure(map[string]interface{}{"ubuntu": map[string]interface{}{"oval": []string{"warty"}}}))
+}
@@ -0,0 +1,225 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wolfi implements a vulnerability source updater using the security
+// databases of Wolfi and Chainguard.
+package wolfi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
+	"github.com/coreos/clair/ext/versionfmt/dpkg"
+	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/httputil"
+)
+
+const (
+	updaterFlag  = "wolfiUpdater"
+	updaterName  = "wolfi"
+	nvdURLPrefix = "https://cve.mitre.org/cgi-bin/cvename.cgi?name="
+	// affected type indicates if the affected feature hint is for binary or
+	// source package.
+	affectedType = database.AffectBinaryPackage
+
+	// notAffectedVersion is the version under which the security databases
+	// list the vulnerabilities that do not affect a package.
+	notAffectedVersion = "0"
+)
+
+// feed is a security database in the secdb JSON format.
+type feed struct {
+	// namespace is the name of the namespace of the vulnerabilities, which
+	// matches the one detected from the os-release file.
+	namespace string
+	url       string
+}
+
+var feeds = []feed{
+	{namespace: "wolfi:rolling", url: "https://packages.wolfi.dev/os/security.json"},
+	{namespace: "chainguard:rolling", url: "https://packages.cgr.dev/chainguard/security.json"},
+}
+
+type secDB struct {
+	Packages []struct {
+		Pkg struct {
+			Name  string              `json:"name"`
+			Fixes map[string][]string `json:"secfixes"`
+		} `json:"pkg"`
+	} `json:"packages"`
+}
+
+type updater struct{}
+
+func init() {
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
+}
+
+func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
+	log.WithField("package", "Wolfi").Info("Start fetching vulnerabilities")
+
+	// The flag contains the hashes of the last processed databases, in the
+	// order of feeds.
+	flagValue, _, err := database.FindKeyValueAndRollback(datastore, updaterFlag)
+	if err != nil {
+		return
+	}
+	knownHashes := strings.Split(flagValue, ",")
+
+	var (
+		hashes  = make([]string, 0, len(feeds))
+		changed bool
+	)
+	for i, f := range feeds {
+		var knownHash string
+		if i < len(knownHashes) {
+			knownHash = knownHashes[i]
+		}
+
+		vulnerabilities, hash, err := fetchFeed(f, knownHash)
+		if err != nil {
+			return resp, err
+		}
+
+		hashes = append(hashes, hash)
+		if hash != knownHash {
+			changed = true
+			resp.Vulnerabilities = append(resp.Vulnerabilities, vulnerabilities...)
+		}
+	}
+
+	// Each changed feed is the complete security database of its namespace.
+	if changed {
+		resp.Complete = true
+		resp.FlagName = updaterFlag
+		resp.FlagValue = strings.Join(hashes, ",")
+	} else {
+		log.WithField("package", "Wolfi").Debug("no update, skip")
+	}
+
+	return resp, nil
+}
+
+func (u *updater) Clean() {}
+
+func fetchFeed(f feed, knownHash string) ([]database.VulnerabilityWithAffected, string, error) {
+	r, err := httputil.Source(updaterName).GetWithUserAgent(f.url)
+	if err != nil {
+		log.WithError(err).WithField("namespace", f.namespace).Error("could not download Wolfi's security database")
+		return nil, "", commonerr.ErrCouldNotDownload
+	}
+	defer r.Body.Close()
+
+	if !httputil.Status2xx(r) {
+		log.WithFields(log.Fields{"StatusCode": r.StatusCode, "namespace": f.namespace}).Error("Failed to update Wolfi")
+		return nil, "", commonerr.ErrCouldNotDownload
+	}
+
+	return parseSecDB(f.namespace, r.Body, knownHash)
+}
+
+// parseSecDB parses a security database and returns its vulnerabilities,
+// unless its hash is knownHash.
+func parseSecDB(namespaceName string, secDBReader io.Reader, knownHash string) ([]database.VulnerabilityWithAffected, string, error) {
+	// Create a TeeReader so that we can unmarshal into JSON and write to a hash
+	// digest at the same time.
+	jsonSHA := sha256.New()
+	teedJSONReader := io.TeeReader(secDBReader, jsonSHA)
+
+	var db secDB
+	if err := json.NewDecoder(teedJSONReader).Decode(&db); err != nil {
+		log.WithError(err).WithField("namespace", namespaceName).Error("could not unmarshal Wolfi's security database")
+		return nil, "", commonerr.ErrCouldNotParse
+	}
+
+	hash := hex.EncodeToString(jsonSHA.Sum(nil))
+	if hash == knownHash {
+		return nil, hash, nil
+	}
+
+	return db.Vulnerabilities(namespaceName), hash, nil
+}
+
+// Vulnerabilities returns the vulnerabilities of the database, each of them
+// affecting all the packages that fixed it.
+func (db *secDB) Vulnerabilities(namespaceName string) (vulns []database.VulnerabilityWithAffected) {
+	namespace := database.Namespace{Name: namespaceName, VersionFormat: dpkg.ParserName}
+
+	vulnsByName := make(map[string]*database.VulnerabilityWithAffected)
+	for _, pkg := range db.Packages {
+		for version, names := range pkg.Pkg.Fixes {
+			if version == notAffectedVersion {
+				continue
+			}
+
+			if err := versionfmt.Valid(dpkg.ParserName, version); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"version":      version,
+					"package name": pkg.Pkg.Name,
+				}).Warning("could not parse package version, skipping")
+				continue
+			}
+
+			for _, name := range names {
+				// An entry may list the aliases of a vulnerability after its
+				// name.
+				fields := strings.Fields(name)
+				if len(fields) == 0 {
+					continue
+				}
+				name = fields[0]
+
+				vuln, ok := vulnsByName[name]
+				if !ok {
+					vuln = &database.VulnerabilityWithAffected{
+						Vulnerability: database.Vulnerability{
+							Name:      name,
+							Severity:  database.UnknownSeverity,
+							Namespace: namespace,
+						},
+					}
+					if strings.HasPrefix(name, "CVE-") {
+						vuln.Link = nvdURLPrefix + name
+					}
+					vulnsByName[name] = vuln
+				}
+
+				vuln.Affected = append(vuln.Affected, database.AffectedFeature{
+					AffectedType:    affectedType,
+					FeatureName:     pkg.Pkg.Name,
+					AffectedVersion: version,
+					FixedInVersion:  version,
+					Namespace:       namespace,
+				})
+			}
+		}
+	}
+
+	for _, vuln := range vulnsByName {
+		vulns = append(vulns, *vuln)
+	}
+
+	// Sort the vulnerabilities so that the response is stable.
+	sort.Slice(vulns, func(i, j int) bool { return vulns[i].Name < vulns[j].Name })
+	return
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wolfi
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt/dpkg"
+)
+
+func TestWolfiParser(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	path := filepath.Join(filepath.Dir(filename))
+
+	testFile, err := os.Open(filepath.Join(path, "/testdata/security.json"))
+	require.Nil(t, err)
+	defer testFile.Close()
+
+	vulns, hash, err := parseSecDB("wolfi:rolling", testFile, "")
+	require.Nil(t, err)
+	assert.NotEmpty(t, hash)
+
+	namespace := database.Namespace{Name: "wolfi:rolling", VersionFormat: dpkg.ParserName}
+	if assert.Len(t, vulns, 4) {
+		assert.Equal(t, "CVE-2022-28391", vulns[0].Name)
+		assert.Equal(t, namespace, vulns[0].Namespace)
+		assert.Equal(t, "https://cve.mitre.org/cgi-bin/cvename.cgi?name=CVE-2022-28391", vulns[0].Link)
+		assert.Equal(t, []database.AffectedFeature{
+			{
+				AffectedType:    affectedType,
+				FeatureName:     "busybox",
+				AffectedVersion: "1.36.1-r1",
+				FixedInVersion:  "1.36.1-r1",
+				Namespace:       namespace,
+			},
+		}, vulns[0].Affected)
+
+		assert.Equal(t, "CVE-2022-43551", vulns[1].Name)
+		assert.Equal(t, "CVE-2022-43552", vulns[2].Name)
+
+		assert.Equal(t, "CVE-2023-38545", vulns[3].Name)
+		assert.Len(t, vulns[3].Affected, 2)
+		for _, affected := range vulns[3].Affected {
+			assert.Equal(t, "8.4.0-r0", affected.FixedInVersion)
+		}
+	}
+}
+
+func TestWolfiParserKnownHash(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	path := filepath.Join(filepath.Dir(filename))
+
+	testFile, err := os.Open(filepath.Join(path, "/testdata/security.json"))
+	require.Nil(t, err)
+	defer testFile.Close()
+
+	_, hash, err := parseSecDB("wolfi:rolling", testFile, "")
+	require.Nil(t, err)
+
+	testFile.Seek(0, 0)
+	vulns, knownHash, err := parseSecDB("wolfi:rolling", testFile, hash)
+	require.Nil(t, err)
+	assert.Equal(t, hash, knownHash)
+	assert.Empty(t, vulns)
+}
@@ -0,0 +1,307 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+// filesCacheExt is the extension of the entries of the extracted files cache,
+// which are written to a temporary file first.
+const filesCacheExt = ".gob"
+
+// digestRegexp matches the digests of the layers, whose content is the same
+// across images.
+var digestRegexp = regexp.MustCompile(`^sha(256|384|512):[a-f0-9]{64,128}$`)
+
+var (
+	promFilesCacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "clair_worker_files_cache_requests_total",
+		Help: "Number of lookups of the files extracted from the layers in the on-disk cache, by result (hit or miss).",
+	}, []string{"result"})
+
+	promFilesCacheEvictionsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "clair_worker_files_cache_evictions_total",
+		Help: "Number of entries evicted from the on-disk cache of the extracted files to keep it under its maximum size.",
+	})
+
+	promFilesCacheSizeBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "clair_worker_files_cache_size_bytes",
+		Help: "Size of the on-disk cache of the extracted files.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(promFilesCacheRequestsTotal)
+	prometheus.MustRegister(promFilesCacheEvictionsTotal)
+	prometheus.MustRegister(promFilesCacheSizeBytes)
+}
+
+// filesCache holds on disk the files extracted from the layers whose hash is
+// a digest, keyed by the digest and the extracted filenames, so that a layer
+// shared by many images is only extracted once. The least recently used
+// entries are evicted once the cache exceeds its maximum size.
+type filesCache struct {
+	dir     string
+	maxSize int64
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type filesCacheEntry struct {
+	name string
+	size int64
+}
+
+// newFilesCache returns a cache of at most maxSize bytes in dir, with the
+// entries left by the previous runs ordered by their last use. It returns nil,
+// which caches nothing, when dir is empty.
+func newFilesCache(dir string, maxSize int64) (*filesCache, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []os.FileInfo
+	for _, info := range infos {
+		switch {
+		case info.IsDir():
+		case strings.HasSuffix(info.Name(), filesCacheExt):
+			existing = append(existing, info)
+		default:
+			// The temporary files of the entries being written when Clair
+			// stopped.
+			os.Remove(filepath.Join(dir, info.Name()))
+		}
+	}
+
+	// The entries are touched when they are used.
+	sort.Slice(existing, func(i, j int) bool { return existing[i].ModTime().After(existing[j].ModTime()) })
+
+	c := &filesCache{dir: dir, maxSize: maxSize, lru: list.New(), entries: make(map[string]*list.Element)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, info := range existing {
+		c.entries[info.Name()] = c.lru.PushBack(filesCacheEntry{name: info.Name(), size: info.Size()})
+		c.size += info.Size()
+	}
+	c.evict()
+
+	return c, nil
+}
+
+// filesCacheName returns the name of the entry of the files matching the
+// given filenames extracted from the layer with the given digest, which
+// depends on the limits of the extraction too, or false when the hash of the
+// layer is not a digest.
+func filesCacheName(hash string, filenames []string) (string, bool) {
+	if !digestRegexp.MatchString(hash) {
+		return "", false
+	}
+
+	sorted := append([]string(nil), filenames...)
+	sort.Strings(sorted)
+
+	sha := sha256.New()
+	fmt.Fprintf(sha, "%s\n%d\n%d\n", hash, tarutil.MaxExtractableFileSize, tarutil.MaxExtractedSize)
+	for _, filename := range sorted {
+		fmt.Fprintln(sha, filename)
+	}
+
+	return hex.EncodeToString(sha.Sum(nil)) + filesCacheExt, true
+}
+
+// get returns the files matching filenames extracted from the layer with the
+// given hash, if they are cached. The entries that cannot be read are
+// evicted.
+func (c *filesCache) get(hash string, filenames []string) (tarutil.FilesMap, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	name, ok := filesCacheName(hash, filenames)
+	if !ok {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	elem, ok := c.entries[name]
+	if ok {
+		c.lru.MoveToFront(elem)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		promFilesCacheRequestsTotal.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+
+	path := filepath.Join(c.dir, name)
+	files, err := readFilesCacheEntry(path)
+	if err != nil {
+		log.WithError(err).WithField("layer", hash).Warning("could not read the cached files of the layer, evicting them")
+		c.remove(name)
+		promFilesCacheRequestsTotal.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	promFilesCacheRequestsTotal.WithLabelValues("hit").Inc()
+	return files, true
+}
+
+// add caches the files matching filenames extracted from the layer with the
+// given hash, unless the hash is not a digest or the files alone exceed the
+// maximum size of the cache.
+func (c *filesCache) add(hash string, filenames []string, files tarutil.FilesMap) {
+	if c == nil {
+		return
+	}
+
+	name, ok := filesCacheName(hash, filenames)
+	if !ok {
+		return
+	}
+
+	size, err := c.write(name, files)
+	if err != nil {
+		log.WithError(err).WithField("layer", hash).Warning("could not cache the files of the layer")
+		return
+	}
+
+	if size < 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[name]; ok {
+		c.size -= elem.Value.(filesCacheEntry).size
+		c.lru.Remove(elem)
+	}
+	c.entries[name] = c.lru.PushFront(filesCacheEntry{name: name, size: size})
+	c.size += size
+	c.evict()
+}
+
+// write writes an entry to a temporary file, which is then renamed so that the
+// entries are complete, and returns its size, or -1 if it exceeds the maximum
+// size of the cache.
+func (c *filesCache) write(name string, files tarutil.FilesMap) (int64, error) {
+	f, err := ioutil.TempFile(c.dir, name+".tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(f.Name())
+
+	if err := gob.NewEncoder(f).Encode(files); err != nil {
+		f.Close()
+		return 0, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+
+	if info.Size() > c.maxSize {
+		return -1, nil
+	}
+
+	return info.Size(), os.Rename(f.Name(), filepath.Join(c.dir, name))
+}
+
+// remove evicts the entry with the given name, which could not be read.
+func (c *filesCache) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[name]; ok {
+		c.removeElement(elem)
+	}
+	promFilesCacheSizeBytes.Set(float64(c.size))
+}
+
+// evict removes the least recently used entries until the cache does not
+// exceed its maximum size. It must be called with c.mu held.
+func (c *filesCache) evict() {
+	for c.size > c.maxSize && c.lru.Len() > 0 {
+		c.removeElement(c.lru.Back())
+		promFilesCacheEvictionsTotal.Inc()
+	}
+	promFilesCacheSizeBytes.Set(float64(c.size))
+}
+
+// removeElement removes an entry and its file. It must be called with c.mu
+// held.
+func (c *filesCache) removeElement(elem *list.Element) {
+	entry := c.lru.Remove(elem).(filesCacheEntry)
+	delete(c.entries, entry.name)
+	c.size -= entry.size
+
+	if err := os.Remove(filepath.Join(c.dir, entry.name)); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).WithField("entry", entry.name).Warning("could not remove the cached files")
+	}
+}
+
+func readFilesCacheEntry(path string) (tarutil.FilesMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var files tarutil.FilesMap
+	if err := gob.NewDecoder(f).Decode(&files); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/pkg/tarutil"
+)
+
+func TestFilesCache(t *testing.T) {
+	var (
+		base      = "sha256:" + strings.Repeat("a", 64)
+		other     = "sha256:" + strings.Repeat("b", 64)
+		filenames = []string{"etc/os-release", "var/lib/dpkg/status"}
+		files     = tarutil.FilesMap{"etc/os-release": []byte("ID=debian\n")}
+	)
+
+	dir, err := ioutil.TempDir("", "clair-files-cache")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// A nil cache caches nothing.
+	var c *filesCache
+	c.add(base, filenames, files)
+	_, ok := c.get(base, filenames)
+	assert.False(t, ok)
+	c, err = newFilesCache("", 1<<20)
+	assert.Nil(t, err)
+	assert.Nil(t, c)
+
+	c, err = newFilesCache(dir, 1<<20)
+	require.Nil(t, err)
+	c.add(base, filenames, files)
+	cached, ok := c.get(base, []string{"var/lib/dpkg/status", "etc/os-release"})
+	if assert.True(t, ok) {
+		assert.Equal(t, files, cached)
+	}
+
+	// The files extracted for other filenames, or from a layer whose hash is
+	// not a digest, are not cached.
+	_, ok = c.get(base, filenames[:1])
+	assert.False(t, ok)
+	c.add("layer", filenames, files)
+	_, ok = c.get("layer", filenames)
+	assert.False(t, ok)
+
+	// The entries are kept across restarts.
+	c, err = newFilesCache(dir, 1<<20)
+	require.Nil(t, err)
+	_, ok = c.get(base, filenames)
+	assert.True(t, ok)
+
+	// The least recently used entry is evicted.
+	c.maxSize = c.size
+	c.add(other, filenames, files)
+	_, ok = c.get(base, filenames)
+	assert.False(t, ok)
+	_, ok = c.get(other, filenames)
+	assert.True(t, ok)
+	entries, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.Nil(t, err)
+	assert.Len(t, entries, 1)
+
+	// The entries that cannot be read are evicted.
+	require.Nil(t, ioutil.WriteFile(entries[0], []byte("corrupted"), 0600))
+	_, ok = c.get(other, filenames)
+	assert.False(t, ok)
+	assert.Zero(t, c.size)
+
+	// The entries larger than the cache are not cached.
+	c, err = newFilesCache(dir, 1)
+	require.Nil(t, err)
+	c.add(base, filenames, files)
+	_, ok = c.get(base, filenames)
+	assert.False(t, ok)
+	entries, err = filepath.Glob(filepath.Join(dir, "*"))
+	require.Nil(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/coreos/clair/database"
+)
+
+var promLayerCacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "clair_worker_layer_cache_requests_total",
+	Help: "Number of lookups of the analyzed layers in the in-process cache, by result (hit or miss).",
+}, []string{"result"})
+
+func init() {
+	prometheus.MustRegister(promLayerCacheRequestsTotal)
+}
+
+// layerCache holds the analyzed layers in front of the database, keyed by
+// their hash.
+type layerCache struct {
+	cache *lru.Cache
+	ttl   time.Duration
+}
+
+type layerCacheEntry struct {
+	layer      database.Layer
+	expiration time.Time
+}
+
+// newLayerCache returns a cache of at most size layers, which are kept at most
+// ttl, or forever when it is not positive. It returns nil, which caches
+// nothing, when size is not positive.
+func newLayerCache(size int, ttl time.Duration) *layerCache {
+	if size <= 0 {
+		return nil
+	}
+
+	cache, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+
+	return &layerCache{cache: cache, ttl: ttl}
+}
+
+// get returns the layer with the given hash if it has been analyzed by all the
+// given detectors. The entries that expired or were analyzed by older
+// detectors, e.g. before an upgrade, are evicted.
+func (c *layerCache) get(hash string, detectors []database.Detector) (database.Layer, bool) {
+	if c == nil {
+		return database.Layer{}, false
+	}
+
+	v, ok := c.cache.Get(hash)
+	if !ok {
+		promLayerCacheRequestsTotal.WithLabelValues("miss").Inc()
+		return database.Layer{}, false
+	}
+
+	entry := v.(layerCacheEntry)
+	if (!entry.expiration.IsZero() && time.Now().After(entry.expiration)) ||
+		len(database.DiffDetectors(detectors, entry.layer.By)) != 0 {
+		c.cache.Remove(hash)
+		promLayerCacheRequestsTotal.WithLabelValues("miss").Inc()
+		return database.Layer{}, false
+	}
+
+	promLayerCacheRequestsTotal.WithLabelValues("hit").Inc()
+	return entry.layer, true
+}
+
+// add caches the given layer.
+func (c *layerCache) add(layer database.Layer) {
+	if c == nil {
+		return
+	}
+
+	entry := layerCacheEntry{layer: layer}
+	if c.ttl > 0 {
+		entry.expiration = time.Now().Add(c.ttl)
+	}
+
+	c.cache.Add(layer.Hash, entry)
+}
+
+// remove evicts the layers with the given hashes, e.g. after they are deleted
+// from the database.
+func (c *layerCache) remove(hashes ...string) {
+	if c == nil {
+		return
+	}
+
+	for _, hash := range hashes {
+		c.cache.Remove(hash)
+	}
+}
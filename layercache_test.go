@@ -0,0 +1,71 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/clair/database"
+)
+
+func TestLayerCache(t *testing.T) {
+	detectors := []database.Detector{
+		database.NewNamespaceDetector("os-release", "1.0"),
+		database.NewFeatureDetector("dpkg", "1.0"),
+	}
+
+	// A nil cache caches nothing.
+	var c *layerCache
+	c.add(database.Layer{Hash: "layer", By: detectors})
+	c.remove("layer")
+	_, ok := c.get("layer", detectors)
+	assert.False(t, ok)
+	assert.Nil(t, newLayerCache(0, time.Hour))
+
+	c = newLayerCache(1, 0)
+	c.add(database.Layer{Hash: "layer", By: detectors})
+	layer, ok := c.get("layer", detectors)
+	if assert.True(t, ok) {
+		assert.Equal(t, "layer", layer.Hash)
+	}
+
+	// The least recently used layer is evicted.
+	c.add(database.Layer{Hash: "other", By: detectors})
+	_, ok = c.get("layer", detectors)
+	assert.False(t, ok)
+
+	// A layer analyzed by older detectors is evicted.
+	upgraded := []database.Detector{detectors[0], database.NewFeatureDetector("dpkg", "2.0")}
+	_, ok = c.get("other", upgraded)
+	assert.False(t, ok)
+	_, ok = c.get("other", detectors)
+	assert.False(t, ok)
+
+	// A deleted layer is evicted.
+	c.add(database.Layer{Hash: "other", By: detectors})
+	c.remove("other")
+	_, ok = c.get("other", detectors)
+	assert.False(t, ok)
+
+	// An expired layer is evicted.
+	c = newLayerCache(1, time.Nanosecond)
+	c.add(database.Layer{Hash: "layer", By: detectors})
+	time.Sleep(time.Millisecond)
+	_, ok = c.get("layer", detectors)
+	assert.False(t, ok)
+}
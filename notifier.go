@@ -15,21 +15,25 @@
 package clair
 
 import (
+	"encoding/json"
+	"math/rand"
 	"time"
 
-	"github.com/coreos/pkg/timeutil"
 	"github.com/pborman/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/coreos/clair/database"
 	"github.com/coreos/clair/ext/notification"
+	"github.com/coreos/clair/pkg/pagination"
 	"github.com/coreos/clair/pkg/stopper"
 )
 
 const (
 	notifierCheckInterval       = 5 * time.Minute
 	notifierMaxBackOff          = 15 * time.Minute
+	notifierBackOffFactor       = 2
+	notifierInitialBackOff      = time.Second
 	notifierLockRefreshDuration = time.Minute * 2
 	notifierLockDuration        = time.Minute*8 + notifierLockRefreshDuration
 
@@ -60,6 +64,10 @@ func RunNotifier(config *notification.Config, datastore database.Datastore, stop
 	defer stopper.End()
 
 	// Configure registered notifiers.
+	if config != nil {
+		config.Datastore = datastore
+		config.Stop = stopper.Chan()
+	}
 	for senderName, sender := range notification.Senders() {
 		if configured, err := sender.Configure(config); configured {
 			log.WithField(logSenderName, senderName).Info("sender configured")
@@ -91,7 +99,50 @@ func RunNotifier(config *notification.Config, datastore database.Datastore, stop
 		// Handle task.
 		done := make(chan bool, 1)
 		go func() {
-			success, interrupted := handleTask(*notification, stopper, config.Attempts)
+			skip, err := belowMinimumSeverity(datastore, notification.Name, config.MinimumSeverity)
+			if err != nil {
+				log.WithError(err).WithField(logNotiName, notification.Name).Warning("could not check notification severity")
+			}
+
+			allowlisted, err := allowlistedNotification(datastore, notification.Name)
+			if err != nil {
+				log.WithError(err).WithField(logNotiName, notification.Name).Warning("could not check notification against the allowlist")
+			}
+
+			var key notificationKey
+			var keyed, coalesced bool
+			if config.DedupWindow > 0 && !skip && !allowlisted {
+				key, keyed, err = findNotificationKey(datastore, notification.Name)
+				if err != nil {
+					log.WithError(err).WithField(logNotiName, notification.Name).Warning("could not find the vulnerability of the notification")
+				}
+			}
+
+			if keyed {
+				coalesced, err = coalesceNotification(datastore, config.DedupWindow, key, *notification, whoAmI)
+				if err != nil {
+					log.WithError(err).WithField(logNotiName, notification.Name).Warning("could not check notification against the ones recently sent")
+				}
+			}
+
+			var success, interrupted bool
+			if skip {
+				log.WithFields(log.Fields{logNotiName: notification.Name, "minimum severity": config.MinimumSeverity}).Info("skipping notification below the minimum severity")
+				success = true
+			} else if allowlisted {
+				log.WithField(logNotiName, notification.Name).Info("skipping notification of an allowlisted vulnerability")
+				success = true
+			} else if coalesced {
+				log.WithFields(log.Fields{logNotiName: notification.Name, "vulnerability": key.name, "namespace": key.namespace}).Info("coalescing notification with the one recently sent about the same vulnerability")
+			} else {
+				success, interrupted = handleTask(*notification, stopper, config)
+				if success && keyed {
+					if err := notificationSent(datastore, key, *notification); err != nil {
+						log.WithError(err).WithField(logNotiName, notification.Name).Warning("could not record the sent notification")
+					}
+				}
+			}
+
 			if success {
 				err := markNotificationAsRead(datastore, notification.Name)
 				if err != nil {
@@ -102,7 +153,11 @@ func RunNotifier(config *notification.Config, datastore database.Datastore, stop
 			if interrupted {
 				running = false
 			}
-			unlock(datastore, notification.Name, whoAmI)
+			// The coalesced notifications stay locked until their window has
+			// elapsed.
+			if !coalesced {
+				unlock(datastore, notification.Name, whoAmI)
+			}
 			done <- true
 		}()
 
@@ -148,15 +203,21 @@ func findTask(datastore database.Datastore, renotifyInterval time.Duration, whoA
 	}
 }
 
-func handleTask(n database.NotificationHook, st *stopper.Stopper, maxAttempts int) (bool, bool) {
+func handleTask(n database.NotificationHook, st *stopper.Stopper, config *notification.Config) (bool, bool) {
+	maxAttempts := config.Attempts
+
 	// Send notification.
 	for senderName, sender := range notification.Senders() {
 		var attempts int
 		var backOff time.Duration
+		b := newBackOff(config)
 		for {
 			// Max attempts exceeded.
 			if attempts >= maxAttempts {
 				log.WithFields(log.Fields{logNotiName: n.Name, logSenderName: senderName, "max attempts": maxAttempts}).Info("giving up on sending notification : max attempts exceeded")
+				if forgetter, ok := sender.(notification.Forgetter); ok {
+					forgetter.Forget(n.Name)
+				}
 				return false, false
 			}
 
@@ -173,7 +234,7 @@ func handleTask(n database.NotificationHook, st *stopper.Stopper, maxAttempts in
 				// Send failed; increase attempts/backoff and retry.
 				promNotifierBackendErrorsTotal.WithLabelValues(senderName).Inc()
 				log.WithError(err).WithFields(log.Fields{logSenderName: senderName, logNotiName: n.Name}).Error("could not send notification via notifier")
-				backOff = timeutil.ExpBackoff(backOff, notifierMaxBackOff)
+				backOff = b.next()
 				attempts++
 				continue
 			}
@@ -187,6 +248,187 @@ func handleTask(n database.NotificationHook, st *stopper.Stopper, maxAttempts in
 	return true, false
 }
 
+// backOff computes the delays between the attempts to send a notification.
+//
+// The delays grow exponentially up to a maximum and are jittered so that the
+// notifications that failed at the same time are not all retried at once.
+type backOff struct {
+	factor  float64
+	max     time.Duration
+	current time.Duration
+}
+
+// newBackOff creates a backOff from the notifier configuration, using the
+// default factor and maximum delay when they are not set.
+func newBackOff(config *notification.Config) *backOff {
+	b := &backOff{
+		factor: config.BackoffFactor,
+		max:    config.MaxBackoff,
+	}
+
+	if b.factor <= 1 {
+		b.factor = notifierBackOffFactor
+	}
+
+	if b.max <= 0 {
+		b.max = notifierMaxBackOff
+	}
+
+	if config.RenotifyInterval > 0 && b.max > config.RenotifyInterval {
+		b.max = config.RenotifyInterval
+	}
+
+	return b
+}
+
+// next returns the delay to wait before the next attempt, which is randomly
+// chosen between half and the whole of the exponentially growing delay.
+func (b *backOff) next() time.Duration {
+	if b.current == 0 {
+		b.current = notifierInitialBackOff
+	} else {
+		b.current = time.Duration(float64(b.current) * b.factor)
+	}
+
+	if b.current > b.max {
+		b.current = b.max
+	}
+
+	half := b.current / 2
+	return half + time.Duration(rand.Int63n(int64(b.current-half)+1))
+}
+
+// notificationKey identifies the vulnerability that a notification is about.
+type notificationKey struct {
+	name      string
+	namespace string
+}
+
+// notificationDedupKeyPrefix prefixes the keys of the coalescing states of the
+// vulnerabilities in the key/value store, which are shared by the notifiers of
+// every Clair instance.
+const notificationDedupKeyPrefix = "notifierDedup/"
+
+// notificationDedup is the coalescing state of a vulnerability, which is
+// stored as JSON in the key/value store.
+//
+// The notifications about the vulnerability received within the window after
+// one about it was sent are coalesced: they are not sent but stay unread and
+// locked until the window has elapsed, and the latest of them is then sent
+// while the older ones are marked as read.
+type notificationDedup struct {
+	// Sent is when the last notification about the vulnerability was sent.
+	Sent time.Time `json:"sent"`
+	// Latest is the name of the latest coalesced notification, which was
+	// created at LatestCreated.
+	Latest        string    `json:"latest,omitempty"`
+	LatestCreated time.Time `json:"latestCreated,omitempty"`
+}
+
+func (key notificationKey) dedupKey() string {
+	return notificationDedupKeyPrefix + key.namespace + "/" + key.name
+}
+
+func findNotificationDedup(tx database.Session, key notificationKey) (notificationDedup, error) {
+	var dedup notificationDedup
+	value, ok, err := tx.FindKeyValue(key.dedupKey())
+	if err != nil || !ok {
+		return dedup, err
+	}
+
+	err = json.Unmarshal([]byte(value), &dedup)
+	return dedup, err
+}
+
+func updateNotificationDedup(tx database.Session, key notificationKey, dedup notificationDedup) error {
+	value, err := json.Marshal(dedup)
+	if err != nil {
+		return err
+	}
+
+	return tx.UpdateKeyValue(key.dedupKey(), string(value))
+}
+
+// coalesceNotification returns whether the notification about the given
+// vulnerability must not be sent because another one about it was sent within
+// the window.
+//
+// The coalesced notification, locked by the given owner, stays locked until
+// the window has elapsed. It supersedes the older coalesced notification,
+// which is marked as read, or is marked as read itself if it is older.
+func coalesceNotification(datastore database.Datastore, window time.Duration, key notificationKey, n database.NotificationHook, owner string) (bool, error) {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	dedup, err := findNotificationDedup(tx, key)
+	if err != nil {
+		return false, err
+	}
+
+	until := time.Until(dedup.Sent.Add(window))
+	if dedup.Sent.IsZero() || until <= 0 {
+		return false, nil
+	}
+
+	superseded := dedup.Latest
+	if dedup.Latest == "" || dedup.LatestCreated.Before(n.Created) {
+		dedup.Latest, dedup.LatestCreated = n.Name, n.Created
+	} else if dedup.Latest != n.Name {
+		superseded = n.Name
+	}
+
+	if superseded != "" && superseded != dedup.Latest {
+		if err := tx.MarkNotificationAsRead(superseded); err != nil {
+			return false, err
+		}
+	}
+
+	if _, _, err := tx.Lock(n.Name, owner, until, true); err != nil {
+		return false, err
+	}
+
+	if err := updateNotificationDedup(tx, key, dedup); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// notificationSent records that the notification about the given
+// vulnerability was sent, which starts a new window and supersedes the
+// coalesced notification that is not more recent.
+func notificationSent(datastore database.Datastore, key notificationKey, n database.NotificationHook) error {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	dedup, err := findNotificationDedup(tx, key)
+	if err != nil {
+		return err
+	}
+
+	if dedup.Latest != "" && !dedup.LatestCreated.After(n.Created) {
+		if dedup.Latest != n.Name {
+			if err := tx.MarkNotificationAsRead(dedup.Latest); err != nil {
+				return err
+			}
+		}
+		dedup.Latest, dedup.LatestCreated = "", time.Time{}
+	}
+
+	dedup.Sent = time.Now()
+	if err := updateNotificationDedup(tx, key, dedup); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func findNewNotification(datastore database.Datastore, renotifyInterval time.Duration) (database.NotificationHook, bool, error) {
 	tx, err := datastore.Begin()
 	if err != nil {
@@ -196,6 +438,112 @@ func findNewNotification(datastore database.Datastore, renotifyInterval time.Dur
 	return tx.FindNewNotification(time.Now().Add(-renotifyInterval))
 }
 
+// belowMinimumSeverity returns whether neither the old nor the new
+// vulnerability of a notification reaches the given minimum severity.
+func belowMinimumSeverity(datastore database.Datastore, name string, minimum database.Severity) (bool, error) {
+	if minimum == "" {
+		return false, nil
+	}
+
+	tx, err := datastore.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	n, ok, err := tx.FindVulnerabilityNotification(name, 1, pagination.FirstPageToken, pagination.FirstPageToken)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	for _, vuln := range []*database.PagedVulnerableAncestries{n.Old, n.New} {
+		if vuln != nil && vuln.Severity.Compare(minimum) >= 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// findNotificationKey returns the vulnerability that a notification is about,
+// which is its new vulnerability, or its old one when it was removed.
+func findNotificationKey(datastore database.Datastore, name string) (notificationKey, bool, error) {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return notificationKey{}, false, err
+	}
+	defer tx.Rollback()
+
+	n, ok, err := tx.FindVulnerabilityNotification(name, 1, pagination.FirstPageToken, pagination.FirstPageToken)
+	if err != nil || !ok {
+		return notificationKey{}, false, err
+	}
+
+	for _, vuln := range []*database.PagedVulnerableAncestries{n.New, n.Old} {
+		if vuln != nil {
+			return notificationKey{vuln.Name, vuln.Namespace.Name}, true, nil
+		}
+	}
+
+	return notificationKey{}, false, nil
+}
+
+// allowlistedNotification returns whether both the old and the new
+// vulnerability of a notification, when they exist, are suppressed by the
+// allowlist. Only the entries that are not restricted to a feature apply to
+// the notifications.
+func allowlistedNotification(datastore database.Datastore, name string) (bool, error) {
+	if !hasAllowlist() {
+		return false, nil
+	}
+
+	tx, err := datastore.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	n, ok, err := tx.FindVulnerabilityNotification(name, 1, pagination.FirstPageToken, pagination.FirstPageToken)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if n.Old == nil && n.New == nil {
+		return false, nil
+	}
+
+	for _, vuln := range []*database.PagedVulnerableAncestries{n.Old, n.New} {
+		if vuln != nil && !IsAllowlisted(vuln.Name, vuln.Namespace.Name, "") {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// FlushNotificationsBefore discards the pending notifications created before
+// the given time, e.g. after an outage of the receiver, so that they are not
+// sent anymore, and returns their names.
+func FlushNotificationsBefore(datastore database.Datastore, before time.Time) ([]string, error) {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	names, err := tx.DeletePendingNotificationsBefore(before)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{"before": before, "notifications": len(names)}).Info("flushed pending notifications")
+	return names, nil
+}
+
 func markNotificationAsRead(datastore database.Datastore, name string) error {
 	tx, err := datastore.Begin()
 	if err != nil {
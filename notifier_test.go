@@ -0,0 +1,212 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/notification"
+	"github.com/coreos/clair/ext/versionfmt/dpkg"
+	"github.com/coreos/clair/pkg/pagination"
+	"github.com/coreos/clair/pkg/stopper"
+)
+
+func TestBackOff(t *testing.T) {
+	// Defaults.
+	b := newBackOff(&notification.Config{RenotifyInterval: 2 * time.Hour})
+	assert.Equal(t, float64(notifierBackOffFactor), b.factor)
+	assert.Equal(t, notifierMaxBackOff, b.max)
+
+	// The maximum delay is capped by the renotify interval.
+	b = newBackOff(&notification.Config{RenotifyInterval: time.Minute, MaxBackoff: time.Hour})
+	assert.Equal(t, time.Minute, b.max)
+
+	b = newBackOff(&notification.Config{MaxBackoff: 10 * time.Second, BackoffFactor: 3})
+	for _, expected := range []time.Duration{time.Second, 3 * time.Second, 9 * time.Second, 10 * time.Second, 10 * time.Second} {
+		delay := b.next()
+		assert.Equal(t, expected, b.current)
+		assert.True(t, delay >= expected/2 && delay <= expected, "delay %s should be between %s and %s", delay, expected/2, expected)
+	}
+}
+
+func TestBelowMinimumSeverity(t *testing.T) {
+	notifications := map[string]database.VulnerabilityNotificationWithVulnerable{
+		"new-low": {
+			New: &database.PagedVulnerableAncestries{Vulnerability: database.Vulnerability{Severity: database.LowSeverity}},
+		},
+		"old-high": {
+			Old: &database.PagedVulnerableAncestries{Vulnerability: database.Vulnerability{Severity: database.HighSeverity}},
+			New: &database.PagedVulnerableAncestries{Vulnerability: database.Vulnerability{Severity: database.NegligibleSeverity}},
+		},
+		"new-medium": {
+			New: &database.PagedVulnerableAncestries{Vulnerability: database.Vulnerability{Severity: database.MediumSeverity}},
+		},
+	}
+
+	datastore := &database.MockDatastore{}
+	datastore.FctBegin = func() (database.Session, error) {
+		session := &database.MockSession{}
+		session.FctRollback = func() error { return nil }
+		session.FctFindVulnerabilityNotification = func(name string, limit int, oldPage pagination.Token, newPage pagination.Token) (database.VulnerabilityNotificationWithVulnerable, bool, error) {
+			n, ok := notifications[name]
+			return n, ok, nil
+		}
+		return session, nil
+	}
+
+	for name, expected := range map[string]bool{"new-low": true, "old-high": false, "new-medium": false, "missing": false} {
+		below, err := belowMinimumSeverity(datastore, name, database.MediumSeverity)
+		if assert.Nil(t, err) {
+			assert.Equal(t, expected, below, name)
+		}
+	}
+
+	// Without minimum severity, every notification is sent.
+	below, err := belowMinimumSeverity(datastore, "new-low", "")
+	if assert.Nil(t, err) {
+		assert.False(t, below)
+	}
+}
+
+func TestNotificationDedup(t *testing.T) {
+	const window = 300 * time.Millisecond
+	debian := database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+	key := notificationKey{"CVE-1", debian.Name}
+
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "mem"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	tx, err := datastore.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{debian}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{archiveTestVulnerability(key.name, debian, database.HighSeverity)}))
+	require.Nil(t, tx.Commit())
+
+	insert := func(names ...string) {
+		tx, err := datastore.Begin()
+		require.Nil(t, err)
+		defer tx.Rollback()
+
+		var notifications []database.VulnerabilityNotification
+		for i, name := range names {
+			notifications = append(notifications, database.VulnerabilityNotification{
+				NotificationHook: database.NotificationHook{Name: name, Created: time.Now().Add(time.Duration(i) * time.Second)},
+				New:              &database.Vulnerability{Name: key.name, Namespace: debian},
+			})
+		}
+		require.Nil(t, tx.InsertVulnerabilityNotifications(notifications))
+		require.Nil(t, tx.Commit())
+	}
+
+	// next finds and locks a notification like findTask, without waiting.
+	next := func(owner string) (database.NotificationHook, bool) {
+		n, ok, err := findNewNotification(datastore, time.Hour)
+		require.Nil(t, err)
+		if ok {
+			locked, _ := lock(datastore, n.Name, owner, notifierLockDuration, false)
+			require.True(t, locked)
+		}
+		return n, ok
+	}
+
+	notified := func(name string) bool {
+		tx, err := datastore.Begin()
+		require.Nil(t, err)
+		defer tx.Rollback()
+
+		n, ok, err := tx.FindVulnerabilityNotification(name, 1, pagination.FirstPageToken, pagination.FirstPageToken)
+		require.Nil(t, err)
+		require.True(t, ok)
+		return !n.Notified.IsZero()
+	}
+
+	send := func(n database.NotificationHook, owner string) {
+		require.Nil(t, notificationSent(datastore, key, n))
+		require.Nil(t, markNotificationAsRead(datastore, n.Name))
+		unlock(datastore, n.Name, owner)
+	}
+
+	// The first notification is sent.
+	insert("a")
+	n, ok := next("notifier-1")
+	require.True(t, ok)
+	coalesced, err := coalesceNotification(datastore, window, key, n, "notifier-1")
+	require.Nil(t, err)
+	require.False(t, coalesced)
+	send(n, "notifier-1")
+
+	// The following ones within the window are coalesced into the latest one,
+	// and stay locked.
+	insert("b", "c")
+	for _, expected := range []string{"b", "c"} {
+		n, ok = next("notifier-1")
+		require.True(t, ok)
+		require.Equal(t, expected, n.Name)
+		coalesced, err = coalesceNotification(datastore, window, key, n, "notifier-1")
+		require.Nil(t, err)
+		require.True(t, coalesced)
+	}
+	assert.True(t, notified("b"))
+	assert.False(t, notified("c"))
+
+	// After a restart, that is with another owner and without anything in
+	// memory, the latest one is sent once the window has elapsed.
+	_, ok = next("notifier-2")
+	assert.False(t, ok)
+
+	time.Sleep(window)
+	n, ok = next("notifier-2")
+	require.True(t, ok)
+	require.Equal(t, "c", n.Name)
+	coalesced, err = coalesceNotification(datastore, window, key, n, "notifier-2")
+	require.Nil(t, err)
+	require.False(t, coalesced)
+	send(n, "notifier-2")
+
+	_, ok = next("notifier-2")
+	assert.False(t, ok)
+	assert.True(t, notified("c"))
+}
+
+// forgettingSender fails every notification and records the ones it forgot.
+type forgettingSender struct {
+	forgotten []string
+}
+
+func (s *forgettingSender) Configure(*notification.Config) (bool, error) { return true, nil }
+
+func (s *forgettingSender) Send(string) error { return errors.New("unavailable") }
+
+func (s *forgettingSender) Forget(notificationName string) {
+	s.forgotten = append(s.forgotten, notificationName)
+}
+
+func TestHandleTaskForgetsOnGiveUp(t *testing.T) {
+	sender := &forgettingSender{}
+	notification.RegisterSender("forgetting", sender)
+	defer notification.UnregisterSender("forgetting")
+
+	success, interrupted := handleTask(database.NotificationHook{Name: "notification"}, stopper.NewStopper(), &notification.Config{Attempts: 1})
+	assert.False(t, success)
+	assert.False(t, interrupted)
+	assert.Equal(t, []string{"notification"}, sender.forgotten)
+}
@@ -0,0 +1,234 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cyclonedx implements the encodings of the CycloneDX 1.5 software
+// bills of materials, restricted to the components and their vulnerabilities.
+//
+// See https://cyclonedx.org/docs/1.5/json and https://cyclonedx.org/docs/1.5/xml.
+package cyclonedx
+
+import (
+	"encoding/json"
+	"encoding/xml"
+)
+
+// SpecVersion is the version of the CycloneDX specification of the BOMs.
+const SpecVersion = "1.5"
+
+// The media types of the encodings of the BOMs.
+const (
+	MediaTypeJSON = "application/vnd.cyclonedx+json; version=" + SpecVersion
+	MediaTypeXML  = "application/vnd.cyclonedx+xml; version=" + SpecVersion
+)
+
+// xmlNamespace is the namespace of the XML BOMs.
+const xmlNamespace = "http://cyclonedx.org/schema/bom/" + SpecVersion
+
+// The types of the components.
+const (
+	ComponentTypeApplication     = "application"
+	ComponentTypeContainer       = "container"
+	ComponentTypeLibrary         = "library"
+	ComponentTypeOperatingSystem = "operating-system"
+)
+
+// The severities of the ratings of the vulnerabilities.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityInfo     = "info"
+	SeverityNone     = "none"
+	SeverityUnknown  = "unknown"
+)
+
+// BOM is a bill of materials, listing the components of a piece of software
+// and their known vulnerabilities.
+//
+// The fields with no data are left out of both encodings, as the arrays of
+// the JSON schema and the sequences of the XML schema may not be empty. The
+// lists are named types to leave their empty XML sequences out, which
+// encoding/xml writes otherwise.
+type BOM struct {
+	XMLName      xml.Name `json:"-" xml:"bom"`
+	XMLNamespace string   `json:"-" xml:"xmlns,attr"`
+
+	BOMFormat       string          `json:"bomFormat" xml:"-"`
+	SpecVersion     string          `json:"specVersion" xml:"-"`
+	SerialNumber    string          `json:"serialNumber,omitempty" xml:"serialNumber,attr,omitempty"`
+	Version         int             `json:"version" xml:"version,attr"`
+	Metadata        *Metadata       `json:"metadata,omitempty" xml:"metadata,omitempty"`
+	Components      Components      `json:"components,omitempty" xml:"components,omitempty"`
+	Vulnerabilities Vulnerabilities `json:"vulnerabilities,omitempty" xml:"vulnerabilities,omitempty"`
+}
+
+// Metadata describes the BOM itself: when and by which tools it was made, and
+// the component that it describes.
+type Metadata struct {
+	Timestamp string     `json:"timestamp,omitempty" xml:"timestamp,omitempty"`
+	Tools     *Tools     `json:"tools,omitempty" xml:"tools,omitempty"`
+	Component *Component `json:"component,omitempty" xml:"component,omitempty"`
+}
+
+// Tools are the tools that made the BOM.
+type Tools struct {
+	Components Components `json:"components" xml:"components"`
+}
+
+// Component is a piece of software, identified within its BOM by its BOMRef.
+type Component struct {
+	Type       string     `json:"type" xml:"type,attr"`
+	BOMRef     string     `json:"bom-ref,omitempty" xml:"bom-ref,attr,omitempty"`
+	Name       string     `json:"name" xml:"name"`
+	Version    string     `json:"version,omitempty" xml:"version,omitempty"`
+	PURL       string     `json:"purl,omitempty" xml:"purl,omitempty"`
+	Properties Properties `json:"properties,omitempty" xml:"properties,omitempty"`
+}
+
+// Property is a name-value pair giving the data that the schema has no field
+// for.
+type Property struct {
+	Name  string `json:"name" xml:"name,attr"`
+	Value string `json:"value" xml:",chardata"`
+}
+
+// Vulnerability is a known vulnerability of the components that it affects.
+type Vulnerability struct {
+	BOMRef         string     `json:"bom-ref,omitempty" xml:"bom-ref,attr,omitempty"`
+	ID             string     `json:"id" xml:"id"`
+	Source         *Source    `json:"source,omitempty" xml:"source,omitempty"`
+	Ratings        Ratings    `json:"ratings,omitempty" xml:"ratings,omitempty"`
+	Description    string     `json:"description,omitempty" xml:"description,omitempty"`
+	Recommendation string     `json:"recommendation,omitempty" xml:"recommendation,omitempty"`
+	Advisories     Advisories `json:"advisories,omitempty" xml:"advisories,omitempty"`
+	Affects        Affects    `json:"affects,omitempty" xml:"affects,omitempty"`
+	Properties     Properties `json:"properties,omitempty" xml:"properties,omitempty"`
+}
+
+// Source is the source of the data of a vulnerability.
+type Source struct {
+	Name string `json:"name,omitempty" xml:"name,omitempty"`
+	URL  string `json:"url,omitempty" xml:"url,omitempty"`
+}
+
+// Rating is the severity of a vulnerability according to a source.
+type Rating struct {
+	Source   *Source `json:"source,omitempty" xml:"source,omitempty"`
+	Severity string  `json:"severity,omitempty" xml:"severity,omitempty"`
+}
+
+// Advisory is a link to an advisory of a vulnerability.
+type Advisory struct {
+	URL string `json:"url" xml:"url"`
+}
+
+// Affect references a component affected by a vulnerability by its BOMRef.
+type Affect struct {
+	Ref string `json:"ref" xml:"ref"`
+}
+
+// Components are encoded as a sequence of component elements in XML.
+type Components []Component
+
+// Vulnerabilities are encoded as a sequence of vulnerability elements in XML.
+type Vulnerabilities []Vulnerability
+
+// Properties are encoded as a sequence of property elements in XML.
+type Properties []Property
+
+// Ratings are encoded as a sequence of rating elements in XML.
+type Ratings []Rating
+
+// Advisories are encoded as a sequence of advisory elements in XML.
+type Advisories []Advisory
+
+// Affects are encoded as a sequence of target elements in XML.
+type Affects []Affect
+
+// MarshalXML implements xml.Marshaler.
+func (l Components) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeSequence(e, start, "component", len(l), func(i int) interface{} { return l[i] })
+}
+
+// MarshalXML implements xml.Marshaler.
+func (l Vulnerabilities) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeSequence(e, start, "vulnerability", len(l), func(i int) interface{} { return l[i] })
+}
+
+// MarshalXML implements xml.Marshaler.
+func (l Properties) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeSequence(e, start, "property", len(l), func(i int) interface{} { return l[i] })
+}
+
+// MarshalXML implements xml.Marshaler.
+func (l Ratings) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeSequence(e, start, "rating", len(l), func(i int) interface{} { return l[i] })
+}
+
+// MarshalXML implements xml.Marshaler.
+func (l Advisories) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeSequence(e, start, "advisory", len(l), func(i int) interface{} { return l[i] })
+}
+
+// MarshalXML implements xml.Marshaler.
+func (l Affects) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeSequence(e, start, "target", len(l), func(i int) interface{} { return l[i] })
+}
+
+// encodeSequence encodes the n elements returned by elem, named name, inside
+// start. Nothing is written when there are none.
+func encodeSequence(e *xml.Encoder, start xml.StartElement, name string, n int, elem func(int) interface{}) error {
+	if n == 0 {
+		return nil
+	}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if err := e.EncodeElement(elem(i), xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// New returns an empty BOM with the given serial number, which should be a
+// URN of a random UUID.
+func New(serialNumber string) *BOM {
+	return &BOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  SpecVersion,
+		SerialNumber: serialNumber,
+		Version:      1,
+	}
+}
+
+// EncodeJSON returns the JSON encoding of the BOM.
+func (b *BOM) EncodeJSON() ([]byte, error) {
+	return json.MarshalIndent(b, "", "  ")
+}
+
+// EncodeXML returns the XML encoding of the BOM, along with its declaration.
+func (b *BOM) EncodeXML() ([]byte, error) {
+	doc := *b
+	doc.XMLNamespace = xmlNamespace
+
+	d, err := xml.MarshalIndent(&doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), d...), nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cyclonedx
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBOM() *BOM {
+	bom := New("urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79")
+	bom.Metadata = &Metadata{
+		Timestamp: "2018-01-01T00:00:00Z",
+		Component: &Component{Type: ComponentTypeContainer, BOMRef: "image", Name: "image"},
+	}
+	bom.Components = []Component{{
+		Type:       ComponentTypeLibrary,
+		BOMRef:     "debian:9/openssl@1.1.0f-3",
+		Name:       "openssl",
+		Version:    "1.1.0f-3",
+		Properties: []Property{{Name: "clair:namespace", Value: "debian:9"}},
+	}}
+	bom.Vulnerabilities = []Vulnerability{{
+		BOMRef:     "debian:9/CVE-2017-3735",
+		ID:         "CVE-2017-3735",
+		Source:     &Source{Name: "debian:9"},
+		Ratings:    []Rating{{Severity: SeverityLow}},
+		Advisories: []Advisory{{URL: "https://security-tracker.debian.org/tracker/CVE-2017-3735"}},
+		Affects:    []Affect{{Ref: "debian:9/openssl@1.1.0f-3"}},
+	}}
+	return bom
+}
+
+func TestEncodeJSON(t *testing.T) {
+	d, err := testBOM().EncodeJSON()
+	require.Nil(t, err)
+
+	var doc map[string]interface{}
+	require.Nil(t, json.Unmarshal(d, &doc))
+	assert.Equal(t, "CycloneDX", doc["bomFormat"])
+	assert.Equal(t, "1.5", doc["specVersion"])
+	assert.Equal(t, float64(1), doc["version"])
+	assert.NotContains(t, doc, "XMLName")
+
+	components := doc["components"].([]interface{})
+	require.Len(t, components, 1)
+	assert.Equal(t, map[string]interface{}{
+		"type":       "library",
+		"bom-ref":    "debian:9/openssl@1.1.0f-3",
+		"name":       "openssl",
+		"version":    "1.1.0f-3",
+		"properties": []interface{}{map[string]interface{}{"name": "clair:namespace", "value": "debian:9"}},
+	}, components[0])
+
+	vulnerabilities := doc["vulnerabilities"].([]interface{})
+	require.Len(t, vulnerabilities, 1)
+	assert.Equal(t, []interface{}{map[string]interface{}{"ref": "debian:9/openssl@1.1.0f-3"}}, vulnerabilities[0].(map[string]interface{})["affects"])
+
+	// The empty arrays are left out.
+	d, err = New("").EncodeJSON()
+	require.Nil(t, err)
+	assert.NotContains(t, string(d), "components")
+	assert.NotContains(t, string(d), "serialNumber")
+}
+
+func TestEncodeXML(t *testing.T) {
+	d, err := testBOM().EncodeXML()
+	require.Nil(t, err)
+
+	doc := string(d)
+	assert.True(t, strings.HasPrefix(doc, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, doc, `<bom xmlns="http://cyclonedx.org/schema/bom/1.5" serialNumber="urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79" version="1">`)
+	assert.Contains(t, doc, `<component type="library" bom-ref="debian:9/openssl@1.1.0f-3">`)
+	assert.Contains(t, doc, `<property name="clair:namespace">debian:9</property>`)
+	assert.Contains(t, doc, `<vulnerability bom-ref="debian:9/CVE-2017-3735">`)
+	assert.Contains(t, doc, `<affects>`)
+	assert.Contains(t, doc, `<target>`)
+	assert.Contains(t, doc, `<ref>debian:9/openssl@1.1.0f-3</ref>`)
+	assert.NotContains(t, doc, "bomFormat")
+	assert.NotContains(t, doc, "<tools>")
+
+	// The empty sequences are left out.
+	assert.Equal(t, 1, strings.Count(doc, "<properties>"))
+	assert.NotContains(t, doc, "<properties></properties>")
+}
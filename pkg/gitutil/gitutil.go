@@ -35,23 +35,60 @@ var ErrFailedRevParse = errors.New("failed to rev-parse git repository")
 // ErrFailedPull is returned when a git pull is unsuccessful.
 var ErrFailedPull = errors.New("failed to pull git repository")
 
+// Options are the options of the git commands run by CloneOrPullWithOptions.
+type Options struct {
+	// Authorization is the value of the Authorization header sent to the
+	// remote over HTTP. It is passed to git through its environment, so that
+	// it is neither written to the repository nor visible in the arguments of
+	// the commands.
+	Authorization string
+
+	// Shallow only fetches the latest commit of the remote, for the
+	// repositories whose history is too large to be cloned entirely.
+	Shallow bool
+}
+
+// command prepares a git command, run in the provided path with the options.
+func (opts Options) command(path string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = path
+	if opts.Authorization != "" {
+		cmd.Env = append(os.Environ(),
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: "+opts.Authorization,
+		)
+	}
+	return cmd
+}
+
 // pull performs a git pull on the provided path and returns the commit SHA
 // for the HEAD reference.
-func pull(path string) (head string, err error) {
-	// Prepare a command to pull the repository.
-	cmd := exec.Command("git", "pull")
-	cmd.Dir = path
+//
+// A shallow repository is reset to the latest commit of the remote instead,
+// as its history cannot be merged.
+func pull(path string, opts Options) (head string, err error) {
+	// Prepare the commands to pull the repository.
+	cmds := []*exec.Cmd{opts.command(path, "pull")}
+	if opts.Shallow {
+		cmds = []*exec.Cmd{
+			opts.command(path, "fetch", "--depth", "1", "origin", "HEAD"),
+			opts.command(path, "reset", "--hard", "FETCH_HEAD"),
+		}
+	}
 
-	// Execute the command.
-	var commandOutput []byte
-	commandOutput, err = cmd.CombinedOutput()
-	if err != nil {
-		log.WithError(err).WithFields(log.Fields{
-			"path":   path,
-			"output": string(commandOutput),
-		}).Error("failed to git rev-parse repository")
-		err = ErrFailedPull
-		return
+	// Execute the commands.
+	for _, cmd := range cmds {
+		var commandOutput []byte
+		commandOutput, err = cmd.CombinedOutput()
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"path":   path,
+				"output": string(commandOutput),
+			}).Error("failed to git pull repository")
+			err = ErrFailedPull
+			return
+		}
 	}
 
 	return revParseHead(path)
@@ -63,6 +100,12 @@ func pull(path string) (head string, err error) {
 // If repoPath is left empty, a temporary directory is generated with the
 // provided prefix and returned.
 func CloneOrPull(remote, repoPath, tempDirPrefix string) (path, head string, err error) {
+	return CloneOrPullWithOptions(remote, repoPath, tempDirPrefix, Options{})
+}
+
+// CloneOrPullWithOptions is CloneOrPull, with the git commands run with the
+// provided options.
+func CloneOrPullWithOptions(remote, repoPath, tempDirPrefix string, opts Options) (path, head string, err error) {
 	// Create a temporary directory if the path is unspecified.
 	if repoPath == "" {
 		path, err = ioutil.TempDir(os.TempDir(), tempDirPrefix)
@@ -74,17 +117,17 @@ func CloneOrPull(remote, repoPath, tempDirPrefix string) (path, head string, err
 	}
 
 	if _, pathExists := os.Stat(path); repoPath == "" || os.IsNotExist(pathExists) {
-		head, err = clone(remote, path)
+		head, err = clone(remote, path, opts)
 		return
 	}
 
-	head, err = pull(path)
+	head, err = pull(path, opts)
 	return
 }
 
 // clone performs a git clone to the provided path and returns the commit SHA
 // for the HEAD reference.
-func clone(remote, path string) (head string, err error) {
+func clone(remote, path string, opts Options) (head string, err error) {
 	// Handle an invalid path.
 	if path == "" {
 		log.WithField("remote", remote).Error("attempted to git clone repository to empty path")
@@ -93,8 +136,11 @@ func clone(remote, path string) (head string, err error) {
 	}
 
 	// Prepare a command to clone the repository.
-	cmd := exec.Command("git", "clone", remote, ".")
-	cmd.Dir = path
+	args := []string{"clone", remote, "."}
+	if opts.Shallow {
+		args = []string{"clone", "--depth", "1", remote, "."}
+	}
+	cmd := opts.command(path, args...)
 
 	// Execute the command.
 	var commandOutput []byte
@@ -75,3 +75,34 @@ func TestCloneOrPull(t *testing.T) {
 	require.Equal(t, path, newPath, "No new path should be created when pulling")
 	require.Equal(t, expectedHead, newHead)
 }
+
+func getCommitCount(t *testing.T, repoPath string) string {
+	cmd := exec.Command("git", "rev-list", "--count", "HEAD")
+	cmd.Dir = repoPath
+	out, err := cmd.CombinedOutput()
+	require.Nil(t, err, "Failed to count commits, output=%s", string(out))
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+func TestCloneOrPullShallow(t *testing.T) {
+	remote := createTemporaryGitRepo(t)
+	createEmptyCommit(t, remote)
+	createEmptyCommit(t, remote)
+	expectedHead := getHeadCommitRev(t, remote)
+
+	// The local clones are only shallow when the remote is a URL.
+	opts := Options{Shallow: true}
+	path, head, err := CloneOrPullWithOptions("file://"+remote, "", "9c2d4181", opts)
+	require.Nil(t, err)
+	defer os.RemoveAll(path)
+	require.Equal(t, expectedHead, head)
+	require.Equal(t, "1", getCommitCount(t, path))
+
+	createEmptyCommit(t, remote)
+	expectedHead = getHeadCommitRev(t, remote)
+	newPath, newHead, err := CloneOrPullWithOptions("file://"+remote, path, "9c2d4181", opts)
+	require.Nil(t, err)
+	require.Equal(t, path, newPath, "No new path should be created when pulling")
+	require.Equal(t, expectedHead, newHead)
+	require.Equal(t, "1", getCommitCount(t, path))
+}
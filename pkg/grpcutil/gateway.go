@@ -30,10 +30,11 @@ import (
 type RegisterServiceHandlerFunc func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error
 
 // NewGateway creates a new http.Handler and grpc.ClientConn with the provided
-// gRPC Services registered.
-func NewGateway(addr string, tlsConfig *tls.Config, funcs []RegisterServiceHandlerFunc) (http.Handler, *grpc.ClientConn, error) {
+// gRPC Services registered. The ServeMux of the Gateway is configured with
+// muxOpts, and the given DialOptions are added to the ones configuring TLS.
+func NewGateway(addr string, tlsConfig *tls.Config, funcs []RegisterServiceHandlerFunc, muxOpts []runtime.ServeMuxOption, opts ...grpc.DialOption) (http.Handler, *grpc.ClientConn, error) {
 	// Configure the right DialOptions the for TLS configuration.
-	var dialOpts []grpc.DialOption
+	dialOpts := opts
 	if tlsConfig != nil {
 		var gwTLSConfig *tls.Config
 		gwTLSConfig = tlsConfig.Clone()
@@ -49,7 +50,7 @@ func NewGateway(addr string, tlsConfig *tls.Config, funcs []RegisterServiceHandl
 	}
 
 	// Register services.
-	srvmux := runtime.NewServeMux()
+	srvmux := runtime.NewServeMux(muxOpts...)
 	for _, fn := range funcs {
 		err = fn(context.TODO(), srvmux, conn)
 		if err != nil {
@@ -16,13 +16,20 @@
 package grpcutil
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/cockroachdb/cmux"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc"
 
 	"github.com/coreos/clair/pkg/httputil"
 )
@@ -34,6 +41,23 @@ type MuxedGRPCServer struct {
 	TLSConfig           *tls.Config
 	ServicesFunc        RegisterServicesFunc
 	ServiceHandlerFuncs []RegisterServiceHandlerFunc
+
+	// ClientAuth is the policy for the certificates of the clients when
+	// serving TLS with a CA. The certificates are required when it is not set,
+	// and are not requested when serving TLS without a CA.
+	ClientAuth tls.ClientAuthType
+
+	// Stop, once closed, makes the server stop accepting requests and wait
+	// for the in-flight ones before returning.
+	Stop <-chan struct{}
+
+	// Interceptors are run on the gRPC requests, including the ones forwarded
+	// by the Gateway.
+	Interceptors Interceptors
+
+	// GatewayOptions configure the ServeMux of the Gateway, e.g. to encode
+	// some responses differently.
+	GatewayOptions []runtime.ServeMuxOption
 }
 
 // ListenAndServe listens on the TCP network address srv.Addr and handles both
@@ -47,22 +71,23 @@ func (srv *MuxedGRPCServer) ListenAndServe(mw httputil.Middleware) error {
 	if err != nil {
 		return err
 	}
+	defer l.Close()
 
 	tcpMux := cmux.New(l)
 
-	grpcListener := tcpMux.Match(cmux.HTTP2HeaderField("content-type", "application/grpc"))
+	grpcListener := newStoppableListener(tcpMux.Match(cmux.HTTP2HeaderField("content-type", "application/grpc")))
 	defer grpcListener.Close()
 
-	httpListener := tcpMux.Match(cmux.Any())
+	httpListener := newStoppableListener(tcpMux.Match(cmux.Any()))
 	defer httpListener.Close()
 
-	httpHandler, conn, err := NewGateway(httpListener.Addr().String(), nil, srv.ServiceHandlerFuncs)
+	httpHandler, conn, err := NewGateway(httpListener.Addr().String(), nil, srv.ServiceHandlerFuncs, srv.GatewayOptions)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	gsrv := NewServer(nil, srv.ServicesFunc)
+	gsrv := NewServer(nil, srv.ServicesFunc, srv.Interceptors)
 	defer gsrv.Stop()
 
 	go func() { tcpMux.Serve() }()
@@ -75,21 +100,140 @@ func (srv *MuxedGRPCServer) ListenAndServe(mw httputil.Middleware) error {
 	httpsrv := &http.Server{
 		Handler: httpHandler,
 	}
-	httpsrv.Serve(httpListener)
+	return srv.serve(httpsrv, gsrv, httpListener)
+}
+
+type acceptedConn struct {
+	conn net.Conn
+	err  error
+}
+
+// stoppableListener is a net.Listener whose Accept returns once it is closed.
+//
+// The Accept of the cmux listeners only returns once all the connections
+// being matched are, which never happens for an idle connection such as the
+// one of the Gateway when it did not send any request yet.
+type stoppableListener struct {
+	net.Listener
+	accepted chan acceptedConn
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func newStoppableListener(l net.Listener) *stoppableListener {
+	sl := &stoppableListener{
+		Listener: l,
+		accepted: make(chan acceptedConn),
+		closed:   make(chan struct{}),
+	}
+	go sl.accept()
+	return sl
+}
+
+func (l *stoppableListener) accept() {
+	for {
+		conn, err := l.Listener.Accept()
+		select {
+		case l.accepted <- acceptedConn{conn, err}:
+		case <-l.closed:
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+	}
+}
+
+func (l *stoppableListener) Accept() (net.Conn, error) {
+	select {
+	case a := <-l.accepted:
+		return a.conn, a.err
+	case <-l.closed:
+		return nil, cmux.ErrListenerClosed
+	}
+}
+
+func (l *stoppableListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return l.Listener.Close()
+}
+
+// pipeNetwork is the network of the connections of a pipeListener.
+const pipeNetwork = "pipe"
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return pipeNetwork }
+func (pipeAddr) String() string  { return pipeNetwork }
+
+// errPipeListenerClosed is returned when dialing or accepting on a closed
+// pipeListener.
+var errPipeListenerClosed = errors.New("pipe listener closed")
+
+// pipeListener is a net.Listener whose connections are in-memory pipes
+// opened by its Dial method.
+type pipeListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, errPipeListenerClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
 	return nil
 }
 
-func configureCA(tlsConfig *tls.Config, caPath string) error {
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+// Dial opens a connection to the listener, it can be used as a gRPC dialer.
+// The connection is accepted without delay as long as the listener is served,
+// so the timeout is ignored.
+func (l *pipeListener) Dial(addr string, timeout time.Duration) (net.Conn, error) {
+	server, client := net.Pipe()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.closed:
+		client.Close()
+		server.Close()
+		return nil, errPipeListenerClosed
+	}
+}
+
+func configureCA(tlsConfig *tls.Config, caPath string, clientAuth tls.ClientAuthType) error {
 	caCert, err := ioutil.ReadFile(caPath)
 	if err != nil {
 		return err
 	}
 
 	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM(caCert)
+	if !caCertPool.AppendCertsFromPEM(caCert) {
+		return fmt.Errorf("no PEM certificate found in %q", caPath)
+	}
 
 	tlsConfig.ClientCAs = caCertPool
-	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	tlsConfig.ClientAuth = clientAuth
+	if clientAuth == tls.NoClientCert {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
 
 	return nil
 }
@@ -108,31 +252,46 @@ func configureCertificate(tlsConfig *tls.Config, certFile, keyFile string) error
 
 // ListenAndServeTLS listens on the TCP network address srv.Addr and handles both
 // gRPC and JSON requests over HTTP over TLS. An optional HTTP middleware can
-// be provided to wrap the output of each request.
+// be provided to wrap the output of each request. The certificates of the
+// clients are verified against the CA found at caPath, unless it is empty.
 //
 // Internally, the same net.Listener is used because the http.Handler will
-// pivot based on whether the request is gRPC or HTTP.
+// pivot based on whether the request is gRPC or HTTP. The Gateway reaches the
+// gRPC server in memory, so that it does not need a client certificate.
 func (srv *MuxedGRPCServer) ListenAndServeTLS(certFile, keyFile, caPath string, mw httputil.Middleware) error {
 	if srv.TLSConfig == nil {
 		srv.TLSConfig = &tls.Config{}
 	}
-	configureCA(srv.TLSConfig, caPath)
-	configureCertificate(srv.TLSConfig, certFile, keyFile)
+	if caPath != "" {
+		if err := configureCA(srv.TLSConfig, caPath, srv.ClientAuth); err != nil {
+			return err
+		}
+	}
+	if err := configureCertificate(srv.TLSConfig, certFile, keyFile); err != nil {
+		return err
+	}
 
 	listener, err := tls.Listen("tcp", srv.Addr, srv.TLSConfig)
 	if err != nil {
 		return err
 	}
 
-	gwHandler, conn, err := NewGateway(listener.Addr().String(), srv.TLSConfig, srv.ServiceHandlerFuncs)
+	gwListener := newPipeListener()
+	defer gwListener.Close()
+
+	gwHandler, conn, err := NewGateway(gwListener.Addr().String(), nil, srv.ServiceHandlerFuncs, srv.GatewayOptions, grpc.WithDialer(gwListener.Dial))
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	gsrv := NewServer(srv.TLSConfig, srv.ServicesFunc)
+	// The TLS connections are handled by the HTTP server, which hands the
+	// gRPC requests and their client certificate over to the gRPC server.
+	gsrv := NewServer(nil, srv.ServicesFunc, srv.Interceptors)
 	defer gsrv.Stop()
 
+	go func() { gsrv.Serve(gwListener) }()
+
 	httpHandler := HandlerFunc(gsrv, gwHandler)
 	if mw != nil {
 		httpHandler = mw(httpHandler)
@@ -141,6 +300,28 @@ func (srv *MuxedGRPCServer) ListenAndServeTLS(certFile, keyFile, caPath string,
 	httpsrv := &http.Server{
 		Handler: httpHandler,
 	}
-	httpsrv.Serve(listener)
+	return srv.serve(httpsrv, gsrv, listener)
+}
+
+// serve serves HTTP requests on the listener until srv.Stop is closed, and
+// then waits for the in-flight HTTP and gRPC requests to complete.
+func (srv *MuxedGRPCServer) serve(httpsrv *http.Server, gsrv *grpc.Server, listener net.Listener) error {
+	drained := make(chan struct{})
+	if srv.Stop != nil {
+		go func() {
+			<-srv.Stop
+			// The Gateway requests go through the gRPC server, which is only
+			// stopped once they are done.
+			httpsrv.Shutdown(context.Background())
+			gsrv.GracefulStop()
+			close(drained)
+		}()
+	}
+
+	if err := httpsrv.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+
+	<-drained
 	return nil
 }
@@ -0,0 +1,117 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// writeTestCertificate writes a self-signed certificate for localhost and its
+// key in dir, and returns their paths.
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	der, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.Nil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600))
+	require.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+	return certFile, keyFile
+}
+
+func TestListenAndServeTLSWithoutCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grpcutil")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeTestCertificate(t, dir)
+
+	// Reserve a port for the server.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	srv := MuxedGRPCServer{
+		Addr: addr,
+		TLSConfig: &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		},
+		ServicesFunc: func(*grpc.Server) {},
+		Stop:         stop,
+	}
+	go func() { done <- srv.ListenAndServeTLS(certFile, keyFile, "", nil) }()
+	defer func() {
+		close(stop)
+		assert.Nil(t, <-done)
+	}()
+
+	dial := func(config *tls.Config) error {
+		config.InsecureSkipVerify = true
+		conn, err := tls.Dial("tcp", addr, config)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	// The server is served over TLS without requesting client certificates.
+	var dialErr error
+	for attempt := 0; attempt < 50; attempt++ {
+		if dialErr = dial(&tls.Config{MaxVersion: tls.VersionTLS12}); dialErr == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	require.Nil(t, dialErr)
+
+	// The minimum version and the cipher suites are enforced.
+	assert.NotNil(t, dial(&tls.Config{MaxVersion: tls.VersionTLS11}))
+	assert.NotNil(t, dial(&tls.Config{MaxVersion: tls.VersionTLS12, CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}}))
+	assert.Nil(t, dial(&tls.Config{MaxVersion: tls.VersionTLS12, CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}}))
+}
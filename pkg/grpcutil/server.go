@@ -16,23 +16,65 @@ package grpcutil
 
 import (
 	"crypto/tls"
+	"net"
+	"strings"
 
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/coreos/clair/pkg/logutil"
 )
 
+// RequestIDHeader is the metadata key holding the ID of a request, which is
+// generated when the client does not provide it and sent back in the response
+// headers.
+const RequestIDHeader = "x-request-id"
+
+// ClientIdentityHeader is the metadata key holding the identity of the client
+// certificate of the requests forwarded by the Gateway. It is only trusted on
+// the in-memory connection of the Gateway.
+const ClientIdentityHeader = "x-client-identity"
+
+// forwardedForHeader is the metadata key holding the addresses of the clients
+// of the requests forwarded by the Gateway.
+const forwardedForHeader = "x-forwarded-for"
+
+// ErrorCodeTrailer is the metadata key holding the machine-readable code of
+// the CodedError returned by a service, which is sent in the response trailers.
+const ErrorCodeTrailer = "x-error-code"
+
+// CodedError is an error that a service reports to its clients as a gRPC
+// status along with a stable machine-readable code.
+type CodedError interface {
+	error
+	ErrorCode() string
+	GRPCStatus() *status.Status
+}
+
 // RegisterServicesFunc is a function that registers gRPC services with a given
 // server.
 type RegisterServicesFunc func(*grpc.Server)
 
+// Interceptors are the optional interceptors of the requests of a server,
+// which are run once the context of the requests carries their ID and the
+// identity of their client.
+type Interceptors struct {
+	Unary  grpc.UnaryServerInterceptor
+	Stream grpc.StreamServerInterceptor
+}
+
 // NewServer allocates a new grpc.Server and handles some some boilerplate
 // configuration.
-func NewServer(tlsConfig *tls.Config, fn RegisterServicesFunc) *grpc.Server {
+func NewServer(tlsConfig *tls.Config, fn RegisterServicesFunc, interceptors Interceptors) *grpc.Server {
 	// Default ServerOptions
 	grpcOpts := []grpc.ServerOption{
-		grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
-		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
+		grpc.UnaryInterceptor(unaryServerInterceptor(interceptors.Unary)),
+		grpc.StreamInterceptor(streamServerInterceptor(interceptors.Stream)),
 	}
 
 	if tlsConfig != nil {
@@ -44,3 +86,139 @@ func NewServer(tlsConfig *tls.Config, fn RegisterServicesFunc) *grpc.Server {
 	fn(gsrv)
 	return gsrv
 }
+
+// requestContext returns a copy of ctx whose logger adds the ID of the request
+// and the identity of the client, if any, to its entries, and the ID itself.
+func requestContext(ctx context.Context) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var id string
+	if len(md[RequestIDHeader]) > 0 {
+		id = md[RequestIDHeader][0]
+	} else {
+		id = logutil.NewRequestID()
+	}
+	ctx = logutil.WithRequestID(ctx, id)
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if identity, ok := ClientIdentity(&tlsInfo.State); ok {
+				ctx = logutil.WithClientIdentity(ctx, identity)
+			}
+		} else if p.Addr != nil && p.Addr.Network() == pipeNetwork && len(md[ClientIdentityHeader]) > 0 {
+			ctx = logutil.WithClientIdentity(ctx, md[ClientIdentityHeader][0])
+		}
+	}
+
+	return ctx, id
+}
+
+// ClientAddress returns the IP address of the client of a request. The address
+// of the clients of the Gateway is the one that it forwarded, which is only
+// trusted on its in-memory connection or on a loopback one.
+func ClientAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	var ip net.IP
+	if p.Addr.Network() != pipeNetwork {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return p.Addr.String()
+		}
+		if ip = net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return host
+		}
+	}
+
+	// The Gateway appends the address of its client to the forwarded ones.
+	md, _ := metadata.FromIncomingContext(ctx)
+	if forwarded := md[forwardedForHeader]; len(forwarded) > 0 {
+		addrs := strings.Split(forwarded[len(forwarded)-1], ",")
+		return strings.TrimSpace(addrs[len(addrs)-1])
+	}
+
+	if ip != nil {
+		return ip.String()
+	}
+	return p.Addr.String()
+}
+
+// ClientIdentity returns the identity of the verified client certificate of a
+// TLS connection: its common name, or its first DNS name, email address or URI
+// when it has none.
+func ClientIdentity(state *tls.ConnectionState) (string, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+
+	cert := state.VerifiedChains[0][0]
+	switch {
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName, true
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0], true
+	case len(cert.EmailAddresses) > 0:
+		return cert.EmailAddresses[0], true
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String(), true
+	}
+	return "", false
+}
+
+// statusError converts a CodedError to its gRPC status, whose code is set in
+// the trailers with setTrailer.
+func statusError(err error, setTrailer func(metadata.MD) error) error {
+	if codedErr, ok := err.(CodedError); ok {
+		setTrailer(metadata.Pairs(ErrorCodeTrailer, codedErr.ErrorCode()))
+		return codedErr.GRPCStatus().Err()
+	}
+	return err
+}
+
+func unaryServerInterceptor(next grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, id := requestContext(ctx)
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id))
+		return grpc_prometheus.UnaryServerInterceptor(ctx, req, info, func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+			if next != nil {
+				resp, err = next(ctx, req, info, handler)
+			} else {
+				resp, err = handler(ctx, req)
+			}
+			return resp, statusError(err, func(md metadata.MD) error { return grpc.SetTrailer(ctx, md) })
+		})
+	}
+}
+
+// requestStream is a grpc.ServerStream whose context carries the logger of
+// its request.
+type requestStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestStream) Context() context.Context {
+	return s.ctx
+}
+
+func streamServerInterceptor(next grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, id := requestContext(ss.Context())
+		ss.SetHeader(metadata.Pairs(RequestIDHeader, id))
+		return grpc_prometheus.StreamServerInterceptor(srv, &requestStream{ss, ctx}, info, func(srv interface{}, ss grpc.ServerStream) error {
+			var err error
+			if next != nil {
+				err = next(srv, ss, info, handler)
+			} else {
+				err = handler(srv, ss)
+			}
+			return statusError(err, func(md metadata.MD) error {
+				ss.SetTrailer(md)
+				return nil
+			})
+		})
+	}
+}
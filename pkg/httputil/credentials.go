@@ -0,0 +1,190 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputil
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Credentials authenticate the requests made to the feeds of a data source.
+//
+// The secrets are read from files or environment variables, and never from
+// the configuration itself, whenever a request is made so that they can be
+// rotated without restarting Clair.
+type Credentials struct {
+	// Username and the password read from PasswordFile, or from the
+	// environment variable named by PasswordEnv, authenticate the requests
+	// with basic authentication.
+	Username     string
+	PasswordFile string
+	PasswordEnv  string
+
+	// The token read from TokenFile, or from the environment variable named by
+	// TokenEnv, authenticates the requests as a bearer token.
+	TokenFile string
+	TokenEnv  string
+
+	// CertFile and KeyFile are the PEM client certificate and key presented to
+	// the feeds served over TLS.
+	CertFile string
+	KeyFile  string
+}
+
+// Validate ensures that the credentials use a single kind of authorization
+// header and that their secrets can be read.
+func (c Credentials) Validate() error {
+	basic := c.Username != "" || c.PasswordFile != "" || c.PasswordEnv != ""
+	bearer := c.TokenFile != "" || c.TokenEnv != ""
+
+	switch {
+	case basic && bearer:
+		return errors.New("basic authentication and token are mutually exclusive")
+	case basic && c.Username == "":
+		return errors.New("username is empty")
+	case c.PasswordFile != "" && c.PasswordEnv != "":
+		return errors.New("passwordfile and passwordenv are mutually exclusive")
+	case c.TokenFile != "" && c.TokenEnv != "":
+		return errors.New("tokenfile and tokenenv are mutually exclusive")
+	case (c.CertFile == "") != (c.KeyFile == ""):
+		return errors.New("certfile and keyfile must be set together")
+	}
+
+	if _, _, err := c.authorization(); err != nil {
+		return err
+	}
+
+	if c.CertFile != "" {
+		if _, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile); err != nil {
+			return fmt.Errorf("could not load client certificate: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// authorization reads the secret of the credentials and returns whether it is
+// a basic authentication password or a bearer token.
+func (c Credentials) authorization() (secret string, basic bool, err error) {
+	switch {
+	case c.Username != "":
+		secret, err = readSecret(c.PasswordFile, c.PasswordEnv)
+		return secret, true, err
+	case c.TokenFile != "" || c.TokenEnv != "":
+		secret, err = readSecret(c.TokenFile, c.TokenEnv)
+		return secret, false, err
+	}
+	return "", false, nil
+}
+
+// authorize sets the Authorization header of a request, if the credentials
+// have a password or a token.
+func (c Credentials) authorize(req *http.Request) error {
+	secret, basic, err := c.authorization()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case basic:
+		req.SetBasicAuth(c.Username, secret)
+	case secret != "":
+		req.Header.Set("Authorization", "Bearer "+secret)
+	}
+	return nil
+}
+
+// Authorization returns the value of the Authorization header that
+// authenticates to the feeds of the data source, or an empty string if it has
+// no password or token, for the feeds that are not fetched by the HTTP client,
+// such as the git repositories.
+func (s Source) Authorization() (string, error) {
+	_, credentials := s.client()
+
+	req := &http.Request{Header: make(http.Header)}
+	if err := credentials.authorize(req); err != nil {
+		return "", fmt.Errorf("could not authenticate to %s: %s", string(s), err)
+	}
+	return req.Header.Get("Authorization"), nil
+}
+
+// readSecret reads a secret from a file, trimming its surrounding whitespace,
+// or from the environment variable with the given name.
+func readSecret(path, env string) (string, error) {
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("could not read secret: %s", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	if env != "" {
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", env)
+		}
+		return v, nil
+	}
+
+	return "", nil
+}
+
+// clientCertificate loads the client certificate of the credentials for every
+// TLS handshake, so that it can be renewed while Clair runs.
+func (c Credentials) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load client certificate: %s", err)
+	}
+	return &cert, nil
+}
+
+// sourceClient is the HTTP client of a data source with credentials.
+type sourceClient struct {
+	credentials Credentials
+	client      *http.Client
+}
+
+// sources are the clients of the data sources with credentials, by name.
+var sources = map[string]sourceClient{}
+
+// Source is the name of a data source, whose feeds are fetched with the
+// credentials configured for it, if any. The names are case-insensitive.
+//
+// The package-level functions, such as GetWithUserAgent, fetch the feeds
+// anonymously.
+type Source string
+
+func (s Source) client() (*http.Client, Credentials) {
+	if sc, ok := sources[strings.ToLower(string(s))]; ok {
+		return sc.client, sc.credentials
+	}
+	return client, Credentials{}
+}
+
+// do sends a request with the credentials of the data source.
+func (s Source) do(req *http.Request) (*http.Response, error) {
+	c, credentials := s.client()
+	if err := credentials.authorize(req); err != nil {
+		return nil, fmt.Errorf("could not authenticate to %s: %s", string(s), err)
+	}
+	return c.Do(req)
+}
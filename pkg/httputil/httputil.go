@@ -16,28 +16,200 @@
 package httputil
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/coreos/clair/pkg/version"
 )
 
+// ErrChecksumMismatch is returned by Download when the downloaded content does
+// not match its checksum.
+var ErrChecksumMismatch = errors.New("httputil: checksum mismatch")
+
+var (
+	// client is the HTTP client used by GetWithUserAgent and Download, and
+	// by the data sources without credentials.
+	client = &http.Client{}
+
+	// downloadAttempts is the number of attempts of Download.
+	downloadAttempts = 1
+
+	// downloadBackoff is the delay before the second attempt of Download,
+	// which doubles for each following attempt up to maxDownloadBackoff.
+	downloadBackoff    = time.Second
+	maxDownloadBackoff = 30 * time.Second
+)
+
 // Middleware is a function used to wrap the logic of another http.Handler.
 type Middleware func(http.Handler) http.Handler
 
-// GetWithUserAgent performs an HTTP GET with the proper Clair User-Agent.
-func GetWithUserAgent(url string) (*http.Response, error) {
-	client := &http.Client{}
+// ClientConfig is the configuration of the HTTP client used by
+// GetWithUserAgent to fetch the data sources.
+type ClientConfig struct {
+	// Timeout limits the duration of a request, including the reading of its
+	// body. There is no limit if it is 0.
+	Timeout time.Duration
+
+	// Proxy is the URL of the proxy used for all the requests. When it is
+	// empty, the proxy is given by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables.
+	Proxy string
+
+	// CAFile is an optional bundle of PEM certificates of authorities trusted
+	// in addition to the ones of the system.
+	CAFile string
+
+	// Attempts is the number of attempts of the downloads made with Download,
+	// each attempt resuming the previous one when possible. There is a single
+	// attempt if it is not positive.
+	Attempts int
+
+	// Credentials are the credentials of the data sources whose feeds require
+	// authentication, by name of data source (see Source).
+	Credentials map[string]Credentials
+
+	// Mirrors are the base URLs of the mirrors from which the feeds of the data
+	// sources are fetched instead of their upstream, by name of data source
+	// (see Source.URL).
+	Mirrors map[string]string
+}
+
+// ConfigureClient sets up the HTTP client used by GetWithUserAgent, and the
+// clients and mirrors of the data sources.
+func ConfigureClient(cfg ClientConfig) error {
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return err
+	}
+
+	configuredMirrors := make(map[string]*url.URL, len(cfg.Mirrors))
+	for name, base := range cfg.Mirrors {
+		m, err := ParseMirror(base)
+		if err != nil {
+			return fmt.Errorf("invalid mirror of %s: %s", name, err)
+		}
+		configuredMirrors[strings.ToLower(name)] = m
+	}
+
+	configured := make(map[string]sourceClient, len(cfg.Credentials))
+	for name, credentials := range cfg.Credentials {
+		if err := credentials.Validate(); err != nil {
+			return fmt.Errorf("invalid credentials of %s: %s", name, err)
+		}
+
+		sourceTransport := transport
+		if credentials.CertFile != "" {
+			if sourceTransport, err = newTransport(cfg); err != nil {
+				return err
+			}
+
+			if sourceTransport.TLSClientConfig == nil {
+				sourceTransport.TLSClientConfig = &tls.Config{}
+			}
+			sourceTransport.TLSClientConfig.GetClientCertificate = credentials.clientCertificate
+		}
+
+		configured[strings.ToLower(name)] = sourceClient{
+			credentials: credentials,
+			client:      &http.Client{Transport: sourceTransport, Timeout: cfg.Timeout},
+		}
+	}
+
+	client = &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+	}
+	sources = configured
+	mirrors = configuredMirrors
+
+	downloadAttempts = cfg.Attempts
+	if downloadAttempts < 1 {
+		downloadAttempts = 1
+	}
 
+	return nil
+}
+
+// newTransport returns a transport using the proxy and the CA bundle of the
+// configuration.
+func newTransport(cfg ClientConfig) (*http.Transport, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.ParseRequestURI(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse proxy URL: %s", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CAFile != "" {
+		caCert, err := ioutil.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA bundle: %s", err)
+		}
+
+		caCertPool, err := x509.SystemCertPool()
+		if err != nil {
+			caCertPool = x509.NewCertPool()
+		}
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("could not read CA bundle: no PEM certificate found")
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: caCertPool}
+	}
+
+	return transport, nil
+}
+
+func newGetRequest(url string) (*http.Request, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("User-Agent", "Clair/"+version.Version+" (https://github.com/coreos/clair)")
+	return req, nil
+}
+
+// GetWithUserAgent performs an HTTP GET with the proper Clair User-Agent.
+func GetWithUserAgent(url string) (*http.Response, error) {
+	return Source("").GetWithUserAgent(url)
+}
+
+// GetWithUserAgent performs an HTTP GET with the proper Clair User-Agent and
+// the credentials of the data source, on its mirror if it has one.
+func (s Source) GetWithUserAgent(url string) (*http.Response, error) {
+	req, err := newGetRequest(s.URL(url))
+	if err != nil {
+		return nil, err
+	}
 
-	resp, err := client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -45,6 +217,189 @@ func GetWithUserAgent(url string) (*http.Response, error) {
 	return resp, nil
 }
 
+// Checksum verifies the content of a download, returning ErrChecksumMismatch
+// if it does not match.
+type Checksum func(r io.Reader) error
+
+// SHA256Checksum returns a Checksum verifying that the SHA-256 digest of the
+// content is the given hexadecimal digest.
+func SHA256Checksum(digest string) Checksum {
+	return func(r io.Reader) error {
+		sha := sha256.New()
+		if _, err := io.Copy(sha, r); err != nil {
+			return err
+		}
+
+		if !strings.EqualFold(hex.EncodeToString(sha.Sum(nil)), digest) {
+			return ErrChecksumMismatch
+		}
+		return nil
+	}
+}
+
+// FetchSHA256 fetches a companion checksum file, in the format of sha256sum,
+// and returns the first hexadecimal digest it contains.
+func FetchSHA256(url string) (string, error) {
+	return Source("").FetchSHA256(url)
+}
+
+// FetchSHA256 is FetchSHA256 with the credentials of the data source.
+func (s Source) FetchSHA256(url string) (string, error) {
+	r, err := s.GetWithUserAgent(url)
+	if err != nil {
+		return "", err
+	}
+	defer r.Body.Close()
+
+	if !Status2xx(r) {
+		return "", fmt.Errorf("got status %d fetching %s", r.StatusCode, url)
+	}
+
+	scanner := bufio.NewScanner(io.LimitReader(r.Body, 1<<20))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 && len(fields[0]) == sha256.Size*2 {
+			if _, err := hex.DecodeString(fields[0]); err == nil {
+				return fields[0], nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("no SHA-256 digest found in %s", url)
+}
+
+// Download fetches the content at url into f, which is positioned at its start
+// once the download succeeds.
+//
+// The transfer is attempted up to the configured number of attempts, with an
+// exponential backoff between them. An attempt failing partway is resumed by
+// the next one with a Range request, unless the server does not support it.
+// The errors that are not transient, such as a 404 status, are not retried.
+//
+// The downloaded content is verified by the checksum, if any. A content that
+// does not match it is fetched again from the start, within the same attempts.
+func Download(url string, f *os.File, checksum Checksum) error {
+	return Source("").Download(url, f, checksum)
+}
+
+// Download is Download with the credentials of the data source, from its
+// mirror if it has one.
+func (s Source) Download(url string, f *os.File, checksum Checksum) error {
+	var err error
+	for attempt := 0; attempt < downloadAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(downloadDelay(attempt))
+		}
+
+		var retry bool
+		if retry, err = s.download(url, f); err != nil {
+			if !retry {
+				return err
+			}
+			continue
+		}
+
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		if checksum == nil {
+			return nil
+		}
+
+		if err = checksum(f); err == nil {
+			_, err = f.Seek(0, io.SeekStart)
+			return err
+		} else if err != ErrChecksumMismatch {
+			return err
+		}
+
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+// downloadDelay returns the delay before the given attempt of a download.
+func downloadDelay(attempt int) time.Duration {
+	delay := downloadBackoff
+	for i := 1; i < attempt && delay < maxDownloadBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxDownloadBackoff {
+		return maxDownloadBackoff
+	}
+	return delay
+}
+
+// download makes an attempt to download the content at url, appending to what
+// f already has, and returns whether a failure is transient.
+func (s Source) download(url string, f *os.File) (bool, error) {
+	url = s.URL(url)
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := newGetRequest(url)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		// The content must resume where the previous attempt stopped.
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			return true, truncate(f, fmt.Errorf("unexpected content range %q fetching %s", resp.Header.Get("Content-Range"), url))
+		}
+	case resp.StatusCode == http.StatusOK:
+		// The server does not support Range requests: start over.
+		if err := truncate(f, nil); err != nil {
+			return false, err
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		return true, truncate(f, fmt.Errorf("got status %d fetching %s", resp.StatusCode, url))
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("got status %d fetching %s", resp.StatusCode, url)
+	default:
+		return false, fmt.Errorf("got status %d fetching %s", resp.StatusCode, url)
+	}
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return true, err
+	}
+
+	return false, nil
+}
+
+// truncate empties f, returning err unless f could not be emptied.
+func truncate(f *os.File, err error) error {
+	if terr := f.Truncate(0); terr != nil {
+		return terr
+	}
+
+	if _, serr := f.Seek(0, io.SeekStart); serr != nil {
+		return serr
+	}
+
+	return err
+}
+
 // GetClientAddr returns the first value in X-Forwarded-For if it exists
 // otherwise fall back to use RemoteAddr
 func GetClientAddr(r *http.Request) string {
@@ -0,0 +1,248 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureClient(t *testing.T) {
+	defer func(c *http.Client) { client = c }(client)
+
+	assert.NotNil(t, ConfigureClient(ClientConfig{Proxy: "not a URL"}))
+	assert.NotNil(t, ConfigureClient(ClientConfig{CAFile: "/not/a/file"}))
+
+	hang := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.UserAgent(), "Clair/")
+		if r.URL.Path == "/hang" {
+			<-hang
+		}
+	}))
+	defer server.Close()
+	defer close(hang)
+
+	if assert.Nil(t, ConfigureClient(ClientConfig{Timeout: 100 * time.Millisecond})) {
+		resp, err := GetWithUserAgent(server.URL)
+		if assert.Nil(t, err) {
+			assert.True(t, Status2xx(resp))
+			resp.Body.Close()
+		}
+
+		_, err = GetWithUserAgent(server.URL + "/hang")
+		assert.NotNil(t, err)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	defer func(c *http.Client, attempts int, backoff time.Duration) {
+		client, downloadAttempts, downloadBackoff = c, attempts, backoff
+	}(client, downloadAttempts, downloadBackoff)
+	require.Nil(t, ConfigureClient(ClientConfig{Attempts: 3}))
+	downloadBackoff = time.Millisecond
+
+	content := []byte("the content of a large data source")
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("Range"))
+		switch r.URL.Path {
+		case "/resume":
+			if r.Header.Get("Range") == "" {
+				// Fail partway through the first attempt.
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				w.Write(content[:10])
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 10-%d/%d", len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content[10:])
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+		}
+	}))
+	defer server.Close()
+
+	download := func(path string, checksum Checksum) ([]byte, error) {
+		f, err := ioutil.TempFile("", "download")
+		require.Nil(t, err)
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		requests = nil
+		if err := Download(server.URL+path, f, checksum); err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(f)
+	}
+
+	// An interrupted download is resumed.
+	d, err := download("/resume", SHA256Checksum(fmt.Sprintf("%x", sha256.Sum256(content))))
+	if assert.Nil(t, err) {
+		assert.Equal(t, content, d)
+		assert.Equal(t, []string{"", "bytes=10-"}, requests)
+	}
+
+	// A content that does not match its checksum is fetched again.
+	_, err = download("/", SHA256Checksum(strings.Repeat("0", 64)))
+	assert.Equal(t, ErrChecksumMismatch, err)
+	assert.Equal(t, []string{"", "", ""}, requests)
+
+	// Transient errors are retried, unlike the others.
+	_, err = download("/unavailable", nil)
+	assert.NotNil(t, err)
+	assert.Len(t, requests, 3)
+
+	d, err = download("/", nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, content, d)
+	}
+}
+
+func TestFetchSHA256(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "%s  suse.linux.enterprise.server.15.xml.gz\n", digest)
+	}))
+	defer server.Close()
+
+	d, err := FetchSHA256(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, digest, d)
+
+	_, err = FetchSHA256(server.URL + "/missing")
+	assert.NotNil(t, err)
+}
+
+func TestSourceCredentials(t *testing.T) {
+	defer func(c *http.Client, s map[string]sourceClient) { client, sources = c, s }(client, sources)
+
+	dir, err := ioutil.TempDir("", "credentials")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	passwordFile := dir + "/password"
+	require.Nil(t, ioutil.WriteFile(passwordFile, []byte("secret\n"), 0600))
+	os.Setenv("CLAIR_TEST_SOURCE_TOKEN", "token")
+	defer os.Unsetenv("CLAIR_TEST_SOURCE_TOKEN")
+
+	// The credentials must be of a single kind and readable.
+	for _, invalid := range []Credentials{
+		{Username: "user", TokenEnv: "CLAIR_TEST_SOURCE_TOKEN"},
+		{PasswordFile: passwordFile},
+		{Username: "user", PasswordFile: dir + "/missing"},
+		{TokenEnv: "CLAIR_TEST_UNSET_TOKEN"},
+		{CertFile: dir + "/cert.pem"},
+	} {
+		assert.NotNil(t, invalid.Validate(), "%+v", invalid)
+		assert.NotNil(t, ConfigureClient(ClientConfig{Credentials: map[string]Credentials{"vendor": invalid}}))
+	}
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	require.Nil(t, ConfigureClient(ClientConfig{Credentials: map[string]Credentials{
+		"Vendor": {Username: "user", PasswordFile: passwordFile},
+		"token":  {TokenEnv: "CLAIR_TEST_SOURCE_TOKEN"},
+	}}))
+
+	get := func(s Source) string {
+		authorization = ""
+		resp, err := s.GetWithUserAgent(server.URL)
+		require.Nil(t, err)
+		resp.Body.Close()
+		return authorization
+	}
+
+	assert.Equal(t, "Basic dXNlcjpzZWNyZXQ=", get("vendor"))
+	assert.Equal(t, "Bearer token", get("token"))
+	assert.Empty(t, get("other"))
+	assert.Empty(t, get(""))
+
+	// The secrets are read again for every request.
+	require.Nil(t, ioutil.WriteFile(passwordFile, []byte("rotated"), 0600))
+	assert.Equal(t, "Basic dXNlcjpyb3RhdGVk", get("vendor"))
+
+	// The authorization is also available to the feeds fetched otherwise.
+	for s, expected := range map[Source]string{"vendor": "Basic dXNlcjpyb3RhdGVk", "token": "Bearer token", "other": ""} {
+		authorization, err := s.Authorization()
+		if assert.Nil(t, err) {
+			assert.Equal(t, expected, authorization)
+		}
+	}
+}
+
+func TestSourceMirror(t *testing.T) {
+	defer func(c *http.Client, s map[string]sourceClient, m map[string]*url.URL) {
+		client, sources, mirrors = c, s, m
+	}(client, sources, mirrors)
+
+	for _, invalid := range []string{"mirror.example.com/debian", "ftp://mirror.example.com", "https://mirror.example.com/?feed=debian", "%"} {
+		_, err := ParseMirror(invalid)
+		assert.NotNil(t, err, invalid)
+		assert.NotNil(t, ConfigureClient(ClientConfig{Mirrors: map[string]string{"debian": invalid}}), invalid)
+	}
+
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.RequestURI()
+	}))
+	defer server.Close()
+
+	require.Nil(t, ConfigureClient(ClientConfig{Mirrors: map[string]string{
+		"Debian": server.URL + "/feeds/debian/",
+		"rhel":   server.URL,
+	}}))
+
+	assert.Equal(t, server.URL+"/feeds/debian/tracker/data/json", Source("debian").URL("https://security-tracker.debian.org/tracker/data/json"))
+	assert.Equal(t, server.URL+"/security/data/oval/?page=2", Source("rhel").URL("https://www.redhat.com/security/data/oval/?page=2"))
+	assert.Equal(t, "https://ftp.suse.com/pub/", Source("suse").URL("https://ftp.suse.com/pub/"))
+	assert.Equal(t, "https://ftp.suse.com/pub/", Source("").URL("https://ftp.suse.com/pub/"))
+
+	resp, err := Source("debian").GetWithUserAgent("https://security-tracker.debian.org/tracker/data/json")
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "/feeds/debian/tracker/data/json", requested)
+
+	f, err := ioutil.TempFile("", "mirror")
+	require.Nil(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	require.Nil(t, Source("rhel").Download("https://www.redhat.com/security/data/oval/com.redhat.rhsa-RHEL8.xml", f, nil))
+	assert.Equal(t, "/security/data/oval/com.redhat.rhsa-RHEL8.xml", requested)
+}
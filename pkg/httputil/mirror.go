@@ -0,0 +1,67 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputil
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// mirrors are the base URLs of the mirrors of the data sources, by name.
+var mirrors = map[string]*url.URL{}
+
+// ParseMirror parses the base URL of the mirror of a data source, which must be
+// an absolute HTTP or HTTPS URL, possibly with a path.
+func ParseMirror(base string) (*url.URL, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, errors.New("mirror must be an absolute HTTP or HTTPS URL")
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, errors.New("mirror must not have a query or a fragment")
+	}
+
+	return u, nil
+}
+
+// URL returns the URL of a feed of the data source on its mirror, if one is
+// configured, and rawURL otherwise.
+//
+// The scheme and host of rawURL are replaced by the base URL of the mirror, to
+// which its path and query are appended, so that the mirror only has to
+// replicate the layout of the upstream feeds.
+func (s Source) URL(rawURL string) string {
+	m, ok := mirrors[strings.ToLower(string(s))]
+	if !ok {
+		return rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	mirrored := *m
+	mirrored.Path = strings.TrimSuffix(m.Path, "/") + u.Path
+	mirrored.RawPath = ""
+	mirrored.RawQuery = u.RawQuery
+	return mirrored.String()
+}
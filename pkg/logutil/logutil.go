@@ -0,0 +1,106 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logutil implements helpers to configure the logger and to carry a
+// request scoped logger in a context.
+package logutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/pkg/formatter"
+)
+
+// RequestIDField is the field of the entries logged while handling a request
+// that holds the ID of the request.
+const RequestIDField = "request id"
+
+// ClientIdentityField is the field of the entries logged while handling a
+// request that holds the identity of the client certificate of the request.
+const ClientIdentityField = "client identity"
+
+type contextKey struct{}
+
+// Configure sets the format of the standard logger, either "text" or "json",
+// and its level.
+func Configure(format, level string) error {
+	logLevel, err := log.ParseLevel(strings.ToUpper(level))
+	if err != nil {
+		return err
+	}
+
+	var f log.Formatter
+	switch strings.ToLower(format) {
+	case "json":
+		f = &formatter.JSONExtendedFormatter{ShowLn: true}
+	case "text":
+		f = &log.TextFormatter{FullTimestamp: true}
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	log.SetLevel(logLevel)
+	log.SetOutput(os.Stdout)
+	log.SetFormatter(f)
+	return nil
+}
+
+// NewRequestID returns a random ID for a request.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		log.WithError(err).Warning("could not generate a random request id")
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns a copy of ctx whose logger adds the given request ID
+// to its entries.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, FromContext(ctx).WithField(RequestIDField, id))
+}
+
+// RequestID returns the request ID of the logger of ctx, if any.
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := FromContext(ctx).Data[RequestIDField].(string)
+	return id, ok
+}
+
+// WithClientIdentity returns a copy of ctx whose logger adds the given client
+// identity to its entries.
+func WithClientIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, contextKey{}, FromContext(ctx).WithField(ClientIdentityField, identity))
+}
+
+// ClientIdentity returns the client identity of the logger of ctx, if any.
+func ClientIdentity(ctx context.Context) (string, bool) {
+	identity, ok := FromContext(ctx).Data[ClientIdentityField].(string)
+	return identity, ok
+}
+
+// FromContext returns the logger of ctx, or the standard logger if ctx has
+// none.
+func FromContext(ctx context.Context) *log.Entry {
+	if entry, ok := ctx.Value(contextKey{}).(*log.Entry); ok {
+		return entry
+	}
+	return log.NewEntry(log.StandardLogger())
+}
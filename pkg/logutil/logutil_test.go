@@ -0,0 +1,64 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logutil
+
+import (
+	"context"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigure(t *testing.T) {
+	defer Configure("json", "info")
+
+	if assert.Nil(t, Configure("text", "debug")) {
+		assert.Equal(t, log.DebugLevel, log.GetLevel())
+		assert.IsType(t, &log.TextFormatter{}, log.StandardLogger().Formatter)
+	}
+
+	assert.NotNil(t, Configure("xml", "info"))
+	assert.NotNil(t, Configure("json", "verbose"))
+}
+
+func TestRequestID(t *testing.T) {
+	ctx := context.Background()
+	_, ok := RequestID(ctx)
+	assert.False(t, ok)
+	assert.Empty(t, FromContext(ctx).Data)
+
+	id := NewRequestID()
+	assert.Len(t, id, 16)
+	assert.NotEqual(t, id, NewRequestID())
+
+	ctx = WithRequestID(ctx, id)
+	if requestID, ok := RequestID(ctx); assert.True(t, ok) {
+		assert.Equal(t, id, requestID)
+	}
+	assert.Equal(t, log.Fields{RequestIDField: id}, FromContext(ctx).Data)
+}
+
+func TestClientIdentity(t *testing.T) {
+	ctx := context.Background()
+	_, ok := ClientIdentity(ctx)
+	assert.False(t, ok)
+
+	ctx = WithClientIdentity(WithRequestID(ctx, "id"), "client.example.com")
+	if identity, ok := ClientIdentity(ctx); assert.True(t, ok) {
+		assert.Equal(t, "client.example.com", identity)
+	}
+	assert.Equal(t, log.Fields{RequestIDField: "id", ClientIdentityField: "client.example.com"}, FromContext(ctx).Data)
+}
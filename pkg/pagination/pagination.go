@@ -18,24 +18,74 @@ package pagination
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/fernet/fernet-go"
+
+	"github.com/coreos/clair/pkg/commonerr"
 )
 
 // ErrInvalidToken is returned when a token fails to Unmarshal because it was
 // invalid or expired.
 var ErrInvalidToken = errors.New("invalid or expired pagination token")
 
+// ErrExpiredToken is returned when a valid token fails to Unmarshal because it
+// was signed longer than its time to live ago, so that the listing has to be
+// restarted from the first page.
+var ErrExpiredToken = commonerr.NewBadRequestError("pagination token expired, restart listing")
+
+// ErrFutureToken is returned when a valid token fails to Unmarshal because it
+// was signed later than now, beyond the tolerated clock skew.
+var ErrFutureToken = commonerr.NewBadRequestError("pagination token signed in the future, check the clocks of the hosts")
+
+// The lifetime of the tokens when it is not set.
+const (
+	DefaultTokenTTL  = time.Hour
+	DefaultClockSkew = time.Minute
+)
+
+// lifetime is the time to live of the tokens, and the tolerated difference
+// between the clocks of the hosts signing and verifying them.
+var lifetime = struct {
+	sync.RWMutex
+	ttl, clockSkew time.Duration
+}{ttl: DefaultTokenTTL, clockSkew: DefaultClockSkew}
+
+// SetTokenLifetime sets the time to live of the tokens, and the clock skew
+// tolerated between the hosts signing and verifying them, replacing the
+// defaults when they are set.
+func SetTokenLifetime(ttl, clockSkew time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	if clockSkew <= 0 {
+		clockSkew = DefaultClockSkew
+	}
+
+	lifetime.Lock()
+	lifetime.ttl, lifetime.clockSkew = ttl, clockSkew
+	lifetime.Unlock()
+}
+
 // ErrInvalidKeyString is returned when the string representing a key is malformed.
 var ErrInvalidKeyString = errors.New("invalid pagination key string: must be 32-byte URL-safe base64")
 
 // Key represents the key used to cryptographically secure the token
 // being used to keep track of pages.
+//
+// A Key may also hold previous keys, which are only used to verify the tokens
+// that they signed, so that the key can be rotated without invalidating the
+// tokens given to the clients.
 type Key struct {
 	fkey *fernet.Key
+
+	// verifyKeys are the keys accepted to verify a token, starting with fkey.
+	verifyKeys []*fernet.Key
 }
 
 // Token represents an opaque pagination token keeping track of a user's
@@ -48,6 +98,7 @@ var FirstPageToken = Token("")
 // NewKey generates a new random pagination key.
 func NewKey() (k Key, err error) {
 	k.fkey = new(fernet.Key)
+	k.verifyKeys = []*fernet.Key{k.fkey}
 	err = k.fkey.Generate()
 	return k, err
 }
@@ -61,7 +112,28 @@ func KeyFromString(keyString string) (k Key, err error) {
 	if err != nil {
 		return Key{}, ErrInvalidKeyString
 	}
-	return Key{fkey}, err
+	return Key{fkey, []*fernet.Key{fkey}}, err
+}
+
+// KeyFromStrings creates the key for the given strings, the first of which
+// signs the tokens while all of them verify the tokens.
+//
+// Strings must be 32-byte URL-safe base64 representations of the key bytes.
+func KeyFromStrings(keyStrings []string) (k Key, err error) {
+	if len(keyStrings) == 0 {
+		return Key{}, ErrInvalidKeyString
+	}
+
+	for _, keyString := range keyStrings {
+		fkey, err := fernet.DecodeKey(keyString)
+		if err != nil {
+			return Key{}, ErrInvalidKeyString
+		}
+		k.verifyKeys = append(k.verifyKeys, fkey)
+	}
+	k.fkey = k.verifyKeys[0]
+
+	return k, nil
 }
 
 // Must is a helper that wraps calls returning a Key and and error and panics
@@ -73,7 +145,8 @@ func Must(k Key, err error) Key {
 	return k
 }
 
-// String implements the fmt.Stringer interface for Key.
+// String implements the fmt.Stringer interface for Key, representing the key
+// that signs the tokens.
 func (k Key) String() string {
 	return k.fkey.Encode()
 }
@@ -90,13 +163,41 @@ func (k Key) MarshalToken(v interface{}) (Token, error) {
 	return Token(tokenBytes), err
 }
 
-// UnmarshalToken decrypts a Token using provided key and decodes the result
-// into the provided interface.
+// UnmarshalToken decrypts a Token using provided key, or any of its previous
+// keys, and decodes the result into the provided interface.
+//
+// The token must have been signed within its time to live, give or take the
+// tolerated clock skew.
 func (k Key) UnmarshalToken(t Token, v interface{}) error {
-	msg := fernet.VerifyAndDecrypt([]byte(t), time.Hour, []*fernet.Key{k.fkey})
+	// The time of the token is checked once it is verified, as fernet
+	// tolerates a fixed clock skew.
+	msg := fernet.VerifyAndDecrypt([]byte(t), -1, k.verifyKeys)
 	if msg == nil {
 		return ErrInvalidToken
 	}
 
+	lifetime.RLock()
+	ttl, clockSkew := lifetime.ttl, lifetime.clockSkew
+	lifetime.RUnlock()
+
+	signed := t.signedAt()
+	now := time.Now()
+	if now.After(signed.Add(ttl + clockSkew)) {
+		return ErrExpiredToken
+	}
+	if signed.After(now.Add(clockSkew)) {
+		return ErrFutureToken
+	}
+
 	return json.NewDecoder(bytes.NewBuffer(msg)).Decode(&v)
 }
+
+// signedAt returns the time at which a verified token was signed, which
+// follows the version byte of the token.
+func (t Token) signedAt() time.Time {
+	b, _ := base64.URLEncoding.DecodeString(string(t))
+	if len(b) < 9 {
+		return time.Time{}
+	}
+	return time.Unix(int64(binary.BigEndian.Uint64(b[1:9])), 0)
+}
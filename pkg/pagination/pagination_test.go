@@ -0,0 +1,80 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPage struct {
+	StartID int64
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := Must(NewKey())
+	token, err := oldKey.MarshalToken(testPage{StartID: 42})
+	require.Nil(t, err)
+
+	// The tokens signed by a previous key are still accepted.
+	newKey := Must(NewKey())
+	rotated, err := KeyFromStrings([]string{newKey.String(), oldKey.String()})
+	require.Nil(t, err)
+	assert.Equal(t, newKey.String(), rotated.String())
+
+	var page testPage
+	if assert.Nil(t, rotated.UnmarshalToken(token, &page)) {
+		assert.Equal(t, int64(42), page.StartID)
+	}
+
+	// The new tokens are signed by the first key.
+	token, err = rotated.MarshalToken(testPage{StartID: 43})
+	require.Nil(t, err)
+	assert.Nil(t, newKey.UnmarshalToken(token, &page))
+	assert.Equal(t, ErrInvalidToken, oldKey.UnmarshalToken(token, &page))
+
+	_, err = KeyFromStrings([]string{newKey.String(), "invalid"})
+	assert.Equal(t, ErrInvalidKeyString, err)
+	_, err = KeyFromStrings(nil)
+	assert.Equal(t, ErrInvalidKeyString, err)
+}
+
+func TestTokenLifetime(t *testing.T) {
+	defer SetTokenLifetime(DefaultTokenTTL, DefaultClockSkew)
+
+	key := Must(NewKey())
+	token, err := key.MarshalToken(testPage{StartID: 42})
+	require.Nil(t, err)
+
+	var page testPage
+	assert.Nil(t, key.UnmarshalToken(token, &page))
+
+	// The tokens are signed with a precision of a second.
+	SetTokenLifetime(time.Nanosecond, time.Nanosecond)
+	time.Sleep(time.Second)
+	assert.Equal(t, ErrExpiredToken, key.UnmarshalToken(token, &page))
+
+	// The clock skew is tolerated past the time to live.
+	SetTokenLifetime(time.Nanosecond, time.Hour)
+	assert.Nil(t, key.UnmarshalToken(token, &page))
+
+	// The defaults apply when the lifetime is not set.
+	SetTokenLifetime(0, 0)
+	assert.Nil(t, key.UnmarshalToken(token, &page))
+	assert.Equal(t, ErrInvalidToken, key.UnmarshalToken(Token("invalid"), &page))
+}
@@ -0,0 +1,207 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package purl implements the Package URLs identifying the features, which
+// are derived from the lister that found them and from their namespace.
+//
+// See https://github.com/package-url/purl-spec.
+package purl
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// The types of the Package URLs.
+const (
+	TypeAPK    = "apk"
+	TypeDeb    = "deb"
+	TypeRPM    = "rpm"
+	TypePyPI   = "pypi"
+	TypeNPM    = "npm"
+	TypeGolang = "golang"
+)
+
+// listers are the types of the Package URLs of the features, by the name of
+// the lister that found them.
+var listers = map[string]string{
+	"apk":      TypeAPK,
+	"dpkg":     TypeDeb,
+	"rpm":      TypeRPM,
+	"pip":      TypePyPI,
+	"npm":      TypeNPM,
+	"gobinary": TypeGolang,
+}
+
+// PackageURL identifies a package independently of the tool that found it.
+type PackageURL struct {
+	Type       string
+	Namespace  string
+	Name       string
+	Version    string
+	Qualifiers map[string]string
+}
+
+// String returns the canonical form of the Package URL, whose qualifiers are
+// sorted by key.
+func (p PackageURL) String() string {
+	var b strings.Builder
+	b.WriteString("pkg:")
+	b.WriteString(p.Type)
+	b.WriteByte('/')
+	if p.Namespace != "" {
+		for _, segment := range strings.Split(p.Namespace, "/") {
+			b.WriteString(escape(segment))
+			b.WriteByte('/')
+		}
+	}
+	b.WriteString(escape(p.Name))
+
+	if p.Version != "" {
+		b.WriteByte('@')
+		b.WriteString(url.PathEscape(p.Version))
+	}
+
+	keys := make([]string, 0, len(p.Qualifiers))
+	for key, value := range p.Qualifiers {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		if i == 0 {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('&')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(p.Qualifiers[key]))
+	}
+
+	return b.String()
+}
+
+// escape escapes a segment of the path of a Package URL, whose "@" separates
+// the version.
+func escape(segment string) string {
+	return strings.Replace(url.PathEscape(segment), "@", "%40", -1)
+}
+
+// distribution is how the packages of the namespaces of an operating system
+// are identified.
+type distribution struct {
+	// purlType is the type of the packages of the operating system.
+	purlType string
+	// namespace is the namespace of its Package URLs, the name of the
+	// operating system when it is empty.
+	namespace string
+}
+
+// distributions are the operating systems whose packages have Package URLs,
+// by the operating system of their namespace.
+var distributions = map[string]distribution{
+	"debian":     {purlType: TypeDeb},
+	"ubuntu":     {purlType: TypeDeb},
+	"alpine":     {purlType: TypeAPK},
+	"wolfi":      {purlType: TypeAPK},
+	"chainguard": {purlType: TypeAPK},
+	"centos":     {purlType: TypeRPM},
+	"rhel":       {purlType: TypeRPM, namespace: "redhat"},
+	"fedora":     {purlType: TypeRPM},
+	"oracle":     {purlType: TypeRPM},
+	"amzn":       {purlType: TypeRPM},
+	"opensuse":   {purlType: TypeRPM},
+	"sles":       {purlType: TypeRPM},
+	"photon":     {purlType: TypeRPM},
+}
+
+// New returns the Package URL of a feature found by the given lister, e.g.
+// "dpkg", in the given namespace, if both are known and agree.
+//
+// The packages of the operating systems are identified by FromNamespace,
+// while those of the programming languages do not depend on the namespace.
+func New(lister, namespace, name, version string) (PackageURL, bool) {
+	purlType, ok := listers[lister]
+	if !ok || name == "" {
+		return PackageURL{}, false
+	}
+
+	switch purlType {
+	case TypePyPI:
+		// Python normalizes its names to lower case with dashes.
+		return PackageURL{Type: purlType, Name: strings.ToLower(strings.Replace(name, "_", "-", -1)), Version: version}, true
+	case TypeNPM:
+		p := PackageURL{Type: purlType, Name: name, Version: version}
+		if i := strings.Index(name, "/"); strings.HasPrefix(name, "@") && i > 0 {
+			p.Namespace, p.Name = name[:i], name[i+1:]
+		}
+		return p, p.Name != ""
+	case TypeGolang:
+		p := PackageURL{Type: purlType, Name: name, Version: version}
+		if i := strings.LastIndex(name, "/"); i > 0 {
+			p.Namespace, p.Name = name[:i], name[i+1:]
+		}
+		return p, p.Name != ""
+	}
+
+	p, ok := FromNamespace(namespace, name, version)
+	if !ok || p.Type != purlType {
+		return PackageURL{}, false
+	}
+	return p, true
+}
+
+// FromNamespace returns the Package URL of a package of the given namespace,
+// e.g. "debian:9", if its operating system is known.
+//
+// The distro qualifier is the operating system and its version, and the epoch
+// of the versions of the RPM packages is the epoch qualifier.
+func FromNamespace(namespace, name, version string) (PackageURL, bool) {
+	osName, osVersion := namespace, ""
+	if i := strings.Index(namespace, ":"); i >= 0 {
+		osName, osVersion = namespace[:i], namespace[i+1:]
+	}
+
+	d, ok := distributions[osName]
+	if !ok || name == "" {
+		return PackageURL{}, false
+	}
+
+	p := PackageURL{
+		Type:       d.purlType,
+		Namespace:  d.namespace,
+		Name:       name,
+		Version:    version,
+		Qualifiers: make(map[string]string),
+	}
+	if p.Namespace == "" {
+		p.Namespace = osName
+	}
+
+	if osVersion != "" {
+		p.Qualifiers["distro"] = osName + "-" + strings.TrimPrefix(osVersion, "v")
+	}
+
+	if p.Type == TypeRPM {
+		if i := strings.Index(version, ":"); i > 0 {
+			p.Qualifiers["epoch"] = version[:i]
+			p.Version = version[i+1:]
+		}
+	}
+
+	return p, true
+}
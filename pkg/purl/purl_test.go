@@ -0,0 +1,79 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package purl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromNamespace(t *testing.T) {
+	for _, test := range []struct {
+		namespace, name, version string
+
+		purl string
+	}{
+		{"debian:9", "openssl", "1.1.0f-3+deb9u2", "pkg:deb/debian/openssl@1.1.0f-3+deb9u2?distro=debian-9"},
+		{"ubuntu:18.04", "libc6", "2.27-3ubuntu1", "pkg:deb/ubuntu/libc6@2.27-3ubuntu1?distro=ubuntu-18.04"},
+		{"alpine:v3.8", "musl", "1.1.19-r10", "pkg:apk/alpine/musl@1.1.19-r10?distro=alpine-3.8"},
+		{"centos:7", "bash", "4.2.46-31.el7", "pkg:rpm/centos/bash@4.2.46-31.el7?distro=centos-7"},
+		{"rhel:8", "openssl", "1:1.1.1c-2.el8", "pkg:rpm/redhat/openssl@1.1.1c-2.el8?distro=rhel-8&epoch=1"},
+		{"wolfi:rolling", "glibc", "2.38-r1", "pkg:apk/wolfi/glibc@2.38-r1?distro=wolfi-rolling"},
+		{"debian", "base-files", "9.9", "pkg:deb/debian/base-files@9.9"},
+		{"debian:9", "name with spaces", "", "pkg:deb/debian/name%20with%20spaces?distro=debian-9"},
+	} {
+		p, ok := FromNamespace(test.namespace, test.name, test.version)
+		if assert.True(t, ok, test.namespace) {
+			assert.Equal(t, test.purl, p.String())
+		}
+	}
+
+	for _, namespace := range []string{"gentoo:rolling", "python", "unknown:1"} {
+		_, ok := FromNamespace(namespace, "feature", "1.0")
+		assert.False(t, ok, namespace)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, test := range []struct {
+		lister, namespace, name, version string
+
+		purl string
+	}{
+		{"dpkg", "debian:11", "openssl", "1.1.1", "pkg:deb/debian/openssl@1.1.1?distro=debian-11"},
+		{"rpm", "centos:7", "bash", "4.2.46-31.el7", "pkg:rpm/centos/bash@4.2.46-31.el7?distro=centos-7"},
+		{"apk", "alpine:v3.8", "musl", "1.1.19-r10", "pkg:apk/alpine/musl@1.1.19-r10?distro=alpine-3.8"},
+		{"pip", "python", "Django_Rest", "3.9.0", "pkg:pypi/django-rest@3.9.0"},
+		{"npm", "npm", "lodash", "4.17.11", "pkg:npm/lodash@4.17.11"},
+		{"npm", "npm", "@babel/core", "7.2.0", "pkg:npm/%40babel/core@7.2.0"},
+		{"gobinary", "go", "github.com/coreos/clair", "v2.0.0", "pkg:golang/github.com/coreos/clair@v2.0.0"},
+	} {
+		p, ok := New(test.lister, test.namespace, test.name, test.version)
+		if assert.True(t, ok, test.lister) {
+			assert.Equal(t, test.purl, p.String())
+		}
+	}
+
+	for _, test := range []struct{ lister, namespace string }{
+		{"portage", "gentoo:rolling"},
+		{"dpkg", "unknown:1"},
+		{"dpkg", "alpine:v3.8"},
+		{"", "debian:9"},
+	} {
+		_, ok := New(test.lister, test.namespace, "feature", "1.0")
+		assert.False(t, ok, test.lister+" "+test.namespace)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit implements limiting the rate of the requests of each
+// client of a server.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the buckets of the clients that are idle long
+// enough to be full again are forgotten.
+const sweepInterval = time.Minute
+
+// Limiter limits the number of requests per minute of each client, identified
+// by a key.
+//
+// Every client has a bucket holding as many requests as are allowed per minute,
+// which is refilled continuously, so that a client that was idle may send them
+// at once.
+type Limiter struct {
+	perMinute int
+	now       func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewLimiter returns a Limiter allowing the given number of requests per
+// minute to each client.
+func NewLimiter(perMinute int) *Limiter {
+	return &Limiter{
+		perMinute: perMinute,
+		now:       time.Now,
+		buckets:   make(map[string]*bucket),
+	}
+}
+
+// Allow reports whether the client identified by key may send a request now,
+// which is then counted, and otherwise how long it should wait before
+// retrying.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.perMinute), updated: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = l.refill(b, now)
+		b.updated = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate() * float64(time.Second))
+	return false, wait
+}
+
+// rate is the number of requests per second that the buckets are refilled
+// with.
+func (l *Limiter) rate() float64 {
+	return float64(l.perMinute) / 60
+}
+
+// refill returns the tokens of a bucket at the given time.
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(l.perMinute), b.tokens+elapsed*l.rate())
+}
+
+// sweep forgets the buckets that are full, which are the same as the ones of
+// new clients.
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.perMinute) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(2)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		ok, _ := l.Allow("a")
+		require.True(t, ok)
+	}
+
+	ok, wait := l.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 30*time.Second, wait)
+
+	// The other clients have their own bucket.
+	ok, _ = l.Allow("b")
+	assert.True(t, ok)
+
+	now = now.Add(20 * time.Second)
+	ok, wait = l.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 10*time.Second, wait)
+
+	now = now.Add(10 * time.Second)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok)
+	ok, _ = l.Allow("a")
+	assert.False(t, ok)
+}
+
+func TestLimiterSweep(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(60)
+	l.now = func() time.Time { return now }
+
+	l.Allow("a")
+	l.Allow("b")
+	require.Len(t, l.buckets, 2)
+
+	// The idle buckets are forgotten once they are full again.
+	now = now.Add(sweepInterval)
+	l.Allow("b")
+	assert.Len(t, l.buckets, 1)
+	assert.Contains(t, l.buckets, "b")
+}
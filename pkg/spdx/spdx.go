@@ -0,0 +1,133 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spdx implements the JSON encoding of the SPDX 2.3 documents,
+// restricted to the packages and their relationships.
+//
+// See https://spdx.github.io/spdx-spec/v2.3.
+package spdx
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+// Version is the version of the SPDX specification of the documents.
+const Version = "SPDX-2.3"
+
+// MediaTypeJSON is the media type of the JSON encoding of the documents.
+const MediaTypeJSON = "application/spdx+json"
+
+// NoAssertion is the value of the fields about which nothing is asserted.
+const NoAssertion = "NOASSERTION"
+
+// DocumentID is the identifier of the documents themselves.
+const DocumentID = "SPDXRef-DOCUMENT"
+
+// The types of the relationships between the elements of a document.
+const (
+	RelationshipDescribes = "DESCRIBES"
+	RelationshipContains  = "CONTAINS"
+)
+
+// The purposes of the packages.
+const (
+	PurposeContainer = "CONTAINER"
+	PurposeLibrary   = "LIBRARY"
+)
+
+// invalidIDCharacters are the characters that an identifier may not contain.
+var invalidIDCharacters = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// Document describes a piece of software made of packages.
+type Document struct {
+	SPDXVersion       string         `json:"spdxVersion"`
+	DataLicense       string         `json:"dataLicense"`
+	SPDXID            string         `json:"SPDXID"`
+	Name              string         `json:"name"`
+	DocumentNamespace string         `json:"documentNamespace"`
+	CreationInfo      CreationInfo   `json:"creationInfo"`
+	Packages          []Package      `json:"packages,omitempty"`
+	Relationships     []Relationship `json:"relationships,omitempty"`
+}
+
+// CreationInfo tells when and by whom a document was created.
+type CreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// Package is a package described by a document.
+type Package struct {
+	SPDXID                string        `json:"SPDXID"`
+	Name                  string        `json:"name"`
+	VersionInfo           string        `json:"versionInfo,omitempty"`
+	Supplier              string        `json:"supplier,omitempty"`
+	DownloadLocation      string        `json:"downloadLocation"`
+	FilesAnalyzed         bool          `json:"filesAnalyzed"`
+	PrimaryPackagePurpose string        `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs          []ExternalRef `json:"externalRefs,omitempty"`
+	Comment               string        `json:"comment,omitempty"`
+}
+
+// ExternalRef is a reference of a package to an external source of data about
+// it, e.g. its Package URL.
+type ExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// Relationship relates two elements of a document.
+type Relationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// New returns a document without packages, whose namespace is a unique URI.
+func New(name, namespace, created string, creators ...string) *Document {
+	return &Document{
+		SPDXVersion:       Version,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            DocumentID,
+		Name:              name,
+		DocumentNamespace: namespace,
+		CreationInfo:      CreationInfo{Created: created, Creators: creators},
+	}
+}
+
+// ID returns an identifier of an element made of the given parts, whose
+// invalid characters are replaced.
+func ID(parts ...string) string {
+	id := "SPDXRef"
+	for _, part := range parts {
+		id += "-" + invalidIDCharacters.ReplaceAllString(part, "-")
+	}
+	return id
+}
+
+// PURLRef returns the reference of a package to its Package URL.
+func PURLRef(purl string) ExternalRef {
+	return ExternalRef{
+		ReferenceCategory: "PACKAGE-MANAGER",
+		ReferenceType:     "purl",
+		ReferenceLocator:  purl,
+	}
+}
+
+// EncodeJSON returns the JSON encoding of the document.
+func (d *Document) EncodeJSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spdx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestID(t *testing.T) {
+	assert.Equal(t, "SPDXRef-Package-1-libstdc-6", ID("Package", "1", "libstdc++6"))
+	assert.Equal(t, "SPDXRef-Image-sha256-abc", ID("Image", "sha256:abc"))
+}
+
+func TestEncodeJSON(t *testing.T) {
+	doc := New("image", "https://clair.example.com/spdx/image", "2018-01-01T00:00:00Z", "Tool: clair")
+	doc.Packages = []Package{{
+		SPDXID:           ID("Package", "1", "openssl"),
+		Name:             "openssl",
+		VersionInfo:      "1.1.0f-3",
+		DownloadLocation: NoAssertion,
+		ExternalRefs:     []ExternalRef{PURLRef("pkg:deb/debian/openssl@1.1.0f-3?distro=debian-9")},
+	}}
+	doc.Relationships = []Relationship{{DocumentID, RelationshipDescribes, ID("Package", "1", "openssl")}}
+
+	d, err := doc.EncodeJSON()
+	require.Nil(t, err)
+
+	var decoded map[string]interface{}
+	require.Nil(t, json.Unmarshal(d, &decoded))
+	assert.Equal(t, "SPDX-2.3", decoded["spdxVersion"])
+	assert.Equal(t, "CC0-1.0", decoded["dataLicense"])
+	assert.Equal(t, "SPDXRef-DOCUMENT", decoded["SPDXID"])
+	assert.Equal(t, map[string]interface{}{"created": "2018-01-01T00:00:00Z", "creators": []interface{}{"Tool: clair"}}, decoded["creationInfo"])
+
+	packages := decoded["packages"].([]interface{})
+	require.Len(t, packages, 1)
+	pkg := packages[0].(map[string]interface{})
+	assert.Equal(t, false, pkg["filesAnalyzed"])
+	assert.Equal(t, "NOASSERTION", pkg["downloadLocation"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"referenceCategory": "PACKAGE-MANAGER",
+		"referenceType":     "purl",
+		"referenceLocator":  "pkg:deb/debian/openssl@1.1.0f-3?distro=debian-9",
+	}}, pkg["externalRefs"])
+	assert.NotContains(t, pkg, "supplier")
+}
@@ -60,6 +60,28 @@ func (s *Stopper) Sleep(d time.Duration) bool {
 
 // Stop asks every goroutine to end.
 func (s *Stopper) Stop() {
+	s.StopTimeout(0)
+}
+
+// StopTimeout asks every goroutine to end and waits for them during at most d,
+// or indefinitely if d is 0. It returns false if they did not all end in time.
+func (s *Stopper) StopTimeout(d time.Duration) bool {
 	close(s.stop)
-	s.wg.Wait()
+	if d <= 0 {
+		s.wg.Wait()
+		return true
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(d):
+		return false
+	}
 }
@@ -0,0 +1,37 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stopper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopTimeout(t *testing.T) {
+	st := NewStopper()
+	st.Begin()
+	go func() {
+		<-st.Chan()
+		st.End()
+	}()
+	assert.True(t, st.StopTimeout(time.Second))
+
+	st = NewStopper()
+	st.Begin()
+	assert.False(t, st.StopTimeout(10*time.Millisecond))
+	st.End()
+}
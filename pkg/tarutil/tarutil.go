@@ -25,7 +25,11 @@ import (
 	"io"
 	"io/ioutil"
 	"os/exec"
+	"path"
 	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
 )
 
 var (
@@ -40,19 +44,101 @@ var (
 	// may used in an attempt to perform a Denial of Service attack.
 	MaxExtractableFileSize int64 = 200 * 1024 * 1024 // 200 MiB
 
+	// ErrExtractedSizeTooBig occurs when the files to extract are too big
+	// altogether.
+	ErrExtractedSizeTooBig = errors.New("tarutil: could not extract the files from the archive: total size too big")
+
+	// MaxExtractedSize enforces the maximum total size of the files extracted
+	// from a tarball, which protects against archives containing many files
+	// that are individually small enough, such as decompression bombs.
+	MaxExtractedSize int64 = 1024 * 1024 * 1024 // 1 GiB
+
+	// MaxPathDepth is the maximum number of elements of the paths of the
+	// elements of an archive. The deeper elements are skipped, which protects
+	// against archives crafted to exhaust the resources of their consumers.
+	MaxPathDepth = 256
+
+	// maxLinkHops is the maximum number of links that are followed to resolve
+	// a link, which stops the loops of links.
+	maxLinkHops = 40
+
+	// optionalFilenames are the maximum sizes of the files matched by the
+	// optional patterns, by pattern.
+	optionalFilenamesM sync.RWMutex
+	optionalFilenames  = make(map[string]int64)
+
 	readLen     = 6 // max bytes to sniff
 	gzipHeader  = []byte{0x1f, 0x8b}
 	bzip2Header = []byte{0x42, 0x5a, 0x68}
 	xzHeader    = []byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}
+	zstdHeader  = []byte{0x28, 0xb5, 0x2f, 0xfd}
 )
 
 // FilesMap is a map of files' paths to their contents.
 type FilesMap map[string][]byte
 
+// RegisterOptionalFilenames makes the files matched by the given patterns
+// optional: ExtractFiles skips them, rather than failing, when they are larger
+// than maxSize or than the extraction limits, e.g. the executables matched by
+// broad patterns. The files also matched by a pattern that is not optional are
+// still required.
+func RegisterOptionalFilenames(patterns []string, maxSize int64) {
+	optionalFilenamesM.Lock()
+	defer optionalFilenamesM.Unlock()
+
+	for _, pattern := range patterns {
+		optionalFilenames[pattern] = maxSize
+	}
+}
+
+// splitOptionalFilenames returns the patterns among filenames that are not
+// optional, and the maximum sizes of the optional ones, by pattern.
+func splitOptionalFilenames(filenames []string) ([]string, map[string]int64) {
+	optionalFilenamesM.RLock()
+	defer optionalFilenamesM.RUnlock()
+
+	var required []string
+	optional := make(map[string]int64)
+	for _, filename := range filenames {
+		if maxSize, ok := optionalFilenames[filename]; ok {
+			optional[filename] = maxSize
+		} else {
+			required = append(required, filename)
+		}
+	}
+	return required, optional
+}
+
+// maxOptionalSize returns the size of the largest optional file with the
+// given filename that is extracted, and whether it is optional: it matches an
+// optional pattern, or is the target of an optional link.
+func maxOptionalSize(filename string, optional map[string]int64, optionalLinks Links) (int64, bool) {
+	var maxSize int64
+	var matched bool
+	isTarget := optionalLinks.IsTarget(filename)
+	for pattern, size := range optional {
+		if isTarget || MatchFilename(filename, []string{pattern}) {
+			matched = true
+			if size > maxSize {
+				maxSize = size
+			}
+		}
+	}
+	return maxSize, matched
+}
+
 // ExtractFiles decompresses and extracts only the specified files from an
 // io.Reader representing an archive.
+//
+// The elements whose path is not within the archive or too deep are skipped,
+// as are the optional files that are too large, and the links have the content
+// of their target, as long as it is within the archive.
 func ExtractFiles(r io.Reader, filenames []string) (FilesMap, error) {
 	data := make(map[string][]byte)
+	// The optional links are recorded apart, as their targets are optional.
+	links, optionalLinks := make(Links), make(Links)
+	required, optional := splitOptionalFilenames(filenames)
+	var extracted int64
 
 	// Decompress the archive.
 	tr, err := NewTarReadCloser(r)
@@ -72,33 +158,231 @@ func ExtractFiles(r io.Reader, filenames []string) (FilesMap, error) {
 		}
 
 		// Get element filename
-		filename := hdr.Name
-		filename = strings.TrimPrefix(filename, "./")
+		filename, ok := CleanFilename(hdr.Name)
+		if !ok {
+			log.WithField("path", hdr.Name).Warning("tarutil: skipping archive element with invalid path")
+			continue
+		}
 
 		// Determine if we should extract the element
-		toBeExtracted := false
-		for _, s := range filenames {
-			if strings.HasPrefix(filename, s) {
-				toBeExtracted = true
+		elementLinks := links
+		if MatchFilename(filename, required) || links.IsTarget(filename) {
+			// File size limits
+			if err := CheckExtractedSize(hdr.Size, &extracted); err != nil {
+				return data, err
+			}
+		} else if maxSize, ok := maxOptionalSize(filename, optional, optionalLinks); ok {
+			// The optional files that are too large are skipped before
+			// being read.
+			if hdr.Size > maxSize {
+				log.WithFields(log.Fields{"path": filename, "size": hdr.Size}).Debug("tarutil: skipping optional archive element: file too big")
+				continue
+			}
+
+			if err := CheckExtractedSize(hdr.Size, &extracted); err != nil {
+				log.WithError(err).WithField("path", filename).Debug("tarutil: skipping optional archive element")
+				continue
+			}
+			elementLinks = optionalLinks
+		} else {
+			continue
+		}
+
+		// Extract the element
+		if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink || hdr.Typeflag == tar.TypeReg {
+			d, _ := ioutil.ReadAll(tr)
+			data[filename] = d
+			links.Remove(filename)
+			optionalLinks.Remove(filename)
+			elementLinks.Add(hdr, filename)
+		}
+	}
+
+	for link, target := range optionalLinks {
+		links[link] = target
+	}
+	links.Resolve(data, filenames)
+	return data, nil
+}
+
+// CleanFilename returns the path of an element of an archive relative to its
+// root, e.g. "etc/os-release" for "./etc/os-release" or "/etc/os-release",
+// and false if the path leaves the archive, e.g. "../etc/passwd", or is deeper
+// than MaxPathDepth. The path of the root itself is ".".
+func CleanFilename(name string) (string, bool) {
+	filename := path.Clean(name)
+	if filename == ".." || strings.HasPrefix(filename, "../") {
+		return "", false
+	}
+
+	if filename = strings.TrimPrefix(filename, "/"); filename == "" {
+		filename = "."
+	}
+
+	if strings.Count(filename, "/") >= MaxPathDepth {
+		return "", false
+	}
+
+	return filename, true
+}
+
+// Links are the links of the elements extracted from an archive to the
+// elements whose content they have, by filename.
+type Links map[string]string
+
+// Add records the element of the given header, and whose path is filename, if
+// it is a link, replacing the element previously extracted with this path.
+// The links whose target is not within the archive are skipped, as they cannot
+// be followed.
+func (l Links) Add(hdr *tar.Header, filename string) {
+	delete(l, filename)
+
+	var target string
+	switch hdr.Typeflag {
+	case tar.TypeSymlink:
+		// A symbolic link is relative to its directory, unless it is
+		// absolute, in which case it is relative to the root of the archive.
+		target = hdr.Linkname
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(filename), target)
+		}
+	case tar.TypeLink:
+		// A hard link is relative to the root of the archive.
+		target = hdr.Linkname
+	default:
+		return
+	}
+
+	cleaned, ok := CleanFilename(target)
+	if !ok {
+		log.WithFields(log.Fields{"path": filename, "target": hdr.Linkname}).Warning("tarutil: not following link outside of the archive")
+		return
+	}
+
+	l[filename] = cleaned
+}
+
+// Remove forgets the link with the given filename, e.g. after it is deleted
+// by a whiteout.
+func (l Links) Remove(filename string) {
+	delete(l, filename)
+}
+
+// IsTarget reports whether the element with the given filename is the target
+// of a link, which should then be extracted even if it is not requested.
+func (l Links) IsTarget(filename string) bool {
+	for _, target := range l {
+		if target == filename {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve gives the links of files the content of their target, following
+// the links to links, and then removes the targets that do not match the
+// given filenames. The links whose target was not extracted keep their own
+// content, which is empty.
+func (l Links) Resolve(files FilesMap, filenames []string) {
+	targets := make(map[string]struct{})
+	for link := range l {
+		if _, ok := files[link]; !ok {
+			continue
+		}
+
+		target := link
+		for hops := 0; hops < maxLinkHops; hops++ {
+			next, ok := l[target]
+			if !ok {
 				break
 			}
+			targets[next] = struct{}{}
+			target = next
+		}
+
+		if _, isLink := l[target]; isLink {
+			log.WithField("path", link).Warning("tarutil: not following loop of links")
+			continue
 		}
 
-		if toBeExtracted {
-			// File size limit
-			if hdr.Size > MaxExtractableFileSize {
-				return data, ErrExtractedFileTooBig
+		if content, ok := files[target]; ok {
+			files[link] = content
+		}
+	}
+
+	for target := range targets {
+		if !MatchFilename(target, filenames) {
+			delete(files, target)
+		}
+	}
+}
+
+// CheckExtractedSize ensures that a file of the given size can be extracted
+// from an archive, given the size of the files already extracted from it, and
+// adds it to extracted.
+//
+// It returns ErrExtractedFileTooBig or ErrExtractedSizeTooBig when the file
+// exceeds MaxExtractableFileSize or MaxExtractedSize.
+func CheckExtractedSize(size int64, extracted *int64) error {
+	if size > MaxExtractableFileSize {
+		return ErrExtractedFileTooBig
+	}
+
+	if *extracted+size > MaxExtractedSize {
+		return ErrExtractedSizeTooBig
+	}
+
+	*extracted += size
+	return nil
+}
+
+// MatchFilename reports whether the filename of an element of an archive is
+// requested by one of the given filenames.
+//
+// A requested filename matches the elements whose filename it prefixes,
+// unless it contains a wildcard: it is then a pattern, as described by
+// path.Match, that matches whole filenames and in which a "**" element
+// matches any number of directories.
+func MatchFilename(filename string, filenames []string) bool {
+	for _, s := range filenames {
+		if !strings.ContainsAny(s, "*?[") {
+			if strings.HasPrefix(filename, s) {
+				return true
 			}
+			continue
+		}
 
-			// Extract the element
-			if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink || hdr.Typeflag == tar.TypeReg {
-				d, _ := ioutil.ReadAll(tr)
-				data[filename] = d
+		if matchPattern(strings.Split(s, "/"), strings.Split(filename, "/")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchPattern(pattern, elements []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elements); i++ {
+				if matchPattern(pattern[1:], elements[i:]) {
+					return true
+				}
 			}
+			return false
+		}
+
+		if len(elements) == 0 {
+			return false
+		}
+
+		if matched, _ := path.Match(pattern[0], elements[0]); !matched {
+			return false
 		}
+
+		pattern, elements = pattern[1:], elements[1:]
 	}
 
-	return data, nil
+	return len(elements) == 0
 }
 
 // XzReader implements io.ReadCloser for data compressed via `xz`.
@@ -113,11 +397,57 @@ type XzReader struct {
 //
 // It is the caller's responsibility to call Close on the XzReader when done.
 func NewXzReader(r io.Reader) (*XzReader, error) {
-	rpipe, wpipe := io.Pipe()
-	ex, err := exec.LookPath("xz")
+	rc, cmd, closech, err := runDecompressor(r, "xz")
+	if err != nil {
+		return nil, err
+	}
+
+	return &XzReader{rc, cmd, closech}, nil
+}
+
+// Close cleans up the resources used by an XzReader.
+func (r *XzReader) Close() error {
+	r.ReadCloser.Close()
+	r.cmd.Process.Kill()
+	return <-r.closech
+}
+
+// ZstdReader implements io.ReadCloser for data compressed via `zstd`.
+type ZstdReader struct {
+	io.ReadCloser
+	cmd     *exec.Cmd
+	closech chan error
+}
+
+// NewZstdReader returns an io.ReadCloser by executing a command line `zstd`
+// executable to decompress the provided io.Reader.
+//
+// It is the caller's responsibility to call Close on the ZstdReader when done.
+func NewZstdReader(r io.Reader) (*ZstdReader, error) {
+	rc, cmd, closech, err := runDecompressor(r, "zstd")
 	if err != nil {
 		return nil, err
 	}
+
+	return &ZstdReader{rc, cmd, closech}, nil
+}
+
+// Close cleans up the resources used by a ZstdReader.
+func (r *ZstdReader) Close() error {
+	r.ReadCloser.Close()
+	r.cmd.Process.Kill()
+	return <-r.closech
+}
+
+// runDecompressor streams the provided io.Reader through the decompression
+// mode of a command line executable. The returned channel receives the result
+// of the command once its output has been closed.
+func runDecompressor(r io.Reader, name string) (io.ReadCloser, *exec.Cmd, chan error, error) {
+	rpipe, wpipe := io.Pipe()
+	ex, err := exec.LookPath(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	cmd := exec.Command(ex, "--decompress", "--stdout")
 
 	closech := make(chan error)
@@ -131,14 +461,7 @@ func NewXzReader(r io.Reader) (*XzReader, error) {
 		closech <- err
 	}()
 
-	return &XzReader{rpipe, cmd, closech}, nil
-}
-
-// Close cleans up the resources used by an XzReader.
-func (r *XzReader) Close() error {
-	r.ReadCloser.Close()
-	r.cmd.Process.Kill()
-	return <-r.closech
+	return rpipe, cmd, closech, nil
 }
 
 // TarReadCloser embeds a *tar.Reader and the related io.Closer
@@ -158,10 +481,12 @@ func (r *TarReadCloser) Close() error {
 // io.Reader and returns a TarReadCloser wrapping the Reader to transparently
 // decompress the contents.
 //
-// Gzip/Bzip2/XZ detection is done by using the magic numbers:
+// Gzip/Bzip2/XZ/Zstd detection is done by using the magic numbers:
 // Gzip: the first two bytes should be 0x1f and 0x8b. Defined in the RFC1952.
 // Bzip2: the first three bytes should be 0x42, 0x5a and 0x68. No RFC.
 // XZ: the first three bytes should be 0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00. No RFC.
+// Zstd: the first four bytes should be 0x28, 0xb5, 0x2f and 0xfd. Defined in
+// the RFC8878.
 func NewTarReadCloser(r io.Reader) (*TarReadCloser, error) {
 	br := bufio.NewReader(r)
 	header, err := br.Peek(readLen)
@@ -182,6 +507,12 @@ func NewTarReadCloser(r io.Reader) (*TarReadCloser, error) {
 				return nil, err
 			}
 			return &TarReadCloser{tar.NewReader(xzr), xzr}, nil
+		case bytes.HasPrefix(header, zstdHeader):
+			zstdr, err := NewZstdReader(br)
+			if err != nil {
+				return nil, err
+			}
+			return &TarReadCloser{tar.NewReader(zstdr), zstdr}, nil
 		}
 	}
 
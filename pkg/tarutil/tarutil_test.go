@@ -15,6 +15,7 @@
 package tarutil
 
 import (
+	"archive/tar"
 	"bytes"
 	"os"
 	"path/filepath"
@@ -29,6 +30,7 @@ var testTarballs = []string{
 	"utils_test.tar.gz",
 	"utils_test.tar.bz2",
 	"utils_test.tar.xz",
+	"utils_test.tar.zst",
 }
 
 func testfilepath(filename string) string {
@@ -78,3 +80,163 @@ func TestMaxExtractableFileSize(t *testing.T) {
 		assert.Equal(t, ErrExtractedFileTooBig, err)
 	}
 }
+
+func TestMaxExtractedSize(t *testing.T) {
+	defer func(size int64) { MaxExtractedSize = size }(MaxExtractedSize)
+	MaxExtractedSize = 12
+
+	for _, filename := range testTarballs {
+		f, err := os.Open(testfilepath(filename))
+		assert.Nil(t, err)
+		defer f.Close()
+
+		data, err := ExtractFiles(f, []string{"test/"})
+		assert.Nil(t, err)
+		assert.Len(t, data, 2)
+
+		f.Seek(0, 0)
+		_, err = ExtractFiles(f, []string{"test.txt", "plop.txt", "test/"})
+		assert.Equal(t, ErrExtractedSizeTooBig, err)
+	}
+}
+
+func TestMatchFilename(t *testing.T) {
+	filenames := []string{
+		"var/lib/dpkg/",
+		"**/site-packages/*.dist-info/METADATA",
+		"usr/lib/python*/*.egg-info",
+	}
+
+	for filename, expected := range map[string]bool{
+		"var/lib/dpkg/status":  true,
+		"var/lib/rpm/Packages": false,
+		"usr/lib/python3.6/site-packages/six-1.11.0.dist-info/METADATA":   true,
+		"site-packages/six-1.11.0.dist-info/METADATA":                     true,
+		"usr/lib/python3.6/site-packages/six-1.11.0.dist-info/RECORD":     false,
+		"usr/lib/python3.6/site-packages/six-1.11.0.dist-info/METADATA/x": false,
+		"usr/lib/python2.7/argparse.egg-info":                             true,
+		"usr/lib/python2.7/dist-packages/argparse.egg-info":               false,
+	} {
+		assert.Equal(t, expected, MatchFilename(filename, filenames), filename)
+	}
+}
+
+// testArchive returns a tarball of the given headers, in order, whose regular
+// files contain their own name.
+func testArchive(t *testing.T, headers ...tar.Header) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range headers {
+		var content []byte
+		if hdr.Typeflag == tar.TypeReg {
+			content = []byte(hdr.Name)
+			hdr.Size = int64(len(content))
+		}
+		hdr.Mode = 0644
+		assert.Nil(t, tw.WriteHeader(&hdr))
+		_, err := tw.Write(content)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	return &buf
+}
+
+func TestExtractInvalidPaths(t *testing.T) {
+	defer func(depth int) { MaxPathDepth = depth }(MaxPathDepth)
+	MaxPathDepth = 3
+
+	data, err := ExtractFiles(testArchive(t,
+		tar.Header{Name: "./", Typeflag: tar.TypeDir},
+		tar.Header{Name: "../../etc/passwd", Typeflag: tar.TypeReg},
+		tar.Header{Name: "etc/../../etc/shadow", Typeflag: tar.TypeReg},
+		tar.Header{Name: "/etc/os-release", Typeflag: tar.TypeReg},
+		tar.Header{Name: "etc/apt/../hostname", Typeflag: tar.TypeReg},
+		tar.Header{Name: "etc/a/b/c", Typeflag: tar.TypeReg},
+	), []string{"etc/"})
+	assert.Nil(t, err)
+
+	// The elements outside of the archive or too deep are skipped.
+	assert.Equal(t, FilesMap{
+		"etc/os-release": []byte("/etc/os-release"),
+		"etc/hostname":   []byte("etc/apt/../hostname"),
+	}, data)
+}
+
+func TestExtractLinks(t *testing.T) {
+	data, err := ExtractFiles(testArchive(t,
+		tar.Header{Name: "etc/group", Typeflag: tar.TypeReg},
+		tar.Header{Name: "etc/group-", Typeflag: tar.TypeLink, Linkname: "./etc/group"},
+		tar.Header{Name: "etc/issue", Typeflag: tar.TypeSymlink, Linkname: "issue.net"},
+		tar.Header{Name: "etc/issue.net", Typeflag: tar.TypeSymlink, Linkname: "/usr/lib/issue"},
+		tar.Header{Name: "etc/loop", Typeflag: tar.TypeSymlink, Linkname: "loop"},
+		tar.Header{Name: "etc/os-release", Typeflag: tar.TypeSymlink, Linkname: "../usr/lib/os-release"},
+		tar.Header{Name: "etc/passwd", Typeflag: tar.TypeSymlink, Linkname: "../../../etc/passwd"},
+		tar.Header{Name: "etc/shadow", Typeflag: tar.TypeSymlink, Linkname: "/missing"},
+		tar.Header{Name: "usr/lib/issue", Typeflag: tar.TypeReg},
+		tar.Header{Name: "usr/lib/os-release", Typeflag: tar.TypeReg},
+	), []string{"etc/"})
+	assert.Nil(t, err)
+
+	// The links have the content of their target within the archive, which is
+	// not extracted unless it is requested.
+	assert.Equal(t, FilesMap{
+		"etc/group":      []byte("etc/group"),
+		"etc/group-":     []byte("etc/group"),
+		"etc/issue":      []byte("usr/lib/issue"),
+		"etc/issue.net":  []byte("usr/lib/issue"),
+		"etc/loop":       {},
+		"etc/os-release": []byte("usr/lib/os-release"),
+		"etc/passwd":     {},
+		"etc/shadow":     {},
+	}, data)
+}
+
+func TestExtractOptionalFiles(t *testing.T) {
+	defer func(size int64) { MaxExtractedSize = size }(MaxExtractedSize)
+	defer func() { optionalFilenames = make(map[string]int64) }()
+	RegisterOptionalFilenames([]string{"usr/bin/*"}, 10)
+	MaxExtractedSize = 35
+
+	archive := func() *bytes.Buffer {
+		return testArchive(t,
+			tar.Header{Name: "etc/os-release", Typeflag: tar.TypeReg},
+			tar.Header{Name: "usr/bin/a", Typeflag: tar.TypeReg},
+			tar.Header{Name: "usr/bin/large", Typeflag: tar.TypeReg},
+			tar.Header{Name: "usr/bin/b", Typeflag: tar.TypeReg},
+			tar.Header{Name: "usr/bin/link", Typeflag: tar.TypeSymlink, Linkname: "../lib/large"},
+			tar.Header{Name: "usr/lib/large", Typeflag: tar.TypeReg},
+			tar.Header{Name: "usr/bin/c", Typeflag: tar.TypeReg},
+		)
+	}
+
+	// The optional files too large, or beyond the total size, are skipped,
+	// as are the optional targets of the links.
+	data, err := ExtractFiles(archive(), []string{"etc/os-release", "usr/bin/*"})
+	assert.Nil(t, err)
+	assert.Equal(t, FilesMap{
+		"etc/os-release": []byte("etc/os-release"),
+		"usr/bin/a":      []byte("usr/bin/a"),
+		"usr/bin/b":      []byte("usr/bin/b"),
+		"usr/bin/link":   {},
+	}, data)
+
+	// The files that are also required are not skipped.
+	_, err = ExtractFiles(archive(), []string{"etc/os-release", "usr/bin/*", "usr/bin/large"})
+	assert.Equal(t, ErrExtractedSizeTooBig, err)
+}
+
+func TestCleanFilename(t *testing.T) {
+	for name, expected := range map[string]string{
+		"./etc/os-release": "etc/os-release",
+		"/etc/os-release":  "etc/os-release",
+		"/../etc/passwd":   "etc/passwd",
+		"etc/":             "etc",
+		"./":               ".",
+		"../etc/passwd":    "",
+		"..":               "",
+	} {
+		filename, ok := CleanFilename(name)
+		assert.Equal(t, expected != "", ok, name)
+		assert.Equal(t, expected, filename, name)
+	}
+}
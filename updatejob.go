@@ -0,0 +1,323 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/pkg/commonerr"
+)
+
+const (
+	// maxUpdateJobs is the number of update jobs that are remembered, the
+	// oldest finished jobs are forgotten first.
+	maxUpdateJobs = 100
+
+	updateJobLockRetryDuration = time.Second * 10
+)
+
+// ErrUpdaterDisabled is returned when an update is triggered while the updater
+// service is not running.
+var ErrUpdaterDisabled = errors.New("updater service is disabled")
+
+// UpdateJobState is the progress of an update job.
+type UpdateJobState int
+
+const (
+	// UpdateJobPending is the state of a job waiting for the update lock.
+	UpdateJobPending UpdateJobState = iota
+	// UpdateJobRunning is the state of a job fetching and storing updates.
+	UpdateJobRunning
+	// UpdateJobSucceeded is the state of a job that stored the updates of all
+	// its updaters.
+	UpdateJobSucceeded
+	// UpdateJobFailed is the state of a job that stopped on an error.
+	UpdateJobFailed
+)
+
+// UpdateJob is an on-demand update of the vulnerabilities of some updaters,
+// run in the background of the updater service.
+type UpdateJob struct {
+	ID       string
+	Updaters []string
+	State    UpdateJobState
+	Error    string
+
+	Created  time.Time
+	Started  time.Time
+	Finished time.Time
+}
+
+// Done returns true when the job is not running anymore.
+func (j UpdateJob) Done() bool {
+	return j.State == UpdateJobSucceeded || j.State == UpdateJobFailed
+}
+
+// updateJobs holds the update jobs, only one job at a time runs each updater.
+var updateJobs = struct {
+	sync.Mutex
+
+	enabled   bool
+	datastore database.Datastore
+	stopC     chan struct{}
+	wg        sync.WaitGroup
+
+	jobs map[string]*UpdateJob
+	// order lists the job IDs from the oldest to the newest.
+	order []string
+	// active maps the updaters to the ID of their pending or running job.
+	active map[string]string
+}{
+	jobs:   make(map[string]*UpdateJob),
+	active: make(map[string]string),
+}
+
+// enableUpdateJobs allows the update jobs to be triggered until
+// disableUpdateJobs is called.
+func enableUpdateJobs(datastore database.Datastore, stopC chan struct{}) {
+	updateJobs.Lock()
+	defer updateJobs.Unlock()
+
+	updateJobs.enabled = true
+	updateJobs.datastore = datastore
+	updateJobs.stopC = stopC
+}
+
+// disableUpdateJobs refuses the new update jobs and waits for the running ones
+// to finish.
+func disableUpdateJobs() {
+	updateJobs.Lock()
+	updateJobs.enabled = false
+	updateJobs.Unlock()
+
+	updateJobs.wg.Wait()
+}
+
+// TriggerUpdate starts an update of the given updaters, or of all the enabled
+// updaters if none is given, in the background and returns the job tracking
+// it.
+//
+// The updaters that are already updated by a pending or running job are not
+// part of the new job. If all of them are, the job already updating the first
+// one is returned instead.
+func TriggerUpdate(updaters []string) (UpdateJob, error) {
+	updateJobs.Lock()
+	defer updateJobs.Unlock()
+
+	if !updateJobs.enabled {
+		return UpdateJob{}, ErrUpdaterDisabled
+	}
+
+	if len(updaters) == 0 {
+		updaters = EnabledUpdaters
+	}
+
+	var toUpdate []string
+	seen := make(map[string]struct{}, len(updaters))
+	for _, name := range updaters {
+		if !updaterEnabled(name) {
+			return UpdateJob{}, commonerr.NewBadRequestError(fmt.Sprintf("updater %q is not enabled", name))
+		}
+
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		if _, ok := updateJobs.active[name]; !ok {
+			toUpdate = append(toUpdate, name)
+		}
+	}
+
+	if len(toUpdate) == 0 {
+		return *updateJobs.jobs[updateJobs.active[updaters[0]]], nil
+	}
+
+	job := &UpdateJob{
+		ID:       uuid.New(),
+		Updaters: toUpdate,
+		State:    UpdateJobPending,
+		Created:  time.Now().UTC(),
+	}
+
+	updateJobs.jobs[job.ID] = job
+	updateJobs.order = append(updateJobs.order, job.ID)
+	for _, name := range toUpdate {
+		updateJobs.active[name] = job.ID
+	}
+	forgetUpdateJobs()
+
+	log.WithFields(log.Fields{"job": job.ID, "updaters": toUpdate}).Info("update triggered")
+
+	updateJobs.wg.Add(1)
+	go runUpdateJob(updateJobs.datastore, updateJobs.stopC, job.ID, toUpdate)
+
+	return *job, nil
+}
+
+// GetUpdateJob returns the update job with the given ID.
+func GetUpdateJob(id string) (UpdateJob, error) {
+	updateJobs.Lock()
+	defer updateJobs.Unlock()
+
+	job, ok := updateJobs.jobs[id]
+	if !ok {
+		return UpdateJob{}, commonerr.ErrNotFound
+	}
+	return *job, nil
+}
+
+// forgetUpdateJobs removes the oldest finished jobs above maxUpdateJobs. It
+// must be called with the lock of updateJobs held.
+func forgetUpdateJobs() {
+	excess := len(updateJobs.order) - maxUpdateJobs
+	if excess <= 0 {
+		return
+	}
+
+	order := updateJobs.order[:0]
+	for _, id := range updateJobs.order {
+		if excess > 0 && updateJobs.jobs[id].Done() {
+			delete(updateJobs.jobs, id)
+			excess--
+			continue
+		}
+		order = append(order, id)
+	}
+	updateJobs.order = order
+}
+
+// setUpdateJobState updates the state of a job and releases its updaters once
+// it is done.
+func setUpdateJobState(id string, state UpdateJobState, jobErr error) {
+	updateJobs.Lock()
+	defer updateJobs.Unlock()
+
+	job := updateJobs.jobs[id]
+	job.State = state
+
+	switch state {
+	case UpdateJobRunning:
+		job.Started = time.Now().UTC()
+	case UpdateJobSucceeded, UpdateJobFailed:
+		job.Finished = time.Now().UTC()
+		if jobErr != nil {
+			job.Error = jobErr.Error()
+		}
+
+		for _, name := range job.Updaters {
+			if updateJobs.active[name] == id {
+				delete(updateJobs.active, name)
+			}
+		}
+	}
+}
+
+// runUpdateJob waits for the update lock, so that the job does not overlap
+// with the scheduled updates or the jobs of other Clair instances, and updates
+// the vulnerabilities of the given updaters.
+func runUpdateJob(datastore database.Datastore, stopC chan struct{}, id string, updaters []string) {
+	defer updateJobs.wg.Done()
+
+	whoAmI := updaterLockOwner()
+	logger := log.WithField("job", id)
+	for {
+		if hasLock, _ := lock(datastore, updaterLockName, whoAmI, updaterLockDuration, false); hasLock {
+			break
+		}
+
+		logger.Debug("update lock is already taken")
+		select {
+		case <-stopC:
+			setUpdateJobState(id, UpdateJobFailed, errors.New("updater service stopped before the update could start"))
+			return
+		case <-time.After(updateJobLockRetryDuration):
+		}
+	}
+
+	setUpdateJobState(id, UpdateJobRunning, nil)
+
+	doneC := make(chan error, 1)
+	go func() {
+		doneC <- updateOnDemand(datastore, updaters)
+	}()
+
+	// As for the scheduled updates, the job is waited for when Clair stops.
+	var err error
+	for done := false; !done; {
+		select {
+		case err = <-doneC:
+			done = true
+		case <-time.After(updaterLockRefreshDuration):
+			lock(datastore, updaterLockName, whoAmI, updaterLockDuration, true)
+		}
+	}
+
+	unlock(datastore, updaterLockName, whoAmI)
+
+	if err != nil {
+		logger.WithError(err).Error("update job failed")
+		setUpdateJobState(id, UpdateJobFailed, err)
+		return
+	}
+
+	logger.Info("update job finished")
+	setUpdateJobState(id, UpdateJobSucceeded, nil)
+}
+
+// updateOnDemand fetches and stores the vulnerabilities of the given updaters.
+//
+// Unlike update, it leaves the update cycle and the last update time alone so
+// that the scheduled updates are not delayed.
+func updateOnDemand(datastore database.Datastore, updaters []string) error {
+	_, firstUpdate, err := GetLastUpdateTime(datastore)
+	if err != nil {
+		return err
+	}
+
+	return updateUpdaters(datastore, updaters, firstUpdate)
+}
+
+// updateUpdaters fetches and stores the vulnerabilities of the given updaters,
+// outside of the update cycle.
+func updateUpdaters(datastore database.Datastore, updaters []string, firstUpdate bool) error {
+	failed, responses := fetchUpdaters(datastore, updaters)
+	names, vulnerabilities := addUpdatersMetadata(datastore, responses, true)
+
+	for _, name := range names {
+		resp := responses[name]
+		if err := persistUpdate(datastore, vulnerabilities[name], resp, firstUpdate); err != nil {
+			promUpdaterErrorsTotal.Inc()
+			return err
+		}
+
+		for _, note := range resp.Notes {
+			log.WithField("note", note).Warning("fetcher note")
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("could not fetch vulnerabilities from updaters: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt/dpkg"
+	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/pkg/commonerr"
+)
+
+// blockingUpdater is an updater whose updates wait for release to be closed.
+type blockingUpdater struct {
+	mockUpdater
+	release chan struct{}
+}
+
+func (u *blockingUpdater) Update(datastore database.Datastore) (vulnsrc.UpdateResponse, error) {
+	<-u.release
+	return u.mockUpdater.Update(datastore)
+}
+
+func waitUpdateJob(t *testing.T, id string) UpdateJob {
+	for i := 0; i < 100; i++ {
+		job, err := GetUpdateJob(id)
+		require.Nil(t, err)
+		if job.Done() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.FailNow(t, "update job did not finish")
+	return UpdateJob{}
+}
+
+func TestTriggerUpdate(t *testing.T) {
+	ns := database.Namespace{Name: "trigger:1", VersionFormat: dpkg.ParserName}
+	u := &blockingUpdater{
+		mockUpdater: mockUpdater{vuln: database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{Name: "CVE-1", Namespace: ns, Severity: database.HighSeverity},
+			Affected:      []database.AffectedFeature{{AffectedType: database.AffectBinaryPackage, Namespace: ns, FeatureName: "openssl", AffectedVersion: "1.0", FixedInVersion: "1.0"}},
+		}},
+		release: make(chan struct{}),
+	}
+	vulnsrc.RegisterUpdater("trigger-1", u)
+
+	defer func(enabled []string) { EnabledUpdaters = enabled }(EnabledUpdaters)
+	EnabledUpdaters = []string{"trigger-1"}
+
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "mem"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	// The updates cannot be triggered while the updater is not running.
+	_, err = TriggerUpdate(nil)
+	assert.Equal(t, ErrUpdaterDisabled, err)
+
+	enableUpdateJobs(datastore, make(chan struct{}))
+	defer disableUpdateJobs()
+
+	_, err = TriggerUpdate([]string{"unknown"})
+	assert.IsType(t, &commonerr.ErrBadRequest{}, err)
+
+	job, err := TriggerUpdate(nil)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"trigger-1"}, job.Updaters)
+	assert.False(t, job.Done())
+
+	// The updater is not run twice at the same time.
+	again, err := TriggerUpdate([]string{"trigger-1"})
+	require.Nil(t, err)
+	assert.Equal(t, job.ID, again.ID)
+
+	close(u.release)
+	job = waitUpdateJob(t, job.ID)
+	assert.Equal(t, UpdateJobSucceeded, job.State)
+	assert.Empty(t, job.Error)
+	assert.Equal(t, 1, u.calls)
+
+	tx, err := datastore.Begin()
+	require.Nil(t, err)
+	// The update lock is released.
+	owner, _, _, _ := tx.FindLock(updaterLockName)
+	assert.Empty(t, owner)
+
+	vulns, err := tx.FindVulnerabilities([]database.VulnerabilityID{{Name: "CVE-1", Namespace: ns.Name}})
+	require.Nil(t, err)
+	if assert.Len(t, vulns, 1) {
+		assert.True(t, vulns[0].Valid)
+	}
+	require.Nil(t, tx.Rollback())
+
+	// Once done, the updater can be run again.
+	next, err := TriggerUpdate([]string{"trigger-1"})
+	require.Nil(t, err)
+	assert.NotEqual(t, job.ID, next.ID)
+	assert.Equal(t, UpdateJobSucceeded, waitUpdateJob(t, next.ID).State)
+
+	_, err = GetUpdateJob("unknown")
+	assert.Equal(t, commonerr.ErrNotFound, err)
+}
@@ -15,31 +15,60 @@
 package clair
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
+	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/pborman/uuid"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt"
 	"github.com/coreos/clair/ext/vulnmdsrc"
 	"github.com/coreos/clair/ext/vulnsrc"
+	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/httputil"
 	"github.com/coreos/clair/pkg/stopper"
+	"github.com/coreos/clair/pkg/strutil"
 )
 
 const (
 	updaterLastFlagName              = "updater/last"
+	updaterLastSourceFlagPrefix      = "updater/last/"
+	updaterLastStartFlagName         = "updater/last_start"
+	updaterLastErrorFlagName         = "updater/last_error"
+	updaterCheckpointFlagName        = "updater/checkpoint"
 	updaterLockName                  = "updater"
-	updaterLockDuration              = updaterLockRefreshDuration + time.Minute*2
-	updaterLockRefreshDuration       = time.Minute * 8
 	updaterSleepBetweenLoopsDuration = time.Minute
+
+	// DefaultUpdaterLockDuration is the default lease of the updater lock.
+	DefaultUpdaterLockDuration = time.Minute * 10
+
+	// maxInstanceNameLength is the maximum length of the instance name in the
+	// owners of the updater lock, which are at most 64 characters long.
+	maxInstanceNameLength = 55
 )
 
 var (
+	// updaterLockDuration is how long the updater lock is held until it is
+	// refreshed, every updaterLockRefreshDuration, so that the other instances
+	// can take it once its holder stopped refreshing it, e.g. after a crash.
+	updaterLockDuration        = DefaultUpdaterLockDuration
+	updaterLockRefreshDuration = DefaultUpdaterLockDuration * 4 / 5
+
+	// updaterInstanceName names the instance of Clair in the owners of the
+	// updater lock. It is the hostname when it is not configured.
+	updaterInstanceName string
+
 	promUpdaterErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
 		Name: "clair_updater_errors_total",
 		Help: "Numbers of errors that the updater generated.",
@@ -57,6 +86,14 @@ var (
 
 	// EnabledUpdaters contains all updaters to be used for update.
 	EnabledUpdaters []string
+
+	// updaterConcurrency is the maximum number of updaters fetching their data
+	// sources at the same time, or 0 to fetch them all at once.
+	updaterConcurrency int
+
+	// updaterSchedules are the schedules of the updaters that are not updated
+	// on the schedule of the updater service, by name.
+	updaterSchedules map[string]cron.Schedule
 )
 
 func init() {
@@ -67,8 +104,54 @@ func init() {
 
 // UpdaterConfig is the configuration for the Updater service.
 type UpdaterConfig struct {
+	// EnabledUpdaters lists the updaters to run. All the registered updaters
+	// are run when it is empty.
 	EnabledUpdaters []string
-	Interval        time.Duration
+
+	// DisabledUpdaters lists the updaters that must not run, even if they are
+	// enabled.
+	DisabledUpdaters []string
+
+	// Cron is the cron expression scheduling the updates, in UTC, e.g.
+	// "0 */6 * * *", as parsed by cron.ParseStandard. The updates are run
+	// every Interval when it is empty.
+	Cron     string
+	Interval time.Duration
+
+	// Disabled disables the updater, as does an Interval of 0 without Cron.
+	Disabled bool
+
+	// Crons overrides the schedule of some updaters, by name, with their own
+	// cron expression, parsed as Cron. Each of them is then updated on its own
+	// schedule, separately from the other updaters.
+	Crons map[string]string
+
+	// Concurrency is the maximum number of data sources fetched and parsed at
+	// the same time. They are all fetched at once when it is 0. The fetched
+	// vulnerabilities are always stored one data source at a time.
+	Concurrency int
+
+	// LockDuration is the lease of the updater lock, which the instance of
+	// Clair updating the vulnerabilities refreshes until the update is done,
+	// while the other instances skip it. Once its holder stops refreshing it,
+	// e.g. after a crash, the lock expires and the other instances take it.
+	// DefaultUpdaterLockDuration is used when it is not set.
+	//
+	// InstanceName names the instance of Clair in the updater status while it
+	// holds the lock. The hostname is used when it is not set.
+	LockDuration time.Duration
+	InstanceName string
+
+	// DryRun makes the updater fetch the vulnerabilities once and log the
+	// changes it would make to the database, without writing them.
+	DryRun bool
+
+	// HTTP configures the client used to fetch the data sources.
+	HTTP httputil.ClientConfig
+
+	// Params are the parameters of the updaters that implement
+	// vulnsrc.Configurable, each of them under its own key.
+	Params map[string]interface{} `yaml:",inline"`
 }
 
 type vulnerabilityChange struct {
@@ -76,82 +159,147 @@ type vulnerabilityChange struct {
 	new *database.VulnerabilityWithAffected
 }
 
-// RunUpdater begins a process that updates the vulnerability database at
-// regular intervals.
+// SourceSchedules returns the schedules of the updaters that override the
+// schedule of the updates with their own cron expression, by name.
+func (config *UpdaterConfig) SourceSchedules() (map[string]cron.Schedule, error) {
+	schedules := make(map[string]cron.Schedule, len(config.Crons))
+	for name, expr := range config.Crons {
+		schedule, err := parseCron(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		schedules[name] = schedule
+	}
+	return schedules, nil
+}
+
+// parseCron parses a standard cron expression, which must match at some time.
+func parseCron(expr string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule.Next(time.Now().UTC()).IsZero() {
+		return nil, fmt.Errorf("cron expression %q never matches", expr)
+	}
+	return schedule, nil
+}
+
+// IsDisabled returns whether the updater is disabled, either explicitly or by
+// an Interval of 0 without Cron.
+func (config *UpdaterConfig) IsDisabled() bool {
+	return config.Disabled || (config.Cron == "" && config.Interval == 0)
+}
+
+// Schedule returns the schedule of the updates, given by Cron, a standard cron
+// expression, or by Interval when Cron is empty.
+func (config *UpdaterConfig) Schedule() (cron.Schedule, error) {
+	if config.Cron != "" {
+		return parseCron(config.Cron)
+	}
+
+	if config.Interval <= 0 {
+		return nil, errors.New("neither a cron expression nor an interval schedules the updates")
+	}
+
+	return cron.Every(config.Interval), nil
+}
+
+// RunUpdater begins a process that updates the vulnerability database on the
+// schedule of the configuration.
 func RunUpdater(config *UpdaterConfig, datastore database.Datastore, st *stopper.Stopper) {
 	defer st.End()
 
-	// Do not run the updater if there is no config or if the interval is 0.
-	if config == nil || config.Interval == 0 || len(config.EnabledUpdaters) == 0 {
+	// Do not run the updater if there is no config or if it is disabled.
+	if config == nil || config.IsDisabled() || len(EnabledUpdaters) == 0 {
 		log.Info("updater service is disabled.")
 		return
 	}
 
-	whoAmI := uuid.New()
+	schedule, err := config.Schedule()
+	if err != nil {
+		log.WithError(err).Fatal("could not schedule the updater")
+	}
+
+	if err := httputil.ConfigureClient(config.HTTP); err != nil {
+		log.WithError(err).Fatal("could not configure the HTTP client of the updater")
+	}
+
+	configureUpdaters(config.Params)
+	configureUpdaterLock(config)
+	updaterConcurrency = config.Concurrency
+
+	if config.DryRun {
+		log.Info("updater service started in dry run mode")
+		dryRunUpdate(datastore)
+		cleanUpdaters()
+		log.Info("updater service stopped")
+		return
+	}
+
+	whoAmI := updaterLockOwner()
 	log.WithField("lock identifier", whoAmI).Info("updater service started")
 
-	for {
-		var stop bool
+	// The updates can also be triggered on demand while the service runs.
+	enableUpdateJobs(datastore, st.Chan())
+
+	// The updaters with their own cron expression are updated on their own
+	// schedule.
+	sourceSchedules, err := config.SourceSchedules()
+	if err != nil {
+		log.WithError(err).Fatal("could not schedule the updaters")
+	}
+
+	updaterSchedules = make(map[string]cron.Schedule)
+	var schedules sync.WaitGroup
+	for _, name := range EnabledUpdaters {
+		sourceSchedule, ok := sourceSchedules[name]
+		if !ok {
+			continue
+		}
+
+		updaterSchedules[name] = sourceSchedule
+		schedules.Add(1)
+		go func(name string, schedule cron.Schedule) {
+			defer schedules.Done()
+			runUpdaterSchedule(datastore, name, schedule, st)
+		}(name, sourceSchedule)
+	}
 
+	// There is no update cycle to run when all the updaters have their own
+	// schedule.
+	for len(defaultUpdaters()) > 0 {
 		// Determine if this is the first update and define the next update time.
-		// The next update time is (last update time + interval) or now if this is the first update.
+		// The next update time is the one following the last update on the
+		// schedule, or now if this is the first update.
 		nextUpdate := time.Now().UTC()
 		lastUpdate, firstUpdate, err := GetLastUpdateTime(datastore)
 		if err != nil {
 			log.WithError(err).Error("an error occurred while getting the last update time")
-			nextUpdate = nextUpdate.Add(config.Interval)
+			nextUpdate = schedule.Next(nextUpdate)
 		} else if !firstUpdate {
-			nextUpdate = lastUpdate.Add(config.Interval)
+			nextUpdate = schedule.Next(lastUpdate)
 		}
 
 		// If the next update timer is in the past, then try to update.
 		if nextUpdate.Before(time.Now().UTC()) {
-			// Attempt to get a lock on the the update.
-			log.Debug("attempting to obtain update lock")
-			hasLock, hasLockUntil := lock(datastore, updaterLockName, whoAmI, updaterLockDuration, false)
-			if hasLock {
-				// Launch update in a new go routine.
-				doneC := make(chan bool, 1)
-				go func() {
-					update(datastore, firstUpdate)
-					doneC <- true
-				}()
-
-				for done := false; !done && !stop; {
-					select {
-					case <-doneC:
-						done = true
-					case <-time.After(updaterLockRefreshDuration):
-						// Refresh the lock until the update is done.
-						lock(datastore, updaterLockName, whoAmI, updaterLockDuration, true)
-					case <-st.Chan():
-						stop = true
-					}
-				}
-
-				// Unlock the updater.
-				unlock(datastore, updaterLockName, whoAmI)
-
-				if stop {
-					break
-				}
+			hasLock, lockedUntil, stopped := runLocked(datastore, whoAmI, st, func() {
+				update(datastore, firstUpdate, schedulePeriod(schedule, time.Now().UTC()))
+			})
+			if stopped {
+				break
+			}
 
+			if hasLock {
 				// Sleep for a short duration to prevent pinning the CPU on a
 				// consistent failure.
 				if stopped := sleepUpdater(time.Now().Add(updaterSleepBetweenLoopsDuration), st); stopped {
 					break
 				}
 				continue
-			} else {
-				lockOwner, lockExpiration, ok, err := findLock(datastore, updaterLockName)
-				if !ok || err != nil {
-					log.Debug("update lock is already taken")
-					nextUpdate = hasLockUntil
-				} else {
-					log.WithFields(log.Fields{"lock owner": lockOwner, "lock expiration": lockExpiration}).Debug("update lock is already taken")
-					nextUpdate = lockExpiration
-				}
 			}
+			nextUpdate = lockedUntil
 		}
 
 		if stopped := sleepUpdater(nextUpdate, st); stopped {
@@ -159,15 +307,174 @@ func RunUpdater(config *UpdaterConfig, datastore database.Datastore, st *stopper
 		}
 	}
 
-	// Clean resources.
+	// The updaters with their own schedule are updated until Clair stops.
+	<-st.Chan()
+	schedules.Wait()
+	disableUpdateJobs()
+	cleanUpdaters()
+	log.Info("updater service stopped")
+}
+
+// schedulePeriod returns the time between the next two updates of the
+// schedule after t, which bounds the duration of an update cycle.
+func schedulePeriod(schedule cron.Schedule, t time.Time) time.Duration {
+	next := schedule.Next(t)
+	return schedule.Next(next).Sub(next)
+}
+
+// runUpdaterSchedule updates the vulnerabilities of a single updater on its
+// own schedule, until Clair stops.
+//
+// As for the other updates, it holds the updater lock while updating, so that
+// an updater is never updated twice at the same time, by Clair or by another
+// instance.
+func runUpdaterSchedule(datastore database.Datastore, name string, schedule cron.Schedule, st *stopper.Stopper) {
+	whoAmI := updaterLockOwner()
+	logger := log.WithField("updater name", name)
+	logger.Info("updater scheduled on its own cron expression")
+
+	for {
+		nextUpdate := time.Now().UTC()
+		lastUpdate, ok, err := getSourceLastUpdateTime(datastore, name)
+		if err != nil {
+			logger.WithError(err).Error("an error occurred while getting the last update time of the updater")
+			nextUpdate = schedule.Next(nextUpdate)
+		} else if ok {
+			nextUpdate = schedule.Next(lastUpdate)
+		}
+
+		if nextUpdate.Before(time.Now().UTC()) {
+			hasLock, lockedUntil, stopped := runLocked(datastore, whoAmI, st, func() {
+				updateSource(datastore, name)
+			})
+			if stopped {
+				return
+			}
+
+			if hasLock {
+				if stopped := sleepUpdater(time.Now().Add(updaterSleepBetweenLoopsDuration), st); stopped {
+					return
+				}
+				continue
+			}
+			nextUpdate = lockedUntil
+		}
+
+		if stopped := sleepUpdater(nextUpdate, st); stopped {
+			return
+		}
+	}
+}
+
+// runLocked runs fn while holding the updater lock, which is refreshed until fn
+// returns. If the lock is already taken, fn is not run and the time at which
+// the lock expires is returned instead.
+//
+// Once Clair is stopping, fn is still waited for, so that it does not leave its
+// transactions open, and stopped is true.
+func runLocked(datastore database.Datastore, whoAmI string, st *stopper.Stopper, fn func()) (hasLock bool, lockedUntil time.Time, stopped bool) {
+	// Attempt to get a lock on the the update.
+	log.Debug("attempting to obtain update lock")
+	hasLock, hasLockUntil := lock(datastore, updaterLockName, whoAmI, updaterLockDuration, false)
+	if !hasLock {
+		lockOwner, lockExpiration, ok, err := findLock(datastore, updaterLockName)
+		if !ok || err != nil {
+			log.Debug("update lock is already taken")
+			return false, hasLockUntil, false
+		}
+
+		log.WithFields(log.Fields{"lock owner": lockOwner, "lock expiration": lockExpiration}).Info("update lock is held by another instance, skipping the update")
+		return false, lockExpiration, false
+	}
+
+	// Launch update in a new go routine.
+	doneC := make(chan bool, 1)
+	go func() {
+		fn()
+		doneC <- true
+	}()
+
+	stopC := st.Chan()
+	for done := false; !done; {
+		select {
+		case <-doneC:
+			done = true
+		case <-time.After(updaterLockRefreshDuration):
+			// Refresh the lock until the update is done.
+			lock(datastore, updaterLockName, whoAmI, updaterLockDuration, true)
+		case <-stopC:
+			log.Info("waiting for the current update to finish before stopping")
+			stopped = true
+			stopC = nil
+		}
+	}
+
+	// Unlock the updater.
+	unlock(datastore, updaterLockName, whoAmI)
+
+	return true, time.Time{}, stopped
+}
+
+// configureUpdaterLock sets the lease of the updater lock and the name of the
+// instance holding it.
+func configureUpdaterLock(config *UpdaterConfig) {
+	updaterLockDuration = DefaultUpdaterLockDuration
+	if config.LockDuration > 0 {
+		updaterLockDuration = config.LockDuration
+	}
+	updaterLockRefreshDuration = updaterLockDuration * 4 / 5
+
+	updaterInstanceName = config.InstanceName
+}
+
+// updaterLockOwner returns a new owner of the updater lock, made of the name
+// of the instance and of a random identifier, so that the schedules and jobs
+// of an instance hold the lock in turn.
+func updaterLockOwner() string {
+	name := updaterInstanceName
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+
+	if len(name) > maxInstanceNameLength {
+		name = name[:maxInstanceNameLength]
+	}
+
+	return name + "/" + uuid.New()[:8]
+}
+
+// updaterLockHolder returns the name of the instance of the owner of the
+// updater lock, or the owner itself for the owners without one.
+func updaterLockHolder(owner string) string {
+	if i := strings.LastIndex(owner, "/"); i > 0 {
+		return owner[:i]
+	}
+	return owner
+}
+
+// configureUpdaters configures the enabled updaters that take parameters.
+func configureUpdaters(params map[string]interface{}) {
+	updaters := vulnsrc.Updaters()
+	for _, name := range EnabledUpdaters {
+		u, ok := updaters[name].(vulnsrc.Configurable)
+		if !ok {
+			continue
+		}
+
+		if err := u.Configure(params); err != nil {
+			log.WithError(err).WithField("updater name", name).Fatal("could not configure the updater")
+		}
+	}
+}
+
+// cleanUpdaters cleans the resources of the registered appenders and updaters.
+func cleanUpdaters() {
 	for _, appenders := range vulnmdsrc.Appenders() {
 		appenders.Clean()
 	}
 	for _, updaters := range vulnsrc.Updaters() {
 		updaters.Clean()
 	}
-
-	log.Info("updater service stopped")
 }
 
 // sleepUpdater sleeps the updater for an approximate duration, but remains
@@ -185,18 +492,144 @@ func sleepUpdater(approxWakeup time.Time, st *stopper.Stopper) (stopped bool) {
 
 // update fetches all the vulnerabilities from the registered fetchers, updates
 // vulnerabilities, and updater flags, and logs notes from updaters.
-func update(datastore database.Datastore, firstUpdate bool) {
+//
+// The start time and the outcome of the update are recorded in the database so
+// that they can be reported by GetUpdaterStatus.
+func update(datastore database.Datastore, firstUpdate bool, interval time.Duration) {
 	defer setUpdaterDuration(time.Now())
 
 	log.Info("updating vulnerabilities")
 
+	if err := setLastUpdateStart(datastore); err != nil {
+		log.WithError(err).Error("Unable to set last update start time")
+	}
+
+	updateErr := doUpdate(datastore, firstUpdate, interval)
+	if err := setLastUpdateError(datastore, updateErr); err != nil {
+		log.WithError(err).Error("Unable to set last update error")
+	}
+}
+
+func doUpdate(datastore database.Datastore, firstUpdate bool, interval time.Duration) error {
+	// Resume the current update cycle: the updaters that have completed it are
+	// not run again.
+	checkpoint, err := findUpdaterCheckpoint(datastore, interval)
+	if err != nil {
+		log.WithError(err).Error("Unable to find updater checkpoint, starting a new update cycle")
+		checkpoint = newUpdaterCheckpoint()
+	}
+
+	remaining := strutil.Difference(defaultUpdaters(), checkpoint.Completed)
+	if len(checkpoint.Completed) > 0 {
+		log.WithFields(log.Fields{
+			"completed updaters": checkpoint.Completed,
+			"remaining updaters": remaining,
+		}).Info("resuming update cycle")
+	}
+
 	// Fetch updates.
-	success, vulnerabilities, flags, notes := fetch(datastore)
+	failed, responses := fetchUpdaters(datastore, remaining)
+	names, vulnerabilities := addUpdatersMetadata(datastore, responses, true)
+
+	var notes []string
+	for _, name := range names {
+		resp := responses[name]
+		if err := persistUpdate(datastore, vulnerabilities[name], resp, firstUpdate); err != nil {
+			promUpdaterErrorsTotal.Inc()
+			log.WithError(err).WithField("updater name", name).Error("Unable to update vulnerabilities")
+			return err
+		}
+
+		// Once its vulnerabilities are stored, the updater has completed the
+		// cycle.
+		checkpoint.Completed = append(checkpoint.Completed, name)
+		if err := setUpdaterCheckpoint(datastore, checkpoint); err != nil {
+			log.WithError(err).Error("Unable to set updater checkpoint")
+			return err
+		}
+
+		notes = append(notes, resp.Notes...)
+	}
+
+	for _, note := range notes {
+		log.WithField("note", note).Warning("fetcher note")
+	}
+	promUpdaterNotesTotal.Set(float64(len(notes)))
+
+	if len(failed) > 0 {
+		log.WithField("failed updaters", failed).Info("update finished with errors")
+		return fmt.Errorf("could not fetch vulnerabilities from updaters: %s", strings.Join(failed, ", "))
+	}
+
+	// The cycle is complete, the next update starts a new one.
+	if err := setUpdaterCheckpoint(datastore, newUpdaterCheckpoint()); err != nil {
+		log.WithError(err).Error("Unable to reset updater checkpoint")
+		return err
+	}
+
+	err = setLastUpdateTime(datastore)
+	if err != nil {
+		log.WithError(err).Error("Unable to set last update time")
+		return err
+	}
+
+	log.Info("update finished")
+	return nil
+}
+
+// defaultUpdaters returns the enabled updaters that are updated on the
+// schedule of the updater service.
+func defaultUpdaters() []string {
+	updaters := make([]string, 0, len(EnabledUpdaters))
+	for _, name := range EnabledUpdaters {
+		if _, ok := updaterSchedules[name]; !ok {
+			updaters = append(updaters, name)
+		}
+	}
+	return updaters
+}
+
+// updateSource fetches and stores the vulnerabilities of an updater that has
+// its own schedule, and records the time of its update once it succeeds.
+func updateSource(datastore database.Datastore, name string) {
+	defer setUpdaterDuration(time.Now())
 
-	// do vulnerability namespacing again to merge potentially duplicated
-	// vulnerabilities from each updater.
-	vulnerabilities = doVulnerabilitiesNamespacing(vulnerabilities)
+	logger := log.WithField("updater name", name)
+	logger.Info("updating vulnerabilities")
 
+	// Until the update cycle has completed once, the first update of the
+	// updater does not notify anything either.
+	_, firstUpdate, err := GetLastUpdateTime(datastore)
+	if err != nil {
+		logger.WithError(err).Error("an error occurred while getting the last update time")
+		return
+	}
+
+	if firstUpdate {
+		_, updated, err := getSourceLastUpdateTime(datastore, name)
+		if err != nil {
+			logger.WithError(err).Error("an error occurred while getting the last update time of the updater")
+			return
+		}
+		firstUpdate = !updated
+	}
+
+	if err := updateUpdaters(datastore, []string{name}, firstUpdate); err != nil {
+		logger.WithError(err).Error("update finished with errors")
+		return
+	}
+
+	if err := setSourceLastUpdateTime(datastore, name); err != nil {
+		logger.WithError(err).Error("Unable to set last update time")
+		return
+	}
+
+	logger.Info("update finished")
+}
+
+// persistUpdate stores the namespaces and vulnerabilities fetched by an
+// updater, notifies their changes and updates the flag of the updater.
+func persistUpdate(datastore database.Datastore, vulnerabilities []database.VulnerabilityWithAffected, resp vulnsrc.UpdateResponse, firstUpdate bool) error {
 	// deduplicate fetched namespaces and store them into database.
 	nsMap := map[database.Namespace]struct{}{}
 	for _, vuln := range vulnerabilities {
@@ -210,83 +643,186 @@ func update(datastore database.Datastore, firstUpdate bool) {
 
 	if err := database.PersistNamespacesAndCommit(datastore, namespaces); err != nil {
 		log.WithError(err).Error("Unable to insert namespaces")
-		return
+		return err
 	}
 
 	changes, err := updateVulnerabilities(datastore, vulnerabilities)
+	if err != nil {
+		log.WithError(err).Error("Unable to update vulnerabilities")
+		return err
+	}
 
-	defer func() {
+	if resp.Complete {
+		deleted, err := deleteObsoleteVulnerabilities(datastore, vulnerabilities)
 		if err != nil {
-			promUpdaterErrorsTotal.Inc()
+			log.WithError(err).Error("Unable to delete obsolete vulnerabilities")
+			return err
 		}
-	}()
-
-	if err != nil {
-		log.WithError(err).Error("Unable to update vulnerabilities")
-		return
+		changes = append(changes, deleted...)
 	}
 
 	if !firstUpdate {
 		err = createVulnerabilityNotifications(datastore, changes)
 		if err != nil {
 			log.WithError(err).Error("Unable to create notifications")
-			return
+			return err
+		}
+	}
+
+	if resp.FlagName != "" && resp.FlagValue != "" {
+		err = updateUpdaterFlags(datastore, map[string]string{resp.FlagName: resp.FlagValue})
+		if err != nil {
+			log.WithError(err).Error("Unable to update updater flags")
+			return err
 		}
 	}
 
-	err = updateUpdaterFlags(datastore, flags)
+	return nil
+}
+
+// dryRunUpdate fetches all the vulnerabilities from the registered fetchers
+// and logs, per updater, the changes that an update would make to the
+// database without writing them.
+func dryRunUpdate(datastore database.Datastore) {
+	log.Info("updating vulnerabilities (dry run)")
+
+	_, responses := fetchUpdaters(datastore, EnabledUpdaters)
+	names, vulnerabilities := addUpdatersMetadata(datastore, responses, false)
+
+	tx, err := datastore.Begin()
 	if err != nil {
-		log.WithError(err).Error("Unable to update updater flags")
+		log.WithError(err).Error("Unable to begin transaction")
 		return
 	}
+	defer tx.Rollback()
 
-	for _, note := range notes {
-		log.WithField("note", note).Warning("fetcher note")
-	}
-	promUpdaterNotesTotal.Set(float64(len(notes)))
+	for _, name := range names {
+		vulns := vulnerabilities[name]
 
-	if success {
-		err = setLastUpdateTime(datastore)
-		if err != nil {
-			log.WithError(err).Error("Unable to set last update time")
-			return
+		namespaces := make(map[string]struct{})
+		for _, vuln := range vulns {
+			namespaces[vuln.Namespace.Name] = struct{}{}
+		}
+
+		var added, updated, deleted int
+		if len(vulns) > 0 {
+			changes, err := findStoredVulnerabilityChanges(tx, vulns)
+			if err != nil {
+				log.WithError(err).WithField("updater name", name).Error("Unable to compare vulnerabilities")
+				continue
+			}
+
+			if responses[name].Complete {
+				obsolete, err := findObsoleteVulnerabilities(tx, vulns)
+				if err != nil {
+					log.WithError(err).WithField("updater name", name).Error("Unable to find obsolete vulnerabilities")
+					continue
+				}
+				changes = append(changes, obsolete...)
+			}
+
+			for _, change := range changes {
+				switch {
+				case change.old == nil:
+					added++
+				case change.new == nil:
+					deleted++
+				default:
+					updated++
+				}
+			}
 		}
+
+		log.WithFields(log.Fields{
+			"updater name":    name,
+			"namespaces":      len(namespaces),
+			"vulnerabilities": len(vulns),
+			"added":           added,
+			"updated":         updated,
+			"deleted":         deleted,
+		}).Info("dry run: vulnerability changes")
 	}
 
-	log.Info("update finished")
+	log.Info("update finished (dry run)")
 }
 
 func setUpdaterDuration(start time.Time) {
 	promUpdaterDurationSeconds.Set(time.Since(start).Seconds())
 }
 
-// fetch get data from the registered fetchers, in parallel.
-func fetch(datastore database.Datastore) (bool, []database.VulnerabilityWithAffected, map[string]string, []string) {
-	var vulnerabilities []database.VulnerabilityWithAffected
-	var notes []string
-	status := true
-	flags := make(map[string]string)
+// addUpdatersMetadata adds metadata to the vulnerabilities of all the updater
+// responses at once and splits the result back by updater, whose names are
+// returned sorted.
+func addUpdatersMetadata(datastore database.Datastore, responses map[string]vulnsrc.UpdateResponse, refreshStored bool) ([]string, map[string][]database.VulnerabilityWithAffected) {
+	var (
+		names           []string
+		vulnerabilities []database.VulnerabilityWithAffected
+		bounds          = make(map[string][2]int)
+	)
+	for name, resp := range responses {
+		names = append(names, name)
+		bounds[name] = [2]int{len(vulnerabilities), len(vulnerabilities) + len(resp.Vulnerabilities)}
+		vulnerabilities = append(vulnerabilities, resp.Vulnerabilities...)
+	}
+	sort.Strings(names)
+	vulnerabilities = addMetadata(datastore, vulnerabilities, refreshStored)
+
+	vulnerabilitiesByUpdater := make(map[string][]database.VulnerabilityWithAffected, len(names))
+	for _, name := range names {
+		vulnerabilitiesByUpdater[name] = vulnerabilities[bounds[name][0]:bounds[name][1]]
+	}
+
+	return names, vulnerabilitiesByUpdater
+}
+
+type updaterResponse struct {
+	name     string
+	response *vulnsrc.UpdateResponse
+}
+
+// fetchUpdaters gets data from the given enabled updaters, in parallel, and
+// returns their namespaced responses by updater name. Updaters that failed are
+// not part of the responses, their sorted names are returned instead.
+//
+// At most updaterConcurrency updaters fetch at the same time, unless it is 0.
+func fetchUpdaters(datastore database.Datastore, names []string) ([]string, map[string]vulnsrc.UpdateResponse) {
+	var failed []string
+	responses := make(map[string]vulnsrc.UpdateResponse)
 
 	// Fetch updates in parallel.
 	log.Info("fetching vulnerability updates")
-	var responseC = make(chan *vulnsrc.UpdateResponse, 0)
+	toFetch := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		toFetch[name] = struct{}{}
+	}
+
+	var slots chan struct{}
+	if updaterConcurrency > 0 {
+		slots = make(chan struct{}, updaterConcurrency)
+	}
+
+	var responseC = make(chan updaterResponse, 0)
 	numUpdaters := 0
 	for n, u := range vulnsrc.Updaters() {
-		if !updaterEnabled(n) {
+		if _, ok := toFetch[n]; !ok || !updaterEnabled(n) {
 			continue
 		}
 		numUpdaters++
 		go func(name string, u vulnsrc.Updater) {
+			if slots != nil {
+				slots <- struct{}{}
+				defer func() { <-slots }()
+			}
+
 			response, err := u.Update(datastore)
 			if err != nil {
 				promUpdaterErrorsTotal.Inc()
 				log.WithError(err).WithField("updater name", name).Error("an error occurred when fetching update")
-				status = false
-				responseC <- nil
+				responseC <- updaterResponse{name: name}
 				return
 			}
 
-			responseC <- &response
+			responseC <- updaterResponse{name: name, response: &response}
 			log.WithField("updater name", name).Info("finished fetching")
 		}(n, u)
 	}
@@ -294,23 +830,27 @@ func fetch(datastore database.Datastore) (bool, []database.VulnerabilityWithAffe
 	// Collect results of updates.
 	for i := 0; i < numUpdaters; i++ {
 		resp := <-responseC
-		if resp != nil {
-			vulnerabilities = append(vulnerabilities, doVulnerabilitiesNamespacing(resp.Vulnerabilities)...)
-			notes = append(notes, resp.Notes...)
-			if resp.FlagName != "" && resp.FlagValue != "" {
-				flags[resp.FlagName] = resp.FlagValue
-			}
+		if resp.response == nil {
+			failed = append(failed, resp.name)
+			continue
 		}
+
+		resp.response.Vulnerabilities = doVulnerabilitiesNamespacing(resp.response.Vulnerabilities)
+		responses[resp.name] = *resp.response
 	}
 
 	close(responseC)
-	return status, addMetadata(datastore, vulnerabilities), flags, notes
+	sort.Strings(failed)
+	return failed, responses
 }
 
 // Add metadata to the specified vulnerabilities using the registered
 // MetadataFetchers, in parallel.
-func addMetadata(datastore database.Datastore, vulnerabilities []database.VulnerabilityWithAffected) []database.VulnerabilityWithAffected {
-	if len(vulnmdsrc.Appenders()) == 0 || len(vulnerabilities) == 0 {
+//
+// If refreshStored is true, the metadata that incremental appenders report as
+// modified is also updated on the vulnerabilities already in the database.
+func addMetadata(datastore database.Datastore, vulnerabilities []database.VulnerabilityWithAffected, refreshStored bool) []database.VulnerabilityWithAffected {
+	if len(vulnmdsrc.Appenders()) == 0 || (len(vulnerabilities) == 0 && !refreshStored) {
 		return vulnerabilities
 	}
 
@@ -344,6 +884,10 @@ func addMetadata(datastore database.Datastore, vulnerabilities []database.Vulner
 				appender.Append(vulnerability.Name, vulnerability.appendFunc)
 			}
 
+			if incrementalAppender, ok := appender.(vulnmdsrc.IncrementalAppender); ok && refreshStored {
+				refreshStoredMetadata(datastore, name, incrementalAppender)
+			}
+
 			// Purge the metadata cache.
 			appender.PurgeCache()
 		}(n, a)
@@ -354,6 +898,43 @@ func addMetadata(datastore database.Datastore, vulnerabilities []database.Vulner
 	return vulnerabilities
 }
 
+// refreshStoredMetadata updates the metadata of the vulnerabilities in the
+// database that the appender reports as modified.
+func refreshStoredMetadata(datastore database.Datastore, name string, appender vulnmdsrc.IncrementalAppender) {
+	modified := appender.Modified()
+	if len(modified) == 0 {
+		return
+	}
+
+	tx, err := datastore.Begin()
+	if err != nil {
+		log.WithError(err).Error("Unable to begin transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	for _, vulnName := range modified {
+		appender.Append(vulnName, func(metadataKey string, metadata interface{}, _ database.Severity) {
+			if err == nil {
+				err = tx.UpdateVulnerabilityMetadata(vulnName, metadataKey, metadata)
+			}
+		})
+
+		if err != nil {
+			promUpdaterErrorsTotal.Inc()
+			log.WithError(err).WithFields(log.Fields{"appender name": name, "vulnerability": vulnName}).Error("could not update stored vulnerability metadata")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.WithError(err).WithField("appender name", name).Error("could not update stored vulnerability metadata")
+		return
+	}
+
+	log.WithFields(log.Fields{"appender name": name, "count": len(modified)}).Info("updated stored vulnerability metadata")
+}
+
 // GetLastUpdateTime retrieves the latest successful time of update and whether
 // or not it's the first update.
 func GetLastUpdateTime(datastore database.Datastore) (time.Time, bool, error) {
@@ -381,6 +962,90 @@ func GetLastUpdateTime(datastore database.Datastore) (time.Time, bool, error) {
 	return time.Unix(lastUpdateTS, 0).UTC(), false, nil
 }
 
+// UpdaterStatus is the state of the updater as recorded in the database.
+type UpdaterStatus struct {
+	// HasRun is false if no update has ever been started.
+	HasRun bool
+
+	// LastStart is the time at which the last update started.
+	LastStart time.Time
+
+	// LastSuccess is the time at which the last successful update finished.
+	// It is zero if no update has ever succeeded.
+	LastSuccess time.Time
+
+	// LastError is the error of the last update. It is empty if the last
+	// update succeeded or is still in progress.
+	LastError string
+
+	// InProgress is true if an instance of Clair holds the updater lock.
+	InProgress bool
+
+	// LockHolder is the name of the instance of Clair holding the updater
+	// lock, until LockExpiration unless it refreshes it. They are only set
+	// while an update is in progress.
+	LockHolder     string
+	LockExpiration time.Time
+}
+
+// GetUpdaterStatus retrieves the state of the updater from the database.
+func GetUpdaterStatus(datastore database.Datastore) (UpdaterStatus, error) {
+	var status UpdaterStatus
+
+	lastSuccess, firstUpdate, err := GetLastUpdateTime(datastore)
+	if err != nil {
+		return status, err
+	}
+	if !firstUpdate {
+		status.HasRun = true
+		status.LastSuccess = lastSuccess
+	}
+
+	tx, err := datastore.Begin()
+	if err != nil {
+		return status, err
+	}
+	defer tx.Rollback()
+
+	lastStart, ok, err := tx.FindKeyValue(updaterLastStartFlagName)
+	if err != nil {
+		return status, err
+	}
+
+	if ok {
+		lastStartTS, err := strconv.ParseInt(lastStart, 10, 64)
+		if err != nil {
+			return status, err
+		}
+		status.HasRun = true
+		status.LastStart = time.Unix(lastStartTS, 0).UTC()
+	}
+
+	status.LastError, _, err = tx.FindKeyValue(updaterLastErrorFlagName)
+	if err != nil {
+		return status, err
+	}
+
+	// The lock is not found when no update is running.
+	lockOwner, lockExpiration, ok, err := tx.FindLock(updaterLockName)
+	if err != nil && err != commonerr.ErrNotFound {
+		return status, err
+	}
+	status.InProgress = ok && lockExpiration.After(time.Now())
+	if status.InProgress {
+		status.LockHolder = updaterLockHolder(lockOwner)
+		status.LockExpiration = lockExpiration
+	}
+
+	// The error of the previous update is not relevant while a new update is
+	// running.
+	if status.InProgress {
+		status.LastError = ""
+	}
+
+	return status, nil
+}
+
 type lockableVulnerability struct {
 	*database.VulnerabilityWithAffected
 	sync.Mutex
@@ -433,6 +1098,25 @@ func doVulnerabilitiesNamespacing(vulnerabilities []database.VulnerabilityWithAf
 				}).Warn("Mal-formated affected feature (skipped)")
 				continue
 			}
+
+			if fv.IntroducedInVersion != "" && versionfmt.Valid(fv.Namespace.VersionFormat, fv.IntroducedInVersion) != nil {
+				log.WithFields(log.Fields{
+					"Name":          fv.FeatureName,
+					"Introduced In": fv.IntroducedInVersion,
+					"Namespace":     fv.Namespace.Name + ":" + fv.Namespace.VersionFormat,
+				}).Warn("Mal-formated affected feature introduced version (skipped)")
+				continue
+			}
+
+			if fv.LastAffectedVersion != "" && versionfmt.Valid(fv.Namespace.VersionFormat, fv.LastAffectedVersion) != nil {
+				log.WithFields(log.Fields{
+					"Name":          fv.FeatureName,
+					"Last Affected": fv.LastAffectedVersion,
+					"Namespace":     fv.Namespace.Name + ":" + fv.Namespace.VersionFormat,
+				}).Warn("Mal-formated affected feature last affected version (skipped)")
+				continue
+			}
+
 			index := fv.Namespace.Name + ":" + v.Name
 
 			if vulnerability, ok := vulnerabilitiesMap[index]; !ok {
@@ -510,19 +1194,127 @@ func setLastUpdateTime(datastore database.Datastore) error {
 	return tx.Commit()
 }
 
+// getSourceLastUpdateTime retrieves the latest successful time of update of an
+// updater that has its own schedule, and whether it has ever been updated.
+func getSourceLastUpdateTime(datastore database.Datastore, name string) (time.Time, bool, error) {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer tx.Rollback()
+
+	value, ok, err := tx.FindKeyValue(updaterLastSourceFlagPrefix + name)
+	if err != nil || !ok || value == "" {
+		return time.Time{}, false, err
+	}
+
+	ts, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return time.Unix(ts, 0).UTC(), true, nil
+}
+
+// setSourceLastUpdateTime records the last successful date time of update of
+// an updater that has its own schedule in database.
+func setSourceLastUpdateTime(datastore database.Datastore, name string) error {
+	return updateUpdaterFlags(datastore, map[string]string{
+		updaterLastSourceFlagPrefix + name: strconv.FormatInt(time.Now().UTC().Unix(), 10),
+	})
+}
+
+// setLastUpdateStart records the date time at which the last update started in
+// database.
+func setLastUpdateStart(datastore database.Datastore) error {
+	return updateUpdaterFlags(datastore, map[string]string{
+		updaterLastStartFlagName: strconv.FormatInt(time.Now().UTC().Unix(), 10),
+	})
+}
+
+// setLastUpdateError records the error of the last update in database. An
+// empty value means that the last update has succeeded.
+func setLastUpdateError(datastore database.Datastore, updateErr error) error {
+	var value string
+	if updateErr != nil {
+		value = updateErr.Error()
+	}
+
+	return updateUpdaterFlags(datastore, map[string]string{updaterLastErrorFlagName: value})
+}
+
+// updaterCheckpoint records the progress of an update cycle, which is only
+// complete once every enabled updater has stored its vulnerabilities.
+type updaterCheckpoint struct {
+	// Start is the Unix time at which the cycle started.
+	Start int64 `json:"start"`
+	// Completed lists the updaters that have completed the cycle.
+	Completed []string `json:"completed"`
+}
+
+func newUpdaterCheckpoint() updaterCheckpoint {
+	return updaterCheckpoint{Start: time.Now().UTC().Unix()}
+}
+
+// findUpdaterCheckpoint retrieves the checkpoint of the current update cycle
+// in database. A new cycle is started if there is none, or if the current one
+// started more than an interval ago, so that the completed updaters are not
+// kept out of date by a failing one.
+func findUpdaterCheckpoint(datastore database.Datastore, interval time.Duration) (updaterCheckpoint, error) {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return updaterCheckpoint{}, err
+	}
+	defer tx.Rollback()
+
+	value, ok, err := tx.FindKeyValue(updaterCheckpointFlagName)
+	if err != nil {
+		return updaterCheckpoint{}, err
+	}
+
+	if !ok || value == "" {
+		return newUpdaterCheckpoint(), nil
+	}
+
+	var checkpoint updaterCheckpoint
+	if err := json.Unmarshal([]byte(value), &checkpoint); err != nil {
+		return updaterCheckpoint{}, err
+	}
+
+	if time.Since(time.Unix(checkpoint.Start, 0)) > interval {
+		return newUpdaterCheckpoint(), nil
+	}
+
+	return checkpoint, nil
+}
+
+// setUpdaterCheckpoint records the checkpoint of the current update cycle in
+// database.
+func setUpdaterCheckpoint(datastore database.Datastore, checkpoint updaterCheckpoint) error {
+	value, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	return updateUpdaterFlags(datastore, map[string]string{updaterCheckpointFlagName: string(value)})
+}
+
 // isVulnerabilityChange compares two vulnerabilities by their severity and
 // affected features, and return true if they are different.
 func isVulnerabilityChanged(a *database.VulnerabilityWithAffected, b *database.VulnerabilityWithAffected) bool {
 	if a == b {
 		return false
 	} else if a != nil && b != nil && a.Severity == b.Severity && len(a.Affected) == len(b.Affected) {
+		// A vulnerability may affect several ranges of versions of a feature,
+		// which are told apart by the version introducing them. Tagging an
+		// affected feature changes how it is reported, so it is a change too.
 		checked := map[string]bool{}
 		for _, affected := range a.Affected {
-			checked[affected.Namespace.Name+":"+affected.FeatureName] = false
+			checked[affected.Namespace.Name+":"+affected.FeatureName+":"+affected.IntroducedInVersion+":"+affected.Tag] = false
 		}
 
 		for _, affected := range b.Affected {
-			key := affected.Namespace.Name + ":" + affected.FeatureName
+			key := affected.Namespace.Name + ":" + affected.FeatureName + ":" + affected.IntroducedInVersion + ":" + affected.Tag
 			if visited, ok := checked[key]; !ok || visited {
 				return true
 			}
@@ -619,12 +1411,9 @@ func createVulnerabilityNotifications(datastore database.Datastore, changes []vu
 
 // updateVulnerabilities upserts unique vulnerabilities into the database and
 // computes vulnerability changes.
-func updateVulnerabilities(datastore database.Datastore, vulnerabilities []database.VulnerabilityWithAffected) ([]vulnerabilityChange, error) {
-	log.WithField("count", len(vulnerabilities)).Debug("updating vulnerabilities")
-	if len(vulnerabilities) == 0 {
-		return nil, nil
-	}
-
+// findStoredVulnerabilityChanges compares the given vulnerabilities with their
+// version in the database.
+func findStoredVulnerabilityChanges(tx database.Session, vulnerabilities []database.VulnerabilityWithAffected) ([]vulnerabilityChange, error) {
 	ids := make([]database.VulnerabilityID, 0, len(vulnerabilities))
 	for _, vuln := range vulnerabilities {
 		ids = append(ids, database.VulnerabilityID{
@@ -633,12 +1422,6 @@ func updateVulnerabilities(datastore database.Datastore, vulnerabilities []datab
 		})
 	}
 
-	tx, err := datastore.Begin()
-	if err != nil {
-		return nil, err
-	}
-
-	defer tx.Rollback()
 	oldVulnNullable, err := tx.FindVulnerabilities(ids)
 	if err != nil {
 		return nil, err
@@ -651,7 +1434,22 @@ func updateVulnerabilities(datastore database.Datastore, vulnerabilities []datab
 		}
 	}
 
-	changes, err := findVulnerabilityChanges(oldVuln, vulnerabilities)
+	return findVulnerabilityChanges(oldVuln, vulnerabilities)
+}
+
+func updateVulnerabilities(datastore database.Datastore, vulnerabilities []database.VulnerabilityWithAffected) ([]vulnerabilityChange, error) {
+	log.WithField("count", len(vulnerabilities)).Debug("updating vulnerabilities")
+	if len(vulnerabilities) == 0 {
+		return nil, nil
+	}
+
+	tx, err := datastore.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+	changes, err := findStoredVulnerabilityChanges(tx, vulnerabilities)
 	if err != nil {
 		return nil, err
 	}
@@ -688,6 +1486,86 @@ func updateVulnerabilities(datastore database.Datastore, vulnerabilities []datab
 	return changes, nil
 }
 
+// obsoleteWithdrawalReason is the reason of the withdrawal of the
+// vulnerabilities removed from their data source.
+const obsoleteWithdrawalReason = "removed from the data source"
+
+// findObsoleteVulnerabilities returns the deletion of the stored
+// vulnerabilities that belong to the namespaces of the given vulnerabilities,
+// but are not part of them.
+func findObsoleteVulnerabilities(tx database.Session, vulnerabilities []database.VulnerabilityWithAffected) ([]vulnerabilityChange, error) {
+	current := make(map[database.VulnerabilityID]struct{}, len(vulnerabilities))
+	namespaces := make(map[string]struct{})
+	for _, vuln := range vulnerabilities {
+		current[database.VulnerabilityID{Name: vuln.Name, Namespace: vuln.Namespace.Name}] = struct{}{}
+		namespaces[vuln.Namespace.Name] = struct{}{}
+	}
+
+	changes := []vulnerabilityChange{}
+	for namespace := range namespaces {
+		err := tx.WalkVulnerabilities(namespace, time.Time{}, func(vuln database.VulnerabilityWithAffected) error {
+			if _, ok := current[database.VulnerabilityID{Name: vuln.Name, Namespace: vuln.Namespace.Name}]; !ok {
+				changes = append(changes, vulnerabilityChange{old: &vuln})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+// deleteObsoleteVulnerabilities withdraws the stored vulnerabilities that a
+// complete update does not have anymore and returns their changes. They are
+// kept for history, but do not affect any feature anymore.
+//
+// Only the namespaces of the update are affected, so that a source never
+// deletes the vulnerabilities of another one, nor the ones of a namespace that
+// it does not return at all, which is more likely a broken feed than the
+// withdrawal of all its vulnerabilities.
+func deleteObsoleteVulnerabilities(datastore database.Datastore, vulnerabilities []database.VulnerabilityWithAffected) ([]vulnerabilityChange, error) {
+	if len(vulnerabilities) == 0 {
+		log.Warning("skipping the deletion of obsolete vulnerabilities of an empty update")
+		return nil, nil
+	}
+
+	tx, err := datastore.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	changes, err := findObsoleteVulnerabilities(tx, vulnerabilities)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	toRemove := make([]database.VulnerabilityID, 0, len(changes))
+	for _, change := range changes {
+		toRemove = append(toRemove, database.VulnerabilityID{
+			Name:      change.old.Name,
+			Namespace: change.old.Namespace.Name,
+		})
+	}
+
+	log.WithField("count", len(toRemove)).Info("withdrawing obsolete vulnerabilities")
+	if err := tx.WithdrawVulnerabilities(toRemove, obsoleteWithdrawalReason); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
 func updaterEnabled(updaterName string) bool {
 	for _, u := range EnabledUpdaters {
 		if u == updaterName {
@@ -17,11 +17,20 @@ package clair
 import (
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/robfig/cron"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/coreos/clair/database"
+	"github.com/coreos/clair/ext/versionfmt/dpkg"
+	"github.com/coreos/clair/ext/vulnsrc"
+
+	// Register the in-memory datastore.
+	_ "github.com/coreos/clair/database/mem"
 )
 
 type mockUpdaterDatastore struct {
@@ -138,6 +147,17 @@ func newmockUpdaterDatastore() *mockUpdaterDatastore {
 			return r, nil
 		}
 
+		session.FctWalkVulnerabilities = func(namespace string, since time.Time, fn func(database.VulnerabilityWithAffected) error) error {
+			for _, vuln := range session.copy.vulnerabilities {
+				if vuln.Namespace.Name == namespace {
+					if err := fn(vuln); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+
 		session.FctDeleteVulnerabilities = func(ids []database.VulnerabilityID) error {
 			for _, id := range ids {
 				delete(session.copy.vulnerabilities, id)
@@ -145,6 +165,10 @@ func newmockUpdaterDatastore() *mockUpdaterDatastore {
 			return nil
 		}
 
+		session.FctWithdrawVulnerabilities = func(ids []database.VulnerabilityID, reason string) error {
+			return session.FctDeleteVulnerabilities(ids)
+		}
+
 		session.FctInsertVulnerabilities = func(vulnerabilities []database.VulnerabilityWithAffected) error {
 			for _, vuln := range vulnerabilities {
 				id := database.VulnerabilityID{
@@ -176,6 +200,10 @@ func newmockUpdaterDatastore() *mockUpdaterDatastore {
 			return nil
 		}
 
+		session.FctFindLock = func(name string) (string, time.Time, bool, error) {
+			return "", time.Time{}, false, nil
+		}
+
 		return session, nil
 	}
 	return md
@@ -305,6 +333,293 @@ func TestCreatVulnerabilityNotification(t *testing.T) {
 	}
 }
 
+func TestGetUpdaterStatus(t *testing.T) {
+	datastore := newmockUpdaterDatastore()
+
+	status, err := GetUpdaterStatus(datastore)
+	if assert.Nil(t, err) {
+		assert.False(t, status.HasRun)
+		assert.True(t, status.LastStart.IsZero())
+		assert.True(t, status.LastSuccess.IsZero())
+	}
+
+	assert.Nil(t, setLastUpdateStart(datastore))
+	assert.Nil(t, setLastUpdateError(datastore, errors.New("could not download")))
+
+	status, err = GetUpdaterStatus(datastore)
+	if assert.Nil(t, err) {
+		assert.True(t, status.HasRun)
+		assert.False(t, status.LastStart.IsZero())
+		assert.True(t, status.LastSuccess.IsZero())
+		assert.Equal(t, "could not download", status.LastError)
+	}
+
+	assert.Nil(t, setLastUpdateStart(datastore))
+	assert.Nil(t, setLastUpdateTime(datastore))
+	assert.Nil(t, setLastUpdateError(datastore, nil))
+
+	status, err = GetUpdaterStatus(datastore)
+	if assert.Nil(t, err) {
+		assert.True(t, status.HasRun)
+		assert.False(t, status.LastSuccess.IsZero())
+		assert.Empty(t, status.LastError)
+		assert.False(t, status.InProgress)
+	}
+}
+
+func TestGetUpdaterStatusWithoutLock(t *testing.T) {
+	// The datastores do not find the lock when no update is running.
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "mem"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	status, err := GetUpdaterStatus(datastore)
+	if assert.Nil(t, err) {
+		assert.False(t, status.HasRun)
+		assert.False(t, status.InProgress)
+	}
+}
+
+func TestGetUpdaterStatusLockHolder(t *testing.T) {
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "mem"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	defer configureUpdaterLock(&UpdaterConfig{})
+	configureUpdaterLock(&UpdaterConfig{LockDuration: 5 * time.Minute, InstanceName: "clair-0"})
+	assert.Equal(t, 4*time.Minute, updaterLockRefreshDuration)
+
+	owner := updaterLockOwner()
+	assert.NotEqual(t, owner, updaterLockOwner())
+	locked, until := lock(datastore, updaterLockName, owner, updaterLockDuration, false)
+	require.True(t, locked)
+
+	// The other instances skip the update while the lock is held.
+	other := "clair-1/" + owner[len("clair-0/"):]
+	locked, _ = lock(datastore, updaterLockName, other, updaterLockDuration, false)
+	assert.False(t, locked)
+
+	status, err := GetUpdaterStatus(datastore)
+	if assert.Nil(t, err) {
+		assert.True(t, status.InProgress)
+		assert.Equal(t, "clair-0", status.LockHolder)
+		assert.WithinDuration(t, until, status.LockExpiration, time.Second)
+	}
+
+	unlock(datastore, updaterLockName, owner)
+	status, err = GetUpdaterStatus(datastore)
+	if assert.Nil(t, err) {
+		assert.False(t, status.InProgress)
+		assert.Empty(t, status.LockHolder)
+	}
+}
+
+type mockUpdater struct {
+	calls int
+	err   error
+	vuln  database.VulnerabilityWithAffected
+}
+
+func (u *mockUpdater) Update(database.Datastore) (vulnsrc.UpdateResponse, error) {
+	u.calls++
+	if u.err != nil {
+		return vulnsrc.UpdateResponse{}, u.err
+	}
+	return vulnsrc.UpdateResponse{Vulnerabilities: []database.VulnerabilityWithAffected{u.vuln}}, nil
+}
+
+func (u *mockUpdater) Clean() {}
+
+func TestDoUpdateResume(t *testing.T) {
+	ns := database.Namespace{Name: "resume:1", VersionFormat: dpkg.ParserName}
+	affected := []database.AffectedFeature{{AffectedType: database.AffectBinaryPackage, Namespace: ns, FeatureName: "openssl", AffectedVersion: "1.0", FixedInVersion: "1.0"}}
+	u1 := &mockUpdater{vuln: database.VulnerabilityWithAffected{Vulnerability: database.Vulnerability{Name: "CVE-1", Namespace: ns, Severity: database.HighSeverity}, Affected: affected}}
+	u2 := &mockUpdater{vuln: database.VulnerabilityWithAffected{Vulnerability: database.Vulnerability{Name: "CVE-2", Namespace: ns, Severity: database.LowSeverity}, Affected: affected}, err: errors.New("feed is down")}
+	vulnsrc.RegisterUpdater("resume-1", u1)
+	vulnsrc.RegisterUpdater("resume-2", u2)
+
+	defer func(enabled []string) { EnabledUpdaters = enabled }(EnabledUpdaters)
+	EnabledUpdaters = []string{"resume-1", "resume-2"}
+
+	datastore := newmockUpdaterDatastore()
+
+	// The completed updater is checkpointed while the failed one is not.
+	assert.NotNil(t, doUpdate(datastore, true, time.Hour))
+	assert.Len(t, datastore.vulnerabilities, 1)
+	checkpoint, err := findUpdaterCheckpoint(datastore, time.Hour)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"resume-1"}, checkpoint.Completed)
+	}
+
+	// The cycle resumes with the failed updater only.
+	u2.err = nil
+	assert.Nil(t, doUpdate(datastore, true, time.Hour))
+	assert.Equal(t, 1, u1.calls)
+	assert.Equal(t, 2, u2.calls)
+	assert.Len(t, datastore.vulnerabilities, 2)
+
+	// The completed cycle resets the checkpoint.
+	checkpoint, err = findUpdaterCheckpoint(datastore, time.Hour)
+	if assert.Nil(t, err) {
+		assert.Empty(t, checkpoint.Completed)
+	}
+
+	assert.Nil(t, doUpdate(datastore, false, time.Hour))
+	assert.Equal(t, 2, u1.calls)
+	assert.Equal(t, 3, u2.calls)
+
+	// An outdated cycle is not resumed.
+	assert.Nil(t, setUpdaterCheckpoint(datastore, updaterCheckpoint{
+		Start:     time.Now().Add(-2 * time.Hour).Unix(),
+		Completed: []string{"resume-1"},
+	}))
+	checkpoint, err = findUpdaterCheckpoint(datastore, time.Hour)
+	if assert.Nil(t, err) {
+		assert.Empty(t, checkpoint.Completed)
+	}
+}
+
+func TestUpdateOwnInterval(t *testing.T) {
+	ns := database.Namespace{Name: "interval:1", VersionFormat: dpkg.ParserName}
+	affected := []database.AffectedFeature{{AffectedType: database.AffectBinaryPackage, Namespace: ns, FeatureName: "openssl", AffectedVersion: "1.0", FixedInVersion: "1.0"}}
+	u1 := &mockUpdater{vuln: database.VulnerabilityWithAffected{Vulnerability: database.Vulnerability{Name: "CVE-1", Namespace: ns, Severity: database.HighSeverity}, Affected: affected}}
+	u2 := &mockUpdater{vuln: database.VulnerabilityWithAffected{Vulnerability: database.Vulnerability{Name: "CVE-2", Namespace: ns, Severity: database.LowSeverity}, Affected: affected}, err: errors.New("feed is down")}
+	vulnsrc.RegisterUpdater("interval-1", u1)
+	vulnsrc.RegisterUpdater("interval-2", u2)
+
+	defer func(enabled []string, schedules map[string]cron.Schedule) {
+		EnabledUpdaters, updaterSchedules = enabled, schedules
+	}(EnabledUpdaters, updaterSchedules)
+	EnabledUpdaters = []string{"interval-1", "interval-2"}
+	updaterSchedules = map[string]cron.Schedule{"interval-2": cron.Every(time.Hour)}
+
+	datastore := newmockUpdaterDatastore()
+
+	// The updater with its own interval is not part of the update cycle.
+	assert.Nil(t, doUpdate(datastore, true, time.Hour))
+	assert.Equal(t, 1, u1.calls)
+	assert.Equal(t, 0, u2.calls)
+
+	// Its update time is only recorded once it succeeds.
+	updateSource(datastore, "interval-2")
+	_, ok, err := getSourceLastUpdateTime(datastore, "interval-2")
+	if assert.Nil(t, err) {
+		assert.False(t, ok)
+	}
+
+	u2.err = nil
+	updateSource(datastore, "interval-2")
+	assert.Equal(t, 2, u2.calls)
+	assert.Len(t, datastore.vulnerabilities, 2)
+
+	lastUpdate, ok, err := getSourceLastUpdateTime(datastore, "interval-2")
+	if assert.Nil(t, err) && assert.True(t, ok) {
+		assert.WithinDuration(t, time.Now(), lastUpdate, time.Minute)
+	}
+
+	// The other updaters are not affected by its update.
+	_, ok, err = getSourceLastUpdateTime(datastore, "interval-1")
+	if assert.Nil(t, err) {
+		assert.False(t, ok)
+	}
+	assert.Equal(t, 1, u1.calls)
+}
+
+// concurrentUpdater records the highest number of updaters fetching at the
+// same time.
+type concurrentUpdater struct {
+	running, max *int32
+	err          error
+}
+
+func (u concurrentUpdater) Update(database.Datastore) (vulnsrc.UpdateResponse, error) {
+	n := atomic.AddInt32(u.running, 1)
+	defer atomic.AddInt32(u.running, -1)
+	for {
+		max := atomic.LoadInt32(u.max)
+		if n <= max || atomic.CompareAndSwapInt32(u.max, max, n) {
+			break
+		}
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	return vulnsrc.UpdateResponse{}, u.err
+}
+
+func (u concurrentUpdater) Clean() {}
+
+func TestFetchUpdatersConcurrency(t *testing.T) {
+	var running, max int32
+	names := []string{"concurrent-1", "concurrent-2", "concurrent-3", "concurrent-4", "concurrent-5"}
+	for i, name := range names {
+		u := concurrentUpdater{running: &running, max: &max}
+		if i%2 == 1 {
+			u.err = errors.New("feed is down")
+		}
+		vulnsrc.RegisterUpdater(name, u)
+	}
+
+	defer func(enabled []string, concurrency int) {
+		EnabledUpdaters, updaterConcurrency = enabled, concurrency
+	}(EnabledUpdaters, updaterConcurrency)
+	EnabledUpdaters = names
+
+	// The failed updaters do not prevent the others from being fetched.
+	updaterConcurrency = 2
+	failed, responses := fetchUpdaters(newmockUpdaterDatastore(), names)
+	assert.Equal(t, []string{"concurrent-2", "concurrent-4"}, failed)
+	assert.Len(t, responses, 3)
+	assert.Equal(t, int32(2), max)
+
+	// Without limit, the updaters are all fetched at once.
+	max = 0
+	updaterConcurrency = 0
+	failed, responses = fetchUpdaters(newmockUpdaterDatastore(), names)
+	assert.Len(t, failed, 2)
+	assert.Len(t, responses, 3)
+	assert.Equal(t, int32(len(names)), max)
+}
+
+func TestDeleteObsoleteVulnerabilities(t *testing.T) {
+	ns1 := database.Namespace{Name: "obsolete:1", VersionFormat: dpkg.ParserName}
+	ns2 := database.Namespace{Name: "obsolete:2", VersionFormat: dpkg.ParserName}
+	newVuln := func(name string, ns database.Namespace) database.VulnerabilityWithAffected {
+		return database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{Name: name, Namespace: ns, Severity: database.HighSeverity},
+			Affected:      []database.AffectedFeature{{AffectedType: database.AffectBinaryPackage, Namespace: ns, FeatureName: "openssl", AffectedVersion: "1.0", FixedInVersion: "1.0"}},
+		}
+	}
+
+	datastore := newmockUpdaterDatastore()
+	for _, vuln := range []database.VulnerabilityWithAffected{newVuln("CVE-1", ns1), newVuln("CVE-2", ns1), newVuln("CVE-3", ns2)} {
+		datastore.vulnerabilities[database.VulnerabilityID{Name: vuln.Name, Namespace: vuln.Namespace.Name}] = vuln
+	}
+
+	// An empty update does not delete anything.
+	assert.Nil(t, persistUpdate(datastore, nil, vulnsrc.UpdateResponse{Complete: true}, false))
+	assert.Len(t, datastore.vulnerabilities, 3)
+
+	// An incremental update does not delete anything either.
+	update := []database.VulnerabilityWithAffected{newVuln("CVE-1", ns1)}
+	assert.Nil(t, persistUpdate(datastore, update, vulnsrc.UpdateResponse{}, false))
+	assert.Len(t, datastore.vulnerabilities, 3)
+
+	// A complete update only deletes the vulnerabilities of its namespaces.
+	assert.Nil(t, persistUpdate(datastore, update, vulnsrc.UpdateResponse{Complete: true}, false))
+	assert.Len(t, datastore.vulnerabilities, 2)
+	assert.Contains(t, datastore.vulnerabilities, database.VulnerabilityID{Name: "CVE-1", Namespace: ns1.Name})
+	assert.Contains(t, datastore.vulnerabilities, database.VulnerabilityID{Name: "CVE-3", Namespace: ns2.Name})
+
+	// The deletion is notified.
+	var deleted int
+	for _, notification := range datastore.vulnNotification {
+		if notification.New == nil && notification.Old != nil && notification.Old.Name == "CVE-2" {
+			deleted++
+		}
+	}
+	assert.Equal(t, 1, deleted)
+}
+
 func assertVulnerability(t *testing.T, expected database.VulnerabilityWithAffected, actual database.VulnerabilityWithAffected) bool {
 	expectedAF := expected.Affected
 	actualAF := actual.Affected
@@ -325,3 +640,46 @@ func assertVulnerability(t *testing.T, expected database.VulnerabilityWithAffect
 	}
 	return true
 }
+
+func TestUpdaterConfigSchedule(t *testing.T) {
+	from := time.Date(2018, 3, 1, 10, 20, 0, 0, time.UTC)
+
+	// The cron expression replaces the interval.
+	schedule, err := (&UpdaterConfig{Cron: "0 3 * * *", Interval: time.Hour}).Schedule()
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2018, 3, 2, 3, 0, 0, 0, time.UTC), schedule.Next(from))
+	assert.Equal(t, 24*time.Hour, schedulePeriod(schedule, from))
+
+	schedule, err = (&UpdaterConfig{Interval: 2 * time.Hour}).Schedule()
+	require.Nil(t, err)
+	assert.Equal(t, from.Add(2*time.Hour), schedule.Next(from))
+	assert.Equal(t, 2*time.Hour, schedulePeriod(schedule, from))
+
+	for _, config := range []UpdaterConfig{
+		{},
+		{Cron: "0 3 * *"},
+		{Cron: "0 0 30 2 *", Interval: time.Hour},
+	} {
+		_, err := config.Schedule()
+		assert.NotNil(t, err, config.Cron)
+	}
+
+	// The updaters overriding the schedule use the same parser.
+	schedules, err := (&UpdaterConfig{Cron: "0 3 * * *", Crons: map[string]string{"nvd": "@hourly", "oval": "0 4 * * *"}}).SourceSchedules()
+	require.Nil(t, err)
+	if assert.Len(t, schedules, 2) {
+		assert.Equal(t, time.Date(2018, 3, 1, 11, 0, 0, 0, time.UTC), schedules["nvd"].Next(from))
+		assert.Equal(t, time.Date(2018, 3, 2, 4, 0, 0, 0, time.UTC), schedules["oval"].Next(from))
+	}
+
+	for _, expr := range []string{"0 3 * *", "0 0 30 2 *", "1h"} {
+		_, err := (&UpdaterConfig{Crons: map[string]string{"nvd": expr}}).SourceSchedules()
+		assert.NotNil(t, err, expr)
+	}
+
+	// An interval of 0 without cron expression disables the updater.
+	assert.True(t, (&UpdaterConfig{}).IsDisabled())
+	assert.True(t, (&UpdaterConfig{Interval: time.Hour, Disabled: true}).IsDisabled())
+	assert.False(t, (&UpdaterConfig{Cron: "@daily"}).IsDisabled())
+	assert.False(t, (&UpdaterConfig{Interval: time.Hour}).IsDisabled())
+}
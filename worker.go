@@ -15,17 +15,25 @@
 package clair
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/deckarep/golang-set"
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/coreos/clair/database"
 	"github.com/coreos/clair/ext/featurefmt"
 	"github.com/coreos/clair/ext/featurens"
 	"github.com/coreos/clair/ext/imagefmt"
+	"github.com/coreos/clair/ext/objectstore"
 	"github.com/coreos/clair/pkg/commonerr"
+	"github.com/coreos/clair/pkg/logutil"
 	"github.com/coreos/clair/pkg/strutil"
 	"github.com/coreos/clair/pkg/tarutil"
 )
@@ -37,14 +45,120 @@ var (
 
 	// EnabledDetectors are detectors to be used to scan the layers.
 	EnabledDetectors []database.Detector
+
+	workerConfig WorkerConfig
+
+	// analyzedLayers caches the analyzed layers when
+	// workerConfig.LayerCacheSize is set.
+	analyzedLayers *layerCache
+
+	// extractedFiles caches on disk the files extracted from the layers when
+	// workerConfig.FilesCacheDir is set.
+	extractedFiles *filesCache
+
+	promLayerAnalysisDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "clair_worker_layer_analysis_duration_seconds",
+		Help:    "Time it takes to analyze a layer, by the feature listers and namespace detectors that found content in it.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{"lister", "detector"})
+
+	promLayerAnalysisErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "clair_worker_layer_analysis_errors_total",
+		Help: "Number of errors that layer analyses generated, by step.",
+	}, []string{"type"})
+
+	promLayerAnalysesInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "clair_worker_layer_analyses_in_flight",
+		Help: "Number of layers being analyzed.",
+	})
 )
 
+func init() {
+	prometheus.MustRegister(promLayerAnalysisDurationSeconds)
+	prometheus.MustRegister(promLayerAnalysisErrorsTotal)
+	prometheus.MustRegister(promLayerAnalysesInFlight)
+}
+
+// WorkerConfig is the configuration for the worker that analyzes the layers.
+type WorkerConfig struct {
+	// ListerConcurrency is the maximum number of feature listers run at the
+	// same time on a layer. The listers are run sequentially when it is not
+	// set.
+	ListerConcurrency int
+
+	// EnabledListers lists the feature listers run on the layers. All the
+	// registered listers but the opt-in ones, such as gobinary, are run when
+	// it is empty.
+	EnabledListers []string
+
+	// MaxExtractableFileSize and MaxExtractedSize are the maximum sizes, in
+	// bytes, of a file extracted from a layer and of all the files extracted
+	// from a layer. The tarutil defaults are used when they are not set.
+	MaxExtractableFileSize int64
+	MaxExtractedSize       int64
+
+	// MaxLayers is the maximum number of layers of an ancestry, or of the
+	// layers posted at once, which are rejected before any of them is
+	// analyzed otherwise. The number of layers is not limited when it is not
+	// set.
+	MaxLayers int
+
+	// LayerCacheSize is the maximum number of analyzed layers kept in memory,
+	// so that the layers submitted again are not read from the database. The
+	// layers are not cached when it is not set.
+	//
+	// LayerCacheTTL is how long a layer is cached, which is unlimited when it
+	// is not set. A cached layer is also read again from the database once
+	// it has not been analyzed by every enabled detector, e.g. after an
+	// upgrade.
+	LayerCacheSize int
+	LayerCacheTTL  time.Duration
+
+	// FilesCacheDir is the directory where the files extracted from the
+	// layers whose hash is a digest are cached, so that the base layers
+	// shared by many images are downloaded and extracted only once. The files
+	// are not cached when it is not set.
+	//
+	// FilesCacheSize is the maximum size, in bytes, of the cache, whose least
+	// recently used entries are evicted beyond it.
+	FilesCacheDir  string
+	FilesCacheSize int64
+
+	// DefaultNamespace, if set, is the namespace assumed for the features of
+	// an ancestry whose version format has no namespace detected in any of
+	// its layers, e.g. for the derivative base images without release files.
+	//
+	// The features are then matched against the vulnerabilities of a
+	// distribution that may not be the one of the image, so that it is only
+	// applied when it is configured explicitly, with a warning every time.
+	DefaultNamespace *database.Namespace
+}
+
 // LayerRequest represents all information necessary to download and process a
 // layer.
 type LayerRequest struct {
 	Hash    string
 	Path    string
 	Headers map[string]string
+
+	// Registry, if set, is the registry from which the layer is pulled, by
+	// its hash as the digest of its blob, when it has no path.
+	Registry *imagefmt.Registry
+
+	// ObjectStorage, if set, are the credentials reading the layer from object
+	// storage when its path is the URI of an object, e.g. "s3://bucket/key".
+	// Without them, the backends fall back to the credentials of their
+	// environment, if any.
+	ObjectStorage *objectstore.Credentials
+}
+
+// LayerResult is the result of the analysis of a layer by ProcessLayers.
+type LayerResult struct {
+	// Layer is the content of the layer, detected by the enabled detectors.
+	Layer database.Layer
+
+	// Err is the error that stopped the analysis at this layer.
+	Err error
 }
 
 type processResult struct {
@@ -61,15 +175,17 @@ type processRequest struct {
 	detectors     []database.Detector
 }
 
+// introducedFeature is a feature of an ancestry, under each of its candidate
+// namespaces, and the index of the layer that introduced it.
 type introducedFeature struct {
-	feature    database.AncestryFeature
+	features   []database.AncestryFeature
 	layerIndex int
 }
 
 // processRequests in parallel processes a set of requests for unique set of layers
 // and returns sets of unique namespaces, features and layers to be inserted
 // into the database.
-func processRequests(imageFormat string, toDetect map[string]*processRequest) (map[string]*processResult, error) {
+func processRequests(ctx context.Context, imageFormat string, toDetect map[string]*processRequest) (map[string]*processResult, error) {
 	wg := &sync.WaitGroup{}
 	wg.Add(len(toDetect))
 
@@ -82,39 +198,65 @@ func processRequests(imageFormat string, toDetect map[string]*processRequest) (m
 		result := processResult{}
 		results[i] = &result
 		go func(req *processRequest, res *processResult) {
-			*res = *detectContent(imageFormat, req)
+			*res = *detectContent(ctx, imageFormat, req)
 			wg.Done()
 		}(toDetect[i], &result)
 	}
 
 	wg.Wait()
-	errs := []error{}
-	for _, r := range results {
-		errs = append(errs, r.err)
+
+	// The analyses stopped by a cancellation fail with various errors, which
+	// are reported as the cancellation.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// A single error is returned as is so that its cause can be reported, the
+	// errors of the other layers are logged.
+	hashes := make([]string, 0, len(results))
+	for hash := range results {
+		hashes = append(hashes, hash)
 	}
+	sort.Strings(hashes)
 
-	if err := commonerr.CombineErrors(errs...); err != nil {
+	var err error
+	for _, hash := range hashes {
+		if r := results[hash]; r.err != nil {
+			if err == nil {
+				err = r.err
+				continue
+			}
+			logutil.FromContext(ctx).WithError(r.err).WithField("layer", hash).Error("failed to process layer")
+		}
+	}
+
+	if err != nil {
 		return nil, err
 	}
 
 	return results, nil
 }
 
-func getProcessRequest(datastore database.Datastore, req LayerRequest) (preq *processRequest, err error) {
+func getProcessRequest(ctx context.Context, datastore database.Datastore, req LayerRequest) (preq *processRequest, err error) {
+	if layer, ok := analyzedLayers.get(req.Hash, EnabledDetectors); ok {
+		logutil.FromContext(ctx).WithField("layer", req.Hash).Debug("found existing layer in cache")
+		return &processRequest{LayerRequest: req, existingLayer: &layer}, nil
+	}
+
 	layer, ok, err := database.FindLayerAndRollback(datastore, req.Hash)
 	if err != nil {
 		return
 	}
 
 	if !ok {
-		log.WithField("layer", req.Hash).Debug("found no existing layer in database")
+		logutil.FromContext(ctx).WithField("layer", req.Hash).Debug("found no existing layer in database")
 		preq = &processRequest{
 			LayerRequest:  req,
 			existingLayer: &database.Layer{Hash: req.Hash},
 			detectors:     EnabledDetectors,
 		}
 	} else {
-		log.WithFields(log.Fields{
+		logutil.FromContext(ctx).WithFields(log.Fields{
 			"layer":           layer.Hash,
 			"detectors":       layer.By,
 			"feature count":   len(layer.Features),
@@ -131,7 +273,9 @@ func getProcessRequest(datastore database.Datastore, req LayerRequest) (preq *pr
 	return
 }
 
-func persistProcessResult(datastore database.Datastore, results map[string]*processResult) error {
+// persistProcessResult stores the content of the processed layers, stopping
+// before the next transaction once ctx is done.
+func persistProcessResult(ctx context.Context, datastore database.Datastore, results map[string]*processResult) error {
 	features := []database.Feature{}
 	namespaces := []database.Namespace{}
 	for _, r := range results {
@@ -141,15 +285,27 @@ func persistProcessResult(datastore database.Datastore, results map[string]*proc
 
 	features = database.DeduplicateFeatures(features...)
 	namespaces = database.DeduplicateNamespaces(namespaces...)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if err := database.PersistNamespacesAndCommit(datastore, namespaces); err != nil {
 		return err
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if err := database.PersistFeaturesAndCommit(datastore, features); err != nil {
 		return err
 	}
 
 	for _, layer := range results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		if err := database.PersistPartialLayerAndCommit(datastore, layer.newLayerContent); err != nil {
 			return err
 		}
@@ -161,31 +317,32 @@ func persistProcessResult(datastore database.Datastore, results map[string]*proc
 // processLayers processes a set of post layer requests, stores layers and
 // returns an ordered list of processed layers with detected features and
 // namespaces.
-func processLayers(datastore database.Datastore, imageFormat string, requests []LayerRequest) ([]database.Layer, error) {
+func processLayers(ctx context.Context, datastore database.Datastore, imageFormat string, requests []LayerRequest) ([]database.Layer, error) {
 	var (
 		reqMap = make(map[string]*processRequest)
 		err    error
 	)
 
 	for _, r := range requests {
-		reqMap[r.Hash], err = getProcessRequest(datastore, r)
+		reqMap[r.Hash], err = getProcessRequest(ctx, datastore, r)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	results, err := processRequests(imageFormat, reqMap)
+	results, err := processRequests(ctx, imageFormat, reqMap)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := persistProcessResult(datastore, results); err != nil {
+	if err := persistProcessResult(ctx, datastore, results); err != nil {
 		return nil, err
 	}
 
 	completeLayers := getProcessResultLayers(results)
 	layers := make([]database.Layer, 0, len(requests))
 	for _, r := range requests {
+		analyzedLayers.add(completeLayers[r.Hash])
 		layers = append(layers, completeLayers[r.Hash])
 	}
 
@@ -212,7 +369,12 @@ func isAncestryProcessed(datastore database.Datastore, name string) (bool, error
 
 // ProcessAncestry downloads and scans an ancestry if it's not scanned by all
 // enabled processors in this instance of Clair.
-func ProcessAncestry(datastore database.Datastore, imageFormat, name string, layerRequest []LayerRequest) error {
+//
+// The analysis stops as soon as possible once ctx is done, in which case the
+// error of ctx is returned. Every database transaction is either committed or
+// rolled back, so that the layers stored so far can be reused by the next
+// analysis.
+func ProcessAncestry(ctx context.Context, datastore database.Datastore, imageFormat, name string, layerRequest []LayerRequest) error {
 	var (
 		err    error
 		ok     bool
@@ -227,23 +389,172 @@ func ProcessAncestry(datastore database.Datastore, imageFormat, name string, lay
 		return commonerr.NewBadRequestError("could not process a layer which does not have a format")
 	}
 
-	log.WithField("ancestry", name).Debug("start processing ancestry...")
+	if err := checkLayerCount(layerRequest); err != nil {
+		return err
+	}
+
+	logutil.FromContext(ctx).WithField("ancestry", name).Debug("start processing ancestry...")
 	if ok, err = isAncestryProcessed(datastore, name); err != nil {
-		log.WithError(err).Error("could not determine if ancestry is processed")
+		logutil.FromContext(ctx).WithError(err).Error("could not determine if ancestry is processed")
 		return err
 	} else if ok {
-		log.WithField("ancestry", name).Debug("ancestry is already processed")
+		logutil.FromContext(ctx).WithField("ancestry", name).Debug("ancestry is already processed")
 		return nil
 	}
 
-	if layers, err = processLayers(datastore, imageFormat, layerRequest); err != nil {
+	if layers, err = processLayers(ctx, datastore, imageFormat, layerRequest); err != nil {
+		return err
+	}
+
+	return processAncestry(ctx, datastore, name, layers)
+}
+
+// ProcessImage downloads and scans a squashed image, which is a single layer
+// without parent, and returns it as the only layer of an ancestry named after
+// its hash.
+//
+// The layer and its namespaced features are stored as with ProcessAncestry,
+// so that their vulnerabilities can be found, but the ancestry is not.
+func ProcessImage(ctx context.Context, datastore database.Datastore, imageFormat string, request LayerRequest) (database.Ancestry, error) {
+	if imageFormat == "" {
+		return database.Ancestry{}, commonerr.NewBadRequestError("could not process a layer which does not have a format")
+	}
+
+	logutil.FromContext(ctx).WithField("layer", request.Hash).Debug("start processing image...")
+	layers, err := processLayers(ctx, datastore, imageFormat, []LayerRequest{request})
+	if err != nil {
+		return database.Ancestry{}, err
+	}
+
+	return processAncestryFeatures(ctx, datastore, request.Hash, layers)
+}
+
+// ProcessLayers downloads and scans the given layers one after the other, in
+// the order of the requests, each layer being the parent of the next one.
+//
+// It stops at the first layer that fails to be processed and returns the
+// results of the layers processed so far, the last one holding the error. The
+// error of ctx is returned instead once it is done.
+func ProcessLayers(ctx context.Context, datastore database.Datastore, imageFormat string, requests []LayerRequest) ([]LayerResult, error) {
+	if imageFormat == "" {
+		return nil, commonerr.NewBadRequestError("could not process a layer which does not have a format")
+	}
+
+	if err := checkLayerCount(requests); err != nil {
+		return nil, err
+	}
+
+	results := make([]LayerResult, 0, len(requests))
+	for _, r := range requests {
+		layers, err := processLayers(ctx, datastore, imageFormat, []LayerRequest{r})
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		if err != nil {
+			logutil.FromContext(ctx).WithError(err).WithField("layer", r.Hash).Error("could not process layer, stopping")
+			results = append(results, LayerResult{Layer: database.Layer{Hash: r.Hash}, Err: err})
+			break
+		}
+
+		results = append(results, LayerResult{Layer: layers[0]})
+	}
+
+	return results, nil
+}
+
+// DeleteAncestry deletes the analysis of an ancestry, whose layers are kept
+// so that they can be deleted once no other ancestry contains them.
+func DeleteAncestry(datastore database.Datastore, name string) error {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.DeleteAncestry(name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteLayer deletes the analysis of a layer that no ancestry contains, so
+// that it is analyzed again if it is submitted again.
+func DeleteLayer(datastore database.Datastore, hash string) error {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.DeleteLayer(hash); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	analyzedLayers.remove(hash)
+	return nil
+}
+
+// DeleteAnalysesBefore deletes the analyses of the ancestries made before the
+// given time, then those of the layers made before it that no ancestry
+// contains anymore, and returns the names and hashes of the deleted ones.
+func DeleteAnalysesBefore(datastore database.Datastore, before time.Time) (ancestries []string, layers []string, err error) {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	if ancestries, layers, err = tx.DeleteAnalysesBefore(before); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	analyzedLayers.remove(layers...)
+	log.WithFields(log.Fields{"before": before, "ancestries": len(ancestries), "layers": len(layers)}).Info("deleted old analyses")
+	return ancestries, layers, nil
+}
+
+// checkLayerCount rejects the requests with more layers than
+// workerConfig.MaxLayers.
+func checkLayerCount(requests []LayerRequest) error {
+	if workerConfig.MaxLayers > 0 && len(requests) > workerConfig.MaxLayers {
+		return commonerr.NewBadRequestError(fmt.Sprintf("could not process %d layers, which is more than the maximum of %d", len(requests), workerConfig.MaxLayers))
+	}
+
+	return nil
+}
+
+func processAncestry(ctx context.Context, datastore database.Datastore, name string, layers []database.Layer) error {
+	ancestry, err := processAncestryFeatures(ctx, datastore, name, layers)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	return processAncestry(datastore, name, layers)
+	if err := database.UpsertAncestryAndCommit(datastore, ancestry); err != nil {
+		logutil.FromContext(ctx).WithField("ancestry", name).WithError(err).Error("could not upsert ancestry")
+		return err
+	}
+
+	return nil
 }
 
-func processAncestry(datastore database.Datastore, name string, layers []database.Layer) error {
+// processAncestryFeatures computes the ancestry of the given layers and stores
+// its namespaced features along with their vulnerabilities, without storing the
+// ancestry itself.
+func processAncestryFeatures(ctx context.Context, datastore database.Datastore, name string, layers []database.Layer) (database.Ancestry, error) {
 	var (
 		ancestry = database.Ancestry{Name: name}
 		err      error
@@ -251,33 +562,36 @@ func processAncestry(datastore database.Datastore, name string, layers []databas
 
 	ancestry.Layers, ancestry.By, err = computeAncestryLayers(layers)
 	if err != nil {
-		return err
+		return ancestry, err
 	}
 
 	ancestryFeatures := database.GetAncestryFeatures(ancestry)
-	log.WithFields(log.Fields{
+	logutil.FromContext(ctx).WithFields(log.Fields{
 		"ancestry":       name,
 		"processed by":   EnabledDetectors,
 		"features count": len(ancestryFeatures),
 		"layer count":    len(ancestry.Layers),
 	}).Debug("compute ancestry features")
 
+	if err := ctx.Err(); err != nil {
+		return ancestry, err
+	}
+
 	if err := database.PersistNamespacedFeaturesAndCommit(datastore, ancestryFeatures); err != nil {
-		log.WithField("ancestry", name).WithError(err).Error("could not persist namespaced features for ancestry")
-		return err
+		logutil.FromContext(ctx).WithField("ancestry", name).WithError(err).Error("could not persist namespaced features for ancestry")
+		return ancestry, err
 	}
 
-	if err := database.CacheRelatedVulnerabilityAndCommit(datastore, ancestryFeatures); err != nil {
-		log.WithField("ancestry", name).WithError(err).Error("failed to cache feature related vulnerability")
-		return err
+	if err := ctx.Err(); err != nil {
+		return ancestry, err
 	}
 
-	if err := database.UpsertAncestryAndCommit(datastore, ancestry); err != nil {
-		log.WithField("ancestry", name).WithError(err).Error("could not upsert ancestry")
-		return err
+	if err := database.CacheRelatedVulnerabilityAndCommit(datastore, ancestryFeatures); err != nil {
+		logutil.FromContext(ctx).WithField("ancestry", name).WithError(err).Error("failed to cache feature related vulnerability")
+		return ancestry, err
 	}
 
-	return nil
+	return ancestry, nil
 }
 
 func getCommonDetectors(layers []database.Layer) mapset.Set {
@@ -302,14 +616,19 @@ func getCommonDetectors(layers []database.Layer) mapset.Set {
 
 // computeAncestryLayers computes ancestry's layers along with what features are
 // introduced.
+//
+// When the detectors disagree on the namespace of the features of a version
+// format in a layer, the features are in the ancestry under each of the
+// candidate namespaces, so that they are matched against the vulnerabilities
+// of all of them.
 func computeAncestryLayers(layers []database.Layer) ([]database.AncestryLayer, []database.Detector, error) {
 	if len(layers) == 0 {
 		return nil, nil, nil
 	}
 
 	commonDetectors := getCommonDetectors(layers)
-	// version format -> namespace
-	namespaces := map[string]database.LayerNamespace{}
+	// version format -> candidate namespaces, from the most likely one
+	namespaces := map[string][]database.LayerNamespace{}
 	// version format -> feature ID -> feature
 	features := map[string]map[string]introducedFeature{}
 	ancestryLayers := []database.AncestryLayer{}
@@ -319,12 +638,18 @@ func computeAncestryLayers(layers []database.Layer) ([]database.AncestryLayer, [
 
 		// Precondition: namespaces and features contain the result from union
 		// of all parents.
+		// The namespaces detected in a layer replace the ones of its parents.
+		layerNamespaces := map[string][]database.LayerNamespace{}
 		for _, ns := range layer.Namespaces {
 			if !commonDetectors.Contains(ns.By) {
 				continue
 			}
 
-			namespaces[ns.VersionFormat] = ns
+			layerNamespaces[ns.VersionFormat] = append(layerNamespaces[ns.VersionFormat], ns)
+		}
+
+		for vf, candidates := range layerNamespaces {
+			namespaces[vf] = uniqueNamespaces(candidates)
 		}
 
 		// version format -> feature ID -> feature
@@ -334,7 +659,18 @@ func computeAncestryLayers(layers []database.Layer) ([]database.AncestryLayer, [
 				continue
 			}
 
-			if ns, ok := namespaces[f.VersionFormat]; ok {
+			candidates, ok := namespaces[f.VersionFormat]
+			if !ok {
+				// The default namespace is then inherited like a detected one,
+				// until a layer detects one.
+				var ns database.LayerNamespace
+				if ns, ok = defaultLayerNamespace(layer, f); ok {
+					candidates = []database.LayerNamespace{ns}
+					namespaces[f.VersionFormat] = candidates
+				}
+			}
+
+			if ok {
 				var currentMap map[string]introducedFeature
 				if currentMap, ok = currentFeatures[f.VersionFormat]; !ok {
 					currentFeatures[f.VersionFormat] = make(map[string]introducedFeature)
@@ -350,17 +686,18 @@ func computeAncestryLayers(layers []database.Layer) ([]database.AncestryLayer, [
 				}
 
 				if !inherited {
-					currentMap[f.Name+":"+f.Version] = introducedFeature{
-						feature: database.AncestryFeature{
+					introduced := introducedFeature{layerIndex: index}
+					for _, ns := range candidates {
+						introduced.features = append(introduced.features, database.AncestryFeature{
 							NamespacedFeature: database.NamespacedFeature{
 								Feature:   f.Feature,
 								Namespace: ns.Namespace,
 							},
 							NamespaceBy: ns.By,
 							FeatureBy:   f.By,
-						},
-						layerIndex: index,
+						})
 					}
+					currentMap[f.Name+":"+f.Version] = introduced
 				}
 
 			} else {
@@ -384,7 +721,7 @@ func computeAncestryLayers(layers []database.Layer) ([]database.AncestryLayer, [
 		for _, feature := range featureMap {
 			ancestryLayers[feature.layerIndex].Features = append(
 				ancestryLayers[feature.layerIndex].Features,
-				feature.feature,
+				feature.features...,
 			)
 		}
 	}
@@ -397,55 +734,144 @@ func computeAncestryLayers(layers []database.Layer) ([]database.AncestryLayer, [
 	return ancestryLayers, detectors, nil
 }
 
-func extractRequiredFiles(imageFormat string, req *processRequest) (tarutil.FilesMap, error) {
+// uniqueNamespaces returns the candidate namespaces of a version format of a
+// layer, from the most likely one, keeping the most likely detector of the
+// namespaces detected several times.
+func uniqueNamespaces(candidates []database.LayerNamespace) []database.LayerNamespace {
+	featurens.SortNamespaces(candidates)
+
+	unique := make([]database.LayerNamespace, 0, len(candidates))
+	seen := make(map[string]bool, len(candidates))
+	for _, ns := range candidates {
+		if !seen[ns.Name] {
+			unique = append(unique, ns)
+			seen[ns.Name] = true
+		}
+	}
+	return unique
+}
+
+// defaultLayerNamespace returns the default namespace of the worker as the
+// namespace of a feature of the layer that has none, if they have the same
+// version format. The namespace is attributed to the lister of the feature.
+func defaultLayerNamespace(layer database.Layer, f database.LayerFeature) (database.LayerNamespace, bool) {
+	ns := workerConfig.DefaultNamespace
+	if ns == nil || ns.VersionFormat != f.VersionFormat {
+		return database.LayerNamespace{}, false
+	}
+
+	log.WithFields(log.Fields{
+		"layer":          layer.Hash,
+		"version format": f.VersionFormat,
+		"namespace":      ns.Name,
+	}).Warning("no namespace detected for the features of the layer and of its parents, assuming the default namespace")
+
+	return database.LayerNamespace{Namespace: *ns, By: f.By}, true
+}
+
+func extractRequiredFiles(ctx context.Context, imageFormat string, req *processRequest) (tarutil.FilesMap, error) {
 	requiredFiles := append(featurefmt.RequiredFilenames(req.detectors), featurens.RequiredFilenames(req.detectors)...)
 	if len(requiredFiles) == 0 {
-		log.WithFields(log.Fields{
+		logutil.FromContext(ctx).WithFields(log.Fields{
 			"layer":     req.Hash,
 			"detectors": req.detectors,
 		}).Info("layer requires no file to extract")
 		return make(tarutil.FilesMap), nil
 	}
 
-	files, err := imagefmt.Extract(imageFormat, req.Path, req.Headers, requiredFiles)
+	if files, ok := extractedFiles.get(req.Hash, requiredFiles); ok {
+		logutil.FromContext(ctx).WithField("layer", req.Hash).Debug("using the cached files of the layer")
+		return files, nil
+	}
+
+	if req.Registry != nil && req.Path == "" {
+		files, err := imagefmt.ExtractFromRegistry(ctx, imageFormat, *req.Registry, req.Hash, requiredFiles)
+		if err != nil {
+			logutil.FromContext(ctx).WithError(err).WithFields(log.Fields{
+				"layer":    req.Hash,
+				"registry": req.Registry.String(),
+			}).Error("failed to extract data from registry")
+			return nil, err
+		}
+
+		extractedFiles.add(req.Hash, requiredFiles, files)
+		return files, nil
+	}
+
+	if objectstore.IsObjectURI(req.Path) {
+		var credentials objectstore.Credentials
+		if req.ObjectStorage != nil {
+			credentials = *req.ObjectStorage
+		}
+
+		files, err := imagefmt.ExtractFromObjectStorage(ctx, imageFormat, req.Path, credentials, requiredFiles)
+		if err != nil {
+			logutil.FromContext(ctx).WithError(err).WithFields(log.Fields{
+				"layer": req.Hash,
+				"path":  req.Path,
+			}).Error("failed to extract data from object storage")
+			return nil, err
+		}
+
+		extractedFiles.add(req.Hash, requiredFiles, files)
+		return files, nil
+	}
+
+	files, err := imagefmt.Extract(ctx, imageFormat, req.Path, req.Headers, requiredFiles)
 	if err != nil {
-		log.WithError(err).WithFields(log.Fields{
+		logutil.FromContext(ctx).WithError(err).WithFields(log.Fields{
 			"layer": req.Hash,
 			"path":  strutil.CleanURL(req.Path),
 		}).Error("failed to extract data from path")
 		return nil, err
 	}
 
-	return files, err
+	extractedFiles.add(req.Hash, requiredFiles, files)
+	return files, nil
 }
 
 // detectContent downloads a layer and detects all features and namespaces.
-func detectContent(imageFormat string, req *processRequest) (res *processResult) {
+func detectContent(ctx context.Context, imageFormat string, req *processRequest) (res *processResult) {
 	var (
 		files tarutil.FilesMap
 		layer = database.Layer{Hash: req.Hash, By: req.detectors}
 	)
 
 	res = &processResult{req.existingLayer, &layer, nil}
-	log.WithFields(log.Fields{
+	logutil.FromContext(ctx).WithFields(log.Fields{
 		"layer":     req.Hash,
 		"detectors": req.detectors,
 	}).Info("detecting layer content...")
 
-	files, res.err = extractRequiredFiles(imageFormat, req)
+	promLayerAnalysesInFlight.Inc()
+	defer promLayerAnalysesInFlight.Dec()
+	defer observeLayerAnalysis(&layer, time.Now())
+
+	files, res.err = extractRequiredFiles(ctx, imageFormat, req)
 	if res.err != nil {
+		promLayerAnalysisErrorsTotal.WithLabelValues("extract").Inc()
+		return
+	}
+
+	if res.err = ctx.Err(); res.err != nil {
 		return
 	}
 
 	if layer.Namespaces, res.err = featurens.Detect(files, req.detectors); res.err != nil {
+		promLayerAnalysisErrorsTotal.WithLabelValues("namespace").Inc()
 		return
 	}
 
-	if layer.Features, res.err = featurefmt.ListFeatures(files, req.detectors); res.err != nil {
+	if res.err = ctx.Err(); res.err != nil {
 		return
 	}
 
-	log.WithFields(log.Fields{
+	if layer.Features, res.err = featurefmt.ListFeatures(files, req.detectors, workerConfig.ListerConcurrency); res.err != nil {
+		promLayerAnalysisErrorsTotal.WithLabelValues("feature").Inc()
+		return
+	}
+
+	logutil.FromContext(ctx).WithFields(log.Fields{
 		"layer":           req.Hash,
 		"detectors":       req.detectors,
 		"namespace count": len(layer.Namespaces),
@@ -455,8 +881,64 @@ func detectContent(imageFormat string, req *processRequest) (res *processResult)
 	return
 }
 
+// observeLayerAnalysis records the time elapsed since start to analyze a
+// layer, labeled by the detectors that found its features and namespaces.
+func observeLayerAnalysis(layer *database.Layer, start time.Time) {
+	listers := make([]database.Detector, 0, len(layer.Features))
+	for _, f := range layer.Features {
+		listers = append(listers, f.By)
+	}
+
+	detectors := make([]database.Detector, 0, len(layer.Namespaces))
+	for _, ns := range layer.Namespaces {
+		detectors = append(detectors, ns.By)
+	}
+
+	promLayerAnalysisDurationSeconds.
+		WithLabelValues(detectorsLabel(listers), detectorsLabel(detectors)).
+		Observe(time.Since(start).Seconds())
+}
+
+// detectorsLabel returns the sorted, comma-separated names of the given
+// detectors, or "none".
+func detectorsLabel(detectors []database.Detector) string {
+	names := make(map[string]struct{})
+	for _, d := range detectors {
+		names[d.Name] = struct{}{}
+	}
+
+	if len(names) == 0 {
+		return "none"
+	}
+
+	label := make([]string, 0, len(names))
+	for name := range names {
+		label = append(label, name)
+	}
+	sort.Strings(label)
+
+	return strings.Join(label, ",")
+}
+
 // InitWorker initializes the worker.
-func InitWorker(datastore database.Datastore) {
+func InitWorker(config *WorkerConfig, datastore database.Datastore) {
+	if config != nil {
+		workerConfig = *config
+	}
+
+	if workerConfig.MaxExtractableFileSize > 0 {
+		tarutil.MaxExtractableFileSize = workerConfig.MaxExtractableFileSize
+	}
+	if workerConfig.MaxExtractedSize > 0 {
+		tarutil.MaxExtractedSize = workerConfig.MaxExtractedSize
+	}
+	analyzedLayers = newLayerCache(workerConfig.LayerCacheSize, workerConfig.LayerCacheTTL)
+
+	var err error
+	if extractedFiles, err = newFilesCache(workerConfig.FilesCacheDir, workerConfig.FilesCacheSize); err != nil {
+		log.WithError(err).WithField("dir", workerConfig.FilesCacheDir).Fatal("cannot open the cache of the extracted files to initialize worker")
+	}
+
 	if len(EnabledDetectors) == 0 {
 		log.Warn("no enabled detector, and therefore, no ancestry will be processed.")
 		return
@@ -472,6 +954,13 @@ func InitWorker(datastore database.Datastore) {
 		log.WithError(err).Fatal("cannot insert detectors to initialize worker")
 	}
 
+	if ns := workerConfig.DefaultNamespace; ns != nil {
+		log.WithField("namespace", ns.Name).Warning("the features without namespace are assumed to be in the default namespace")
+		if err := tx.PersistNamespaces([]database.Namespace{*ns}); err != nil {
+			log.WithError(err).Fatal("cannot insert default namespace to initialize worker")
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.WithError(err).Fatal("cannot commit detector changes to initialize worker")
 	}
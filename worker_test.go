@@ -15,6 +15,7 @@
 package clair
 
 import (
+	"context"
 	"errors"
 	"path/filepath"
 	"runtime"
@@ -28,6 +29,7 @@ import (
 	"github.com/coreos/clair/ext/featurefmt"
 	"github.com/coreos/clair/ext/featurens"
 	"github.com/coreos/clair/ext/versionfmt/dpkg"
+	"github.com/coreos/clair/pkg/commonerr"
 
 	// Register the required detectors.
 	_ "github.com/coreos/clair/ext/featurefmt/dpkg"
@@ -309,7 +311,7 @@ func TestProcessAncestryWithDistUpgrade(t *testing.T) {
 		{Hash: "jessie", Path: testDataPath + "jessie.tar.gz"},
 	}
 
-	assert.Nil(t, ProcessAncestry(datastore, "Docker", "Mock", layers))
+	assert.Nil(t, ProcessAncestry(context.Background(), datastore, "Docker", "Mock", layers))
 
 	// check the ancestry features
 	features := []database.AncestryFeature{}
@@ -340,11 +342,11 @@ func TestProcessLayers(t *testing.T) {
 		{Hash: "jessie", Path: testDataPath + "jessie.tar.gz"},
 	}
 
-	LayerWithContents, err := processLayers(datastore, "Docker", layers)
+	LayerWithContents, err := processLayers(context.Background(), datastore, "Docker", layers)
 	assert.Nil(t, err)
 	assert.Len(t, LayerWithContents, 3)
 	// ensure resubmit won't break the stuff
-	LayerWithContents, err = processLayers(datastore, "Docker", layers)
+	LayerWithContents, err = processLayers(context.Background(), datastore, "Docker", layers)
 	assert.Nil(t, err)
 	assert.Len(t, LayerWithContents, 3)
 	// Ensure each processed layer is correct
@@ -389,6 +391,31 @@ func TestProcessLayers(t *testing.T) {
 	}
 }
 
+func TestProcessImage(t *testing.T) {
+	_, f, _, _ := runtime.Caller(0)
+	testDataPath := filepath.Join(filepath.Dir(f)) + "/testdata/DistUpgrade/"
+
+	datastore := newMockDatastore()
+
+	ancestry, err := ProcessImage(context.Background(), datastore, "Docker", LayerRequest{Hash: "wheezy", Path: testDataPath + "wheezy.tar.gz"})
+	require.Nil(t, err)
+
+	// The squashed image is the only layer of its ancestry, which is not stored.
+	assert.Equal(t, "wheezy", ancestry.Name)
+	require.Len(t, ancestry.Layers, 1)
+	assert.Equal(t, "wheezy", ancestry.Layers[0].Hash)
+	assert.NotEmpty(t, ancestry.Layers[0].Features)
+	for _, f := range ancestry.Layers[0].Features {
+		assert.Equal(t, "debian:7", f.Namespace.Name)
+	}
+
+	assert.Contains(t, datastore.layers, "wheezy")
+	assert.Empty(t, datastore.ancestry)
+
+	_, err = ProcessImage(context.Background(), datastore, "", LayerRequest{Hash: "wheezy", Path: testDataPath + "wheezy.tar.gz"})
+	assert.IsType(t, &commonerr.ErrBadRequest{}, err)
+}
+
 func getFeatures(a database.Ancestry) []database.AncestryFeature {
 	features := []database.AncestryFeature{}
 	for _, l := range a.Layers {
@@ -398,6 +425,28 @@ func getFeatures(a database.Ancestry) []database.AncestryFeature {
 	return features
 }
 
+func TestProcessLayersCancelled(t *testing.T) {
+	_, f, _, _ := runtime.Caller(0)
+	testDataPath := filepath.Join(filepath.Dir(f)) + "/testdata/DistUpgrade/"
+
+	datastore := newMockDatastore()
+	layers := []LayerRequest{
+		{Hash: "wheezy", Path: testDataPath + "wheezy.tar.gz"},
+		{Hash: "jessie", Path: testDataPath + "jessie.tar.gz"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := ProcessLayers(ctx, datastore, "Docker", layers)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, results)
+
+	assert.Equal(t, context.Canceled, ProcessAncestry(ctx, datastore, "Docker", "Mock", layers))
+	assert.Empty(t, datastore.layers)
+	assert.Empty(t, datastore.ancestry)
+}
+
 func TestComputeAncestryFeatures(t *testing.T) {
 	vf1 := "format 1"
 	vf2 := "format 2"
@@ -581,3 +630,140 @@ func TestComputeAncestryFeatures(t *testing.T) {
 		database.AssertAncestryLayerEqual(t, &expected[i], &ancestryLayers[i])
 	}
 }
+
+func TestComputeAncestryDefaultNamespace(t *testing.T) {
+	defer func(config WorkerConfig) { workerConfig = config }(workerConfig)
+
+	nd := database.NewNamespaceDetector("os-release", "1.0")
+	fd := database.NewFeatureDetector("dpkg", "1.0")
+	ns := database.Namespace{Name: "debian:11", VersionFormat: dpkg.ParserName}
+	openssl := database.Feature{Name: "openssl", Version: "1.1.1n-0", VersionFormat: dpkg.ParserName}
+	curl := database.Feature{Name: "curl", Version: "7.74.0-1", VersionFormat: dpkg.ParserName}
+
+	layers := []database.Layer{
+		{
+			Hash:     "base",
+			By:       []database.Detector{nd, fd},
+			Features: []database.LayerFeature{{Feature: openssl, By: fd}},
+		},
+		{
+			Hash:     "top",
+			By:       []database.Detector{nd, fd},
+			Features: []database.LayerFeature{{Feature: openssl, By: fd}, {Feature: curl, By: fd}},
+		},
+	}
+
+	// The features without namespace cannot be part of the ancestry.
+	_, _, err := computeAncestryLayers(layers)
+	assert.NotNil(t, err)
+
+	// The default namespace is assumed for them, and inherited by the
+	// following layers.
+	workerConfig.DefaultNamespace = &ns
+	ancestryLayers, _, err := computeAncestryLayers(layers)
+	require.Nil(t, err)
+	database.AssertAncestryLayerEqual(t, &database.AncestryLayer{
+		Hash: "base",
+		Features: []database.AncestryFeature{
+			{NamespacedFeature: database.NamespacedFeature{Feature: openssl, Namespace: ns}, FeatureBy: fd, NamespaceBy: fd},
+		},
+	}, &ancestryLayers[0])
+	database.AssertAncestryLayerEqual(t, &database.AncestryLayer{
+		Hash: "top",
+		Features: []database.AncestryFeature{
+			{NamespacedFeature: database.NamespacedFeature{Feature: curl, Namespace: ns}, FeatureBy: fd, NamespaceBy: fd},
+		},
+	}, &ancestryLayers[1])
+
+	// A detected namespace takes precedence over the default one.
+	detected := database.Namespace{Name: "debian:12", VersionFormat: dpkg.ParserName}
+	layers[0].Namespaces = []database.LayerNamespace{{Namespace: detected, By: nd}}
+	ancestryLayers, _, err = computeAncestryLayers(layers)
+	require.Nil(t, err)
+	if assert.Len(t, ancestryLayers[1].Features, 1) {
+		assert.Equal(t, detected, ancestryLayers[1].Features[0].Namespace)
+		assert.Equal(t, nd, ancestryLayers[1].Features[0].NamespaceBy)
+	}
+
+	// The default namespace only applies to the features of its version
+	// format.
+	workerConfig.DefaultNamespace = &database.Namespace{Name: "centos:8", VersionFormat: "rpm"}
+	layers[0].Namespaces = nil
+	_, _, err = computeAncestryLayers(layers)
+	assert.NotNil(t, err)
+}
+
+func TestComputeAncestryCandidateNamespaces(t *testing.T) {
+	osRelease := database.NewNamespaceDetector("os-release", "1.0")
+	aptSources := database.NewNamespaceDetector("apt-sources", "1.0")
+	fd := database.NewFeatureDetector("dpkg", "1.0")
+	ubuntu := database.Namespace{Name: "ubuntu:20.04", VersionFormat: dpkg.ParserName}
+	debian := database.Namespace{Name: "debian:11", VersionFormat: dpkg.ParserName}
+	openssl := database.Feature{Name: "openssl", Version: "1.1.1f-1ubuntu2", VersionFormat: dpkg.ParserName}
+
+	layers := []database.Layer{
+		{
+			Hash: "base",
+			By:   []database.Detector{osRelease, aptSources, fd},
+			Namespaces: []database.LayerNamespace{
+				{Namespace: debian, By: aptSources},
+				{Namespace: ubuntu, By: osRelease},
+				{Namespace: ubuntu, By: aptSources},
+			},
+			Features: []database.LayerFeature{{Feature: openssl, By: fd}},
+		},
+	}
+
+	// The feature is part of the ancestry in every distinct candidate
+	// namespace, the most likely one first.
+	ancestryLayers, _, err := computeAncestryLayers(layers)
+	require.Nil(t, err)
+	if assert.Len(t, ancestryLayers, 1) && assert.Len(t, ancestryLayers[0].Features, 2) {
+		assert.Equal(t, database.AncestryFeature{
+			NamespacedFeature: database.NamespacedFeature{Feature: openssl, Namespace: ubuntu},
+			FeatureBy:         fd,
+			NamespaceBy:       osRelease,
+		}, ancestryLayers[0].Features[0])
+		assert.Equal(t, database.AncestryFeature{
+			NamespacedFeature: database.NamespacedFeature{Feature: openssl, Namespace: debian},
+			FeatureBy:         fd,
+			NamespaceBy:       aptSources,
+		}, ancestryLayers[0].Features[1])
+	}
+}
+
+func TestProcessAncestryMaxLayers(t *testing.T) {
+	defer func(config WorkerConfig) { workerConfig = config }(workerConfig)
+	workerConfig.MaxLayers = 1
+
+	datastore := newMockDatastore()
+	layers := []LayerRequest{
+		{Hash: "wheezy", Path: "wheezy.tar.gz"},
+		{Hash: "jessie", Path: "jessie.tar.gz"},
+	}
+
+	// The layers are rejected before any of them is analyzed.
+	assert.IsType(t, &commonerr.ErrBadRequest{}, ProcessAncestry(context.Background(), datastore, "Docker", "Mock", layers))
+	_, err := ProcessLayers(context.Background(), datastore, "Docker", layers)
+	assert.IsType(t, &commonerr.ErrBadRequest{}, err)
+	assert.Empty(t, datastore.layers)
+}
+
+func TestDeleteLayer(t *testing.T) {
+	defer func(c *layerCache) { analyzedLayers = c }(analyzedLayers)
+	analyzedLayers = newLayerCache(10, 0)
+
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "mem"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	layer := database.Layer{Hash: "layer"}
+	require.Nil(t, database.PersistPartialLayerAndCommit(datastore, &layer))
+	analyzedLayers.add(layer)
+
+	// The deleted layer is analyzed again when it is submitted again.
+	require.Nil(t, DeleteLayer(datastore, "layer"))
+	_, ok := analyzedLayers.get("layer", nil)
+	assert.False(t, ok)
+	assert.Equal(t, commonerr.ErrNotFound, DeleteLayer(datastore, "layer"))
+}